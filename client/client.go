@@ -0,0 +1,226 @@
+// Package client is a typed client of the HTTP API of ntnsync serve. The API is described by the OpenAPI
+// document served at /api/openapi.json.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// API paths.
+const (
+	HealthPath   = "/health"
+	ReadyPath    = "/readyz"
+	VersionPath  = "/api/version"
+	MetricsPath  = "/api/metrics"
+	OpenAPIPath  = "/api/openapi.json"
+	SimulatePath = "/api/simulate"
+	DebugPath    = "/api/debug/state"
+)
+
+// Readiness statuses.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// ErrUnexpectedStatus is returned when the server responds with an unexpected HTTP status.
+var ErrUnexpectedStatus = errors.New("unexpected HTTP status")
+
+// Status is the response of the health and readiness endpoints.
+type Status struct {
+	Status string `json:"status"`           // StatusOK or StatusDegraded
+	Reason string `json:"reason,omitempty"` // Why the server is degraded
+}
+
+// Version is the version of the server.
+type Version struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+}
+
+// Metrics are the event counters of the server, by name (e.g., "events_received"). Events of unknown types are
+// also counted by type, as "events_unknown:<type>".
+type Metrics map[string]int64
+
+// SimulateRequest is the payload of the simulate endpoint.
+type SimulateRequest struct {
+	Event  string `json:"event"`          // Event type (e.g., "page.updated")
+	PageID string `json:"page_id"`        // ID of the page or database the event is about
+	Sign   bool   `json:"sign,omitempty"` // Sign the event with the webhook secret and verify it
+}
+
+// SimulateResponse is the response of the simulate endpoint.
+type SimulateResponse struct {
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	EntityID   string `json:"entity_id"`
+	EntityType string `json:"entity_type"`
+	Signed     bool   `json:"signed"` // The event signature was computed and verified
+}
+
+// DebugDump is the response of the debug endpoint.
+type DebugDump struct {
+	State json.RawMessage `json:"state"` // State of the sync runs in progress
+	Files []string        `json:"files"` // Files of the dump written to the mirror
+}
+
+// Client calls the HTTP API of a running ntnsync server.
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	simulateToken string
+	debugToken    string
+}
+
+// Option configures the Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests (default: http.DefaultClient).
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithSimulateToken sets the bearer token of the simulate endpoint.
+func WithSimulateToken(token string) Option {
+	return func(c *Client) {
+		c.simulateToken = token
+	}
+}
+
+// WithDebugToken sets the bearer token of the debug endpoint.
+func WithDebugToken(token string) Option {
+	return func(c *Client) {
+		c.debugToken = token
+	}
+}
+
+// New creates a client of the server at baseURL (e.g., "http://localhost:8080").
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Health checks that the server is running.
+func (c *Client) Health(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, HealthPath, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Ready returns the readiness of the server. A degraded server is not an error: its status is StatusDegraded,
+// with the reason.
+func (c *Client) Ready(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, ReadyPath, nil, &status, http.StatusServiceUnavailable); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Version returns the version of the server.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if err := c.do(ctx, http.MethodGet, VersionPath, nil, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Metrics returns the event counters of the server.
+func (c *Client) Metrics(ctx context.Context) (Metrics, error) {
+	var metrics Metrics
+	if err := c.do(ctx, http.MethodGet, MetricsPath, nil, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// OpenAPI returns the OpenAPI document of the server.
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := c.do(ctx, http.MethodGet, OpenAPIPath, nil, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Simulate feeds a synthetic event through the webhook handler of the server. It requires the simulate token.
+func (c *Client) Simulate(ctx context.Context, simReq *SimulateRequest) (*SimulateResponse, error) {
+	var simResp SimulateResponse
+	if err := c.do(ctx, http.MethodPost, SimulatePath, simReq, &simResp); err != nil {
+		return nil, err
+	}
+	return &simResp, nil
+}
+
+// DebugState makes the server write a diagnostic dump of its sync runs in progress and goroutine stacks to its
+// mirror, and returns it. It requires the debug token.
+func (c *Client) DebugState(ctx context.Context) (*DebugDump, error) {
+	var dump DebugDump
+	if err := c.do(ctx, http.MethodGet, DebugPath, nil, &dump); err != nil {
+		return nil, err
+	}
+	return &dump, nil
+}
+
+// do sends a request with an optional JSON body, and decodes the JSON response into result. Responses with a
+// status other than 200 or one of okStatuses are errors wrapping ErrUnexpectedStatus.
+func (c *Client) do(ctx context.Context, method, path string, body, result any, okStatuses ...int) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	endpoint := c.baseURL + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.simulateToken != "" && path == SimulatePath {
+		req.Header.Set("Authorization", "Bearer "+c.simulateToken)
+	}
+	if c.debugToken != "" && path == DebugPath {
+		req.Header.Set("Authorization", "Bearer "+c.debugToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && !slices.Contains(okStatuses, resp.StatusCode) {
+		return fmt.Errorf("%s %s: %w: %s", method, endpoint, ErrUnexpectedStatus, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
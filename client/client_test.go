@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testToken = "test-simulate-token" //nolint:gosec // test constant
+
+// newTestServer serves canned responses of the API.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"status":"ok"}`)
+	})
+	mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"status":"degraded","reason":"notion token rejected"}`)
+	})
+	mux.HandleFunc(VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"version":"1.2.3","commit":"abc","build_time":"2026-01-01"}`)
+	})
+	mux.HandleFunc(MetricsPath, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"events_received":3,"events_unknown:foo.bar":1}`)
+	})
+	mux.HandleFunc(SimulatePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req SimulateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid payload", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(SimulateResponse{EventID: "e1", EventType: req.Event, EntityID: req.PageID})
+	})
+	mux.HandleFunc(DebugPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"state":{"pid":42,"runs":[]},"files":[".notion-sync/debug/t-state.json"]}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+	c := New(server.URL+"/", WithSimulateToken(testToken), WithDebugToken(testToken))
+
+	health, err := c.Health(t.Context())
+	if err != nil || health.Status != StatusOK {
+		t.Errorf("Health() = %+v, %v", health, err)
+	}
+
+	ready, err := c.Ready(t.Context())
+	if err != nil || ready.Status != StatusDegraded || ready.Reason != "notion token rejected" {
+		t.Errorf("Ready() = %+v, %v, want degraded", ready, err)
+	}
+
+	version, err := c.Version(t.Context())
+	if err != nil || version.Version != "1.2.3" || version.BuildTime != "2026-01-01" {
+		t.Errorf("Version() = %+v, %v", version, err)
+	}
+
+	metrics, err := c.Metrics(t.Context())
+	if err != nil || metrics["events_received"] != 3 || metrics["events_unknown:foo.bar"] != 1 {
+		t.Errorf("Metrics() = %v, %v", metrics, err)
+	}
+
+	result, err := c.Simulate(t.Context(), &SimulateRequest{Event: "page.updated", PageID: "p1"})
+	if err != nil || result.EventType != "page.updated" || result.EntityID != "p1" {
+		t.Errorf("Simulate() = %+v, %v", result, err)
+	}
+
+	dump, err := c.DebugState(t.Context())
+	if err != nil || len(dump.Files) != 1 || string(dump.State) != `{"pid":42,"runs":[]}` {
+		t.Errorf("DebugState() = %+v, %v", dump, err)
+	}
+}
+
+func TestClient_UnexpectedStatus(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+
+	_, err := New(server.URL, WithSimulateToken("wrong-token")).
+		Simulate(t.Context(), &SimulateRequest{Event: "page.updated", PageID: "p1"})
+	if !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("Simulate() error = %v, want ErrUnexpectedStatus", err)
+	}
+
+	if _, err := New(server.URL).OpenAPI(t.Context()); !errors.Is(err, ErrUnexpectedStatus) {
+		t.Errorf("OpenAPI() error = %v, want ErrUnexpectedStatus", err)
+	}
+}
@@ -30,6 +30,18 @@ var (
 	// ErrPageIDRequired is returned when a page ID or URL is required but not provided.
 	ErrPageIDRequired = errors.New("page ID or URL required")
 
+	// ErrBundleFileRequired is returned when the registry import command has no bundle file.
+	ErrBundleFileRequired = errors.New("registry bundle file required")
+
+	// ErrSearchQueryRequired is returned when the search command has no query.
+	ErrSearchQueryRequired = errors.New("search query required")
+
+	// ErrQueueFileRequired is returned when the queue show command has no queue file.
+	ErrQueueFileRequired = errors.New("queue file required")
+
+	// ErrInvalidWatchInterval is returned when the interval of the watch command is not positive.
+	ErrInvalidWatchInterval = errors.New("watch interval must be positive")
+
 	// ErrNotLocalStore is returned when an operation requires a LocalStore but a different store type was provided.
 	ErrNotLocalStore = errors.New("store is not a LocalStore")
 
@@ -42,6 +54,12 @@ var (
 	// ErrNotionTokenRequired is returned when a Notion token is required but not provided.
 	ErrNotionTokenRequired = errors.New("notion token required (--token or NOTION_TOKEN env var)")
 
+	// ErrNotionUnauthorized is returned when the Notion API rejects the token (HTTP 401), e.g. when it was revoked.
+	ErrNotionUnauthorized = errors.New("notion token rejected")
+
+	// ErrGRPCTokenRequired is returned when the gRPC server is enabled without a bearer token.
+	ErrGRPCTokenRequired = errors.New("grpc token required (--grpc-token or NTN_GRPC_TOKEN env var)")
+
 	// ErrHTTPSPasswordRequired is returned when HTTPS git URL is used without NTN_GIT_PASS.
 	ErrHTTPSPasswordRequired = errors.New("NTN_GIT_PASS required for HTTPS URLs")
 
@@ -89,4 +107,120 @@ var (
 
 	// ErrNoDataSources is returned when a database has no data sources.
 	ErrNoDataSources = errors.New("database has no data sources")
+
+	// ErrPageArchived is returned when a page is archived or in the trash and should not be synced.
+	ErrPageArchived = errors.New("page is archived or in trash")
+
+	// ErrParentBlocked is returned when a page's parent is blocked, making the whole subtree unreachable.
+	ErrParentBlocked = errors.New("parent page is blocked")
+
+	// ErrInvalidQuietHours is returned when quiet hours cannot be parsed.
+	ErrInvalidQuietHours = errors.New("invalid quiet hours")
+
+	// ErrSimulationFailed is returned when the webhook server rejects a simulated event.
+	ErrSimulationFailed = errors.New("webhook simulation failed")
+
+	// ErrInvalidGitSubdir is returned when the git subdirectory of the mirror is not inside the repository.
+	ErrInvalidGitSubdir = errors.New("git subdirectory must be a relative path inside the repository")
+
+	// ErrInvalidQueueLimits is returned when the queue batch size or webhook threshold is out of range.
+	ErrInvalidQueueLimits = errors.New("invalid queue limits")
+
+	// ErrVerifyMismatch is returned when verified pages do not match their files.
+	ErrVerifyMismatch = errors.New("pages do not match their files")
+
+	// ErrInvalidCIDR is returned when an allowed webhook source is not a valid CIDR or IP address.
+	ErrInvalidCIDR = errors.New("invalid CIDR")
+
+	// ErrInvalidPlugin is returned when a converter plugin does not export the expected symbols.
+	ErrInvalidPlugin = errors.New("invalid converter plugin")
+
+	// ErrPluginsUnsupported is returned when converter plugins are configured on a build that cannot load them.
+	ErrPluginsUnsupported = errors.New("converter plugins require a build with cgo (CGO_ENABLED=1) on Linux, " +
+		"macOS or FreeBSD")
+
+	// ErrInvalidPostProcessor is returned when a markdown post-processor is unknown or has an invalid argument.
+	ErrInvalidPostProcessor = errors.New("invalid post-processor")
+
+	// ErrPageNotSynced is returned when a page is expected to be in the mirror but has no registry.
+	ErrPageNotSynced = errors.New("page is not synced")
+
+	// ErrPurgeRootPage is returned when purging a root page, which must be removed from root.md first.
+	ErrPurgeRootPage = errors.New("cannot purge a root page, remove it from root.md first")
+
+	// ErrHistoryRewriteUnsupported is returned when the store cannot rewrite its git history.
+	ErrHistoryRewriteUnsupported = errors.New("store does not support history rewrites")
+
+	// ErrPurgeNotConfirmed is returned when a history rewrite is requested without confirmation.
+	ErrPurgeNotConfirmed = errors.New("history rewrite not confirmed (use --yes)")
+
+	// ErrPurgeIncomplete is returned when the former history is still in the repository after a history rewrite.
+	ErrPurgeIncomplete = errors.New("the former history is still in the local repository")
+
+	// ErrStoreLocked is returned when another process holds the lock of the store directory.
+	ErrStoreLocked = errors.New("store is locked by another process (use --wait to wait for it)")
+
+	// ErrRemoteLocked is returned when another instance holds the lock of the branch on the remote (NTN_GIT_LOCK).
+	ErrRemoteLocked = errors.New("remote branch is locked by another instance (use --wait to wait for it)")
+
+	// ErrNotRuntimeFile is returned when writing a file outside transactions that is not a runtime file.
+	ErrNotRuntimeFile = errors.New("not a runtime file")
+
+	// ErrRuntimeFilesUnsupported is returned when writing runtime files to a store that cannot hold them.
+	ErrRuntimeFilesUnsupported = errors.New("store does not support runtime files")
+
+	// ErrNoActiveRun is returned when dumping the state of the sync runs while none is in progress.
+	ErrNoActiveRun = errors.New("no sync run in progress")
+
+	// ErrInvalidTimeZone is returned when a time zone is not a known IANA time zone name.
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+
+	// ErrInvalidDateFormat is returned when a date format is neither a named format nor a Go layout
+	// keeping timestamps to the second.
+	ErrInvalidDateFormat = errors.New("invalid date format")
+
+	// ErrUnknownPreset is returned when a sync preset is not one of the known presets.
+	ErrUnknownPreset = errors.New("unknown preset")
+
+	// ErrUnknownOutputFormat is returned when an output format is not one of the output profiles.
+	ErrUnknownOutputFormat = errors.New("unknown output format")
+
+	// ErrUnknownOutputMode is returned when the CLI output mode is neither text nor JSON.
+	ErrUnknownOutputMode = errors.New("unknown output mode")
+
+	// ErrJSONOutputUnsupported is returned when --output json is used with a command that only prints text.
+	ErrJSONOutputUnsupported = errors.New("--output json is not supported by this command")
+
+	// ErrUnknownConfigFormat is returned when the config file is neither YAML nor TOML.
+	ErrUnknownConfigFormat = errors.New("unknown config file format")
+
+	// ErrReadOnly is returned when a push or a Notion write is attempted in read-only mode.
+	ErrReadOnly = errors.New("refused in read-only mode (NTN_READ_ONLY)")
+
+	// ErrReadOnlyConflict is returned when a setting that writes is combined with the read-only mode.
+	ErrReadOnlyConflict = errors.New("setting conflicts with read-only mode (NTN_READ_ONLY)")
+
+	// ErrS3NotConfigured is returned when the S3 storage mode is selected without NTN_S3_BUCKET.
+	ErrS3NotConfigured = errors.New("S3 storage not configured (set NTN_S3_BUCKET)")
+
+	// ErrS3Request is returned when an S3 request fails.
+	ErrS3Request = errors.New("S3 request failed")
+
+	// ErrUnhealthyRun is returned when the health score of a sync run is below NTN_HEALTH_THRESHOLD.
+	ErrUnhealthyRun = errors.New("sync run is unhealthy (NTN_HEALTH_THRESHOLD)")
+
+	// ErrInvalidRegistryBundle is returned when a registry bundle fails validation on import.
+	ErrInvalidRegistryBundle = errors.New("invalid registry bundle")
+
+	// ErrUnknownBlockType is wrapped by the conversion warnings of blocks whose type the converter doesn't render.
+	ErrUnknownBlockType = errors.New("unknown block type")
+
+	// ErrUnsupportedRollup is wrapped by the conversion warnings of rollups that are neither a number nor a date.
+	ErrUnsupportedRollup = errors.New("unsupported rollup")
+
+	// ErrTruncatedRollup is wrapped by the conversion warnings of rollups Notion could not compute entirely.
+	ErrTruncatedRollup = errors.New("truncated rollup")
+
+	// ErrUnresolvedMention is wrapped by the conversion warnings of mentions the integration can't resolve.
+	ErrUnresolvedMention = errors.New("unresolved mention")
 )
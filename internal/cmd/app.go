@@ -3,19 +3,25 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/providers/env/v2"
 	"github.com/knadh/koanf/v2"
 	"github.com/urfave/cli/v3"
 
 	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/converter/plugin"
 	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/shutdown"
 	"github.com/fclairamb/ntnsync/internal/store"
 	"github.com/fclairamb/ntnsync/internal/sync"
 	"github.com/fclairamb/ntnsync/internal/version"
@@ -26,15 +32,37 @@ const (
 	// Default ports.
 	defaultWebhookPort = 8080
 
+	// defaultLinkRecheckAfter is how long checked links are not checked again (one week).
+	defaultLinkRecheckAfter = 7 * 24 * time.Hour
+
 	// flagFolder is the shared flag name for folder filtering.
 	flagFolder = "folder"
 	// flagDryRun is the shared flag name for dry-run mode.
 	flagDryRun = "dry-run"
+	// flagEphemeral is the global flag name for the in-memory store.
+	flagEphemeral = "ephemeral"
+	// flagFormat is the global flag name for the output profile.
+	flagFormat = "format"
+	// flagOutput is the global flag name for the output mode of the command results.
+	flagOutput = "output"
+	// flagWait is the global flag name for waiting for the store lock of another process.
+	flagWait = "wait"
+)
+
+// Output modes of the command results.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	// metadataJSONOutput marks the commands printing their results as JSON with --output json.
+	metadataJSONOutput = "json_output"
 )
 
 var (
 	// konfig is the global koanf instance.
 	konfig = koanf.New(".")
+
+	// lockedStores are the stores created by the command, whose lock is released when it ends.
+	lockedStores []store.LockReleaser
 )
 
 // verboseFlag is the shared verbose flag for all commands.
@@ -43,6 +71,20 @@ var verboseFlag = &cli.BoolFlag{
 	Usage: "Enable verbose logging",
 }
 
+// quietHoursFlag is the shared flag for the windows during which no sync happens.
+var quietHoursFlag = &cli.StringFlag{
+	Name:    "quiet-hours",
+	Usage:   "Windows without sync, e.g. \"mon-fri 09:00-18:00,sat 10:00-12:00\"",
+	Sources: cli.EnvVars("NTN_QUIET_HOURS"),
+}
+
+// quietHoursTZFlag is the shared flag for the timezone of quiet hours.
+var quietHoursTZFlag = &cli.StringFlag{
+	Name:    "quiet-hours-tz",
+	Usage:   "Timezone of quiet hours, e.g. Europe/Paris (default: local timezone)",
+	Sources: cli.EnvVars("NTN_QUIET_HOURS_TZ"),
+}
+
 // LogFormat represents the log output format.
 type LogFormat string
 
@@ -69,6 +111,12 @@ func getLogFormat() LogFormat {
 
 // setupLogging configures the global logger based on the verbose flag and NTN_LOG_FORMAT.
 func setupLogging(cmd *cli.Command) {
+	// Keep the terminal clean while the shell asks for completions
+	if isShellCompletion() {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return
+	}
+
 	level := slog.LevelInfo
 	if cmd.Bool("verbose") {
 		level = slog.LevelDebug
@@ -101,19 +149,43 @@ func setupLogging(cmd *cli.Command) {
 	cfg := store.LoadRemoteConfigFromEnv()
 	mode := cfg.EffectiveStorageMode()
 	storePath := resolveStorePath(cmd)
-	if mode == store.StorageModeRemote {
+	if cmd.Bool(flagEphemeral) {
+		slog.Info("storage mode", "mode", "ephemeral")
+	} else if mode == store.StorageModeRemote {
 		slog.Info("storage mode", "mode", "remote", "url", cfg.URL, "dir", storePath)
+	} else if mode == store.StorageModeS3 && cfg.S3 != nil {
+		slog.Info("storage mode", "mode", "s3", "bucket", cfg.S3.Bucket, "prefix", cfg.S3.Prefix)
 	} else {
 		slog.Info("storage mode", "mode", "local", "dir", storePath)
 	}
 }
 
+// Exit codes of the CLI.
+const (
+	// ExitCodeError is the exit code of commands that failed.
+	ExitCodeError = 1
+	// ExitCodeUnauthorized is the exit code of commands stopped because Notion rejected the token. Queued pages
+	// are kept, so that a sync can resume once the token is replaced.
+	ExitCodeUnauthorized = 3
+)
+
+// ExitCode returns the exit code of a command that failed with err.
+func ExitCode(err error) int {
+	if errors.Is(err, apperrors.ErrNotionUnauthorized) {
+		return ExitCodeUnauthorized
+	}
+	return ExitCodeError
+}
+
 // NewApp creates the CLI application.
 func NewApp() *cli.Command {
-	return &cli.Command{
+	app := &cli.Command{
 		Name:    "notion-sync",
 		Usage:   "Synchronize Notion content to a git repository using folder-based organization",
 		Version: version.Version,
+		// Shell completion: "completion bash|zsh|fish|pwsh" and dynamic folder/page ID suggestions
+		EnableShellCompletion:           true,
+		ConfigureShellCompletionCommand: configureCompletionCommand,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "token",
@@ -126,9 +198,40 @@ func NewApp() *cli.Command {
 				Aliases: []string{"s"},
 				Value:   "notion",
 			},
+			&cli.BoolFlag{
+				Name:    flagEphemeral,
+				Usage:   "Keep everything in memory: nothing is written to disk, committed or pushed",
+				Sources: cli.EnvVars("NTN_EPHEMERAL"),
+			},
+			&cli.StringFlag{
+				Name:    flagConfig,
+				Usage:   "Config file (default: ntnsync.yaml, ntnsync.yml or ntnsync.toml in the working directory)",
+				Sources: cli.EnvVars("NTN_CONFIG"),
+			},
+			&cli.StringFlag{
+				Name:  flagFormat,
+				Usage: "Output profile of all folders: " + strings.Join(converter.Profiles, ", ") + " (overrides NTN_PROFILE)",
+			},
+			&cli.StringFlag{
+				Name:    flagOutput,
+				Aliases: []string{"o"},
+				Usage:   "Output mode of the command results: text or json (list, status, pull, cleanup, search)",
+				Value:   outputText,
+				Sources: cli.EnvVars("NTN_OUTPUT"),
+			},
+			&cli.BoolFlag{
+				Name:    flagWait,
+				Usage:   "Wait for another process writing to the store directory to finish, instead of failing",
+				Sources: cli.EnvVars("NTN_LOCK_WAIT"),
+			},
 			verboseFlag,
 		},
-		Before: func(ctx context.Context, _ *cli.Command) (context.Context, error) {
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			// Settings of the config file apply as environment variables that are not set
+			if err := loadConfigFile(cmd); err != nil {
+				return ctx, err
+			}
+
 			// Load environment variables with NTN_ prefix
 			if err := konfig.Load(env.Provider(".", env.Opt{
 				Prefix: "NTN_",
@@ -136,8 +239,28 @@ func NewApp() *cli.Command {
 				return ctx, fmt.Errorf("load env: %w", err)
 			}
 
+			if err := applyOutputFormat(cmd); err != nil {
+				return ctx, err
+			}
+
+			if err := checkOutputMode(cmd); err != nil {
+				return ctx, err
+			}
+
+			if err := checkReadOnly(); err != nil {
+				return ctx, err
+			}
+
+			if err := checkConverterPlugins(); err != nil {
+				return ctx, err
+			}
+
 			return ctx, nil
 		},
+		After: func(ctx context.Context, _ *cli.Command) error {
+			releaseStoreLocks(ctx)
+			return nil
+		},
 		Commands: []*cli.Command{
 			getCommand(),
 			scanCommand(),
@@ -145,20 +268,36 @@ func NewApp() *cli.Command {
 			syncCommand(),
 			listCommand(),
 			statusCommand(),
+			browseCommand(),
+			openCommand(),
+			searchCommand(),
 			cleanupCommand(),
+			checkLinksCommand(),
+			verifyCommand(),
+			diffCommand(),
 			reindexCommand(),
+			relinkCommand(),
+			adoptCommand(),
+			purgeCommand(),
+			queueCommand(),
+			registryCommand(),
 			remoteCommand(),
 			serveCommand(),
+			watchCommand(),
+			webhookCommand(),
 		},
 	}
+	rejectJSONOutput(app.Commands)
+	return app
 }
 
 // getCommand creates the get subcommand.
 func getCommand() *cli.Command {
 	return &cli.Command{
-		Name:      "get",
-		Usage:     "Fetch a single page and place it in the hierarchy based on its parents",
-		ArgsUsage: "<page_id_or_url>",
+		Name:          "get",
+		Usage:         "Fetch a single page and place it in the hierarchy based on its parents",
+		ArgsUsage:     "<page_id_or_url>",
+		ShellComplete: completeWithPageIDs,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    flagFolder,
@@ -208,12 +347,29 @@ func getCommand() *cli.Command {
 }
 
 // scanCommand creates the scan subcommand.
+//
+//nolint:funlen // CLI command with many flags
 func scanCommand() *cli.Command {
 	return &cli.Command{
-		Name:      "scan",
-		Usage:     "Re-scan a page to discover and queue all child pages",
-		ArgsUsage: "<page_id_or_url>",
+		Name:          "scan",
+		Usage:         "Re-scan a page to discover and queue new and changed child pages",
+		ArgsUsage:     "<page_id_or_url>",
+		ShellComplete: completeWithPageIDs,
 		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "full",
+				Usage: "Descend into all tracked subtrees, not only the ones whose parent changed",
+			},
+			&cli.BoolFlag{
+				Name:  "all-roots",
+				Usage: "Scan all enabled root pages of root.md instead of a single page",
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"j"},
+				Usage:   "Number of folders scanned in parallel with --all-roots",
+				Value:   1,
+			},
 			verboseFlag,
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
@@ -221,6 +377,15 @@ func scanCommand() *cli.Command {
 			return ctx, nil
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			opts := sync.ScanOptions{
+				Full:        cmd.Bool("full"),
+				Concurrency: cmd.Int("concurrency"),
+			}
+
+			if cmd.Bool("all-roots") {
+				return scanAllRoots(ctx, cmd, opts)
+			}
+
 			// Get page ID or URL from args
 			if cmd.Args().Len() < 1 {
 				return apperrors.ErrPageIDRequired
@@ -244,21 +409,49 @@ func scanCommand() *cli.Command {
 			crawler := sync.NewCrawler(client, store, sync.WithCrawlerLogger(slog.Default()))
 
 			// Scan the page
-			if err := crawler.ScanPage(ctx, pageID); err != nil {
+			result, err := crawler.ScanPage(ctx, pageID, opts)
+			if err != nil {
 				return fmt.Errorf("scan page: %w", err)
 			}
 
-			displayScanComplete()
+			displayScanComplete(result)
 			return nil
 		},
 	}
 }
 
+// scanAllRoots scans all enabled root pages of root.md.
+func scanAllRoots(ctx context.Context, cmd *cli.Command, opts sync.ScanOptions) error {
+	// Setup client and store
+	client, store, err := setupClientAndStore(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Create crawler
+	crawler := sync.NewCrawler(client, store, sync.WithCrawlerLogger(slog.Default()))
+
+	// Reconcile root.md
+	if reconcileErr := crawler.ReconcileRootMd(ctx); reconcileErr != nil {
+		return fmt.Errorf("reconcile root.md: %w", reconcileErr)
+	}
+
+	result, err := crawler.ScanRoots(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("scan roots: %w", err)
+	}
+
+	displayScanComplete(result)
+	return nil
+}
+
 // pullCommand creates the pull subcommand.
 func pullCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "pull",
-		Usage: "Fetch all pages changed since last pull and queue them for sync",
+		Name:          "pull",
+		Metadata:      map[string]any{metadataJSONOutput: true},
+		Usage:         "Fetch all pages changed since last pull and queue them for sync",
+		ShellComplete: completeWithFolders,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    flagFolder,
@@ -283,6 +476,12 @@ func pullCommand() *cli.Command {
 				Name:  flagDryRun,
 				Usage: "Preview changes without modifying anything",
 			},
+			&cli.BoolFlag{
+				Name:  "ignore-quiet-hours",
+				Usage: "Pull even during quiet hours",
+			},
+			quietHoursFlag,
+			quietHoursTZFlag,
 			verboseFlag,
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
@@ -290,6 +489,10 @@ func pullCommand() *cli.Command {
 			return ctx, nil
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if skip, err := skipForQuietHours(ctx, cmd); err != nil || skip {
+				return err
+			}
+
 			folder := cmd.String(flagFolder)
 			since := cmd.Duration("since")
 			maxPages := cmd.Int("max-pages")
@@ -325,6 +528,9 @@ func pullCommand() *cli.Command {
 			}
 
 			// Display results
+			if isJSONOutput(cmd) {
+				return printJSON(result)
+			}
 			displayPullResults(result, all, dryRun)
 
 			return nil
@@ -337,8 +543,10 @@ func pullCommand() *cli.Command {
 //nolint:funlen // CLI command with many flags
 func syncCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "sync",
-		Usage: "Process the queue and sync all pages recursively",
+		Name:          "sync",
+		Metadata:      map[string]any{metadataJSONOutput: true},
+		Usage:         "Process the queue and sync all pages recursively",
+		ShellComplete: completeWithFolders,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    flagFolder,
@@ -369,18 +577,52 @@ func syncCommand() *cli.Command {
 				Usage:   "Maximum number of queue files to process (0 = unlimited)",
 				Value:   0,
 			},
+			&cli.DurationFlag{
+				Name: "crawl-budget",
+				Usage: "Run as a step of an incremental crawl: stop before the pages not expected to fit in this time," +
+					" final commit included (e.g., 10m)",
+			},
+			&cli.IntFlag{
+				Name:  "crawl-api-budget",
+				Usage: "Run as a step of an incremental crawl: stop before exceeding this number of Notion API calls",
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"j"},
+				Usage:   "Number of pages of a queue file fetched in parallel (default: NTN_SYNC_CONCURRENCY, or 1)",
+			},
+			&cli.BoolFlag{
+				Name:  flagDryRun,
+				Usage: "Report the files the queue would create, update or delete, fetching page metadata only",
+			},
+			presetFlag,
 			verboseFlag,
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if err := applyPreset(cmd); err != nil {
+				return ctx, err
+			}
 			setupLogging(cmd)
 			return ctx, nil
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if !cmd.Bool(flagDryRun) && isJSONOutput(cmd) {
+				return fmt.Errorf("%w: %s without --%s", apperrors.ErrJSONOutputUnsupported, cmd.FullName(), flagDryRun)
+			}
+
+			// On shutdown, the pages in progress are finished and committed within the grace period
+			defer shutdown.Begin(ctx)()
+			startedAt := time.Now()
+
 			folder := cmd.String(flagFolder)
 			maxPages := cmd.Int("max-pages")
 			maxFiles := cmd.Int("max-files")
 			maxTime := cmd.Duration("max-time")
 			maxQueueFiles := cmd.Int("max-queue-files")
+			preset, err := selectedPreset(cmd)
+			if err != nil {
+				return err
+			}
 
 			// Setup client and store
 			client, storeInst, err := setupClientAndStore(cmd)
@@ -388,16 +630,46 @@ func syncCommand() *cli.Command {
 				return err
 			}
 
+			// Dry run: the store is neither pulled nor written, and the queue is kept
+			if cmd.Bool(flagDryRun) {
+				crawler := sync.NewCrawler(client, storeInst, sync.WithCrawlerLogger(slog.Default()))
+				plan, planErr := crawler.PlanQueue(ctx, sync.SyncPlanOptions{
+					Folder:        folder,
+					MaxPages:      maxPages,
+					MaxQueueFiles: maxQueueFiles,
+				})
+				if planErr != nil {
+					return fmt.Errorf("plan queue: %w", planErr)
+				}
+				if isJSONOutput(cmd) {
+					return printJSON(plan)
+				}
+				displaySyncPlan(plan)
+				return nil
+			}
+
 			// Get remote config for commit/push settings
 			remoteConfig := storeRemoteConfig(storeInst)
 
-			// Pull from remote before processing (if remote is configured)
+			// Lock the branch on the remote until the end of the command, then pull from it (if remote is configured)
+			if err = acquireRemoteLock(ctx, storeInst); err != nil {
+				return err
+			}
 			if err = storePull(ctx, storeInst); err != nil {
 				return fmt.Errorf("pull from remote: %w", err)
 			}
 
-			// Create crawler
-			crawler := sync.NewCrawler(client, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			// Create crawler, reporting the run until the final commit
+			crawlerOpts := []sync.CrawlerOption{sync.WithCrawlerLogger(slog.Default())}
+			if cmd.IsSet("concurrency") {
+				crawlerOpts = append(crawlerOpts, sync.WithConcurrency(cmd.Int("concurrency")))
+			}
+			if budget, ok := crawlBudget(cmd, startedAt); ok {
+				crawlerOpts = append(crawlerOpts, sync.WithCrawlBudget(budget))
+			}
+			crawler := sync.NewCrawler(client, storeInst, crawlerOpts...)
+			crawler.StartRun(ctx, folder)
+			defer crawler.FinishRun(ctx)
 
 			// Reconcile root.md
 			if reconcileErr := crawler.ReconcileRootMd(ctx); reconcileErr != nil {
@@ -405,26 +677,19 @@ func syncCommand() *cli.Command {
 			}
 
 			// Process queue with limits and periodic commit support
-			commitPeriod := remoteConfig.GetCommitPeriod()
-			if commitPeriod > 0 {
-				// Use periodic commit callback
-				tracker := newCommitTracker(commitPeriod)
-				err = crawler.ProcessQueueWithCallback(ctx, folder, maxPages, maxFiles, maxQueueFiles, maxTime,
-					func() error {
-						if tracker.shouldCommit() {
-							if commitErr := commitAndPush(ctx, crawler, storeInst, remoteConfig, "periodic sync"); commitErr != nil {
-								return commitErr
-							}
-							tracker.markCommitted()
-						}
-						return nil
-					})
-			} else {
-				err = crawler.ProcessQueue(ctx, folder, maxPages, maxFiles, maxQueueFiles, maxTime)
-			}
+			err = processQueueWithCommits(ctx, crawler, storeInst, remoteConfig, folder,
+				maxPages, maxFiles, maxQueueFiles, maxTime)
 			if err != nil {
 				return fmt.Errorf("process queue: %w", err)
 			}
+			if exceeded := crawler.QuotaExceededFolders(); len(exceeded) > 0 {
+				slog.WarnContext(ctx, "some folders exceeded their quota, new pages were not queued",
+					"folders", exceeded)
+			}
+			if crawler.MirrorSizeExceeded() {
+				slog.WarnContext(ctx, "the mirror reached its size cap, new pages were not queued"+
+					" (see ntnsync status)")
+			}
 
 			// Final commit if enabled (via NTN_COMMIT or NTN_COMMIT_PERIOD)
 			if remoteConfig.IsCommitEnabled() {
@@ -433,6 +698,22 @@ func syncCommand() *cli.Command {
 				}
 			}
 
+			if shutdown.Requested(ctx) {
+				slog.InfoContext(ctx, "sync stopped by shutdown, the remaining pages stay queued")
+				return nil
+			}
+
+			if preset.verify {
+				if verifyErr := verifySynced(ctx, crawler, folder); verifyErr != nil {
+					return verifyErr
+				}
+			}
+
+			// The changes are committed first: an unhealthy run still keeps what it synced
+			if healthErr := crawler.CheckHealth(); healthErr != nil {
+				return healthErr
+			}
+
 			slog.InfoContext(ctx, "sync complete")
 			return nil
 		},
@@ -442,8 +723,10 @@ func syncCommand() *cli.Command {
 // listCommand creates the list subcommand.
 func listCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "list",
-		Usage: "List all folders and their pages",
+		Name:          "list",
+		Metadata:      map[string]any{metadataJSONOutput: true},
+		Usage:         "List all folders and their pages",
+		ShellComplete: completeWithFolders,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    flagFolder,
@@ -485,6 +768,13 @@ func listCommand() *cli.Command {
 				return fmt.Errorf("list pages: %w", err)
 			}
 
+			if isJSONOutput(cmd) {
+				if folders == nil {
+					folders = []*sync.FolderInfo{}
+				}
+				return printJSON(folders)
+			}
+
 			if len(folders) == 0 {
 				displayNoFoldersMessage()
 				return nil
@@ -499,14 +789,24 @@ func listCommand() *cli.Command {
 // statusCommand creates the status subcommand.
 func statusCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "status",
-		Usage: "Show sync status and queue information",
+		Name:          "status",
+		Metadata:      map[string]any{metadataJSONOutput: true},
+		Usage:         "Show sync status and queue information",
+		ShellComplete: completeWithFolders,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    flagFolder,
 				Aliases: []string{"f"},
 				Usage:   "Only show status for specified folder",
 			},
+			&cli.BoolFlag{
+				Name:  "blocked",
+				Usage: "List pages blocked by archived or inaccessible parents",
+			},
+			&cli.BoolFlag{
+				Name:  "perf",
+				Usage: "Show pipeline metrics (fetch, convert and write durations) of the last sync runs",
+			},
 			verboseFlag,
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
@@ -537,12 +837,128 @@ func statusCommand() *cli.Command {
 			}
 
 			// Display status
+			if isJSONOutput(cmd) {
+				return printJSON(status)
+			}
 			if folder != "" {
 				displayFolderStatus(folder, status)
 			} else {
 				displayOverallStatus(status)
 			}
+			displayBlockedPages(status, cmd.Bool("blocked"))
+			displayPendingReviews(status)
+			displayQuotaWarnings(status)
+			displayMirrorSize(status)
+			displayCrawlProgress(status)
+			displayQueueLimits(status)
+			if cmd.Bool("perf") {
+				displayPerf(status)
+			}
+
+			return nil
+		},
+	}
+}
+
+// verifyCommand creates the verify subcommand.
+func verifyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "Check that synced files match their recorded hash, or current Notion content with --remote",
+		ArgsUsage: "[folder]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "remote",
+				Usage: "Refetch pages and compare files with what current Notion content converts to",
+			},
+			&cli.IntFlag{
+				Name:  "sample",
+				Usage: "Number of pages to verify, picked randomly (0 = all)",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			remote := cmd.Bool("remote")
+
+			var crawler *sync.Crawler
+			if remote {
+				client, storeInst, err := setupClientAndStore(cmd)
+				if err != nil {
+					return err
+				}
+				crawler = sync.NewCrawler(client, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			} else {
+				storeInst, _, err := createStore(cmd)
+				if err != nil {
+					return err
+				}
+				crawler = sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			}
+
+			result, err := crawler.Verify(ctx, sync.VerifyOptions{
+				Folder: cmd.Args().First(),
+				Remote: remote,
+				Sample: cmd.Int("sample"),
+			})
+			if err != nil {
+				return fmt.Errorf("verify: %w", err)
+			}
+
+			displayVerifyResults(result)
+
+			if len(result.Mismatches) > 0 {
+				return fmt.Errorf("%d of %d: %w", len(result.Mismatches), result.Checked, apperrors.ErrVerifyMismatch)
+			}
+			return nil
+		},
+	}
+}
+
+// diffCommand creates the diff subcommand.
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:          "diff",
+		Usage:         "Show how syncing would change the files of pages, without writing anything",
+		ArgsUsage:     "[page_id_or_url]",
+		ShellComplete: completeWithPageIDs,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    flagFolder,
+				Aliases: []string{"f"},
+				Usage:   "Only diff the pages of this folder, when no page is given",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			opts := sync.DiffOptions{Folder: cmd.String(flagFolder)}
+			if cmd.Args().Len() > 0 {
+				pageID, err := notion.ParsePageIDOrURL(cmd.Args().First())
+				if err != nil {
+					return fmt.Errorf("invalid page ID or URL: %w", err)
+				}
+				opts.PageID = pageID
+			}
+
+			client, storeInst, err := setupClientAndStore(cmd)
+			if err != nil {
+				return err
+			}
+			crawler := sync.NewCrawler(client, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+			diffs, err := crawler.Diff(ctx, opts)
+			if err != nil {
+				return fmt.Errorf("diff: %w", err)
+			}
 
+			displayDiffs(ctx, diffs)
 			return nil
 		},
 	}
@@ -582,15 +998,20 @@ func reindexCommand() *cli.Command {
 	}
 }
 
-// cleanupCommand creates the cleanup subcommand.
-func cleanupCommand() *cli.Command {
+// relinkCommand creates the relink subcommand.
+func relinkCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "cleanup",
-		Usage: "Delete orphaned pages not tracing to root.md",
+		Name:  "relink",
+		Usage: "Rewrite the links to synced Notion pages of all files into relative links to their files",
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    flagFolder,
+				Aliases: []string{"f"},
+				Usage:   "Only relink the files of this folder",
+			},
 			&cli.BoolFlag{
 				Name:  flagDryRun,
-				Usage: "Preview only, don't delete anything",
+				Usage: "Show the files whose links would be rewritten without making changes",
 			},
 			verboseFlag,
 		},
@@ -599,34 +1020,26 @@ func cleanupCommand() *cli.Command {
 			return ctx, nil
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			dryRun := cmd.Bool(flagDryRun)
-
-			// Setup store (no client needed for cleanup)
 			storeInst, remoteConfig, err := createStore(cmd)
 			if err != nil {
 				return err
 			}
 
-			// Create crawler (no client needed for cleanup)
 			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			dryRun := cmd.Bool(flagDryRun)
 
-			// Reconcile root.md first
-			if reconcileErr := crawler.ReconcileRootMd(ctx); reconcileErr != nil {
-				return fmt.Errorf("reconcile root.md: %w", reconcileErr)
-			}
-
-			// Run cleanup
-			result, err := crawler.Cleanup(ctx, dryRun)
+			result, err := crawler.Relink(ctx, sync.RelinkOptions{
+				Folder: cmd.String(flagFolder),
+				DryRun: dryRun,
+			})
 			if err != nil {
-				return fmt.Errorf("cleanup: %w", err)
+				return fmt.Errorf("relink: %w", err)
 			}
 
-			// Display results
-			displayCleanupResults(result, dryRun)
+			displayRelinkResults(result, dryRun)
 
-			// Commit if enabled and not dry-run
-			if !dryRun && remoteConfig.IsCommitEnabled() && result.DeletedFiles > 0 {
-				if err := commitAndPush(ctx, crawler, storeInst, remoteConfig, "cleanup orphaned pages"); err != nil {
+			if !dryRun && remoteConfig.IsCommitEnabled() && len(result.Changed) > 0 {
+				if err := commitAndPush(ctx, crawler, storeInst, remoteConfig, "relink pages"); err != nil {
 					return err
 				}
 			}
@@ -636,51 +1049,504 @@ func cleanupCommand() *cli.Command {
 	}
 }
 
-// remoteCommand creates the remote subcommand.
-func remoteCommand() *cli.Command {
+// adoptCommand creates the adopt subcommand.
+func adoptCommand() *cli.Command {
 	return &cli.Command{
-		Name:  "remote",
-		Usage: "Manage remote git repository",
-		Commands: []*cli.Command{
-			{
-				Name:  "show",
-				Usage: "Show current remote configuration from environment variables",
-				Flags: []cli.Flag{
-					verboseFlag,
-				},
-				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
-					setupLogging(cmd)
-					return ctx, nil
-				},
-				Action: func(_ context.Context, _ *cli.Command) error {
-					cfg := store.LoadRemoteConfigFromEnv()
-					displayRemoteConfig(cfg)
-					return nil
-				},
+		Name:  "adopt",
+		Usage: "Build the registry from markdown files generated by another Notion exporter and mark them as synced",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "id-key",
+				Usage: "Frontmatter key holding the Notion ID or URL (repeatable, default: notion_id, id, url, ...)",
 			},
-			{
-				Name:  "test",
-				Usage: "Test connection to remote repository",
-				Flags: []cli.Flag{
-					verboseFlag,
-				},
-				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
-					setupLogging(cmd)
-					return ctx, nil
-				},
-				Action: func(ctx context.Context, _ *cli.Command) error {
-					cfg := store.LoadRemoteConfigFromEnv()
-
-					if !cfg.IsEnabled() {
-						return apperrors.ErrRemoteNotConfiguredSetURL
-					}
-
-					return displayConnectionTest(ctx, cfg)
-				},
+			&cli.StringFlag{
+				Name:    flagFolder,
+				Aliases: []string{"f"},
+				Usage:   "Folder of files at the top of the repository",
+				Value:   "default",
+			},
+			&cli.BoolFlag{
+				Name:  flagDryRun,
+				Usage: "Show what would be adopted without making changes",
 			},
+			verboseFlag,
 		},
-	}
-}
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			storeInst, remoteConfig, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+
+			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			dryRun := cmd.Bool(flagDryRun)
+
+			result, err := crawler.Adopt(ctx, sync.AdoptOptions{
+				IDKeys:        cmd.StringSlice("id-key"),
+				DefaultFolder: cmd.String(flagFolder),
+				DryRun:        dryRun,
+			})
+			if err != nil {
+				return fmt.Errorf("adopt: %w", err)
+			}
+
+			displayAdoptResults(result, dryRun)
+
+			if !dryRun && remoteConfig.IsCommitEnabled() && len(result.Adopted) > 0 {
+				if err := commitAndPush(ctx, crawler, storeInst, remoteConfig, "adopt existing pages"); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// purgeCommand creates the purge subcommand.
+func purgeCommand() *cli.Command {
+	return &cli.Command{
+		Name:          "purge",
+		Usage:         "Delete a mistakenly synced page from the mirror, and optionally from the git history",
+		ArgsUsage:     "<page_id_or_url>",
+		ShellComplete: completeWithPageIDs,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "rewrite-history",
+				Usage: "Also remove the page from every commit of the mirror branch, and force push it",
+			},
+			&cli.BoolFlag{
+				Name:  "yes",
+				Usage: "Confirm the history rewrite",
+			},
+			&cli.BoolFlag{
+				Name:  flagDryRun,
+				Usage: "Show what would be deleted and rewritten without making changes",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() < 1 {
+				return apperrors.ErrPageIDRequired
+			}
+			pageID, err := notion.ParsePageIDOrURL(cmd.Args().Get(0))
+			if err != nil {
+				return fmt.Errorf("invalid page ID or URL: %w", err)
+			}
+			return runPurge(ctx, cmd, pageID)
+		},
+	}
+}
+
+// runPurge deletes a page from the mirror, then rewrites the history of the mirror branch if requested.
+func runPurge(ctx context.Context, cmd *cli.Command, pageID string) error {
+	dryRun := cmd.Bool(flagDryRun)
+	rewrite := cmd.Bool("rewrite-history")
+
+	storeInst, remoteConfig, err := createStore(cmd)
+	if err != nil {
+		return err
+	}
+
+	var rewriter store.HistoryRewriter
+	if rewrite {
+		var ok bool
+		if rewriter, ok = storeInst.(store.HistoryRewriter); !ok {
+			return apperrors.ErrHistoryRewriteUnsupported
+		}
+		if !dryRun && !cmd.Bool("yes") {
+			displayPurgeWarning()
+			return apperrors.ErrPurgeNotConfirmed
+		}
+	}
+
+	crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+	result, err := crawler.Purge(ctx, pageID, dryRun)
+	if err != nil {
+		return fmt.Errorf("purge: %w", err)
+	}
+	displayPurgeResults(result, dryRun)
+
+	reason := "purge page " + result.Page.ID
+	if !rewrite {
+		if !dryRun && remoteConfig.IsCommitEnabled() {
+			return commitAndPush(ctx, crawler, storeInst, remoteConfig, reason)
+		}
+		return nil
+	}
+
+	// The deletion must be committed before the rewrite, whatever the commit settings
+	if !dryRun {
+		message := fmt.Sprintf("[ntnsync] %s at %s", reason, formatTime(time.Now()))
+		if err := crawler.CommitChanges(ctx, message); err != nil {
+			return fmt.Errorf("commit purge: %w", err)
+		}
+	}
+
+	history, err := rewriter.PurgeHistory(ctx, result.Paths, dryRun)
+	if err != nil {
+		return fmt.Errorf("rewrite history: %w", err)
+	}
+	displayHistoryRewrite(history, dryRun)
+	if dryRun {
+		return nil
+	}
+
+	if remoteConfig.IsPushEnabled() {
+		if err := rewriter.ForcePush(ctx); err != nil {
+			return fmt.Errorf("force push (retry with 'remote push --force'): %w", err)
+		}
+	}
+	displayPurgeWarning()
+
+	// Another reference (a tag, a branch, the remote-tracking branch without push) may keep the former history
+	if rewriter.HasCommit(history.OldHead) {
+		displayHistoryRetained(resolveStorePath(cmd), history.OldHead)
+		return apperrors.ErrPurgeIncomplete
+	}
+	return nil
+}
+
+// cleanupCommand creates the cleanup subcommand.
+func cleanupCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "cleanup",
+		Metadata: map[string]any{metadataJSONOutput: true},
+		Usage:    "Delete orphaned pages not tracing to root.md",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  flagDryRun,
+				Usage: "Preview only, don't delete anything",
+			},
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "Also delete the least recently edited leaf pages until the mirror fits NTN_MAX_MIRROR_SIZE",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			dryRun := cmd.Bool(flagDryRun)
+
+			// Setup store (no client needed for cleanup)
+			storeInst, remoteConfig, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+
+			// Create crawler (no client needed for cleanup)
+			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+			// Reconcile root.md first
+			if reconcileErr := crawler.ReconcileRootMd(ctx); reconcileErr != nil {
+				return fmt.Errorf("reconcile root.md: %w", reconcileErr)
+			}
+
+			// Run cleanup
+			result, err := crawler.Cleanup(ctx, dryRun)
+			if err != nil {
+				return fmt.Errorf("cleanup: %w", err)
+			}
+
+			jsonOutput := isJSONOutput(cmd)
+			if !jsonOutput {
+				displayCleanupResults(result, dryRun)
+			}
+
+			// Prune the mirror down to its size cap if requested
+			var pruneResult *sync.PruneResult
+			var pruned int
+			if cmd.Bool("prune") {
+				var pruneErr error
+				pruneResult, pruneErr = crawler.PruneMirror(ctx, sync.PrunePolicyOldestLeaves, dryRun)
+				if pruneErr != nil {
+					return fmt.Errorf("prune: %w", pruneErr)
+				}
+				if !jsonOutput {
+					displayPruneResults(pruneResult, dryRun)
+				}
+				pruned = len(pruneResult.PrunedPages)
+			}
+
+			// Commit if enabled and not dry-run
+			if !dryRun && remoteConfig.IsCommitEnabled() && result.DeletedFiles+pruned > 0 {
+				if err := commitAndPush(ctx, crawler, storeInst, remoteConfig, "cleanup orphaned pages"); err != nil {
+					return err
+				}
+			}
+
+			if jsonOutput {
+				return printJSON(&cleanupOutput{CleanupResult: result, DryRun: dryRun, Prune: pruneResult})
+			}
+			return nil
+		},
+	}
+}
+
+// checkLinksCommand creates the check-links subcommand.
+func checkLinksCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "check-links",
+		Usage: "Check the notion_url of synced pages and report dead or redirected ones",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:    "interval",
+				Usage:   "Minimum delay between two requests",
+				Value:   time.Second,
+				Sources: cli.EnvVars("NTN_LINK_CHECK_INTERVAL"),
+			},
+			&cli.DurationFlag{
+				Name:    "recheck-after",
+				Usage:   "Only check links not checked for this long (0 = check all)",
+				Value:   defaultLinkRecheckAfter,
+				Sources: cli.EnvVars("NTN_LINK_RECHECK_AFTER"),
+			},
+			&cli.IntFlag{
+				Name:  "max-links",
+				Usage: "Maximum number of links to check (0 = unlimited)",
+			},
+			&cli.BoolFlag{
+				Name:  flagDryRun,
+				Usage: "Check links without saving the report",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			dryRun := cmd.Bool(flagDryRun)
+
+			storeInst, remoteConfig, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+
+			// Links are checked against notion.so directly: no client needed
+			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+			result, err := crawler.CheckLinks(ctx, sync.LinkCheckOptions{
+				Interval:     cmd.Duration("interval"),
+				RecheckAfter: cmd.Duration("recheck-after"),
+				MaxLinks:     cmd.Int("max-links"),
+				DryRun:       dryRun,
+			})
+			if err != nil {
+				return fmt.Errorf("check links: %w", err)
+			}
+
+			displayLinkCheckResults(result, dryRun)
+
+			if !dryRun && remoteConfig.IsCommitEnabled() && result.Checked > 0 {
+				if err := commitAndPush(ctx, crawler, storeInst, remoteConfig, "check notion links"); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// remoteCommand creates the remote subcommand.
+func remoteCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "remote",
+		Usage: "Manage remote git repository",
+		Commands: []*cli.Command{
+			{
+				Name:  "show",
+				Usage: "Show current remote configuration from environment variables",
+				Flags: []cli.Flag{
+					verboseFlag,
+				},
+				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+					setupLogging(cmd)
+					return ctx, nil
+				},
+				Action: func(_ context.Context, _ *cli.Command) error {
+					cfg := store.LoadRemoteConfigFromEnv()
+					displayRemoteConfig(cfg)
+					return nil
+				},
+			},
+			{
+				Name:  "test",
+				Usage: "Test connection to remote repository",
+				Flags: []cli.Flag{
+					verboseFlag,
+				},
+				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+					setupLogging(cmd)
+					return ctx, nil
+				},
+				Action: func(ctx context.Context, _ *cli.Command) error {
+					cfg := store.LoadRemoteConfigFromEnv()
+
+					if !cfg.IsEnabled() {
+						return apperrors.ErrRemoteNotConfiguredSetURL
+					}
+
+					return displayConnectionTest(ctx, cfg)
+				},
+			},
+			remotePushCommand(),
+		},
+	}
+}
+
+// queueCommand creates the queue subcommand.
+func queueCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "queue",
+		Usage: "Manage the sync queue",
+		Commands: []*cli.Command{
+			queueShowCommand(),
+			{
+				Name:  "migrate",
+				Usage: "Convert queue files using the legacy pageIds list to the current format",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  flagDryRun,
+						Usage: "Only list the queue files to convert",
+					},
+					verboseFlag,
+				},
+				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+					setupLogging(cmd)
+					return ctx, nil
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					dryRun := cmd.Bool(flagDryRun)
+
+					storeInst, remoteConfig, err := createStore(cmd)
+					if err != nil {
+						return err
+					}
+					crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+					migrated, err := crawler.MigrateQueue(ctx, dryRun)
+					if err != nil {
+						return err
+					}
+					displayQueueMigration(migrated, dryRun)
+
+					if !dryRun && remoteConfig.IsCommitEnabled() && len(migrated) > 0 {
+						return commitAndPush(ctx, crawler, storeInst, remoteConfig, "migrate queue files")
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// queueShowCommand creates the queue show subcommand.
+func queueShowCommand() *cli.Command {
+	return &cli.Command{
+		Name:          "show",
+		Metadata:      map[string]any{metadataJSONOutput: true},
+		Usage:         "Show the pages of a queue file",
+		ArgsUsage:     "<queue_file>",
+		ShellComplete: completeWithQueueFiles,
+		Flags:         []cli.Flag{verboseFlag},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() < 1 {
+				return apperrors.ErrQueueFileRequired
+			}
+			filename := filepath.Base(cmd.Args().First())
+
+			storeInst, _, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+			entry, err := queue.NewManager(storeInst, slog.Default()).ReadEntry(ctx, filename)
+			if err != nil {
+				return err
+			}
+
+			if isJSONOutput(cmd) {
+				return printJSON(entry)
+			}
+			displayQueueEntry(filename, entry)
+			return nil
+		},
+	}
+}
+
+// remotePushCommand creates the remote push subcommand.
+func remotePushCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "push",
+		Usage: "Push local commits to the remote repository",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "retry-pending",
+				Usage: "Only push if a previous push failed",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Replace the history of the remote branch (after 'purge --rewrite-history')",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			storeInst, remoteConfig, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+			if !remoteConfig.IsEnabled() {
+				return apperrors.ErrRemoteNotConfiguredSetURL
+			}
+
+			if cmd.Bool("force") {
+				rewriter, ok := storeInst.(store.HistoryRewriter)
+				if !ok {
+					return apperrors.ErrHistoryRewriteUnsupported
+				}
+				return rewriter.ForcePush(ctx)
+			}
+
+			spooler, ok := storeInst.(store.PushSpooler)
+			if ok {
+				spool, spoolErr := spooler.PendingPush()
+				if spoolErr != nil {
+					return fmt.Errorf("read pending push: %w", spoolErr)
+				}
+				displayPendingPush(spool)
+				if spool == nil && cmd.Bool("retry-pending") {
+					return nil
+				}
+			}
+
+			if err := storeInst.Push(ctx); err != nil {
+				return fmt.Errorf("push to remote: %w", err)
+			}
+
+			slog.InfoContext(ctx, "push complete")
+			return nil
+		},
+	}
+}
 
 // serveCommand creates the serve subcommand for the webhook server.
 //
@@ -720,6 +1586,90 @@ func serveCommand() *cli.Command {
 				Value:   0,
 				Sources: cli.EnvVars("NTN_WEBHOOK_SYNC_DELAY"),
 			},
+			&cli.DurationFlag{
+				Name:    "sync-max-run-time",
+				Usage:   "Maximum duration of an automatic sync run, continued by the next run (0 = unlimited)",
+				Value:   webhook.DefaultSyncMaxRunTime,
+				Sources: cli.EnvVars("NTN_WEBHOOK_SYNC_MAX_RUN_TIME"),
+			},
+			&cli.DurationFlag{
+				Name:    "auth-probe-delay",
+				Usage:   "Delay between checks of the Notion token once it was rejected (sync resumes when it works again)",
+				Value:   webhook.DefaultAuthProbeDelay,
+				Sources: cli.EnvVars("NTN_WEBHOOK_AUTH_PROBE_DELAY"),
+			},
+			&cli.BoolFlag{
+				Name:    "ignore-own-events",
+				Usage:   "Ignore events triggered only by our own integration, to prevent sync loops",
+				Value:   true,
+				Sources: cli.EnvVars("NTN_WEBHOOK_IGNORE_OWN_EVENTS"),
+			},
+			&cli.IntFlag{
+				Name:    "grpc-port",
+				Usage:   "gRPC port for internal tooling (0 = disabled)",
+				Sources: cli.EnvVars("NTN_GRPC_PORT"),
+			},
+			&cli.StringFlag{
+				Name:    "grpc-address",
+				Usage:   "Address the gRPC server listens on (empty = all interfaces)",
+				Value:   webhook.DefaultGRPCAddress,
+				Sources: cli.EnvVars("NTN_GRPC_ADDRESS"),
+			},
+			&cli.StringFlag{
+				Name:    "grpc-token",
+				Usage:   "Bearer token required by every gRPC call (required with --grpc-port)",
+				Sources: cli.EnvVars("NTN_GRPC_TOKEN"),
+			},
+			&cli.StringFlag{
+				Name:    "simulate-token",
+				Usage:   "Bearer token enabling the /api/simulate endpoint (disabled if not set)",
+				Sources: cli.EnvVars("NTN_WEBHOOK_SIMULATE_TOKEN"),
+			},
+			&cli.StringFlag{
+				Name:    "debug-token",
+				Usage:   "Bearer token enabling the /api/debug/state endpoint (disabled if not set)",
+				Sources: cli.EnvVars("NTN_WEBHOOK_DEBUG_TOKEN"),
+			},
+			&cli.BoolFlag{
+				Name:    "admin-ui",
+				Usage:   "Serve the admin UI on /admin/",
+				Sources: cli.EnvVars("NTN_ADMIN_UI"),
+			},
+			&cli.StringFlag{
+				Name:    "admin-user",
+				Usage:   "Basic auth user of the admin UI",
+				Value:   webhook.DefaultAdminUser,
+				Sources: cli.EnvVars("NTN_ADMIN_USER"),
+			},
+			&cli.StringFlag{
+				Name:    "admin-password",
+				Usage:   "Basic auth password of the admin UI (no auth if not set)",
+				Sources: cli.EnvVars("NTN_ADMIN_PASSWORD"),
+			},
+			&cli.StringFlag{
+				Name:    "allowed-cidrs",
+				Usage:   "Comma-separated CIDRs or IPs allowed to call the webhook endpoint (empty = all)",
+				Sources: cli.EnvVars("NTN_WEBHOOK_ALLOWED_CIDRS"),
+			},
+			&cli.IntFlag{
+				Name:    "max-body-size",
+				Usage:   "Maximum size of a webhook request body, in bytes (0 = unlimited)",
+				Value:   webhook.DefaultMaxBodySize,
+				Sources: cli.EnvVars("NTN_WEBHOOK_MAX_BODY_SIZE"),
+			},
+			&cli.IntFlag{
+				Name:    "rate-limit",
+				Usage:   "Webhook requests allowed per minute and source IP (0 = unlimited)",
+				Value:   webhook.DefaultRateLimit,
+				Sources: cli.EnvVars("NTN_WEBHOOK_RATE_LIMIT"),
+			},
+			&cli.BoolFlag{
+				Name:    "trust-proxy",
+				Usage:   "Use the last X-Forwarded-For address as the request source (behind a reverse proxy)",
+				Sources: cli.EnvVars("NTN_WEBHOOK_TRUST_PROXY"),
+			},
+			quietHoursFlag,
+			quietHoursTZFlag,
 			verboseFlag,
 		},
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
@@ -733,14 +1683,27 @@ func serveCommand() *cli.Command {
 					" (set --secret or NTN_WEBHOOK_SECRET)")
 			}
 
-			// Setup store (webhook server needs it for queue management)
-			storeInst, remoteConfig, err := createStore(cmd)
+			// Setup store (webhook server needs it for queue management). The lock of the store directory is only
+			// held while writing, and waited for while another command holds it, so that no event is lost.
+			storeInst, remoteConfig, err := createStoreWithLockWait(cmd, true)
 			if err != nil {
 				return err
 			}
 
 			// Create queue manager
 			queueMgr := queue.NewManager(storeInst, slog.Default())
+			if err := queueMgr.SetLimits(sync.GetConfig().QueueLimits()); err != nil {
+				return fmt.Errorf("set queue limits: %w", err)
+			}
+
+			if cmd.Int("grpc-port") > 0 && cmd.String("grpc-token") == "" {
+				return apperrors.ErrGRPCTokenRequired
+			}
+
+			allowedCIDRs, err := webhook.ParseCIDRs(cmd.String("allowed-cidrs"))
+			if err != nil {
+				return fmt.Errorf("allowed CIDRs: %w", err)
+			}
 
 			// Create webhook config
 			cfg := &webhook.ServerConfig{
@@ -749,6 +1712,25 @@ func serveCommand() *cli.Command {
 				Secret:    secret,
 				AutoSync:  cmd.Bool("auto-sync"),
 				SyncDelay: cmd.Duration("sync-delay"),
+				GRPCPort:  cmd.Int("grpc-port"),
+
+				GRPCAddress: cmd.String("grpc-address"),
+				GRPCToken:   cmd.String("grpc-token"),
+
+				SyncMaxRunTime:  cmd.Duration("sync-max-run-time"),
+				AuthProbeDelay:  cmd.Duration("auth-probe-delay"),
+				IgnoreOwnEvents: cmd.Bool("ignore-own-events"),
+				SimulateToken:   cmd.String("simulate-token"),
+				DebugToken:      cmd.String("debug-token"),
+
+				AdminUI:       cmd.Bool("admin-ui"),
+				AdminUser:     cmd.String("admin-user"),
+				AdminPassword: cmd.String("admin-password"),
+
+				AllowedCIDRs: allowedCIDRs,
+				MaxBodySize:  int64(cmd.Int("max-body-size")),
+				RateLimit:    cmd.Int("rate-limit"),
+				TrustProxy:   cmd.Bool("trust-proxy"),
 			}
 
 			// Create sync worker if NOTION_TOKEN is available
@@ -759,7 +1741,7 @@ func serveCommand() *cli.Command {
 			}
 
 			if token != "" && cfg.AutoSync {
-				client := notion.NewClient(token)
+				client := newNotionClient(token)
 				crawler := sync.NewCrawler(client, storeInst, sync.WithCrawlerLogger(slog.Default()))
 
 				// Reconcile root.md at startup
@@ -767,25 +1749,58 @@ func serveCommand() *cli.Command {
 					return fmt.Errorf("reconcile root.md: %w", reconcileErr)
 				}
 
-				opts := []webhook.SyncWorkerOption{}
+				quiet, quietErr := loadQuietHours(cmd)
+				if quietErr != nil {
+					return quietErr
+				}
+
+				opts := []webhook.SyncWorkerOption{
+					webhook.WithQuietHours(quiet),
+					webhook.WithMaxRunTime(cfg.SyncMaxRunTime),
+				}
+				if cfg.AuthProbeDelay > 0 {
+					opts = append(opts, webhook.WithAuthProbeDelay(cfg.AuthProbeDelay))
+				}
 				if cfg.SyncDelay > 0 {
 					opts = append(opts, webhook.WithSyncDelay(cfg.SyncDelay))
 				}
 
 				syncWorker = webhook.NewSyncWorker(crawler, storeInst, remoteConfig, slog.Default(), opts...)
-				slog.InfoContext(ctx, "auto-sync enabled", "sync_delay", cfg.SyncDelay)
+				slog.InfoContext(ctx, "auto-sync enabled",
+					"sync_delay", cfg.SyncDelay,
+					"sync_max_run_time", cfg.SyncMaxRunTime)
 			} else if cfg.AutoSync {
 				slog.WarnContext(ctx, "auto-sync disabled: NOTION_TOKEN not configured")
 			}
 
 			// Create and start server
 			server := webhook.NewServer(cfg, queueMgr, storeInst, slog.Default(), syncWorker, remoteConfig)
+			if token != "" && cfg.IgnoreOwnEvents {
+				server.EnableLoopPrevention(newNotionClient(token))
+			}
+			if cfg.GRPCPort > 0 {
+				// Status and listing only read the store: they get their own crawler, without a Notion client
+				server.EnableGRPC(sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default())))
+			}
+			if cfg.AdminUI {
+				if cfg.AdminPassword == "" {
+					slog.WarnContext(ctx, "admin UI enabled without a password - anyone reaching the server can use it"+
+						" (set --admin-password or NTN_ADMIN_PASSWORD)")
+				}
+				server.EnableAdminUI(sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default())))
+			}
 
 			slog.InfoContext(ctx, "starting webhook server",
 				"port", cfg.Port,
 				"path", cfg.Path,
 				"auto_sync", cfg.AutoSync,
 				"sync_delay", cfg.SyncDelay,
+				"grpc_port", cfg.GRPCPort,
+				"grpc_address", cfg.GRPCAddress,
+				"ignore_own_events", cfg.IgnoreOwnEvents && token != "",
+				"simulate_endpoint", cfg.SimulateToken != "",
+				"debug_endpoint", cfg.DebugToken != "",
+				"admin_ui", cfg.AdminUI,
 				"version", version.Version)
 
 			return server.Start(ctx)
@@ -805,6 +1820,17 @@ func storeRemoteConfig(storeInst store.Store) *store.RemoteConfig {
 	}
 }
 
+// acquireRemoteLock locks the branch on the remote if the store supports it and the lock is enabled (NTN_GIT_LOCK).
+// It is released with the store locks.
+func acquireRemoteLock(ctx context.Context, storeInst store.Store) error {
+	if locker, ok := storeInst.(store.RemoteLocker); ok {
+		if err := locker.AcquireRemoteLock(ctx); err != nil {
+			return fmt.Errorf("lock remote: %w", err)
+		}
+	}
+	return nil
+}
+
 // storePull pulls from remote if the store supports it.
 func storePull(ctx context.Context, storeInst store.Store) error {
 	switch typed := storeInst.(type) {
@@ -820,6 +1846,45 @@ func storePull(ctx context.Context, storeInst store.Store) error {
 	return nil
 }
 
+// crawlBudget returns the budget of a sync run started at startedAt, from the --crawl-budget and
+// --crawl-api-budget flags. Returns false if the run has no budget.
+func crawlBudget(cmd *cli.Command, startedAt time.Time) (sync.CrawlBudget, bool) {
+	budget := sync.CrawlBudget{APICalls: cmd.Int("crawl-api-budget")}
+	if duration := cmd.Duration("crawl-budget"); duration > 0 {
+		budget.Deadline = startedAt.Add(duration)
+	}
+	return budget, !budget.Deadline.IsZero() || budget.APICalls > 0
+}
+
+// loadQuietHours parses the quiet hours flags. Returns nil if no quiet hours are configured.
+func loadQuietHours(cmd *cli.Command) (*sync.QuietHours, error) {
+	spec := cmd.String(quietHoursFlag.Name)
+	if spec == "" {
+		return nil, nil //nolint:nilnil // nil quiet hours indicates they are disabled
+	}
+	quiet, err := sync.ParseQuietHours(spec, cmd.String(quietHoursTZFlag.Name))
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours: %w", err)
+	}
+	return quiet, nil
+}
+
+// skipForQuietHours returns true if the command must be skipped because quiet hours are active.
+func skipForQuietHours(ctx context.Context, cmd *cli.Command) (bool, error) {
+	quiet, err := loadQuietHours(cmd)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if !quiet.Active(now) || cmd.Bool("ignore-quiet-hours") {
+		return false, nil
+	}
+
+	slog.InfoContext(ctx, "quiet hours, skipping", "command", cmd.Name, "until", quiet.End(now))
+	return true, nil
+}
+
 // resolveStorePath returns the store path from NTN_DIR env var or --store-path flag.
 func resolveStorePath(cmd *cli.Command) string {
 	// NTN_DIR env var takes precedence
@@ -838,15 +1903,46 @@ func resolveStorePath(cmd *cli.Command) string {
 // If NTN_QUEUE_BRANCH is set, returns a SplitStore that routes the queue
 // (.notion-sync/queue) to a separate branch while content, .notion-sync/ids
 // and .notion-sync/state.json stay on the main branch. Otherwise, returns a
-// plain LocalStore.
+// plain LocalStore. With --ephemeral, returns an empty in-memory store that
+// is discarded when the command exits. With NTN_STORAGE=s3 (or NTN_S3_BUCKET
+// set without NTN_GIT_URL), returns a store of the objects of the bucket.
 func createStore(cmd *cli.Command) (store.Store, *store.RemoteConfig, error) {
+	return createStoreWithLockWait(cmd, cmd.Bool(flagWait))
+}
+
+// createStoreWithLockWait is like createStore, waiting for the lock of the store directory held by another process
+// if lockWait is true, instead of failing.
+func createStoreWithLockWait(cmd *cli.Command, lockWait bool) (store.Store, *store.RemoteConfig, error) {
 	storePath := resolveStorePath(cmd)
 	remoteConfig := store.LoadRemoteConfigFromEnv()
 
-	contentStore, err := store.NewLocalStore(storePath, store.WithRemoteConfig(remoteConfig))
+	if cmd.Bool(flagEphemeral) {
+		push := false
+		remoteConfig.Storage = store.StorageModeLocal
+		remoteConfig.URL = ""
+		remoteConfig.QueueBranch = ""
+		remoteConfig.Push = &push
+		return store.NewMemStore(), remoteConfig, nil
+	}
+
+	if remoteConfig.EffectiveStorageMode() == store.StorageModeS3 {
+		s3Store, err := store.NewS3Store(context.Background(), remoteConfig.S3)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create store: %w", err)
+		}
+		push := false
+		remoteConfig.QueueBranch = ""
+		remoteConfig.Push = &push
+		return s3Store, remoteConfig, nil
+	}
+
+	contentStore, err := store.NewLocalStore(storePath,
+		store.WithRemoteConfig(remoteConfig),
+		store.WithLockWait(lockWait))
 	if err != nil {
 		return nil, nil, fmt.Errorf("create store: %w", err)
 	}
+	lockedStores = append(lockedStores, contentStore)
 
 	if remoteConfig.HasQueueBranch() {
 		// Keep the queue clone outside the content working tree so the content
@@ -863,15 +1959,19 @@ func createStore(cmd *cli.Command) (store.Store, *store.RemoteConfig, error) {
 			Commit:       remoteConfig.Commit,
 			CommitPeriod: remoteConfig.CommitPeriod,
 			Push:         remoteConfig.Push,
+			ReadOnly:     remoteConfig.ReadOnly,
+			Subdir:       remoteConfig.Subdir,
 		}
 
 		queueStore, err := store.NewLocalStore(queuePath,
 			store.WithRemoteConfig(queueRemoteConfig),
 			store.WithCreateBranchIfMissing(),
+			store.WithLockWait(lockWait),
 			store.WithLogger(slog.Default()))
 		if err != nil {
 			return nil, nil, fmt.Errorf("create queue store: %w", err)
 		}
+		lockedStores = append(lockedStores, queueStore)
 
 		slog.Info("queue branch enabled",
 			"branch", remoteConfig.QueueBranch,
@@ -883,13 +1983,24 @@ func createStore(cmd *cli.Command) (store.Store, *store.RemoteConfig, error) {
 	return contentStore, remoteConfig, nil
 }
 
+// releaseStoreLocks releases the locks of the stores created by the command, so that other processes can write to
+// the store directories without waiting for the locks to become stale.
+func releaseStoreLocks(ctx context.Context) {
+	for _, st := range lockedStores {
+		if err := st.ReleaseAllLocks(); err != nil {
+			slog.WarnContext(ctx, "failed to release store lock", "error", err)
+		}
+	}
+	lockedStores = nil
+}
+
 // setupClientAndStore creates the Notion client and store from command flags.
 func setupClientAndStore(cmd *cli.Command) (*notion.Client, store.Store, error) {
 	token := cmd.String("token")
 	if token == "" {
 		token = os.Getenv("NOTION_TOKEN")
 	}
-	if token == "" {
+	if token == "" && os.Getenv("NTN_HTTP_REPLAY") == "" {
 		return nil, nil, apperrors.ErrNotionTokenRequired
 	}
 
@@ -898,6 +2009,112 @@ func setupClientAndStore(cmd *cli.Command) (*notion.Client, store.Store, error)
 		return nil, nil, err
 	}
 
-	client := notion.NewClient(token)
+	client := newNotionClient(token)
 	return client, storeInst, nil
 }
+
+// applyOutputFormat makes the output profile selected with --format the default one (NTN_PROFILE).
+// Folders with their own profile (NTN_FOLDER_PROFILES) keep it.
+func applyOutputFormat(cmd *cli.Command) error {
+	format := strings.ToLower(strings.TrimSpace(cmd.String(flagFormat)))
+	if format == "" {
+		return nil
+	}
+	if !converter.IsValidProfile(format) {
+		return fmt.Errorf("%w: %q (expected one of %s)", apperrors.ErrUnknownOutputFormat, format,
+			strings.Join(converter.Profiles, ", "))
+	}
+	if err := os.Setenv("NTN_PROFILE", format); err != nil {
+		return fmt.Errorf("set NTN_PROFILE: %w", err)
+	}
+	sync.ResetConfig()
+	return nil
+}
+
+// checkOutputMode checks that the output mode selected with --output is a known one.
+func checkOutputMode(cmd *cli.Command) error {
+	switch mode := strings.ToLower(strings.TrimSpace(cmd.String(flagOutput))); mode {
+	case outputText, outputJSON:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q (expected %s or %s)", apperrors.ErrUnknownOutputMode, mode, outputText, outputJSON)
+	}
+}
+
+// isJSONOutput returns true if the command results are printed as JSON (--output json).
+func isJSONOutput(cmd *cli.Command) bool {
+	return strings.EqualFold(strings.TrimSpace(cmd.String(flagOutput)), outputJSON)
+}
+
+// rejectJSONOutput makes the commands that only print text fail with --output json, instead of printing text to
+// scripts expecting JSON. Commands with the metadataJSONOutput metadata are left as is.
+func rejectJSONOutput(commands []*cli.Command) {
+	for _, command := range commands {
+		if len(command.Commands) > 0 {
+			rejectJSONOutput(command.Commands)
+			continue
+		}
+		if supported, _ := command.Metadata[metadataJSONOutput].(bool); supported {
+			continue
+		}
+		before := command.Before
+		command.Before = func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			if isJSONOutput(cmd) {
+				return ctx, fmt.Errorf("%w: %s", apperrors.ErrJSONOutputUnsupported, cmd.FullName())
+			}
+			if before == nil {
+				return ctx, nil
+			}
+			return before(ctx, cmd)
+		}
+	}
+}
+
+// newNotionClient creates a Notion client, sending up to NTN_NOTION_RATE_LIMIT requests per second on average.
+// NTN_HTTP_RECORD records its requests and responses in a cassette directory, NTN_HTTP_REPLAY replays them offline.
+func newNotionClient(token string) *notion.Client {
+	var opts []notion.ClientOption
+	if value := os.Getenv("NTN_NOTION_RATE_LIMIT"); value != "" {
+		requestsPerSecond, err := strconv.ParseFloat(value, 64)
+		if err != nil || requestsPerSecond <= 0 {
+			slog.Warn("invalid NTN_NOTION_RATE_LIMIT, using the default", "value", value)
+		} else {
+			opts = append(opts, notion.WithRateLimit(requestsPerSecond))
+		}
+	}
+	if sync.GetConfig().ReadOnly {
+		opts = append(opts, notion.WithReadOnly())
+	}
+	if dir := os.Getenv("NTN_HTTP_REPLAY"); dir != "" {
+		slog.Warn("replaying recorded Notion API responses, no request is sent", "dir", dir)
+		opts = append(opts, notion.WithReplay(dir))
+	} else if dir := os.Getenv("NTN_HTTP_RECORD"); dir != "" {
+		slog.Warn("recording Notion API requests and responses", "dir", dir)
+		opts = append(opts, notion.WithRecording(dir))
+	}
+	return notion.NewClient(token, opts...)
+}
+
+// checkConverterPlugins fails fast when converter plugins (NTN_CONVERTER_PLUGINS) are configured on a build
+// without cgo, which cannot load them, rather than silently rendering their blocks with the built-in converter.
+func checkConverterPlugins() error {
+	if len(sync.GetConfig().ConverterPlugins) == 0 || plugin.Supported {
+		return nil
+	}
+	return fmt.Errorf("%w: NTN_CONVERTER_PLUGINS is set", apperrors.ErrPluginsUnsupported)
+}
+
+// checkReadOnly fails fast when the read-only mode (NTN_READ_ONLY) is combined with a setting that pushes the
+// mirror or writes to Notion, rather than failing on the first write.
+func checkReadOnly() error {
+	if !sync.GetConfig().ReadOnly {
+		return nil
+	}
+	if err := store.LoadRemoteConfigFromEnv().CheckReadOnly(); err != nil {
+		return err
+	}
+	if sync.GetConfig().StatusPageID != "" {
+		return fmt.Errorf("%w: NTN_STATUS_PAGE", apperrors.ErrReadOnlyConflict)
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/muesli/termenv"
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/converter/postprocess"
+	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+const (
+	// notionPageURLPrefix is used to build "open in Notion" links from page IDs.
+	notionPageURLPrefix = "https://www.notion.so/"
+
+	// browseChromeLines are the lines of the browser screen that are not a list or a preview: the title, the
+	// status and the key help.
+	browseChromeLines = 4
+
+	// browseDefaultWidth and browseDefaultHeight are the size of the screen until the terminal reports it.
+	browseDefaultWidth  = 80
+	browseDefaultHeight = 24
+)
+
+// browseCommand creates the browse subcommand.
+func browseCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "browse",
+		Usage: "Interactively browse folders and pages of the synced mirror",
+		Flags: []cli.Flag{
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			storeInst, remoteConfig, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+
+			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+			folders, err := crawler.ListPages(ctx, "", false)
+			if err != nil {
+				return fmt.Errorf("list pages: %w", err)
+			}
+
+			if len(folders) == 0 {
+				displayNoFoldersMessage()
+				return nil
+			}
+
+			// The style is resolved before the terminal UI starts: querying the terminal background reads its input
+			style := styles.LightStyle
+			if termenv.HasDarkBackground() {
+				style = styles.DarkStyle
+			}
+
+			model := newBrowser(ctx, crawler, storeInst, folders, style)
+			if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+				return fmt.Errorf("run browser: %w", err)
+			}
+
+			if model.queued > 0 {
+				displayBrowseQueued(model.queued)
+				if remoteConfig.IsCommitEnabled() {
+					return commitAndPush(ctx, crawler, storeInst, remoteConfig, "browse re-sync")
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// browseView is a screen of the browser.
+type browseView int
+
+const (
+	browseFolders browseView = iota // List of the folders
+	browsePages                     // List of the pages of a folder
+	browsePage                      // Details and rendered preview of a page
+)
+
+// browser is the terminal UI of the browse command, over the registry index: folders → pages → page.
+type browser struct {
+	ctx     context.Context //nolint:containedctx // The bubbletea model has no context of its own
+	crawler *sync.Crawler
+	store   store.Store
+	style   string                                      // Glamour style of the previews
+	openURL func(ctx context.Context, url string) error // Opens a page in Notion
+
+	view    browseView
+	folders []*sync.FolderInfo
+	folder  *sync.FolderInfo
+	pages   []*sync.PageInfo // Pages of the folder, sorted by path
+	visible []*sync.PageInfo // Pages matching the filter
+	page    *sync.PageInfo
+	preview []string // Rendered lines of the page
+
+	folderCursor int
+	pageCursor   int
+	scroll       int // First line of the preview shown
+
+	filter    string
+	filtering bool // Keys are typed in the filter
+
+	width  int
+	height int
+	status string
+	queued int // Pages queued for re-sync
+}
+
+// newBrowser creates the browser over the folders of the mirror.
+func newBrowser(
+	ctx context.Context, crawler *sync.Crawler, st store.Store, folders []*sync.FolderInfo, style string,
+) *browser {
+	return &browser{
+		ctx:     ctx,
+		crawler: crawler,
+		store:   st,
+		style:   style,
+		openURL: openURL,
+		folders: folders,
+		width:   browseDefaultWidth,
+		height:  browseDefaultHeight,
+	}
+}
+
+// Init implements tea.Model.
+func (b *browser) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		if b.view == browsePage {
+			b.renderPreview()
+		}
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return b, tea.Quit
+		}
+		if b.filtering {
+			b.typeFilter(msg)
+			return b, nil
+		}
+		if msg.String() == "q" {
+			return b, tea.Quit
+		}
+		b.status = ""
+		switch b.view {
+		case browseFolders:
+			b.updateFolders(msg)
+		case browsePages:
+			b.updatePages(msg)
+		case browsePage:
+			b.updatePage(msg)
+		}
+	}
+	return b, nil
+}
+
+// updateFolders handles the keys of the folder list.
+func (b *browser) updateFolders(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k":
+		b.folderCursor = max(b.folderCursor-1, 0)
+	case "down", "j":
+		b.folderCursor = min(b.folderCursor+1, len(b.folders)-1)
+	case "enter", "right", "l":
+		b.folder = b.folders[b.folderCursor]
+		b.pages = slices.Clone(b.folder.Pages)
+		slices.SortFunc(b.pages, func(a, c *sync.PageInfo) int { return strings.Compare(a.Path, c.Path) })
+		b.filter = ""
+		b.applyFilter()
+		b.view = browsePages
+	}
+}
+
+// updatePages handles the keys of the page list.
+func (b *browser) updatePages(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k":
+		b.pageCursor = max(b.pageCursor-1, 0)
+	case "down", "j":
+		b.pageCursor = min(b.pageCursor+1, max(len(b.visible)-1, 0))
+	case "/":
+		b.filtering = true
+	case "enter", "right", "l":
+		if len(b.visible) == 0 {
+			return
+		}
+		b.page = b.visible[b.pageCursor]
+		b.scroll = 0
+		b.renderPreview()
+		b.view = browsePage
+	case "esc", "left", "h", "b":
+		b.view = browseFolders
+	}
+}
+
+// updatePage handles the keys of the page details.
+func (b *browser) updatePage(msg tea.KeyMsg) {
+	maxScroll := max(len(b.preview)-b.previewHeight(), 0)
+	switch msg.String() {
+	case "up", "k":
+		b.scroll = max(b.scroll-1, 0)
+	case "down", "j":
+		b.scroll = min(b.scroll+1, maxScroll)
+	case "pgup":
+		b.scroll = max(b.scroll-b.previewHeight(), 0)
+	case "pgdown", " ":
+		b.scroll = min(b.scroll+b.previewHeight(), maxScroll)
+	case "r":
+		if err := b.crawler.QueuePageResync(b.ctx, b.page.ID); err != nil {
+			b.status = fmt.Sprintf("Could not queue page: %v", err)
+			return
+		}
+		b.queued++
+		b.status = "Page queued for re-sync."
+	case "o":
+		url := notionPageURLPrefix + b.page.ID
+		if err := b.openURL(b.ctx, url); err != nil {
+			b.status = "Open " + url
+			return
+		}
+		b.status = "Opened " + url
+	case "esc", "left", "h", "b":
+		b.view = browsePages
+	}
+}
+
+// typeFilter handles the keys typed in the filter of the page list.
+func (b *browser) typeFilter(msg tea.KeyMsg) {
+	switch msg.Type { //nolint:exhaustive // Other keys are ignored while filtering
+	case tea.KeyEnter:
+		b.filtering = false
+	case tea.KeyEsc:
+		b.filtering = false
+		b.filter = ""
+	case tea.KeyBackspace:
+		if runes := []rune(b.filter); len(runes) > 0 {
+			b.filter = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.filter += string(msg.Runes)
+	}
+	b.applyFilter()
+}
+
+// applyFilter updates the pages matching the filter.
+func (b *browser) applyFilter() {
+	b.visible = filterPages(b.pages, b.filter)
+	b.pageCursor = 0
+}
+
+// renderPreview renders the markdown of the page, without its frontmatter, to the width of the terminal.
+func (b *browser) renderPreview() {
+	content, err := b.store.Read(b.ctx, b.page.Path)
+	if err != nil {
+		b.preview = []string{fmt.Sprintf("Could not read page: %v", err)}
+		return
+	}
+	_, body := postprocess.SplitFrontmatter(string(content))
+
+	rendered := body
+	renderer, err := glamour.NewTermRenderer(glamour.WithStandardStyle(b.style), glamour.WithWordWrap(b.width))
+	if err == nil {
+		if out, err := renderer.Render(body); err == nil {
+			rendered = out
+		}
+	}
+	b.preview = strings.Split(strings.Trim(rendered, "\n"), "\n")
+	b.scroll = min(b.scroll, max(len(b.preview)-b.previewHeight(), 0))
+}
+
+// previewHeight returns the number of preview lines shown below the page details.
+func (b *browser) previewHeight() int {
+	const pageDetailsLines = 4 // Path, ID, last sync and URL
+	return max(b.height-browseChromeLines-pageDetailsLines, 1)
+}
+
+// View implements tea.Model.
+func (b *browser) View() string {
+	var view strings.Builder
+	switch b.view {
+	case browseFolders:
+		b.viewFolders(&view)
+	case browsePages:
+		b.viewPages(&view)
+	case browsePage:
+		b.viewPage(&view)
+	}
+	view.WriteString("\n" + b.status + "\n")
+	return view.String()
+}
+
+// viewFolders writes the folder list.
+func (b *browser) viewFolders(view *strings.Builder) {
+	view.WriteString("Folders\n\n")
+	lines := make([]string, len(b.folders))
+	for i, folder := range b.folders {
+		lines[i] = fmt.Sprintf("%s (%d pages)", folder.Name, folder.TotalPages)
+	}
+	b.writeList(view, lines, b.folderCursor)
+	view.WriteString("\n↑/↓ move • enter open • q quit")
+}
+
+// viewPages writes the page list of the folder.
+func (b *browser) viewPages(view *strings.Builder) {
+	fmt.Fprintf(view, "Pages in %s", b.folder.Name)
+	if b.filter != "" || b.filtering {
+		fmt.Fprintf(view, " matching %q", b.filter)
+	}
+	view.WriteString("\n\n")
+	lines := make([]string, len(b.visible))
+	for i, page := range b.visible {
+		lines[i] = fmt.Sprintf("%s - %q", page.Path, page.Title)
+	}
+	b.writeList(view, lines, b.pageCursor)
+	if b.filtering {
+		view.WriteString("\nfilter: " + b.filter + "█ • enter apply • esc clear")
+		return
+	}
+	view.WriteString("\n↑/↓ move • enter open • / filter • esc back • q quit")
+}
+
+// viewPage writes the details of the page and the visible part of its preview.
+func (b *browser) viewPage(view *strings.Builder) {
+	page := b.page
+	title := page.Title
+	if page.IsRoot {
+		title += " (root page)"
+	}
+	if page.IsOrphaned {
+		title += " (ORPHANED: parent deleted)"
+	}
+	fmt.Fprintf(view, "%s\n", title)
+	fmt.Fprintf(view, "  Path:        %s\n", page.Path)
+	fmt.Fprintf(view, "  ID:          %s\n", page.ID)
+	fmt.Fprintf(view, "  Last synced: %s\n", formatTimeSince(page.LastSynced))
+	fmt.Fprintf(view, "  URL:         %s\n", notionPageURLPrefix+page.ID)
+
+	end := min(b.scroll+b.previewHeight(), len(b.preview))
+	for _, line := range b.preview[b.scroll:end] {
+		view.WriteString(line + "\n")
+	}
+	view.WriteString("\n↑/↓ scroll • r force re-sync • o open in Notion • esc back • q quit")
+}
+
+// writeList writes the lines of a list around the cursor, as many as fit on the screen.
+func (b *browser) writeList(view *strings.Builder, lines []string, cursor int) {
+	height := max(b.height-browseChromeLines, 1)
+	start := max(min(cursor-height/2, len(lines)-height), 0) //nolint:mnd // Cursor in the middle of the screen
+	end := min(start+height, len(lines))
+	if len(lines) == 0 {
+		view.WriteString("  (none)\n")
+	}
+	for i := start; i < end; i++ {
+		marker := "  "
+		if i == cursor {
+			marker = "> "
+		}
+		view.WriteString(marker + lines[i] + "\n")
+	}
+}
+
+// filterPages returns the pages whose title or path contains the filter (case-insensitive).
+func filterPages(pages []*sync.PageInfo, filter string) []*sync.PageInfo {
+	if filter == "" {
+		return pages
+	}
+
+	filter = strings.ToLower(filter)
+	var filtered []*sync.PageInfo
+	for _, page := range pages {
+		if strings.Contains(strings.ToLower(page.Title), filter) || strings.Contains(strings.ToLower(page.Path), filter) {
+			filtered = append(filtered, page)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour/styles"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+func TestFilterPages(t *testing.T) {
+	t.Parallel()
+
+	pages := []*sync.PageInfo{
+		{ID: "1", Title: "Roadmap", Path: "tech/roadmap.md"},
+		{ID: "2", Title: "Onboarding", Path: "hr/welcome.md"},
+		{ID: "3", Title: "Team", Path: "tech/team.md"},
+	}
+
+	ids := func(pages []*sync.PageInfo) []string {
+		var ids []string
+		for _, page := range pages {
+			ids = append(ids, page.ID)
+		}
+		return ids
+	}
+	for filter, want := range map[string][]string{
+		"":        {"1", "2", "3"},
+		"ROAD":    {"1"},
+		"tech/":   {"1", "3"},
+		"welcome": {"2"},
+		"missing": nil,
+	} {
+		if got := ids(filterPages(pages, filter)); !slices.Equal(got, want) {
+			t.Errorf("filterPages(%q) = %v, want %v", filter, got, want)
+		}
+	}
+}
+
+// TestBrowser drives the browser through a folder, the page filter, the preview and the page actions.
+func TestBrowser(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	st := store.NewMemStore()
+	tx, err := st.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	content := "---\nnotion_id: page2\n---\n# Team\n\nThe members of the team.\n"
+	if err := tx.Write(ctx, "tech/team.md", []byte(content)); err != nil {
+		t.Fatalf("failed to write page: %v", err)
+	}
+
+	folders := []*sync.FolderInfo{{Name: "tech", TotalPages: 2, Pages: []*sync.PageInfo{
+		{ID: "page2", Title: "Team", Path: "tech/team.md"},
+		{ID: "page1", Title: "Roadmap", Path: "tech/roadmap.md"},
+	}}}
+	b := newBrowser(ctx, sync.NewCrawler(nil, st), st, folders, styles.NoTTYStyle)
+	var opened []string
+	b.openURL = func(_ context.Context, url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	press := func(keys ...string) {
+		for _, key := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			}
+			b.Update(msg)
+		}
+	}
+
+	press("enter")
+	if b.view != browsePages || !strings.Contains(b.View(), "> tech/roadmap.md") {
+		t.Fatalf("view = %q, want the pages of tech sorted by path", b.View())
+	}
+
+	press("/", "t", "e", "a", "m", "enter")
+	if len(b.visible) != 1 || b.visible[0].ID != "page2" || b.filtering {
+		t.Fatalf("visible pages = %v, want the page matching the filter", b.visible)
+	}
+
+	press("enter")
+	view := b.View()
+	if b.view != browsePage || !strings.Contains(view, "The members of the team.") || strings.Contains(view, "notion_id") {
+		t.Fatalf("view = %q, want the rendered page without its frontmatter", view)
+	}
+
+	press("o")
+	if !slices.Equal(opened, []string{notionPageURLPrefix + "page2"}) {
+		t.Errorf("opened = %v, want the Notion page", opened)
+	}
+
+	// The page has no registry: it cannot be queued
+	press("r")
+	if b.queued != 0 || !strings.Contains(b.status, "Could not queue page") {
+		t.Errorf("queued = %d, status = %q, want an error", b.queued, b.status)
+	}
+
+	press("esc", "esc")
+	if b.view != browseFolders {
+		t.Errorf("view = %v, want the folders", b.view)
+	}
+	if _, cmd := b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q should quit")
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// Paths read by shell completion. Completion reads the mirror directly from disk:
+// it must be fast and must never clone, init or commit anything.
+const (
+	completionStateFile = ".notion-sync/state.json"
+	completionIDsDir    = ".notion-sync/ids"
+	completionQueueDir  = ".notion-sync/queue"
+
+	// completionFlag is appended by the shell completion scripts to request suggestions.
+	completionFlag = "--generate-shell-completion"
+)
+
+// configureCompletionCommand makes the "completion" command visible in the help output.
+func configureCompletionCommand(cmd *cli.Command) {
+	cmd.Hidden = false
+	cmd.Usage = "Output shell completion script for bash, zsh, fish or powershell"
+}
+
+// isShellCompletion returns true if the binary was invoked to generate completions.
+func isShellCompletion() bool {
+	return len(os.Args) > 0 && os.Args[len(os.Args)-1] == completionFlag
+}
+
+// completionLastArg returns the argument preceding the word being completed.
+// Shells invoke the binary with the words typed so far followed by --generate-shell-completion.
+func completionLastArg() string {
+	args := os.Args
+	if len(args) < 2 { //nolint:mnd // binary name + completion flag
+		return ""
+	}
+	lastArg := args[len(args)-2]
+	if lastArg == completionFlag {
+		return ""
+	}
+	return lastArg
+}
+
+// isFolderFlag returns true if the argument is the --folder flag (or its alias).
+func isFolderFlag(arg string) bool {
+	return arg == "--"+flagFolder || arg == "-f"
+}
+
+// completeWithFolders completes folder names after --folder, and flags otherwise.
+func completeWithFolders(ctx context.Context, cmd *cli.Command) {
+	if isFolderFlag(completionLastArg()) {
+		printFolderSuggestions(resolveStorePath(cmd), cmd.Root().Writer)
+		return
+	}
+	cli.DefaultCompleteWithFlags(ctx, cmd)
+}
+
+// completeWithPageIDs completes page IDs (with their titles) for commands taking
+// a page ID argument, and folder names after --folder.
+func completeWithPageIDs(ctx context.Context, cmd *cli.Command) {
+	lastArg := completionLastArg()
+	switch {
+	case isFolderFlag(lastArg):
+		printFolderSuggestions(resolveStorePath(cmd), cmd.Root().Writer)
+	case strings.HasPrefix(lastArg, "-"):
+		cli.DefaultCompleteWithFlags(ctx, cmd)
+	default:
+		printPageSuggestions(resolveStorePath(cmd), cmd.Root().Writer)
+	}
+}
+
+// completeWithQueueFiles completes queue filenames for commands taking a queue file argument.
+func completeWithQueueFiles(ctx context.Context, cmd *cli.Command) {
+	if strings.HasPrefix(completionLastArg(), "-") {
+		cli.DefaultCompleteWithFlags(ctx, cmd)
+		return
+	}
+	for _, filename := range completionQueueFiles(resolveStorePath(cmd)) {
+		_, _ = fmt.Fprintln(cmd.Root().Writer, filename)
+	}
+}
+
+// printFolderSuggestions prints the folder names found in the state file.
+func printFolderSuggestions(storePath string, writer io.Writer) {
+	for _, folder := range completionFolders(storePath) {
+		_, _ = fmt.Fprintln(writer, folder)
+	}
+}
+
+// printPageSuggestions prints "id:title" lines for all registered pages.
+func printPageSuggestions(storePath string, writer io.Writer) {
+	for _, reg := range completionPages(storePath) {
+		if reg.Title != "" {
+			_, _ = fmt.Fprintf(writer, "%s:%s\n", reg.ID, reg.Title)
+		} else {
+			_, _ = fmt.Fprintln(writer, reg.ID)
+		}
+	}
+}
+
+// completionFolders returns the sorted folder names from the state file.
+func completionFolders(storePath string) []string {
+	data, err := os.ReadFile(filepath.Join(storePath, completionStateFile)) //nolint:gosec // user-provided store path
+	if err != nil {
+		return nil
+	}
+
+	var state sync.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+
+	folders := slices.Clone(state.Folders)
+	slices.Sort(folders)
+	return folders
+}
+
+// completionQueueFiles returns the sorted queue filenames. With NTN_QUEUE_BRANCH, the queue is in its own clone
+// next to the store (see createStore).
+func completionQueueFiles(storePath string) []string {
+	if os.Getenv("NTN_QUEUE_BRANCH") != "" {
+		storePath = filepath.Clean(storePath) + "-queue"
+	}
+	matches, err := filepath.Glob(filepath.Join(storePath, completionQueueDir, "*.json"))
+	if err != nil {
+		return nil
+	}
+
+	files := make([]string, 0, len(matches))
+	for _, path := range matches {
+		files = append(files, filepath.Base(path))
+	}
+	slices.Sort(files)
+	return files
+}
+
+// completionPages returns the page registries sorted by title.
+func completionPages(storePath string) []*sync.PageRegistry {
+	matches, err := filepath.Glob(filepath.Join(storePath, completionIDsDir, "page-*.json"))
+	if err != nil {
+		return nil
+	}
+
+	pages := make([]*sync.PageRegistry, 0, len(matches))
+	for _, path := range matches {
+		data, err := os.ReadFile(path) //nolint:gosec // path comes from glob in the store directory
+		if err != nil {
+			continue
+		}
+
+		var reg sync.PageRegistry
+		if err := json.Unmarshal(data, &reg); err != nil || reg.ID == "" {
+			continue
+		}
+		pages = append(pages, &reg)
+	}
+
+	slices.SortFunc(pages, func(a, b *sync.PageRegistry) int {
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	})
+	return pages
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeCompletionFile writes a file of the store, creating its directories.
+func writeCompletionFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestCompletionFolders(t *testing.T) {
+	t.Parallel()
+	storePath := t.TempDir()
+
+	if folders := completionFolders(storePath); folders != nil {
+		t.Errorf("completionFolders() without state = %v, want nil", folders)
+	}
+
+	writeCompletionFile(t, filepath.Join(storePath, completionStateFile), `{"folders":["tech","default","product"]}`)
+	if folders := completionFolders(storePath); !slices.Equal(folders, []string{"default", "product", "tech"}) {
+		t.Errorf("completionFolders() = %v, want [default product tech]", folders)
+	}
+}
+
+func TestCompletionQueueFiles(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "notion")
+	for _, name := range []string{"00001001.json", "00000001.json", "notes.txt"} {
+		writeCompletionFile(t, filepath.Join(storePath, completionQueueDir, name), "{}")
+	}
+	writeCompletionFile(t, filepath.Join(storePath+"-queue", completionQueueDir, "00000042.json"), "{}")
+
+	t.Setenv("NTN_QUEUE_BRANCH", "")
+	if files := completionQueueFiles(storePath); !slices.Equal(files, []string{"00000001.json", "00001001.json"}) {
+		t.Errorf("completionQueueFiles() = %v, want [00000001.json 00001001.json]", files)
+	}
+
+	// With a queue branch, the queue is in its own clone next to the store
+	t.Setenv("NTN_QUEUE_BRANCH", "queue")
+	if files := completionQueueFiles(storePath); !slices.Equal(files, []string{"00000042.json"}) {
+		t.Errorf("completionQueueFiles() with a queue branch = %v, want [00000042.json]", files)
+	}
+}
+
+func TestPrintPageSuggestions(t *testing.T) {
+	t.Parallel()
+	storePath := t.TempDir()
+	idsDir := filepath.Join(storePath, completionIDsDir)
+	writeCompletionFile(t, filepath.Join(idsDir, "page-b.json"), `{"id":"b","title":"beta"}`)
+	writeCompletionFile(t, filepath.Join(idsDir, "page-a.json"), `{"id":"a","title":"Alpha"}`)
+	writeCompletionFile(t, filepath.Join(idsDir, "page-c.json"), `{"id":"c"}`)
+	writeCompletionFile(t, filepath.Join(idsDir, "page-broken.json"), `{`)
+
+	var out bytes.Buffer
+	printPageSuggestions(storePath, &out)
+	if want := "c\na:Alpha\nb:beta\n"; out.String() != want {
+		t.Errorf("printPageSuggestions() = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/toml/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+const (
+	// flagConfig is the global flag name for the config file.
+	flagConfig = "config"
+	// configKeyDelimiter separates the levels of flattened config file keys. Folder names may hold dots, but not
+	// slashes.
+	configKeyDelimiter = "/"
+)
+
+// configFileNames are the config files looked up in the working directory when --config is not given.
+var configFileNames = []string{"ntnsync.yaml", "ntnsync.yml", "ntnsync.toml"}
+
+// configFileEnvAliases are the environment variables of config file keys that don't follow the NTN_ naming.
+var configFileEnvAliases = map[string]string{
+	"NTN_NOTION_TOKEN": "NOTION_TOKEN",
+}
+
+// configFolderSettings are the settings of the "folders" section, by folder name.
+type configFolderSettings struct {
+	profile     string
+	maxPages    string
+	maxSize     string
+	exclude     string
+	owners      string
+	postprocess string
+}
+
+// loadConfigFile applies the settings of the config file (--config, or the first of configFileNames in the
+// working directory) as environment variables. Keys are environment variable names without their NTN_ prefix,
+// in lower case and optionally nested: "git: {url: ...}" sets NTN_GIT_URL. The "folders" section holds
+// per-folder settings (profile, max_pages, max_size, exclude, owners, postprocess), merged into NTN_FOLDER_PROFILES,
+// NTN_FOLDER_QUOTAS, NTN_FOLDER_EXCLUDE, NTN_CODEOWNERS and NTN_FOLDER_POSTPROCESS.
+// Environment variables that are already set win over the file, and flags over both.
+func loadConfigFile(cmd *cli.Command) error {
+	path := cmd.String(flagConfig)
+	if path == "" {
+		path = findConfigFile()
+		if path == "" {
+			return nil
+		}
+	}
+
+	var parser koanf.Parser
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parser = yaml.Parser()
+	case ".toml":
+		parser = toml.Parser()
+	default:
+		return fmt.Errorf("%w: %s (expected .yaml, .yml or .toml)", apperrors.ErrUnknownConfigFormat, path)
+	}
+
+	konf := koanf.New(configKeyDelimiter)
+	if err := konf.Load(file.Provider(path), parser); err != nil {
+		return fmt.Errorf("load config file %s: %w", path, err)
+	}
+
+	applied := make(map[string]string)
+	for name, value := range configFileEnv(konf.All()) {
+		if _, set := os.LookupEnv(name); set {
+			continue // The environment wins over the file
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("set %s: %w", name, err)
+		}
+		applied[name] = value
+	}
+	sync.ResetConfig()
+
+	// The flags of this command were parsed before the file was loaded
+	for _, flag := range cmd.Flags {
+		name := flag.Names()[0]
+		docFlag, ok := flag.(cli.DocGenerationFlag)
+		if !ok || cmd.IsSet(name) {
+			continue
+		}
+		for _, envVar := range docFlag.GetEnvVars() {
+			if value, ok := applied[envVar]; ok {
+				if err := cmd.Set(name, value); err != nil {
+					return fmt.Errorf("set --%s from the config file: %w", name, err)
+				}
+				break
+			}
+		}
+	}
+
+	slog.Debug("loaded config file", "path", path, "settings", len(applied))
+	return nil
+}
+
+// findConfigFile returns the first of configFileNames that exists in the working directory, or "".
+func findConfigFile() string {
+	for _, name := range configFileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// configFileEnv converts the flattened keys of a config file to environment variables.
+func configFileEnv(values map[string]any) map[string]string {
+	env := make(map[string]string, len(values))
+	folders := make(map[string]*configFolderSettings)
+
+	for key, value := range values {
+		if rest, ok := strings.CutPrefix(key, "folders"+configKeyDelimiter); ok {
+			if folder, setting, ok := strings.Cut(rest, configKeyDelimiter); ok && folder != "" {
+				addConfigFolderSetting(folders, folder, setting, value)
+			}
+			continue
+		}
+
+		// Dotted keys ("git.url: ...") are nested keys too
+		name := "NTN_" + strings.ToUpper(strings.NewReplacer(configKeyDelimiter, "_", ".", "_").Replace(key))
+		if alias, ok := configFileEnvAliases[name]; ok {
+			name = alias
+		}
+		env[name] = configValueString(value)
+	}
+
+	var profiles, quotas, excludes, owners, postprocess []string
+	for _, folder := range slices.Sorted(maps.Keys(folders)) {
+		settings := folders[folder]
+		if settings.profile != "" {
+			profiles = append(profiles, folder+"="+settings.profile)
+		}
+		if settings.maxPages != "" || settings.maxSize != "" {
+			quota := folder + "=" + settings.maxPages
+			if settings.maxSize != "" {
+				quota += "/" + settings.maxSize
+			}
+			quotas = append(quotas, quota)
+		}
+		if settings.exclude != "" {
+			excludes = append(excludes, folder+"="+settings.exclude)
+		}
+		if settings.owners != "" {
+			owners = append(owners, folder+"="+settings.owners)
+		}
+		if settings.postprocess != "" {
+			postprocess = append(postprocess, folder+"="+settings.postprocess)
+		}
+	}
+	if len(profiles) > 0 {
+		env["NTN_FOLDER_PROFILES"] = strings.Join(profiles, ",")
+	}
+	if len(quotas) > 0 {
+		env["NTN_FOLDER_QUOTAS"] = strings.Join(quotas, ",")
+	}
+	if len(excludes) > 0 {
+		env["NTN_FOLDER_EXCLUDE"] = strings.Join(excludes, ",")
+	}
+	if len(owners) > 0 {
+		env["NTN_CODEOWNERS"] = strings.Join(owners, ",")
+	}
+	if len(postprocess) > 0 {
+		env["NTN_FOLDER_POSTPROCESS"] = strings.Join(postprocess, ",")
+	}
+
+	return env
+}
+
+// addConfigFolderSetting records a setting of the "folders" section. Unknown settings are ignored.
+// The exclusion rules and post-processors are a list, or a string of items separated by "|". The owners are a list,
+// or a string of owners separated by spaces.
+func addConfigFolderSetting(folders map[string]*configFolderSettings, folder, setting string, value any) {
+	settings, ok := folders[folder]
+	if !ok {
+		settings = &configFolderSettings{}
+		folders[folder] = settings
+	}
+	switch setting {
+	case "profile":
+		settings.profile = configValueString(value)
+	case "max_pages":
+		settings.maxPages = configValueString(value)
+	case "max_size":
+		settings.maxSize = configValueString(value)
+	case "exclude":
+		settings.exclude = configPipeList(value)
+	case "postprocess":
+		settings.postprocess = configPipeList(value)
+	case "owners":
+		settings.owners = strings.ReplaceAll(configValueString(value), ",", " ")
+	default:
+		slog.Warn("unknown folder setting in the config file", "folder", folder, "setting", setting)
+	}
+}
+
+// configPipeList formats a list of a config file value as items separated by "|", or a string as is.
+func configPipeList(value any) string {
+	list, ok := value.([]any)
+	if !ok {
+		return configValueString(value)
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = configScalarString(item)
+	}
+	return strings.Join(items, "|")
+}
+
+// configValueString formats a config file value like the environment variable it sets: lists are
+// comma-separated.
+func configValueString(value any) string {
+	if list, ok := value.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = configScalarString(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return configScalarString(value)
+}
+
+// configScalarString formats a config file scalar. Floats are written without exponent (1000000, not 1e+06), as
+// parsers load numbers like 1e6 or 1_000_000.0 as floats.
+func configScalarString(value any) string {
+	switch number := value.(type) {
+	case float64:
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(number), 'f', -1, 32)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+func TestConfigFileEnv(t *testing.T) {
+	t.Parallel()
+
+	env := configFileEnv(map[string]any{
+		"commit":                            true,
+		"git/url":                           "git@example.com:acme/mirror.git",
+		"webhook.port":                      8080,
+		"notion/token":                      "secret",
+		"max_pages":                         float64(1000000),
+		"queue_delay_factor":                0.5,
+		"inline_database_columns":           []any{"Status", "Owner"},
+		"folders/tech/profile":              "mkdocs",
+		"folders/tech/max_pages":            float64(5000),
+		"folders/tech/max_size":             "500MB",
+		"folders/tech/exclude":              []any{"title:Draft*", "databases"},
+		"folders/tech/owners":               []any{"@acme/engineering", "@acme/docs"},
+		"folders/docs.v2/profile":           "github",
+		"folders/docs.v2/postprocess":       "strip-comments",
+		"folders/release.2024.01/max_pages": 10,
+	})
+
+	want := map[string]string{
+		"NTN_COMMIT":                  "true",
+		"NTN_GIT_URL":                 "git@example.com:acme/mirror.git",
+		"NTN_WEBHOOK_PORT":            "8080",
+		"NOTION_TOKEN":                "secret",
+		"NTN_MAX_PAGES":               "1000000",
+		"NTN_QUEUE_DELAY_FACTOR":      "0.5",
+		"NTN_INLINE_DATABASE_COLUMNS": "Status,Owner",
+		"NTN_FOLDER_PROFILES":         "docs.v2=github,tech=mkdocs",
+		"NTN_FOLDER_QUOTAS":           "release.2024.01=10,tech=5000/500MB",
+		"NTN_FOLDER_EXCLUDE":          "tech=title:Draft*|databases",
+		"NTN_CODEOWNERS":              "tech=@acme/engineering @acme/docs",
+		"NTN_FOLDER_POSTPROCESS":      "docs.v2=strip-comments",
+	}
+	for name, value := range want {
+		if env[name] != value {
+			t.Errorf("%s = %q, want %q", name, env[name], value)
+		}
+	}
+	if len(env) != len(want) {
+		t.Errorf("configFileEnv() = %v, want %d variables", env, len(want))
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "ntnsync.yaml")
+	config := `git:
+  url: git@example.com:acme/mirror.git
+  branch: from-file
+max_pages: 1e6
+max_files: 30
+folders:
+  docs.v2:
+    profile: github
+`
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	configEnv := []string{"NTN_GIT_URL", "NTN_GIT_BRANCH", "NTN_MAX_PAGES", "NTN_MAX_FILES", "NTN_FOLDER_PROFILES"}
+	for _, name := range configEnv {
+		t.Setenv(name, "")
+		if err := os.Unsetenv(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("NTN_GIT_BRANCH", "from-env")
+
+	cmd := &cli.Command{
+		Name: "sync",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: flagConfig},
+			&cli.StringFlag{Name: "branch", Value: "main", Sources: cli.EnvVars("NTN_GIT_BRANCH")},
+			&cli.IntFlag{Name: "max-pages", Value: 10, Sources: cli.EnvVars("NTN_MAX_PAGES")},
+			&cli.IntFlag{Name: "max-files", Value: 10, Sources: cli.EnvVars("NTN_MAX_FILES")},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return loadConfigFile(cmd)
+		},
+	}
+	if err := cmd.Run(context.Background(), []string{"sync", "--config", configPath, "--max-files", "5"}); err != nil {
+		t.Fatalf("loadConfigFile() error = %v", err)
+	}
+
+	// The environment wins over the file, and the file over defaults
+	for name, want := range map[string]string{
+		"NTN_GIT_URL":         "git@example.com:acme/mirror.git",
+		"NTN_GIT_BRANCH":      "from-env",
+		"NTN_MAX_PAGES":       "1000000",
+		"NTN_FOLDER_PROFILES": "docs.v2=github",
+	} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	// Flags are set again from the settings of the file, unless given on the command line
+	if got := cmd.String("branch"); got != "from-env" {
+		t.Errorf("--branch = %q, want the environment", got)
+	}
+	if got := cmd.Int("max-pages"); got != 1000000 {
+		t.Errorf("--max-pages = %d, want the config file", got)
+	}
+	if got := cmd.Int("max-files"); got != 5 {
+		t.Errorf("--max-files = %d, want the command line", got)
+	}
+}
@@ -2,15 +2,21 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/queue"
 	"github.com/fclairamb/ntnsync/internal/store"
 	"github.com/fclairamb/ntnsync/internal/sync"
+	"github.com/fclairamb/ntnsync/internal/webhook"
 )
 
 const (
@@ -20,6 +26,23 @@ const (
 	daysPerMonth = 30
 )
 
+// printJSON prints a command result as indented JSON, for --output json.
+func printJSON(result any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("encode result: %w", err)
+	}
+	return nil
+}
+
+// cleanupOutput is the JSON output of the cleanup command.
+type cleanupOutput struct {
+	*sync.CleanupResult
+	DryRun bool              `json:"dry_run"`
+	Prune  *sync.PruneResult `json:"prune,omitempty"`
+}
+
 // printPageFlat prints a page in flat list format.
 //
 //nolint:forbidigo // CLI user output function
@@ -30,10 +53,11 @@ func printPageFlat(page *sync.PageInfo) {
 		orphanedMark = " (ORPHANED - parent deleted)"
 	}
 
-	fmt.Printf("  %s - \"%s\" (last synced: %s)%s\n",
+	fmt.Printf("  %s - \"%s\" (last synced: %s)%s%s\n",
 		page.Path,
 		page.Title,
 		timeSince,
+		formatOpenComments(page.OpenComments),
 		orphanedMark)
 }
 
@@ -62,11 +86,12 @@ func printPageTree(page *sync.PageInfo, prefix string, isLast bool) {
 		filename = page.Path[idx+1:]
 	}
 
-	fmt.Printf("%s%s - \"%s\" (last synced: %s)%s\n",
+	fmt.Printf("%s%s - \"%s\" (last synced: %s)%s%s\n",
 		prefix+branch,
 		filename,
 		page.Title,
 		timeSince,
+		formatOpenComments(page.OpenComments),
 		orphanedMark)
 
 	// Print children
@@ -75,6 +100,18 @@ func printPageTree(page *sync.PageInfo, prefix string, isLast bool) {
 	}
 }
 
+// formatOpenComments returns the mark of pages with unresolved comments, or an empty string.
+func formatOpenComments(count int) string {
+	switch {
+	case count == 1:
+		return " [1 open comment]"
+	case count > 1:
+		return fmt.Sprintf(" [%d open comments]", count)
+	default:
+		return ""
+	}
+}
+
 // displayFolderStatus displays status for a specific folder.
 //
 //nolint:forbidigo // CLI user output function
@@ -190,12 +227,260 @@ func displayQueueSummary(status *sync.StatusInfo) {
 	}
 }
 
+// displayBlockedPages displays the blocked pages summary, or their details when requested.
+//
+//nolint:forbidigo // CLI user output function
+func displayBlockedPages(status *sync.StatusInfo, details bool) {
+	if len(status.BlockedPages) == 0 {
+		if details {
+			fmt.Println("\nBlocked: none")
+		}
+		return
+	}
+
+	if !details {
+		fmt.Printf("\nBlocked: %d pages (use --blocked to list them)\n", len(status.BlockedPages))
+		return
+	}
+
+	slices.SortFunc(status.BlockedPages, func(a, b *sync.BlockedInfo) int {
+		return a.BlockedAt.Compare(b.BlockedAt)
+	})
+
+	fmt.Printf("\nBlocked: %d pages\n", len(status.BlockedPages))
+	for _, page := range status.BlockedPages {
+		fmt.Printf("  - %s [%s] %s (%s)\n", page.ID, page.Folder, page.Reason, formatTimeSince(page.BlockedAt))
+		if page.BlockedBy != "" {
+			fmt.Printf("      blocked by: %s\n", page.BlockedBy)
+		}
+		if page.Error != "" {
+			fmt.Printf("      error: %s\n", page.Error)
+		}
+	}
+}
+
+// displayPendingReviews displays the pages held back by the content loss guard.
+//
+//nolint:forbidigo // CLI user output function
+func displayPendingReviews(status *sync.StatusInfo) {
+	if len(status.PendingReviews) == 0 {
+		return
+	}
+
+	fmt.Printf("\nPending review: %d pages (suspicious content loss)\n", len(status.PendingReviews))
+	for _, path := range status.PendingReviews {
+		fmt.Printf("  - %s\n", path)
+	}
+}
+
+// displayQuotaWarnings displays the folders that reached their quota.
+//
+//nolint:forbidigo // CLI user output function
+func displayQuotaWarnings(status *sync.StatusInfo) {
+	var overQuota []*sync.FolderStatus
+	for _, folderStatus := range status.Folders {
+		if folderStatus.OverQuota {
+			overQuota = append(overQuota, folderStatus)
+		}
+	}
+	if len(overQuota) == 0 {
+		return
+	}
+
+	slices.SortFunc(overQuota, func(a, b *sync.FolderStatus) int { return strings.Compare(a.Name, b.Name) })
+
+	fmt.Printf("\nOver quota: %d folders (new pages are not queued)\n", len(overQuota))
+	for _, folderStatus := range overQuota {
+		fmt.Printf("  - %s: %d pages", folderStatus.Name, folderStatus.PageCount)
+		if folderStatus.Quota.MaxPages > 0 {
+			fmt.Printf(" (max %d)", folderStatus.Quota.MaxPages)
+		}
+		fmt.Printf(", %s", sync.FormatBytes(folderStatus.TotalBytes))
+		if folderStatus.Quota.MaxBytes > 0 {
+			fmt.Printf(" (max %s)", sync.FormatBytes(folderStatus.Quota.MaxBytes))
+		}
+		fmt.Println()
+	}
+}
+
+// displayMirrorSize displays the size of the mirror, if it has a size cap.
+//
+//nolint:forbidigo // CLI user output function
+func displayMirrorSize(status *sync.StatusInfo) {
+	if status.MaxMirrorSize <= 0 {
+		return
+	}
+
+	fmt.Printf("\nMirror size: %s (max %s)\n", sync.FormatBytes(status.MirrorSize), sync.FormatBytes(status.MaxMirrorSize))
+	if status.MirrorSize >= status.MaxMirrorSize {
+		fmt.Println("  Size cap reached: new pages are not queued")
+		if len(status.SuggestedExclusions) > 0 {
+			fmt.Printf("  Consider excluding the largest folders: %s\n", strings.Join(status.SuggestedExclusions, ", "))
+		}
+	}
+}
+
+// displayCrawlProgress displays the progress of the incremental deep crawl, if one was run.
+//
+//nolint:forbidigo // CLI user output function
+func displayCrawlProgress(status *sync.StatusInfo) {
+	crawl := status.Crawl
+	if crawl == nil {
+		return
+	}
+
+	if crawl.CompletedAt != nil {
+		fmt.Printf("\nCrawl: completed %s, %d pages synced in %d runs\n",
+			formatTimeSince(*crawl.CompletedAt), crawl.Pages, crawl.Runs)
+		return
+	}
+	fmt.Printf("\nCrawl: started %s, %d pages synced in %d runs, %d pages queued\n",
+		formatTimeSince(crawl.StartedAt), crawl.Pages, crawl.Runs, crawl.Queued)
+	fmt.Printf("  Last run: %s, stopped by %s\n", formatTimeSince(crawl.LastRunAt), crawl.LastStop)
+	fmt.Printf("  Average page: %s, %.1f API calls\n", formatDuration(crawl.PageTime), crawl.PageCalls)
+}
+
+// displayQueueLimits displays the effective queue limits, and warnings about queue files created with other ones.
+//
+//nolint:forbidigo // CLI user output function
+func displayQueueLimits(status *sync.StatusInfo) {
+	limits := status.QueueLimits
+	fmt.Printf("\nQueue limits: batch size %d, webhook threshold %d", limits.BatchSize, limits.WebhookThreshold)
+	if limits.WebhookWindow > 0 {
+		fmt.Printf(", webhook window %s (up to %d pages)", limits.WebhookWindow,
+			cmp.Or(limits.WebhookMaxPages, limits.BatchSize))
+	}
+	fmt.Println()
+	for _, warning := range status.QueueWarnings {
+		fmt.Printf("  Warning: %s\n", warning)
+	}
+}
+
+// displayPerf displays the pipeline metrics of the last sync runs, as p50/p95 durations per phase.
+//
+//nolint:forbidigo // CLI user output function
+func displayPerf(status *sync.StatusInfo) {
+	if len(status.Perf) == 0 {
+		fmt.Println("\nPipeline metrics: no sync run recorded yet")
+		return
+	}
+
+	fmt.Printf("\nPipeline metrics of the last %d runs (p50 / p95):\n", len(status.Perf))
+	fmt.Printf("  %-20s %6s %9s", "Run", "Pages", "API calls")
+	for _, phase := range sync.PerfPhases {
+		fmt.Printf(" %21s", phase)
+	}
+	fmt.Printf(" %15s\n", "Throttled (429)")
+
+	for _, run := range status.Perf {
+		fmt.Printf("  %-20s %6d %9d", formatTime(run.StartedAt), run.Pages, sumCounts(run.APICalls))
+		for _, phase := range sync.PerfPhases {
+			stats := run.Phases[phase]
+			fmt.Printf(" %21s", formatDuration(stats.P50)+" / "+formatDuration(stats.P95))
+		}
+		fmt.Printf(" %15s\n", fmt.Sprintf("%s (%d)", formatDuration(run.Throttled), run.RateLimited))
+	}
+
+	last := status.Perf[len(status.Perf)-1]
+	if last.ParentHits > 0 {
+		fmt.Printf("\nParent lookups answered from the cache in the last run: %d (%d API calls avoided)\n",
+			last.ParentHits, last.AvoidedCalls)
+	}
+	if len(last.HeaviestPages) > 0 {
+		fmt.Printf("\nPages making the most API calls in the last run:\n")
+		for _, page := range last.HeaviestPages {
+			fmt.Printf("  %s %5d  %s\n", page.PageID, page.Total, formatCounts(page.Calls))
+		}
+	}
+}
+
+// sumCounts returns the sum of counts.
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+// formatCounts formats counts by name, sorted by name (e.g. "block_children=12, page=1").
+func formatCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// displayPruneResults displays the pages pruned to keep the mirror under its size cap.
+//
+//nolint:forbidigo // CLI user output function
+func displayPruneResults(result *sync.PruneResult, dryRun bool) {
+	fmt.Printf("\nPrune Results:\n")
+	fmt.Printf("  Mirror size: %s -> %s\n", sync.FormatBytes(result.SizeBefore), sync.FormatBytes(result.SizeAfter))
+	fmt.Printf("  Pages pruned: %d\n", len(result.PrunedPages))
+	for _, reg := range result.PrunedPages {
+		fmt.Printf("    - %s (%s, last edited %s)\n", reg.FilePath, sync.FormatBytes(reg.Size),
+			reg.LastEdited.Format(time.DateOnly))
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run - no changes were made\n")
+	}
+}
+
+// displaySyncPlan displays the files a sync would write or delete.
+//
+//nolint:forbidigo // CLI user output function
+func displaySyncPlan(plan *sync.SyncPlan) {
+	counts := map[string]int{}
+	for _, file := range plan.Files {
+		counts[file.Action]++
+	}
+
+	fmt.Printf("\nSync Plan:\n")
+	fmt.Printf("  Queue files: %d\n", plan.QueueFiles)
+	fmt.Printf("  Pages: %d\n", plan.Pages)
+	fmt.Printf("  Files to create: %d\n", counts[sync.PlanActionCreate])
+	fmt.Printf("  Files to update: %d\n", counts[sync.PlanActionUpdate])
+	fmt.Printf("  Files to delete: %d\n", counts[sync.PlanActionDelete])
+	for _, file := range plan.Files {
+		line := fmt.Sprintf("    %-6s %s", file.Action, file.Path)
+		if file.Reason != "" {
+			line += " (" + file.Reason + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("  Pages skipped: %d\n", plan.Skipped)
+	fmt.Printf("  Pages deferred: %d\n", plan.Deferred)
+	fmt.Printf("  Pages dropped: %d\n", plan.Dropped)
+	if plan.Failed > 0 {
+		fmt.Printf("  Pages failed: %d\n", plan.Failed)
+	}
+	fmt.Printf("  API calls: %d made, at least %d to sync\n", plan.APICalls, plan.EstimatedAPICalls)
+
+	fmt.Printf("\nDry run - no changes were made\n")
+}
+
+// displaySimulateResult displays the event simulated by the webhook server.
+//
+//nolint:forbidigo // CLI user output function
+func displaySimulateResult(result *webhook.SimulateResponse) {
+	fmt.Printf("Simulated %s event %s for %s %s\n", result.EventType, result.EventID, result.EntityType, result.EntityID)
+	if result.Signed {
+		fmt.Println("Signature: computed and verified with the webhook secret")
+	} else {
+		fmt.Println("Signature: not verified")
+	}
+}
+
 // displayCleanupResults displays the results of a cleanup operation.
 //
 //nolint:forbidigo // CLI user output function
 func displayCleanupResults(result *sync.CleanupResult, dryRun bool) {
 	fmt.Printf("\nCleanup Results:\n")
 	fmt.Printf("  Orphaned pages found: %d\n", result.OrphanedPages)
+	fmt.Printf("  Unused assets found: %d\n", result.UnusedAssets)
 
 	if dryRun {
 		fmt.Printf("\nDry run - no changes were made\n")
@@ -205,12 +490,257 @@ func displayCleanupResults(result *sync.CleanupResult, dryRun bool) {
 	}
 }
 
+// displayQueueMigration displays the queue files converted by "queue migrate".
+//
+//nolint:forbidigo // CLI user output function
+func displayQueueMigration(migrated []string, dryRun bool) {
+	if len(migrated) == 0 {
+		fmt.Printf("All queue files use the current format\n")
+		return
+	}
+	verb := "Converted"
+	if dryRun {
+		verb = "To convert"
+	}
+	fmt.Printf("%s (%d):\n", verb, len(migrated))
+	for _, filename := range migrated {
+		fmt.Printf("  %s\n", filename)
+	}
+}
+
+// displayBrowseQueued prints the number of pages queued for re-sync by the browse command.
+//
+//nolint:forbidigo // CLI user output function
+func displayBrowseQueued(queued int) {
+	fmt.Printf("Queued %d pages for re-sync, run 'ntnsync sync' to fetch them.\n", queued)
+}
+
+// displayQueueEntry prints a queue file and its pages.
+//
+//nolint:forbidigo // CLI user output function
+func displayQueueEntry(filename string, entry *queue.Entry) {
+	fmt.Printf("%s: %s, folder %s, created %s\n",
+		filename, entry.Type, entry.Folder, entry.CreatedAt.Format(time.RFC3339))
+	if entry.ParentID != "" {
+		fmt.Printf("Parent: %s\n", entry.ParentID)
+	}
+	fmt.Printf("Pages (%d):\n", len(entry.Pages))
+	for _, page := range entry.Pages {
+		line := "  " + page.ID
+		if page.Failures > 0 {
+			line += fmt.Sprintf(" (%d failures, not before %s)", page.Failures, page.NotBefore.Format(time.RFC3339))
+		}
+		fmt.Println(line)
+	}
+}
+
+// displayRegistryImport prints the counts of a registry import.
+//
+//nolint:forbidigo // CLI user output function
+func displayRegistryImport(result *sync.RegistryImportResult, dryRun bool) {
+	verb := "Imported"
+	if dryRun {
+		verb = "To import"
+	}
+	fmt.Printf("%s: %d pages, %d files, %d users, %d blocked\n",
+		verb, result.Pages, result.Files, result.Users, result.Blocked)
+	if result.Removed > 0 {
+		verb = "Removed"
+		if dryRun {
+			verb = "To remove"
+		}
+		fmt.Printf("%s: %d registries absent from the bundle\n", verb, result.Removed)
+	}
+}
+
+// displayDiffs prints the diffs of pages, and logs the pages that could not be diffed.
+//
+//nolint:forbidigo // CLI user output function
+func displayDiffs(ctx context.Context, diffs []*sync.PageDiff) {
+	changed := 0
+	for _, pageDiff := range diffs {
+		if pageDiff.Err != nil {
+			slog.WarnContext(ctx, "failed to diff page",
+				"page_id", pageDiff.PageID, "path", pageDiff.FilePath, "error", pageDiff.Err)
+			continue
+		}
+		fmt.Print(pageDiff.Diff)
+		changed++
+	}
+	// Logged, so that the output remains a patch
+	slog.InfoContext(ctx, "diff complete", "changed_pages", changed)
+}
+
+// displayVerifyResults displays the results of a verification.
+//
+//nolint:forbidigo // CLI user output function
+func displayVerifyResults(result *sync.VerifyResult) {
+	fmt.Printf("\nVerify Results:\n")
+	fmt.Printf("  Pages verified: %d\n", result.Checked)
+	fmt.Printf("  Pages skipped: %d\n", result.Skipped)
+	fmt.Printf("  Fetch errors: %d\n", len(result.Errors))
+
+	if len(result.Mismatches) > 0 {
+		fmt.Printf("\nMismatches (%d):\n", len(result.Mismatches))
+		for _, mismatch := range result.Mismatches {
+			if mismatch.Line > 0 {
+				fmt.Printf("  %s: %s (line %d)\n", mismatch.FilePath, mismatch.Reason, mismatch.Line)
+			} else {
+				fmt.Printf("  %s: %s\n", mismatch.FilePath, mismatch.Reason)
+			}
+		}
+	}
+
+	for pageID, errMsg := range result.Errors {
+		fmt.Printf("  Error %s: %s\n", pageID, errMsg)
+	}
+}
+
+// displayAdoptResults displays the results of an adoption.
+//
+//nolint:forbidigo // CLI user output function
+func displayAdoptResults(result *sync.AdoptResult, dryRun bool) {
+	fmt.Printf("\nAdopt Results:\n")
+	fmt.Printf("  Pages adopted: %d\n", len(result.Adopted))
+	fmt.Printf("  Already tracked: %d\n", result.Tracked)
+	fmt.Printf("  Files without Notion ID: %d\n", len(result.NoID))
+
+	if len(result.Duplicates) > 0 {
+		fmt.Printf("\nDuplicate pages, kept the first file (%d):\n", len(result.Duplicates))
+		for _, filePath := range result.Duplicates {
+			fmt.Printf("  %s\n", filePath)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run - no changes were made\n")
+	}
+}
+
+// displayRelinkResults displays the results of a relink pass.
+//
+//nolint:forbidigo // CLI user output function
+func displayRelinkResults(result *sync.RelinkResult, dryRun bool) {
+	fmt.Printf("\nRelink Results:\n")
+	fmt.Printf("  Files scanned: %d\n", result.Files)
+	fmt.Printf("  Links rewritten: %d\n", result.Links)
+	fmt.Printf("  Links to pages not synced: %d\n", result.Unresolved)
+
+	if len(result.Changed) > 0 {
+		fmt.Printf("\nFiles changed (%d):\n", len(result.Changed))
+		for _, filePath := range result.Changed {
+			fmt.Printf("  %s\n", filePath)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run - no changes were made\n")
+	}
+}
+
+// displayPurgeResults displays the result of purging a page.
+//
+//nolint:forbidigo // CLI user output function
+func displayPurgeResults(result *sync.PurgeResult, dryRun bool) {
+	fmt.Printf("\nPurge Results:\n")
+	fmt.Printf("  Page: %s (%s)\n", result.Page.ID, result.Page.FilePath)
+
+	fmt.Printf("\nFiles deleted (%d):\n", len(result.Deleted))
+	for _, path := range result.Deleted {
+		fmt.Printf("  %s\n", path)
+	}
+
+	fmt.Printf("\nPaths removed from history with --rewrite-history (%d):\n", len(result.Paths))
+	for _, path := range result.Paths {
+		fmt.Printf("  %s\n", path)
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run - no changes were made\n")
+	}
+}
+
+// displayHistoryRewrite displays the result of a history rewrite.
+//
+//nolint:forbidigo // CLI user output function
+func displayHistoryRewrite(result *store.PurgeHistoryResult, dryRun bool) {
+	fmt.Printf("\nHistory Rewrite (branch %s):\n", result.Branch)
+	fmt.Printf("  Commits: %d\n", result.Commits)
+	fmt.Printf("  Commits containing the page: %d\n", result.Purged)
+	fmt.Printf("  Commits rewritten: %d\n", result.Rewritten)
+	fmt.Printf("  Head: %s -> %s\n", shortHash(result.OldHead), shortHash(result.NewHead))
+
+	if dryRun {
+		fmt.Printf("\nDry run - the history was not rewritten\n")
+	}
+}
+
+// displayPurgeWarning displays the consequences of a history rewrite.
+//
+//nolint:forbidigo // CLI user output function
+func displayPurgeWarning() {
+	fmt.Printf("\n!!! WARNING: rewriting the history is irreversible, and replaces the history of the remote branch !!!\n")
+	fmt.Printf("  - Stop 'serve' and scheduled syncs first: a pull before the force push merges the old history back\n")
+	fmt.Printf("  - Every other clone of the mirror must be cloned again\n")
+	fmt.Printf("  - Forks, caches and pull requests of the git host may still hold the content\n")
+	fmt.Printf("  - Links to the page in other files (parent page, indexes) are not rewritten\n")
+	fmt.Printf("  - Secrets the page contained must be rotated anyway\n")
+}
+
+// displayHistoryRetained displays how to remove the former history that a reference still holds.
+//
+//nolint:forbidigo // CLI user output function
+func displayHistoryRetained(storePath, oldHead string) {
+	fmt.Printf("\n!!! The purge is incomplete: a reference still holds the former history of the branch !!!\n")
+	fmt.Printf("  - List the references holding it: git -C %s for-each-ref --contains %s\n", storePath, oldHead)
+	fmt.Printf("  - The remote-tracking branch is replaced by: ntnsync remote push --force\n")
+	fmt.Printf("  - Delete the other references, then run: git -C %s reflog expire --expire=now --all && "+
+		"git -C %s gc --prune=now\n", storePath, storePath)
+}
+
+// shortHash returns the abbreviated form of a commit hash.
+func shortHash(hash string) string {
+	const shortHashLength = 7
+	if len(hash) > shortHashLength {
+		return hash[:shortHashLength]
+	}
+	return hash
+}
+
+// displayLinkCheckResults displays the results of a link check.
+//
+//nolint:forbidigo // CLI user output function
+func displayLinkCheckResults(result *sync.LinkCheckResult, dryRun bool) {
+	fmt.Printf("\nLink Check Results:\n")
+	fmt.Printf("  Links checked: %d\n", result.Checked)
+	fmt.Printf("  Links skipped: %d\n", result.Skipped)
+	fmt.Printf("  Check errors: %d\n", result.Errors)
+
+	if len(result.Dead) > 0 {
+		fmt.Printf("\nDead links (%d):\n", len(result.Dead))
+		for _, link := range result.Dead {
+			fmt.Printf("  %s: %s (HTTP %d)\n", link.FilePath, link.URL, link.HTTPStatus)
+		}
+	}
+
+	if len(result.Redirected) > 0 {
+		fmt.Printf("\nRedirected links (%d):\n", len(result.Redirected))
+		for _, link := range result.Redirected {
+			fmt.Printf("  %s: %s -> %s\n", link.FilePath, link.URL, link.FinalURL)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run - report not saved\n")
+	}
+}
+
 // displayPullResults displays the results of a pull operation.
 //
 //nolint:forbidigo // CLI user output function
 func displayPullResults(result *sync.PullResult, showAll, dryRun bool) {
 	fmt.Printf("\nPull Results:\n")
-	fmt.Printf("  Cutoff time: %s\n", result.CutoffTime.Format(time.RFC3339))
+	fmt.Printf("  Cutoff time: %s\n", formatTime(result.CutoffTime))
 	fmt.Printf("  Pages found: %d\n", result.PagesFound)
 	fmt.Printf("  Pages queued: %d\n", result.PagesQueued)
 	if showAll {
@@ -218,6 +748,11 @@ func displayPullResults(result *sync.PullResult, showAll, dryRun bool) {
 		fmt.Printf("    - Updated pages: %d\n", result.UpdatedPages)
 	}
 	fmt.Printf("  Pages skipped: %d\n", result.PagesSkipped)
+	for _, reason := range sync.PullSkipReasons {
+		if count := result.SkippedByReason[reason]; count > 0 {
+			fmt.Printf("    - %s: %d\n", strings.ReplaceAll(reason, "_", " "), count)
+		}
+	}
 
 	if dryRun {
 		fmt.Printf("\nDry run - no changes were made\n")
@@ -241,6 +776,25 @@ func displayRemoteConfig(cfg *store.RemoteConfig) {
 		fmt.Printf("Storage:  %s\n", effectiveMode)
 	}
 
+	if effectiveMode == store.StorageModeS3 {
+		if cfg.S3 == nil {
+			fmt.Println("\nS3: not configured (set NTN_S3_BUCKET)")
+			return
+		}
+		fmt.Printf("Bucket:   %s\n", cfg.S3.Bucket)
+		if cfg.S3.Prefix != "" {
+			fmt.Printf("Prefix:   %s\n", cfg.S3.Prefix)
+		}
+		if cfg.S3.Region != "" {
+			fmt.Printf("Region:   %s\n", cfg.S3.Region)
+		}
+		if cfg.S3.Endpoint != "" {
+			fmt.Printf("Endpoint: %s (path-style requests)\n", cfg.S3.Endpoint)
+		}
+		fmt.Println("\nGit operations disabled (files are written to the bucket)")
+		return
+	}
+
 	if effectiveMode == store.StorageModeLocal {
 		fmt.Println("\nRemote operations disabled (local-only mode)")
 		if cfg.URL != "" {
@@ -265,6 +819,9 @@ func displayRemoteConfig(cfg *store.RemoteConfig) {
 		}
 	}
 	fmt.Printf("Branch:   %s\n", cfg.Branch)
+	if cfg.Subdir != "" {
+		fmt.Printf("Subdir:   %s (monorepo mode, only files under it are written and committed)\n", cfg.Subdir)
+	}
 	if cfg.HasQueueBranch() {
 		fmt.Printf("Queue:    %s (separate branch for .notion-sync/queue)\n", cfg.QueueBranch)
 	}
@@ -292,11 +849,32 @@ func displayConnectionTest(ctx context.Context, cfg *store.RemoteConfig) error {
 	return nil
 }
 
+// displayPendingPush displays the pushes that failed and are waiting to be retried.
+//
+//nolint:forbidigo // CLI user output function
+func displayPendingPush(spool *store.PushSpool) {
+	if spool == nil {
+		fmt.Println("No pending push.")
+		return
+	}
+	fmt.Printf("Pending push: %d commits, failing since %s (%d attempts)\n",
+		len(spool.Commits), formatTimeSince(spool.Since), spool.Attempts)
+	fmt.Printf("Last error:   %s\n", spool.LastError)
+}
+
 // displayScanComplete displays the scan complete message.
 //
 //nolint:forbidigo // CLI user output function
-func displayScanComplete() {
-	fmt.Println("\nScan complete. Run 'sync' to download the queued child pages.")
+func displayScanComplete(result *sync.ScanResult) {
+	fmt.Println()
+	if result.RootsScanned > 0 {
+		fmt.Printf("Scanned %d root pages.\n", result.RootsScanned)
+	}
+	fmt.Printf("Scan complete: %d pages scanned, %d new, %d changed, %d unchanged subtrees skipped.\n",
+		result.PagesScanned, result.NewChildren, result.ChangedPages, result.SkippedSubtrees)
+	if result.NewChildren > 0 || result.ChangedPages > 0 {
+		fmt.Println("Run 'sync' to download the queued child pages.")
+	}
 }
 
 // displayNoFoldersMessage displays the no folders found message.
@@ -334,22 +912,28 @@ func displayPageList(folders []*sync.FolderInfo, tree bool) {
 	}
 }
 
-// commitTracker tracks time since last commit for periodic commits.
+// commitTracker tracks time since last commit for periodic commits, and the changes pending for chunked commits.
 type commitTracker struct {
-	lastCommit time.Time
-	period     time.Duration
+	lastCommit   time.Time
+	period       time.Duration
+	chunkReached func() bool // Whether enough changes are pending to commit a chunk (nil = no chunking)
 }
 
-// newCommitTracker creates a new commit tracker with the given period.
-func newCommitTracker(period time.Duration) *commitTracker {
+// newCommitTracker creates a new commit tracker with the given period (0 = no periodic commits) and
+// chunk check (nil = no chunked commits).
+func newCommitTracker(period time.Duration, chunkReached func() bool) *commitTracker {
 	return &commitTracker{
-		lastCommit: time.Now(),
-		period:     period,
+		lastCommit:   time.Now(),
+		period:       period,
+		chunkReached: chunkReached,
 	}
 }
 
-// shouldCommit returns true if enough time has passed since last commit.
+// shouldCommit returns true if enough time has passed since last commit, or enough changes are pending.
 func (t *commitTracker) shouldCommit() bool {
+	if t.chunkReached != nil && t.chunkReached() {
+		return true
+	}
 	if t.period == 0 {
 		return false
 	}
@@ -365,29 +949,83 @@ func (t *commitTracker) markCommitted() {
 func commitAndPush(
 	ctx context.Context, crawler *sync.Crawler, storeInst store.Store, cfg *store.RemoteConfig, reason string,
 ) error {
-	message := fmt.Sprintf("[ntnsync] %s at %s", reason, time.Now().Format(time.RFC3339))
+	crawler.SetRunPhase(ctx, sync.RunPhaseCommit)
+	message := fmt.Sprintf("[ntnsync] %s at %s", reason, formatTime(time.Now()))
 	if err := crawler.CommitChanges(ctx, message); err != nil {
 		slog.WarnContext(ctx, "failed to commit changes", "error", err, "reason", reason)
 		return nil // Don't fail the sync for commit errors
 	}
 
-	// Push if enabled
+	// Push if enabled. Failed pushes are spooled by the store to be retried later, but still fail the run.
 	if cfg.IsPushEnabled() {
 		if err := storeInst.Push(ctx); err != nil {
+			if _, spooled := storeInst.(store.PushSpooler); spooled {
+				return fmt.Errorf("push to remote (run 'remote push --retry-pending' to retry): %w", err)
+			}
 			return fmt.Errorf("push to remote: %w", err)
 		}
+		if err := crawler.NotifyPush(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to notify push", "error", err)
+		}
 	}
 
 	return nil
 }
 
+// processQueueWithCommits processes the queue, committing periodically (NTN_COMMIT_PERIOD) and in chunks
+// (NTN_COMMIT_MAX_FILES, NTN_COMMIT_MAX_SIZE) when enabled.
+func processQueueWithCommits(
+	ctx context.Context, crawler *sync.Crawler, storeInst store.Store, cfg *store.RemoteConfig, folder string,
+	maxPages, maxFiles, maxQueueFiles int, maxTime time.Duration,
+) error {
+	commitPeriod := cfg.GetCommitPeriod()
+	var chunkReached func() bool
+	if cfg.IsCommitEnabled() && sync.GetConfig().CommitChunking() {
+		chunkReached = crawler.CommitChunkReached
+	}
+	if commitPeriod <= 0 && chunkReached == nil {
+		return crawler.ProcessQueue(ctx, folder, maxPages, maxFiles, maxQueueFiles, maxTime)
+	}
+
+	// Commit periodically and in chunks from the progress hooks
+	tracker := newCommitTracker(commitPeriod, chunkReached)
+	crawler.SetProgressHooks(sync.ProgressHooks{
+		OnQueueFileDone: func(ctx context.Context, _ string) error {
+			if !tracker.shouldCommit() {
+				return nil
+			}
+			if err := commitAndPush(ctx, crawler, storeInst, cfg, "periodic sync"); err != nil {
+				return err
+			}
+			tracker.markCommitted()
+			return nil
+		},
+	})
+	defer crawler.SetProgressHooks(sync.ProgressHooks{})
+	return crawler.ProcessQueue(ctx, folder, maxPages, maxFiles, maxQueueFiles, maxTime)
+}
+
 // formatTimeSince formats a time duration in a human-readable way.
+// Times older than a day are followed by the absolute time.
 func formatTimeSince(t time.Time) string {
 	if t.IsZero() {
 		return "never"
 	}
 
-	duration := time.Since(t)
+	relative := formatRelativeTime(time.Since(t))
+	if time.Since(t) >= hoursPerDay*time.Hour {
+		return fmt.Sprintf("%s (%s)", relative, formatTime(t))
+	}
+	return relative
+}
+
+// formatTime formats an absolute time with the configured time zone and date format.
+func formatTime(t time.Time) string {
+	return sync.GetConfig().TimeFormat.Format(t)
+}
+
+// formatRelativeTime formats the time elapsed since an event in human-readable form.
+func formatRelativeTime(duration time.Duration) string {
 
 	switch {
 	case duration < time.Minute:
@@ -424,3 +1062,15 @@ func formatTimeSince(t time.Time) string {
 		return fmt.Sprintf("%d months ago", months)
 	}
 }
+
+// formatDuration formats a phase duration with a precision suited to its magnitude.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String() //nolint:mnd // Two decimals
+	case d >= time.Millisecond:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
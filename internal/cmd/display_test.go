@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+func TestCommitAndPush_SpooledPushFails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	remotePath := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := git.PlainInit(remotePath, true); err != nil {
+		t.Fatalf("failed to init remote: %v", err)
+	}
+	cfg := &store.RemoteConfig{
+		URL:      remotePath,
+		Password: "unused", // Only used for HTTPS, required for non-SSH URLs
+		Branch:   "main",
+		User:     "test",
+		Email:    "test@localhost",
+	}
+	st, err := store.NewLocalStore(filepath.Join(t.TempDir(), "local"), store.WithRemoteConfig(cfg))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	tx, _ := st.BeginTx(ctx)
+	_ = tx.Write(ctx, "page.md", []byte("# Page"))
+
+	// Make the remote unreachable
+	if err := os.Rename(remotePath, remotePath+".offline"); err != nil {
+		t.Fatalf("failed to move remote: %v", err)
+	}
+
+	// The push is spooled for a later retry, but the CLI run still fails
+	if err := commitAndPush(ctx, sync.NewCrawler(nil, st), st, cfg, "test"); err == nil {
+		t.Fatal("commitAndPush() to an unreachable remote should fail")
+	}
+	if spool, err := st.PendingPush(); err != nil || spool == nil {
+		t.Errorf("PendingPush() = %v, %v, want a pending push", spool, err)
+	}
+}
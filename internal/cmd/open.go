@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// openCommand creates the open subcommand.
+func openCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "open",
+		Usage:     "Print the Notion URL of a mirrored file or page ID, or the file of a Notion URL",
+		ArgsUsage: "<page_id|path|url>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "browser",
+				Aliases: []string{"b"},
+				Usage:   "Open the Notion page in the browser instead of printing its URL",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() < 1 {
+				return apperrors.ErrPageIDRequired
+			}
+			ref := cmd.Args().First()
+
+			storeInst, remoteConfig, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+			mirrorDir := filepath.Join(resolveStorePath(cmd), remoteConfig.Subdir)
+
+			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			isURL := strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+			if !isURL {
+				ref = mirrorRelativePath(mirrorDir, ref)
+			}
+			page, err := crawler.FindPage(ctx, ref)
+			if err != nil {
+				return err
+			}
+
+			// A Notion URL resolves to the file, anything else to the Notion page
+			if isURL {
+				_, _ = fmt.Fprintln(os.Stdout, filepath.Join(mirrorDir, filepath.FromSlash(page.Path)))
+				return nil
+			}
+			url := notionPageURLPrefix + page.ID
+			if cmd.Bool("browser") {
+				return openURL(ctx, url)
+			}
+			_, _ = fmt.Fprintln(os.Stdout, url)
+			return nil
+		},
+	}
+}
+
+// mirrorRelativePath returns the path of a file relative to the mirror directory, when the path is absolute or
+// names an existing file from the working directory (like a path completed by the shell). Other references are
+// returned unchanged, as paths relative to the mirror or page IDs.
+func mirrorRelativePath(mirrorDir, ref string) string {
+	if !filepath.IsAbs(ref) {
+		if _, err := os.Stat(ref); err != nil {
+			return ref
+		}
+	}
+	absRef, err := filepath.Abs(ref)
+	if err != nil {
+		return ref
+	}
+	absDir, err := filepath.Abs(mirrorDir)
+	if err != nil {
+		return ref
+	}
+	rel, err := filepath.Rel(absDir, absRef)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ref
+	}
+	return filepath.ToSlash(rel)
+}
+
+// openURL opens a URL in the browser.
+func openURL(ctx context.Context, url string) error {
+	var opener []string
+	switch runtime.GOOS {
+	case "darwin":
+		opener = []string{"open", url}
+	case "windows":
+		opener = []string{"rundll32", "url.dll,FileProtocolHandler", url}
+	default:
+		opener = []string{"xdg-open", url}
+	}
+
+	//nolint:gosec // Fixed opener command, URL built from a registry page ID
+	if err := exec.CommandContext(ctx, opener[0], opener[1:]...).Start(); err != nil {
+		return fmt.Errorf("open %s: %w", url, err)
+	}
+	return nil
+}
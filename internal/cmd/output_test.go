@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// runApp runs the application with args on an ephemeral store, and returns what it printed on stdout.
+func runApp(t *testing.T, args ...string) ([]byte, error) {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan []byte)
+	go func() {
+		content, _ := io.ReadAll(reader)
+		output <- content
+	}()
+
+	runErr := NewApp().Run(context.Background(), append([]string{"ntnsync", "--ephemeral"}, args...))
+	_ = writer.Close()
+	return <-output, runErr
+}
+
+// jsonKeys returns the sorted keys of a JSON object.
+func jsonKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatalf("output is not a JSON object: %v\n%s", err, data)
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// unsetOutputEnv unsets NTN_OUTPUT for the duration of a test.
+func unsetOutputEnv(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("NTN_OUTPUT", "")
+	if err := os.Unsetenv("NTN_OUTPUT"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONOutput_Commands(t *testing.T) {
+	unsetOutputEnv(t)
+
+	tests := []struct {
+		args     []string
+		wantKeys []string // Keys of the object, nil for an array
+	}{
+		{args: []string{"search", "query"}},
+		{
+			args: []string{"status"},
+			wantKeys: []string{
+				"folder_count", "folders", "mirror_size", "queue_entries", "queue_limits", "total_pages",
+				"total_root_pages",
+			},
+		},
+		{
+			args:     []string{"cleanup", "--dry-run"},
+			wantKeys: []string{"deleted_files", "deleted_registries", "dry_run", "orphaned_pages", "unused_assets"},
+		},
+		{
+			args:     []string{"registry", "export"},
+			wantKeys: []string{"blocked", "exported_at", "files", "format", "ntnsync_version", "pages", "users"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			output, err := runApp(t, append([]string{"--output", "json"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("%v error = %v", tt.args, err)
+			}
+			if tt.wantKeys == nil {
+				var list []json.RawMessage
+				if err := json.Unmarshal(output, &list); err != nil || list == nil {
+					t.Errorf("%v output = %s, want a JSON array", tt.args, output)
+				}
+				return
+			}
+			if got := jsonKeys(t, output); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("%v keys = %v, want %v", tt.args, got, tt.wantKeys)
+			}
+		})
+	}
+}
+
+// TestJSONOutput_Shapes checks the keys of the results of the commands that need Notion, a queue file or a synced
+// mirror.
+func TestJSONOutput_Shapes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		result   any
+		wantKeys []string
+	}{
+		"list": {
+			result:   &sync.FolderInfo{},
+			wantKeys: []string{"name", "orphaned_pages", "pages", "root_pages", "total_pages"},
+		},
+		"pull": {
+			result: &sync.PullResult{},
+			wantKeys: []string{
+				"cutoff_time", "new_pages", "pages_found", "pages_queued", "pages_skipped", "skipped_by_reason",
+				"updated_pages",
+			},
+		},
+		"sync --dry-run": {
+			result: &sync.SyncPlan{},
+			wantKeys: []string{
+				"api_calls", "deferred", "dropped", "estimated_api_calls", "failed", "files", "pages", "queue_files",
+				"skipped",
+			},
+		},
+		"cleanup --prune": {
+			result: &cleanupOutput{CleanupResult: &sync.CleanupResult{}, Prune: &sync.PruneResult{}},
+			wantKeys: []string{
+				"deleted_files", "deleted_registries", "dry_run", "orphaned_pages", "prune", "unused_assets",
+			},
+		},
+		"queue show": {
+			// The queue file format
+			result:   &queue.Entry{Type: "update", Folder: "tech", Pages: []queue.Page{{ID: "abc"}}},
+			wantKeys: []string{"createdAt", "folder", "pages", "type"},
+		},
+		"registry import": {
+			result:   &sync.RegistryImportResult{},
+			wantKeys: []string{"blocked", "files", "pages", "removed", "users"},
+		},
+	}
+	for name, tt := range tests {
+		data, err := json.Marshal(tt.result)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := jsonKeys(t, data); !slices.Equal(got, tt.wantKeys) {
+			t.Errorf("%s keys = %v, want %v", name, got, tt.wantKeys)
+		}
+	}
+}
+
+func TestJSONOutput_Unsupported(t *testing.T) {
+	unsetOutputEnv(t)
+
+	for _, args := range [][]string{
+		{"verify"},
+		{"remote", "show"},
+		{"sync"},
+	} {
+		output, err := runApp(t, append([]string{"-o", "json"}, args...)...)
+		if !errors.Is(err, apperrors.ErrJSONOutputUnsupported) {
+			t.Errorf("%v error = %v, want ErrJSONOutputUnsupported", args, err)
+		}
+		if len(bytes.TrimSpace(output)) > 0 {
+			t.Errorf("%v printed %q", args, output)
+		}
+	}
+
+	// Text output is still available
+	if _, err := runApp(t, "remote", "show"); err != nil {
+		t.Errorf("remote show error = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// flagPreset is the flag name of the sync preset.
+const flagPreset = "preset"
+
+// syncPreset is a named combination of sync settings. Its settings are defaults: a flag or an environment
+// variable set explicitly always wins.
+type syncPreset struct {
+	env    map[string]string // Environment variables of the sync config
+	flags  map[string]string // Flags of the sync command
+	verify bool              // Check the hashes of synced files after the run
+}
+
+// syncPresets are the presets selectable with --preset.
+var syncPresets = map[string]syncPreset{
+	// fast fetches nested blocks two levels deep and no assets. Unchanged pages are always skipped.
+	"fast": {
+		env: map[string]string{
+			"NTN_BLOCK_DEPTH":     "2",
+			"NTN_DOWNLOAD_ASSETS": "false",
+		},
+	},
+	// thorough fetches blocks at any depth and verifies the hashes of synced files.
+	"thorough": {
+		env: map[string]string{
+			"NTN_BLOCK_DEPTH": "0",
+		},
+		verify: true,
+	},
+	// ci bounds the run and logs in JSON.
+	"ci": {
+		env: map[string]string{
+			"NTN_LOG_FORMAT": "json",
+		},
+		flags: map[string]string{
+			"max-pages": "500",
+			"max-time":  "10m",
+		},
+	},
+}
+
+// presetFlag is the flag selecting a sync preset.
+var presetFlag = &cli.StringFlag{
+	Name:    flagPreset,
+	Usage:   "Preset of sync settings: " + strings.Join(presetNames(), ", ") + " (explicit settings win)",
+	Sources: cli.EnvVars("NTN_PRESET"),
+}
+
+// presetNames returns the names of the sync presets, sorted.
+func presetNames() []string {
+	return slices.Sorted(maps.Keys(syncPresets))
+}
+
+// selectedPreset returns the preset selected on cmd (zero if none).
+func selectedPreset(cmd *cli.Command) (syncPreset, error) {
+	name := strings.ToLower(strings.TrimSpace(cmd.String(flagPreset)))
+	if name == "" {
+		return syncPreset{}, nil
+	}
+	preset, ok := syncPresets[name]
+	if !ok {
+		return syncPreset{}, fmt.Errorf("%w: %q (expected one of %s)", apperrors.ErrUnknownPreset, name,
+			strings.Join(presetNames(), ", "))
+	}
+	return preset, nil
+}
+
+// applyPreset applies the preset selected on cmd to the settings not set explicitly. It must run before logging
+// is set up and the sync config is loaded.
+func applyPreset(cmd *cli.Command) error {
+	preset, err := selectedPreset(cmd)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range preset.env {
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+		if setErr := os.Setenv(key, value); setErr != nil {
+			return fmt.Errorf("set %s: %w", key, setErr)
+		}
+	}
+	for flag, value := range preset.flags {
+		if cmd.IsSet(flag) {
+			continue
+		}
+		if setErr := cmd.Set(flag, value); setErr != nil {
+			return fmt.Errorf("set --%s: %w", flag, setErr)
+		}
+	}
+
+	// The sync config may have been loaded before the preset was applied
+	sync.ResetConfig()
+	return nil
+}
+
+// verifySynced checks the hashes of the synced files of folder (empty = all folders), for presets verifying them.
+func verifySynced(ctx context.Context, crawler *sync.Crawler, folder string) error {
+	result, err := crawler.Verify(ctx, sync.VerifyOptions{Folder: folder})
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	displayVerifyResults(result)
+
+	if len(result.Mismatches) > 0 {
+		return fmt.Errorf("%d of %d: %w", len(result.Mismatches), result.Checked, apperrors.ErrVerifyMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// presetEnvKeys are the environment variables set by the presets, or selecting one.
+var presetEnvKeys = []string{"NTN_PRESET", "NTN_BLOCK_DEPTH", "NTN_DOWNLOAD_ASSETS", "NTN_LOG_FORMAT"}
+
+// runPresetCommand runs a command with the preset flag and the flags set by presets, applying the preset.
+func runPresetCommand(t *testing.T, args ...string) (*cli.Command, error) {
+	t.Helper()
+
+	flag := *presetFlag
+	cmd := &cli.Command{
+		Name: "sync",
+		Flags: []cli.Flag{
+			&flag,
+			&cli.IntFlag{Name: "max-pages"},
+			&cli.DurationFlag{Name: "max-time"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return applyPreset(cmd)
+		},
+	}
+	err := cmd.Run(context.Background(), append([]string{"sync"}, args...))
+	return cmd, err
+}
+
+func TestApplyPreset(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		env       map[string]string
+		wantEnv   map[string]string
+		wantPages int
+		wantTime  time.Duration
+		wantErr   error
+	}{
+		{
+			name:    "none",
+			wantEnv: map[string]string{"NTN_BLOCK_DEPTH": "", "NTN_LOG_FORMAT": ""},
+		},
+		{
+			name:    "fast",
+			args:    []string{"--preset", "fast"},
+			wantEnv: map[string]string{"NTN_BLOCK_DEPTH": "2", "NTN_DOWNLOAD_ASSETS": "false"},
+		},
+		{
+			name:    "thorough",
+			args:    []string{"--preset", "thorough"},
+			wantEnv: map[string]string{"NTN_BLOCK_DEPTH": "0", "NTN_DOWNLOAD_ASSETS": ""},
+		},
+		{
+			name:      "ci, case and spaces ignored",
+			args:      []string{"--preset", " CI "},
+			wantEnv:   map[string]string{"NTN_LOG_FORMAT": "json"},
+			wantPages: 500,
+			wantTime:  10 * time.Minute,
+		},
+		{
+			name:      "selected from the environment",
+			env:       map[string]string{"NTN_PRESET": "ci"},
+			wantEnv:   map[string]string{"NTN_LOG_FORMAT": "json"},
+			wantPages: 500,
+			wantTime:  10 * time.Minute,
+		},
+		{
+			name:      "explicit flag wins",
+			args:      []string{"--preset", "ci", "--max-pages", "20"},
+			wantPages: 20,
+			wantTime:  10 * time.Minute,
+		},
+		{
+			name:    "explicit environment variable wins",
+			args:    []string{"--preset", "fast"},
+			env:     map[string]string{"NTN_BLOCK_DEPTH": "5"},
+			wantEnv: map[string]string{"NTN_BLOCK_DEPTH": "5", "NTN_DOWNLOAD_ASSETS": "false"},
+		},
+		{
+			name:    "unknown preset",
+			args:    []string{"--preset", "slow"},
+			wantEnv: map[string]string{"NTN_BLOCK_DEPTH": "", "NTN_DOWNLOAD_ASSETS": ""},
+			wantErr: apperrors.ErrUnknownPreset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range presetEnvKeys {
+				t.Setenv(key, "")
+				if err := os.Unsetenv(key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cmd, err := runPresetCommand(t, tt.args...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyPreset() error = %v, want %v", err, tt.wantErr)
+			}
+			for key, want := range tt.wantEnv {
+				if got := os.Getenv(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got := cmd.Int("max-pages"); got != tt.wantPages {
+				t.Errorf("--max-pages = %d, want %d", got, tt.wantPages)
+			}
+			if got := cmd.Duration("max-time"); got != tt.wantTime {
+				t.Errorf("--max-time = %s, want %s", got, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestSelectedPreset(t *testing.T) {
+	t.Setenv("NTN_PRESET", "")
+
+	for name, wantVerify := range map[string]bool{"": false, "fast": false, "thorough": true, "ci": false} {
+		flag := *presetFlag
+		var preset syncPreset
+		cmd := &cli.Command{
+			Name:  "sync",
+			Flags: []cli.Flag{&flag},
+			Action: func(_ context.Context, cmd *cli.Command) error {
+				var err error
+				preset, err = selectedPreset(cmd)
+				return err
+			},
+		}
+		if err := cmd.Run(context.Background(), []string{"sync", "--preset=" + name}); err != nil {
+			t.Fatalf("selectedPreset(%q) error = %v", name, err)
+		}
+		if preset.verify != wantVerify {
+			t.Errorf("selectedPreset(%q) verify = %v, want %v", name, preset.verify, wantVerify)
+		}
+	}
+}
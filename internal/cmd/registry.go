@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// registryCommand creates the registry command, to export and import the registries as a single JSON bundle.
+func registryCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "registry",
+		Usage: "Export and import the page, file, user and blocked registries",
+		Commands: []*cli.Command{
+			{
+				Name:     "export",
+				Metadata: map[string]any{metadataJSONOutput: true},
+				Usage:    "Print all the registries as a single JSON bundle",
+				Flags: []cli.Flag{
+					verboseFlag,
+				},
+				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+					setupLogging(cmd)
+					return ctx, nil
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					storeInst, _, err := createStore(cmd)
+					if err != nil {
+						return err
+					}
+					crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+					bundle, err := crawler.ExportRegistries(ctx)
+					if err != nil {
+						return err
+					}
+					return printJSON(bundle)
+				},
+			},
+			{
+				Name:      "import",
+				Metadata:  map[string]any{metadataJSONOutput: true},
+				Usage:     "Write the registries of a JSON bundle, after validating it",
+				ArgsUsage: "<bundle.json|->",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  flagDryRun,
+						Usage: "Only validate the bundle and show what would be imported",
+					},
+					&cli.BoolFlag{
+						Name:  "replace",
+						Usage: "Remove the registries absent from the bundle",
+					},
+					verboseFlag,
+				},
+				Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+					setupLogging(cmd)
+					return ctx, nil
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					if cmd.Args().Len() < 1 {
+						return apperrors.ErrBundleFileRequired
+					}
+					dryRun := cmd.Bool(flagDryRun)
+
+					data, err := readBundleFile(cmd.Args().First())
+					if err != nil {
+						return err
+					}
+					bundle, err := sync.ParseRegistryBundle(data)
+					if err != nil {
+						return err
+					}
+
+					storeInst, remoteConfig, err := createStore(cmd)
+					if err != nil {
+						return err
+					}
+					crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+
+					result, err := crawler.ImportRegistries(ctx, bundle, sync.RegistryImportOptions{
+						DryRun:  dryRun,
+						Replace: cmd.Bool("replace"),
+					})
+					if err != nil {
+						return err
+					}
+					if isJSONOutput(cmd) {
+						if err := printJSON(result); err != nil {
+							return err
+						}
+					} else {
+						displayRegistryImport(result, dryRun)
+					}
+
+					if !dryRun && remoteConfig.IsCommitEnabled() {
+						return commitAndPush(ctx, crawler, storeInst, remoteConfig, "import registries")
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// readBundleFile reads a registry bundle from a file, or from stdin for "-".
+func readBundleFile(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read bundle from stdin: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // Path provided by the user
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	return data, nil
+}
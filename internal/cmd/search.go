@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// searchCommand creates the search subcommand.
+func searchCommand() *cli.Command {
+	return &cli.Command{
+		Name:          "search",
+		Metadata:      map[string]any{metadataJSONOutput: true},
+		Usage:         "Search the titles, properties and content of the mirrored pages (no Notion API call)",
+		ArgsUsage:     "<query>",
+		ShellComplete: completeWithFolders,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    flagFolder,
+				Aliases: []string{"f"},
+				Usage:   "Only search pages in specified folder",
+			},
+			&cli.IntFlag{
+				Name:    "limit",
+				Aliases: []string{"n"},
+				Usage:   "Maximum number of pages to show (0 = unlimited)",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the results as JSON, for scripting (same as --output json)",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			query := strings.Join(cmd.Args().Slice(), " ")
+			if strings.TrimSpace(query) == "" {
+				return apperrors.ErrSearchQueryRequired
+			}
+
+			storeInst, _, err := createStore(cmd)
+			if err != nil {
+				return err
+			}
+
+			crawler := sync.NewCrawler(nil, storeInst, sync.WithCrawlerLogger(slog.Default()))
+			results, err := crawler.Search(ctx, query, cmd.String(flagFolder))
+			if err != nil {
+				return fmt.Errorf("search: %w", err)
+			}
+			if limit := cmd.Int("limit"); limit > 0 && len(results) > limit {
+				results = results[:limit]
+			}
+
+			if cmd.Bool("json") || isJSONOutput(cmd) {
+				if results == nil {
+					results = []*sync.SearchResult{}
+				}
+				return printJSON(results)
+			}
+			displaySearchResults(results)
+			return nil
+		},
+	}
+}
+
+// displaySearchResults prints the pages matching a search, with their matching snippets.
+func displaySearchResults(results []*sync.SearchResult) {
+	if len(results) == 0 {
+		fmt.Println("No matching pages.")
+		return
+	}
+	for _, result := range results {
+		fmt.Printf("%s (%s)\n", result.Path, result.Title)
+		for _, match := range result.Matches {
+			if match.Line > 0 {
+				fmt.Printf("  %d [%s]: %s\n", match.Line, match.Field, match.Snippet)
+			} else {
+				fmt.Printf("  [%s]: %s\n", match.Field, match.Snippet)
+			}
+		}
+		if more := result.Total - len(result.Matches); more > 0 {
+			fmt.Printf("  ... %d more matches\n", more)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/shutdown"
+	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+const (
+	// defaultWatchInterval is the default time between the end of a watch cycle and the start of the next one.
+	defaultWatchInterval = 5 * time.Minute
+	// defaultWatchJitter is the default maximum random delay added to the watch interval.
+	defaultWatchJitter = 30 * time.Second
+	// watchRunStaleAfter is the age after which the run file of another process is considered left by a dead one.
+	watchRunStaleAfter = 30 * time.Minute
+)
+
+// watchCommand creates the watch subcommand.
+func watchCommand() *cli.Command {
+	return &cli.Command{
+		Name:          "watch",
+		Usage:         "Pull, sync, commit and push on a schedule, for mirrors that can't receive webhooks",
+		ShellComplete: completeWithFolders,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:    "interval",
+				Aliases: []string{"i"},
+				Usage:   "Time between the end of a cycle and the start of the next one",
+				Value:   defaultWatchInterval,
+				Sources: cli.EnvVars("NTN_WATCH_INTERVAL"),
+			},
+			&cli.DurationFlag{
+				Name:    "jitter",
+				Usage:   "Maximum random delay added to the interval, to spread the load of several mirrors",
+				Value:   defaultWatchJitter,
+				Sources: cli.EnvVars("NTN_WATCH_JITTER"),
+			},
+			&cli.StringFlag{
+				Name:    flagFolder,
+				Aliases: []string{"f"},
+				Usage:   "Only pull and sync pages in specified folder",
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Include pages not yet tracked in pulls (discover new pages)",
+			},
+			&cli.DurationFlag{
+				Name:    "max-time",
+				Aliases: []string{"t"},
+				Usage:   "Maximum time to spend syncing per cycle, continued by the next cycle (0 = unlimited)",
+				Sources: cli.EnvVars("NTN_WATCH_MAX_TIME"),
+			},
+			quietHoursFlag,
+			quietHoursTZFlag,
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			interval := cmd.Duration("interval")
+			if interval <= 0 {
+				return fmt.Errorf("%w: %s", apperrors.ErrInvalidWatchInterval, interval)
+			}
+
+			client, storeInst, err := setupClientAndStore(cmd)
+			if err != nil {
+				return err
+			}
+			quiet, err := loadQuietHours(cmd)
+			if err != nil {
+				return err
+			}
+
+			w := &watcher{
+				client:       client,
+				store:        storeInst,
+				remoteConfig: storeRemoteConfig(storeInst),
+				quietHours:   quiet,
+				folder:       cmd.String(flagFolder),
+				all:          cmd.Bool("all"),
+				maxTime:      cmd.Duration("max-time"),
+			}
+			return w.run(ctx, interval, max(cmd.Duration("jitter"), 0))
+		},
+	}
+}
+
+// watcher runs watch cycles: pull, sync, commit and push. Cycles never overlap: the next one is scheduled when
+// the previous one ends, and cycles are skipped while another process is syncing the same mirror.
+type watcher struct {
+	client       *notion.Client
+	store        store.Store
+	remoteConfig *store.RemoteConfig
+	quietHours   *sync.QuietHours
+	folder       string
+	all          bool
+	maxTime      time.Duration
+}
+
+// run runs a cycle, then another one every interval plus a random jitter, until the context is canceled.
+func (w *watcher) run(ctx context.Context, interval, jitter time.Duration) error {
+	slog.InfoContext(ctx, "watching Notion changes",
+		"interval", interval,
+		"jitter", jitter,
+		"folder", w.folder,
+		"max_time", w.maxTime)
+
+	for {
+		if err := w.cycle(ctx); err != nil && ctx.Err() == nil {
+			// Errors are usually transient (network, Notion outage): the next cycle tries again
+			slog.ErrorContext(ctx, "watch cycle failed", "error", err)
+		}
+		if ctx.Err() != nil {
+			slog.InfoContext(ctx, "watch stopped")
+			return nil
+		}
+
+		delay := interval
+		if jitter > 0 {
+			delay += rand.N(jitter + 1) //nolint:gosec // Jitter, not security sensitive
+		}
+		slog.InfoContext(ctx, "waiting for the next watch cycle", "delay", delay.Round(time.Second))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.InfoContext(ctx, "watch stopped")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// releaseLock releases the locks of the store directory and of the remote branch acquired by a cycle.
+func (w *watcher) releaseLock(ctx context.Context) {
+	if releaser, ok := w.store.(store.LockReleaser); ok {
+		if err := releaser.ReleaseAllLocks(); err != nil {
+			slog.WarnContext(ctx, "failed to release store lock", "error", err)
+		}
+	}
+}
+
+// cycle pulls the changes of Notion, syncs the queue, then commits and pushes.
+func (w *watcher) cycle(ctx context.Context) error {
+	// On shutdown, the pages in progress are finished and committed within the grace period
+	defer shutdown.Begin(ctx)()
+
+	if now := time.Now(); w.quietHours.Active(now) {
+		slog.InfoContext(ctx, "quiet hours, skipping watch cycle", "until", w.quietHours.End(now))
+		return nil
+	}
+
+	crawler := sync.NewCrawler(w.client, w.store, sync.WithCrawlerLogger(slog.Default()))
+	if run := crawler.ActiveRun(ctx, watchRunStaleAfter); run != nil {
+		slog.InfoContext(ctx, "another sync run is in progress, skipping watch cycle",
+			"pid", run.PID,
+			"hostname", run.Hostname,
+			"phase", run.Phase,
+			"started_at", run.StartedAt)
+		return nil
+	}
+	crawler.StartRun(ctx, w.folder)
+	defer crawler.FinishRun(ctx)
+	// Other processes can write to the store between cycles
+	defer w.releaseLock(ctx)
+
+	// Lock the branch on the remote until the end of the cycle, then pull from it (if remote is configured)
+	if err := acquireRemoteLock(ctx, w.store); err != nil {
+		return err
+	}
+	if err := storePull(ctx, w.store); err != nil {
+		return fmt.Errorf("pull from remote: %w", err)
+	}
+	if err := crawler.ReconcileRootMd(ctx); err != nil {
+		return fmt.Errorf("reconcile root.md: %w", err)
+	}
+
+	result, err := crawler.Pull(ctx, sync.PullOptions{Folder: w.folder, All: w.all})
+	if err != nil {
+		return fmt.Errorf("pull: %w", err)
+	}
+	slog.InfoContext(ctx, "pulled Notion changes",
+		"pages_found", result.PagesFound,
+		"pages_queued", result.PagesQueued)
+
+	if err := processQueueWithCommits(ctx, crawler, w.store, w.remoteConfig, w.folder, 0, 0, 0, w.maxTime); err != nil {
+		return fmt.Errorf("process queue: %w", err)
+	}
+	if w.remoteConfig.IsCommitEnabled() {
+		if err := commitAndPush(ctx, crawler, w.store, w.remoteConfig, "watch sync"); err != nil {
+			return err
+		}
+	}
+
+	// An unhealthy cycle is reported, but doesn't stop watching
+	if err := crawler.CheckHealth(); err != nil {
+		slog.WarnContext(ctx, "watch cycle is unhealthy", "error", err)
+	}
+	return nil
+}
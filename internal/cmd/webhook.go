@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/fclairamb/ntnsync/internal/webhook"
+)
+
+// webhookCommand creates the webhook subcommand.
+func webhookCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "webhook",
+		Usage: "Interact with a running webhook server",
+		Commands: []*cli.Command{
+			webhookSimulateCommand(),
+		},
+	}
+}
+
+// webhookSimulateCommand creates the webhook simulate subcommand.
+func webhookSimulateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "simulate",
+		Usage: "Feed a synthetic event through a running webhook server, without editing Notion pages",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "event",
+				Usage: "Event type to simulate (e.g., page.updated, database.content_updated)",
+				Value: "page.updated",
+			},
+			&cli.StringFlag{
+				Name:     "page",
+				Usage:    "ID of the page (or database) the event is about",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "url",
+				Usage:   "Base URL of the webhook server",
+				Value:   fmt.Sprintf("http://localhost:%d", defaultWebhookPort),
+				Sources: cli.EnvVars("NTN_WEBHOOK_URL"),
+			},
+			&cli.StringFlag{
+				Name:    "simulate-token",
+				Usage:   "Bearer token of the simulate endpoint",
+				Sources: cli.EnvVars("NTN_WEBHOOK_SIMULATE_TOKEN"),
+			},
+			&cli.BoolFlag{
+				Name:  "sign",
+				Usage: "Sign the event with the server's webhook secret and verify the signature",
+			},
+			verboseFlag,
+		},
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			setupLogging(cmd)
+			return ctx, nil
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			result, err := webhook.SimulateEvent(ctx, cmd.String("url"), cmd.String("simulate-token"),
+				&webhook.SimulateRequest{
+					Event:  cmd.String("event"),
+					PageID: cmd.String("page"),
+					Sign:   cmd.Bool("sign"),
+				})
+			if err != nil {
+				return fmt.Errorf("simulate event: %w", err)
+			}
+
+			displaySimulateResult(result)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Code caption styles select how the caption of a code block (often a filename) is rendered.
+const (
+	// CodeCaptionBold renders the caption as a bold line before the code block.
+	CodeCaptionBold = "bold"
+	// CodeCaptionTitle renders the caption as a title attribute of the code fence (```go title="main.go"),
+	// as supported by MkDocs Material and Docusaurus.
+	CodeCaptionTitle = "title"
+	// CodeCaptionNone drops the caption.
+	CodeCaptionNone = "none"
+)
+
+// CodeCaptionStyles lists the supported code caption styles.
+var CodeCaptionStyles = []string{CodeCaptionBold, CodeCaptionTitle, CodeCaptionNone}
+
+// IsValidCodeCaptionStyle returns true if the code caption style is supported. An empty style is the
+// default one.
+func IsValidCodeCaptionStyle(style string) bool {
+	return style == "" || slices.Contains(CodeCaptionStyles, style)
+}
+
+// codeLanguages maps Notion code block languages to the identifiers of common syntax highlighters
+// (highlight.js, Prism, Pygments). Languages missing from the table are used as-is, with spaces
+// replaced by dashes.
+var codeLanguages = map[string]string{
+	"plain text":    "",
+	"c++":           "cpp",
+	"c#":            "csharp",
+	"f#":            "fsharp",
+	"objective-c":   "objectivec",
+	"coffeescript":  "coffee",
+	"docker":        "dockerfile",
+	"flow":          "javascript",
+	"java/c/c++/c#": "java",
+	"markup":        "html",
+	"vb.net":        "vbnet",
+	"visual basic":  "vb",
+	"webassembly":   "wasm",
+}
+
+// codeLanguage returns the highlighter identifier of a Notion code block language.
+func codeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if identifier, ok := codeLanguages[language]; ok {
+		return identifier
+	}
+	return strings.ReplaceAll(language, " ", "-")
+}
+
+// convertCode renders a code block as a fenced block, with its caption in the configured style.
+func convertCode(code *notion.CodeBlock, opts *ConvertOptions) string {
+	text := notion.ParseRichText(code.RichText) // No markdown formatting inside code
+	lang := codeLanguage(code.Language)
+	caption := strings.Join(strings.Fields(notion.ParseRichText(code.Caption)), " ")
+
+	switch {
+	case caption == "" || opts.CodeCaptions == CodeCaptionNone:
+		return fmt.Sprintf("```%s\n%s\n```\n", lang, text)
+	case opts.CodeCaptions == CodeCaptionTitle:
+		if lang == "" {
+			lang = "text" // Attributes need a language
+		}
+		title := strings.ReplaceAll(caption, `"`, `'`)
+		return fmt.Sprintf("```%s title=\"%s\"\n%s\n```\n", lang, title, text)
+	default:
+		caption = opts.escapeText(strings.ReplaceAll(caption, "*", `\*`))
+		return fmt.Sprintf("**%s**\n\n```%s\n%s\n```\n", caption, lang, text)
+	}
+}
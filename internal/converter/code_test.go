@@ -0,0 +1,62 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestCodeLanguage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"go":            "go",
+		"plain text":    "",
+		"C++":           "cpp",
+		"c#":            "csharp",
+		"docker":        "dockerfile",
+		"visual basic":  "vb",
+		"java/c/c++/c#": "java",
+		"some language": "some-language",
+	}
+	for language, want := range tests {
+		if got := codeLanguage(language); got != want {
+			t.Errorf("codeLanguage(%q) = %q, want %q", language, got, want)
+		}
+	}
+}
+
+func TestConvertCode_Caption(t *testing.T) {
+	t.Parallel()
+
+	code := &notion.CodeBlock{
+		RichText: []notion.RichText{{Type: "text", PlainText: "package main"}},
+		Caption:  []notion.RichText{{Type: "text", PlainText: "cmd/main.go"}},
+		Language: "go",
+	}
+
+	tests := map[string]string{
+		"":               "**cmd/main.go**\n\n```go\npackage main\n```\n",
+		CodeCaptionBold:  "**cmd/main.go**\n\n```go\npackage main\n```\n",
+		CodeCaptionTitle: "```go title=\"cmd/main.go\"\npackage main\n```\n",
+		CodeCaptionNone:  "```go\npackage main\n```\n",
+	}
+	for style, want := range tests {
+		if got := convertCode(code, &ConvertOptions{CodeCaptions: style}); got != want {
+			t.Errorf("convertCode(%q) = %q, want %q", style, got, want)
+		}
+	}
+
+	// Code blocks without a caption are not affected by the style
+	code.Caption = nil
+	if got := convertCode(code, &ConvertOptions{CodeCaptions: CodeCaptionTitle}); got != "```go\npackage main\n```\n" {
+		t.Errorf("convertCode() without caption = %q", got)
+	}
+
+	// Title attributes need a language
+	code.Caption = []notion.RichText{{Type: "text", PlainText: "notes.txt"}}
+	code.Language = "plain text"
+	if got := convertCode(code, &ConvertOptions{CodeCaptions: CodeCaptionTitle}); got != "```text title=\"notes.txt\"\npackage main\n```\n" {
+		t.Errorf("convertCode() plain text with title = %q", got)
+	}
+}
@@ -3,12 +3,14 @@ package converter
 
 import (
 	"fmt"
+	"maps"
+	"path"
 	"path/filepath"
 	"slices"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/converter/plugin"
 	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/version"
 )
@@ -38,6 +40,16 @@ const (
 type Converter struct {
 	// IncludeFrontmatter controls whether to include YAML frontmatter.
 	IncludeFrontmatter bool
+	// FilenameRules controls how titles are turned into filenames in links.
+	FilenameRules FilenameRules
+	// Plugins render the block types they are registered for, before the built-in rendering (optional).
+	Plugins *plugin.Registry
+	// TimeFormat controls how timestamps are written in the frontmatter.
+	TimeFormat TimeFormat
+	// Properties selects the database properties written in the frontmatter of database pages.
+	Properties PropertySelection
+	// Links controls how links to child pages and databases are rendered.
+	Links LinkStyle
 }
 
 // FileProcessor processes a file URL and returns the local path.
@@ -49,20 +61,38 @@ type FileProcessor func(fileURL string) string
 type ConvertOptions struct {
 	Folder           string        // Folder name for this page
 	PageTitle        string        // Page title (used for child page link paths)
+	Aliases          []string      // Previous titles and file paths of the page (stored in frontmatter)
 	FilePath         string        // File path (stored in frontmatter)
 	LastSynced       time.Time     // When we synced this page
 	NotionType       string        // Type: "page" or "database"
 	IsRoot           bool          // Whether this is a root page
 	ParentID         string        // Resolved parent page/database ID (empty for root pages)
 	FileProcessor    FileProcessor // Optional callback to process file URLs
+	AssetProcessor   FileProcessor // Optional callback storing Notion-hosted icons and covers as local assets
 	SimplifiedDepth  int           // Depth limit used if page was depth-limited (0 if not limited)
 	DownloadDuration time.Duration // Time to download page from Notion API
+	Profile          string        // Output profile (ProfileDefault if empty), recorded in frontmatter otherwise
+	// InlineDatabases are the top rows of child databases to show under their links, by normalized database ID
+	InlineDatabases map[string]*InlineDatabase
+	// CodeCaptions is the code caption style (CodeCaptionBold if empty)
+	CodeCaptions string
+	// Ancestors are the titles of the ancestors of the page, from its root, shown by breadcrumb blocks
+	Ancestors []string
+	// OpenComments is the number of unresolved comments of the page (written in frontmatter when not zero)
+	OpenComments int
+	// HTMLTableColumns is the number of columns above which tables are written as HTML tables (0 = never)
+	HTMLTableColumns int
+	// DatabaseRenderMode is how the pages of a database are listed in its file (DatabaseRenderList if empty)
+	DatabaseRenderMode string
+	// Warnings are the anomalies of the conversion, collected while converting (written as sync_warnings)
+	Warnings []*ConversionWarning
 }
 
 // NewConverter creates a new converter with default settings.
 func NewConverter() *Converter {
 	return &Converter{
 		IncludeFrontmatter: true,
+		FilenameRules:      DefaultFilenameRules(),
 	}
 }
 
@@ -75,12 +105,8 @@ func (c *Converter) Convert(page *notion.Page, blocks []notion.Block) []byte {
 func (c *Converter) ConvertWithOptions(page *notion.Page, blocks []notion.Block, opts *ConvertOptions) []byte {
 	var builder strings.Builder
 
-	if c.IncludeFrontmatter {
-		builder.WriteString(c.generateFrontmatter(page, opts))
-	}
-
 	// Add title as h1
-	title := page.Title()
+	title := opts.escapeText(page.Title())
 	if title != "" {
 		fmt.Fprintf(&builder, "# %s\n\n", title)
 	}
@@ -100,39 +126,33 @@ func (c *Converter) ConvertWithOptions(page *notion.Page, blocks []notion.Block,
 		}
 	}
 
-	return []byte(builder.String())
+	return c.finish(c.withFrontmatter(page, builder.String(), opts), opts)
+}
+
+// withFrontmatter prepends the frontmatter to converted content, when enabled. It is generated after the content,
+// to list the warnings of its conversion.
+func (c *Converter) withFrontmatter(page *notion.Page, content string, opts *ConvertOptions) string {
+	if !c.IncludeFrontmatter {
+		return content
+	}
+	return c.generateFrontmatter(page, opts) + content
 }
 
-// ConvertDatabase converts a database to Markdown with a list of direct child pages.
+// ConvertDatabase converts a database to Markdown with its direct child pages, as a list or a table of their
+// properties depending on the database render mode.
 func (c *Converter) ConvertDatabase(
 	database *notion.Database, dbPages []notion.DatabasePage, opts *ConvertOptions,
 ) []byte {
 	var builder strings.Builder
 
-	if c.IncludeFrontmatter {
-		// Create a pseudo-page for frontmatter generation
-		page := &notion.Page{
-			ID:             database.ID,
-			CreatedTime:    database.CreatedTime,
-			LastEditedTime: database.LastEditedTime,
-			CreatedBy:      database.CreatedBy,
-			LastEditedBy:   database.LastEditedBy,
-			Parent:         database.Parent,
-			Icon:           database.Icon,
-			Cover:          database.Cover,
-			URL:            database.URL,
-		}
-		builder.WriteString(c.generateFrontmatter(page, opts))
-	}
-
 	// Add database title as heading
-	title := database.GetTitle()
+	title := opts.escapeText(database.GetTitle())
 	if title != "" {
 		fmt.Fprintf(&builder, "# %s\n\n", title)
 	}
 
 	// Add description if present
-	description := notion.ParseRichText(database.Description)
+	description := opts.escapeText(notion.ParseRichText(database.Description))
 	if description != "" {
 		builder.WriteString(description + "\n\n")
 	}
@@ -152,51 +172,61 @@ func (c *Converter) ConvertDatabase(
 		}
 	}
 
-	// Add list with links to direct child pages
-	if len(directChildren) > 0 {
-		// Extract the base filename from file path to use for links
-		// This ensures we use the sanitized filename (e.g., "wiki" not "Wiki")
-		baseFilename := strings.TrimSuffix(filepath.Base(opts.FilePath), ".md")
-
+	// Add list with links to direct child pages, or a table of their properties
+	switch {
+	case len(directChildren) == 0:
+		builder.WriteString("*This database has no direct child pages.*\n\n")
+	case opts.DatabaseRenderMode == DatabaseRenderTable:
+		builder.WriteString(c.convertDatabaseTable(database, directChildren, opts))
+		builder.WriteString("\n")
+	default:
 		for i := range directChildren {
 			dbPage := &directChildren[i]
-			pageTitle := dbPage.Title()
-			if pageTitle == "" {
-				pageTitle = "Untitled"
-			}
-
-			// Generate relative link to the page
-			// Use sanitized base filename from file path, not original title
-			slug := SanitizeFilename(pageTitle)
-			relPath := fmt.Sprintf("./%s/%s.md", baseFilename, slug)
-			pageID := NormalizeID(dbPage.ID)
-
-			fmt.Fprintf(&builder, "- [%s](%s)<!-- page_id:%s -->\n", pageTitle, relPath, pageID)
+			pageTitle, relPath := c.databasePageLink(dbPage, opts)
+			builder.WriteString(c.childLink(pageTitle, relPath, NormalizeID(dbPage.ID), opts))
 		}
 		builder.WriteString("\n")
-	} else {
-		builder.WriteString("*This database has no direct child pages.*\n\n")
 	}
 
-	return []byte(builder.String())
+	// Create a pseudo-page for frontmatter generation
+	page := &notion.Page{
+		ID:             database.ID,
+		CreatedTime:    database.CreatedTime,
+		LastEditedTime: database.LastEditedTime,
+		CreatedBy:      database.CreatedBy,
+		LastEditedBy:   database.LastEditedBy,
+		Parent:         database.Parent,
+		Icon:           database.Icon,
+		Cover:          database.Cover,
+		URL:            database.URL,
+	}
+	return c.finish(c.withFrontmatter(page, builder.String(), opts), opts)
 }
 
 // generateFrontmatter creates YAML frontmatter for the page.
 //
 //nolint:funlen // Many fields to generate
 func (c *Converter) generateFrontmatter(page *notion.Page, opts *ConvertOptions) string {
-	var builder strings.Builder
-	builder.WriteString("---\n")
-	fmt.Fprintf(&builder, "ntnsync_version: %s\n", version.Version)
-	fmt.Fprintf(&builder, "notion_id: %s\n", page.ID)
+	var fields yamlMapping
+	fields.add("ntnsync_version", version.Version)
+	fields.add("notion_id", page.ID)
 
 	// Title (use page title, or opts.PageTitle for databases)
-	title := page.Title()
+	title := NormalizeText(page.Title())
 	if title == "" {
-		title = opts.PageTitle
+		title = NormalizeText(opts.PageTitle)
 	}
 	if title != "" {
-		fmt.Fprintf(&builder, "title: %q\n", title)
+		fields.add("title", yamlQuoted(title))
+	}
+
+	// Previous titles and paths, so that static site generators can redirect them
+	if len(opts.Aliases) > 0 {
+		aliases := make([]string, 0, len(opts.Aliases))
+		for _, alias := range opts.Aliases {
+			aliases = append(aliases, NormalizeText(alias))
+		}
+		fields.add("aliases", aliases)
 	}
 
 	// Notion type (page or database)
@@ -204,84 +234,118 @@ func (c *Converter) generateFrontmatter(page *notion.Page, opts *ConvertOptions)
 	if notionType == "" {
 		notionType = blockTypePage
 	}
-	fmt.Fprintf(&builder, "notion_type: %s\n", notionType)
+	fields.add("notion_type", notionType)
 
 	// Use provided folder
 	if opts.Folder != "" {
-		fmt.Fprintf(&builder, "notion_folder: %s\n", opts.Folder)
+		fields.add("notion_folder", opts.Folder)
 	}
 
 	// File path for self-reference
 	if opts.FilePath != "" {
-		fmt.Fprintf(&builder, "file_path: %s\n", opts.FilePath)
+		fields.add("file_path", opts.FilePath)
 	}
 
 	// Creator and editor information (formatted as "Name <email> [id]")
 	if page.CreatedBy.ID != "" {
-		fmt.Fprintf(&builder, "created_by: %q\n", page.CreatedBy.Format())
+		fields.add("created_by", yamlQuoted(NormalizeText(page.CreatedBy.Format())))
 	}
 	if page.LastEditedBy.ID != "" {
-		fmt.Fprintf(&builder, "last_edited_by: %q\n", page.LastEditedBy.Format())
+		fields.add("last_edited_by", yamlQuoted(NormalizeText(page.LastEditedBy.Format())))
 	}
 
-	fmt.Fprintf(&builder, "last_edited: %s\n", page.LastEditedTime.Format(time.RFC3339))
+	fields.add("last_edited", yamlTimestamp(c.TimeFormat.Format(page.LastEditedTime)))
 
 	// Last synced time
 	if !opts.LastSynced.IsZero() {
-		fmt.Fprintf(&builder, "last_synced: %s\n", opts.LastSynced.Format(time.RFC3339))
+		fields.add("last_synced", yamlTimestamp(c.TimeFormat.Format(opts.LastSynced)))
 	}
 
-	// Icon
-	if iconStr := formatIcon(page.Icon); iconStr != "" {
-		fmt.Fprintf(&builder, "icon: %q\n", iconStr)
+	// Icon and cover
+	if iconStr := formatIcon(page.Icon, opts.AssetProcessor); iconStr != "" {
+		fields.add("icon", yamlQuoted(NormalizeText(iconStr)))
+	}
+	if coverStr := formatCover(page.Cover, opts.AssetProcessor); coverStr != "" {
+		fields.add("cover", yamlQuoted(coverStr))
 	}
 
 	// Include resolved parent ID (page or database, never block)
 	if opts.ParentID != "" {
-		fmt.Fprintf(&builder, "notion_parent_id: %s\n", opts.ParentID)
+		fields.add("notion_parent_id", opts.ParentID)
 	}
 
-	fmt.Fprintf(&builder, "is_root: %t\n", opts.IsRoot)
-	fmt.Fprintf(&builder, "notion_url: %s\n", page.URL)
+	fields.add("is_root", opts.IsRoot)
+	fields.add("notion_url", page.URL)
+
+	// Record the output profile when it isn't the default one
+	if opts.Profile != "" && opts.Profile != ProfileDefault {
+		fields.add("output_profile", opts.Profile)
+	}
 
 	// Include simplified_depth if page was depth-limited
 	if opts.SimplifiedDepth > 0 {
-		fmt.Fprintf(&builder, "simplified_depth: %d\n", opts.SimplifiedDepth)
+		fields.add("simplified_depth", opts.SimplifiedDepth)
 	}
 
 	// Include download duration if set
 	if opts.DownloadDuration > 0 {
-		fmt.Fprintf(&builder, "download_duration: %s\n", opts.DownloadDuration)
+		fields.add("download_duration", opts.DownloadDuration)
+	}
+
+	// Include the number of unresolved comments, so that pages needing attention stand out
+	if opts.OpenComments > 0 {
+		fields.add("open_comments", opts.OpenComments)
 	}
 
-	// Include properties for database pages (pages whose parent is a database)
+	// Properties of database pages (pages whose parent is a database), converted before listing the warnings
+	var properties yamlMapping
 	if page.Parent.DatabaseID != "" && len(page.Properties) > 0 {
-		propsBuilder := strings.Builder{}
-		names := make([]string, 0, len(page.Properties))
-		for name := range page.Properties {
-			names = append(names, name)
-		}
-		slices.Sort(names)
-		for _, name := range names {
-			prop := page.Properties[name]
-			value := extractPropertyValue(&prop)
-			if value == nil {
-				continue
-			}
-			formatted := formatPropertyValue(value)
-			if formatted == "" {
-				continue
-			}
-			fmt.Fprintf(&propsBuilder, "  %s: %s\n", name, formatted)
+		filter := c.Properties.filterFor(page.Parent.DatabaseID, page.Parent.DataSourceID)
+		properties = c.propertiesMapping(page.Properties, filter, opts)
+	}
+
+	// Include what didn't translate, so that content owners can see it
+	if len(opts.Warnings) > 0 {
+		fields.add("sync_warnings", warningsList(opts.Warnings))
+	}
+
+	if opts.Profile == ProfileObsidian {
+		fields.addProperties(properties)
+	} else if len(properties) > 0 {
+		fields.add("properties", properties)
+	}
+
+	return fields.frontmatter()
+}
+
+// propertiesMapping converts the database page properties selected by the filter to a YAML mapping,
+// sorted by name unless the filter orders them. Empty properties are skipped, and at most
+// Properties.Max properties are written. The rollups whose value isn't written are recorded as warnings.
+func (c *Converter) propertiesMapping(
+	props map[string]notion.Property, filter PropertyFilter, opts *ConvertOptions,
+) yamlMapping {
+	var properties yamlMapping
+	for _, name := range filter.names(slices.Collect(maps.Keys(props))) {
+		if c.Properties.Max > 0 && len(properties) >= c.Properties.Max {
+			break
 		}
-		if propsBuilder.Len() > 0 {
-			builder.WriteString("properties:\n")
-			builder.WriteString(propsBuilder.String())
+		prop := props[name]
+		opts.checkRollup(NormalizeText(name), &prop)
+		if value := propertyYAMLValue(extractPropertyValue(&prop), c.TimeFormat); value != nil {
+			properties.add(NormalizeText(name), value)
 		}
 	}
+	return properties
+}
 
-	builder.WriteString("---\n\n")
-	return builder.String()
+// childLink renders a link to a child page or database, whose path is relative to the page being converted.
+// The obsidian profile links it with a wikilink, from the root of the mirror.
+func (c *Converter) childLink(title, relPath, pageID string, opts *ConvertOptions) string {
+	if opts.Profile != ProfileObsidian {
+		return c.Links.formatChildLink(opts.escapeText(title), relPath, pageID)
+	}
+	target := path.Join(path.Dir(filepath.ToSlash(opts.FilePath)), TrimPageExt(relPath))
+	return c.Links.formatWikiLink(title, target, pageID)
 }
 
 // convertBlock converts a single block to Markdown.
@@ -290,12 +354,19 @@ func (c *Converter) generateFrontmatter(page *notion.Page, opts *ConvertOptions)
 func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOptions) string {
 	indent := strings.Repeat("  ", depth)
 
+	if c.Plugins.Handles(block.Type) {
+		children := c.convertChildren(block.Children, depth, opts)
+		if rendered, ok := c.Plugins.Convert(block.Type, block.Raw, children); ok {
+			return rendered
+		}
+	}
+
 	switch block.Type {
 	case blockTypeParagraph:
 		if block.Paragraph == nil {
 			return "\n"
 		}
-		text := notion.ParseRichTextToMarkdown(block.Paragraph.RichText)
+		text := richTextToMarkdown(block.Paragraph.RichText, opts)
 		if text == "" {
 			return "\n"
 		}
@@ -307,7 +378,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Heading1 == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.Heading1.RichText)
+		text := richTextToMarkdown(block.Heading1.RichText, opts)
 		if block.Heading1.IsToggleable {
 			var sb strings.Builder
 			fmt.Fprintf(&sb, "# %s\n", text)
@@ -322,7 +393,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Heading2 == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.Heading2.RichText)
+		text := richTextToMarkdown(block.Heading2.RichText, opts)
 		if block.Heading2.IsToggleable {
 			var sb strings.Builder
 			fmt.Fprintf(&sb, "## %s\n", text)
@@ -337,7 +408,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Heading3 == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.Heading3.RichText)
+		text := richTextToMarkdown(block.Heading3.RichText, opts)
 		if block.Heading3.IsToggleable {
 			var sb strings.Builder
 			fmt.Fprintf(&sb, "### %s\n", text)
@@ -352,7 +423,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.BulletedListItem == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.BulletedListItem.RichText)
+		text := richTextToMarkdown(block.BulletedListItem.RichText, opts)
 		result := fmt.Sprintf("%s- %s\n", indent, text)
 		result += c.convertChildren(block.Children, depth+1, opts)
 		return result
@@ -361,7 +432,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.NumberedListItem == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.NumberedListItem.RichText)
+		text := richTextToMarkdown(block.NumberedListItem.RichText, opts)
 		result := fmt.Sprintf("%s1. %s\n", indent, text)
 		result += c.convertChildren(block.Children, depth+1, opts)
 		return result
@@ -370,7 +441,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.ToDo == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.ToDo.RichText)
+		text := richTextToMarkdown(block.ToDo.RichText, opts)
 		checkbox := "[ ]"
 		if block.ToDo.Checked {
 			checkbox = "[x]"
@@ -383,29 +454,19 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Toggle == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.Toggle.RichText)
-		var sb strings.Builder
-		fmt.Fprintf(&sb, "<!-- collapsible: start -->\n**%s**\n\n", text)
-		sb.WriteString(c.convertChildren(block.Children, 0, opts))
-		sb.WriteString("<!-- collapsible: end -->\n")
-		return sb.String()
+		return c.convertToggle(block, opts)
 
 	case "code":
 		if block.Code == nil {
 			return ""
 		}
-		text := notion.ParseRichText(block.Code.RichText) // No markdown formatting inside code
-		lang := block.Code.Language
-		if lang == "plain text" {
-			lang = ""
-		}
-		return fmt.Sprintf("```%s\n%s\n```\n", lang, text)
+		return convertCode(block.Code, opts)
 
 	case "quote":
 		if block.Quote == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.Quote.RichText)
+		text := richTextToMarkdown(block.Quote.RichText, opts)
 		lines := strings.Split(text, "\n")
 		var sb strings.Builder
 		for _, line := range lines {
@@ -418,22 +479,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Callout == nil {
 			return ""
 		}
-		text := notion.ParseRichTextToMarkdown(block.Callout.RichText)
-		emoji := ""
-		if block.Callout.Icon != nil && block.Callout.Icon.Emoji != "" {
-			emoji = block.Callout.Icon.Emoji + " "
-		}
-		lines := strings.Split(text, "\n")
-		var builder strings.Builder
-		for i, line := range lines {
-			prefix := "> "
-			if i == 0 {
-				prefix = "> " + emoji
-			}
-			fmt.Fprintf(&builder, "%s%s\n", prefix, line)
-		}
-		builder.WriteString(c.convertChildren(block.Children, depth, opts))
-		return builder.String()
+		return c.convertCallout(block, depth, opts)
 
 	case "divider":
 		return "---\n"
@@ -446,7 +492,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if opts.FileProcessor != nil {
 			fileURL = opts.FileProcessor(fileURL)
 		}
-		caption := notion.ParseRichText(block.Image.Caption)
+		caption := opts.escapeText(notion.ParseRichText(block.Image.Caption))
 		if caption == "" {
 			caption = "image"
 		}
@@ -461,7 +507,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if opts.FileProcessor != nil {
 			fileURL = opts.FileProcessor(fileURL)
 		}
-		caption := notion.ParseRichText(block.Video.Caption)
+		caption := opts.escapeText(notion.ParseRichText(block.Video.Caption))
 		if caption == "" {
 			caption = "Video"
 		}
@@ -491,7 +537,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if opts.FileProcessor != nil {
 			fileURL = opts.FileProcessor(fileURL)
 		}
-		caption := notion.ParseRichText(block.PDF.Caption)
+		caption := opts.escapeText(notion.ParseRichText(block.PDF.Caption))
 		if caption == "" {
 			caption = "PDF"
 		}
@@ -502,7 +548,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Bookmark == nil {
 			return ""
 		}
-		caption := notion.ParseRichText(block.Bookmark.Caption)
+		caption := opts.escapeText(notion.ParseRichText(block.Bookmark.Caption))
 		if caption == "" {
 			caption = block.Bookmark.URL
 		}
@@ -522,20 +568,22 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 			return ""
 		}
 		// Link to child page - uses parent page's title as directory name
-		parentDir := strings.ToLower(SanitizeFilename(opts.PageTitle))
-		childFile := strings.ToLower(SanitizeFilename(block.ChildPage.Title))
-		pageID := NormalizeID(block.ID)
-		return fmt.Sprintf("- [%s](./%s/%s.md)<!-- page_id:%s -->\n", block.ChildPage.Title, parentDir, childFile, pageID)
+		parentDir := c.FilenameRules.Sanitize(opts.PageTitle)
+		childFile := c.FilenameRules.Sanitize(block.ChildPage.Title)
+		relPath := parentDir + "/" + childFile + FileExtension(opts.Profile)
+		return c.childLink(block.ChildPage.Title, relPath, NormalizeID(block.ID), opts)
 
 	case "child_database":
 		if block.ChildDatabase == nil {
 			return ""
 		}
 		// Link to child database - uses parent page's title as directory name
-		parentDir := strings.ToLower(SanitizeFilename(opts.PageTitle))
-		childFile := strings.ToLower(SanitizeFilename(block.ChildDatabase.Title))
+		parentDir := c.FilenameRules.Sanitize(opts.PageTitle)
+		childFile := c.FilenameRules.Sanitize(block.ChildDatabase.Title)
 		dbID := NormalizeID(block.ID)
-		return fmt.Sprintf("- [%s](./%s/%s.md)<!-- page_id:%s -->\n", block.ChildDatabase.Title, parentDir, childFile, dbID)
+		relPath := parentDir + "/" + childFile + FileExtension(opts.Profile)
+		link := c.childLink(block.ChildDatabase.Title, relPath, dbID, opts)
+		return link + convertInlineDatabase(opts.InlineDatabases[dbID], opts)
 
 	case "synced_block":
 		// Just render children for synced blocks
@@ -545,7 +593,7 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		if block.Table == nil {
 			return ""
 		}
-		return c.convertTable(block)
+		return c.convertTable(block, opts)
 
 	case "column_list":
 		// Render columns sequentially
@@ -575,8 +623,39 @@ func (c *Converter) convertBlock(block *notion.Block, depth int, opts *ConvertOp
 		}
 		return fmt.Sprintf("[Embed](%s)\n", block.Embed.URL)
 
+	// The following blocks can't be represented in markdown: a placeholder shows there is content in Notion
+	case "audio":
+		if block.Audio == nil {
+			return ""
+		}
+		fileURL := c.getFileURL(block.Audio)
+		if opts.FileProcessor != nil {
+			fileURL = opts.FileProcessor(fileURL)
+		}
+		caption := opts.escapeText(notion.ParseRichText(block.Audio.Caption))
+		if caption == "" {
+			caption = "Audio"
+		}
+		fileID := NormalizeID(block.ID)
+		return fmt.Sprintf("> 🎵 Audio: [%s](%s)<!-- file_id:%s -->\n", caption, fileURL, fileID)
+
+	case "breadcrumb":
+		trail := append(slices.Clone(opts.Ancestors), opts.PageTitle)
+		return fmt.Sprintf("> 🧭 %s\n", opts.escapeText(strings.Join(trail, " / ")))
+
+	case "template":
+		// Template buttons duplicate their children when clicked: the children are not page content
+		text := "Template"
+		if block.Template != nil {
+			if label := richTextToMarkdown(block.Template.RichText, opts); label != "" {
+				text = label
+			}
+		}
+		return fmt.Sprintf("> 🔘 Template button: %s\n", text)
+
 	default:
-		// Unknown block type - skip
+		// Unknown block type - skip, reporting it
+		opts.warn(WarningUnknownBlock, block.Type)
 		return ""
 	}
 }
@@ -590,45 +669,6 @@ func (c *Converter) convertChildren(children []notion.Block, depth int, opts *Co
 	return sb.String()
 }
 
-// convertTable converts a table block with its rows.
-func (c *Converter) convertTable(block *notion.Block) string {
-	if block.Table == nil || len(block.Children) == 0 {
-		return ""
-	}
-
-	var builder strings.Builder
-	width := block.Table.TableWidth
-
-	for i := range block.Children {
-		row := &block.Children[i]
-		if row.TableRow == nil {
-			continue
-		}
-
-		// Build row
-		builder.WriteString("|")
-		for j := range width {
-			cell := ""
-			if j < len(row.TableRow.Cells) {
-				cell = notion.ParseRichTextToMarkdown(row.TableRow.Cells[j])
-			}
-			fmt.Fprintf(&builder, " %s |", cell)
-		}
-		builder.WriteString("\n")
-
-		// Add header separator after first row if it's a header
-		if i == 0 && block.Table.HasColumnHeader {
-			builder.WriteString("|")
-			for range width {
-				builder.WriteString(" --- |")
-			}
-			builder.WriteString("\n")
-		}
-	}
-
-	return builder.String()
-}
-
 // getFileURL extracts URL from a file block.
 func (c *Converter) getFileURL(file *notion.FileBlock) string {
 	if file == nil {
@@ -651,8 +691,8 @@ func (c *Converter) isListItem(block *notion.Block) bool {
 }
 
 // formatIcon formats an icon for frontmatter output.
-// Returns empty string if icon is nil.
-func formatIcon(icon *notion.Icon) string {
+// Notion-hosted icons go through the asset processor, if any.
+func formatIcon(icon *notion.Icon, assetProcessor FileProcessor) string {
 	if icon == nil {
 		return ""
 	}
@@ -665,12 +705,38 @@ func formatIcon(icon *notion.Icon) string {
 		}
 	case "file":
 		if icon.File != nil {
-			return "file:" + icon.File.URL
+			return "file:" + processAsset(icon.File.URL, assetProcessor)
 		}
 	}
 	return ""
 }
 
+// formatCover formats a cover for frontmatter output, like icons.
+func formatCover(cover *notion.FileBlock, assetProcessor FileProcessor) string {
+	if cover == nil {
+		return ""
+	}
+	switch cover.Type {
+	case "external":
+		if cover.External != nil {
+			return "external:" + cover.External.URL
+		}
+	case "file":
+		if cover.File != nil {
+			return "file:" + processAsset(cover.File.URL, assetProcessor)
+		}
+	}
+	return ""
+}
+
+// processAsset returns the local path of a Notion-hosted file, or its URL without an asset processor.
+func processAsset(fileURL string, assetProcessor FileProcessor) string {
+	if assetProcessor == nil {
+		return fileURL
+	}
+	return assetProcessor(fileURL)
+}
+
 // extractPropertyValue extracts the display value from a Property.
 // Returns nil if the property has no value or is a title property (titles are handled separately).
 //
@@ -804,28 +870,26 @@ func extractPropertyValue(prop *notion.Property) any {
 	return nil
 }
 
-// formatPropertyValue formats a property value for YAML frontmatter.
-func formatPropertyValue(value any) string {
+// propertyYAMLValue converts a property value to a frontmatter value.
+// Returns nil for values that should be omitted (e.g. empty lists).
+func propertyYAMLValue(value any, timeFormat TimeFormat) any {
 	switch typedVal := value.(type) {
+	case nil:
+		return nil
 	case string:
-		return fmt.Sprintf("%q", typedVal)
-	case float64:
-		return strconv.FormatFloat(typedVal, 'f', -1, 64)
-	case int:
-		return strconv.Itoa(typedVal)
-	case bool:
-		return strconv.FormatBool(typedVal)
+		return yamlQuoted(NormalizeText(typedVal))
+	case time.Time:
+		return yamlTimestamp(timeFormat.Format(typedVal))
 	case []string:
 		if len(typedVal) == 0 {
-			return ""
+			return nil
 		}
-		var builder strings.Builder
-		builder.WriteString("\n")
-		for _, s := range typedVal {
-			fmt.Fprintf(&builder, "    - %q\n", s)
+		items := make([]string, len(typedVal))
+		for i, item := range typedVal {
+			items[i] = NormalizeText(item)
 		}
-		return strings.TrimSuffix(builder.String(), "\n")
+		return items
 	default:
-		return fmt.Sprintf("%v", typedVal)
+		return typedVal
 	}
 }
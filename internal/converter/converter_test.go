@@ -2,10 +2,12 @@ package converter
 
 import (
 	"encoding/json"
+	"log/slog"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/converter/plugin"
 	"github.com/fclairamb/ntnsync/internal/notion"
 )
 
@@ -705,6 +707,52 @@ func TestConvertBlock_Divider(t *testing.T) {
 	}
 }
 
+func TestConvertBlock_Placeholders(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	tests := []struct {
+		name  string
+		block notion.Block
+		opts  ConvertOptions
+		want  string
+	}{
+		{
+			name: "audio",
+			block: notion.Block{
+				ID:    "audio123",
+				Type:  "audio",
+				Audio: &notion.FileBlock{External: &notion.ExternalFile{URL: "https://example.com/a.mp3"}},
+			},
+			want: "> 🎵 Audio: [Audio](https://example.com/a.mp3)<!-- file_id:audio123 -->\n",
+		},
+		{
+			name:  "breadcrumb",
+			block: notion.Block{Type: "breadcrumb", Breadcrumb: &notion.BreadcrumbBlock{}},
+			opts:  ConvertOptions{PageTitle: "Page", Ancestors: []string{"Root", "Parent"}},
+			want:  "> 🧭 Root / Parent / Page\n",
+		},
+		{
+			name: "template button",
+			block: notion.Block{
+				Type:     "template",
+				Template: &notion.TemplateBlock{RichText: []notion.RichText{{Type: "text", PlainText: "New meeting"}}},
+				Children: []notion.Block{{Type: "divider"}},
+			},
+			want: "> 🔘 Template button: New meeting\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := c.convertBlock(&tc.block, 0, &tc.opts); got != tc.want {
+				t.Errorf("convertBlock() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestConvertBlock_Unknown(t *testing.T) {
 	t.Parallel()
 
@@ -786,6 +834,23 @@ func TestConvertWithOptions_DownloadDuration(t *testing.T) {
 	})
 }
 
+func TestConvertWithOptions_OpenComments(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{ID: "123e4567-e89b-12d3-a456-426614174000", URL: "https://notion.so/test"}
+
+	result := string(c.ConvertWithOptions(page, nil, &ConvertOptions{OpenComments: 3}))
+	if !strings.Contains(result, "\nopen_comments: 3\n") {
+		t.Errorf("ConvertWithOptions() should include open_comments: 3, got:\n%s", result)
+	}
+
+	result = string(c.ConvertWithOptions(page, nil, &ConvertOptions{}))
+	if strings.Contains(result, "open_comments") {
+		t.Errorf("ConvertWithOptions() should not include open_comments when zero, got:\n%s", result)
+	}
+}
+
 func TestConvert_PropertiesAlphabeticalOrder(t *testing.T) {
 	t.Parallel()
 
@@ -822,3 +887,135 @@ func TestConvert_PropertiesAlphabeticalOrder(t *testing.T) {
 		t.Errorf("Convert() properties not in alphabetical order, want:\n%s\ngot:\n%s", wantProps, result)
 	}
 }
+
+func TestConvert_NormalizesTitleAndContent(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{
+		ID:             "123e4567-e89b-12d3-a456-426614174000",
+		LastEditedTime: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Parent:         notion.Parent{Type: "database_id", DatabaseID: "db-1"},
+		Properties: map[string]notion.Property{
+			"Name": {
+				Type:  "title",
+				Title: []notion.RichText{{Type: "text", PlainText: "\ufeffCafé \u200b🚀 Привет"}},
+			},
+			"Tag": {
+				Type:     "rich_text",
+				RichText: []notion.RichText{{Type: "text", PlainText: "a\u200bb\x00"}},
+			},
+		},
+	}
+	blocks := []notion.Block{
+		{
+			Type: "paragraph",
+			Paragraph: &notion.ParagraphBlock{
+				RichText: []notion.RichText{{Type: "text", PlainText: "crème\u200b brûlée"}},
+			},
+		},
+	}
+
+	resultStr := string(c.Convert(page, blocks))
+
+	if !strings.Contains(resultStr, "title: \"Café 🚀 Привет\"\n") {
+		t.Errorf("frontmatter title should be normalized, got:\n%s", resultStr)
+	}
+	if !strings.Contains(resultStr, "  Tag: \"ab\"\n") {
+		t.Errorf("frontmatter property should be normalized, got:\n%s", resultStr)
+	}
+	if !strings.Contains(resultStr, "# Café 🚀 Привет\n") {
+		t.Errorf("h1 title should be normalized, got:\n%s", resultStr)
+	}
+	if !strings.Contains(resultStr, "crème brûlée") {
+		t.Errorf("content should be normalized, got:\n%s", resultStr)
+	}
+	if strings.ContainsAny(resultStr, "\u200b\ufeff\x00") {
+		t.Error("output should not contain zero-width or control characters")
+	}
+}
+
+func TestConvert_NormalizeKeepsCode(t *testing.T) {
+	t.Parallel()
+
+	page := &notion.Page{
+		ID: "page123",
+		Properties: map[string]notion.Property{
+			"title": {Type: "title", Title: []notion.RichText{{Type: "text", PlainText: "Code"}}},
+		},
+	}
+	blocks := []notion.Block{
+		{
+			Type: "paragraph",
+			Paragraph: &notion.ParagraphBlock{RichText: []notion.RichText{
+				{Type: "text", PlainText: "soft\u00adhyphen and "},
+				{Type: "text", PlainText: "a\u200bb", Annotations: &notion.Annotations{Code: true}},
+			}},
+		},
+		{Type: "code", Code: &notion.CodeBlock{
+			Language: "go",
+			RichText: []notion.RichText{{Type: "text", PlainText: "s := \"\x1b[0m\u00ad\""}},
+		}},
+	}
+
+	c := NewConverter()
+	c.IncludeFrontmatter = false
+	got := string(c.Convert(page, blocks))
+
+	for _, want := range []string{
+		"softhyphen and `a\u200bb`\n",
+		"```go\ns := \"\x1b[0m\u00ad\"\n```\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Convert() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+// testEmbedPlugin renders embeds and a custom block type.
+type testEmbedPlugin struct{}
+
+func (testEmbedPlugin) BlockTypes() []string {
+	return []string{"embed", "custom_tool"}
+}
+
+func (testEmbedPlugin) ConvertBlock(block []byte, children string) (string, error) {
+	var parsed struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	if err := json.Unmarshal(block, &parsed); err != nil {
+		return "", err
+	}
+	return "<" + parsed.Type + " " + parsed.ID + ">" + children, nil
+}
+
+func TestConvertBlock_Plugins(t *testing.T) {
+	t.Parallel()
+
+	var blocks []notion.Block
+	err := json.Unmarshal([]byte(`[
+		{"object":"block","id":"b1","type":"embed","embed":{"url":"https://tool.internal/1"}},
+		{"object":"block","id":"b2","type":"custom_tool","custom_tool":{}},
+		{"object":"block","id":"b3","type":"divider","divider":{}}
+	]`), &blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conv := NewConverter()
+	conv.IncludeFrontmatter = false
+	if got := conv.convertBlock(&blocks[0], 0, &ConvertOptions{}); got != "[Embed](https://tool.internal/1)\n" {
+		t.Errorf("without plugins, embed = %q", got)
+	}
+
+	conv.Plugins = plugin.NewRegistry(slog.New(slog.DiscardHandler))
+	conv.Plugins.Register(testEmbedPlugin{})
+
+	want := map[int]string{0: "<embed b1>\n", 1: "<custom_tool b2>\n", 2: "---\n"}
+	for i, expected := range want {
+		if got := conv.convertBlock(&blocks[i], 0, &ConvertOptions{}); got != expected {
+			t.Errorf("block %s = %q, want %q", blocks[i].ID, got, expected)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Database render modes select how the pages of a database are listed in its file. The Notion API doesn't expose
+// the saved views of databases (filters, sorts), so pages are listed in the order of the database query.
+const (
+	// DatabaseRenderList renders links to the pages of the database.
+	DatabaseRenderList = "list"
+	// DatabaseRenderTable renders a table of the pages of the database: a link to each page followed by the values
+	// of the properties of the database schema.
+	DatabaseRenderTable = "table"
+
+	// defaultTitleColumn is the header of the title column of databases without a title property in their schema.
+	defaultTitleColumn = "Name"
+)
+
+// DatabaseRenderModes lists the supported database render modes.
+var DatabaseRenderModes = []string{DatabaseRenderList, DatabaseRenderTable}
+
+// IsValidDatabaseRenderMode returns true if the database render mode is supported. An empty mode is the default
+// one.
+func IsValidDatabaseRenderMode(mode string) bool {
+	return mode == "" || slices.Contains(DatabaseRenderModes, mode)
+}
+
+// DatabaseColumns returns the columns of the table of a database: the name of its title property, and the other
+// properties of its schema selected like frontmatter properties (NTN_DATABASE_PROPERTIES), by name. Without a
+// schema, the properties of the pages are used.
+func (c *Converter) DatabaseColumns(database *notion.Database, pages []notion.DatabasePage) (string, []string) {
+	titleColumn, _ := database.TitleProperty()
+
+	var available []string
+	for name := range database.Properties {
+		if name != titleColumn {
+			available = append(available, name)
+		}
+	}
+	if len(database.Properties) == 0 {
+		for i := range pages {
+			for name := range pages[i].Properties {
+				if name != pages[i].TitleProperty && !slices.Contains(available, name) &&
+					!isTitleProperty(pages[i].Properties[name]) {
+					available = append(available, name)
+				}
+			}
+		}
+	}
+	if titleColumn == "" {
+		titleColumn = defaultTitleColumn
+	}
+
+	columns := c.Properties.filterFor(database.ID, database.DataSourceID).names(available)
+	if c.Properties.Max > 0 && len(columns) > c.Properties.Max {
+		columns = columns[:c.Properties.Max]
+	}
+	return titleColumn, columns
+}
+
+// isTitleProperty returns true if a raw page property is the title property.
+func isTitleProperty(raw json.RawMessage) bool {
+	var prop struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(raw, &prop) == nil && prop.Type == "title"
+}
+
+// convertDatabaseTable renders the direct child pages of a database as a markdown table.
+func (c *Converter) convertDatabaseTable(
+	database *notion.Database, pages []notion.DatabasePage, opts *ConvertOptions,
+) string {
+	titleColumn, columns := c.DatabaseColumns(database, pages)
+
+	var builder strings.Builder
+	builder.WriteString("| " + escapeTableCell(opts.escapeText(titleColumn), opts) + " |")
+	for _, column := range columns {
+		fmt.Fprintf(&builder, " %s |", escapeTableCell(opts.escapeText(column), opts))
+	}
+	builder.WriteString("\n|")
+	for range len(columns) + 1 {
+		builder.WriteString(" --- |")
+	}
+	builder.WriteString("\n")
+
+	for i := range pages {
+		dbPage := &pages[i]
+		pageTitle, relPath := c.databasePageLink(dbPage, opts)
+		link := c.childLink(pageTitle, relPath, NormalizeID(dbPage.ID), opts)
+		link = strings.TrimSpace(strings.TrimPrefix(link, "- "))
+		fmt.Fprintf(&builder, "| %s |", escapeTableCell(link, opts))
+		for _, column := range columns {
+			fmt.Fprintf(&builder, " %s |", escapeTableCell(opts.escapeText(PropertyText(dbPage.Properties[column])), opts))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// databasePageLink returns the title of a page of a database and the path of its file, relative to the file of the
+// database. The path uses the sanitized filename of the database file (e.g., "wiki" not "Wiki").
+func (c *Converter) databasePageLink(dbPage *notion.DatabasePage, opts *ConvertOptions) (string, string) {
+	pageTitle := dbPage.Title()
+	if pageTitle == "" {
+		pageTitle = "Untitled"
+	}
+	baseFilename := TrimPageExt(filepath.Base(opts.FilePath))
+	return pageTitle, baseFilename + "/" + c.FilenameRules.Sanitize(pageTitle) + FileExtension(opts.Profile)
+}
@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestConvertDatabase_Table(t *testing.T) {
+	t.Parallel()
+
+	dbID := "db123e4567-e89b-12d3-a456-426614174000"
+	database := &notion.Database{
+		ID:    dbID,
+		Title: []notion.RichText{{Type: "text", PlainText: "Tasks"}},
+		Properties: map[string]any{
+			"Task":   map[string]any{"id": "title", "type": "title"},
+			"Status": map[string]any{"id": "a", "type": "select"},
+			"Notes":  map[string]any{"id": "b", "type": "rich_text"},
+		},
+	}
+	page := func(id, title, status, notes string) notion.DatabasePage {
+		props := map[string]any{
+			"Task":   map[string]any{"type": "title", "title": []notion.RichText{{Type: "text", PlainText: title}}},
+			"Status": map[string]any{"type": "select", "select": map[string]any{"name": status}},
+			"Notes": map[string]any{"type": "rich_text", "rich_text": []notion.RichText{
+				{Type: "text", PlainText: notes},
+			}},
+		}
+		raw := make(map[string]json.RawMessage, len(props))
+		for name, prop := range props {
+			data, err := json.Marshal(prop)
+			if err != nil {
+				t.Fatalf("marshal property: %v", err)
+			}
+			raw[name] = data
+		}
+		return notion.DatabasePage{
+			ID:         id,
+			Parent:     notion.Parent{Type: "database_id", DatabaseID: dbID},
+			Properties: raw,
+		}
+	}
+	dbPages := []notion.DatabasePage{
+		page("page1", "Write docs", "Done", "a | b"),
+		page("page2", "Fix bug", "", ""),
+	}
+
+	c := NewConverter()
+	result := string(c.ConvertDatabase(database, dbPages, &ConvertOptions{
+		FilePath:           "tech/tasks.md",
+		DatabaseRenderMode: DatabaseRenderTable,
+	}))
+
+	for _, want := range []string{
+		"| Task | Notes | Status |\n| --- | --- | --- |\n",
+		"| [Write docs](./tasks/write-docs.md)<!-- page_id:page1 --> | a \\| b | Done |\n",
+		"| [Fix bug](./tasks/fix-bug.md)<!-- page_id:page2 --> |  |  |\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("ConvertDatabase() = %q, want it to contain %q", result, want)
+		}
+	}
+}
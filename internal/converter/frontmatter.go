@@ -0,0 +1,221 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	yamlIndent = "  "
+
+	// yamlIndicators are characters that cannot start a plain (unquoted) YAML scalar.
+	yamlIndicators = "-?:,[]{}#&*!|>'\"%@`"
+)
+
+// yamlNumberPattern matches the strings YAML 1.1 or 1.2 readers may load as numbers, in lower case: decimal,
+// binary, octal and hexadecimal integers, floats, sexagesimal numbers like 1:30, infinity and NaN.
+var yamlNumberPattern = regexp.MustCompile(
+	`^[-+]?(0[bxo][0-9a-f_]+|[0-9_.:]*[0-9][0-9_.:]*(e[-+]?[0-9]+)?|\.inf|\.nan)$`)
+
+// yamlTimestampPattern matches the strings YAML 1.1 readers may load as dates or timestamps.
+var yamlTimestampPattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([tT \t]|$)`)
+
+// yamlTimestamp is a formatted timestamp, emitted as a plain scalar when YAML readers load it as a timestamp, and
+// as a string otherwise.
+type yamlTimestamp string
+
+// yamlQuoted is a string that is always emitted as a double-quoted YAML scalar,
+// even when it would be safe as a plain scalar. Used for free text like titles.
+type yamlQuoted string
+
+// yamlField is a key/value pair of the frontmatter, in output order.
+// Supported values: string, yamlQuoted, yamlTimestamp, bool, int, float64, time.Time, time.Duration,
+// []string (sequence of quoted strings) and yamlMapping (nested mapping).
+type yamlField struct {
+	key   string
+	value any
+}
+
+// yamlMapping is an ordered YAML mapping. Go maps and generic marshalers don't preserve
+// key order, and the frontmatter order is part of the file format (and of git diffs).
+type yamlMapping []yamlField
+
+// add appends a key/value pair to the mapping.
+func (m *yamlMapping) add(key string, value any) {
+	*m = append(*m, yamlField{key: key, value: value})
+}
+
+// addProperties appends database properties as top-level fields, for tools reading them from there (Obsidian).
+// Properties named like a field of the mapping get a "property_" prefix.
+func (m *yamlMapping) addProperties(properties yamlMapping) {
+	for _, prop := range properties {
+		key := prop.key
+		if slices.ContainsFunc(*m, func(field yamlField) bool { return field.key == key }) {
+			key = "property_" + key
+		}
+		m.add(key, prop.value)
+	}
+}
+
+// encode writes the mapping as YAML at the given indentation level.
+func (m yamlMapping) encode(builder *strings.Builder, level int) {
+	indent := strings.Repeat(yamlIndent, level)
+	for _, field := range m {
+		builder.WriteString(indent)
+		builder.WriteString(yamlKey(field.key))
+		builder.WriteString(":")
+
+		switch value := field.value.(type) {
+		case yamlMapping:
+			builder.WriteString("\n")
+			value.encode(builder, level+1)
+		case []string:
+			builder.WriteString("\n")
+			for _, item := range value {
+				fmt.Fprintf(builder, "%s%s- %s\n", indent, yamlIndent, yamlDoubleQuoted(item))
+			}
+		default:
+			builder.WriteString(" ")
+			builder.WriteString(yamlScalar(value))
+			builder.WriteString("\n")
+		}
+	}
+}
+
+// frontmatter returns the mapping as a markdown frontmatter block.
+func (m yamlMapping) frontmatter() string {
+	var builder strings.Builder
+	builder.WriteString("---\n")
+	m.encode(&builder, 0)
+	builder.WriteString("---\n\n")
+	return builder.String()
+}
+
+// yamlScalar formats a scalar value.
+func yamlScalar(value any) string {
+	switch typedVal := value.(type) {
+	case yamlQuoted:
+		return yamlDoubleQuoted(string(typedVal))
+	case string:
+		return yamlString(typedVal)
+	case yamlTimestamp:
+		if yamlTimestampPattern.MatchString(string(typedVal)) && isPlainYAMLText(string(typedVal)) {
+			return string(typedVal)
+		}
+		return yamlString(string(typedVal))
+	case bool:
+		return strconv.FormatBool(typedVal)
+	case int:
+		return strconv.Itoa(typedVal)
+	case float64:
+		return strconv.FormatFloat(typedVal, 'f', -1, 64)
+	case time.Time:
+		return typedVal.Format(time.RFC3339)
+	case time.Duration:
+		return typedVal.String()
+	default:
+		return yamlString(fmt.Sprintf("%v", typedVal))
+	}
+}
+
+// yamlKey formats a mapping key, quoting it if needed (e.g. property names with colons).
+func yamlKey(key string) string {
+	return yamlString(key)
+}
+
+// yamlString formats a string as a plain scalar when that is unambiguous, and as a
+// double-quoted scalar otherwise.
+func yamlString(s string) string {
+	if isPlainYAMLSafe(s) {
+		return s
+	}
+	return yamlDoubleQuoted(s)
+}
+
+// isPlainYAMLSafe returns true if the string can be written unquoted and reads back as the same string, with
+// YAML 1.1 readers (PyYAML, Obsidian, Hugo) as well as YAML 1.2 ones: not as a number, timestamp, boolean, null,
+// or a different structure.
+func isPlainYAMLSafe(s string) bool {
+	if !isPlainYAMLText(s) {
+		return false
+	}
+
+	lower := strings.ToLower(s)
+	switch lower {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~", "<<", "=":
+		return false
+	}
+	if yamlNumberPattern.MatchString(lower) || yamlTimestampPattern.MatchString(s) {
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return false
+	}
+
+	return true
+}
+
+// isPlainYAMLText returns true if the string can be written unquoted without being read as a different structure
+// (an indicator, a comment, a mapping, or trimmed spaces).
+func isPlainYAMLText(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	if strings.ContainsRune(yamlIndicators, rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// yamlDoubleQuoted formats a string as a YAML double-quoted scalar.
+// Only escapes defined by YAML are used, so every YAML parser reads back the exact string.
+func yamlDoubleQuoted(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s) + 2) //nolint:mnd // Surrounding quotes
+	builder.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			builder.WriteString(`\"`)
+		case '\\':
+			builder.WriteString(`\\`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\u2028':
+			builder.WriteString(`\L`)
+		case '\u2029':
+			builder.WriteString(`\P`)
+		default:
+			switch {
+			case unicode.IsPrint(r):
+				builder.WriteRune(r)
+			case r <= 0xFFFF:
+				fmt.Fprintf(&builder, `\u%04X`, r)
+			default:
+				fmt.Fprintf(&builder, `\U%08X`, r)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
@@ -0,0 +1,301 @@
+package converter
+
+import (
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestYAMLString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "tech", want: "tech"},
+		{name: "path", input: "tech/wiki/page.md", want: "tech/wiki/page.md"},
+		{name: "url", input: "https://notion.so/page-123", want: "https://notion.so/page-123"},
+		{name: "empty", input: "", want: `""`},
+		{name: "colon space", input: "Meeting: notes", want: `"Meeting: notes"`},
+		{name: "trailing colon", input: "Notes:", want: `"Notes:"`},
+		{name: "comment", input: "Issue #12", want: `"Issue #12"`},
+		{name: "leading indicator", input: "- item", want: `"- item"`},
+		{name: "leading quote", input: `'quoted'`, want: `"'quoted'"`},
+		{name: "leading space", input: " padded", want: `" padded"`},
+		{name: "boolean word", input: "yes", want: `"yes"`},
+		{name: "null word", input: "null", want: `"null"`},
+		{name: "number", input: "12345", want: `"12345"`},
+		{name: "float", input: "1e10", want: `"1e10"`},
+		{name: "hex number", input: "0x1F", want: `"0x1F"`},
+		{name: "newline", input: "line1\nline2", want: `"line1\nline2"`},
+		{name: "date", input: "2024-01-02", want: `"2024-01-02"`},
+		{name: "timestamp", input: "2024-01-02 10:30:00", want: `"2024-01-02 10:30:00"`},
+		{name: "sexagesimal", input: "1:30", want: `"1:30"`},
+		{name: "infinity", input: ".inf", want: `".inf"`},
+		{name: "nan", input: ".NaN", want: `".NaN"`},
+		{name: "underscored number", input: "1_000", want: `"1_000"`},
+		{name: "leading dot float", input: ".5", want: `".5"`},
+		{name: "binary number", input: "0b101", want: `"0b101"`},
+		{name: "tilde", input: "~", want: `"~"`},
+		{name: "merge key", input: "<<", want: `"<<"`},
+		{name: "version", input: "v1.2.3", want: "v1.2.3"},
+		{name: "page id", input: "123e4567-e89b-12d3-a456-426614174000", want: "123e4567-e89b-12d3-a456-426614174000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := yamlString(tt.input); got != tt.want {
+				t.Errorf("yamlString(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLScalar_Timestamp(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]string{
+		"2024-01-02T10:30:00Z":      "2024-01-02T10:30:00Z",
+		"2024-01-02T10:30:00+02:00": "2024-01-02T10:30:00+02:00",
+		"2024-01-02 10:30:00":       "2024-01-02 10:30:00",
+		"02/01/2024 10:30":          "02/01/2024 10:30",
+		"Jan 2: 10:30":              `"Jan 2: 10:30"`,
+	} {
+		if got := yamlScalar(yamlTimestamp(input)); got != want {
+			t.Errorf("yamlScalar(yamlTimestamp(%q)) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestYAMLDoubleQuoted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "quotes", input: `Say "hi"`, want: `"Say \"hi\""`},
+		{name: "backslash", input: `C:\path`, want: `"C:\\path"`},
+		{name: "newline and tab", input: "a\nb\tc\r", want: `"a\nb\tc\r"`},
+		{name: "line separator", input: "a\u2028b\u2029c", want: `"a\Lb\Pc"`},
+		{name: "control character", input: "a\x07b", want: `"a\u0007b"`},
+		{name: "non-breaking space", input: "a\u00a0b", want: `"a\u00A0b"`},
+		{name: "unicode kept", input: "Café 🚀 Привет", want: `"Café 🚀 Привет"`},
+		{name: "no go-specific escapes", input: "\x1b", want: `"\u001B"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := yamlDoubleQuoted(tt.input); got != tt.want {
+				t.Errorf("yamlDoubleQuoted(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLMapping_PreservesOrder(t *testing.T) {
+	t.Parallel()
+
+	var nested yamlMapping
+	nested.add("b", 2)
+	nested.add("a", yamlQuoted("x"))
+
+	var fields yamlMapping
+	fields.add("zeta", "last-first")
+	fields.add("alpha", true)
+	fields.add("when", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	fields.add("took", 1500*time.Millisecond)
+	fields.add("list", []string{"one", `t"wo`})
+	fields.add("nested", nested)
+
+	want := "---\n" +
+		"zeta: last-first\n" +
+		"alpha: true\n" +
+		"when: 2024-01-15T10:30:00Z\n" +
+		"took: 1.5s\n" +
+		"list:\n" +
+		"  - \"one\"\n" +
+		"  - \"t\\\"wo\"\n" +
+		"nested:\n" +
+		"  b: 2\n" +
+		"  a: \"x\"\n" +
+		"---\n\n"
+
+	if got := fields.frontmatter(); got != want {
+		t.Errorf("frontmatter() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateFrontmatter_SpecialCharacters(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{
+		ID:             "abc123",
+		LastEditedTime: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Parent:         notion.Parent{Type: "database_id", DatabaseID: "db-1"},
+		Properties: map[string]notion.Property{
+			"Name": {
+				Type:  "title",
+				Title: []notion.RichText{{Type: "text", PlainText: "Q&A: \"What's next?\"\nPart 2"}},
+			},
+			"Status: current": {
+				Type:   "select",
+				Select: &notion.SelectOption{Name: "In progress # soon"},
+			},
+			"Tags": {
+				Type:        "multi_select",
+				MultiSelect: []notion.SelectOption{{Name: "a: b"}, {Name: `back\slash`}},
+			},
+		},
+	}
+
+	result := c.generateFrontmatter(page, &ConvertOptions{Folder: "tech", FilePath: "tech/q-a.md"})
+
+	expected := []string{
+		"title: \"Q&A: \\\"What's next?\\\"\\nPart 2\"\n",
+		"notion_folder: tech\n",
+		"file_path: tech/q-a.md\n",
+		"properties:\n",
+		"  \"Status: current\": \"In progress # soon\"\n",
+		"  Tags:\n    - \"a: b\"\n    - \"back\\\\slash\"\n",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("frontmatter should contain %q, got:\n%s", want, result)
+		}
+	}
+
+	// Every line of the frontmatter must be a single YAML line: no raw newline in values.
+	lines := strings.Split(strings.TrimSuffix(result, "---\n\n"), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Part 2") {
+			t.Errorf("title newline leaked into frontmatter: %q", line)
+		}
+	}
+}
+
+func TestGenerateFrontmatter_Aliases(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{
+		ID: "abc123",
+		Properties: map[string]notion.Property{
+			"title": {Type: "title", Title: []notion.RichText{{Type: "text", PlainText: "Roadmap 2026"}}},
+		},
+	}
+
+	result := c.generateFrontmatter(page, &ConvertOptions{Aliases: []string{"Roadmap", "tech/roadmap.md"}})
+	want := "title: \"Roadmap 2026\"\naliases:\n  - \"Roadmap\"\n  - \"tech/roadmap.md\"\n"
+	if !strings.Contains(result, want) {
+		t.Errorf("frontmatter should contain %q, got:\n%s", want, result)
+	}
+
+	if result = c.generateFrontmatter(page, &ConvertOptions{}); strings.Contains(result, "aliases:") {
+		t.Errorf("frontmatter without aliases should not have an aliases field, got:\n%s", result)
+	}
+}
+
+func TestGenerateFrontmatter_PropertySelection(t *testing.T) {
+	t.Parallel()
+
+	selectProp := func(name string) notion.Property {
+		return notion.Property{Type: "select", Select: &notion.SelectOption{Name: name}}
+	}
+	page := &notion.Page{
+		ID:     "abc123",
+		Parent: notion.Parent{Type: "database_id", DatabaseID: "11111111-2222-3333-4444-555555555555"},
+		Properties: map[string]notion.Property{
+			"Status":   selectProp("Done"),
+			"Owner":    selectProp("Alice"),
+			"Cost":     selectProp("High"),
+			"Priority": selectProp("P1"),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		selection PropertySelection
+		want      string
+	}{
+		{
+			name: "all properties, by name",
+			want: "properties:\n  Cost: \"High\"\n  Owner: \"Alice\"\n  Priority: \"P1\"\n  Status: \"Done\"\n",
+		},
+		{
+			name: "database allow list, in order",
+			selection: PropertySelection{
+				Default: PropertyFilter{Deny: []string{"Status"}},
+				Databases: map[string]PropertyFilter{
+					"11111111222233334444555555555555": {Allow: []string{"status", "Owner", "Unknown"}},
+				},
+			},
+			want: "properties:\n  Status: \"Done\"\n  Owner: \"Alice\"\n---",
+		},
+		{
+			name:      "default deny list",
+			selection: PropertySelection{Default: PropertyFilter{Deny: []string{"cost", "Priority"}}},
+			want:      "properties:\n  Owner: \"Alice\"\n  Status: \"Done\"\n---",
+		},
+		{
+			name:      "capped",
+			selection: PropertySelection{Max: 2},
+			want:      "properties:\n  Cost: \"High\"\n  Owner: \"Alice\"\n---",
+		},
+	}
+	for _, tt := range tests {
+		c := NewConverter()
+		c.Properties = tt.selection
+		if result := c.generateFrontmatter(page, &ConvertOptions{}); !strings.Contains(result, tt.want) {
+			t.Errorf("%s: frontmatter should contain %q, got:\n%s", tt.name, tt.want, result)
+		}
+	}
+
+	c := NewConverter()
+	c.Properties = PropertySelection{Default: PropertyFilter{Allow: []string{"Unknown"}}}
+	if result := c.generateFrontmatter(page, &ConvertOptions{}); strings.Contains(result, "properties:") {
+		t.Errorf("frontmatter without selected properties should not have a properties field, got:\n%s", result)
+	}
+}
+
+func TestGenerateFrontmatter_IconAndCoverAssets(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{
+		ID:    "abc123",
+		Icon:  &notion.Icon{Type: "file", File: &notion.File{URL: "https://s3.amazonaws.com/icon.png?sig"}},
+		Cover: &notion.FileBlock{Type: "external", External: &notion.ExternalFile{URL: "https://example.com/cover.jpg"}},
+	}
+
+	result := c.generateFrontmatter(page, &ConvertOptions{})
+	for _, want := range []string{
+		"icon: \"file:https://s3.amazonaws.com/icon.png?sig\"\n",
+		"cover: \"external:https://example.com/cover.jpg\"\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("frontmatter should contain %q, got:\n%s", want, result)
+		}
+	}
+
+	page.Cover = &notion.FileBlock{Type: "file", File: &notion.File{URL: "https://s3.amazonaws.com/cover.jpg?sig"}}
+	result = c.generateFrontmatter(page, &ConvertOptions{
+		AssetProcessor: func(fileURL string) string {
+			return "../assets/" + strings.TrimSuffix(path.Base(fileURL), "?sig")
+		},
+	})
+	for _, want := range []string{"icon: \"file:../assets/icon.png\"\n", "cover: \"file:../assets/cover.jpg\"\n"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("frontmatter should contain %q, got:\n%s", want, result)
+		}
+	}
+}
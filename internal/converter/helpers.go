@@ -1,6 +1,8 @@
 package converter
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"unicode"
 
@@ -12,8 +14,93 @@ import (
 const (
 	// Filename constraints.
 	maxFilenameLength = 100 // Maximum filename length before truncation
+
+	// filenameSeparatorChars are the characters that separate words in filenames.
+	filenameSeparatorChars = " -_/\\:|"
+
+	// Emoji-only titles are slugified from their code points.
+	emojiSlugPrefix   = "emoji"
+	maxEmojiSlugRunes = 4 // Maximum number of code points used in an emoji slug
 )
 
+// Filename case strategies.
+const (
+	FilenameCaseLower    = "lower"    // Lowercase filenames (default)
+	FilenameCasePreserve = "preserve" // Keep the case of the title
+)
+
+// FilenameRules controls how page titles are turned into filenames.
+// The rules in effect are recorded in the sync state, so changing them never renames existing files.
+type FilenameRules struct {
+	Case      string   `json:"case"`                // FilenameCaseLower or FilenameCasePreserve
+	Separator string   `json:"separator"`           // Word separator: "-" or "_"
+	MaxLength int      `json:"max_length"`          // Maximum filename length (without extension)
+	Stopwords []string `json:"stopwords,omitempty"` // Words removed from filenames (case-insensitive)
+}
+
+// DefaultFilenameRules returns the default rules: lowercase, dash-separated, 100 characters.
+func DefaultFilenameRules() FilenameRules {
+	return FilenameRules{
+		Case:      FilenameCaseLower,
+		Separator: "-",
+		MaxLength: maxFilenameLength,
+	}
+}
+
+// withDefaults replaces unset or invalid fields with their default values.
+func (r FilenameRules) withDefaults() FilenameRules {
+	defaults := DefaultFilenameRules()
+	if r.Case != FilenameCasePreserve {
+		r.Case = defaults.Case
+	}
+	if r.Separator != "_" {
+		r.Separator = defaults.Separator
+	}
+	if r.MaxLength <= 0 {
+		r.MaxLength = defaults.MaxLength
+	}
+	return r
+}
+
+// Equal returns true if both rules produce the same filenames.
+func (r FilenameRules) Equal(other FilenameRules) bool {
+	r, other = r.withDefaults(), other.withDefaults()
+	return r.Case == other.Case && r.Separator == other.Separator &&
+		r.MaxLength == other.MaxLength && slices.Equal(r.Stopwords, other.Stopwords)
+}
+
+// NormalizeText normalizes text coming from Notion before it is written to markdown or frontmatter.
+// Invalid UTF-8 sequences are dropped, the text is converted to NFC, and invisible characters
+// that break YAML parsers or make identical titles differ (zero-width space, word joiner, BOM,
+// control characters other than tab and newline) are removed.
+// Zero-width joiners and non-joiners are kept: they are meaningful in emoji sequences and
+// in scripts like Persian.
+func NormalizeText(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = norm.NFC.String(s)
+
+	return strings.Map(func(r rune) rune {
+		if isInvisibleRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isInvisibleRune returns true for characters stripped by NormalizeText.
+func isInvisibleRune(r rune) bool {
+	switch r {
+	case '\t', '\n':
+		return false
+	case '\u200B', // zero-width space
+		'\u2060', // word joiner
+		'\uFEFF', // byte order mark / zero-width no-break space
+		'\u00AD': // soft hyphen
+		return true
+	}
+	return unicode.IsControl(r)
+}
+
 // transliterate converts accented characters to their ASCII equivalents.
 // Uses Unicode NFD normalization to decompose characters like é into e + combining accent,
 // then removes the combining marks.
@@ -24,59 +111,121 @@ func transliterate(s string) string {
 	return result
 }
 
-// SanitizeFilename makes a string safe for use as a filename.
+// SanitizeFilename makes a string safe for use as a filename, using the default rules.
 // Only allows pattern [a-z][a-z0-9-]* (lowercase letters, numbers, hyphens).
 // Must start with a letter.
+// Titles made only of emoji get a slug built from their code points (e.g. "emoji-1f680").
 func SanitizeFilename(name string) string {
-	// Transliterate accented characters to ASCII equivalents
-	name = transliterate(name)
+	return DefaultFilenameRules().Sanitize(name)
+}
 
-	// Convert to lowercase
-	name = strings.ToLower(name)
+// Sanitize makes a string safe for use as a filename according to the rules.
+// Only ASCII letters, numbers and the separator are kept, and the result starts with a letter.
+func (r FilenameRules) Sanitize(name string) string {
+	r = r.withDefaults()
 
-	// Build result with only allowed characters
-	var result strings.Builder
-	for _, r := range name {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
-			result.WriteRune(r)
-		} else if r == ' ' || r == '-' || r == '_' || r == '/' || r == '\\' || r == ':' || r == '|' {
-			// Replace separators with dash
-			result.WriteRune('-')
-		}
-		// All other characters (including non-ASCII) are dropped
-	}
+	name = NormalizeText(name)
+	original := name
 
-	filename := result.String()
+	// Transliterate accented characters to ASCII equivalents
+	name = transliterate(name)
 
-	// Collapse multiple dashes
-	for strings.Contains(filename, "--") {
-		filename = strings.ReplaceAll(filename, "--", "-")
+	if r.Case != FilenameCasePreserve {
+		name = strings.ToLower(name)
 	}
 
-	// Trim dashes from ends
-	filename = strings.Trim(filename, "-")
+	words := r.removeStopwords(filenameWords(name))
+	filename := strings.Join(words, r.Separator)
 
 	// Ensure it starts with a letter
-	for len(filename) > 0 && (filename[0] < 'a' || filename[0] > 'z') {
+	for len(filename) > 0 && !isASCIILetter(rune(filename[0])) {
 		filename = filename[1:]
 	}
 
 	// Truncate to reasonable length
-	if len(filename) > maxFilenameLength {
-		filename = filename[:maxFilenameLength]
+	if len(filename) > r.MaxLength {
+		filename = filename[:r.MaxLength]
 	}
 
-	// Ensure it doesn't end with a dash after truncation
-	filename = strings.TrimRight(filename, "-")
+	// Ensure it doesn't end with a separator after truncation
+	filename = strings.TrimRight(filename, r.Separator)
 
 	// Handle empty result
 	if filename == "" {
-		filename = defaultUntitledStr
+		filename = emojiSlug(original, r.Separator)
 	}
 
 	return filename
 }
 
+// filenameWords splits a name into words of ASCII letters and numbers.
+// Separator characters end a word; all other characters (including non-ASCII) are dropped.
+func filenameWords(name string) []string {
+	var words []string
+	var word strings.Builder
+	for _, r := range name {
+		switch {
+		case isASCIILetter(r) || (r >= '0' && r <= '9'):
+			word.WriteRune(r)
+		case strings.ContainsRune(filenameSeparatorChars, r):
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		}
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// removeStopwords drops stopwords from the words, unless all of them are stopwords.
+func (r FilenameRules) removeStopwords(words []string) []string {
+	if len(r.Stopwords) == 0 {
+		return words
+	}
+
+	kept := make([]string, 0, len(words))
+	for _, word := range words {
+		if !slices.ContainsFunc(r.Stopwords, func(stopword string) bool {
+			return strings.EqualFold(stopword, word)
+		}) {
+			kept = append(kept, word)
+		}
+	}
+
+	if len(kept) == 0 {
+		return words
+	}
+	return kept
+}
+
+// isASCIILetter returns true for a-z and A-Z.
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// emojiSlug builds a slug from the symbols of a title that has no other usable characters.
+// Returns "untitled" if the title contains no symbols.
+func emojiSlug(name, separator string) string {
+	parts := []string{emojiSlugPrefix}
+	for _, r := range name {
+		if !unicode.Is(unicode.So, r) {
+			continue // skip joiners, variation selectors, skin tone modifiers, punctuation
+		}
+		parts = append(parts, fmt.Sprintf("%x", r))
+		if len(parts) > maxEmojiSlugRunes {
+			break
+		}
+	}
+
+	if len(parts) == 1 {
+		return defaultUntitledStr
+	}
+	return strings.Join(parts, separator)
+}
+
 // NormalizeID removes dashes from Notion IDs for consistent format.
 func NormalizeID(id string) string {
 	return strings.ReplaceAll(id, "-", "")
@@ -322,3 +322,227 @@ func TestNormalizeID_RemovesDashes(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "decomposed accents composed to NFC",
+			input: "cafe\u0301",
+			want:  "café",
+		},
+		{
+			name:  "zero-width space removed",
+			input: "Road\u200bmap",
+			want:  "Roadmap",
+		},
+		{
+			name:  "BOM and word joiner removed",
+			input: "\ufeffNotes\u2060 2024",
+			want:  "Notes 2024",
+		},
+		{
+			name:  "control characters removed",
+			input: "Title\x00\x07\r",
+			want:  "Title",
+		},
+		{
+			name:  "tabs and newlines kept",
+			input: "a\tb\nc",
+			want:  "a\tb\nc",
+		},
+		{
+			name:  "invalid UTF-8 dropped",
+			input: "ok\xff\xfeok",
+			want:  "okok",
+		},
+		{
+			name:  "emoji ZWJ sequence kept",
+			input: "👩\u200d💻 Dev",
+			want:  "👩\u200d💻 Dev",
+		},
+		{
+			name:  "mixed scripts kept",
+			input: "Plan Привет 计划 مرحبا", //nolint:gosmopolitan // Testing mixed-script content
+			want:  "Plan Привет 计划 مرحبا", //nolint:gosmopolitan // Testing mixed-script content
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := NormalizeText(tt.input)
+			if got != tt.want {
+				t.Errorf("NormalizeText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename_EmojiOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "single emoji",
+			input: "🚀",
+			want:  "emoji-1f680",
+		},
+		{
+			name:  "emoji with variation selector and spaces",
+			input: " ❤\ufe0f 🎉 ",
+			want:  "emoji-2764-1f389",
+		},
+		{
+			name:  "ZWJ sequence",
+			input: "👩\u200d💻",
+			want:  "emoji-1f469-1f4bb",
+		},
+		{
+			name:  "long emoji title truncated",
+			input: "🍎🍐🍊🍋🍌🍉",
+			want:  "emoji-1f34e-1f350-1f34a-1f34b",
+		},
+		{
+			name:  "emoji with text uses text",
+			input: "🚀 Launch",
+			want:  "launch",
+		},
+		{
+			name:  "non-latin script only",
+			input: "计划", //nolint:gosmopolitan // Testing non-ASCII title fallback
+			want:  defaultUntitledStr,
+		},
+		{
+			name:  "zero-width characters ignored",
+			input: "Road\u200bmap",
+			want:  "roadmap",
+		},
+		{
+			name:  "decomposed accents",
+			input: "cafe\u0301 cre\u0300me",
+			want:  "cafe-creme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := SanitizeFilename(tt.input)
+			if got != tt.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilenameRules_Sanitize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		rules FilenameRules
+		input string
+		want  string
+	}{
+		{
+			name:  "zero value uses defaults",
+			rules: FilenameRules{},
+			input: "My Page Title",
+			want:  "my-page-title",
+		},
+		{
+			name:  "preserve case",
+			rules: FilenameRules{Case: FilenameCasePreserve},
+			input: "ISO 27001 Café",
+			want:  "ISO-27001-Cafe",
+		},
+		{
+			name:  "preserve case strips leading digits",
+			rules: FilenameRules{Case: FilenameCasePreserve},
+			input: "2024 Roadmap",
+			want:  "Roadmap",
+		},
+		{
+			name:  "underscore separator",
+			rules: FilenameRules{Separator: "_"},
+			input: "Meeting notes - Q1/Q2",
+			want:  "meeting_notes_q1_q2",
+		},
+		{
+			name:  "unsupported separator falls back to dash",
+			rules: FilenameRules{Separator: "."},
+			input: "a b",
+			want:  "a-b",
+		},
+		{
+			name:  "max length",
+			rules: FilenameRules{MaxLength: 10},
+			input: "a very long page title",
+			want:  "a-very-lon",
+		},
+		{
+			name:  "max length trims trailing separator",
+			rules: FilenameRules{MaxLength: 7},
+			input: "abcdef ghi",
+			want:  "abcdef",
+		},
+		{
+			name:  "stopwords removed",
+			rules: FilenameRules{Stopwords: []string{"the", "of", "a"}},
+			input: "The Lord of the Rings",
+			want:  "lord-rings",
+		},
+		{
+			name:  "stopwords with preserved case",
+			rules: FilenameRules{Case: FilenameCasePreserve, Stopwords: []string{"the"}},
+			input: "The Hobbit",
+			want:  "Hobbit",
+		},
+		{
+			name:  "only stopwords kept",
+			rules: FilenameRules{Stopwords: []string{"the", "end"}},
+			input: "The End",
+			want:  "the-end",
+		},
+		{
+			name:  "emoji slug uses separator",
+			rules: FilenameRules{Separator: "_"},
+			input: "🚀🎉",
+			want:  "emoji_1f680_1f389",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.rules.Sanitize(tt.input)
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilenameRules_Equal(t *testing.T) {
+	t.Parallel()
+
+	if !DefaultFilenameRules().Equal(FilenameRules{}) {
+		t.Error("zero value rules should equal the default rules")
+	}
+	if DefaultFilenameRules().Equal(FilenameRules{Separator: "_"}) {
+		t.Error("rules with different separators should not be equal")
+	}
+	if DefaultFilenameRules().Equal(FilenameRules{Stopwords: []string{"the"}}) {
+		t.Error("rules with different stopwords should not be equal")
+	}
+}
@@ -0,0 +1,386 @@
+package html
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// notionPageURL is the URL of a Notion page from its ID, for links to pages outside of the document tree.
+const notionPageURL = "https://www.notion.so/"
+
+// renderer renders the blocks of a page.
+type renderer struct {
+	conv    *Converter
+	opts    *converter.ConvertOptions
+	hasMath bool // Whether the page has equations, which load KaTeX
+}
+
+// listTag returns the list element holding a block, or an empty string if it is not a list item.
+func listTag(block *notion.Block) string {
+	switch block.Type {
+	case "bulleted_list_item":
+		return "ul"
+	case "numbered_list_item":
+		return "ol"
+	case "to_do":
+		return `ul class="todo"`
+	default:
+		return ""
+	}
+}
+
+// renderBlocks renders blocks, grouping consecutive list items of the same kind in a list element.
+func (r *renderer) renderBlocks(sb *strings.Builder, blocks []notion.Block) {
+	openList := ""
+	for i := range blocks {
+		block := &blocks[i]
+		if tag := listTag(block); tag != openList {
+			if openList != "" {
+				fmt.Fprintf(sb, "</%s>\n", strings.Fields(openList)[0])
+			}
+			if tag != "" {
+				fmt.Fprintf(sb, "<%s>\n", tag)
+			}
+			openList = tag
+		}
+		r.renderBlock(sb, block)
+	}
+	if openList != "" {
+		fmt.Fprintf(sb, "</%s>\n", strings.Fields(openList)[0])
+	}
+}
+
+// renderBlock renders a single block.
+//
+//nolint:funlen,gocognit,gocyclo,cyclop // Large switch statement for all Notion block types
+func (r *renderer) renderBlock(sb *strings.Builder, block *notion.Block) {
+	switch block.Type {
+	case "paragraph":
+		if block.Paragraph == nil {
+			return
+		}
+		if text := r.richText(block.Paragraph.RichText); text != "" {
+			fmt.Fprintf(sb, "<p>%s</p>\n", text)
+		}
+		r.renderChildren(sb, block)
+
+	case "heading_1", "heading_2", "heading_3":
+		r.renderHeading(sb, block)
+
+	case "bulleted_list_item", "numbered_list_item":
+		item := block.BulletedListItem
+		if block.Type == "numbered_list_item" {
+			item = block.NumberedListItem
+		}
+		if item == nil {
+			return
+		}
+		fmt.Fprintf(sb, "<li>%s", r.richText(item.RichText))
+		r.renderNested(sb, block)
+		sb.WriteString("</li>\n")
+
+	case "to_do":
+		if block.ToDo == nil {
+			return
+		}
+		checked := ""
+		if block.ToDo.Checked {
+			checked = " checked"
+		}
+		fmt.Fprintf(sb, "<li><input type=\"checkbox\" disabled%s> %s", checked, r.richText(block.ToDo.RichText))
+		r.renderNested(sb, block)
+		sb.WriteString("</li>\n")
+
+	case "toggle":
+		if block.Toggle == nil {
+			return
+		}
+		fmt.Fprintf(sb, "<details>\n<summary>%s</summary>\n", r.richText(block.Toggle.RichText))
+		r.renderChildren(sb, block)
+		sb.WriteString("</details>\n")
+
+	case "code":
+		if block.Code == nil {
+			return
+		}
+		sb.WriteString("<figure class=\"code\">\n")
+		if caption := notion.ParseRichText(block.Code.Caption); caption != "" {
+			fmt.Fprintf(sb, "<figcaption>%s</figcaption>\n", escape(caption))
+		}
+		fmt.Fprintf(sb, "<pre><code class=\"language-%s\">%s</code></pre>\n</figure>\n",
+			escape(strings.ReplaceAll(block.Code.Language, " ", "-")), escape(notion.ParseRichText(block.Code.RichText)))
+
+	case "quote":
+		if block.Quote == nil {
+			return
+		}
+		fmt.Fprintf(sb, "<blockquote>\n<p>%s</p>\n", r.richText(block.Quote.RichText))
+		r.renderChildren(sb, block)
+		sb.WriteString("</blockquote>\n")
+
+	case "callout":
+		if block.Callout == nil {
+			return
+		}
+		color := strings.TrimSuffix(block.Callout.Color, "_background")
+		if color == "" {
+			color = "default"
+		}
+		fmt.Fprintf(sb, "<div class=\"callout callout-%s\">\n", escape(color))
+		if icon := block.Callout.Icon; icon != nil && icon.Emoji != "" {
+			fmt.Fprintf(sb, "<span class=\"callout-icon\">%s</span>\n", escape(icon.Emoji))
+		}
+		fmt.Fprintf(sb, "<div class=\"callout-content\">\n<p>%s</p>\n", r.richText(block.Callout.RichText))
+		r.renderChildren(sb, block)
+		sb.WriteString("</div>\n</div>\n")
+
+	case "divider":
+		sb.WriteString("<hr>\n")
+
+	case "image":
+		if block.Image == nil {
+			return
+		}
+		caption := notion.ParseRichText(block.Image.Caption)
+		alt := caption
+		if alt == "" {
+			alt = "image"
+		}
+		fmt.Fprintf(sb, "<figure>\n<img src=\"%s\" alt=\"%s\" data-file-id=\"%s\">\n",
+			escape(r.fileURL(block.Image)), escape(alt), converter.NormalizeID(block.ID))
+		if caption != "" {
+			fmt.Fprintf(sb, "<figcaption>%s</figcaption>\n", escape(caption))
+		}
+		sb.WriteString("</figure>\n")
+
+	case "video":
+		if block.Video == nil {
+			return
+		}
+		fmt.Fprintf(sb, "<figure>\n<video controls src=\"%s\" data-file-id=\"%s\"></video>\n",
+			escape(r.fileURL(block.Video)), converter.NormalizeID(block.ID))
+		if caption := notion.ParseRichText(block.Video.Caption); caption != "" {
+			fmt.Fprintf(sb, "<figcaption>%s</figcaption>\n", escape(caption))
+		}
+		sb.WriteString("</figure>\n")
+
+	case "audio":
+		if block.Audio == nil {
+			return
+		}
+		fmt.Fprintf(sb, "<figure>\n<audio controls src=\"%s\" data-file-id=\"%s\"></audio>\n",
+			escape(r.fileURL(block.Audio)), converter.NormalizeID(block.ID))
+		if caption := notion.ParseRichText(block.Audio.Caption); caption != "" {
+			fmt.Fprintf(sb, "<figcaption>%s</figcaption>\n", escape(caption))
+		}
+		sb.WriteString("</figure>\n")
+
+	case "file", "pdf":
+		file := block.File
+		label := "File"
+		if block.Type == "pdf" {
+			file, label = block.PDF, "PDF"
+		}
+		if file == nil {
+			return
+		}
+		if caption := notion.ParseRichText(file.Caption); caption != "" {
+			label = caption
+		}
+		if file.Name != "" {
+			label = file.Name
+		}
+		fmt.Fprintf(sb, "<p class=\"file\"><a href=\"%s\" data-file-id=\"%s\">%s</a></p>\n",
+			escape(r.fileURL(file)), converter.NormalizeID(block.ID), escape(label))
+
+	case "bookmark":
+		if block.Bookmark == nil {
+			return
+		}
+		caption := notion.ParseRichText(block.Bookmark.Caption)
+		if caption == "" {
+			caption = block.Bookmark.URL
+		}
+		fmt.Fprintf(sb, "<p class=\"bookmark\"><a href=\"%s\">%s</a></p>\n", escape(block.Bookmark.URL), escape(caption))
+
+	case "embed":
+		if block.Embed == nil {
+			return
+		}
+		fmt.Fprintf(sb, "<p class=\"embed\"><a href=\"%s\">%s</a></p>\n", escape(block.Embed.URL), escape(block.Embed.URL))
+
+	case "equation":
+		if block.Equation == nil {
+			return
+		}
+		r.hasMath = true
+		fmt.Fprintf(sb, "<div class=\"equation\">$$%s$$</div>\n", escape(block.Equation.Expression))
+
+	case "table":
+		if block.Table != nil {
+			r.renderTable(sb, block)
+		}
+
+	case "child_page", "child_database":
+		title := ""
+		if block.ChildPage != nil {
+			title = block.ChildPage.Title
+		} else if block.ChildDatabase != nil {
+			title = block.ChildDatabase.Title
+		}
+		rules := r.conv.Markdown.FilenameRules
+		relPath := rules.Sanitize(r.opts.PageTitle) + "/" + rules.Sanitize(title) + converter.FileExtension(r.opts.Profile)
+		fmt.Fprintf(sb, "<ul class=\"children\">\n%s</ul>\n",
+			r.conv.childLink(title, relPath, converter.NormalizeID(block.ID)))
+
+	case "link_to_page":
+		if block.LinkToPage == nil {
+			return
+		}
+		targetID := block.LinkToPage.PageID
+		if targetID == "" {
+			targetID = block.LinkToPage.DatabaseID
+		}
+		if targetID != "" {
+			targetID = converter.NormalizeID(targetID)
+			fmt.Fprintf(sb, "<p><a href=\"%s%s\" data-page-id=\"%s\">Page link</a></p>\n", notionPageURL, targetID, targetID)
+		}
+
+	case "breadcrumb":
+		trail := append(append([]string{}, r.opts.Ancestors...), r.opts.PageTitle)
+		fmt.Fprintf(sb, "<nav class=\"breadcrumb\">%s</nav>\n", escape(strings.Join(trail, " / ")))
+
+	case "column_list":
+		sb.WriteString("<div class=\"columns\">\n")
+		r.renderChildren(sb, block)
+		sb.WriteString("</div>\n")
+
+	case "column":
+		sb.WriteString("<div class=\"column\">\n")
+		r.renderChildren(sb, block)
+		sb.WriteString("</div>\n")
+
+	case "synced_block":
+		r.renderChildren(sb, block)
+
+	default:
+		// Blocks without an HTML equivalent (table of contents, template buttons, unknown types) are skipped
+	}
+}
+
+// renderHeading renders a heading. Toggleable headings hold their children in a details element.
+func (r *renderer) renderHeading(sb *strings.Builder, block *notion.Block) {
+	heading, level := block.Heading1, 2 //nolint:mnd // The page title is the h1
+	switch block.Type {
+	case "heading_2":
+		heading, level = block.Heading2, 3 //nolint:mnd // Heading levels shift by one under the title
+	case "heading_3":
+		heading, level = block.Heading3, 4 //nolint:mnd // Heading levels shift by one under the title
+	}
+	if heading == nil {
+		return
+	}
+
+	text := r.richText(heading.RichText)
+	if !heading.IsToggleable {
+		fmt.Fprintf(sb, "<h%d>%s</h%d>\n", level, text, level)
+		return
+	}
+	fmt.Fprintf(sb, "<details>\n<summary><h%d>%s</h%d></summary>\n", level, text, level)
+	r.renderChildren(sb, block)
+	sb.WriteString("</details>\n")
+}
+
+// renderTable renders a table block with its rows.
+func (r *renderer) renderTable(sb *strings.Builder, block *notion.Block) {
+	sb.WriteString("<table>\n")
+	for i := range block.Children {
+		row := block.Children[i].TableRow
+		if row == nil {
+			continue
+		}
+		sb.WriteString("<tr>")
+		for j := range block.Table.TableWidth {
+			cell := ""
+			if j < len(row.Cells) {
+				cell = r.richText(row.Cells[j])
+			}
+			tag := "td"
+			if (i == 0 && block.Table.HasColumnHeader) || (j == 0 && block.Table.HasRowHeader) {
+				tag = "th"
+			}
+			fmt.Fprintf(sb, "<%s>%s</%s>", tag, cell, tag)
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("</table>\n")
+}
+
+// renderChildren renders the children of a block.
+func (r *renderer) renderChildren(sb *strings.Builder, block *notion.Block) {
+	r.renderBlocks(sb, block.Children)
+}
+
+// renderNested renders the children of a list item inside it, on their own lines.
+func (r *renderer) renderNested(sb *strings.Builder, block *notion.Block) {
+	if len(block.Children) > 0 {
+		sb.WriteString("\n")
+		r.renderChildren(sb, block)
+	}
+}
+
+// fileURL returns the URL of a file, processed (e.g. downloaded) by the file processor if any.
+func (r *renderer) fileURL(file *notion.FileBlock) string {
+	fileURL := ""
+	switch {
+	case file.External != nil:
+		fileURL = file.External.URL
+	case file.File != nil:
+		fileURL = file.File.URL
+	}
+	if r.opts.FileProcessor != nil {
+		fileURL = r.opts.FileProcessor(fileURL)
+	}
+	return fileURL
+}
+
+// richText renders rich text as HTML, with its annotations and links. Inline equations are rendered by KaTeX.
+func (r *renderer) richText(richText []notion.RichText) string {
+	var sb strings.Builder
+	for i := range richText {
+		item := &richText[i]
+		text := escape(item.PlainText)
+		switch {
+		case item.Type == "equation" && item.Equation != nil:
+			r.hasMath = true
+			text = `<span class="equation">\(` + escape(item.Equation.Expression) + `\)</span>`
+		case item.Type == "mention" && item.Mention != nil && item.Mention.User != nil:
+			text = escape("@" + item.Mention.User.Format())
+		}
+		text = strings.ReplaceAll(text, "\n", "<br>\n")
+
+		if a := item.Annotations; a != nil {
+			text = wrap(text, "code", a.Code)
+			text = wrap(text, "strong", a.Bold)
+			text = wrap(text, "em", a.Italic)
+			text = wrap(text, "del", a.Strikethrough)
+			text = wrap(text, "u", a.Underline)
+		}
+		if item.Href != nil && *item.Href != "" {
+			text = fmt.Sprintf("<a href=\"%s\">%s</a>", escape(*item.Href), text)
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// wrap wraps text in an element if enabled.
+func wrap(text, tag string, enabled bool) string {
+	if !enabled {
+		return text
+	}
+	return "<" + tag + ">" + text + "</" + tag + ">"
+}
@@ -0,0 +1,200 @@
+// Package html converts Notion pages and databases to standalone HTML documents, with embedded styling, so that
+// the mirror can be published as a static site. It is selected by the html output profile.
+package html
+
+import (
+	"fmt"
+	stdhtml "html"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+// KaTeX renders the equations, loaded only by the pages that have some.
+const katexHead = `<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/katex@0.16.11/dist/katex.min.css">
+<script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.11/dist/katex.min.js"></script>
+<script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.11/dist/contrib/auto-render.min.js"
+  onload="renderMathInElement(document.body)"></script>
+`
+
+// Converter converts Notion pages and databases to HTML documents.
+type Converter struct {
+	// Markdown is the markdown converter whose filename rules and link style apply to the links of the documents.
+	Markdown *converter.Converter
+}
+
+// NewConverter creates an HTML converter sharing the filename rules and link style of a markdown converter.
+func NewConverter(markdown *converter.Converter) *Converter {
+	return &Converter{Markdown: markdown}
+}
+
+// ConvertWithOptions converts a page and its blocks to an HTML document.
+func (c *Converter) ConvertWithOptions(
+	page *notion.Page, blocks []notion.Block, opts *converter.ConvertOptions,
+) []byte {
+	r := &renderer{conv: c, opts: opts}
+	var body strings.Builder
+	title := page.Title()
+	if title != "" {
+		fmt.Fprintf(&body, "<h1 class=\"title\">%s</h1>\n", escape(title))
+	}
+	r.renderBlocks(&body, blocks)
+
+	return c.document(title, r.hasMath, metadata{
+		id:         page.ID,
+		url:        page.URL,
+		lastEdited: page.LastEditedTime,
+		notionType: opts.NotionType,
+		opts:       opts,
+	}, body.String())
+}
+
+// ConvertDatabase converts a database to an HTML document listing its direct child pages, as a list or a table.
+func (c *Converter) ConvertDatabase(
+	database *notion.Database, dbPages []notion.DatabasePage, opts *converter.ConvertOptions,
+) []byte {
+	var body strings.Builder
+	title := database.GetTitle()
+	if title != "" {
+		fmt.Fprintf(&body, "<h1 class=\"title\">%s</h1>\n", escape(title))
+	}
+	if description := notion.ParseRichText(database.Description); description != "" {
+		fmt.Fprintf(&body, "<p class=\"description\">%s</p>\n", escape(description))
+	}
+
+	dbID := converter.NormalizeID(database.ID)
+	var children []notion.DatabasePage
+	for i := range dbPages {
+		if converter.NormalizeID(dbPages[i].Parent.ID()) == dbID {
+			children = append(children, dbPages[i]) // Only direct child pages
+		}
+	}
+
+	switch {
+	case len(children) == 0:
+		body.WriteString("<p><em>This database has no direct child pages.</em></p>\n")
+	case opts.DatabaseRenderMode == converter.DatabaseRenderTable:
+		c.renderDatabaseTable(&body, database, children, opts)
+	default:
+		var links strings.Builder
+		for i := range children {
+			pageTitle, relPath := c.databasePageLink(&children[i], opts)
+			links.WriteString(c.childLink(pageTitle, relPath, converter.NormalizeID(children[i].ID)))
+		}
+		fmt.Fprintf(&body, "<ul class=\"children\">\n%s</ul>\n", links.String())
+	}
+
+	return c.document(title, false, metadata{
+		id:         database.ID,
+		url:        database.URL,
+		lastEdited: database.LastEditedTime,
+		notionType: "database",
+		opts:       opts,
+	}, body.String())
+}
+
+// renderDatabaseTable renders the direct child pages of a database as a table of their properties.
+func (c *Converter) renderDatabaseTable(
+	sb *strings.Builder, database *notion.Database, pages []notion.DatabasePage, opts *converter.ConvertOptions,
+) {
+	titleColumn, columns := c.Markdown.DatabaseColumns(database, pages)
+
+	sb.WriteString("<table class=\"children\">\n<tr>")
+	fmt.Fprintf(sb, "<th>%s</th>", escape(titleColumn))
+	for _, column := range columns {
+		fmt.Fprintf(sb, "<th>%s</th>", escape(column))
+	}
+	sb.WriteString("</tr>\n")
+	for i := range pages {
+		pageTitle, relPath := c.databasePageLink(&pages[i], opts)
+		if c.Markdown.Links.PathCase == converter.LinkPathLower {
+			relPath = strings.ToLower(relPath)
+		}
+		fmt.Fprintf(sb, "<tr><td><a href=\"./%s\" data-page-id=\"%s\">%s</a></td>",
+			escape(relPath), converter.NormalizeID(pages[i].ID), escape(pageTitle))
+		for _, column := range columns {
+			fmt.Fprintf(sb, "<td>%s</td>", escape(converter.PropertyText(pages[i].Properties[column])))
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("</table>\n")
+}
+
+// databasePageLink returns the title of a page of a database and the path of its file, relative to the document of
+// the database.
+func (c *Converter) databasePageLink(dbPage *notion.DatabasePage, opts *converter.ConvertOptions) (string, string) {
+	pageTitle := dbPage.Title()
+	if pageTitle == "" {
+		pageTitle = "Untitled"
+	}
+	baseFilename := converter.TrimPageExt(filepath.Base(opts.FilePath))
+	return pageTitle, baseFilename + "/" + c.Markdown.FilenameRules.Sanitize(pageTitle) +
+		converter.FileExtension(opts.Profile)
+}
+
+// metadata is the information about the page written in the head of its document, as the frontmatter of
+// markdown files.
+type metadata struct {
+	id         string
+	url        string
+	lastEdited time.Time
+	notionType string
+	opts       *converter.ConvertOptions
+}
+
+// document wraps the body of a page in a standalone HTML document.
+func (c *Converter) document(title string, hasMath bool, meta metadata, body string) []byte {
+	notionType := meta.notionType
+	if notionType == "" {
+		notionType = "page"
+	}
+
+	var doc strings.Builder
+	doc.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	doc.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&doc, "<title>%s</title>\n", escape(converter.NormalizeText(title)))
+	fmt.Fprintf(&doc, "<meta name=\"generator\" content=\"ntnsync %s\">\n", escape(version.Version))
+	writeMeta(&doc, "notion_id", meta.id)
+	writeMeta(&doc, "notion_type", notionType)
+	writeMeta(&doc, "notion_folder", meta.opts.Folder)
+	writeMeta(&doc, "notion_url", meta.url)
+	if !meta.lastEdited.IsZero() {
+		writeMeta(&doc, "last_edited", meta.lastEdited.UTC().Format(time.RFC3339))
+	}
+	if !meta.opts.LastSynced.IsZero() {
+		writeMeta(&doc, "last_synced", meta.opts.LastSynced.UTC().Format(time.RFC3339))
+	}
+	doc.WriteString("<style>\n" + stylesheet + "</style>\n")
+	if hasMath {
+		doc.WriteString(katexHead)
+	}
+	doc.WriteString("</head>\n<body>\n<main>\n")
+	doc.WriteString(body)
+	doc.WriteString("</main>\n</body>\n</html>\n")
+	return []byte(doc.String())
+}
+
+// writeMeta writes a meta element, if its value is not empty.
+func writeMeta(doc *strings.Builder, name, value string) {
+	if value != "" {
+		fmt.Fprintf(doc, "<meta name=\"%s\" content=\"%s\">\n", name, escape(value))
+	}
+}
+
+// childLink renders a list item linking to a child page or database, whose path is relative to the document.
+func (c *Converter) childLink(title, relPath, pageID string) string {
+	if c.Markdown.Links.PathCase == converter.LinkPathLower {
+		relPath = strings.ToLower(relPath)
+	}
+	return fmt.Sprintf("<li><a href=\"./%s\" data-page-id=\"%s\">%s</a></li>\n",
+		escape(relPath), pageID, escape(title))
+}
+
+// escape escapes text for HTML content and attribute values.
+func escape(text string) string {
+	return stdhtml.EscapeString(text)
+}
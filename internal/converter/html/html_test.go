@@ -0,0 +1,86 @@
+package html
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func text(content string) []notion.RichText {
+	return []notion.RichText{{Type: "text", PlainText: content}}
+}
+
+func TestConvertWithOptions(t *testing.T) {
+	t.Parallel()
+
+	page := &notion.Page{
+		ID: "page123",
+		Properties: map[string]notion.Property{
+			"title": {Type: "title", Title: text("Design <notes>")},
+		},
+	}
+	blocks := []notion.Block{
+		{Type: "paragraph", Paragraph: &notion.ParagraphBlock{RichText: []notion.RichText{
+			{Type: "text", PlainText: "Energy: "},
+			{Type: "equation", PlainText: "E=mc^2", Equation: &notion.Equation{Expression: "E=mc^2"}},
+			{Type: "text", PlainText: " bold", Annotations: &notion.Annotations{Bold: true}},
+		}}},
+		{Type: "bulleted_list_item", BulletedListItem: &notion.ListItemBlock{RichText: text("one")}},
+		{Type: "bulleted_list_item", BulletedListItem: &notion.ListItemBlock{RichText: text("two")}},
+		{Type: "callout", Callout: &notion.CalloutBlock{
+			RichText: text("Careful"), Icon: &notion.Icon{Type: "emoji", Emoji: "⚠️"}, Color: "red_background",
+		}},
+		{
+			Type:     "toggle",
+			Toggle:   &notion.ToggleBlock{RichText: text("More")},
+			Children: []notion.Block{{Type: "paragraph", Paragraph: &notion.ParagraphBlock{RichText: text("Hidden")}}},
+		},
+		{
+			Type:  "table",
+			Table: &notion.TableBlock{TableWidth: 2, HasColumnHeader: true},
+			Children: []notion.Block{
+				{Type: "table_row", TableRow: &notion.TableRowBlock{Cells: [][]notion.RichText{text("A"), text("B")}}},
+				{Type: "table_row", TableRow: &notion.TableRowBlock{Cells: [][]notion.RichText{text("1"), text("2")}}},
+			},
+		},
+		{ID: "child123", Type: "child_page", ChildPage: &notion.ChildPageBlock{Title: "Child"}},
+	}
+
+	c := NewConverter(converter.NewConverter())
+	got := string(c.ConvertWithOptions(page, blocks, &converter.ConvertOptions{
+		Profile: converter.ProfileHTML, PageTitle: "Design notes", Folder: "tech",
+	}))
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Design &lt;notes&gt;</title>",
+		`<meta name="notion_id" content="page123">`,
+		"<style>",
+		"katex.min.js",
+		`<p>Energy: <span class="equation">\(E=mc^2\)</span><strong> bold</strong></p>`,
+		"<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n",
+		"<div class=\"callout callout-red\">\n<span class=\"callout-icon\">⚠️</span>",
+		"<details>\n<summary>More</summary>\n<p>Hidden</p>\n</details>\n",
+		"<tr><th>A</th><th>B</th></tr>\n<tr><td>1</td><td>2</td></tr>\n",
+		`<a href="./design-notes/child.html" data-page-id="child123">Child</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ConvertWithOptions() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestConvertWithOptions_NoMath(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter(converter.NewConverter())
+	got := string(c.ConvertWithOptions(&notion.Page{ID: "page123"}, []notion.Block{
+		{Type: "paragraph", Paragraph: &notion.ParagraphBlock{RichText: text("Plain")}},
+	}, &converter.ConvertOptions{Profile: converter.ProfileHTML}))
+
+	if strings.Contains(got, "katex") {
+		t.Error("pages without equations must not load KaTeX")
+	}
+}
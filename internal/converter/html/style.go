@@ -0,0 +1,41 @@
+package html
+
+// stylesheet is the style embedded in every document, so that the mirror can be served as is.
+const stylesheet = `body { margin: 0; color: #37352f; background: #fff;
+  font: 16px/1.6 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 860px; margin: 0 auto; padding: 2rem 1.5rem 4rem; }
+h1.title { font-size: 2.2rem; margin-bottom: 1.5rem; }
+a { color: #0b6e99; }
+code { font-family: SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.9em; background: #f1f1ef;
+  padding: 0.1em 0.3em; border-radius: 3px; }
+pre { background: #f7f6f3; padding: 1rem; border-radius: 4px; overflow-x: auto; }
+pre code { background: none; padding: 0; }
+figure { margin: 1rem 0; }
+figure.code figcaption, figcaption { color: #787774; font-size: 0.875rem; }
+img, video { max-width: 100%; }
+blockquote { margin: 1rem 0; padding-left: 1rem; border-left: 3px solid #37352f; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #e9e9e7; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f7f6f3; }
+ul.todo { list-style: none; padding-left: 0.5rem; }
+ul.children { padding-left: 1.2rem; }
+details { margin: 0.5rem 0; }
+summary { cursor: pointer; }
+summary h2, summary h3, summary h4 { display: inline; }
+hr { border: none; border-top: 1px solid #e9e9e7; margin: 1.5rem 0; }
+.callout { display: flex; gap: 0.6rem; padding: 1rem; margin: 1rem 0; border-radius: 4px; background: #f1f1ef; }
+.callout-content > :first-child { margin-top: 0; }
+.callout-content > :last-child { margin-bottom: 0; }
+.callout-blue { background: #e7f3f8; }
+.callout-green { background: #edf3ec; }
+.callout-yellow { background: #fbf3db; }
+.callout-orange { background: #faebdd; }
+.callout-brown { background: #f4eeee; }
+.callout-red { background: #fdebec; }
+.callout-purple { background: #f6f3f9; }
+.callout-pink { background: #faf1f5; }
+.columns { display: flex; gap: 1.5rem; }
+.column { flex: 1; min-width: 0; }
+.equation { overflow-x: auto; text-align: center; }
+.breadcrumb, .description { color: #787774; }
+`
@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// InlineDatabase holds the top rows of a child database, rendered as a compact table under its link.
+type InlineDatabase struct {
+	TitleColumn string                // Name of the title property, used as the first column header
+	Columns     []string              // Other properties to show, in order
+	Rows        []notion.DatabasePage // Top rows of the database
+	HasMore     bool                  // Whether the database has more rows than shown
+}
+
+// convertInlineDatabase renders the rows of a child database as a markdown table.
+func convertInlineDatabase(inline *InlineDatabase, opts *ConvertOptions) string {
+	if inline == nil || len(inline.Rows) == 0 {
+		return ""
+	}
+
+	titleColumn := inline.TitleColumn
+	if titleColumn == "" {
+		titleColumn = "Name"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\n| " + escapeTableCell(opts.escapeText(titleColumn), opts) + " |")
+	for _, column := range inline.Columns {
+		fmt.Fprintf(&builder, " %s |", escapeTableCell(opts.escapeText(column), opts))
+	}
+	builder.WriteString("\n|")
+	for range len(inline.Columns) + 1 {
+		builder.WriteString(" --- |")
+	}
+	builder.WriteString("\n")
+
+	for i := range inline.Rows {
+		row := &inline.Rows[i]
+		fmt.Fprintf(&builder, "| %s |", escapeTableCell(opts.escapeText(row.Title()), opts))
+		for _, column := range inline.Columns {
+			fmt.Fprintf(&builder, " %s |", escapeTableCell(opts.escapeText(PropertyText(row.Properties[column])), opts))
+		}
+		builder.WriteString("\n")
+	}
+
+	if inline.HasMore {
+		builder.WriteString("\n*More rows are available in the database.*\n")
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// PropertyText formats a raw database property as table cell text.
+func PropertyText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var prop notion.Property
+	if err := json.Unmarshal(raw, &prop); err != nil {
+		return ""
+	}
+
+	switch value := extractPropertyValue(&prop).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		if value {
+			return "✓"
+		}
+		return ""
+	case []string:
+		return strings.Join(value, ", ")
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,52 @@
+package converter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestConvertChildDatabase_InlineRows(t *testing.T) {
+	t.Parallel()
+
+	block := &notion.Block{
+		ID:            "db-1",
+		Type:          "child_database",
+		ChildDatabase: &notion.ChildDatabaseBlock{Title: "Tasks"},
+	}
+	inline := &InlineDatabase{
+		TitleColumn: "Task",
+		Columns:     []string{"Status", "Tags", "Done", "Notes"},
+		Rows: []notion.DatabasePage{{
+			TitleProperty: "Task",
+			Properties: map[string]json.RawMessage{
+				"Task":   json.RawMessage(`{"type":"title","title":[{"type":"text","plain_text":"Write | docs"}]}`),
+				"Status": json.RawMessage(`{"type":"status","status":{"name":"In progress"}}`),
+				"Tags":   json.RawMessage(`{"type":"multi_select","multi_select":[{"name":"a"},{"name":"b"}]}`),
+				"Done":   json.RawMessage(`{"type":"checkbox","checkbox":true}`),
+				"Notes":  json.RawMessage(`{"type":"rich_text","rich_text":[{"type":"text","plain_text":"First\nSecond"}]}`),
+			},
+		}},
+		HasMore: true,
+	}
+
+	c := NewConverter()
+	got := c.convertBlock(block, 0, &ConvertOptions{
+		PageTitle:       "Project",
+		InlineDatabases: map[string]*InlineDatabase{"db1": inline},
+	})
+	want := "- [Tasks](./project/tasks.md)<!-- page_id:db1 -->\n" +
+		"\n| Task | Status | Tags | Done | Notes |\n| --- | --- | --- | --- | --- |\n" +
+		"| Write \\| docs | In progress | a, b | ✓ | First<br>Second |\n" +
+		"\n*More rows are available in the database.*\n\n"
+	if got != want {
+		t.Errorf("convertBlock() = %q, want %q", got, want)
+	}
+
+	// Without inline rows, the database is only linked
+	if got := c.convertBlock(block, 0, &ConvertOptions{PageTitle: "Project"}); got !=
+		"- [Tasks](./project/tasks.md)<!-- page_id:db1 -->\n" {
+		t.Errorf("convertBlock() without inline rows = %q", got)
+	}
+}
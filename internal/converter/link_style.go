@@ -0,0 +1,89 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Link styles select how links to child pages and databases are rendered.
+const (
+	// LinkTextTitle uses the title of the child as link text.
+	LinkTextTitle = "title"
+	// LinkTextPath uses the path of the child as link text.
+	LinkTextPath = "path"
+
+	// LinkLayoutBullet renders each link as a list item.
+	LinkLayoutBullet = "bullet"
+	// LinkLayoutInline renders links without a list marker, so that consecutive links form a paragraph.
+	LinkLayoutInline = "inline"
+
+	// LinkPathPreserve keeps the case of the child filename, as given by the filename rules.
+	LinkPathPreserve = "preserve"
+	// LinkPathLower lowercases link paths.
+	LinkPathLower = "lower"
+)
+
+// LinkStyle controls how links to child pages and databases are rendered, in child_page and
+// child_database blocks and in database listings. The zero value renders bullets with the title as text.
+type LinkStyle struct {
+	Text     string // LinkTextTitle or LinkTextPath
+	Layout   string // LinkLayoutBullet or LinkLayoutInline
+	PathCase string // LinkPathPreserve or LinkPathLower
+}
+
+// IsValidLinkText returns true if the link text is supported. An empty value is the default one.
+func IsValidLinkText(text string) bool {
+	return text == "" || slices.Contains([]string{LinkTextTitle, LinkTextPath}, text)
+}
+
+// IsValidLinkLayout returns true if the link layout is supported. An empty value is the default one.
+func IsValidLinkLayout(layout string) bool {
+	return layout == "" || slices.Contains([]string{LinkLayoutBullet, LinkLayoutInline}, layout)
+}
+
+// IsValidLinkPathCase returns true if the link path case is supported. An empty value is the default one.
+func IsValidLinkPathCase(pathCase string) bool {
+	return pathCase == "" || slices.Contains([]string{LinkPathPreserve, LinkPathLower}, pathCase)
+}
+
+// formatChildLink renders a link to a child page or database, identified by its page ID comment.
+// The path is relative to the parent page, without the leading "./".
+func (s LinkStyle) formatChildLink(title, path, pageID string) string {
+	if s.PathCase == LinkPathLower {
+		path = strings.ToLower(path)
+	}
+
+	text := title
+	if s.Text == LinkTextPath {
+		text = path
+	}
+
+	marker := "- "
+	if s.Layout == LinkLayoutInline {
+		marker = ""
+	}
+	return fmt.Sprintf("%s[%s](./%s)<!-- page_id:%s -->\n", marker, text, path, pageID)
+}
+
+// wikiLinkTextReplacer replaces the characters ending the text of a wikilink.
+var wikiLinkTextReplacer = strings.NewReplacer("|", "-", "[", "(", "]", ")")
+
+// formatWikiLink renders a link to a child page or database as an Obsidian wikilink, identified by its page ID
+// comment. The target is the path of the child from the root of the vault, without the ".md" extension.
+func (s LinkStyle) formatWikiLink(title, target, pageID string) string {
+	if s.PathCase == LinkPathLower {
+		target = strings.ToLower(target)
+	}
+
+	link := "[[" + target + "]]"
+	if s.Text != LinkTextPath {
+		link = "[[" + target + "|" + wikiLinkTextReplacer.Replace(title) + "]]"
+	}
+
+	marker := "- "
+	if s.Layout == LinkLayoutInline {
+		marker = ""
+	}
+	return fmt.Sprintf("%s%s<!-- page_id:%s -->\n", marker, link, pageID)
+}
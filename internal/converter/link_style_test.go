@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestLinkStyle_FormatChildLink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		style LinkStyle
+		want  string
+	}{
+		{"default", LinkStyle{}, "- [My Page](./Parent/My-Page.md)<!-- page_id:abc -->\n"},
+		{"path text", LinkStyle{Text: LinkTextPath}, "- [Parent/My-Page.md](./Parent/My-Page.md)<!-- page_id:abc -->\n"},
+		{"inline", LinkStyle{Layout: LinkLayoutInline}, "[My Page](./Parent/My-Page.md)<!-- page_id:abc -->\n"},
+		{
+			"lowercase path", LinkStyle{Text: LinkTextPath, PathCase: LinkPathLower},
+			"- [parent/my-page.md](./parent/my-page.md)<!-- page_id:abc -->\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.style.formatChildLink("My Page", "Parent/My-Page.md", "abc"); got != tt.want {
+				t.Errorf("formatChildLink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertBlock_ChildLinksFollowLinkStyle(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	c.Links = LinkStyle{Text: LinkTextPath, Layout: LinkLayoutInline}
+	opts := &ConvertOptions{PageTitle: "Parent Page"}
+
+	page := c.convertBlock(&notion.Block{
+		ID: "child123", Type: "child_page", ChildPage: &notion.ChildPageBlock{Title: "Child"},
+	}, 0, opts)
+	if want := "[parent-page/child.md](./parent-page/child.md)<!-- page_id:child123 -->\n"; page != want {
+		t.Errorf("child_page = %q, want %q", page, want)
+	}
+
+	database := c.convertBlock(&notion.Block{
+		ID: "db123", Type: "child_database", ChildDatabase: &notion.ChildDatabaseBlock{Title: "Tasks"},
+	}, 0, opts)
+	if want := "[parent-page/tasks.md](./parent-page/tasks.md)<!-- page_id:db123 -->\n"; database != want {
+		t.Errorf("child_database = %q, want %q", database, want)
+	}
+}
@@ -0,0 +1,160 @@
+package converter
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Extensions of the page files.
+const (
+	extMarkdown = ".md"
+	extMDX      = ".mdx"
+	extHTML     = ".html"
+)
+
+// mdxEscaper escapes the characters MDX parses as JSX tags or JavaScript expressions.
+var mdxEscaper = strings.NewReplacer("<", `\<`, "{", `\{`, "}", `\}`)
+
+// htmlCommentPattern matches the HTML comments written by the converter (page and file IDs, collapsible markers),
+// which MDX does not support. Escaped "<" are not the start of a comment.
+var htmlCommentPattern = regexp.MustCompile(`(^|[^\\])<!--\s*(.*?)\s*-->`)
+
+// FileExtension returns the extension of the page files written with an output profile: ".mdx" for the docusaurus
+// profile, ".html" for the html profile, ".md" otherwise.
+func FileExtension(profile string) string {
+	switch profile {
+	case ProfileDocusaurus:
+		return extMDX
+	case ProfileHTML:
+		return extHTML
+	default:
+		return extMarkdown
+	}
+}
+
+// IsPageFile returns true if the path is a page file, whatever the output profile it was written with.
+func IsPageFile(filePath string) bool {
+	ext := path.Ext(filePath)
+	return ext == extMarkdown || ext == extMDX || ext == extHTML
+}
+
+// TrimPageExt removes the extension of a page file.
+func TrimPageExt(filePath string) string {
+	if IsPageFile(filePath) {
+		return strings.TrimSuffix(filePath, path.Ext(filePath))
+	}
+	return filePath
+}
+
+// escapeText escapes text coming from Notion for the output profile: the docusaurus profile escapes what MDX would
+// parse as JSX.
+func (opts *ConvertOptions) escapeText(text string) string {
+	if opts.Profile != ProfileDocusaurus {
+		return text
+	}
+	return mdxEscaper.Replace(text)
+}
+
+// richTextToMarkdown converts rich text to markdown, escaped for the output profile. Inline code is never escaped.
+func richTextToMarkdown(richText []notion.RichText, opts *ConvertOptions) string {
+	opts.checkMentions(richText)
+	if opts.Profile != ProfileDocusaurus {
+		return notion.ParseRichTextToMarkdown(richText)
+	}
+	return notion.RichTextToMarkdown(richText, mdxEscaper.Replace)
+}
+
+// finish normalizes the converted content, turning its HTML comments into MDX comments for the docusaurus profile.
+func (c *Converter) finish(content string, opts *ConvertOptions) []byte {
+	if opts.Profile == ProfileDocusaurus {
+		content = mdxComments(content)
+	}
+	return []byte(normalizeMarkdown(content))
+}
+
+// normalizeMarkdown applies NormalizeText to the markdown content outside of fenced code blocks and inline code
+// spans, which are written as they are: a code sample may need the characters NormalizeText removes.
+func normalizeMarkdown(content string) string {
+	var builder strings.Builder
+	fence := ""
+	for line := range strings.Lines(content) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			builder.WriteString(strings.ToValidUTF8(line, ""))
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+			builder.WriteString(NormalizeText(line))
+		default:
+			normalizeInline(&builder, line)
+		}
+	}
+	return builder.String()
+}
+
+// normalizeInline writes a line of markdown with NormalizeText applied outside of its inline code spans. A code
+// span starts with a run of backticks and ends with a run of the same length; an unmatched run is text.
+func normalizeInline(builder *strings.Builder, line string) {
+	for {
+		start := strings.IndexByte(line, '`')
+		if start < 0 {
+			break
+		}
+		ticks := len(line[start:]) - len(strings.TrimLeft(line[start:], "`"))
+		end := codeSpanEnd(line[start+ticks:], ticks)
+		if end < 0 {
+			builder.WriteString(NormalizeText(line[:start+ticks]))
+			line = line[start+ticks:]
+			continue
+		}
+		end += start + ticks
+		builder.WriteString(NormalizeText(line[:start]))
+		builder.WriteString(strings.ToValidUTF8(line[start:end], ""))
+		line = line[end:]
+	}
+	builder.WriteString(NormalizeText(line))
+}
+
+// codeSpanEnd returns the index just after the run of exactly ticks backticks closing a code span in s, or -1.
+func codeSpanEnd(s string, ticks int) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+		if run == ticks {
+			return i + run
+		}
+		i += run
+	}
+	return -1
+}
+
+// mdxComments replaces the HTML comments of the content with MDX comments, outside of fenced code blocks:
+// "<!-- page_id:abc -->" becomes "{/* page_id:abc */}".
+func mdxComments(content string) string {
+	var builder strings.Builder
+	fence := ""
+	for line := range strings.Lines(content) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"), strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			line = htmlCommentPattern.ReplaceAllString(line, "${1}{/* ${2} */}")
+		}
+		builder.WriteString(line)
+	}
+	return builder.String()
+}
@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestConvertWithOptions_Docusaurus(t *testing.T) {
+	t.Parallel()
+
+	page := &notion.Page{
+		ID: "page123",
+		Properties: map[string]notion.Property{
+			"title": {Type: "title", Title: []notion.RichText{{Type: "text", PlainText: "Generics <T>"}}},
+		},
+	}
+	blocks := []notion.Block{
+		{
+			Type: blockTypeParagraph,
+			Paragraph: &notion.ParagraphBlock{RichText: []notion.RichText{
+				{Type: "text", PlainText: "Use <Button> with {props} or "},
+				{Type: "text", PlainText: "<Tag />", Annotations: &notion.Annotations{Code: true}},
+			}},
+		},
+		{ID: "child123", Type: "child_page", ChildPage: &notion.ChildPageBlock{Title: "A < B"}},
+		{Type: "code", Code: &notion.CodeBlock{
+			Language: "html",
+			RichText: []notion.RichText{{Type: "text", PlainText: "<!-- kept -->"}},
+		}},
+	}
+
+	c := NewConverter()
+	c.IncludeFrontmatter = false
+	got := string(c.ConvertWithOptions(page, blocks, &ConvertOptions{
+		Profile: ProfileDocusaurus, PageTitle: "Generics", FilePath: "tech/generics.mdx",
+	}))
+
+	for _, want := range []string{
+		"# Generics \\<T>\n",
+		"Use \\<Button> with \\{props\\} or `<Tag />`\n",
+		"- [A \\< B](./generics/a-b.mdx){/* page_id:child123 */}\n",
+		"```html\n<!-- kept -->\n```\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ConvertWithOptions() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestPageFileExtension(t *testing.T) {
+	t.Parallel()
+
+	if got := FileExtension(ProfileDocusaurus); got != ".mdx" {
+		t.Errorf("FileExtension(docusaurus) = %q, want .mdx", got)
+	}
+	if got := FileExtension(ProfileGitHub); got != ".md" {
+		t.Errorf("FileExtension(github) = %q, want .md", got)
+	}
+	for input, want := range map[string]string{"a/b.md": "a/b", "a/b.mdx": "a/b", "a/b.txt": "a/b.txt"} {
+		if got := TrimPageExt(input); got != want {
+			t.Errorf("TrimPageExt(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package plugin defines the contract of external block converters, loaded at runtime so that
+// organizations can render their own block types (embeds of internal tools, for instance) without
+// forking ntnsync.
+//
+// A converter declares the Notion block types it renders, and receives each of these blocks as the
+// JSON object returned by the Notion API, along with the Markdown of its children. It can replace the
+// rendering of a known block type (such as "embed") as well as render types ntnsync does not know.
+//
+// Converters are Go plugins, built with `go build -buildmode=plugin` against the same Go version as
+// ntnsync, and exporting two functions that only use standard types. Loading them requires a build of
+// ntnsync with cgo (see Supported):
+//
+//	// BlockTypes returns the Notion block types rendered by the plugin.
+//	func BlockTypes() []string
+//
+//	// ConvertBlock renders a block to Markdown. Returning an error falls back to the built-in rendering.
+//	func ConvertBlock(block []byte, children string) (string, error)
+//
+// Other runtimes (WASM modules, for instance) only need to implement Converter to be registered.
+package plugin
+
+import (
+	"fmt"
+	"log/slog"
+	"plugin"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// Symbols exported by Go plugins.
+const (
+	symbolBlockTypes   = "BlockTypes"
+	symbolConvertBlock = "ConvertBlock"
+)
+
+// Converter renders Notion blocks to Markdown.
+type Converter interface {
+	// BlockTypes returns the Notion block types rendered by the converter (e.g. "embed").
+	BlockTypes() []string
+	// ConvertBlock renders a block, given as the JSON object returned by the Notion API, with the
+	// Markdown of its children already rendered. An empty result skips the block.
+	ConvertBlock(block []byte, children string) (string, error)
+}
+
+// Registry holds the converters by block type.
+// A nil registry has no converter.
+type Registry struct {
+	converters map[string]Converter
+	logger     *slog.Logger
+}
+
+// NewRegistry creates an empty registry. Conversion errors are logged with the logger.
+func NewRegistry(logger *slog.Logger) *Registry {
+	return &Registry{
+		converters: make(map[string]Converter),
+		logger:     logger,
+	}
+}
+
+// Register registers a converter for its block types, replacing previous converters of these types.
+func (r *Registry) Register(conv Converter) {
+	for _, blockType := range conv.BlockTypes() {
+		r.converters[blockType] = conv
+	}
+}
+
+// Load opens the Go plugins at the given paths and registers them, in order.
+func (r *Registry) Load(paths []string) error {
+	for _, path := range paths {
+		conv, err := Open(path)
+		if err != nil {
+			return err
+		}
+		r.Register(conv)
+		r.logger.Debug("Loaded converter plugin", "path", path, "block_types", conv.BlockTypes())
+	}
+	return nil
+}
+
+// Handles returns true if a converter is registered for the block type.
+func (r *Registry) Handles(blockType string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.converters[blockType]
+	return ok
+}
+
+// Convert renders a block with the converter of its type. Returns false if there is no such converter
+// or if it failed, in which case the block should be rendered by the built-in converter.
+func (r *Registry) Convert(blockType string, block []byte, children string) (string, bool) {
+	if !r.Handles(blockType) {
+		return "", false
+	}
+
+	rendered, err := r.converters[blockType].ConvertBlock(block, children)
+	if err != nil {
+		r.logger.Warn("Converter plugin failed, using the built-in rendering",
+			"block_type", blockType, "error", err)
+		return "", false
+	}
+	if rendered != "" && !strings.HasSuffix(rendered, "\n") {
+		rendered += "\n"
+	}
+	return rendered, true
+}
+
+// Open opens a Go plugin exporting the BlockTypes and ConvertBlock functions.
+func Open(path string) (Converter, error) {
+	if !Supported {
+		return nil, fmt.Errorf("open converter plugin %s: %w", path, apperrors.ErrPluginsUnsupported)
+	}
+	plug, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open converter plugin %s: %w", path, err)
+	}
+
+	blockTypes, err := lookup[func() []string](plug, symbolBlockTypes)
+	if err != nil {
+		return nil, fmt.Errorf("converter plugin %s: %w", path, err)
+	}
+	convertBlock, err := lookup[func([]byte, string) (string, error)](plug, symbolConvertBlock)
+	if err != nil {
+		return nil, fmt.Errorf("converter plugin %s: %w", path, err)
+	}
+
+	return &funcConverter{blockTypes: blockTypes, convertBlock: convertBlock}, nil
+}
+
+// lookup returns a symbol of a plugin with the expected type.
+func lookup[T any](plug *plugin.Plugin, name string) (T, error) {
+	var zero T
+	sym, err := plug.Lookup(name)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %w", apperrors.ErrInvalidPlugin, err)
+	}
+	typed, ok := sym.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s has type %T", apperrors.ErrInvalidPlugin, name, sym)
+	}
+	return typed, nil
+}
+
+// funcConverter is a converter made of the functions exported by a Go plugin.
+type funcConverter struct {
+	blockTypes   func() []string
+	convertBlock func([]byte, string) (string, error)
+}
+
+// BlockTypes returns the block types of the plugin.
+func (f *funcConverter) BlockTypes() []string {
+	return f.blockTypes()
+}
+
+// ConvertBlock renders a block with the plugin.
+func (f *funcConverter) ConvertBlock(block []byte, children string) (string, error) {
+	return f.convertBlock(block, children)
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// embedConverter renders embeds of an internal tool, and fails on others.
+type embedConverter struct{}
+
+func (embedConverter) BlockTypes() []string {
+	return []string{"embed", "internal_tool"}
+}
+
+func (embedConverter) ConvertBlock(block []byte, children string) (string, error) {
+	var parsed struct {
+		Embed struct {
+			URL string `json:"url"`
+		} `json:"embed"`
+	}
+	if err := json.Unmarshal(block, &parsed); err != nil {
+		return "", err
+	}
+	if parsed.Embed.URL == "" {
+		return "", errors.New("not an embed")
+	}
+	return "[Dashboard](" + parsed.Embed.URL + ")\n" + children, nil
+}
+
+func TestRegistry_Convert(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(slog.New(slog.DiscardHandler))
+	registry.Register(embedConverter{})
+
+	if !registry.Handles("embed") || !registry.Handles("internal_tool") || registry.Handles("paragraph") {
+		t.Error("Handles() does not match the registered block types")
+	}
+
+	rendered, ok := registry.Convert("embed", []byte(`{"type":"embed","embed":{"url":"https://tool/1"}}`), "child\n")
+	if !ok || rendered != "[Dashboard](https://tool/1)\nchild\n" {
+		t.Errorf("Convert(embed) = %q, %v", rendered, ok)
+	}
+
+	// A failing converter falls back to the built-in rendering
+	if rendered, ok := registry.Convert("internal_tool", []byte(`{"type":"internal_tool"}`), ""); ok {
+		t.Errorf("Convert(failing) = %q, want fallback", rendered)
+	}
+
+	if _, ok := registry.Convert("paragraph", []byte(`{}`), ""); ok {
+		t.Error("Convert(unregistered) should fall back")
+	}
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	t.Parallel()
+
+	var registry *Registry
+	if registry.Handles("embed") {
+		t.Error("a nil registry should not handle any block type")
+	}
+	if _, ok := registry.Convert("embed", nil, ""); ok {
+		t.Error("a nil registry should not convert blocks")
+	}
+}
+
+func TestRegistry_LoadUnsupported(t *testing.T) {
+	t.Parallel()
+	if Supported {
+		t.Skip("plugins are supported by this build, see CGO_ENABLED=0 go test")
+	}
+
+	registry := NewRegistry(slog.New(slog.DiscardHandler))
+	if err := registry.Load([]string{"plugin.so"}); !errors.Is(err, apperrors.ErrPluginsUnsupported) {
+		t.Errorf("Load() error = %v, want ErrPluginsUnsupported", err)
+	}
+}
+
+func TestRegistry_LoadMissing(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(slog.New(slog.DiscardHandler))
+	if err := registry.Load([]string{filepath.Join(t.TempDir(), "missing.so")}); err == nil {
+		t.Error("Load() of a missing plugin should fail")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build cgo && (linux || darwin || freebsd)
+
+package plugin
+
+// Supported is true when the binary can load Go plugins: they require cgo, on Linux, macOS or FreeBSD.
+const Supported = true
@@ -0,0 +1,7 @@
+//go:build !cgo || !(linux || darwin || freebsd)
+
+package plugin
+
+// Supported is false when the binary cannot load Go plugins, such as the release binaries and the Docker
+// image, built with CGO_ENABLED=0.
+const Supported = false
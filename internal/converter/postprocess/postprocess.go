@@ -0,0 +1,394 @@
+// Package postprocess transforms converted markdown documents with a chain of built-in processors, run in order
+// after the conversion and before the document is written: link rewrites, a table of contents, heading offsets,
+// redactions and regular expression replacements. Processors only see the body of documents: their frontmatter,
+// which the mirror relies on, is kept as converted.
+//
+// A chain is written as processors separated by "|", each with an optional argument after ":":
+//
+//	links:https://www.notion.so/=>/notion/   rewrites the link targets starting with a prefix
+//	toc[:3]                                  adds a table of contents of the headings up to a level
+//	headings:1                               shifts the level of headings (negative to raise them)
+//	redact:emails | redact:/regexp/          replaces email addresses or the matches of a regexp with [redacted]
+//	replace:/regexp/replacement/             replaces the matches of a regexp ($1 expands to the first group)
+//
+// Regular expressions are written between slashes, and may contain "|" and escaped slashes ("\/").
+package postprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// Names of the built-in processors.
+const (
+	NameLinks    = "links"
+	NameTOC      = "toc"
+	NameHeadings = "headings"
+	NameRedact   = "redact"
+	NameReplace  = "replace"
+)
+
+const (
+	// Redacted replaces the text removed by redactions.
+	Redacted = "[redacted]"
+
+	// redactEmails is the argument of the redaction of email addresses.
+	redactEmails = "emails"
+	// minTOCLevel is the smallest level argument of a table of contents: the title is usually the only h1.
+	minTOCLevel = 2
+	// defaultTOCLevel is the deepest heading level listed by a table of contents without argument.
+	defaultTOCLevel = 3
+	// maxHeadingLevel is the deepest markdown heading level.
+	maxHeadingLevel = 6
+	// frontmatterDelimiter opens and closes the frontmatter of documents.
+	frontmatterDelimiter = "---\n"
+
+	// Parts of the regular expression arguments split on their slashes: an empty prefix, the regular expression,
+	// the replacement of replace, and an empty suffix.
+	redactParts  = 3
+	replaceParts = 4
+)
+
+// emailPattern matches email addresses.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// Processor transforms the body of a markdown document.
+type Processor interface {
+	// Process returns the transformed body.
+	Process(body string) string
+}
+
+// Chain is an ordered list of processors. An empty chain leaves documents unchanged.
+type Chain []Processor
+
+// Process runs the processors in order on the body of a document, keeping its frontmatter.
+func (ch Chain) Process(doc []byte) []byte {
+	if len(ch) == 0 {
+		return doc
+	}
+	frontmatter, body := SplitFrontmatter(string(doc))
+	for _, processor := range ch {
+		body = processor.Process(body)
+	}
+	return []byte(frontmatter + body)
+}
+
+// Parse parses a chain of processors separated by "|".
+func Parse(spec string) (Chain, error) {
+	var chain Chain
+	for _, item := range Split(spec) {
+		processor, err := New(item)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, processor)
+	}
+	return chain, nil
+}
+
+// Split splits processors separated by "|", keeping together the regular expressions that contain "|". Empty
+// items are dropped.
+func Split(spec string) []string {
+	var split []string
+	inRegexp := false
+	for part := range strings.SplitSeq(spec, "|") {
+		if inRegexp {
+			split[len(split)-1] += "|" + part
+		} else {
+			split = append(split, part)
+		}
+		inRegexp = !regexpComplete(strings.TrimSpace(split[len(split)-1]))
+	}
+
+	items := split[:0]
+	for _, item := range split {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// regexpComplete returns false if a processor has a regular expression argument whose closing slash is missing.
+func regexpComplete(item string) bool {
+	name, arg, _ := strings.Cut(item, ":")
+	if !strings.HasPrefix(arg, "/") {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case NameRedact:
+		return len(splitSlashes(arg)) >= redactParts
+	case NameReplace:
+		return len(splitSlashes(arg)) >= replaceParts
+	default:
+		return true
+	}
+}
+
+// New creates a processor from its name and optional argument, like "headings:1".
+func New(item string) (Processor, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(item), ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case NameLinks:
+		from, to, found := strings.Cut(arg, "=>")
+		if !found || from == "" {
+			return nil, fmt.Errorf("%w: %q (expected links:prefix=>replacement)", apperrors.ErrInvalidPostProcessor, item)
+		}
+		return &linkRewriter{from: from, to: to}, nil
+	case NameTOC:
+		level := defaultTOCLevel
+		if arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < minTOCLevel || n > maxHeadingLevel {
+				return nil, fmt.Errorf("%w: %q (expected a level from 2 to 6)", apperrors.ErrInvalidPostProcessor, item)
+			}
+			level = n
+		}
+		return &tableOfContents{maxLevel: level}, nil
+	case NameHeadings:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n == 0 || n <= -maxHeadingLevel || n >= maxHeadingLevel {
+			return nil, fmt.Errorf("%w: %q (expected an offset from -5 to 5)", apperrors.ErrInvalidPostProcessor, item)
+		}
+		return &headingOffset{offset: n}, nil
+	case NameRedact:
+		if arg == redactEmails {
+			return &replacer{pattern: emailPattern, replacement: Redacted, literal: true}, nil
+		}
+		parts := splitSlashes(arg)
+		if len(parts) != redactParts || parts[0] != "" || parts[2] != "" {
+			return nil, fmt.Errorf("%w: %q (expected redact:emails or redact:/regexp/)",
+				apperrors.ErrInvalidPostProcessor, item)
+		}
+		return newReplacer(item, parts[1], Redacted, true)
+	case NameReplace:
+		parts := splitSlashes(arg)
+		if len(parts) != replaceParts || parts[0] != "" || parts[3] != "" {
+			return nil, fmt.Errorf("%w: %q (expected replace:/regexp/replacement/)",
+				apperrors.ErrInvalidPostProcessor, item)
+		}
+		return newReplacer(item, parts[1], strings.ReplaceAll(parts[2], `\/`, "/"), false)
+	default:
+		return nil, fmt.Errorf("%w: unknown processor %q", apperrors.ErrInvalidPostProcessor, name)
+	}
+}
+
+// splitSlashes splits an argument on its slashes that are not escaped by a backslash.
+func splitSlashes(arg string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			i++ // Skip the escaped character
+		case '/':
+			parts = append(parts, arg[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, arg[start:])
+}
+
+// SplitFrontmatter splits a document into its frontmatter, with its delimiters, and its body.
+func SplitFrontmatter(doc string) (string, string) {
+	if !strings.HasPrefix(doc, frontmatterDelimiter) {
+		return "", doc
+	}
+	end := strings.Index(doc[len(frontmatterDelimiter):], "\n"+frontmatterDelimiter)
+	if end < 0 {
+		return "", doc
+	}
+	end += len(frontmatterDelimiter) + 1 + len(frontmatterDelimiter)
+	return doc[:end], doc[end:]
+}
+
+// linkRewriter rewrites the targets of links and images starting with a prefix.
+type linkRewriter struct {
+	from string
+	to   string
+}
+
+// Process rewrites the inline links, images and autolinks of the body.
+func (l *linkRewriter) Process(body string) string {
+	body = strings.ReplaceAll(body, "]("+l.from, "]("+l.to)
+	return strings.ReplaceAll(body, "<"+l.from, "<"+l.to)
+}
+
+// replacer replaces the matches of a regular expression.
+type replacer struct {
+	pattern     *regexp.Regexp
+	replacement string
+	literal     bool // The replacement is not expanded
+}
+
+// newReplacer compiles the regular expression of a replacer.
+func newReplacer(item, pattern, replacement string, literal bool) (*replacer, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", apperrors.ErrInvalidPostProcessor, item, err)
+	}
+	return &replacer{pattern: re, replacement: replacement, literal: literal}, nil
+}
+
+// Process replaces the matches in the whole body, code blocks included.
+func (r *replacer) Process(body string) string {
+	if r.literal {
+		return r.pattern.ReplaceAllLiteralString(body, r.replacement)
+	}
+	return r.pattern.ReplaceAllString(body, r.replacement)
+}
+
+// headingOffset shifts the level of headings, within the levels 1 to 6.
+type headingOffset struct {
+	offset int
+}
+
+// Process shifts the headings outside code blocks.
+func (h *headingOffset) Process(body string) string {
+	lines := strings.SplitAfter(body, "\n")
+	forEachHeading(lines, func(i, level int, text string) {
+		level = min(max(level+h.offset, 1), maxHeadingLevel)
+		lines[i] = strings.Repeat("#", level) + " " + text + lineEnding(lines[i])
+	})
+	return strings.Join(lines, "")
+}
+
+// tableOfContents lists the headings of a document below its title, up to maxLevel, with links to their anchors.
+type tableOfContents struct {
+	maxLevel int
+}
+
+// tocMarker is the line rendered for table_of_contents blocks, replaced by the table of contents.
+const tocMarker = "[TOC]"
+
+// tocEntry is a heading listed by a table of contents.
+type tocEntry struct {
+	level  int
+	text   string
+	anchor string
+}
+
+// Process replaces the first table_of_contents block with the table of contents, or inserts it after the title:
+// the heading the document starts with. Documents without other headings are left unchanged.
+func (t *tableOfContents) Process(body string) string {
+	lines := strings.SplitAfter(body, "\n")
+
+	titleLine := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			if strings.HasPrefix(line, "#") {
+				titleLine = i
+			}
+			break
+		}
+	}
+
+	var entries []tocEntry
+	anchors := make(map[string]int)
+	minLevel := maxHeadingLevel
+	forEachHeading(lines, func(i, level int, text string) {
+		anchor := headingAnchor(text, anchors) // The title takes its anchor too
+		if i == titleLine || level > t.maxLevel {
+			return
+		}
+		entries = append(entries, tocEntry{level: level, text: text, anchor: anchor})
+		minLevel = min(minLevel, level)
+	})
+	if len(entries) == 0 {
+		return body
+	}
+
+	var toc strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&toc, "%s- [%s](#%s)\n", strings.Repeat("  ", entry.level-minLevel), entry.text, entry.anchor)
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == tocMarker {
+			lines[i] = toc.String()
+			return strings.Join(lines, "")
+		}
+	}
+
+	insertAt := titleLine + 1
+	block := toc.String() + "\n"
+	if titleLine >= 0 && insertAt < len(lines) && strings.TrimSpace(lines[insertAt]) == "" {
+		insertAt++
+	} else if titleLine >= 0 {
+		block = "\n" + block
+	}
+	lines = append(lines[:insertAt], append([]string{block}, lines[insertAt:]...)...)
+	return strings.Join(lines, "")
+}
+
+// forEachHeading calls fn with the index, level and text of the ATX headings of lines, outside fenced code blocks.
+func forEachHeading(lines []string, fn func(i, level int, text string)) {
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(trimmed), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if marker := fenceMarker(trimmed); marker != "" {
+			fence = marker
+			continue
+		}
+
+		level := 0
+		for level < len(trimmed) && trimmed[level] == '#' {
+			level++
+		}
+		if level == 0 || level > maxHeadingLevel || level >= len(trimmed) || trimmed[level] != ' ' {
+			continue
+		}
+		fn(i, level, strings.TrimSpace(trimmed[level+1:]))
+	}
+}
+
+// fenceMarker returns the backticks or tildes opening a fenced code block, or "" if the line does not open one.
+func fenceMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	for _, char := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, char))
+		if n >= 3 { //nolint:mnd // Fences are at least 3 characters long
+			return strings.Repeat(char, n)
+		}
+	}
+	return ""
+}
+
+// lineEnding returns the line ending of a line ("" for the last line of a document without one).
+func lineEnding(line string) string {
+	return line[len(strings.TrimRight(line, "\r\n")):]
+}
+
+// headingAnchor returns the anchor of a heading, like GitHub and most markdown renderers generate it: lowercase,
+// without punctuation, spaces replaced by hyphens, and suffixed with a number when already used.
+func headingAnchor(text string, used map[string]int) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			builder.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+		}
+	}
+	anchor := builder.String()
+	count := used[anchor]
+	used[anchor] = count + 1
+	if count > 0 {
+		anchor += "-" + strconv.Itoa(count)
+	}
+	return anchor
+}
@@ -0,0 +1,106 @@
+package postprocess
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestChain_Process(t *testing.T) {
+	t.Parallel()
+
+	doc := "---\nnotion_id: abc\n# not a heading\n---\n# Title\n\n" +
+		"Mail [bob](https://www.notion.so/abc) at bob@example.com\n\n" +
+		"## Setup\n\n```sh\n# comment\n```\n\n### Setup\n\n## Ticket ABC-12\n"
+
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "links and redaction",
+			spec: "links:https://www.notion.so/=>/notion/|redact:emails",
+			want: "---\nnotion_id: abc\n# not a heading\n---\n# Title\n\n" +
+				"Mail [bob](/notion/abc) at [redacted]\n\n" +
+				"## Setup\n\n```sh\n# comment\n```\n\n### Setup\n\n## Ticket ABC-12\n",
+		},
+		{
+			name: "headings then toc",
+			spec: "headings:1|toc:3",
+			want: "---\nnotion_id: abc\n# not a heading\n---\n## Title\n\n" +
+				"- [Setup](#setup)\n- [Ticket ABC-12](#ticket-abc-12)\n\n" +
+				"Mail [bob](https://www.notion.so/abc) at bob@example.com\n\n" +
+				"### Setup\n\n```sh\n# comment\n```\n\n#### Setup\n\n### Ticket ABC-12\n",
+		},
+		{
+			name: "toc then replace",
+			spec: `toc|replace:/([A-Z]+-\d+)/[$1](https:\/\/jira\/$1)/`,
+			want: "---\nnotion_id: abc\n# not a heading\n---\n# Title\n\n" +
+				"- [Setup](#setup)\n  - [Setup](#setup-1)\n- [Ticket [ABC-12](https://jira/ABC-12)](#ticket-abc-12)\n\n" +
+				"Mail [bob](https://www.notion.so/abc) at bob@example.com\n\n" +
+				"## Setup\n\n```sh\n# comment\n```\n\n### Setup\n\n## Ticket [ABC-12](https://jira/ABC-12)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := string(chain.Process([]byte(doc))); got != tt.want {
+				t.Errorf("Process() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableOfContents_Marker(t *testing.T) {
+	t.Parallel()
+
+	chain, err := Parse("toc")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	doc := "# Title\n\nIntro\n\n[TOC]\n\n## Café crème 🚀\n"
+	want := "# Title\n\nIntro\n\n- [Café crème 🚀](#café-crème-)\n\n## Café crème 🚀\n"
+	if got := string(chain.Process([]byte(doc))); got != want {
+		t.Errorf("Process() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Without headings, the document is unchanged
+	if got := string(chain.Process([]byte("# Title\n\n[TOC]\n"))); got != "# Title\n\n[TOC]\n" {
+		t.Errorf("Process() = %q, want the document unchanged", got)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	got := Split(" toc | redact:/secret|token/ || replace:/a\\/b|c/d/|headings:-1")
+	want := []string{"toc", "redact:/secret|token/", "replace:/a\\/b|c/d/", "headings:-1"}
+	if len(got) != len(want) {
+		t.Fatalf("Split() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Split()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, item := range []string{
+		"unknown", "links:https://a", "toc:1", "toc:x", "headings:0", "headings:6",
+		"redact", "redact:/[/", "replace:/a/", "replace:/(/b/",
+	} {
+		if _, err := New(item); !errors.Is(err, apperrors.ErrInvalidPostProcessor) {
+			t.Errorf("New(%q) error = %v, want ErrInvalidPostProcessor", item, err)
+		}
+	}
+}
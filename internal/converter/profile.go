@@ -0,0 +1,175 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Output profiles select how blocks without a markdown equivalent (callouts, toggles) are rendered.
+const (
+	// ProfileDefault renders callouts as blockquotes and toggles with collapsible comments.
+	ProfileDefault = "default"
+	// ProfileGitHub renders callouts as GitHub alerts and toggles as <details> elements.
+	ProfileGitHub = "github"
+	// ProfileMkDocs renders callouts as MkDocs admonitions and toggles as collapsible admonitions.
+	ProfileMkDocs = "mkdocs"
+	// ProfileObsidian renders callouts as Obsidian callouts, toggles as folded callouts, links to child pages as
+	// wikilinks, and database properties as top-level frontmatter fields, so that the mirror is an Obsidian vault.
+	ProfileObsidian = "obsidian"
+	// ProfileDocusaurus renders callouts as Docusaurus admonitions and toggles as <details> elements, in .mdx files
+	// whose text is escaped so that MDX does not parse it as JSX.
+	ProfileDocusaurus = "docusaurus"
+	// ProfileHTML writes standalone HTML documents instead of markdown (see the html package).
+	ProfileHTML = "html"
+
+	// mkdocsIndent is the indentation of admonition content.
+	mkdocsIndent = "    "
+)
+
+// Profiles lists the supported output profiles.
+var Profiles = []string{ProfileDefault, ProfileGitHub, ProfileMkDocs, ProfileObsidian, ProfileDocusaurus, ProfileHTML}
+
+// IsValidProfile returns true if the profile is supported. An empty profile is the default one.
+func IsValidProfile(profile string) bool {
+	return profile == "" || slices.Contains(Profiles, profile)
+}
+
+// calloutKind is the kind of admonition matching a callout color.
+type calloutKind int
+
+const (
+	calloutNote calloutKind = iota
+	calloutTip
+	calloutImportant
+	calloutWarning
+	calloutCaution
+)
+
+// calloutKindFromColor maps a Notion callout color to an admonition kind.
+func calloutKindFromColor(color string) calloutKind {
+	switch strings.TrimSuffix(color, "_background") {
+	case "green":
+		return calloutTip
+	case "purple", "pink":
+		return calloutImportant
+	case "yellow", "orange", "brown":
+		return calloutWarning
+	case "red":
+		return calloutCaution
+	default:
+		return calloutNote
+	}
+}
+
+// gitHubAlert returns the GitHub alert type of a callout kind.
+func (k calloutKind) gitHubAlert() string {
+	return [...]string{"NOTE", "TIP", "IMPORTANT", "WARNING", "CAUTION"}[k]
+}
+
+// mkdocsAdmonition returns the MkDocs (and Docusaurus) admonition type of a callout kind.
+func (k calloutKind) mkdocsAdmonition() string {
+	return [...]string{"note", "tip", "info", "warning", "danger"}[k]
+}
+
+// obsidianCallout returns the Obsidian callout type of a callout kind.
+func (k calloutKind) obsidianCallout() string {
+	return [...]string{"note", "tip", "important", "warning", "danger"}[k]
+}
+
+// convertCallout converts a callout block according to the output profile.
+func (c *Converter) convertCallout(block *notion.Block, depth int, opts *ConvertOptions) string {
+	text := richTextToMarkdown(block.Callout.RichText, opts)
+	emoji := ""
+	if block.Callout.Icon != nil && block.Callout.Icon.Emoji != "" {
+		emoji = block.Callout.Icon.Emoji + " "
+	}
+	kind := calloutKindFromColor(block.Callout.Color)
+	lines := strings.Split(emoji+text, "\n")
+
+	var builder strings.Builder
+	switch opts.Profile {
+	case ProfileGitHub:
+		fmt.Fprintf(&builder, "> [!%s]\n", kind.gitHubAlert())
+		for _, line := range lines {
+			fmt.Fprintf(&builder, "> %s\n", line)
+		}
+		builder.WriteString(c.convertChildren(block.Children, depth, opts))
+	case ProfileMkDocs:
+		fmt.Fprintf(&builder, "!!! %s\n\n", kind.mkdocsAdmonition())
+		builder.WriteString(indentLines(strings.Join(lines, "\n")+"\n", mkdocsIndent))
+		builder.WriteString(indentLines(c.convertChildren(block.Children, 0, opts), mkdocsIndent))
+	case ProfileDocusaurus:
+		fmt.Fprintf(&builder, ":::%s\n\n", kind.mkdocsAdmonition())
+		builder.WriteString(strings.Join(lines, "\n") + "\n")
+		if children := c.convertChildren(block.Children, 0, opts); children != "" {
+			builder.WriteString("\n" + children)
+		}
+		builder.WriteString("\n:::\n")
+	case ProfileObsidian:
+		fmt.Fprintf(&builder, "> [!%s]\n", kind.obsidianCallout())
+		builder.WriteString(quoteLines(strings.Join(lines, "\n") + "\n" + c.convertChildren(block.Children, 0, opts)))
+	default:
+		for _, line := range lines {
+			fmt.Fprintf(&builder, "> %s\n", line)
+		}
+		builder.WriteString(c.convertChildren(block.Children, depth, opts))
+	}
+	return builder.String()
+}
+
+// convertToggle converts a toggle block according to the output profile.
+func (c *Converter) convertToggle(block *notion.Block, opts *ConvertOptions) string {
+	text := richTextToMarkdown(block.Toggle.RichText, opts)
+	children := c.convertChildren(block.Children, 0, opts)
+
+	var builder strings.Builder
+	switch opts.Profile {
+	case ProfileGitHub, ProfileDocusaurus:
+		fmt.Fprintf(&builder, "<details>\n<summary>%s</summary>\n\n", text)
+		builder.WriteString(children)
+		builder.WriteString("\n</details>\n")
+	case ProfileMkDocs:
+		fmt.Fprintf(&builder, "??? note \"%s\"\n\n", strings.ReplaceAll(text, `"`, "'"))
+		builder.WriteString(indentLines(children, mkdocsIndent))
+	case ProfileObsidian:
+		fmt.Fprintf(&builder, "> [!note]- %s\n", text)
+		builder.WriteString(quoteLines(children))
+	default:
+		fmt.Fprintf(&builder, "<!-- collapsible: start -->\n**%s**\n\n", text)
+		builder.WriteString(children)
+		builder.WriteString("<!-- collapsible: end -->\n")
+	}
+	return builder.String()
+}
+
+// indentLines indents every non-empty line of the text.
+func indentLines(text, indent string) string {
+	lines := strings.SplitAfter(text, "\n")
+	var builder strings.Builder
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			builder.WriteString(indent)
+		}
+		builder.WriteString(line)
+	}
+	return builder.String()
+}
+
+// quoteLines prefixes every line of the text with a blockquote marker, so that it stays inside the callout.
+func quoteLines(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	var builder strings.Builder
+	for line := range strings.Lines(strings.TrimSuffix(text, "\n") + "\n") {
+		if strings.TrimSpace(line) == "" {
+			builder.WriteString(">\n")
+			continue
+		}
+		builder.WriteString("> " + line)
+	}
+	return builder.String()
+}
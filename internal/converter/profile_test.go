@@ -0,0 +1,148 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestConvertCallout_Profiles(t *testing.T) {
+	t.Parallel()
+
+	block := &notion.Block{
+		Type: "callout",
+		Callout: &notion.CalloutBlock{
+			RichText: []notion.RichText{{Type: "text", PlainText: "Careful\nsecond line"}},
+			Icon:     &notion.Icon{Type: "emoji", Emoji: "⚠️"},
+			Color:    "yellow_background",
+		},
+		Children: []notion.Block{{
+			Type:      blockTypeParagraph,
+			Paragraph: &notion.ParagraphBlock{RichText: []notion.RichText{{Type: "text", PlainText: "Child"}}},
+		}},
+	}
+
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{profile: "", want: "> ⚠️ Careful\n> second line\nChild\n"},
+		{profile: ProfileGitHub, want: "> [!WARNING]\n> ⚠️ Careful\n> second line\nChild\n"},
+		{profile: ProfileMkDocs, want: "!!! warning\n\n    ⚠️ Careful\n    second line\n    Child\n"},
+		{profile: ProfileObsidian, want: "> [!warning]\n> ⚠️ Careful\n> second line\n> Child\n"},
+		{profile: ProfileDocusaurus, want: ":::warning\n\n⚠️ Careful\nsecond line\n\nChild\n\n:::\n"},
+	}
+
+	c := NewConverter()
+	for _, tc := range tests {
+		t.Run(tc.profile, func(t *testing.T) {
+			t.Parallel()
+			if got := c.convertBlock(block, 0, &ConvertOptions{Profile: tc.profile}); got != tc.want {
+				t.Errorf("convertBlock() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConvertToggle_Profiles(t *testing.T) {
+	t.Parallel()
+
+	block := &notion.Block{
+		Type:   "toggle",
+		Toggle: &notion.ToggleBlock{RichText: []notion.RichText{{Type: "text", PlainText: "Details"}}},
+		Children: []notion.Block{{
+			Type:      blockTypeParagraph,
+			Paragraph: &notion.ParagraphBlock{RichText: []notion.RichText{{Type: "text", PlainText: "Hidden"}}},
+		}},
+	}
+
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{profile: "", want: "<!-- collapsible: start -->\n**Details**\n\nHidden\n<!-- collapsible: end -->\n"},
+		{profile: ProfileGitHub, want: "<details>\n<summary>Details</summary>\n\nHidden\n\n</details>\n"},
+		{profile: ProfileMkDocs, want: "??? note \"Details\"\n\n    Hidden\n"},
+		{profile: ProfileObsidian, want: "> [!note]- Details\n> Hidden\n"},
+		{profile: ProfileDocusaurus, want: "<details>\n<summary>Details</summary>\n\nHidden\n\n</details>\n"},
+	}
+
+	c := NewConverter()
+	for _, tc := range tests {
+		t.Run(tc.profile, func(t *testing.T) {
+			t.Parallel()
+			if got := c.convertBlock(block, 0, &ConvertOptions{Profile: tc.profile}); got != tc.want {
+				t.Errorf("convertBlock() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateFrontmatter_Profile(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{ID: "abc123"}
+
+	if got := c.generateFrontmatter(page, &ConvertOptions{Profile: ProfileMkDocs}); !strings.Contains(got,
+		"output_profile: mkdocs\n") {
+		t.Errorf("frontmatter should record the profile, got:\n%s", got)
+	}
+	if got := c.generateFrontmatter(page, &ConvertOptions{Profile: ProfileDefault}); strings.Contains(got,
+		"output_profile") {
+		t.Errorf("frontmatter should not record the default profile, got:\n%s", got)
+	}
+}
+
+func TestGenerateFrontmatter_ObsidianProperties(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	page := &notion.Page{
+		ID:     "abc123",
+		Parent: notion.Parent{Type: "database_id", DatabaseID: "db-1"},
+		Properties: map[string]notion.Property{
+			"Status": {Type: "select", Select: &notion.SelectOption{Name: "Done"}},
+			"title":  {Type: "rich_text", RichText: []notion.RichText{{Type: "text", PlainText: "Subtitle"}}},
+		},
+	}
+
+	got := c.generateFrontmatter(page, &ConvertOptions{Profile: ProfileObsidian, PageTitle: "Task"})
+	if !strings.Contains(got, "\nStatus: \"Done\"\n") || !strings.Contains(got, "\nproperty_title: \"Subtitle\"\n") {
+		t.Errorf("properties should be top-level fields, got:\n%s", got)
+	}
+	if strings.Contains(got, "\nproperties:") {
+		t.Errorf("properties should not be nested, got:\n%s", got)
+	}
+}
+
+func TestConvertBlock_ObsidianWikiLinks(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	opts := &ConvertOptions{Profile: ProfileObsidian, PageTitle: "Parent Page", FilePath: "docs/parent-page.md"}
+
+	page := c.convertBlock(&notion.Block{
+		ID: "child123", Type: "child_page", ChildPage: &notion.ChildPageBlock{Title: "Child | Draft"},
+	}, 0, opts)
+	if want := "- [[docs/parent-page/child-draft|Child - Draft]]<!-- page_id:child123 -->\n"; page != want {
+		t.Errorf("child_page = %q, want %q", page, want)
+	}
+}
+
+func TestIsValidProfile(t *testing.T) {
+	t.Parallel()
+
+	for _, profile := range Profiles {
+		if !IsValidProfile(profile) {
+			t.Errorf("IsValidProfile(%q) = false, want true", profile)
+		}
+	}
+	if !IsValidProfile("") {
+		t.Error("IsValidProfile(\"\") = false, want true")
+	}
+	if IsValidProfile("hugo") {
+		t.Error("IsValidProfile(\"hugo\") = true, want false")
+	}
+}
@@ -0,0 +1,62 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultMaxProperties is the default maximum number of database properties written in the frontmatter
+// of a page.
+const DefaultMaxProperties = 50
+
+// PropertyFilter selects the properties of a database written in the frontmatter, by name.
+// Names are matched case-insensitively.
+type PropertyFilter struct {
+	Allow []string // Properties to write, in this order (empty = all properties, by name)
+	Deny  []string // Properties never written
+}
+
+// PropertySelection selects the database properties written in the frontmatter of database pages.
+// The zero value writes all properties.
+type PropertySelection struct {
+	Default   PropertyFilter            // Filter of databases without their own
+	Databases map[string]PropertyFilter // Filters by normalized database or data source ID
+	Max       int                       // Maximum number of properties per page (0 = unlimited)
+}
+
+// filterFor returns the filter of a database, identified by its database or data source ID.
+func (s *PropertySelection) filterFor(ids ...string) PropertyFilter {
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if filter, ok := s.Databases[NormalizeID(id)]; ok {
+			return filter
+		}
+	}
+	return s.Default
+}
+
+// names returns the names of the properties to write, in order.
+func (f PropertyFilter) names(available []string) []string {
+	slices.Sort(available)
+
+	var names []string
+	if len(f.Allow) == 0 {
+		names = available
+	} else {
+		for _, allowed := range f.Allow {
+			if i := slices.IndexFunc(available, func(name string) bool {
+				return strings.EqualFold(name, strings.TrimSpace(allowed))
+			}); i >= 0 && !slices.Contains(names, available[i]) {
+				names = append(names, available[i])
+			}
+		}
+	}
+
+	return slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		return slices.ContainsFunc(f.Deny, func(denied string) bool {
+			return strings.EqualFold(name, strings.TrimSpace(denied))
+		})
+	})
+}
@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// htmlEntityEscaper escapes the braces that MDX would parse as JSX expressions, in HTML text.
+var htmlEntityEscaper = strings.NewReplacer("{", "&#123;", "}", "&#125;")
+
+// convertTable converts a table block with its rows: a markdown table, or an HTML table when it has more columns
+// than opts.HTMLTableColumns.
+func (c *Converter) convertTable(block *notion.Block, opts *ConvertOptions) string {
+	if block.Table == nil || len(block.Children) == 0 {
+		return ""
+	}
+	if opts.HTMLTableColumns > 0 && block.Table.TableWidth > opts.HTMLTableColumns {
+		return convertHTMLTable(block, opts)
+	}
+
+	var builder strings.Builder
+	width := block.Table.TableWidth
+
+	for i := range block.Children {
+		row := &block.Children[i]
+		if row.TableRow == nil {
+			continue
+		}
+
+		// Build row
+		builder.WriteString("|")
+		for j := range width {
+			cell := ""
+			if j < len(row.TableRow.Cells) {
+				cell = escapeTableCell(richTextToMarkdown(row.TableRow.Cells[j], opts), opts)
+			}
+			fmt.Fprintf(&builder, " %s |", cell)
+		}
+		builder.WriteString("\n")
+
+		// Add header separator after first row if it's a header
+		if i == 0 && block.Table.HasColumnHeader {
+			builder.WriteString("|")
+			for range width {
+				builder.WriteString(" --- |")
+			}
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
+
+// escapeTableCell makes converted text safe to use in a markdown table cell: pipes are escaped, and line breaks
+// become <br> elements, so that the cell stays on the line of its row.
+func escapeTableCell(text string, opts *ConvertOptions) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", htmlLineBreak(opts))
+}
+
+// htmlLineBreak returns the line break element of the output profile: MDX only supports self-closing ones.
+func htmlLineBreak(opts *ConvertOptions) string {
+	if opts.Profile == ProfileDocusaurus {
+		return "<br />"
+	}
+	return "<br>"
+}
+
+// convertHTMLTable converts a table block to an HTML table, whose cells are not limited to the width of a line.
+func convertHTMLTable(block *notion.Block, opts *ConvertOptions) string {
+	var builder strings.Builder
+	builder.WriteString("<table>\n")
+	for i := range block.Children {
+		row := block.Children[i].TableRow
+		if row == nil {
+			continue
+		}
+		builder.WriteString("<tr>")
+		for j := range block.Table.TableWidth {
+			cell := ""
+			if j < len(row.Cells) {
+				cell = richTextToHTML(row.Cells[j], opts)
+			}
+			tag := "td"
+			if (i == 0 && block.Table.HasColumnHeader) || (j == 0 && block.Table.HasRowHeader) {
+				tag = "th"
+			}
+			fmt.Fprintf(&builder, "<%s>%s</%s>", tag, cell, tag)
+		}
+		builder.WriteString("</tr>\n")
+	}
+	builder.WriteString("</table>\n")
+	return builder.String()
+}
+
+// richTextToHTML converts rich text to HTML, with its annotations and links: markdown is not rendered inside
+// HTML tables.
+func richTextToHTML(richText []notion.RichText, opts *ConvertOptions) string {
+	opts.checkMentions(richText)
+	escape := html.EscapeString
+	if opts.Profile == ProfileDocusaurus {
+		escape = func(text string) string { return htmlEntityEscaper.Replace(html.EscapeString(text)) }
+	}
+
+	var builder strings.Builder
+	for i := range richText {
+		item := &richText[i]
+		text := item.PlainText
+		switch {
+		case item.Type == "equation" && item.Equation != nil:
+			text = "$" + item.Equation.Expression + "$"
+		case item.Type == "mention" && item.Mention != nil && item.Mention.User != nil:
+			text = "@" + item.Mention.User.Format()
+		}
+		text = strings.ReplaceAll(escape(text), "\n", htmlLineBreak(opts))
+
+		if a := item.Annotations; a != nil {
+			text = wrapHTML(text, "code", a.Code)
+			text = wrapHTML(text, "strong", a.Bold)
+			text = wrapHTML(text, "em", a.Italic)
+			text = wrapHTML(text, "del", a.Strikethrough)
+		}
+		if item.Href != nil && *item.Href != "" {
+			text = fmt.Sprintf("<a href=\"%s\">%s</a>", escape(*item.Href), text)
+		}
+		builder.WriteString(text)
+	}
+	return builder.String()
+}
+
+// wrapHTML wraps text in an element if enabled.
+func wrapHTML(text, tag string, enabled bool) string {
+	if !enabled {
+		return text
+	}
+	return "<" + tag + ">" + text + "</" + tag + ">"
+}
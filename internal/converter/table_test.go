@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// tableBlock builds a table block with a header row, from the plain text of its cells.
+func tableBlock(rows ...[]string) *notion.Block {
+	block := &notion.Block{
+		Type:  "table",
+		Table: &notion.TableBlock{TableWidth: len(rows[0]), HasColumnHeader: true},
+	}
+	for _, row := range rows {
+		cells := make([][]notion.RichText, len(row))
+		for i, text := range row {
+			cells[i] = []notion.RichText{{Type: "text", PlainText: text}}
+		}
+		block.Children = append(block.Children, notion.Block{
+			Type:     "table_row",
+			TableRow: &notion.TableRowBlock{Cells: cells},
+		})
+	}
+	return block
+}
+
+func TestConvertTable_Escaping(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	block := tableBlock([]string{"Command", "Notes"}, []string{"a | b", "first\nsecond"})
+
+	want := "| Command | Notes |\n| --- | --- |\n| a \\| b | first<br>second |\n"
+	if got := c.convertBlock(block, 0, &ConvertOptions{}); got != want {
+		t.Errorf("convertBlock() = %q, want %q", got, want)
+	}
+
+	want = "| Command | Notes |\n| --- | --- |\n| a \\| b | first<br />second |\n"
+	if got := c.convertBlock(block, 0, &ConvertOptions{Profile: ProfileDocusaurus}); got != want {
+		t.Errorf("convertBlock() with docusaurus = %q, want %q", got, want)
+	}
+}
+
+func TestConvertTable_HTMLFallback(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverter()
+	block := tableBlock([]string{"A", "B", "C"}, []string{"x < y", "first\nsecond", "{z}"})
+	block.Children[1].TableRow.Cells[0][0].Annotations = &notion.Annotations{Bold: true}
+
+	// Tables up to the threshold stay markdown tables
+	if got := c.convertBlock(block, 0, &ConvertOptions{HTMLTableColumns: 3}); got[0] != '|' {
+		t.Errorf("convertBlock() with 3 columns = %q, want a markdown table", got)
+	}
+
+	want := "<table>\n<tr><th>A</th><th>B</th><th>C</th></tr>\n" +
+		"<tr><td><strong>x &lt; y</strong></td><td>first<br>second</td><td>{z}</td></tr>\n</table>\n"
+	if got := c.convertBlock(block, 0, &ConvertOptions{HTMLTableColumns: 2}); got != want {
+		t.Errorf("convertBlock() = %q, want %q", got, want)
+	}
+
+	want = "<table>\n<tr><th>A</th><th>B</th><th>C</th></tr>\n" +
+		"<tr><td><strong>x &lt; y</strong></td><td>first<br />second</td><td>&#123;z&#125;</td></tr>\n</table>\n"
+	opts := &ConvertOptions{HTMLTableColumns: 2, Profile: ProfileDocusaurus}
+	if got := c.convertBlock(block, 0, opts); got != want {
+		t.Errorf("convertBlock() with docusaurus = %q, want %q", got, want)
+	}
+}
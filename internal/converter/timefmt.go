@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// Named date formats.
+const (
+	// DateFormatRFC3339 writes timestamps like 2006-01-02T15:04:05+02:00 (default).
+	DateFormatRFC3339 = "rfc3339"
+	// DateFormatDateTime writes timestamps like 2006-01-02 15:04:05, in the configured time zone.
+	DateFormatDateTime = "datetime"
+)
+
+// TimeFormat is how timestamps are written in frontmatter and reports: in which time zone, and with
+// which layout. The zero value writes RFC 3339 timestamps in UTC.
+type TimeFormat struct {
+	Location *time.Location // Time zone (UTC if nil)
+	Layout   string         // Go time layout (time.RFC3339 if empty)
+}
+
+// ParseTimeFormat parses a time zone (IANA name like Europe/Paris, "Local" or empty for UTC) and a date
+// format (a named format or a Go time layout). Layouts must keep timestamps to the second, so that
+// frontmatter timestamps can be read back.
+func ParseTimeFormat(zone, format string) (TimeFormat, error) {
+	var timeFormat TimeFormat
+
+	if zone = strings.TrimSpace(zone); zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return TimeFormat{}, fmt.Errorf("%w: %q", apperrors.ErrInvalidTimeZone, zone)
+		}
+		timeFormat.Location = loc
+	}
+
+	switch format = strings.TrimSpace(format); strings.ToLower(format) {
+	case "", DateFormatRFC3339:
+	case DateFormatDateTime:
+		timeFormat.Layout = time.DateTime
+	default:
+		reference := time.Date(2026, time.December, 31, 23, 58, 59, 0, timeFormat.location())
+		parsed, err := time.ParseInLocation(format, reference.Format(format), timeFormat.location())
+		if err != nil || !parsed.Equal(reference) {
+			return TimeFormat{}, fmt.Errorf("%w: %q", apperrors.ErrInvalidDateFormat, format)
+		}
+		timeFormat.Layout = format
+	}
+
+	return timeFormat, nil
+}
+
+// Format formats a timestamp in the time zone and layout.
+func (f TimeFormat) Format(t time.Time) string {
+	return t.In(f.location()).Format(f.layout())
+}
+
+// Parse parses a timestamp written with Format. RFC 3339 timestamps are accepted too, so that files
+// written with another format can still be read.
+func (f TimeFormat) Parse(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(f.layout(), value, f.location())
+	if err != nil && f.layout() != time.RFC3339 {
+		if rfcTime, rfcErr := time.Parse(time.RFC3339, value); rfcErr == nil {
+			return rfcTime, nil
+		}
+	}
+	return t, err
+}
+
+// location returns the time zone, UTC by default.
+func (f TimeFormat) location() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
+}
+
+// layout returns the Go time layout, RFC 3339 by default.
+func (f TimeFormat) layout() string {
+	if f.Layout == "" {
+		return time.RFC3339
+	}
+	return f.Layout
+}
@@ -0,0 +1,62 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestParseTimeFormat(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		zone, format, want string
+	}{
+		{"", "", "2026-03-14T09:30:00Z"},
+		{"Europe/Paris", "", "2026-03-14T10:30:00+01:00"},
+		{"Asia/Tokyo", "datetime", "2026-03-14 18:30:00"},
+		{"America/New_York", "RFC3339", "2026-03-14T05:30:00-04:00"},
+		{"", "02/01/2006 15:04:05", "14/03/2026 09:30:00"},
+	}
+	for _, tt := range tests {
+		timeFormat, err := ParseTimeFormat(tt.zone, tt.format)
+		if err != nil {
+			t.Fatalf("ParseTimeFormat(%q, %q) error = %v", tt.zone, tt.format, err)
+		}
+		got := timeFormat.Format(ts)
+		if got != tt.want {
+			t.Errorf("ParseTimeFormat(%q, %q).Format() = %q, want %q", tt.zone, tt.format, got, tt.want)
+		}
+		if parsed, err := timeFormat.Parse(got); err != nil || !parsed.Equal(ts) {
+			t.Errorf("Parse(%q) = %v, %v, want %v", got, parsed, err, ts)
+		}
+	}
+
+	if _, err := ParseTimeFormat("Mars/Olympus", ""); !errors.Is(err, apperrors.ErrInvalidTimeZone) {
+		t.Errorf("unknown time zone error = %v, want ErrInvalidTimeZone", err)
+	}
+	for _, format := range []string{"2006-01-02", "yyyy-mm-dd"} {
+		if _, err := ParseTimeFormat("", format); !errors.Is(err, apperrors.ErrInvalidDateFormat) {
+			t.Errorf("ParseTimeFormat(%q) error = %v, want ErrInvalidDateFormat", format, err)
+		}
+	}
+}
+
+func TestTimeFormat_ParseRFC3339Fallback(t *testing.T) {
+	t.Parallel()
+
+	timeFormat, err := ParseTimeFormat("Europe/Paris", DateFormatDateTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files written before the date format changed
+	parsed, err := timeFormat.Parse("2026-03-14T09:30:00Z")
+	if err != nil || !parsed.Equal(time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Parse(RFC 3339) = %v, %v", parsed, err)
+	}
+}
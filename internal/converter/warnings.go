@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"slices"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Kinds of conversion warnings.
+const (
+	WarningUnknownBlock      = "unknown_block"      // Block whose type isn't rendered
+	WarningUnsupportedRollup = "unsupported_rollup" // Rollup property that is neither a number nor a date
+	WarningTruncatedRollup   = "truncated_rollup"   // Rollup property Notion could not compute entirely
+	WarningUnresolvedMention = "unresolved_mention" // Mention of a page, database or user the integration can't read
+)
+
+// notionUntitled is the text Notion gives to the mentions of pages and databases the integration can't read.
+const notionUntitled = "Untitled"
+
+// ConversionWarning is an anomaly of a conversion: content of the page that didn't translate to the output. It
+// wraps the sentinel error of its kind (apperrors.ErrUnknownBlockType...).
+type ConversionWarning struct {
+	Kind   string // Kind of anomaly (Warning* constants)
+	Detail string // What didn't translate: block type, property name or mentioned ID
+}
+
+// Error implements the error interface. It is also the text of the warning in the frontmatter.
+func (w *ConversionWarning) Error() string {
+	return w.Kind + ": " + w.Detail
+}
+
+// Unwrap returns the sentinel error of the kind of the warning.
+func (w *ConversionWarning) Unwrap() error {
+	switch w.Kind {
+	case WarningUnknownBlock:
+		return apperrors.ErrUnknownBlockType
+	case WarningUnsupportedRollup:
+		return apperrors.ErrUnsupportedRollup
+	case WarningTruncatedRollup:
+		return apperrors.ErrTruncatedRollup
+	case WarningUnresolvedMention:
+		return apperrors.ErrUnresolvedMention
+	default:
+		return nil
+	}
+}
+
+// warn records a conversion warning, once per kind and detail.
+func (opts *ConvertOptions) warn(kind, detail string) {
+	warning := &ConversionWarning{Kind: kind, Detail: detail}
+	if !slices.ContainsFunc(opts.Warnings, func(other *ConversionWarning) bool { return *other == *warning }) {
+		opts.Warnings = append(opts.Warnings, warning)
+	}
+}
+
+// checkMentions records the mentions of rich text that the integration couldn't resolve: users without a name,
+// and pages and databases Notion titles "Untitled" because they aren't shared with the integration.
+func (opts *ConvertOptions) checkMentions(richText []notion.RichText) {
+	for i := range richText {
+		mention := richText[i].Mention
+		if richText[i].Type != "mention" || mention == nil {
+			continue
+		}
+		switch {
+		case mention.User != nil && mention.User.Name == "":
+			opts.warn(WarningUnresolvedMention, "user "+mention.User.ID)
+		case mention.Page != nil && richText[i].PlainText == notionUntitled:
+			opts.warn(WarningUnresolvedMention, "page "+NormalizeID(mention.Page.ID))
+		case mention.Database != nil && richText[i].PlainText == notionUntitled:
+			opts.warn(WarningUnresolvedMention, "database "+NormalizeID(mention.Database.ID))
+		}
+	}
+}
+
+// checkRollup records the rollup properties whose value isn't written: the rollups Notion could not compute
+// entirely (over 25 relations), and the ones that are neither a number nor a date.
+func (opts *ConvertOptions) checkRollup(name string, prop *notion.Property) {
+	if prop.Type != "rollup" || prop.Rollup == nil {
+		return
+	}
+	switch prop.Rollup.Type {
+	case propTypeNumber, propTypeDate:
+	case "incomplete":
+		opts.warn(WarningTruncatedRollup, name)
+	case "array":
+		if len(prop.Rollup.Array) > 0 {
+			opts.warn(WarningUnsupportedRollup, name)
+		}
+	default:
+		opts.warn(WarningUnsupportedRollup, name)
+	}
+}
+
+// warningsList returns the texts of the warnings, for the sync_warnings frontmatter field.
+func warningsList(warnings []*ConversionWarning) []string {
+	list := make([]string, len(warnings))
+	for i, warning := range warnings {
+		list[i] = NormalizeText(warning.Error())
+	}
+	return list
+}
@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestConvertWithOptions_Warnings(t *testing.T) {
+	t.Parallel()
+
+	count := 3.0
+	page := &notion.Page{
+		ID:     "abc123",
+		Parent: notion.Parent{Type: "database_id", DatabaseID: "11111111-2222-3333-4444-555555555555"},
+		Properties: map[string]notion.Property{
+			"Tasks":  {Type: "rollup", Rollup: &notion.RollupValue{Type: "incomplete"}},
+			"Labels": {Type: "rollup", Rollup: &notion.RollupValue{Type: "array", Array: []any{"a"}}},
+			"Count":  {Type: "rollup", Rollup: &notion.RollupValue{Type: "number", Number: &count}},
+		},
+	}
+	mention := func(text string, m *notion.Mention) notion.RichText {
+		return notion.RichText{Type: "mention", PlainText: text, Mention: m}
+	}
+	blocks := []notion.Block{
+		{Type: "ai_block"},
+		{Type: "paragraph", Paragraph: &notion.ParagraphBlock{RichText: []notion.RichText{
+			mention("Untitled", &notion.Mention{Type: "page", Page: &struct {
+				ID string `json:"id"`
+			}{ID: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"}}),
+			mention("@Alice", &notion.Mention{Type: "user", User: &notion.User{ID: "user1", Name: "Alice"}}),
+			mention("@Anonymous", &notion.Mention{Type: "user", User: &notion.User{ID: "user2"}}),
+		}}},
+		{Type: "ai_block"},
+	}
+
+	opts := &ConvertOptions{}
+	result := string(NewConverter().ConvertWithOptions(page, blocks, opts))
+
+	want := "sync_warnings:\n" +
+		"  - \"unknown_block: ai_block\"\n" +
+		"  - \"unresolved_mention: page aaaaaaaabbbbccccddddeeeeeeeeeeee\"\n" +
+		"  - \"unresolved_mention: user user2\"\n" +
+		"  - \"unsupported_rollup: Labels\"\n" +
+		"  - \"truncated_rollup: Tasks\"\n" +
+		"properties:\n  Count: 3\n"
+	if !strings.Contains(result, want) {
+		t.Errorf("frontmatter should contain %q, got:\n%s", want, result)
+	}
+
+	sentinels := []error{apperrors.ErrUnknownBlockType, apperrors.ErrUnresolvedMention,
+		apperrors.ErrUnresolvedMention, apperrors.ErrUnsupportedRollup, apperrors.ErrTruncatedRollup}
+	if len(opts.Warnings) != len(sentinels) {
+		t.Fatalf("Warnings = %v, want %d warnings", opts.Warnings, len(sentinels))
+	}
+	for i, sentinel := range sentinels {
+		if !errors.Is(opts.Warnings[i], sentinel) {
+			t.Errorf("Warnings[%d] = %v, should wrap %v", i, opts.Warnings[i], sentinel)
+		}
+	}
+
+	clean := &ConvertOptions{}
+	if result := NewConverter().ConvertWithOptions(&notion.Page{ID: "abc123"}, nil, clean); strings.Contains(
+		string(result), "sync_warnings:") || len(clean.Warnings) != 0 {
+		t.Errorf("conversion without anomalies should not have warnings, got:\n%s", result)
+	}
+}
@@ -147,3 +147,74 @@ func (c *Client) GetAllBlockChildrenWithLimit(
 		MaxDepth:   maxDepth,
 	}, nil
 }
+
+// BlockInput is a block to create, in the shape expected by the Notion API.
+// Only the block types written by ntnsync are supported.
+type BlockInput struct {
+	Object    string          `json:"object"`
+	Type      string          `json:"type"`
+	Paragraph *TextBlockInput `json:"paragraph,omitempty"`
+	Callout   *TextBlockInput `json:"callout,omitempty"`
+}
+
+// TextBlockInput is the content of a text block to create.
+type TextBlockInput struct {
+	RichText []RichTextInput `json:"rich_text"`
+	Icon     *Icon           `json:"icon,omitempty"`
+}
+
+// RichTextInput is plain text to create, as a text rich text object.
+type RichTextInput struct {
+	Type string           `json:"type"`
+	Text TextContentInput `json:"text"`
+}
+
+// TextContentInput is the content of a text rich text object to create.
+type TextContentInput struct {
+	Content string `json:"content"`
+}
+
+// NewTextBlock returns a block of the given type (paragraph or callout) holding plain text. The emoji is
+// the icon of callouts, and is ignored for other types.
+func NewTextBlock(blockType, text, emoji string) BlockInput {
+	content := &TextBlockInput{
+		RichText: []RichTextInput{{Type: "text", Text: TextContentInput{Content: text}}},
+	}
+	block := BlockInput{Object: "block", Type: blockType}
+	switch blockType {
+	case "callout":
+		if emoji != "" {
+			content.Icon = &Icon{Type: "emoji", Emoji: emoji}
+		}
+		block.Callout = content
+	default:
+		block.Type = "paragraph"
+		block.Paragraph = content
+	}
+	return block
+}
+
+// AppendBlockChildren appends blocks to the children of a block or page, and returns the created blocks.
+// The integration needs the insert content capability.
+func (c *Client) AppendBlockChildren(ctx context.Context, blockID string, children []BlockInput) ([]Block, error) {
+	path := fmt.Sprintf("/blocks/%s/children", blockID)
+	body := map[string]any{"children": children}
+
+	var result BlockChildrenResponse
+	if err := c.do(ctx, "PATCH", path, body, &result); err != nil {
+		return nil, fmt.Errorf("append block children %s: %w", blockID, err)
+	}
+
+	return result.Results, nil
+}
+
+// DeleteBlock moves a block to the trash. The integration needs the update content capability.
+func (c *Client) DeleteBlock(ctx context.Context, blockID string) error {
+	path := "/blocks/" + blockID
+
+	if err := c.do(ctx, "DELETE", path, nil, nil); err != nil {
+		return fmt.Errorf("delete block %s: %w", blockID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestAppendBlockChildren(t *testing.T) {
+	t.Parallel()
+
+	var body struct {
+		Children []BlockInput `json:"children"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/blocks/page1/children" {
+			t.Errorf("request = %s %s, want PATCH /blocks/page1/children", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		fmt.Fprint(w, `{"object":"list","results":[{"object":"block","id":"block1","type":"callout"}]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	blocks, err := client.AppendBlockChildren(context.Background(), "page1", []BlockInput{
+		NewTextBlock("callout", "Synced", "✅"),
+	})
+	if err != nil {
+		t.Fatalf("AppendBlockChildren() error = %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].ID != "block1" {
+		t.Errorf("AppendBlockChildren() = %+v, want the created block", blocks)
+	}
+
+	if len(body.Children) != 1 {
+		t.Fatalf("children = %+v, want one", body.Children)
+	}
+	callout := body.Children[0].Callout
+	if callout == nil || callout.RichText[0].Text.Content != "Synced" || callout.Icon.Emoji != "✅" {
+		t.Errorf("callout = %+v, want the text and emoji", callout)
+	}
+}
+
+func TestReadOnlyClient(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/data_sources/ds1/query":
+			fmt.Fprint(w, `{"object":"list","results":[]}`)
+		default:
+			fmt.Fprint(w, `{"object":"block","id":"block1","type":"paragraph"}`)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewClient("token", WithBaseURL(server.URL), WithReadOnly())
+
+	_, err := client.AppendBlockChildren(ctx, "page1", []BlockInput{NewTextBlock("paragraph", "x", "")})
+	if !errors.Is(err, apperrors.ErrReadOnly) {
+		t.Errorf("AppendBlockChildren() error = %v, want ErrReadOnly", err)
+	}
+	if err := client.DeleteBlock(ctx, "block1"); !errors.Is(err, apperrors.ErrReadOnly) {
+		t.Errorf("DeleteBlock() error = %v, want ErrReadOnly", err)
+	}
+
+	// Reads still go through, POST queries included
+	if _, err := client.GetBlock(ctx, "block1"); err != nil {
+		t.Errorf("GetBlock() error = %v", err)
+	}
+	if _, err := client.QueryDataSource(ctx, "ds1"); err != nil {
+		t.Errorf("QueryDataSource() error = %v", err)
+	}
+
+	want := []string{"GET /blocks/block1", "POST /data_sources/ds1/query"}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,109 @@
+package notion
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// API call types counted by a CallCounter.
+const (
+	CallPage          = "page"           // Page fetch
+	CallPageProperty  = "page_property"  // Paginated page property fetch
+	CallBlock         = "block"          // Single block fetch
+	CallBlockChildren = "block_children" // Block children fetch, one per page of children
+	CallDatabase      = "database"       // Database or data source fetch
+	CallDatabaseQuery = "database_query" // Data source query, one per page of results
+	CallSearch        = "search"         // Search
+	CallUser          = "user"           // User fetch
+	CallOther         = "other"          // Any other call
+)
+
+// callCounterKey is the context key for storing the API call counter.
+const callCounterKey contextKey = "callCounter"
+
+// CallCounter counts the API requests made with a context, by call type. Retried requests are counted
+// once per attempt, as each attempt is a request to the API. It is safe for concurrent use.
+type CallCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// WithCallCounter returns a new context counting its API requests in counter.
+func WithCallCounter(ctx context.Context, counter *CallCounter) context.Context {
+	return context.WithValue(ctx, callCounterKey, counter)
+}
+
+// callCounterFromContext extracts the API call counter from context, returns nil if not set.
+func callCounterFromContext(ctx context.Context) *CallCounter {
+	if counter, ok := ctx.Value(callCounterKey).(*CallCounter); ok {
+		return counter
+	}
+	return nil
+}
+
+// add counts a request. A nil counter counts nothing.
+func (c *CallCounter) add(callType string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[callType]++
+}
+
+// Counts returns the number of requests by call type.
+func (c *CallCounter) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.counts)
+}
+
+// Total returns the number of requests.
+func (c *CallCounter) Total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, count := range c.counts {
+		total += count
+	}
+	return total
+}
+
+// callType returns the type of an API call from its path.
+func callType(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch segments[0] {
+	case "pages":
+		if len(segments) > 2 && segments[2] == "properties" {
+			return CallPageProperty
+		}
+		return CallPage
+	case "blocks":
+		if len(segments) > 2 && segments[2] == "children" {
+			return CallBlockChildren
+		}
+		return CallBlock
+	case "databases", "data_sources":
+		if len(segments) > 2 && segments[2] == "query" {
+			return CallDatabaseQuery
+		}
+		return CallDatabase
+	case "search":
+		return CallSearch
+	case "users":
+		return CallUser
+	default:
+		return CallOther
+	}
+}
@@ -0,0 +1,72 @@
+package notion
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallType(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"/pages/abc": CallPage,
+		"/pages/abc/properties/title?page_size=100": CallPageProperty,
+		"/blocks/abc":                        CallBlock,
+		"/blocks/abc/children?page_size=100": CallBlockChildren,
+		"/databases/abc":                     CallDatabase,
+		"/data_sources/abc":                  CallDatabase,
+		"/data_sources/abc/query":            CallDatabaseQuery,
+		"/search":                            CallSearch,
+		"/users/me":                          CallUser,
+		"/comments":                          CallOther,
+	}
+	for path, want := range tests {
+		if got := callType(path); got != want {
+			t.Errorf("callType(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestClient_CountsCalls(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/pages/p1":
+			fmt.Fprint(w, `{"object":"page","id":"p1"}`)
+		case "/blocks/p1/children":
+			fmt.Fprint(w, `{"object":"list","results":[],"has_more":false}`)
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	counter := &CallCounter{}
+	ctx := WithCallCounter(t.Context(), counter)
+
+	if _, err := client.GetPage(ctx, "p1"); err != nil {
+		t.Fatalf("GetPage() error = %v", err)
+	}
+	if _, err := client.GetBlockChildren(ctx, "p1", ""); err != nil {
+		t.Fatalf("GetBlockChildren() error = %v", err)
+	}
+	// Calls made without the counter are not counted
+	if _, err := client.GetPage(t.Context(), "p1"); err != nil {
+		t.Fatalf("GetPage() error = %v", err)
+	}
+
+	want := map[string]int{CallPage: 1, CallBlockChildren: 1}
+	if got := counter.Counts(); !maps.Equal(got, want) {
+		t.Errorf("Counts() = %v, want %v", got, want)
+	}
+	if counter.Total() != 2 {
+		t.Errorf("Total() = %d, want 2", counter.Total())
+	}
+}
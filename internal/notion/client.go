@@ -8,6 +8,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -47,7 +50,7 @@ const (
 	// HTTP client configuration.
 	httpTimeout = 30 * time.Second // Timeout for HTTP requests
 
-	// Rate limiting configuration (~3 requests/second).
+	// Rate limiting configuration (~3 requests/second, the average rate allowed by Notion).
 	rateLimitInterval = 350 * time.Millisecond
 
 	// HTTP status codes.
@@ -55,6 +58,7 @@ const (
 )
 
 // Client is a Notion API client with rate limiting.
+// It is safe for concurrent use: a rate limit response pauses the requests of all goroutines.
 type Client struct {
 	httpClient  *http.Client
 	token       string
@@ -62,6 +66,39 @@ type Client struct {
 	baseURL     string
 	apiVersion  string
 	logger      *slog.Logger
+	readOnly    bool // Refuse the requests that write to Notion
+
+	throttleMu  sync.Mutex    // Protects pausedUntil and throttle
+	pausedUntil time.Time     // No request is sent before this time, after a rate limit response
+	throttle    ThrottleStats // Throttling of the requests since the client was created
+}
+
+// ThrottleStats measures how much the requests of a client were throttled, and how fast the API answered them.
+type ThrottleStats struct {
+	Waited      time.Duration // Time requests waited for the rate limiter or after rate limit responses
+	Paused      time.Duration // Part of Waited spent after rate limit responses, as asked by their Retry-After
+	RateLimited int           // Rate limit (429) responses
+	Requests    int           // Requests answered by the API, rate limit responses included
+	Latency     time.Duration // Total time the API took to answer the requests
+}
+
+// Sub returns the throttling since an earlier measure.
+func (s ThrottleStats) Sub(earlier ThrottleStats) ThrottleStats {
+	return ThrottleStats{
+		Waited:      s.Waited - earlier.Waited,
+		Paused:      s.Paused - earlier.Paused,
+		RateLimited: s.RateLimited - earlier.RateLimited,
+		Requests:    s.Requests - earlier.Requests,
+		Latency:     s.Latency - earlier.Latency,
+	}
+}
+
+// AverageLatency returns the average time the API took to answer a request (0 without requests).
+func (s ThrottleStats) AverageLatency() time.Duration {
+	if s.Requests <= 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Requests)
 }
 
 // ClientOption configures the client.
@@ -81,6 +118,16 @@ func WithLogger(l *slog.Logger) ClientOption {
 	}
 }
 
+// WithRateLimit sets the average number of requests per second, shared by all the goroutines using the client
+// (zero or negative keeps the default of ~3 requests per second).
+func WithRateLimit(requestsPerSecond float64) ClientOption {
+	return func(client *Client) {
+		if requestsPerSecond > 0 {
+			client.rateLimiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+		}
+	}
+}
+
 // WithBaseURL sets a custom base URL (useful for testing).
 func WithBaseURL(url string) ClientOption {
 	return func(client *Client) {
@@ -88,6 +135,14 @@ func WithBaseURL(url string) ClientOption {
 	}
 }
 
+// WithReadOnly refuses the requests that write to Notion, before they are sent, so that a misconfigured mirror
+// cannot write with a token that has more rights than it needs.
+func WithReadOnly() ClientOption {
+	return func(client *Client) {
+		client.readOnly = true
+	}
+}
+
 // NewClient creates a new Notion API client.
 func NewClient(token string, opts ...ClientOption) *Client {
 	client := &Client{
@@ -112,6 +167,7 @@ type requestInfo struct {
 	path      string
 	pageID    string
 	startTime time.Time
+	counter   *CallCounter // Counter of the requests made for the current page (nil if not counted)
 }
 
 // logArgs returns base log arguments with optional pageID.
@@ -125,9 +181,18 @@ func (ri *requestInfo) logArgs(extra ...any) []any {
 
 // do performs an HTTP request with rate limiting and retries.
 func (c *Client) do(ctx context.Context, method, path string, body, result any) error {
+	if c.readOnly && isWriteRequest(method, path) {
+		return fmt.Errorf("%s %s: %w", method, path, apperrors.ErrReadOnly)
+	}
+
+	waitStart := time.Now()
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return fmt.Errorf("rate limiter: %w", err)
 	}
+	if err := c.waitPause(ctx); err != nil {
+		return err
+	}
+	c.addThrottle(time.Since(waitStart), 0)
 
 	req, err := c.buildRequest(ctx, method, path, body)
 	if err != nil {
@@ -139,6 +204,7 @@ func (c *Client) do(ctx context.Context, method, path string, body, result any)
 		path:      path,
 		pageID:    PageIDFromContext(ctx),
 		startTime: time.Now(),
+		counter:   callCounterFromContext(ctx),
 	}
 
 	c.logger.DebugContext(ctx, "API request", reqInfo.logArgs()...)
@@ -146,6 +212,19 @@ func (c *Client) do(ctx context.Context, method, path string, body, result any)
 	return c.executeWithRetry(ctx, req, reqInfo, result)
 }
 
+// isWriteRequest returns true if a request changes Notion content. Searches and database queries are POST
+// requests that only read.
+func isWriteRequest(method, path string) bool {
+	switch method {
+	case http.MethodGet:
+		return false
+	case http.MethodPost:
+		return path != "/search" && !strings.HasSuffix(path, "/query")
+	default:
+		return true
+	}
+}
+
 // buildRequest creates an HTTP request with the appropriate headers.
 func (c *Client) buildRequest(ctx context.Context, method, path string, body any) (*http.Request, error) {
 	var bodyReader io.Reader
@@ -188,6 +267,8 @@ func (c *Client) executeWithRetry(ctx context.Context, req *http.Request, reqInf
 func (c *Client) executeRequest(
 	ctx context.Context, req *http.Request, reqInfo *requestInfo, result any, attempt int, backoff *time.Duration,
 ) (bool, error) {
+	reqInfo.counter.add(callType(reqInfo.path))
+	attemptStart := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return true, fmt.Errorf("do request: %w", err)
@@ -197,9 +278,10 @@ func (c *Client) executeRequest(
 	if err != nil {
 		return true, err
 	}
+	c.addLatency(time.Since(attemptStart))
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return c.handleRateLimit(ctx, reqInfo, attempt, backoff)
+		return c.handleRateLimit(ctx, reqInfo, attempt, backoff, retryAfter(resp.Header.Get("Retry-After")))
 	}
 
 	if resp.StatusCode >= httpStatusBadRequest {
@@ -230,27 +312,116 @@ func (c *Client) readAndCloseBody(ctx context.Context, resp *http.Response) ([]b
 	return respBody, nil
 }
 
-// handleRateLimit handles rate limit responses with backoff.
+// handleRateLimit handles rate limit responses with backoff, waiting for the delay of the Retry-After header
+// when the response has one.
 func (c *Client) handleRateLimit(
-	ctx context.Context, reqInfo *requestInfo, attempt int, backoff *time.Duration,
+	ctx context.Context, reqInfo *requestInfo, attempt int, backoff *time.Duration, retryAfter time.Duration,
 ) (bool, error) {
+	wait := *backoff
+	if retryAfter > 0 {
+		wait = retryAfter
+	}
 	c.logger.WarnContext(ctx, "rate limited, backing off",
-		reqInfo.logArgs("attempt", attempt+1, "backoff", *backoff)...)
+		reqInfo.logArgs("attempt", attempt+1, "backoff", wait)...)
 
-	select {
-	case <-ctx.Done():
-		return true, ctx.Err()
-	case <-time.After(*backoff):
-		*backoff *= 2
-		return false, nil
+	waitStart := time.Now()
+	c.pause(wait)
+	err := c.waitPause(ctx)
+	c.addThrottle(time.Since(waitStart), 1)
+	c.addPaused(wait)
+	if err != nil {
+		return true, err
+	}
+	*backoff *= 2
+	return false, nil
+}
+
+// retryAfter parses the Retry-After header of a response: a number of seconds or an HTTP date.
+// Returns 0 if the header is missing or invalid.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// Throttle returns how much the requests of the client were throttled since it was created.
+func (c *Client) Throttle() ThrottleStats {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	return c.throttle
+}
+
+// addThrottle records the time a request waited, and whether it was rate limited.
+func (c *Client) addThrottle(waited time.Duration, rateLimited int) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	c.throttle.Waited += waited
+	c.throttle.RateLimited += rateLimited
+}
+
+// addPaused records the pause asked by a rate limit response.
+func (c *Client) addPaused(d time.Duration) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	c.throttle.Paused += d
+}
+
+// addLatency records the time the API took to answer a request.
+func (c *Client) addLatency(d time.Duration) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	c.throttle.Requests++
+	c.throttle.Latency += d
+}
+
+// pause delays all the requests of the client by d, so that concurrent requests back off together.
+func (c *Client) pause(d time.Duration) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	if until := time.Now().Add(d); until.After(c.pausedUntil) {
+		c.pausedUntil = until
+	}
+}
+
+// waitPause waits until the requests of the client are no longer paused.
+func (c *Client) waitPause(ctx context.Context) error {
+	for {
+		c.throttleMu.Lock()
+		wait := time.Until(c.pausedUntil)
+		c.throttleMu.Unlock()
+		if wait <= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
 	}
 }
 
 // parseErrorResponse parses an API error response.
+// Rejected tokens are reported as apperrors.ErrNotionUnauthorized, as no other request can succeed.
 func (c *Client) parseErrorResponse(respBody []byte, statusCode int) error {
+	var err error
 	var errResp APIError
-	if err := json.Unmarshal(respBody, &errResp); err != nil {
-		return apperrors.NewHTTPError(statusCode, string(respBody))
+	if jsonErr := json.Unmarshal(respBody, &errResp); jsonErr != nil {
+		err = apperrors.NewHTTPError(statusCode, string(respBody))
+	} else {
+		err = &errResp
+	}
+
+	if statusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %w", apperrors.ErrNotionUnauthorized, err)
 	}
-	return &errResp
+	return err
 }
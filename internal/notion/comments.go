@@ -0,0 +1,52 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+)
+
+// Comment is a comment of a page or block discussion.
+type Comment struct {
+	Object       string `json:"object"`
+	ID           string `json:"id"`
+	DiscussionID string `json:"discussion_id"`
+}
+
+// CommentListResponse represents the response from the comments endpoint.
+type CommentListResponse struct {
+	Object     string    `json:"object"`
+	Results    []Comment `json:"results"`
+	NextCursor *string   `json:"next_cursor"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// CountOpenComments returns the number of unresolved comments of a page or block. The API only lists unresolved
+// comments, and requires the "Read comments" capability of the integration.
+func (c *Client) CountOpenComments(ctx context.Context, blockID string) (int, error) {
+	c.logger.DebugContext(ctx, "Counting open comments", slog.String("blockId", blockID))
+
+	count := 0
+	var cursor string
+
+	for {
+		path := fmt.Sprintf("/comments?block_id=%s&page_size=%d", url.QueryEscape(blockID), defaultPageSize)
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+
+		var result CommentListResponse
+		if err := c.do(ctx, "GET", path, nil, &result); err != nil {
+			return 0, fmt.Errorf("list comments of %s: %w", blockID, err)
+		}
+		count += len(result.Results)
+
+		if !result.HasMore || result.NextCursor == nil {
+			break
+		}
+		cursor = *result.NextCursor
+	}
+
+	return count, nil
+}
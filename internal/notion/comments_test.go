@@ -0,0 +1,41 @@
+package notion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCountOpenComments(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/comments" || r.URL.Query().Get("block_id") != "p1" {
+			t.Errorf("unexpected request: %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("start_cursor") == "" {
+			fmt.Fprint(w, `{"object":"list","results":[
+				{"object":"comment","id":"c1","discussion_id":"d1"},
+				{"object":"comment","id":"c2","discussion_id":"d1"}
+			],"has_more":true,"next_cursor":"next"}`)
+			return
+		}
+		fmt.Fprint(w, `{"object":"list","results":[
+			{"object":"comment","id":"c3","discussion_id":"d2"}
+		],"has_more":false}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	count, err := client.CountOpenComments(t.Context(), "p1")
+	if err != nil {
+		t.Fatalf("CountOpenComments() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("CountOpenComments() = %d, want 3", count)
+	}
+}
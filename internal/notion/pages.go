@@ -16,6 +16,10 @@ const (
 	// API pagination settings.
 	defaultPageSize = 100 // Default number of results per page
 
+	// maxInlineTitleItems is the number of rich text items returned inline for a title property.
+	// Longer titles are truncated and must be read from the page property endpoint.
+	maxInlineTitleItems = 25
+
 	// Notion ID format constants.
 	notionIDLength         = 32 // Length of a Notion ID without dashes
 	notionIDWithDashLength = 36 // Length of a Notion ID with dashes (UUID format: 8-4-4-4-12)
@@ -109,6 +113,8 @@ func (c *Client) GetDatabase(ctx context.Context, databaseID string) (*Database,
 		IsInline:       container.IsInline,
 		DataSourceID:   dataSource.ID,
 		DataSources:    container.DataSources,
+
+		SchemaEditedTime: dataSource.LastEditedTime,
 	}, nil
 }
 
@@ -167,6 +173,130 @@ func (c *Client) QueryDatabase(ctx context.Context, databaseID string) ([]Databa
 	return c.QueryDataSource(ctx, container.DataSources[0].ID)
 }
 
+// QueryDatabasePages queries the pages of an already fetched database.
+// It reuses the database's data source and schema instead of resolving them again,
+// and uses the schema's title property to extract page titles, fetching the full
+// title of pages whose inline title was truncated.
+func (c *Client) QueryDatabasePages(ctx context.Context, database *Database) ([]DatabasePage, error) {
+	if database.DataSourceID == "" {
+		return nil, fmt.Errorf("database %s: %w", database.ID, apperrors.ErrNoDataSources)
+	}
+
+	pages, err := c.QueryDataSource(ctx, database.DataSourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.completeTitles(ctx, database, pages); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// QueryDatabaseTopPages returns the first pages of a database in its default order, with at most limit pages.
+// hasMore is true if the database has more pages.
+func (c *Client) QueryDatabaseTopPages(
+	ctx context.Context, database *Database, limit int,
+) (pages []DatabasePage, hasMore bool, err error) {
+	if database.DataSourceID == "" {
+		return nil, false, fmt.Errorf("database %s: %w", database.ID, apperrors.ErrNoDataSources)
+	}
+
+	var result QueryDatabaseResponse
+	path := fmt.Sprintf("/data_sources/%s/query", database.DataSourceID)
+	body := map[string]any{"page_size": min(max(limit, 1), defaultPageSize)}
+	if err := c.do(ctx, "POST", path, body, &result); err != nil {
+		return nil, false, fmt.Errorf("query data source %s: %w", database.DataSourceID, err)
+	}
+
+	pages = result.Results
+	if err := c.completeTitles(ctx, database, pages); err != nil {
+		return nil, false, err
+	}
+
+	return pages, result.HasMore, nil
+}
+
+// completeTitles sets the title property of database pages and completes their truncated titles.
+func (c *Client) completeTitles(ctx context.Context, database *Database, pages []DatabasePage) error {
+	titleName, titleID := database.TitleProperty()
+	if titleName == "" {
+		return nil
+	}
+
+	for i := range pages {
+		page := &pages[i]
+		page.TitleProperty = titleName
+		if err := c.completeTitle(ctx, page, titleID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeTitle replaces a truncated inline title with the full title from the page property endpoint.
+func (c *Client) completeTitle(ctx context.Context, page *DatabasePage, titleID string) error {
+	var prop struct {
+		ID    string     `json:"id"`
+		Type  string     `json:"type"`
+		Title []RichText `json:"title"`
+	}
+	if json.Unmarshal(page.Properties[page.TitleProperty], &prop) != nil || len(prop.Title) < maxInlineTitleItems {
+		return nil // Unparsable titles are handled by Title()'s fallback
+	}
+
+	if prop.ID != "" {
+		titleID = prop.ID
+	}
+	title, err := c.GetPageTitleProperty(ctx, page.ID, titleID)
+	if err != nil {
+		return fmt.Errorf("get full title of page %s: %w", page.ID, err)
+	}
+
+	prop.Title = title
+	raw, err := json.Marshal(prop)
+	if err != nil {
+		return fmt.Errorf("encode title of page %s: %w", page.ID, err)
+	}
+	page.Properties[page.TitleProperty] = raw
+	return nil
+}
+
+// GetPageTitleProperty retrieves all the rich text items of a page's title property.
+func (c *Client) GetPageTitleProperty(ctx context.Context, pageID, propertyID string) ([]RichText, error) {
+	c.logger.DebugContext(ctx, "Fetching page title property", slog.String("pageId", pageID))
+
+	var title []RichText
+	var cursor string
+
+	for {
+		path := fmt.Sprintf("/pages/%s/properties/%s?page_size=%d",
+			pageID, url.PathEscape(propertyID), defaultPageSize)
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+
+		var result PropertyItemList
+		if err := c.do(ctx, "GET", path, nil, &result); err != nil {
+			return nil, fmt.Errorf("get property %s of page %s: %w", propertyID, pageID, err)
+		}
+
+		for i := range result.Results {
+			if result.Results[i].Title != nil {
+				title = append(title, *result.Results[i].Title)
+			}
+		}
+
+		if !result.HasMore || result.NextCursor == nil {
+			break
+		}
+		cursor = *result.NextCursor
+	}
+
+	return title, nil
+}
+
 // SearchFilter configures the search query.
 type SearchFilter struct {
 	Query       string
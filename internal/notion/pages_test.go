@@ -0,0 +1,240 @@
+package notion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestDatabaseTitleProperty(t *testing.T) {
+	t.Parallel()
+
+	db := &Database{
+		Properties: map[string]any{
+			"Status":    map[string]any{"id": "abc", "type": "status"},
+			"Task name": map[string]any{"id": "title", "type": "title"},
+		},
+	}
+
+	name, id := db.TitleProperty()
+	if name != "Task name" || id != "title" {
+		t.Errorf("TitleProperty() = (%q, %q), want (%q, %q)", name, id, "Task name", "title")
+	}
+
+	if name, _ := (&Database{}).TitleProperty(); name != "" {
+		t.Errorf("TitleProperty() without schema = %q, want empty", name)
+	}
+}
+
+func TestDatabasePageTitle_UsesSchemaProperty(t *testing.T) {
+	t.Parallel()
+
+	page := &DatabasePage{
+		Properties: map[string]json.RawMessage{
+			"Task name": json.RawMessage(`{"type":"title","title":[{"type":"text","plain_text":"Real title"}]}`),
+			"Notes":     json.RawMessage(`{"type":"rich_text","rich_text":[{"type":"text","plain_text":"x"}]}`),
+		},
+		TitleProperty: "Task name",
+	}
+	if got := page.Title(); got != "Real title" {
+		t.Errorf("Title() = %q, want %q", got, "Real title")
+	}
+
+	// A stale schema property name falls back to scanning properties
+	page.TitleProperty = "Name"
+	if got := page.Title(); got != "Real title" {
+		t.Errorf("Title() with unknown title property = %q, want %q", got, "Real title")
+	}
+}
+
+func TestQueryDatabasePages_CompletesTruncatedTitle(t *testing.T) {
+	t.Parallel()
+
+	inline := make([]map[string]any, maxInlineTitleItems)
+	for i := range inline {
+		inline[i] = map[string]any{"type": "text", "plain_text": "a"}
+	}
+	inlineTitle, err := json.Marshal(map[string]any{"id": "title", "type": "title", "title": inline})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/data_sources/ds1/query":
+			fmt.Fprintf(w, `{"object":"list","results":[
+				{"object":"page","id":"p1","properties":{"Task name":%s}},
+				{"object":"page","id":"p2","properties":{"Task name":
+					{"id":"title","type":"title","title":[{"type":"text","plain_text":"Short"}]}}}
+			],"has_more":false}`, inlineTitle)
+		case r.URL.Path == "/pages/p1/properties/title" && r.URL.Query().Get("start_cursor") == "":
+			fmt.Fprint(w, `{"object":"list","results":[
+				{"object":"property_item","type":"title","title":{"type":"text","plain_text":"Full "}}
+			],"has_more":true,"next_cursor":"c2"}`)
+		case r.URL.Path == "/pages/p1/properties/title":
+			fmt.Fprint(w, `{"object":"list","results":[
+				{"object":"property_item","type":"title","title":{"type":"text","plain_text":"title"}}
+			],"has_more":false}`)
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	db := &Database{
+		ID:           "db1",
+		DataSourceID: "ds1",
+		Properties:   map[string]any{"Task name": map[string]any{"id": "title", "type": "title"}},
+	}
+
+	pages, err := client.QueryDatabasePages(t.Context(), db)
+	if err != nil {
+		t.Fatalf("QueryDatabasePages() error = %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("QueryDatabasePages() returned %d pages, want 2", len(pages))
+	}
+	if got := pages[0].Title(); got != "Full title" {
+		t.Errorf("truncated title = %q, want %q", got, "Full title")
+	}
+	if got := pages[1].Title(); got != "Short" {
+		t.Errorf("short title = %q, want %q", got, "Short")
+	}
+}
+
+func TestQueryDatabaseTopPages_LimitsPageSize(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			PageSize int `json:"page_size"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.PageSize != 2 {
+			t.Errorf("page_size = %d (err=%v), want 2", body.PageSize, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","results":[
+			{"object":"page","id":"p1","properties":{"Name":
+				{"id":"title","type":"title","title":[{"type":"text","plain_text":"First"}]}}},
+			{"object":"page","id":"p2","properties":{}}
+		],"has_more":true,"next_cursor":"c2"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	db := &Database{
+		ID:           "db1",
+		DataSourceID: "ds1",
+		Properties:   map[string]any{"Name": map[string]any{"id": "title", "type": "title"}},
+	}
+
+	pages, hasMore, err := client.QueryDatabaseTopPages(t.Context(), db, 2)
+	if err != nil {
+		t.Fatalf("QueryDatabaseTopPages() error = %v", err)
+	}
+	if len(pages) != 2 || !hasMore {
+		t.Fatalf("QueryDatabaseTopPages() = %d pages (hasMore=%v), want 2 pages with more", len(pages), hasMore)
+	}
+	if got := pages[0].Title(); got != "First" {
+		t.Errorf("title = %q, want %q", got, "First")
+	}
+}
+
+func TestGetMe_RejectedToken(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"object":"error","status":401,"code":"unauthorized","message":"API token is invalid."}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	_, err := client.GetMe(t.Context())
+	if !errors.Is(err, apperrors.ErrNotionUnauthorized) {
+		t.Fatalf("GetMe() error = %v, want ErrNotionUnauthorized", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "unauthorized" {
+		t.Errorf("GetMe() error = %v, want the API error to be kept", err)
+	}
+}
+
+func TestClient_RateLimitPausesAllRequests(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var arrivals []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		first := len(arrivals) == 1
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if first {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"object":"error","status":429,"code":"rate_limited","message":"Rate limited"}`)
+			return
+		}
+		fmt.Fprint(w, `{"object":"user","id":"me","type":"bot"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient("token", WithBaseURL(server.URL))
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			if _, err := client.GetMe(t.Context()); err != nil {
+				t.Errorf("GetMe() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+
+	// The request that was not rate limited waits for the backoff of the other one
+	mu.Lock()
+	defer mu.Unlock()
+	if len(arrivals) != 3 {
+		t.Fatalf("requests = %d, want 3", len(arrivals))
+	}
+	if wait := arrivals[1].Sub(arrivals[0]); wait < 900*time.Millisecond {
+		t.Errorf("second request sent %v after the rate limited one, want it to back off", wait)
+	}
+	if throttle := client.Throttle(); throttle.RateLimited != 1 || throttle.Waited < 900*time.Millisecond {
+		t.Errorf("Throttle() = %+v, want 1 rate limited request and the backoff waited", throttle)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"invalid":                       0,
+		"Wed, 21 Oct 2015 07:28:00 GMT": 0, // In the past
+	}
+	for header, want := range tests {
+		if got := retryAfter(header); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", header, got, want)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(future); got < 50*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %v, want about a minute", future, got)
+	}
+}
@@ -0,0 +1,271 @@
+package notion
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Cassettes hold the request/response pairs of a sync, one JSON file per request, so that a user can submit the
+// API responses a bug was seen with and a maintainer can replay them offline, without access to the workspace.
+// Only the method, path, body and response are recorded: the Authorization header (the token) never is.
+
+const (
+	cassetteExt       = ".json"
+	cassetteNameLimit = 80 // Maximum length of the readable part of a cassette file name
+)
+
+// cassetteNameCleaner matches the characters of a request path that are replaced in cassette file names.
+var cassetteNameCleaner = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// interaction is a recorded request and its response.
+type interaction struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"` // Path and query, relative to the host
+	RequestBody json.RawMessage `json:"request_body,omitempty"`
+	Status      int             `json:"status"`
+	RetryAfter  string          `json:"retry_after,omitempty"`
+	Response    json.RawMessage `json:"response,omitempty"`
+}
+
+// key returns the key matching a replayed request with its recorded interaction.
+func (i *interaction) key() string {
+	return interactionKey(i.Method, i.Path, i.RequestBody)
+}
+
+// interactionKey returns the key of a request: its method, path and a hash of its body, compacted as the
+// cassette files indent it.
+func interactionKey(method, path string, body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return method + " " + path
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	sum := sha256.Sum256(body)
+	return method + " " + path + " " + hex.EncodeToString(sum[:8])
+}
+
+// rawJSON returns a body as raw JSON, or as a JSON string when it is not valid JSON.
+func rawJSON(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// readRequestBody reads the body of a request and restores it, so that it can still be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if closeErr := req.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// recorder is an http.RoundTripper recording the requests it sends, and their responses, in a cassette directory.
+type recorder struct {
+	next http.RoundTripper
+	dir  string
+
+	mu    sync.Mutex
+	count int // Recorded interactions, numbering the cassette files in the order of the responses
+}
+
+// RoundTrip sends a request and records it with its response.
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if closeErr := resp.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := r.save(&interaction{
+		Method:      req.Method,
+		Path:        req.URL.RequestURI(),
+		RequestBody: rawJSON(reqBody),
+		Status:      resp.StatusCode,
+		RetryAfter:  resp.Header.Get("Retry-After"),
+		Response:    rawJSON(respBody),
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes an interaction to the next cassette file.
+func (r *recorder) save(record *interaction) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal cassette: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == 0 {
+		if err := os.MkdirAll(r.dir, 0o750); err != nil {
+			return fmt.Errorf("create cassette dir: %w", err)
+		}
+	}
+	r.count++
+	name := strings.Trim(cassetteNameCleaner.ReplaceAllString(record.Path, "-"), "-")
+	if len(name) > cassetteNameLimit {
+		name = name[:cassetteNameLimit]
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("%05d-%s-%s%s", r.count, record.Method, name, cassetteExt))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// replayer is an http.RoundTripper answering requests with the responses recorded in a cassette directory, without
+// any network access. Identical requests get their recorded responses in order, the last one being repeated.
+// Requests that were not recorded get a 404 response.
+type replayer struct {
+	dir string
+
+	loadOnce     sync.Once
+	loadErr      error
+	mu           sync.Mutex
+	interactions map[string][]*interaction // Recorded interactions not replayed yet, by key
+	last         map[string]*interaction   // Last replayed interaction, by key
+}
+
+// load reads the cassette files, in the order they were recorded.
+func (r *replayer) load() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("read cassette dir: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	r.interactions = make(map[string][]*interaction)
+	r.last = make(map[string]*interaction)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != cassetteExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("read cassette: %w", err)
+		}
+		var record interaction
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("parse cassette %s: %w", entry.Name(), err)
+		}
+		key := record.key()
+		r.interactions[key] = append(r.interactions[key], &record)
+	}
+	return nil
+}
+
+// next returns the recorded interaction answering a request, nil if it was not recorded.
+func (r *replayer) next(key string) *interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if queue := r.interactions[key]; len(queue) > 0 {
+		r.interactions[key] = queue[1:]
+		r.last[key] = queue[0]
+	}
+	return r.last[key]
+}
+
+// RoundTrip answers a request with its recorded response.
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.loadOnce.Do(func() { r.loadErr = r.load() })
+	if r.loadErr != nil {
+		return nil, r.loadErr
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	record := r.next(interactionKey(req.Method, req.URL.RequestURI(), rawJSON(body)))
+	if record == nil {
+		record = &interaction{
+			Status: http.StatusNotFound,
+			Response: json.RawMessage(fmt.Sprintf(
+				`{"object":"error","status":404,"code":"object_not_found","message":%q}`,
+				"request not recorded in the cassette: "+req.Method+" "+req.URL.RequestURI())),
+		}
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	if record.RetryAfter != "" {
+		header.Set("Retry-After", record.RetryAfter)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status)),
+		StatusCode:    record.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(record.Response)),
+		ContentLength: int64(len(record.Response)),
+		Request:       req,
+	}, nil
+}
+
+// WithRecording records the requests sent to Notion and their responses as JSON files in a cassette directory,
+// which WithReplay can replay offline.
+func WithRecording(dir string) ClientOption {
+	return func(client *Client) {
+		next := client.httpClient.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		client.httpClient = &http.Client{
+			Timeout:   client.httpClient.Timeout,
+			Transport: &recorder{next: next, dir: dir},
+		}
+	}
+}
+
+// WithReplay answers the requests with the responses recorded in a cassette directory by WithRecording, without
+// network access nor rate limiting.
+func WithReplay(dir string) ClientOption {
+	return func(client *Client) {
+		client.httpClient = &http.Client{Transport: &replayer{dir: dir}}
+		client.rateLimiter = rate.NewLimiter(rate.Inf, 1)
+	}
+}
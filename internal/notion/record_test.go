@@ -0,0 +1,72 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordingReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/pages/page1":
+			_, _ = w.Write([]byte(`{"object":"page","id":"page1","url":"https://www.notion.so/page1"}`))
+		case "/search":
+			_, _ = w.Write([]byte(`{"object":"list","results":[{"object":"page","id":"page2"}],"has_more":false}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	recording := NewClient("secret-token", WithBaseURL(server.URL), WithRecording(dir))
+	if _, err := recording.GetPage(ctx, "page1"); err != nil {
+		t.Fatalf("GetPage() while recording error = %v", err)
+	}
+	if _, err := recording.Search(ctx, SearchFilter{FilterType: "page"}); err != nil {
+		t.Fatalf("Search() while recording error = %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("cassette files = %v, %v; want 2", files, err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if strings.Contains(string(data), "secret-token") {
+			t.Errorf("cassette %s contains the token", file.Name())
+		}
+	}
+
+	// Replayed offline: the server is gone, and no token is needed
+	server.Close()
+	replay := NewClient("", WithBaseURL(server.URL), WithReplay(dir))
+	page, err := replay.GetPage(ctx, "page1")
+	if err != nil || page.ID != "page1" {
+		t.Fatalf("replayed GetPage() = %+v, %v; want page1", page, err)
+	}
+	result, err := replay.Search(ctx, SearchFilter{FilterType: "page"})
+	if err != nil || len(result.Results) != 1 {
+		t.Fatalf("replayed Search() = %+v, %v; want one result", result, err)
+	}
+
+	// A request that was not recorded is not found
+	_, err = replay.GetPage(ctx, "page3")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("replayed GetPage() of an unrecorded page error = %v, want a 404 API error", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -55,6 +56,9 @@ type Database struct {
 	DataSourceID string `json:"data_source_id,omitempty"`
 	// DataSources contains all data sources in this database (API 2025-09-03+).
 	DataSources []DataSourceInfo `json:"data_sources,omitempty"`
+	// SchemaEditedTime is the last edit time of the primary data source, which changes with its schema
+	// (e.g., a new property or a renamed select option) but not with its rows (API 2025-09-03+).
+	SchemaEditedTime time.Time `json:"schema_edited_time,omitzero"`
 }
 
 // DataSourceInfo represents basic info about a data source in a database container.
@@ -108,6 +112,20 @@ func (d *Database) GetTitle() string {
 	return ParseRichText(d.Title)
 }
 
+// TitleProperty returns the name and ID of the title property from the data source schema.
+// Every data source has exactly one title property, whatever it was renamed to.
+func (d *Database) TitleProperty() (name, id string) {
+	for propName, raw := range d.Properties {
+		schema, ok := raw.(map[string]any)
+		if !ok || schema["type"] != propTypeTitle {
+			continue
+		}
+		propID, _ := schema["id"].(string)
+		return propName, propID
+	}
+	return "", ""
+}
+
 // QueryDatabaseResponse represents the response from querying a database.
 type QueryDatabaseResponse struct {
 	Object     string         `json:"object"`
@@ -117,6 +135,22 @@ type QueryDatabaseResponse struct {
 	Type       string         `json:"type"`
 }
 
+// PropertyItemList represents a paginated page property value (GET /pages/{id}/properties/{property_id}).
+// Title properties are returned as one property item per rich text item.
+type PropertyItemList struct {
+	Object     string         `json:"object"`
+	Results    []PropertyItem `json:"results"`
+	NextCursor *string        `json:"next_cursor"`
+	HasMore    bool           `json:"has_more"`
+}
+
+// PropertyItem represents a single item of a paginated page property value.
+type PropertyItem struct {
+	Object string    `json:"object"`
+	Type   string    `json:"type"`
+	Title  *RichText `json:"title,omitempty"`
+}
+
 // DatabasePage represents a page returned from a database query.
 // It has a simpler structure than Page to handle the complex property types.
 type DatabasePage struct {
@@ -134,10 +168,20 @@ type DatabasePage struct {
 	Properties     map[string]json.RawMessage `json:"properties"`
 	URL            string                     `json:"url"`
 	PublicURL      *string                    `json:"public_url"`
+
+	// TitleProperty is the name of the title property, from the data source schema.
+	// Set by QueryDatabasePages; when empty, Title() looks for any title property.
+	TitleProperty string `json:"-"`
 }
 
 // Title extracts the title from database page properties.
 func (p *DatabasePage) Title() string {
+	if p.TitleProperty != "" {
+		if title, ok := p.titleFromProperty(p.TitleProperty); ok {
+			return title
+		}
+	}
+
 	// Try to find a title property
 	for _, propData := range p.Properties {
 		var prop struct {
@@ -154,6 +198,22 @@ func (p *DatabasePage) Title() string {
 	return "Untitled"
 }
 
+// titleFromProperty returns the title held by the named property, if it is a non-empty title.
+func (p *DatabasePage) titleFromProperty(name string) (string, bool) {
+	propData, ok := p.Properties[name]
+	if !ok {
+		return "", false
+	}
+	var prop struct {
+		Type  string     `json:"type"`
+		Title []RichText `json:"title,omitempty"`
+	}
+	if err := json.Unmarshal(propData, &prop); err != nil || prop.Type != propTypeTitle || len(prop.Title) == 0 {
+		return "", false
+	}
+	return ParseRichText(prop.Title), true
+}
+
 // ToPage converts a DatabasePage to a regular Page.
 func (p *DatabasePage) ToPage() *Page {
 	return &Page{
@@ -452,6 +512,7 @@ type Block struct {
 	Divider          *DividerBlock         `json:"divider,omitempty"`
 	Image            *FileBlock            `json:"image,omitempty"`
 	Video            *FileBlock            `json:"video,omitempty"`
+	Audio            *FileBlock            `json:"audio,omitempty"`
 	File             *FileBlock            `json:"file,omitempty"`
 	PDF              *FileBlock            `json:"pdf,omitempty"`
 	Bookmark         *BookmarkBlock        `json:"bookmark,omitempty"`
@@ -466,9 +527,24 @@ type Block struct {
 	Column           *ColumnBlock          `json:"column,omitempty"`
 	LinkToPage       *LinkToPageBlock      `json:"link_to_page,omitempty"`
 	Embed            *EmbedBlock           `json:"embed,omitempty"`
+	Breadcrumb       *BreadcrumbBlock      `json:"breadcrumb,omitempty"`
+	Template         *TemplateBlock        `json:"template,omitempty"`
 
 	// Children holds nested blocks (populated by recursive fetch)
 	Children []Block `json:"-"`
+
+	// Raw is the JSON object the block was decoded from, for converter plugins
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes a block, keeping its JSON object in Raw.
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type blockFields Block
+	if err := json.Unmarshal(data, (*blockFields)(b)); err != nil {
+		return err
+	}
+	b.Raw = slices.Clone(data)
+	return nil
 }
 
 // ParagraphBlock contains paragraph content.
@@ -526,7 +602,7 @@ type CalloutBlock struct {
 // DividerBlock is an empty struct for dividers.
 type DividerBlock struct{}
 
-// FileBlock contains file/image/video content.
+// FileBlock contains file/image/video/audio content.
 type FileBlock struct {
 	Type     string        `json:"type"`
 	Caption  []RichText    `json:"caption"`
@@ -613,6 +689,14 @@ type EmbedBlock struct {
 	URL string `json:"url"`
 }
 
+// BreadcrumbBlock is a breadcrumb block (no content, it shows the path of the page).
+type BreadcrumbBlock struct{}
+
+// TemplateBlock is a template button, whose children are the blocks it duplicates.
+type TemplateBlock struct {
+	RichText []RichText `json:"rich_text"`
+}
+
 // Icon represents an emoji or external icon.
 type Icon struct {
 	Type     string        `json:"type"`
@@ -688,6 +772,12 @@ func ParseRichText(richText []RichText) string {
 
 // ParseRichTextToMarkdown converts rich text array to markdown string.
 func ParseRichTextToMarkdown(richText []RichText) string {
+	return RichTextToMarkdown(richText, nil)
+}
+
+// RichTextToMarkdown converts rich text array to markdown string, passing the text outside of inline code through
+// escape (when not nil) before formatting it.
+func RichTextToMarkdown(richText []RichText, escape func(string) string) string {
 	var builder strings.Builder
 	for i := range richText {
 		item := &richText[i]
@@ -697,6 +787,9 @@ func ParseRichTextToMarkdown(richText []RichText) string {
 		if item.Type == richTextTypeMention && item.Mention != nil && item.Mention.User != nil {
 			text = "@" + item.Mention.User.Format()
 		}
+		if escape != nil && (item.Annotations == nil || !item.Annotations.Code) {
+			text = escape(text)
+		}
 
 		if item.Annotations != nil {
 			if item.Annotations.Code {
@@ -2,6 +2,7 @@
 package queue
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -13,87 +14,189 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
 	"github.com/fclairamb/ntnsync/internal/store"
 )
 
 const (
-	queueDir           = ".notion-sync/queue"
-	queueFileFormat    = "%08d.json" // 00000001.json, 00000002.json, etc.
-	maxItemsPerQueue   = 10          // Maximum page IDs per queue file
-	webhookIDThreshold = 1000        // IDs below this are for webhook events (high priority)
+	queueDir        = ".notion-sync/queue"
+	queueFileFormat = "%08d.json" // 00000001.json, 00000002.json, etc.
+	maxQueueNumber  = 99999999    // Largest queue file number that keeps files sorted by name
+
+	// DefaultBatchSize is the default maximum number of pages per queue file.
+	DefaultBatchSize = 10
+	// DefaultWebhookThreshold is the default first number of regular queue files. Lower numbers are for
+	// webhook events (high priority).
+	DefaultWebhookThreshold = 1000
+
+	// EntryVersion is the format version of the queue entries written. Version 2 lists pages in Pages;
+	// entries without a version may use the legacy PageIDs list, which is converted when they are read.
+	EntryVersion = 2
+)
+
+// Limits are the tunable sizes of the queue.
+type Limits struct {
+	BatchSize        int `json:"batch_size"`        // Maximum number of pages per queue file
+	WebhookThreshold int `json:"webhook_threshold"` // Queue file numbers below this are for webhook events (high priority)
+	// WebhookWindow is the time during which webhook events of a folder are added to the same queue file
+	// (0 = one queue file per event).
+	WebhookWindow time.Duration `json:"webhook_window,omitempty"`
+	// WebhookMaxPages is the maximum number of pages of a coalesced webhook queue file (0 = BatchSize)
+	WebhookMaxPages int `json:"webhook_max_pages,omitempty"`
+}
+
+// DefaultLimits returns the default queue limits.
+func DefaultLimits() Limits {
+	return Limits{BatchSize: DefaultBatchSize, WebhookThreshold: DefaultWebhookThreshold}
+}
+
+// Validate checks that the limits are usable: a positive batch size, and a webhook threshold leaving
+// room for webhook entries below it and for regular entries above it.
+func (l Limits) Validate() error {
+	if l.BatchSize < 1 {
+		return fmt.Errorf("%w: batch size %d must be positive", apperrors.ErrInvalidQueueLimits, l.BatchSize)
+	}
+	if l.WebhookThreshold < 2 || l.WebhookThreshold >= maxQueueNumber {
+		return fmt.Errorf("%w: webhook threshold %d must be between 2 and %d",
+			apperrors.ErrInvalidQueueLimits, l.WebhookThreshold, maxQueueNumber-1)
+	}
+	if l.WebhookWindow < 0 || l.WebhookMaxPages < 0 {
+		return fmt.Errorf("%w: webhook window %s and max pages %d must not be negative",
+			apperrors.ErrInvalidQueueLimits, l.WebhookWindow, l.WebhookMaxPages)
+	}
+	return nil
+}
+
+// Queue entry types.
+const (
+	// TypeUpdate is the type of entries forcing the sync of their pages.
+	TypeUpdate = "update"
+	// TypeProperties is the type of entries refreshing only the frontmatter of already synced pages,
+	// from their metadata and without downloading their blocks.
+	TypeProperties = "properties"
 )
 
 // Page represents a page in the queue with its last edited time.
+// Fields are ordered by JSON key, like the ones of Entry.
 type Page struct {
-	ID         string    `json:"id"`          // Page ID
-	LastEdited time.Time `json:"last_edited"` // Last edited time from Notion
+	Failures      int       `json:"failures,omitempty"`       // Consecutive failed attempts to sync the page
+	ID            string    `json:"id"`                       // Page ID
+	LastEdited    time.Time `json:"last_edited,omitzero"`     // Last edited time from Notion (zero = unknown)
+	NotBefore     time.Time `json:"not_before,omitzero"`      // Not retried before, after failures (zero = now)
+	UpdatedBlocks []string  `json:"updated_blocks,omitempty"` // Blocks changed by a webhook event (empty = unknown)
 }
 
 // Entry represents a single queue file's content.
+// Fields are ordered by JSON key so that queue files are written with sorted keys.
 type Entry struct {
-	Type      string    `json:"type"`               // "init" or "update"
+	CreatedAt time.Time `json:"createdAt"`          // When this queue entry was created
 	Folder    string    `json:"folder"`             // Folder name
-	Pages     []Page    `json:"pages,omitempty"`    // Pages to process (new format)
-	PageIDs   []string  `json:"pageIds,omitempty"`  // Page IDs to process (legacy format, deprecated)
+	PageIDs   []string  `json:"pageIds,omitempty"`  // Deprecated: legacy list of pages, converted to Pages when read
+	Pages     []Page    `json:"pages,omitempty"`    // Pages to process
 	ParentID  string    `json:"parentId,omitempty"` // Parent page ID (for child pages)
-	CreatedAt time.Time `json:"createdAt"`          // When this queue entry was created
+	Type      string    `json:"type"`               // "init", "update" or "properties"
+	Version   int       `json:"version,omitempty"`  // Format version (EntryVersion; 0 = before versioning)
+}
+
+// upgrade converts the entry to the current format: pages of the legacy PageIDs list are moved to Pages,
+// with an unknown last edited time (the page itself is fetched when it is processed anyway).
+// Returns true if the entry changed.
+func (qe *Entry) upgrade() bool {
+	if qe.Version >= EntryVersion && len(qe.PageIDs) == 0 {
+		return false
+	}
+	for _, id := range qe.PageIDs {
+		qe.Pages = append(qe.Pages, Page{ID: id})
+	}
+	qe.PageIDs = nil
+	qe.Version = EntryVersion
+	return true
 }
 
-// GetPageIDs returns all page IDs from the entry, supporting both old and new formats.
+// marshalEntry encodes an entry in a canonical form, so that queue files committed to git produce
+// reviewable diffs: sorted keys, UTC timestamps with second precision and pages sorted by ID.
+func marshalEntry(entry *Entry) ([]byte, error) {
+	canonical := *entry
+	canonical.Version = EntryVersion
+	canonical.CreatedAt = canonical.CreatedAt.UTC().Truncate(time.Second)
+	canonical.PageIDs = slices.Clone(entry.PageIDs)
+	slices.Sort(canonical.PageIDs)
+	canonical.Pages = slices.Clone(entry.Pages)
+	for i := range canonical.Pages {
+		canonical.Pages[i].LastEdited = canonical.Pages[i].LastEdited.UTC().Truncate(time.Second)
+		canonical.Pages[i].NotBefore = canonical.Pages[i].NotBefore.UTC().Truncate(time.Second)
+		canonical.Pages[i].UpdatedBlocks = slices.Clone(canonical.Pages[i].UpdatedBlocks)
+		slices.Sort(canonical.Pages[i].UpdatedBlocks)
+	}
+	slices.SortStableFunc(canonical.Pages, func(a, b Page) int { return strings.Compare(a.ID, b.ID) })
+
+	data, err := json.MarshalIndent(&canonical, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal entry: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// GetPageIDs returns all page IDs from the entry, including the ones of the legacy list.
 func (qe *Entry) GetPageIDs() []string {
-	if len(qe.Pages) > 0 {
-		ids := make([]string, len(qe.Pages))
-		for i := range qe.Pages {
-			ids[i] = qe.Pages[i].ID
-		}
-		return ids
+	ids := make([]string, 0, qe.GetPageCount())
+	for i := range qe.Pages {
+		ids = append(ids, qe.Pages[i].ID)
 	}
-	return qe.PageIDs
+	return append(ids, qe.PageIDs...)
 }
 
-// GetPageCount returns the number of pages in the entry.
+// GetPageCount returns the number of pages in the entry, including the ones of the legacy list.
 func (qe *Entry) GetPageCount() int {
-	if len(qe.Pages) > 0 {
-		return len(qe.Pages)
-	}
-	return len(qe.PageIDs)
+	return len(qe.Pages) + len(qe.PageIDs)
 }
 
 // Manager handles queue file operations.
 type Manager struct {
 	store  store.Store
 	tx     store.Transaction
+	limits Limits
 	Logger *slog.Logger
 }
 
-// NewManager creates a queue manager.
+// NewManager creates a queue manager with the default limits.
 func NewManager(st store.Store, logger *slog.Logger) *Manager {
 	return &Manager{
 		store:  st,
+		limits: DefaultLimits(),
 		Logger: logger,
 	}
 }
 
+// SetLimits sets the queue limits. Invalid limits are rejected and the current ones are kept.
+func (qm *Manager) SetLimits(limits Limits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	qm.limits = limits
+	return nil
+}
+
+// Limits returns the effective queue limits.
+func (qm *Manager) Limits() Limits {
+	return qm.limits
+}
+
 // SetTransaction sets the transaction to use for write operations.
 func (qm *Manager) SetTransaction(tx store.Transaction) {
 	qm.tx = tx
 }
 
 // CreateEntry creates new queue file(s) with the next sequential number(s).
-// If entry has more than maxItemsPerQueue pages, it splits into multiple files.
+// If entry has more pages than the batch size, it splits into multiple files.
+// Pages given in the legacy PageIDs list are queued with an unknown last edited time.
 func (qm *Manager) CreateEntry(ctx context.Context, entry Entry) (string, error) {
-	// Determine if we're using new or legacy format
-	useNewFormat := len(entry.Pages) > 0
-	pageCount := entry.GetPageCount()
-
-	if pageCount == 0 {
+	entry.Pages = slices.Clone(entry.Pages)
+	entry.upgrade()
+	if len(entry.Pages) == 0 {
 		return "", nil // Nothing to queue
 	}
-
-	if useNewFormat {
-		return qm.createEntriesNewFormat(ctx, entry)
-	}
-	return qm.createEntriesLegacyFormat(ctx, entry)
+	return qm.createEntries(ctx, entry)
 }
 
 // ListEntries returns all queue files in sorted order.
@@ -120,8 +223,18 @@ func (qm *Manager) ListEntries(ctx context.Context) ([]string, error) {
 	return queueFiles, nil
 }
 
-// ReadEntry reads a queue file.
+// ReadEntry reads a queue file, converted to the current format.
 func (qm *Manager) ReadEntry(ctx context.Context, filename string) (*Entry, error) {
+	entry, err := qm.readEntryFile(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	entry.upgrade()
+	return entry, nil
+}
+
+// readEntryFile reads a queue file as it is stored.
+func (qm *Manager) readEntryFile(ctx context.Context, filename string) (*Entry, error) {
 	path := filepath.Join(queueDir, filename)
 	data, err := qm.store.Read(ctx, path)
 	if err != nil {
@@ -142,9 +255,9 @@ func (qm *Manager) UpdateEntry(ctx context.Context, filename string, entry *Entr
 		"filename", filename,
 		"remaining_pages", entry.GetPageCount())
 
-	data, err := json.MarshalIndent(entry, "", "  ")
+	data, err := marshalEntry(entry)
 	if err != nil {
-		return fmt.Errorf("marshal entry: %w", err)
+		return err
 	}
 
 	path := filepath.Join(queueDir, filename)
@@ -200,26 +313,27 @@ func (qm *Manager) IsPageQueued(ctx context.Context, pageID, queueType string) (
 }
 
 // GetNextQueueNumber returns the next available queue file number for regular (non-webhook) entries.
-// Regular entries start at webhookIDThreshold (1000) and increment upward.
+// Regular entries start at the webhook threshold (1000 by default) and increment upward.
 func (qm *Manager) GetNextQueueNumber(ctx context.Context) (int, error) {
 	files, err := qm.ListEntries(ctx)
 	if err != nil {
 		return 0, err
 	}
 
+	threshold := qm.limits.WebhookThreshold
 	if len(files) == 0 {
-		return webhookIDThreshold, nil
+		return threshold, nil
 	}
 
-	// Find the maximum ID >= webhookIDThreshold
-	maxNum := webhookIDThreshold - 1
+	// Find the maximum ID >= threshold
+	maxNum := threshold - 1
 	for _, file := range files {
 		numStr := strings.TrimSuffix(file, ".json")
 		num, err := strconv.Atoi(numStr)
 		if err != nil {
 			continue
 		}
-		if num >= webhookIDThreshold && num > maxNum {
+		if num >= threshold && num > maxNum {
 			maxNum = num
 		}
 	}
@@ -227,6 +341,39 @@ func (qm *Manager) GetNextQueueNumber(ctx context.Context) (int, error) {
 	return maxNum + 1, nil
 }
 
+// CheckLimits checks the existing queue files against the limits, and returns warnings about files
+// created with other limits. Such files are still processed in order.
+func (qm *Manager) CheckLimits(ctx context.Context) ([]string, error) {
+	files, err := qm.ListEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	oversized := 0
+	for _, filename := range files {
+		entry, err := qm.ReadEntry(ctx, filename)
+		if err != nil {
+			continue
+		}
+		if entry.GetPageCount() > qm.limits.BatchSize {
+			oversized++
+		}
+	}
+	if oversized > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"%d queue files hold more than %d pages (created with a larger batch size)", oversized, qm.limits.BatchSize))
+	}
+
+	if minID, err := qm.GetMinQueueID(ctx); err == nil && minID == 1 {
+		warnings = append(warnings, fmt.Sprintf(
+			"webhook queue IDs are exhausted: webhook events are queued as regular entries until %s is processed",
+			fmt.Sprintf(queueFileFormat, minID)))
+	}
+
+	return warnings, nil
+}
+
 // GetMinQueueID returns the minimum queue ID from existing entries.
 // Returns 0 if there are no entries.
 func (qm *Manager) GetMinQueueID(ctx context.Context) (int, error) {
@@ -239,46 +386,93 @@ func (qm *Manager) GetMinQueueID(ctx context.Context) (int, error) {
 	return strconv.Atoi(numStr)
 }
 
-// CreateWebhookEntry creates a queue entry for webhook-triggered events.
-// Webhook entries use IDs below webhookIDThreshold (decrementing from 999, 998, ...)
+// IsWebhookEntry returns true if the queue file was created for a webhook event (its number is below the
+// webhook threshold).
+func (qm *Manager) IsWebhookEntry(filename string) bool {
+	num, err := strconv.Atoi(strings.TrimSuffix(filename, ".json"))
+	return err == nil && num < qm.limits.WebhookThreshold
+}
+
+// CreateWebhookEntry creates a queue entry of type "update" for webhook-triggered events.
+// Webhook entries use IDs below the webhook threshold (decrementing from 999, 998, ...)
 // to ensure they are processed before regular queue entries.
 func (qm *Manager) CreateWebhookEntry(ctx context.Context, pageID, folder string) (string, error) {
+	return qm.CreateWebhookEntryWithType(ctx, pageID, folder, TypeUpdate)
+}
+
+// CreateWebhookEntryWithType creates a webhook queue entry of the given type.
+func (qm *Manager) CreateWebhookEntryWithType(ctx context.Context, pageID, folder, queueType string) (string, error) {
+	return qm.createWebhookEntry(ctx, Page{ID: pageID, LastEdited: time.Now()}, folder, queueType)
+}
+
+// CreateWebhookBlocksEntry creates a webhook update entry of a page, with the blocks changed by the event, so that
+// only their subtrees are fetched again when block diff is enabled.
+func (qm *Manager) CreateWebhookBlocksEntry(
+	ctx context.Context, pageID, folder string, updatedBlocks []string,
+) (string, error) {
+	page := Page{ID: pageID, LastEdited: time.Now(), UpdatedBlocks: updatedBlocks}
+	return qm.createWebhookEntry(ctx, page, folder, TypeUpdate)
+}
+
+// createWebhookEntry creates a webhook queue entry of a page, with a decrementing ID for priority.
+func (qm *Manager) createWebhookEntry(ctx context.Context, page Page, folder, queueType string) (string, error) {
+	pageID := page.ID
+
 	// Find the current minimum queue ID
 	minID, err := qm.GetMinQueueID(ctx)
 	if err != nil {
 		return "", fmt.Errorf("get min queue id: %w", err)
 	}
 
+	// Events arriving shortly after the newest webhook entry are added to it
+	if qm.limits.WebhookWindow > 0 && minID > 0 && minID < qm.limits.WebhookThreshold {
+		filename := fmt.Sprintf(queueFileFormat, minID)
+		coalesced, err := qm.coalesceWebhookEntry(ctx, filename, page, folder, queueType)
+		if err != nil {
+			return "", err
+		}
+		if coalesced {
+			return filename, nil
+		}
+	}
+
 	// Determine the new ID
 	var newID int
-	if minID == 0 || minID >= webhookIDThreshold {
-		// No webhook entries yet, start at 999
-		newID = webhookIDThreshold - 1
+	if minID == 0 || minID >= qm.limits.WebhookThreshold {
+		// No webhook entries yet, start right below the threshold
+		newID = qm.limits.WebhookThreshold - 1
 	} else {
 		// Decrement from current minimum
 		newID = minID - 1
 	}
+	if newID < 1 {
+		// All webhook IDs are used: queue it as a regular entry rather than overwriting one
+		qm.Logger.WarnContext(ctx, "webhook queue IDs exhausted, queueing as a regular entry",
+			"page_id", pageID,
+			"webhook_threshold", qm.limits.WebhookThreshold)
+		if newID, err = qm.GetNextQueueNumber(ctx); err != nil {
+			return "", err
+		}
+	}
 
 	filename := fmt.Sprintf(queueFileFormat, newID)
 	qm.Logger.DebugContext(ctx, "creating webhook queue entry",
 		"filename", filename,
 		"page_id", pageID,
-		"folder", folder)
+		"folder", folder,
+		"type", queueType)
 
-	// Create entry with type "update" (webhook events always force sync)
 	entry := Entry{
-		Type:   "update",
-		Folder: folder,
-		Pages: []Page{
-			{ID: pageID, LastEdited: time.Now()},
-		},
+		Type:      queueType,
+		Folder:    folder,
+		Pages:     []Page{page},
 		CreatedAt: time.Now(),
 	}
 
 	// Marshal entry
-	data, err := json.MarshalIndent(entry, "", "  ")
+	data, err := marshalEntry(&entry)
 	if err != nil {
-		return "", fmt.Errorf("marshal entry: %w", err)
+		return "", err
 	}
 
 	// Write queue file
@@ -290,42 +484,83 @@ func (qm *Manager) CreateWebhookEntry(ctx context.Context, pageID, folder string
 	return filename, nil
 }
 
-// createEntriesNewFormat creates queue entries using the new Pages format.
-func (qm *Manager) createEntriesNewFormat(ctx context.Context, entry Entry) (string, error) {
-	var firstFilename string
-	pages := entry.Pages
-
-	for len(pages) > 0 {
-		// Take up to maxItemsPerQueue items
-		chunkSize := min(len(pages), maxItemsPerQueue)
-		chunk := pages[:chunkSize]
-		pages = pages[chunkSize:]
-
-		filename, err := qm.createChunkEntryNewFormat(ctx, entry, chunk)
-		if err != nil {
-			return "", err
+// coalesceWebhookEntry adds a page to a webhook queue entry of the same folder and type, created within the webhook
+// window and not full yet. A page already in the entry gets the new event's last edited time and blocks. Returns
+// false if the page needs an entry of its own.
+func (qm *Manager) coalesceWebhookEntry(
+	ctx context.Context, filename string, page Page, folder, queueType string,
+) (bool, error) {
+	entry, err := qm.ReadEntry(ctx, filename)
+	if err != nil || entry.Folder != folder || entry.Type != queueType ||
+		time.Since(entry.CreatedAt) > qm.limits.WebhookWindow {
+		return false, nil //nolint:nilerr // An unreadable entry is left alone, the page gets its own entry
+	}
+
+	if i := slices.IndexFunc(entry.Pages, func(p Page) bool { return p.ID == page.ID }); i >= 0 {
+		queued := &entry.Pages[i]
+		queued.LastEdited = page.LastEdited
+		queued.NotBefore = time.Time{} // A new event is processed right away
+		if len(queued.UpdatedBlocks) == 0 || len(page.UpdatedBlocks) == 0 {
+			queued.UpdatedBlocks = nil // The whole page is fetched again
+		} else {
+			for _, block := range page.UpdatedBlocks {
+				if !slices.Contains(queued.UpdatedBlocks, block) {
+					queued.UpdatedBlocks = append(queued.UpdatedBlocks, block)
+				}
+			}
 		}
-
-		if firstFilename == "" {
-			firstFilename = filename
+	} else {
+		if len(entry.Pages) >= cmp.Or(qm.limits.WebhookMaxPages, qm.limits.BatchSize) {
+			return false, nil
 		}
+		entry.Pages = append(entry.Pages, page)
 	}
 
-	return firstFilename, nil
+	qm.Logger.DebugContext(ctx, "adding page to webhook queue entry",
+		"filename", filename,
+		"page_id", page.ID,
+		"folder", folder,
+		"pages", len(entry.Pages))
+	data, err := marshalEntry(entry)
+	if err != nil {
+		return false, err
+	}
+	if err := qm.tx.Write(ctx, filepath.Join(queueDir, filename), data); err != nil {
+		return false, fmt.Errorf("write queue file: %w", err)
+	}
+	return true, nil
+}
+
+// PagesAddedSince returns the pages of a queue file that were not in an earlier read of it: the pages that webhook
+// events added to it (see Limits.WebhookWindow) while it was processed.
+func (qm *Manager) PagesAddedSince(ctx context.Context, filename string, earlier *Entry) []Page {
+	current, err := qm.ReadEntry(ctx, filename)
+	if err != nil {
+		return nil
+	}
+	var added []Page
+	for _, page := range current.Pages {
+		if !slices.ContainsFunc(earlier.Pages, func(p Page) bool {
+			return p.ID == page.ID && p.LastEdited.Equal(page.LastEdited)
+		}) {
+			added = append(added, page)
+		}
+	}
+	return added
 }
 
-// createEntriesLegacyFormat creates queue entries using the legacy PageIDs format.
-func (qm *Manager) createEntriesLegacyFormat(ctx context.Context, entry Entry) (string, error) {
+// createEntries creates the queue entries of the pages of entry, in chunks of at most BatchSize pages.
+func (qm *Manager) createEntries(ctx context.Context, entry Entry) (string, error) {
 	var firstFilename string
-	pageIDs := entry.PageIDs
+	pages := entry.Pages
 
-	for len(pageIDs) > 0 {
-		// Take up to maxItemsPerQueue items
-		chunkSize := min(len(pageIDs), maxItemsPerQueue)
-		chunk := pageIDs[:chunkSize]
-		pageIDs = pageIDs[chunkSize:]
+	for len(pages) > 0 {
+		// Take up to BatchSize items
+		chunkSize := min(len(pages), qm.limits.BatchSize)
+		chunk := pages[:chunkSize]
+		pages = pages[chunkSize:]
 
-		filename, err := qm.createChunkEntryLegacy(ctx, entry, chunk)
+		filename, err := qm.createChunkEntry(ctx, entry, chunk)
 		if err != nil {
 			return "", err
 		}
@@ -338,8 +573,8 @@ func (qm *Manager) createEntriesLegacyFormat(ctx context.Context, entry Entry) (
 	return firstFilename, nil
 }
 
-// createChunkEntryNewFormat creates a single queue file for a chunk using new format.
-func (qm *Manager) createChunkEntryNewFormat(
+// createChunkEntry creates a single queue file for a chunk of pages.
+func (qm *Manager) createChunkEntry(
 	ctx context.Context, entry Entry, chunk []Page,
 ) (string, error) {
 	nextNum, err := qm.GetNextQueueNumber(ctx)
@@ -362,9 +597,9 @@ func (qm *Manager) createChunkEntryNewFormat(
 		CreatedAt: time.Now(),
 	}
 
-	data, err := json.MarshalIndent(chunkEntry, "", "  ")
+	data, err := marshalEntry(&chunkEntry)
 	if err != nil {
-		return "", fmt.Errorf("marshal entry: %w", err)
+		return "", err
 	}
 
 	path := filepath.Join(queueDir, filename)
@@ -375,37 +610,32 @@ func (qm *Manager) createChunkEntryNewFormat(
 	return filename, nil
 }
 
-// createChunkEntryLegacy creates a single queue file for a chunk using legacy format.
-func (qm *Manager) createChunkEntryLegacy(ctx context.Context, entry Entry, chunk []string) (string, error) {
-	nextNum, err := qm.GetNextQueueNumber(ctx)
-	if err != nil {
-		return "", fmt.Errorf("get next queue number: %w", err)
-	}
-
-	filename := fmt.Sprintf(queueFileFormat, nextNum)
-	qm.Logger.DebugContext(ctx, "creating queue entry",
-		"filename", filename,
-		"type", entry.Type,
-		"folder", entry.Folder,
-		"page_count", len(chunk))
-
-	chunkEntry := Entry{
-		Type:      entry.Type,
-		Folder:    entry.Folder,
-		PageIDs:   chunk,
-		ParentID:  entry.ParentID,
-		CreatedAt: time.Now(),
+// Migrate rewrites the queue files written before the current format version (see EntryVersion), so that they
+// no longer use the legacy PageIDs list, and returns their names. With dryRun, they are only listed.
+func (qm *Manager) Migrate(ctx context.Context, dryRun bool) ([]string, error) {
+	if exists, err := qm.store.Exists(ctx, queueDir); err != nil || !exists {
+		return nil, err
 	}
-
-	data, err := json.MarshalIndent(chunkEntry, "", "  ")
+	files, err := qm.ListEntries(ctx)
 	if err != nil {
-		return "", fmt.Errorf("marshal entry: %w", err)
+		return nil, fmt.Errorf("list queue entries: %w", err)
 	}
 
-	path := filepath.Join(queueDir, filename)
-	if err := qm.tx.Write(ctx, path, data); err != nil {
-		return "", fmt.Errorf("write queue file: %w", err)
+	var migrated []string
+	for _, filename := range files {
+		entry, err := qm.readEntryFile(ctx, filename)
+		if err != nil {
+			return migrated, err
+		}
+		if !entry.upgrade() {
+			continue
+		}
+		if !dryRun {
+			if err := qm.UpdateEntry(ctx, filename, entry); err != nil {
+				return migrated, err
+			}
+		}
+		migrated = append(migrated, filename)
 	}
-
-	return filename, nil
+	return migrated, nil
 }
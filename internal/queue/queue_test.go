@@ -2,11 +2,15 @@ package queue
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
 	"github.com/fclairamb/ntnsync/internal/store"
 )
 
@@ -27,8 +31,8 @@ func TestQueue_StartsAt1000(t *testing.T) {
 		t.Fatalf("GetNextQueueNumber failed: %v", err)
 	}
 
-	if num != webhookIDThreshold {
-		t.Errorf("expected first queue number to be %d, got %d", webhookIDThreshold, num)
+	if num != DefaultWebhookThreshold {
+		t.Errorf("expected first queue number to be %d, got %d", DefaultWebhookThreshold, num)
 	}
 }
 
@@ -99,6 +103,41 @@ func TestQueueFromWebhook_FirstEntry(t *testing.T) {
 	}
 }
 
+// TestQueueFromWebhook_UpdatedBlocks verifies that webhook entries keep the blocks changed by the event.
+func TestQueueFromWebhook_UpdatedBlocks(t *testing.T) {
+	t.Parallel()
+	_, qm := createTestStoreAndManager(t)
+	ctx := context.Background()
+
+	filename, err := qm.CreateWebhookBlocksEntry(ctx, "page1", "test", []string{"block2", "block1"})
+	if err != nil {
+		t.Fatalf("CreateWebhookBlocksEntry failed: %v", err)
+	}
+
+	entry, err := qm.ReadEntry(ctx, filename)
+	if err != nil {
+		t.Fatalf("ReadEntry failed: %v", err)
+	}
+	if entry.Type != testQueueTypeUpd || len(entry.Pages) != 1 {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if blocks := entry.Pages[0].UpdatedBlocks; len(blocks) != 2 || blocks[0] != "block1" || blocks[1] != "block2" {
+		t.Errorf("expected sorted updated blocks [block1 block2], got %v", blocks)
+	}
+}
+
+// TestIsWebhookEntry verifies that queue files below the webhook threshold are webhook entries.
+func TestIsWebhookEntry(t *testing.T) {
+	t.Parallel()
+	_, qm := createTestStoreAndManager(t)
+
+	for filename, want := range map[string]bool{"00000999.json": true, "00001000.json": false, "notes.json": false} {
+		if got := qm.IsWebhookEntry(filename); got != want {
+			t.Errorf("IsWebhookEntry(%q) = %v, want %v", filename, got, want)
+		}
+	}
+}
+
 // TestQueueFromWebhook_Decrementing verifies webhook entries decrement properly.
 func TestQueueFromWebhook_Decrementing(t *testing.T) {
 	t.Parallel()
@@ -290,6 +329,98 @@ func TestWebhookEntryWithExistingRegular(t *testing.T) {
 	}
 }
 
+// TestMarshalEntry_Canonical verifies that entries are encoded with sorted keys, second precision
+// timestamps and pages sorted by ID.
+func TestMarshalEntry_Canonical(t *testing.T) {
+	t.Parallel()
+
+	paris := time.FixedZone("CET", 3600)
+	entry := &Entry{
+		Type:   testQueueTypeUpd,
+		Folder: "tech",
+		Pages: []Page{
+			{ID: "bbb", LastEdited: time.Date(2026, 1, 2, 11, 0, 0, 123456789, paris)},
+			{ID: "aaa", LastEdited: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		CreatedAt: time.Date(2026, 1, 3, 12, 30, 15, 999999999, time.UTC),
+	}
+
+	data, err := marshalEntry(entry)
+	if err != nil {
+		t.Fatalf("marshalEntry() error = %v", err)
+	}
+
+	want := `{
+  "createdAt": "2026-01-03T12:30:15Z",
+  "folder": "tech",
+  "pages": [
+    {
+      "id": "aaa",
+      "last_edited": "2026-01-01T10:00:00Z"
+    },
+    {
+      "id": "bbb",
+      "last_edited": "2026-01-02T10:00:00Z"
+    }
+  ],
+  "type": "update",
+  "version": 2
+}
+`
+	if string(data) != want {
+		t.Errorf("marshalEntry() =\n%s\nwant\n%s", data, want)
+	}
+	if entry.Pages[0].ID != "bbb" {
+		t.Error("marshalEntry() should not reorder the entry's pages")
+	}
+}
+
+// TestMigrate verifies that queue entries using the legacy PageIDs list are converted to the pages format.
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, qm := createTestStoreAndManager(t)
+
+	legacy := []byte(`{"createdAt":"2026-01-03T12:30:15Z","folder":"tech","pageIds":["bbb","aaa"],"type":"update"}`)
+	if err := qm.tx.Write(ctx, filepath.Join(queueDir, "00001000.json"), legacy); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	current := Entry{Type: testQueueTypeUpd, Folder: "tech", Pages: []Page{{ID: "ccc"}}}
+	if _, err := qm.CreateEntry(ctx, current); err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	entry, err := qm.ReadEntry(ctx, "00001000.json")
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	if ids := entry.GetPageIDs(); len(entry.PageIDs) != 0 || strings.Join(ids, ",") != "bbb,aaa" {
+		t.Errorf("ReadEntry() pages = %v, legacy = %v, want the legacy pages converted", ids, entry.PageIDs)
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		migrated, err := qm.Migrate(ctx, dryRun)
+		if err != nil {
+			t.Fatalf("Migrate(%v) error = %v", dryRun, err)
+		}
+		if strings.Join(migrated, ",") != "00001000.json" {
+			t.Errorf("Migrate(%v) = %v, want [00001000.json]", dryRun, migrated)
+		}
+	}
+	if migrated, _ := qm.Migrate(ctx, false); len(migrated) != 0 {
+		t.Errorf("Migrate() after migration = %v, want none", migrated)
+	}
+
+	entry, err = qm.readEntryFile(ctx, "00001000.json")
+	if err != nil {
+		t.Fatalf("readEntryFile() error = %v", err)
+	}
+	if entry.Version != EntryVersion || len(entry.PageIDs) != 0 || len(entry.Pages) != 2 {
+		t.Errorf("migrated entry = %+v, want version %d with 2 pages", entry, EntryVersion)
+	}
+}
+
 // createTestStoreAndManager creates a temporary LocalStore and Manager with transaction for testing.
 func createTestStoreAndManager(t *testing.T) (store.Store, *Manager) { //nolint:unparam // may be used in future
 	t.Helper()
@@ -324,3 +455,155 @@ func createTestStoreAndManager(t *testing.T) (store.Store, *Manager) { //nolint:
 
 	return st, qm
 }
+
+// TestLimits_Validate verifies the validation of queue limits.
+func TestLimits_Validate(t *testing.T) {
+	t.Parallel()
+
+	if err := DefaultLimits().Validate(); err != nil {
+		t.Errorf("default limits: unexpected error %v", err)
+	}
+	for _, limits := range []Limits{
+		{BatchSize: 0, WebhookThreshold: 1000},
+		{BatchSize: 10, WebhookThreshold: 1},
+		{BatchSize: 10, WebhookThreshold: maxQueueNumber},
+	} {
+		if err := limits.Validate(); !errors.Is(err, apperrors.ErrInvalidQueueLimits) {
+			t.Errorf("%+v: expected ErrInvalidQueueLimits, got %v", limits, err)
+		}
+	}
+
+	_, qm := createTestStoreAndManager(t)
+	if err := qm.SetLimits(Limits{BatchSize: 0, WebhookThreshold: 1000}); err == nil {
+		t.Error("SetLimits accepted invalid limits")
+	}
+	if qm.Limits() != DefaultLimits() {
+		t.Errorf("invalid limits replaced the current ones: %+v", qm.Limits())
+	}
+}
+
+// TestLimits_Custom verifies that entries are split by the batch size and numbered from the webhook threshold.
+func TestLimits_Custom(t *testing.T) {
+	t.Parallel()
+	_, qm := createTestStoreAndManager(t)
+	ctx := context.Background()
+
+	if err := qm.SetLimits(Limits{BatchSize: 2, WebhookThreshold: 5000}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+
+	filename, err := qm.CreateEntry(ctx, Entry{
+		Type:   "init",
+		Folder: "test",
+		Pages:  []Page{{ID: "page1"}, {ID: "page2"}, {ID: "page3"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	if filename != "00005000.json" {
+		t.Errorf("expected first entry 00005000.json, got %s", filename)
+	}
+
+	webhookFile, err := qm.CreateWebhookEntry(ctx, "page4", "test")
+	if err != nil {
+		t.Fatalf("CreateWebhookEntry failed: %v", err)
+	}
+	if webhookFile != "00004999.json" {
+		t.Errorf("expected webhook entry 00004999.json, got %s", webhookFile)
+	}
+
+	files, err := qm.ListEntries(ctx)
+	if err != nil {
+		t.Fatalf("ListEntries failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected 3 queue files (2 batches and a webhook entry), got %v", files)
+	}
+
+	// Files created with a larger batch size are reported
+	if err := qm.SetLimits(Limits{BatchSize: 1, WebhookThreshold: 5000}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	warnings, err := qm.CheckLimits(ctx)
+	if err != nil {
+		t.Fatalf("CheckLimits failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "1 queue files hold more than 1 pages") {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
+// TestQueueFromWebhook_Exhausted verifies that webhook entries never get the ID 0.
+func TestQueueFromWebhook_Exhausted(t *testing.T) {
+	t.Parallel()
+	_, qm := createTestStoreAndManager(t)
+	ctx := context.Background()
+
+	if err := qm.SetLimits(Limits{BatchSize: DefaultBatchSize, WebhookThreshold: 2}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+
+	for i, want := range []string{"00000001.json", "00000002.json"} {
+		filename, err := qm.CreateWebhookEntry(ctx, "page", "test")
+		if err != nil {
+			t.Fatalf("CreateWebhookEntry %d failed: %v", i, err)
+		}
+		if filename != want {
+			t.Errorf("webhook entry %d: expected %s, got %s", i, want, filename)
+		}
+	}
+
+	warnings, err := qm.CheckLimits(ctx)
+	if err != nil {
+		t.Fatalf("CheckLimits failed: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "exhausted") {
+		t.Errorf("expected an exhaustion warning, got %v", warnings)
+	}
+}
+
+// TestQueueFromWebhook_Coalesced verifies that webhook events of a folder within the window share a queue file.
+func TestQueueFromWebhook_Coalesced(t *testing.T) {
+	t.Parallel()
+	_, qm := createTestStoreAndManager(t)
+	ctx := context.Background()
+
+	limits := DefaultLimits()
+	limits.WebhookWindow = time.Minute
+	limits.WebhookMaxPages = 3
+	if err := qm.SetLimits(limits); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+
+	var filenames []string
+	for _, event := range []struct{ pageID, folder string }{
+		{"page1", "test"}, {"page2", "test"}, {"page1", "test"}, {"page3", "test"}, // Shared, page1 once
+		{"page4", "test"}, // The entry is full
+		{"page5", "other"},
+	} {
+		filename, err := qm.CreateWebhookEntry(ctx, event.pageID, event.folder)
+		if err != nil {
+			t.Fatalf("CreateWebhookEntry(%s) failed: %v", event.pageID, err)
+		}
+		filenames = append(filenames, filename)
+	}
+	want := "00000999.json,00000999.json,00000999.json,00000999.json,00000998.json,00000997.json"
+	if got := strings.Join(filenames, ","); got != want {
+		t.Errorf("queue files = %s, want %s", got, want)
+	}
+
+	entry, err := qm.ReadEntry(ctx, testQueueFile)
+	if err != nil {
+		t.Fatalf("ReadEntry failed: %v", err)
+	}
+	if ids := entry.GetPageIDs(); strings.Join(ids, ",") != "page1,page2,page3" {
+		t.Errorf("coalesced pages = %v, want [page1 page2 page3]", ids)
+	}
+
+	// Pages added while the entry was processed are found
+	earlier := *entry
+	earlier.Pages = entry.Pages[:2]
+	if added := qm.PagesAddedSince(ctx, testQueueFile, &earlier); len(added) != 1 || added[0].ID != "page3" {
+		t.Errorf("PagesAddedSince() = %v, want page3", added)
+	}
+}
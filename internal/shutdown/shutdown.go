@@ -0,0 +1,106 @@
+// Package shutdown provides graceful shutdown of long-running operations, which finish their work in progress
+// before their context is canceled.
+package shutdown
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Controller coordinates a graceful shutdown. Once requested, the operations in progress stop taking new work,
+// and the context is canceled when they are all done, or when the grace period expires.
+type Controller struct {
+	done     <-chan struct{} // Closed once the context is canceled
+	cancel   context.CancelFunc
+	stopping chan struct{}
+
+	mu        sync.Mutex
+	active    int  // Operations in progress
+	requested bool // A shutdown was requested
+}
+
+// New returns a context carrying a new controller, which cancels it.
+func New(parent context.Context) (context.Context, *Controller) {
+	ctx, cancel := context.WithCancel(parent)
+	c := &Controller{done: ctx.Done(), cancel: cancel, stopping: make(chan struct{})}
+	return context.WithValue(ctx, contextKey{}, c), c
+}
+
+// Request asks the operations in progress to stop. The context is canceled once they are done, or after the
+// grace period. A second request, or a request without a grace period, cancels it immediately.
+func (c *Controller) Request(grace time.Duration) {
+	c.mu.Lock()
+	if c.requested {
+		c.mu.Unlock()
+		slog.Warn("shutdown requested again, stopping now")
+		c.cancel()
+		return
+	}
+	c.requested = true
+	close(c.stopping)
+	idle := c.active == 0
+	c.mu.Unlock()
+
+	if idle || grace <= 0 {
+		c.cancel()
+		return
+	}
+	slog.Info("finishing the work in progress before shutting down", "grace_period", grace)
+	time.AfterFunc(grace, func() {
+		select {
+		case <-c.done:
+		default:
+			slog.Warn("shutdown grace period expired, stopping now", "grace_period", grace)
+			c.cancel()
+		}
+	})
+}
+
+// Begin marks the start of an operation that finishes its work in progress on shutdown: the context is not
+// canceled before the returned function is called, unless the grace period expires. Operations can be nested.
+func Begin(ctx context.Context) func() {
+	c, _ := ctx.Value(contextKey{}).(*Controller)
+	if c == nil {
+		return func() {}
+	}
+
+	c.mu.Lock()
+	c.active++
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			c.active--
+			done := c.requested && c.active == 0
+			c.mu.Unlock()
+			if done {
+				c.cancel()
+			}
+		})
+	}
+}
+
+// Stopping returns a channel closed when a shutdown is requested. It is nil, and never closed, for contexts
+// without a controller.
+func Stopping(ctx context.Context) <-chan struct{} {
+	if c, _ := ctx.Value(contextKey{}).(*Controller); c != nil {
+		return c.stopping
+	}
+	return nil
+}
+
+// Requested returns true if a shutdown was requested: operations should stop taking new work.
+func Requested(ctx context.Context) bool {
+	select {
+	case <-Stopping(ctx):
+		return true
+	default:
+		return false
+	}
+}
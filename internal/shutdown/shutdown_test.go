@@ -0,0 +1,73 @@
+package shutdown
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestController_WaitsForOperations(t *testing.T) {
+	t.Parallel()
+
+	ctx, controller := New(context.Background())
+	end := Begin(ctx)
+	nested := Begin(ctx)
+	if Requested(ctx) {
+		t.Fatal("Requested() = true before the request")
+	}
+
+	controller.Request(time.Hour)
+	if !Requested(ctx) {
+		t.Error("Requested() = false after the request")
+	}
+	nested()
+	nested() // Ending twice counts once
+	if ctx.Err() != nil {
+		t.Fatal("context canceled before the operations are done")
+	}
+
+	end()
+	if ctx.Err() == nil {
+		t.Error("context not canceled once the operations are done")
+	}
+}
+
+func TestController_Cancels(t *testing.T) {
+	t.Parallel()
+
+	// Without operations in progress
+	ctx, controller := New(context.Background())
+	controller.Request(time.Hour)
+	if ctx.Err() == nil {
+		t.Error("context not canceled without operations in progress")
+	}
+
+	// After the grace period
+	ctx, controller = New(context.Background())
+	defer Begin(ctx)()
+	controller.Request(10 * time.Millisecond)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("context not canceled after the grace period")
+	}
+
+	// On a second request
+	ctx, controller = New(context.Background())
+	defer Begin(ctx)()
+	controller.Request(time.Hour)
+	controller.Request(time.Hour)
+	if ctx.Err() == nil {
+		t.Error("context not canceled on a second request")
+	}
+}
+
+func TestWithoutController(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	Begin(ctx)()
+	if Requested(ctx) || Stopping(ctx) != nil {
+		t.Error("shutdown requested without a controller")
+	}
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitSizeMaxAge is the time a measure of the repository size is reused for, if no git operation changed it.
+const gitSizeMaxAge = 5 * time.Minute
+
+// Git operations whose durations are recorded.
+const (
+	gitOpPull   = "pull"
+	gitOpPush   = "push"
+	gitOpCommit = "commit"
+)
+
+// GitOpStats are the number and total duration of the calls of a git operation.
+type GitOpStats struct {
+	Count    int           `json:"count"`
+	Failures int           `json:"failures"`
+	Duration time.Duration `json:"duration"` // Total duration of the calls, failed ones included
+}
+
+// GitMetrics are the git operations of a store since it was opened, and the size of its repository, so that
+// operators can tell when the mirror repository needs a gc or a history purge.
+type GitMetrics struct {
+	Pull   GitOpStats `json:"pull"`   // Pulls from the remote, merges included
+	Push   GitOpStats `json:"push"`   // Pushes to the remote, pulls of rejected pushes included
+	Commit GitOpStats `json:"commit"` // Commits created (or failed), staging included
+
+	RepoSize     int64 `json:"repo_size"`     // Size of the .git directory, in bytes
+	PackSize     int64 `json:"pack_size"`     // Size of the pack files, in bytes
+	Packs        int   `json:"packs"`         // Number of pack files
+	LooseObjects int   `json:"loose_objects"` // Objects not packed yet (git gc packs them)
+	LooseSize    int64 `json:"loose_size"`    // Size of the loose objects, in bytes
+}
+
+// GitMetricsReader is implemented by stores backed by git repositories.
+type GitMetricsReader interface {
+	// GitMetrics returns the git operations since the store was opened, and the size of its repositories.
+	GitMetrics() (*GitMetrics, error)
+}
+
+// Sub returns the git operations since an earlier measure, with the current repository size.
+func (m GitMetrics) Sub(earlier GitMetrics) GitMetrics {
+	sub := func(now, before GitOpStats) GitOpStats {
+		return GitOpStats{
+			Count:    now.Count - before.Count,
+			Failures: now.Failures - before.Failures,
+			Duration: now.Duration - before.Duration,
+		}
+	}
+	delta := m
+	delta.Pull = sub(m.Pull, earlier.Pull)
+	delta.Push = sub(m.Push, earlier.Push)
+	delta.Commit = sub(m.Commit, earlier.Commit)
+	return delta
+}
+
+// merge combines the metrics of another repository into these ones.
+func (m *GitMetrics) merge(other *GitMetrics) *GitMetrics {
+	add := func(a, b GitOpStats) GitOpStats {
+		return GitOpStats{
+			Count:    a.Count + b.Count,
+			Failures: a.Failures + b.Failures,
+			Duration: a.Duration + b.Duration,
+		}
+	}
+	merged := *m
+	merged.Pull = add(m.Pull, other.Pull)
+	merged.Push = add(m.Push, other.Push)
+	merged.Commit = add(m.Commit, other.Commit)
+	merged.RepoSize += other.RepoSize
+	merged.PackSize += other.PackSize
+	merged.Packs += other.Packs
+	merged.LooseObjects += other.LooseObjects
+	merged.LooseSize += other.LooseSize
+	return &merged
+}
+
+// recordGitOp records a git operation started at start, which failed if err is not nil. The repository size is
+// measured again by the next call of GitMetrics.
+func (s *LocalStore) recordGitOp(name string, start time.Time, err error) {
+	elapsed := time.Since(start)
+
+	s.gitMu.Lock()
+	defer s.gitMu.Unlock()
+
+	var op *GitOpStats
+	switch name {
+	case gitOpPull:
+		op = &s.git.Pull
+	case gitOpPush:
+		op = &s.git.Push
+	default:
+		op = &s.git.Commit
+	}
+	op.Count++
+	op.Duration += elapsed
+	if err != nil {
+		op.Failures++
+	}
+	s.gitSizedAt = time.Time{}
+}
+
+// GitMetrics returns the git operations since the store was opened, and the size of its repository. The size is
+// measured again after a git operation of the store, or once it is gitSizeMaxAge old.
+func (s *LocalStore) GitMetrics() (*GitMetrics, error) {
+	s.gitMu.Lock()
+	defer s.gitMu.Unlock()
+
+	if time.Since(s.gitSizedAt) >= gitSizeMaxAge {
+		if err := s.measureRepositoryLocked(); err != nil {
+			return nil, err
+		}
+		s.gitSizedAt = time.Now()
+	}
+	metrics := s.git
+	return &metrics, nil
+}
+
+// measureRepositoryLocked measures the size of the .git directory, its packs and its loose objects.
+// Caller must hold s.gitMu.
+func (s *LocalStore) measureRepositoryLocked() error {
+	gitDir := filepath.Join(s.rootPath, ".git")
+	objectsDir := filepath.Join(gitDir, "objects")
+	var measure GitMetrics
+
+	err := filepath.WalkDir(gitDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Files removed during the walk (a concurrent gc) are skipped
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil //nolint:nilerr // Removed during the walk
+		}
+
+		measure.RepoSize += info.Size()
+		switch dir := filepath.Dir(path); {
+		case dir == filepath.Join(objectsDir, "pack") && strings.HasSuffix(path, ".pack"):
+			measure.Packs++
+			measure.PackSize += info.Size()
+		case filepath.Dir(dir) == objectsDir && len(filepath.Base(dir)) == 2:
+			measure.LooseObjects++
+			measure.LooseSize += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("measure repository: %w", err)
+	}
+
+	s.git.RepoSize = measure.RepoSize
+	s.git.PackSize = measure.PackSize
+	s.git.Packs = measure.Packs
+	s.git.LooseObjects = measure.LooseObjects
+	s.git.LooseSize = measure.LooseSize
+	return nil
+}
+
+// GitMetrics returns the git operations of both stores, and the size of both repositories.
+func (s *SplitStore) GitMetrics() (*GitMetrics, error) {
+	content, err := s.contentStore.GitMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("content store: %w", err)
+	}
+	queue, err := s.queueStore.GitMetrics()
+	if err != nil {
+		return nil, fmt.Errorf("queue store: %w", err)
+	}
+	return content.merge(queue), nil
+}
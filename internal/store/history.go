@@ -0,0 +1,356 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// HistoryRewriter is implemented by stores that can remove paths from their git history.
+type HistoryRewriter interface {
+	// PurgeHistory rewrites the history of the mirror branch so that no commit contains the given paths
+	// (files or directories). Paths must already be deleted and committed.
+	PurgeHistory(ctx context.Context, paths []string, dryRun bool) (*PurgeHistoryResult, error)
+	// ForcePush pushes the rewritten branch, replacing the history of the remote branch.
+	ForcePush(ctx context.Context) error
+	// HasCommit returns true if the repository still holds a commit, such as the former head of a rewritten
+	// branch that another reference points to.
+	HasCommit(hash string) bool
+}
+
+// PurgeHistoryResult describes a history rewrite.
+type PurgeHistoryResult struct {
+	Branch    string // Rewritten branch
+	Commits   int    // Commits of the branch
+	Purged    int    // Commits that contained one of the paths
+	Rewritten int    // Commits rewritten: the purged ones and their descendants
+	OldHead   string // Head of the branch before the rewrite
+	NewHead   string // Head of the branch after the rewrite (not stored on dry-run)
+}
+
+// PurgeHistory rewrites the history of the current branch, removing the given paths from every commit.
+// Rewritten commits keep their author, committer and message, but lose their signature.
+// Objects that are no longer referenced are pruned from the repository (see HasCommit to check that the former
+// history is gone).
+func (s *LocalStore) PurgeHistory(ctx context.Context, paths []string, dryRun bool) (*PurgeHistoryResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	head, err := s.repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("get head: %w", err)
+	}
+
+	rewrite := &historyRewrite{
+		repo:    s.repo,
+		dryRun:  dryRun,
+		trees:   make(map[plumbing.Hash]plumbing.Hash),
+		commits: make(map[plumbing.Hash]plumbing.Hash),
+	}
+	for _, p := range paths {
+		repoPath := strings.Trim(path.Join(s.subdir, path.Clean("/"+p)), "/")
+		if repoPath != "" {
+			rewrite.paths = append(rewrite.paths, strings.Split(repoPath, "/"))
+		}
+	}
+
+	newHead, err := rewrite.run(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeHistoryResult{
+		Branch:    head.Name().Short(),
+		Commits:   len(rewrite.commits),
+		Purged:    rewrite.purged,
+		Rewritten: rewrite.rewritten,
+		OldHead:   head.Hash().String(),
+		NewHead:   newHead.String(),
+	}
+	s.logger.InfoContext(ctx, "rewrote history",
+		"branch", result.Branch,
+		"commits", result.Commits,
+		"purged", result.Purged,
+		"rewritten", result.Rewritten,
+		"dry_run", dryRun)
+	if dryRun || newHead == head.Hash() {
+		return result, nil
+	}
+
+	if err := s.repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), newHead)); err != nil {
+		return nil, fmt.Errorf("update branch %s: %w", result.Branch, err)
+	}
+	if err := s.pruneLocked(); err != nil {
+		s.logger.WarnContext(ctx, "failed to prune the former history", "error", err)
+	}
+	return result, nil
+}
+
+// ForcePush pushes the current branch to the remote, replacing its history.
+func (s *LocalStore) ForcePush(ctx context.Context) error {
+	if s.isReadOnly() {
+		return apperrors.ErrReadOnly
+	}
+	if !s.IsRemoteEnabled() {
+		return apperrors.ErrRemoteNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth, err := s.remoteConfig.GetAuth()
+	if err != nil {
+		return fmt.Errorf("get auth: %w", err)
+	}
+
+	s.logger.WarnContext(ctx, "force pushing to remote", "url", s.remoteConfig.URL, "branch", s.remoteConfig.Branch)
+	refSpec := config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/heads/%s", s.remoteConfig.Branch, s.remoteConfig.Branch))
+	err = s.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: gitRemoteOrigin,
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Force:      true,
+	})
+	// A failed force push is not spooled: a regular push would merge the former history back
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("force push: %w", err)
+	}
+	s.recordPushLocked(ctx, nil)
+
+	// The remote-tracking branch no longer references the former history
+	if err := s.pruneLocked(); err != nil {
+		s.logger.WarnContext(ctx, "failed to prune the former history", "error", err)
+	}
+	return nil
+}
+
+// HasCommit returns true if the repository still holds the commit.
+func (s *LocalStore) HasCommit(hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repo.Storer.HasEncodedObject(plumbing.NewHash(hash)) == nil
+}
+
+// pruneLocked deletes the objects no longer referenced, so that purged content does not stay in the
+// repository, like "git reflog expire --expire=now --all && git gc --prune=now": the reflogs are emptied,
+// unreferenced loose objects are deleted, and the referenced objects are repacked in a single pack replacing
+// the former ones. Caller must hold s.mu.
+func (s *LocalStore) pruneLocked() error {
+	if err := expireReflogs(filepath.Join(s.rootPath, ".git", "logs")); err != nil {
+		return fmt.Errorf("expire reflogs: %w", err)
+	}
+	err := s.repo.Prune(git.PruneOptions{
+		OnlyObjectsOlderThan: time.Now(),
+		Handler:              s.repo.DeleteObject,
+	})
+	if err != nil {
+		return fmt.Errorf("prune unreferenced objects: %w", err)
+	}
+	if err := s.repo.RepackObjects(&git.RepackConfig{}); err != nil {
+		return fmt.Errorf("repack objects: %w", err)
+	}
+
+	// The repository keeps the indexes of the deleted packs in memory
+	repo, err := git.PlainOpen(s.rootPath)
+	if err != nil {
+		return fmt.Errorf("reopen repository: %w", err)
+	}
+	s.repo = repo
+	return nil
+}
+
+// expireReflogs empties the reflogs of a git directory, which would otherwise reference the former history.
+// ntnsync doesn't write reflogs, but git does when the mirror is used by hand.
+func expireReflogs(logsDir string) error {
+	err := filepath.WalkDir(logsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		return os.Truncate(path, 0)
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("walk %s: %w", logsDir, err)
+	}
+	return nil
+}
+
+// historyRewrite removes paths from the commits of a branch.
+type historyRewrite struct {
+	repo   *git.Repository
+	paths  [][]string // Removed paths, split in components
+	dryRun bool       // Compute the rewritten objects without storing them
+
+	trees     map[plumbing.Hash]plumbing.Hash // Rewritten root trees, by original hash
+	commits   map[plumbing.Hash]plumbing.Hash // Rewritten commits, by original hash
+	purged    int
+	rewritten int
+}
+
+// run rewrites the history of a commit, parents first, and returns the rewritten commit.
+func (h *historyRewrite) run(head plumbing.Hash) (plumbing.Hash, error) {
+	stack := []plumbing.Hash{head}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		if _, done := h.commits[hash]; done {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		commit, err := h.repo.CommitObject(hash)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("read commit %s: %w", hash, err)
+		}
+
+		pending := false
+		for _, parent := range commit.ParentHashes {
+			if _, done := h.commits[parent]; !done {
+				stack = append(stack, parent)
+				pending = true
+			}
+		}
+		if pending {
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+		rewritten, err := h.rewriteCommit(commit)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		h.commits[hash] = rewritten
+	}
+	return h.commits[head], nil
+}
+
+// rewriteCommit rewrites a commit whose parents were already rewritten.
+// Returns the commit itself if neither its tree nor its parents changed.
+func (h *historyRewrite) rewriteCommit(commit *object.Commit) (plumbing.Hash, error) {
+	treeHash, ok := h.trees[commit.TreeHash]
+	if !ok {
+		var err error
+		treeHash, err = h.rewriteTree(commit.TreeHash, h.paths)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("rewrite tree of commit %s: %w", commit.Hash, err)
+		}
+		if treeHash.IsZero() {
+			if treeHash, err = h.storeTree(nil); err != nil {
+				return plumbing.ZeroHash, err
+			}
+		}
+		h.trees[commit.TreeHash] = treeHash
+	}
+
+	changed := treeHash != commit.TreeHash
+	if changed {
+		h.purged++
+	}
+	parents := make([]plumbing.Hash, len(commit.ParentHashes))
+	for i, parent := range commit.ParentHashes {
+		parents[i] = h.commits[parent]
+		changed = changed || parents[i] != parent
+	}
+	if !changed {
+		return commit.Hash, nil
+	}
+	h.rewritten++
+
+	rewritten := *commit
+	rewritten.TreeHash = treeHash
+	rewritten.ParentHashes = parents
+	rewritten.PGPSignature = ""
+
+	obj := h.repo.Storer.NewEncodedObject()
+	if err := rewritten.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode commit: %w", err)
+	}
+	return h.store(obj)
+}
+
+// rewriteTree removes paths from a tree. Returns the zero hash if the rewritten tree is empty.
+func (h *historyRewrite) rewriteTree(hash plumbing.Hash, paths [][]string) (plumbing.Hash, error) {
+	tree, err := object.GetTree(h.repo.Storer, hash)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("read tree %s: %w", hash, err)
+	}
+
+	changed := false
+	entries := make([]object.TreeEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		removed := false
+		var subPaths [][]string
+		for _, p := range paths {
+			if p[0] != entry.Name {
+				continue
+			}
+			if len(p) == 1 {
+				removed = true
+				break
+			}
+			subPaths = append(subPaths, p[1:])
+		}
+		if removed {
+			changed = true
+			continue
+		}
+
+		if len(subPaths) > 0 && entry.Mode == filemode.Dir {
+			subHash, err := h.rewriteTree(entry.Hash, subPaths)
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+			if subHash != entry.Hash {
+				changed = true
+				if subHash.IsZero() {
+					continue // Git does not keep empty directories
+				}
+				entry.Hash = subHash
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	switch {
+	case !changed:
+		return hash, nil
+	case len(entries) == 0:
+		return plumbing.ZeroHash, nil
+	default:
+		return h.storeTree(entries)
+	}
+}
+
+// storeTree stores a tree with the given entries.
+func (h *historyRewrite) storeTree(entries []object.TreeEntry) (plumbing.Hash, error) {
+	tree := &object.Tree{Entries: entries}
+	obj := h.repo.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode tree: %w", err)
+	}
+	return h.store(obj)
+}
+
+// store stores an object, unless running dry, and returns its hash.
+func (h *historyRewrite) store(obj plumbing.EncodedObject) (plumbing.Hash, error) {
+	if h.dryRun {
+		return obj.Hash(), nil
+	}
+	hash, err := h.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("store object: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFiles writes files (deleting those with empty content) and commits them.
+func commitFiles(ctx context.Context, t *testing.T, st *LocalStore, message string, files map[string]string) {
+	t.Helper()
+
+	tx, err := st.BeginTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		if content == "" {
+			err = tx.Delete(ctx, path)
+		} else {
+			err = tx.Write(ctx, path, []byte(content))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(ctx, message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// historyFiles returns the files of every commit of the current branch, by commit message.
+func historyFiles(t *testing.T, st *LocalStore) map[string][]string {
+	t.Helper()
+
+	head, err := st.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter, err := st.repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string][]string)
+	err = iter.ForEach(func(commit *object.Commit) error {
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		files[commit.Message] = []string{}
+		return tree.Files().ForEach(func(file *object.File) error {
+			files[commit.Message] = append(files[commit.Message], file.Name)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestLocalStore_PurgeHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commitFiles(ctx, t, st, "first", map[string]string{"tech/public.md": "public"})
+	commitFiles(ctx, t, st, "leak", map[string]string{
+		"tech/secret.md":             "secret",
+		"tech/secret/files/plan.png": "image",
+	})
+	commitFiles(ctx, t, st, "rename", map[string]string{
+		"tech/secret.md":         "",
+		"tech/renamed-secret.md": "secret v2",
+		"tech/public.md":         "public v2",
+	})
+	commitFiles(ctx, t, st, "purge", map[string]string{
+		"tech/renamed-secret.md":     "",
+		"tech/secret/files/plan.png": "",
+	})
+	paths := []string{"tech/secret.md", "tech/secret/files", "tech/renamed-secret.md"}
+
+	// The former history is packed and referenced by a reflog, like in a mirror maintained by git
+	if err := st.repo.RepackObjects(&git.RepackConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	reflog := filepath.Join(st.rootPath, ".git", "logs", "HEAD")
+	if err := os.MkdirAll(filepath.Dir(reflog), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reflog, []byte("former history\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := st.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun, err := st.PurgeHistory(ctx, paths, true)
+	if err != nil {
+		t.Fatalf("PurgeHistory(dry run) error = %v", err)
+	}
+	if after, _ := st.repo.Head(); after.Hash() != before.Hash() {
+		t.Error("dry run changed the branch head")
+	}
+	if dryRun.Commits != 4 || dryRun.Purged != 2 || dryRun.Rewritten != 3 {
+		t.Errorf("dry run result = %+v", dryRun)
+	}
+
+	result, err := st.PurgeHistory(ctx, paths, false)
+	if err != nil {
+		t.Fatalf("PurgeHistory() error = %v", err)
+	}
+	if result.NewHead != dryRun.NewHead || result.NewHead == result.OldHead {
+		t.Errorf("result = %+v, dry run = %+v", result, dryRun)
+	}
+
+	history := historyFiles(t, st)
+	if len(history) != 4 {
+		t.Fatalf("history has %d commits, want 4: %v", len(history), history)
+	}
+	for message, files := range history {
+		for _, file := range files {
+			if file != "tech/public.md" {
+				t.Errorf("commit %q still contains %s", message, file)
+			}
+		}
+	}
+
+	// The former history is gone from the repository
+	if st.HasCommit(result.OldHead) || !st.HasCommit(result.NewHead) {
+		t.Errorf("HasCommit() of the former head = %v, of the new head = %v", st.HasCommit(result.OldHead),
+			st.HasCommit(result.NewHead))
+	}
+	for _, secret := range []string{"secret", "secret v2", "image"} {
+		if st.repo.Storer.HasEncodedObject(plumbing.ComputeHash(plumbing.BlobObject, []byte(secret))) == nil {
+			t.Errorf("blob %q is still in the repository", secret)
+		}
+	}
+	if info, err := os.Stat(reflog); err != nil || info.Size() != 0 {
+		t.Errorf("reflog = %v, %v, want an empty reflog", info, err)
+	}
+
+	content, err := st.Read(ctx, "tech/public.md")
+	if err != nil || string(content) != "public v2" {
+		t.Errorf("public.md = %q, %v", content, err)
+	}
+
+	// Purging again changes nothing
+	again, err := st.PurgeHistory(ctx, paths, false)
+	if err != nil || again.Rewritten != 0 || again.NewHead != result.NewHead {
+		t.Errorf("second purge = %+v, %v", again, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -28,6 +29,9 @@ const (
 	// File and directory permissions.
 	dirPerm  = 0750 // Directory permissions: rwxr-x---
 	filePerm = 0600 // File permissions: rw-------
+
+	// readOnlyCommitTrailer marks the commits of a read-only mirror, which are never pushed by ntnsync.
+	readOnlyCommitTrailer = "\n\nRead-Only-Mirror: true"
 )
 
 // LocalStore implements Store using local filesystem and git.
@@ -38,6 +42,16 @@ type LocalStore struct {
 	logger                *slog.Logger
 	remoteConfig          *RemoteConfig
 	createBranchIfMissing bool
+	subdir                string // Subdirectory of the repository holding the mirror, in slash form (empty = root)
+	lockMu                sync.Mutex
+	lock                  *heldLock // Lock file held since the first transaction (nil = not held)
+	lockHolds             int       // Transactions holding the lock file, released when none does anymore
+	lockWait              bool      // Wait for the lock of another process instead of failing
+	remoteLockMu          sync.Mutex
+	remoteLock            *heldRemoteLock // Lock of the branch on the remote (nil = not held)
+	gitMu                 sync.Mutex
+	git                   GitMetrics // Git operations since the store was opened, and last repository measure
+	gitSizedAt            time.Time  // When the repository was last measured (zero = to measure)
 }
 
 // LocalStoreOption configures LocalStore.
@@ -78,16 +92,56 @@ func NewLocalStore(path string, opts ...LocalStoreOption) (*LocalStore, error) {
 		opt(store)
 	}
 
+	if store.remoteConfig != nil {
+		subdir, err := cleanSubdir(store.remoteConfig.Subdir)
+		if err != nil {
+			return nil, err
+		}
+		store.subdir = subdir
+	}
+
 	// Initialize repository (clone from remote or init locally)
 	repo, err := store.initializeRepository(path)
 	if err != nil {
 		return nil, err
 	}
-
 	store.repo = repo
+
+	if store.subdir != "" {
+		if err := os.MkdirAll(store.fullPath("."), dirPerm); err != nil {
+			return nil, fmt.Errorf("create subdirectory %s: %w", store.subdir, err)
+		}
+	}
+
 	return store, nil
 }
 
+// cleanSubdir validates and normalizes the subdirectory of the repository holding the mirror.
+// It must be relative and stay inside the repository.
+func cleanSubdir(subdir string) (string, error) {
+	subdir = strings.Trim(filepath.ToSlash(strings.TrimSpace(subdir)), "/")
+	if subdir == "" {
+		return "", nil
+	}
+
+	cleaned := path.Clean(subdir)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || cleaned == ".git" ||
+		strings.HasPrefix(cleaned, ".git/") {
+		return "", fmt.Errorf("%w: %q", apperrors.ErrInvalidGitSubdir, subdir)
+	}
+	return cleaned, nil
+}
+
+// fullPath returns the filesystem path of a store path, under the mirror's subdirectory.
+func (s *LocalStore) fullPath(name string) string {
+	return filepath.Join(s.rootPath, filepath.FromSlash(s.subdir), name)
+}
+
+// inSubdir returns true if a path relative to the repository root is under the mirror's subdirectory.
+func (s *LocalStore) inSubdir(repoPath string) bool {
+	return s.subdir == "" || repoPath == s.subdir || strings.HasPrefix(repoPath, s.subdir+"/")
+}
+
 // Read reads a file from the store.
 func (s *LocalStore) Read(ctx context.Context, path string) ([]byte, error) {
 	s.mu.RLock()
@@ -95,7 +149,7 @@ func (s *LocalStore) Read(ctx context.Context, path string) ([]byte, error) {
 
 	s.logger.DebugContext(ctx, "reading file", "path", path)
 
-	fullPath := filepath.Join(s.rootPath, path)
+	fullPath := s.fullPath(path)
 	data, err := os.ReadFile(fullPath) //nolint:gosec // path is application controlled
 	if err != nil {
 		s.logger.DebugContext(ctx, "read file failed", "path", path, "error", err)
@@ -113,7 +167,7 @@ func (s *LocalStore) Exists(ctx context.Context, path string) (bool, error) {
 
 	s.logger.DebugContext(ctx, "checking file exists", "path", path)
 
-	fullPath := filepath.Join(s.rootPath, path)
+	fullPath := s.fullPath(path)
 	_, err := os.Stat(fullPath)
 	if err == nil {
 		s.logger.DebugContext(ctx, "file exists", "path", path)
@@ -134,7 +188,7 @@ func (s *LocalStore) List(ctx context.Context, dir string) ([]FileInfo, error) {
 
 	s.logger.DebugContext(ctx, "listing directory", "dir", dir)
 
-	fullPath := filepath.Join(s.rootPath, dir)
+	fullPath := s.fullPath(dir)
 	entries, err := os.ReadDir(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -163,8 +217,12 @@ func (s *LocalStore) List(ctx context.Context, dir string) ([]FileInfo, error) {
 	return files, nil
 }
 
-// BeginTx starts a new transaction.
-func (s *LocalStore) BeginTx(_ context.Context) (Transaction, error) {
+// BeginTx starts a new transaction, after acquiring the lock file of the store if it isn't held yet, so that two
+// processes never write to the same directory. The transaction holds the lock until ReleaseLock.
+func (s *LocalStore) BeginTx(ctx context.Context) (Transaction, error) {
+	if err := s.acquireLock(ctx); err != nil {
+		return nil, err
+	}
 	return &localTransaction{
 		store:         s,
 		modifiedPaths: make(map[string]bool),
@@ -173,7 +231,7 @@ func (s *LocalStore) BeginTx(_ context.Context) (Transaction, error) {
 
 // FS returns an fs.FS view of the store.
 func (s *LocalStore) FS() fs.FS {
-	return os.DirFS(s.rootPath)
+	return os.DirFS(s.fullPath("."))
 }
 
 // Lock acquires the store's write lock for external coordination.
@@ -191,6 +249,11 @@ func (s *LocalStore) IsRemoteEnabled() bool {
 	return s.remoteConfig.IsEnabled()
 }
 
+// isReadOnly returns true if the store runs in read-only mode (NTN_READ_ONLY).
+func (s *LocalStore) isReadOnly() bool {
+	return s.remoteConfig != nil && s.remoteConfig.ReadOnly
+}
+
 // RemoteConfig returns the remote configuration.
 func (s *LocalStore) RemoteConfig() *RemoteConfig {
 	return s.remoteConfig
@@ -205,7 +268,10 @@ func (s *LocalStore) Pull(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.pullLocked(ctx)
+	start := time.Now()
+	err := s.pullLocked(ctx)
+	s.recordGitOp(gitOpPull, start, err)
+	return err
 }
 
 // pullLocked performs the actual pull operation. Caller must hold s.mu.
@@ -295,6 +361,9 @@ func (s *LocalStore) fetchAndMergeLocked(ctx context.Context, auth transport.Aut
 // Push pushes local commits to the remote repository.
 // If a non-fast-forward error occurs, it will attempt to pull first and retry the push.
 func (s *LocalStore) Push(ctx context.Context) error {
+	if s.isReadOnly() {
+		return apperrors.ErrReadOnly
+	}
 	if !s.IsRemoteEnabled() {
 		return nil
 	}
@@ -307,7 +376,68 @@ func (s *LocalStore) Push(ctx context.Context) error {
 		return fmt.Errorf("get auth: %w", err)
 	}
 
-	err = s.pushLocked(ctx, auth)
+	start := time.Now()
+	err = s.pushOrPullAndPushLocked(ctx, auth)
+	s.recordGitOp(gitOpPush, start, err)
+	s.recordPushLocked(ctx, err)
+	return err
+}
+
+// HeadCommit returns the hash of the commit at the head of the mirror branch.
+func (s *LocalStore) HeadCommit() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	head, err := s.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("get head: %w", err)
+	}
+	return head.Hash().String(), nil
+}
+
+// RecentCommits returns the last commits of the mirror branch, newest first, at most limit. A branch without
+// commits has none.
+func (s *LocalStore) RecentCommits(_ context.Context, limit int) ([]CommitInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	head, err := s.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get head: %w", err)
+	}
+
+	iter, err := s.repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, fmt.Errorf("read log: %w", err)
+	}
+	defer iter.Close()
+
+	var commits []CommitInfo
+	for len(commits) < limit {
+		commit, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read log: %w", err)
+		}
+		message, _, _ := strings.Cut(commit.Message, "\n")
+		commits = append(commits, CommitInfo{
+			Hash:    commit.Hash.String(),
+			Message: message,
+			Author:  commit.Author.Name,
+			Time:    commit.Author.When,
+		})
+	}
+	return commits, nil
+}
+
+// pushOrPullAndPushLocked pushes, pulling first and retrying if the push is rejected. Caller must hold s.mu.
+func (s *LocalStore) pushOrPullAndPushLocked(ctx context.Context, auth transport.AuthMethod) error {
+	err := s.pushLocked(ctx, auth)
 	if err == nil {
 		return nil
 	}
@@ -401,7 +531,7 @@ func (t *localTransaction) Write(_ context.Context, path string, content []byte)
 	t.store.mu.Lock()
 	defer t.store.mu.Unlock()
 
-	fullPath := filepath.Join(t.store.rootPath, path)
+	fullPath := t.store.fullPath(path)
 	if err := os.MkdirAll(filepath.Dir(fullPath), dirPerm); err != nil {
 		return fmt.Errorf("create parent dir: %w", err)
 	}
@@ -428,7 +558,7 @@ func (t *localTransaction) WriteStream(_ context.Context, path string, reader io
 	t.store.mu.Lock()
 	defer t.store.mu.Unlock()
 
-	fullPath := filepath.Join(t.store.rootPath, path)
+	fullPath := t.store.fullPath(path)
 	if err := os.MkdirAll(filepath.Dir(fullPath), dirPerm); err != nil {
 		return 0, fmt.Errorf("create parent dir: %w", err)
 	}
@@ -483,7 +613,7 @@ func (t *localTransaction) Delete(_ context.Context, path string) error {
 	t.store.mu.Lock()
 	defer t.store.mu.Unlock()
 
-	fullPath := filepath.Join(t.store.rootPath, path)
+	fullPath := t.store.fullPath(path)
 	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("delete file %s: %w", path, err)
 	}
@@ -504,7 +634,7 @@ func (t *localTransaction) Mkdir(_ context.Context, path string) error {
 	t.store.mu.Lock()
 	defer t.store.mu.Unlock()
 
-	fullPath := filepath.Join(t.store.rootPath, path)
+	fullPath := t.store.fullPath(path)
 	if err := os.MkdirAll(fullPath, dirPerm); err != nil {
 		return fmt.Errorf("create directory %s: %w", path, err)
 	}
@@ -525,25 +655,44 @@ func (t *localTransaction) Commit(_ context.Context, message string) error {
 	t.store.mu.Lock()
 	defer t.store.mu.Unlock()
 
+	// Commits created or failed are recorded, not the ones without changes
+	start := time.Now()
+	committed, err := t.commitLocked(message)
+	if committed || err != nil {
+		t.store.recordGitOp(gitOpCommit, start, err)
+	}
+	return err
+}
+
+// commitLocked stages all changes and creates a git commit if there are any, and returns whether it did.
+// Caller must hold t.mu and t.store.mu.
+func (t *localTransaction) commitLocked(message string) (bool, error) {
 	worktree, err := t.store.repo.Worktree()
 	if err != nil {
-		return fmt.Errorf("get worktree: %w", err)
+		return false, fmt.Errorf("get worktree: %w", err)
 	}
 
-	// Stage all changes in the worktree (equivalent to git add -A)
-	if addErr := worktree.AddWithOptions(&git.AddOptions{All: true}); addErr != nil {
-		return fmt.Errorf("git add: %w", addErr)
+	// Stage all changes in the worktree (equivalent to git add -A), or only under the mirror's subdirectory
+	addOptions := &git.AddOptions{All: true}
+	if t.store.subdir != "" {
+		addOptions = &git.AddOptions{Path: t.store.subdir}
+	}
+	if addErr := worktree.AddWithOptions(addOptions); addErr != nil {
+		return false, fmt.Errorf("git add: %w", addErr)
+	}
+	if err := t.store.unstageRuntimeFilesLocked(); err != nil {
+		return false, err
 	}
 
-	// Check if there are any staged changes
+	// Check if there are any staged changes, ignoring unrelated changes of the repository
 	status, err := worktree.Status()
 	if err != nil {
-		return fmt.Errorf("get status: %w", err)
+		return false, fmt.Errorf("get status: %w", err)
 	}
 
 	hasChanges := false
-	for _, s := range status {
-		if s.Staging != ' ' {
+	for file, s := range status {
+		if s.Staging != ' ' && s.Staging != git.Untracked && t.store.inSubdir(file) {
 			hasChanges = true
 			break
 		}
@@ -552,7 +701,7 @@ func (t *localTransaction) Commit(_ context.Context, message string) error {
 	if !hasChanges {
 		// Clear modified paths since there's nothing to commit
 		t.modifiedPaths = make(map[string]bool)
-		return nil
+		return false, nil
 	}
 
 	// Determine author from remote config or use defaults
@@ -564,6 +713,9 @@ func (t *localTransaction) Commit(_ context.Context, message string) error {
 	}
 
 	// Create commit
+	if t.store.isReadOnly() {
+		message += readOnlyCommitTrailer
+	}
 	_, err = worktree.Commit(message, &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  authorName,
@@ -572,12 +724,12 @@ func (t *localTransaction) Commit(_ context.Context, message string) error {
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("commit: %w", err)
+		return false, fmt.Errorf("commit: %w", err)
 	}
 
 	// Clear modified paths after successful commit
 	t.modifiedPaths = make(map[string]bool)
-	return nil
+	return true, nil
 }
 
 // Rollback discards all uncommitted changes and closes the transaction.
@@ -599,7 +751,14 @@ func (t *localTransaction) Rollback(_ context.Context) error {
 	}
 
 	// Only reset if there are changes
-	if len(t.modifiedPaths) > 0 {
+	switch {
+	case len(t.modifiedPaths) == 0:
+	case t.store.subdir != "":
+		// Other changes of the repository are not ours to discard
+		if err := t.store.restoreLocked(t.modifiedPaths); err != nil {
+			return err
+		}
+	default:
 		if err := worktree.Reset(&git.ResetOptions{Mode: git.HardReset}); err != nil {
 			return fmt.Errorf("reset worktree: %w", err)
 		}
@@ -610,6 +769,47 @@ func (t *localTransaction) Rollback(_ context.Context) error {
 	return nil
 }
 
+// restoreLocked restores the given store paths to their content at HEAD, deleting the ones HEAD doesn't have.
+// Caller must hold s.mu.
+func (s *LocalStore) restoreLocked(paths map[string]bool) error {
+	var tree *object.Tree
+	if head, err := s.repo.Head(); err == nil {
+		commit, err := s.repo.CommitObject(head.Hash())
+		if err != nil {
+			return fmt.Errorf("get HEAD commit: %w", err)
+		}
+		if tree, err = commit.Tree(); err != nil {
+			return fmt.Errorf("get HEAD tree: %w", err)
+		}
+	}
+
+	for name := range paths {
+		fullPath := s.fullPath(name)
+		var file *object.File
+		if tree != nil {
+			file, _ = tree.File(path.Join(s.subdir, filepath.ToSlash(name)))
+		}
+		if file == nil {
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("delete file %s: %w", name, err)
+			}
+			continue
+		}
+
+		content, err := file.Contents()
+		if err != nil {
+			return fmt.Errorf("read %s at HEAD: %w", name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), dirPerm); err != nil {
+			return fmt.Errorf("create parent dir: %w", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), filePerm); err != nil {
+			return fmt.Errorf("restore file %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // initializeRepository initializes a git repository, either by cloning from remote or creating locally.
 func (s *LocalStore) initializeRepository(path string) (*git.Repository, error) {
 	_, statErr := os.Stat(path)
@@ -3,10 +3,13 @@ package store
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
 )
 
 // setupWriteStreamTest creates an isolated test environment with its own tmpDir and transaction.
@@ -160,3 +163,235 @@ func testWriteStreamAtomic(ctx context.Context, t *testing.T, tx Transaction, tm
 		}
 	}
 }
+
+func TestCleanSubdir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "docs/notion", want: "docs/notion"},
+		{input: "/docs/notion/", want: "docs/notion"},
+		{input: "docs/./notion", want: "docs/notion"},
+		{input: "..", wantErr: true},
+		{input: "docs/../../other", wantErr: true},
+		{input: ".git/hooks", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := cleanSubdir(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("cleanSubdir(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLocalStore_Subdir(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	store, err := NewLocalStore(tmpDir, WithRemoteConfig(&RemoteConfig{
+		Storage: StorageModeLocal,
+		Subdir:  "docs/notion",
+	}))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	// A file of the monorepo, unrelated to the mirror
+	unrelated := filepath.Join(tmpDir, "README.md")
+	if err := os.WriteFile(unrelated, []byte("monorepo"), 0o600); err != nil {
+		t.Fatalf("failed to write unrelated file: %v", err)
+	}
+
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if err := tx.Write(ctx, "root.md", []byte("root")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "docs", "notion", "root.md")); err != nil {
+		t.Fatalf("expected root.md under the subdirectory: %v", err)
+	}
+	if data, err := store.Read(ctx, "root.md"); err != nil || string(data) != "root" {
+		t.Fatalf("Read() = %q, %v; want root", data, err)
+	}
+
+	if err := tx.Commit(ctx, "first"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	head, err := store.repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	commit, err := store.repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("failed to get tree: %v", err)
+	}
+	if _, err := tree.File("docs/notion/root.md"); err != nil {
+		t.Errorf("expected docs/notion/root.md to be committed: %v", err)
+	}
+	if _, err := tree.File("README.md"); err == nil {
+		t.Error("unrelated README.md must not be committed")
+	}
+
+	// Unrelated changes alone don't make a commit
+	if err := tx.Commit(ctx, "second"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if newHead, _ := store.repo.Head(); newHead.Hash() != head.Hash() {
+		t.Error("expected no commit for unrelated changes")
+	}
+
+	// Rollback only restores the mirror's files
+	if err := tx.Write(ctx, "root.md", []byte("changed")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := tx.Write(ctx, "new.md", []byte("new")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if data, err := store.Read(ctx, "root.md"); err != nil || string(data) != "root" {
+		t.Errorf("after rollback, root.md = %q, %v; want root", data, err)
+	}
+	if exists, _ := store.Exists(ctx, "new.md"); exists {
+		t.Error("expected new.md to be deleted by rollback")
+	}
+	if data, err := os.ReadFile(unrelated); err != nil || string(data) != "monorepo" {
+		t.Errorf("unrelated file = %q, %v; want it untouched", data, err)
+	}
+}
+
+func TestLocalStore_HeadCommit(t *testing.T) {
+	t.Parallel()
+
+	ctx, store, tx, _ := setupWriteStreamTest(t)
+
+	if err := tx.Write(ctx, "a.md", []byte("a")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := tx.Commit(ctx, "first"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	head, err := store.HeadCommit()
+	if err != nil {
+		t.Fatalf("HeadCommit() error = %v", err)
+	}
+	if len(head) != 40 {
+		t.Errorf("HeadCommit() = %q, want a commit hash", head)
+	}
+}
+
+func TestLocalStore_RecentCommits(t *testing.T) {
+	t.Parallel()
+
+	ctx, store, tx, _ := setupWriteStreamTest(t)
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := tx.Write(ctx, name+".md", []byte(name)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := tx.Commit(ctx, "add "+name+"\n\ndetails"); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+
+	commits, err := store.RecentCommits(ctx, 2)
+	if err != nil {
+		t.Fatalf("RecentCommits() error = %v", err)
+	}
+	if len(commits) != 2 || commits[0].Message != "add c" || commits[1].Message != "add b" {
+		t.Fatalf("RecentCommits() = %+v, want the last 2 commits, newest first", commits)
+	}
+	head, _ := store.HeadCommit()
+	if commits[0].Hash != head || commits[0].Time.IsZero() {
+		t.Errorf("RecentCommits()[0] = %+v, want the head commit %s", commits[0], head)
+	}
+}
+
+func TestLocalStore_GitMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx, store, tx, _ := setupWriteStreamTest(t)
+
+	if err := tx.Write(ctx, "a.md", []byte("a")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := tx.Commit(ctx, "add a"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	// Commits without changes are not counted
+	if err := tx.Commit(ctx, "nothing"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	metrics, err := store.GitMetrics()
+	if err != nil {
+		t.Fatalf("GitMetrics() error = %v", err)
+	}
+	if metrics.Commit.Count != 1 || metrics.Commit.Failures != 0 || metrics.Push.Count != 0 {
+		t.Errorf("GitMetrics() = %+v, want 1 commit", metrics)
+	}
+	if metrics.RepoSize == 0 || metrics.LooseObjects == 0 {
+		t.Errorf("GitMetrics() = %+v, want the size of the repository and its loose objects", metrics)
+	}
+}
+
+func TestLocalStore_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir(), WithRemoteConfig(&RemoteConfig{ReadOnly: true}))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if err := tx.Write(ctx, "a.md", []byte("a")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := tx.Commit(ctx, "sync"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	head, err := store.repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	commit, err := store.repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	if commit.Message != "sync"+readOnlyCommitTrailer {
+		t.Errorf("commit message = %q, want the read-only trailer", commit.Message)
+	}
+
+	if err := store.Push(ctx); !errors.Is(err, apperrors.ErrReadOnly) {
+		t.Errorf("Push() error = %v, want ErrReadOnly", err)
+	}
+	if err := store.ForcePush(ctx); !errors.Is(err, apperrors.ErrReadOnly) {
+		t.Errorf("ForcePush() error = %v, want ErrReadOnly", err)
+	}
+
+	push := true
+	conflicting := &RemoteConfig{ReadOnly: true, Push: &push}
+	if err := conflicting.CheckReadOnly(); !errors.Is(err, apperrors.ErrReadOnlyConflict) {
+		t.Errorf("CheckReadOnly() error = %v, want ErrReadOnlyConflict", err)
+	}
+	if (&RemoteConfig{ReadOnly: true, URL: "https://example.com/repo.git"}).IsPushEnabled() {
+		t.Error("IsPushEnabled() = true in read-only mode")
+	}
+}
@@ -0,0 +1,346 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// LockFile prevents two processes from writing to the same store directory. It is a runtime file.
+const LockFile = ".notion-sync/lock"
+
+const (
+	// lockRefreshInterval is the time between two refreshes of the lock file by the process holding it.
+	lockRefreshInterval = 30 * time.Second
+	// lockStaleAfter is the age after which a lock file that wasn't refreshed is considered left by a dead process.
+	lockStaleAfter = 2 * time.Minute
+	// lockPollInterval is the time between two attempts to acquire a lock held by another process, with lock wait.
+	lockPollInterval = time.Second
+)
+
+// LockInfo is the content of the lock file.
+type LockInfo struct {
+	PID        int       `json:"pid"`
+	Hostname   string    `json:"hostname,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	UpdatedAt  time.Time `json:"updated_at"` // Refreshed while the lock is held
+}
+
+// LockReleaser is implemented by stores holding a lock on their directory. Each transaction holds the lock from
+// BeginTx until it is released: the lock is only released when no transaction holds it anymore, so that concurrent
+// writers of the same process (webhook events and sync runs) don't release it under each other.
+type LockReleaser interface {
+	// ReleaseLock releases the hold of a transaction on the lock. Releasing a lock that isn't held is not an error.
+	ReleaseLock() error
+	// ReleaseAllLocks releases the lock whatever the transactions holding it, when the process is done writing.
+	ReleaseAllLocks() error
+}
+
+// heldLock is the lock file held by the store.
+type heldLock struct {
+	info LockInfo
+	stop chan struct{}
+	done chan struct{}
+}
+
+// isOwnedBy returns true if the lock was acquired by the process.
+func (l *LockInfo) isOwnedBy(pid int, hostname string) bool {
+	return l.PID == pid && l.Hostname == hostname
+}
+
+// isStale returns true if the lock wasn't refreshed recently: its process died without releasing it.
+func (l *LockInfo) isStale(now time.Time) bool {
+	return now.Sub(l.UpdatedAt) > lockStaleAfter
+}
+
+// describe returns the holder of the lock, for error messages.
+func (l *LockInfo) describe() string {
+	if l == nil {
+		return "an unreadable lock file"
+	}
+	return fmt.Sprintf("%s (pid %d on %s) since %s", l.Command, l.PID, l.Hostname,
+		l.AcquiredAt.Format(time.RFC3339))
+}
+
+// WithLockWait makes transactions wait for the lock of another process to be released, instead of failing.
+func WithLockWait(wait bool) LocalStoreOption {
+	return func(s *LocalStore) {
+		s.lockWait = wait
+	}
+}
+
+// acquireLock adds the hold of a transaction on the lock file of the store, acquiring the lock file if no
+// transaction holds it: before the first transaction, and again after it was released.
+func (s *LocalStore) acquireLock(ctx context.Context) error {
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+
+	if s.lock == nil {
+		if err := s.acquireLockFileLocked(ctx); err != nil {
+			return err
+		}
+	}
+	s.lockHolds++
+	return nil
+}
+
+// acquireLockFileLocked acquires the lock file. It fails with ErrStoreLocked if another process holds it, unless
+// lock wait is enabled. Stale locks are taken over. Caller must hold s.lockMu.
+func (s *LocalStore) acquireLockFileLocked(ctx context.Context) error {
+	hostname, _ := os.Hostname()
+	lockPath := s.fullPath(LockFile)
+	for {
+		now := time.Now().UTC()
+		info := LockInfo{
+			PID:        os.Getpid(),
+			Hostname:   hostname,
+			Command:    filepath.Base(os.Args[0]),
+			AcquiredAt: now,
+			UpdatedAt:  now,
+		}
+		created, err := createLockFile(lockPath, &info)
+		if err != nil {
+			return err
+		}
+		if created {
+			s.lock = &heldLock{info: info, stop: make(chan struct{}), done: make(chan struct{})}
+			go s.refreshLock(s.lock, lockPath)
+			s.logger.DebugContext(ctx, "store lock acquired", "path", lockPath)
+			return nil
+		}
+
+		data, modTime, err := readLockData(lockPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // Released in the meantime
+		}
+		if err != nil {
+			return err
+		}
+		// An unreadable lock (corrupted, or written by hand) is held until it gets as old as a stale one
+		holder, parseErr := parseLockInfo(data)
+		switch {
+		case parseErr == nil && holder.isOwnedBy(info.PID, hostname):
+			// Held by another store of this process on the same directory: they don't race with each other
+			s.lock = &heldLock{info: *holder, stop: make(chan struct{}), done: make(chan struct{})}
+			go s.refreshLock(s.lock, lockPath)
+			return nil
+		case (parseErr == nil && holder.isStale(now)) || (parseErr != nil && now.Sub(modTime) > lockStaleAfter):
+			// Left by a dead process: it can be taken over
+			s.logger.WarnContext(ctx, "taking over stale store lock", "path", lockPath, "holder", holder)
+			if err := takeOverLock(lockPath, data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		lockedErr := fmt.Errorf("%w: by %s", apperrors.ErrStoreLocked, holder.describe())
+		if !s.lockWait {
+			return lockedErr
+		}
+		s.logger.InfoContext(ctx, "waiting for the store lock", "error", lockedErr)
+
+		timer := time.NewTimer(lockPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("wait for store lock: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// createLockFile creates the lock file if it doesn't exist. It returns false if another process created it.
+// The lock is written to a temporary file, then linked in place, so that other processes never read a partial lock.
+func createLockFile(lockPath string, info *LockInfo) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(lockPath), dirPerm); err != nil {
+		return false, fmt.Errorf("create lock dir: %w", err)
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return false, fmt.Errorf("marshal lock: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(lockPath), filepath.Base(lockPath)+".*.tmp")
+	if err != nil {
+		return false, fmt.Errorf("create lock: %w", err)
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return false, fmt.Errorf("write lock: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return false, fmt.Errorf("close lock: %w", err)
+	}
+
+	// Unlike a rename, a link fails if the lock exists
+	if err := os.Link(file.Name(), lockPath); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("link lock: %w", err)
+	}
+	return true, nil
+}
+
+// takeOverLock removes a stale lock file, unless another process replaced it since its content was read: the lock
+// is moved aside first, and linked back if its content changed meanwhile.
+func takeOverLock(lockPath string, stale []byte) error {
+	asidePath := fmt.Sprintf("%s.%d-%d.stale", lockPath, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockPath, asidePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil // Taken over by another process
+		}
+		return fmt.Errorf("move stale lock: %w", err)
+	}
+	if data, err := os.ReadFile(asidePath); err == nil && !bytes.Equal(data, stale) { //nolint:gosec // Store path
+		// Refreshed or taken over by another process since it was read: it is not stale
+		_ = os.Link(asidePath, lockPath)
+	}
+	if err := os.Remove(asidePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove stale lock: %w", err)
+	}
+	return nil
+}
+
+// readLockData reads the content and the modification time of the lock file.
+func readLockData(lockPath string) ([]byte, time.Time, error) {
+	data, err := os.ReadFile(lockPath) //nolint:gosec // Store path
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("read lock: %w", err)
+	}
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("stat lock: %w", err)
+	}
+	return data, info.ModTime(), nil
+}
+
+// parseLockInfo parses the content of the lock file.
+func parseLockInfo(data []byte) (*LockInfo, error) {
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("unmarshal lock: %w", err)
+	}
+	return &info, nil
+}
+
+// readLockFile reads the lock file.
+func readLockFile(lockPath string) (*LockInfo, error) {
+	data, _, err := readLockData(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	return parseLockInfo(data)
+}
+
+// refreshLock updates the lock file periodically until the lock is released, so that other processes don't take
+// it over as stale.
+func (s *LocalStore) refreshLock(lock *heldLock, lockPath string) {
+	defer close(lock.done)
+
+	ticker := time.NewTicker(lockRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lock.stop:
+			return
+		case <-ticker.C:
+		}
+
+		holder, err := readLockFile(lockPath)
+		if err != nil || !holder.isOwnedBy(lock.info.PID, lock.info.Hostname) {
+			s.logger.Error("store lock was taken over by another process", "path", lockPath, "holder", holder)
+			return
+		}
+		info := lock.info
+		info.UpdatedAt = time.Now().UTC()
+		data, err := json.Marshal(&info)
+		if err != nil {
+			continue
+		}
+		tmpPath := lockPath + ".tmp"
+		if err := os.WriteFile(tmpPath, data, filePerm); err != nil {
+			s.logger.Warn("failed to refresh store lock", "path", lockPath, "error", err)
+			continue
+		}
+		if err := os.Rename(tmpPath, lockPath); err != nil {
+			_ = os.Remove(tmpPath)
+			s.logger.Warn("failed to refresh store lock", "path", lockPath, "error", err)
+		}
+	}
+}
+
+// ReleaseLock releases the hold of a transaction on the lock file. When no other transaction holds it, the lock file
+// and the lock of the branch on the remote are released.
+func (s *LocalStore) ReleaseLock() error {
+	return s.releaseLocks(false)
+}
+
+// ReleaseAllLocks releases the lock file and the lock of the branch on the remote, if they are held, whatever the
+// transactions holding them.
+func (s *LocalStore) ReleaseAllLocks() error {
+	return s.releaseLocks(true)
+}
+
+// releaseLocks releases the hold of a transaction (or all of them) on the lock file, then the locks if no
+// transaction holds them anymore. The locks are released with s.lockMu held, so that a transaction beginning
+// meanwhile acquires them again once released.
+func (s *LocalStore) releaseLocks(all bool) error {
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+
+	if s.lockHolds > 1 && !all {
+		s.lockHolds--
+		return nil // Still held by other transactions
+	}
+	s.lockHolds = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteLockReleaseTimeout)
+	defer cancel()
+	return errors.Join(s.ReleaseRemoteLock(ctx), s.releaseLockFileLocked())
+}
+
+// releaseLockFileLocked stops refreshing the lock file and removes it, if it is still held by this process.
+// Caller must hold s.lockMu.
+func (s *LocalStore) releaseLockFileLocked() error {
+	if s.lock == nil {
+		return nil
+	}
+	close(s.lock.stop)
+	<-s.lock.done
+	info := s.lock.info
+	s.lock = nil
+
+	lockPath := s.fullPath(LockFile)
+	holder, err := readLockFile(lockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil || !holder.isOwnedBy(info.PID, info.Hostname) {
+		return nil // Taken over by another process, which now owns it
+	}
+	if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove lock: %w", err)
+	}
+	return nil
+}
+
+// ReleaseLock releases the hold of a transaction on the locks of the content and queue stores.
+func (s *SplitStore) ReleaseLock() error {
+	return errors.Join(s.contentStore.ReleaseLock(), s.queueStore.ReleaseLock())
+}
+
+// ReleaseAllLocks releases the locks of the content and queue stores.
+func (s *SplitStore) ReleaseAllLocks() error {
+	return errors.Join(s.contentStore.ReleaseAllLocks(), s.queueStore.ReleaseAllLocks())
+}
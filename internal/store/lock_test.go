@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// writeForeignLock writes a lock file held by another process, last refreshed at updatedAt.
+func writeForeignLock(t *testing.T, st *LocalStore, updatedAt time.Time) {
+	t.Helper()
+
+	data, err := json.Marshal(&LockInfo{PID: os.Getpid() + 1, Hostname: "other-host", Command: "ntnsync",
+		AcquiredAt: updatedAt, UpdatedAt: updatedAt})
+	if err != nil {
+		t.Fatalf("marshal lock: %v", err)
+	}
+	if err := os.MkdirAll(st.fullPath(".notion-sync"), dirPerm); err != nil {
+		t.Fatalf("create state dir: %v", err)
+	}
+	if err := os.WriteFile(st.fullPath(LockFile), data, filePerm); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+}
+
+func TestLocalStore_Lock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	// Held by another process
+	writeForeignLock(t, st, time.Now())
+	if _, err := st.BeginTx(ctx); !errors.Is(err, apperrors.ErrStoreLocked) {
+		t.Fatalf("BeginTx() error = %v, want ErrStoreLocked", err)
+	}
+
+	// Left by a dead process
+	writeForeignLock(t, st, time.Now().Add(-lockStaleAfter-time.Minute))
+	tx, err := st.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx() with a stale lock error = %v", err)
+	}
+	holder, err := readLockFile(st.fullPath(LockFile))
+	if err != nil || holder.PID != os.Getpid() {
+		t.Fatalf("lock holder = %+v, %v, want this process", holder, err)
+	}
+
+	// The lock is never committed
+	if err := tx.Write(ctx, "a.md", []byte("a")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := tx.Commit(ctx, "sync"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	head, err := st.repo.Head()
+	if err != nil {
+		t.Fatalf("failed to get HEAD: %v", err)
+	}
+	commit, err := st.repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to get commit: %v", err)
+	}
+	if _, err := commit.File(LockFile); err == nil {
+		t.Error("lock file should not be committed")
+	}
+
+	if err := st.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	if _, err := os.Stat(st.fullPath(LockFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file should be removed, stat error = %v", err)
+	}
+}
+
+func TestLocalStore_LockHolds(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	lockPath := st.fullPath(LockFile)
+	beginTx := func() {
+		t.Helper()
+		if _, err := st.BeginTx(ctx); err != nil {
+			t.Fatalf("BeginTx() error = %v", err)
+		}
+	}
+	assertLocked := func(when string, want bool) {
+		t.Helper()
+		_, err := os.Stat(lockPath)
+		if locked := err == nil; locked != want {
+			t.Errorf("lock %s: stat error = %v, want locked = %v", when, err, want)
+		}
+	}
+
+	// Held until every transaction released it
+	beginTx()
+	beginTx()
+	if err := st.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	assertLocked("with a transaction left", true)
+	if err := st.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+	assertLocked("without transactions", false)
+
+	// Acquired again by the next transaction
+	beginTx()
+	assertLocked("after a new transaction", true)
+
+	beginTx()
+	if err := st.ReleaseAllLocks(); err != nil {
+		t.Fatalf("ReleaseAllLocks() error = %v", err)
+	}
+	assertLocked("after releasing all", false)
+	if err := st.ReleaseLock(); err != nil {
+		t.Errorf("ReleaseLock() of a released lock error = %v", err)
+	}
+}
+
+func TestLocalStore_LockWait(t *testing.T) {
+	t.Parallel()
+
+	st, err := NewLocalStore(t.TempDir(), WithLockWait(true))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	writeForeignLock(t, st, time.Now())
+
+	// Waiting stops with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := st.BeginTx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("BeginTx() error = %v, want DeadlineExceeded", err)
+	}
+
+	// The lock is acquired once released by the other process
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = os.Remove(st.fullPath(LockFile))
+	}()
+	if _, err := st.BeginTx(context.Background()); err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if err := st.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+}
+
+func TestLocalStore_LockUnreadable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	lockPath := st.fullPath(LockFile)
+	if err := os.MkdirAll(filepath.Dir(lockPath), dirPerm); err != nil {
+		t.Fatalf("create state dir: %v", err)
+	}
+	if err := os.WriteFile(lockPath, []byte("{"), filePerm); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	// Held until it gets as old as a stale lock
+	if _, err := st.BeginTx(ctx); !errors.Is(err, apperrors.ErrStoreLocked) {
+		t.Fatalf("BeginTx() with a recent unreadable lock error = %v, want ErrStoreLocked", err)
+	}
+	old := time.Now().Add(-lockStaleAfter - time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatalf("failed to age lock: %v", err)
+	}
+	if _, err := st.BeginTx(ctx); err != nil {
+		t.Fatalf("BeginTx() with a stale unreadable lock error = %v", err)
+	}
+
+	// Only the lock is left in its directory: temporary files are removed
+	entries, err := os.ReadDir(filepath.Dir(lockPath))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "lock.") {
+			t.Errorf("temporary lock file %s was left", entry.Name())
+		}
+	}
+	if err := st.ReleaseLock(); err != nil {
+		t.Fatalf("ReleaseLock() error = %v", err)
+	}
+}
+
+func TestTakeOverLock(t *testing.T) {
+	t.Parallel()
+
+	lockPath := filepath.Join(t.TempDir(), "lock")
+	if err := os.WriteFile(lockPath, []byte("fresh"), filePerm); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	// Another process took the lock over since the stale one was read: its lock is kept
+	if err := takeOverLock(lockPath, []byte("stale")); err != nil {
+		t.Fatalf("takeOverLock() error = %v", err)
+	}
+	if data, err := os.ReadFile(lockPath); err != nil || string(data) != "fresh" {
+		t.Fatalf("lock = %q, %v, want the lock of the other process", data, err)
+	}
+
+	if err := takeOverLock(lockPath, []byte("fresh")); err != nil {
+		t.Fatalf("takeOverLock() error = %v", err)
+	}
+	if _, err := os.Stat(lockPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("stale lock should be removed, stat error = %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(lockPath)); len(entries) != 0 {
+		t.Errorf("files left = %v, want none", entries)
+	}
+}
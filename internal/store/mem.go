@@ -0,0 +1,287 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// memFile is a file held by a MemStore.
+type memFile struct {
+	content []byte
+	modTime time.Time
+}
+
+// MemStore implements Store fully in memory, without git.
+// Commits only snapshot the files so that a rollback can restore them, and push does nothing.
+// It is meant for tests and ephemeral runs that must not touch the disk.
+type MemStore struct {
+	mu        sync.RWMutex
+	files     map[string]memFile
+	dirs      map[string]bool
+	committed map[string]memFile // Files as of the last commit, restored on rollback
+	commitDir map[string]bool    // Directories as of the last commit
+	commits   []string
+}
+
+// NewMemStore creates an empty in-memory store.
+func NewMemStore() *MemStore {
+	return &MemStore{
+		files:     make(map[string]memFile),
+		dirs:      make(map[string]bool),
+		committed: make(map[string]memFile),
+		commitDir: make(map[string]bool),
+	}
+}
+
+// memPath normalizes a store path to a slash-separated relative path.
+func memPath(p string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(p), "/"))
+}
+
+// Read reads a file from the store.
+func (s *MemStore) Read(_ context.Context, p string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	file, ok := s.files[memPath(p)]
+	if !ok {
+		return nil, fmt.Errorf("read file %s: %w", p, fs.ErrNotExist)
+	}
+	return slices.Clone(file.content), nil
+}
+
+// Exists checks if a file or directory exists.
+func (s *MemStore) Exists(_ context.Context, p string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p = memPath(p)
+	_, ok := s.files[p]
+	return ok || s.dirs[p], nil
+}
+
+// List lists files in a directory. It returns nothing if the directory does not exist.
+func (s *MemStore) List(_ context.Context, dir string) ([]FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cleanDir := memPath(dir)
+	var files []FileInfo
+	for p, file := range s.files {
+		if path.Dir(p) == cleanDir {
+			files = append(files, FileInfo{
+				Path:    filepath.Join(dir, path.Base(p)),
+				Size:    int64(len(file.content)),
+				ModTime: file.modTime,
+			})
+		}
+	}
+	for p := range s.dirs {
+		if p != cleanDir && path.Dir(p) == cleanDir {
+			files = append(files, FileInfo{Path: filepath.Join(dir, path.Base(p)), IsDir: true})
+		}
+	}
+
+	slices.SortFunc(files, func(a, b FileInfo) int { return strings.Compare(a.Path, b.Path) })
+	return files, nil
+}
+
+// BeginTx starts a new transaction.
+func (s *MemStore) BeginTx(_ context.Context) (Transaction, error) {
+	return &memTransaction{store: s}, nil
+}
+
+// Push does nothing, as an in-memory store has no remote.
+func (s *MemStore) Push(_ context.Context) error {
+	return nil
+}
+
+// FS returns a read-only snapshot of the store.
+func (s *MemStore) FS() fs.FS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fsys := make(fstest.MapFS, len(s.files))
+	for p, file := range s.files {
+		fsys[p] = &fstest.MapFile{Data: slices.Clone(file.content), Mode: filePerm, ModTime: file.modTime}
+	}
+	return fsys
+}
+
+// Lock acquires the store's write lock for external coordination.
+func (s *MemStore) Lock() {
+	s.mu.Lock()
+}
+
+// Unlock releases the store's write lock.
+func (s *MemStore) Unlock() {
+	s.mu.Unlock()
+}
+
+// Commits returns the messages of the commits made in the store, oldest first.
+func (s *MemStore) Commits() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.commits)
+}
+
+// RecentCommits returns the last commits made in the store, newest first, at most limit. They only have a message.
+func (s *MemStore) RecentCommits(_ context.Context, limit int) ([]CommitInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var commits []CommitInfo
+	for i := len(s.commits) - 1; i >= 0 && len(commits) < limit; i-- {
+		commits = append(commits, CommitInfo{Message: s.commits[i]})
+	}
+	return commits, nil
+}
+
+// addDirsLocked records a path's parent directories. Caller must hold s.mu.
+func (s *MemStore) addDirsLocked(p string) {
+	for dir := path.Dir(p); dir != "." && !s.dirs[dir]; dir = path.Dir(dir) {
+		s.dirs[dir] = true
+	}
+}
+
+// memTransaction implements Transaction on a MemStore.
+// Writes are applied immediately, commits snapshot the files.
+type memTransaction struct {
+	store  *MemStore
+	mu     sync.Mutex
+	dirty  bool
+	closed bool
+}
+
+// Write writes content to a file immediately.
+func (t *memTransaction) Write(_ context.Context, p string, content []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return apperrors.ErrTransactionCommitted
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	p = memPath(p)
+	t.store.files[p] = memFile{content: slices.Clone(content), modTime: time.Now()}
+	t.store.addDirsLocked(p)
+	t.dirty = true
+	return nil
+}
+
+// WriteStream writes content from a reader to a file.
+// Returns the number of bytes written.
+func (t *memTransaction) WriteStream(ctx context.Context, p string, reader io.Reader) (int64, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return int64(len(content)), fmt.Errorf("write content: %w", err)
+	}
+	if err := t.Write(ctx, p, content); err != nil {
+		return 0, err
+	}
+	return int64(len(content)), nil
+}
+
+// Delete deletes a file immediately. Deleting a missing file is not an error.
+func (t *memTransaction) Delete(_ context.Context, p string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return apperrors.ErrTransactionCommitted
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	delete(t.store.files, memPath(p))
+	t.dirty = true
+	return nil
+}
+
+// Mkdir creates a directory.
+func (t *memTransaction) Mkdir(_ context.Context, p string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return apperrors.ErrTransactionCommitted
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	p = memPath(p)
+	if p != "." {
+		t.store.dirs[p] = true
+		t.store.addDirsLocked(p)
+	}
+	return nil
+}
+
+// Commit snapshots the files so that a later rollback restores them.
+// After commit, the transaction can continue to be used for more changes.
+func (t *memTransaction) Commit(_ context.Context, message string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return apperrors.ErrTransactionCommitted
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if !t.dirty || maps.EqualFunc(t.store.files, t.store.committed, memFileEqual) {
+		t.dirty = false
+		return nil
+	}
+
+	t.store.committed = maps.Clone(t.store.files)
+	t.store.commitDir = maps.Clone(t.store.dirs)
+	t.store.commits = append(t.store.commits, message)
+	t.dirty = false
+	return nil
+}
+
+// Rollback restores the files of the last commit and closes the transaction.
+func (t *memTransaction) Rollback(_ context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	if t.dirty {
+		t.store.files = maps.Clone(t.store.committed)
+		t.store.dirs = maps.Clone(t.store.commitDir)
+	}
+
+	t.closed = true
+	return nil
+}
+
+// memFileEqual reports whether two files have the same content.
+func memFileEqual(a, b memFile) bool {
+	return string(a.content) == string(b.content)
+}
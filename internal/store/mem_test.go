@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMemStore_WriteReadList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewMemStore()
+	tx, err := st.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+
+	if err := tx.Write(ctx, "tech/page.md", []byte("# Page")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := tx.WriteStream(ctx, "tech/sub/file.png", strings.NewReader("png")); err != nil {
+		t.Fatalf("WriteStream() error = %v", err)
+	}
+
+	data, err := st.Read(ctx, "tech/page.md")
+	if err != nil || string(data) != "# Page" {
+		t.Errorf("Read() = %q, %v, want %q", data, err, "# Page")
+	}
+	if _, err := st.Read(ctx, "missing.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read() of a missing file error = %v, want fs.ErrNotExist", err)
+	}
+	if exists, _ := st.Exists(ctx, "tech/sub"); !exists {
+		t.Error("Exists() of a parent directory = false, want true")
+	}
+
+	files, err := st.List(ctx, "tech")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "tech/page.md" || files[0].Size != 6 ||
+		files[1].Path != "tech/sub" || !files[1].IsDir {
+		t.Errorf("List() = %+v, want page.md and the sub directory", files)
+	}
+	if files, _ := st.List(ctx, "missing"); len(files) != 0 {
+		t.Errorf("List() of a missing directory = %+v, want nothing", files)
+	}
+
+	if _, err := fs.Stat(st.FS(), "tech/sub/file.png"); err != nil {
+		t.Errorf("FS() should expose written files: %v", err)
+	}
+}
+
+func TestMemStore_CommitAndRollback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	st := NewMemStore()
+	tx, _ := st.BeginTx(ctx)
+
+	_ = tx.Write(ctx, "a.md", []byte("a"))
+	if err := tx.Commit(ctx, "first"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := tx.Commit(ctx, "nothing changed"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	_ = tx.Write(ctx, "b.md", []byte("b"))
+	_ = tx.Delete(ctx, "a.md")
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	if exists, _ := st.Exists(ctx, "a.md"); !exists {
+		t.Error("rollback should restore committed files")
+	}
+	if exists, _ := st.Exists(ctx, "b.md"); exists {
+		t.Error("rollback should discard uncommitted files")
+	}
+	if got := st.Commits(); !slices.Equal(got, []string{"first"}) {
+		t.Errorf("Commits() = %v, want only the commit with changes", got)
+	}
+	if err := tx.Write(ctx, "c.md", nil); err == nil {
+		t.Error("Write() after rollback should fail")
+	}
+}
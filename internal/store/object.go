@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// ObjectInfo describes an object of an object storage.
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ObjectListing is the content of an object storage under a prefix, one level deep.
+type ObjectListing struct {
+	Objects  []ObjectInfo // Objects directly under the prefix
+	Prefixes []string     // Deeper prefixes, ending with "/" (the "directories")
+}
+
+// ObjectStorage is a flat key-value object storage, like an S3 bucket.
+// Missing objects are reported with errors wrapping fs.ErrNotExist.
+type ObjectStorage interface {
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	StatObject(ctx context.Context, key string) (ObjectInfo, error)
+	PutObject(ctx context.Context, key string, content []byte) error
+	DeleteObject(ctx context.Context, key string) error
+	// ListObjects lists the objects and deeper prefixes under prefix, using "/" as delimiter.
+	ListObjects(ctx context.Context, prefix string) (ObjectListing, error)
+}
+
+// ObjectStore implements Store on an object storage, without git: the files are objects, under an optional key
+// prefix. Writes are final: commits do nothing, rollbacks only close the transaction, and push does nothing.
+type ObjectStore struct {
+	storage ObjectStorage
+	prefix  string
+	mu      sync.Mutex
+}
+
+// NewObjectStore creates a store of the objects of storage under prefix (e.g. "mirror/", empty for all).
+func NewObjectStore(storage ObjectStorage, prefix string) *ObjectStore {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &ObjectStore{storage: storage, prefix: prefix}
+}
+
+// objectKey returns the key of a store path.
+func (s *ObjectStore) objectKey(p string) string {
+	p = memPath(p)
+	if p == "." {
+		return strings.TrimSuffix(s.prefix, "/")
+	}
+	return s.prefix + p
+}
+
+// dirPrefix returns the key prefix of the files of a store directory.
+func (s *ObjectStore) dirPrefix(dir string) string {
+	if dir = memPath(dir); dir == "." {
+		return s.prefix
+	}
+	return s.prefix + dir + "/"
+}
+
+// Read reads a file from the store.
+func (s *ObjectStore) Read(ctx context.Context, p string) ([]byte, error) {
+	content, err := s.storage.GetObject(ctx, s.objectKey(p))
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %w", p, err)
+	}
+	return content, nil
+}
+
+// Exists checks if a file or directory exists. Directories exist as long as they hold files.
+func (s *ObjectStore) Exists(ctx context.Context, p string) (bool, error) {
+	_, err := s.storage.StatObject(ctx, s.objectKey(p))
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("stat %s: %w", p, err)
+	}
+
+	listing, err := s.storage.ListObjects(ctx, s.dirPrefix(p))
+	if err != nil {
+		return false, fmt.Errorf("list %s: %w", p, err)
+	}
+	return len(listing.Objects) > 0 || len(listing.Prefixes) > 0, nil
+}
+
+// List lists files in a directory. It returns nothing if the directory does not exist.
+func (s *ObjectStore) List(ctx context.Context, dir string) ([]FileInfo, error) {
+	prefix := s.dirPrefix(dir)
+	listing, err := s.storage.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", dir, err)
+	}
+
+	files := make([]FileInfo, 0, len(listing.Objects)+len(listing.Prefixes))
+	for _, object := range listing.Objects {
+		name := strings.TrimPrefix(object.Key, prefix)
+		if name == "" {
+			continue
+		}
+		files = append(files, FileInfo{Path: filepath.Join(dir, name), Size: object.Size, ModTime: object.ModTime})
+	}
+	for _, sub := range listing.Prefixes {
+		name := strings.TrimSuffix(strings.TrimPrefix(sub, prefix), "/")
+		if name == "" {
+			continue
+		}
+		files = append(files, FileInfo{Path: filepath.Join(dir, name), IsDir: true})
+	}
+
+	slices.SortFunc(files, func(a, b FileInfo) int { return strings.Compare(a.Path, b.Path) })
+	return files, nil
+}
+
+// BeginTx starts a new transaction.
+func (s *ObjectStore) BeginTx(_ context.Context) (Transaction, error) {
+	return &objectTransaction{store: s}, nil
+}
+
+// Push does nothing, as objects are stored as soon as they are written.
+func (s *ObjectStore) Push(_ context.Context) error {
+	return nil
+}
+
+// Lock acquires the store's write lock for external coordination.
+func (s *ObjectStore) Lock() {
+	s.mu.Lock()
+}
+
+// Unlock releases the store's write lock.
+func (s *ObjectStore) Unlock() {
+	s.mu.Unlock()
+}
+
+// objectTransaction implements Transaction on an ObjectStore. Writes are applied immediately and are final.
+type objectTransaction struct {
+	store  *ObjectStore
+	mu     sync.Mutex
+	closed bool
+}
+
+// Write writes content to a file immediately.
+func (t *objectTransaction) Write(ctx context.Context, p string, content []byte) error {
+	if err := t.checkOpen(); err != nil {
+		return err
+	}
+	if err := t.store.storage.PutObject(ctx, t.store.objectKey(p), content); err != nil {
+		return fmt.Errorf("write file %s: %w", p, err)
+	}
+	return nil
+}
+
+// WriteStream writes content from a reader to a file.
+// Returns the number of bytes written.
+func (t *objectTransaction) WriteStream(ctx context.Context, p string, reader io.Reader) (int64, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return int64(len(content)), fmt.Errorf("write content: %w", err)
+	}
+	if err := t.Write(ctx, p, content); err != nil {
+		return 0, err
+	}
+	return int64(len(content)), nil
+}
+
+// Delete deletes a file immediately. Deleting a missing file is not an error.
+func (t *objectTransaction) Delete(ctx context.Context, p string) error {
+	if err := t.checkOpen(); err != nil {
+		return err
+	}
+	if err := t.store.storage.DeleteObject(ctx, t.store.objectKey(p)); err != nil {
+		return fmt.Errorf("delete file %s: %w", p, err)
+	}
+	return nil
+}
+
+// Mkdir does nothing, as object storages have no directories.
+func (t *objectTransaction) Mkdir(_ context.Context, _ string) error {
+	return t.checkOpen()
+}
+
+// Commit does nothing, as writes are already stored.
+// After commit, the transaction can continue to be used for more changes.
+func (t *objectTransaction) Commit(_ context.Context, _ string) error {
+	return t.checkOpen()
+}
+
+// Rollback closes the transaction. Writes can't be reverted.
+func (t *objectTransaction) Rollback(_ context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	return nil
+}
+
+// checkOpen returns an error if the transaction was rolled back.
+func (t *objectTransaction) checkOpen() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return apperrors.ErrTransactionCommitted
+	}
+	return nil
+}
@@ -29,6 +29,8 @@ const (
 	StorageModeLocal StorageMode = "local"
 	// StorageModeRemote uses remote storage (pull/push enabled).
 	StorageModeRemote StorageMode = "remote"
+	// StorageModeS3 stores the mirror in an S3 bucket, without git (no commits, pull or push).
+	StorageModeS3 StorageMode = "s3"
 )
 
 // RemoteConfig holds configuration for remote git operations.
@@ -43,6 +45,11 @@ type RemoteConfig struct {
 	Commit       bool          // Enable automatic git commit (NTN_COMMIT)
 	CommitPeriod time.Duration // Periodic commit interval during sync (NTN_COMMIT_PERIOD)
 	Push         *bool         // Push to remote after commits (NTN_PUSH), nil means auto-detect
+	ReadOnly     bool          // Never push to the remote, and mark commits as such (NTN_READ_ONLY)
+	Subdir       string        // Subdirectory of the repository holding the mirror (NTN_GIT_SUBDIR), empty = root
+	S3           *S3Config     // S3 bucket holding the mirror (NTN_S3_*), nil if NTN_S3_BUCKET is not set
+	Lock         bool          // Lock the branch on the remote during sync runs (NTN_GIT_LOCK)
+	LockTTL      time.Duration // Age after which a remote lock that wasn't refreshed is taken over (NTN_GIT_LOCK_TTL)
 }
 
 // LoadRemoteConfigFromEnv loads remote configuration from environment variables.
@@ -55,6 +62,8 @@ func LoadRemoteConfigFromEnv() *RemoteConfig {
 		QueueBranch: os.Getenv("NTN_QUEUE_BRANCH"),
 		User:        os.Getenv("NTN_GIT_USER"),
 		Email:       os.Getenv("NTN_GIT_EMAIL"),
+		Subdir:      os.Getenv("NTN_GIT_SUBDIR"),
+		S3:          LoadS3ConfigFromEnv(),
 	}
 
 	// Apply defaults
@@ -97,6 +106,14 @@ func LoadRemoteConfigFromEnv() *RemoteConfig {
 		cfg.Push = &push
 	}
 
+	cfg.ReadOnly = parseBoolEnv(os.Getenv("NTN_READ_ONLY"))
+
+	cfg.Lock = parseBoolEnv(os.Getenv("NTN_GIT_LOCK"))
+	cfg.LockTTL = defaultRemoteLockTTL
+	if d, err := time.ParseDuration(os.Getenv("NTN_GIT_LOCK_TTL")); err == nil && d > 0 {
+		cfg.LockTTL = d
+	}
+
 	return cfg
 }
 
@@ -108,18 +125,21 @@ func parseBoolEnv(val string) bool {
 
 // EffectiveStorageMode returns the effective storage mode after auto-detection.
 // If Storage is set explicitly, it returns that value.
-// Otherwise, it returns "remote" if URL is configured, or "local" if not.
+// Otherwise, it returns "remote" if URL is configured, "s3" if a bucket is, or "local" if neither is.
 func (c *RemoteConfig) EffectiveStorageMode() StorageMode {
 	if c == nil {
 		return StorageModeLocal
 	}
-	if c.Storage == StorageModeLocal || c.Storage == StorageModeRemote {
+	if c.Storage == StorageModeLocal || c.Storage == StorageModeRemote || c.Storage == StorageModeS3 {
 		return c.Storage
 	}
-	// Auto-detect: use remote if URL is configured
+	// Auto-detect: use remote if URL is configured, then S3 if a bucket is
 	if c.URL != "" {
 		return StorageModeRemote
 	}
+	if c.S3 != nil {
+		return StorageModeS3
+	}
 	return StorageModeLocal
 }
 
@@ -162,9 +182,9 @@ func (c *RemoteConfig) IsCommitEnabled() bool {
 }
 
 // IsPushEnabled returns true if push to remote is enabled.
-// When NTN_PUSH is not explicitly set, defaults to true if NTN_GIT_URL is set.
+// When NTN_PUSH is not explicitly set, defaults to true if NTN_GIT_URL is set. It is never enabled in read-only mode.
 func (c *RemoteConfig) IsPushEnabled() bool {
-	if c == nil {
+	if c == nil || c.ReadOnly {
 		return false
 	}
 	if c.Push != nil {
@@ -174,6 +194,23 @@ func (c *RemoteConfig) IsPushEnabled() bool {
 	return c.URL != ""
 }
 
+// IsLockEnabled returns true if the branch is locked on the remote during sync runs. Only instances pushing to the
+// remote take the lock.
+func (c *RemoteConfig) IsLockEnabled() bool {
+	return c.IsEnabled() && c.Lock && c.IsPushEnabled()
+}
+
+// CheckReadOnly returns an error if the read-only mode is combined with an explicit push (NTN_PUSH=true).
+func (c *RemoteConfig) CheckReadOnly() error {
+	if c == nil || !c.ReadOnly {
+		return nil
+	}
+	if c.Push != nil && *c.Push {
+		return fmt.Errorf("%w: NTN_PUSH", apperrors.ErrReadOnlyConflict)
+	}
+	return nil
+}
+
 // GetCommitPeriod returns the periodic commit interval.
 func (c *RemoteConfig) GetCommitPeriod() time.Duration {
 	if c == nil {
@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// remoteLockRefPrefix is the prefix of the refs holding the remote locks, followed by the name of the locked branch.
+// Each update of a lock is a commit whose message is the LockInfo, on top of the previous one: pushes without force
+// fail if another instance updated the lock in the meantime.
+const remoteLockRefPrefix = "refs/ntnsync/lock/"
+
+const (
+	// defaultRemoteLockTTL is the default age after which a remote lock that wasn't refreshed is taken over.
+	defaultRemoteLockTTL = 10 * time.Minute
+	// remoteLockPollInterval is the time between two attempts to acquire a remote lock, with lock wait.
+	remoteLockPollInterval = 10 * time.Second
+	// minRemoteLockRefresh is the minimum time between two refreshes of a remote lock.
+	minRemoteLockRefresh = time.Second
+	// remoteLockReleaseTimeout is the maximum duration of the release of a remote lock when the store lock is released.
+	remoteLockReleaseTimeout = 30 * time.Second
+)
+
+// RemoteLocker is implemented by stores able to lock their branch on the remote, so that instances sharing it
+// (e.g. a CI job and a serve daemon) don't interleave their pushes.
+type RemoteLocker interface {
+	// AcquireRemoteLock acquires the lock of the branch, if enabled (NTN_GIT_LOCK) and not held yet.
+	AcquireRemoteLock(ctx context.Context) error
+	// ReleaseRemoteLock releases the lock of the branch, if held.
+	ReleaseRemoteLock(ctx context.Context) error
+}
+
+// heldRemoteLock is the lock of the branch held by the store on the remote.
+type heldRemoteLock struct {
+	info LockInfo
+	hash plumbing.Hash // Last commit pushed to the lock ref, owned by the refresh goroutine while it runs
+	stop chan struct{}
+	done chan struct{}
+}
+
+// remoteLockRef returns the ref holding the lock of the branch on the remote.
+func (s *LocalStore) remoteLockRef() plumbing.ReferenceName {
+	return plumbing.ReferenceName(remoteLockRefPrefix + s.remoteConfig.Branch)
+}
+
+// AcquireRemoteLock acquires the lock of the branch on the remote. It fails with ErrRemoteLocked if another instance
+// holds it, unless lock wait is enabled. Locks not refreshed for NTN_GIT_LOCK_TTL are taken over.
+func (s *LocalStore) AcquireRemoteLock(ctx context.Context) error {
+	if !s.remoteConfig.IsLockEnabled() {
+		return nil
+	}
+
+	s.remoteLockMu.Lock()
+	defer s.remoteLockMu.Unlock()
+
+	if s.remoteLock != nil {
+		return nil
+	}
+
+	auth, err := s.remoteConfig.GetAuth()
+	if err != nil {
+		return fmt.Errorf("get auth: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	for {
+		holder, current, err := s.readRemoteLock(ctx, auth)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if holder == nil || now.Sub(holder.UpdatedAt) > s.remoteConfig.LockTTL {
+			if holder != nil {
+				s.logger.WarnContext(ctx, "taking over stale remote lock", "ref", s.remoteLockRef(), "holder", holder)
+			}
+			info := LockInfo{
+				PID:        os.Getpid(),
+				Hostname:   hostname,
+				Command:    filepath.Base(os.Args[0]),
+				AcquiredAt: now,
+				UpdatedAt:  now,
+			}
+			hash, err := s.pushRemoteLock(ctx, auth, &info, current)
+			if isNonFastForwardErr(err) {
+				continue // Updated by another instance in the meantime
+			}
+			if err != nil {
+				return err
+			}
+
+			s.remoteLock = &heldRemoteLock{info: info, hash: hash, stop: make(chan struct{}), done: make(chan struct{})}
+			go s.refreshRemoteLock(s.remoteLock, auth)
+			s.logger.InfoContext(ctx, "remote lock acquired", "ref", s.remoteLockRef())
+			return nil
+		}
+
+		lockedErr := fmt.Errorf("%w: by %s (pid %d on %s) since %s", apperrors.ErrRemoteLocked, holder.Command,
+			holder.PID, holder.Hostname, holder.AcquiredAt.Format(time.RFC3339))
+		if !s.lockWait {
+			return lockedErr
+		}
+		s.logger.InfoContext(ctx, "waiting for the remote lock", "error", lockedErr)
+
+		timer := time.NewTimer(remoteLockPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("wait for remote lock: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// readRemoteLock returns the lock of the branch on the remote and the commit holding it, or nil if the branch isn't
+// locked. Unreadable locks are returned with a zero time, to be taken over.
+func (s *LocalStore) readRemoteLock(ctx context.Context, auth transport.AuthMethod) (*LockInfo, plumbing.Hash, error) {
+	current, err := s.remoteLockHash(ctx, auth)
+	if err != nil || current.IsZero() {
+		return nil, current, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockRef := s.remoteLockRef()
+	err = s.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: gitRemoteOrigin,
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{config.RefSpec("+" + lockRef + ":" + lockRef)},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, current, fmt.Errorf("fetch remote lock: %w", err)
+	}
+
+	var info LockInfo
+	if commit, err := s.repo.CommitObject(current); err == nil {
+		_ = json.Unmarshal([]byte(commit.Message), &info) // Unreadable locks keep a zero time
+	}
+	return &info, current, nil
+}
+
+// remoteLockHash returns the commit of the lock ref on the remote, or a zero hash if the branch isn't locked.
+func (s *LocalStore) remoteLockHash(ctx context.Context, auth transport.AuthMethod) (plumbing.Hash, error) {
+	remote, err := s.repo.Remote(gitRemoteOrigin)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("get remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("list remote: %w", err)
+	}
+
+	lockRef := s.remoteLockRef()
+	for _, ref := range refs {
+		if ref.Name() == lockRef {
+			return ref.Hash(), nil
+		}
+	}
+	return plumbing.ZeroHash, nil
+}
+
+// pushRemoteLock pushes a lock commit on top of the current one, without force. It fails with a non-fast-forward
+// error if the lock was updated by another instance since it was read.
+func (s *LocalStore) pushRemoteLock(
+	ctx context.Context, auth transport.AuthMethod, info *LockInfo, parent plumbing.Hash,
+) (plumbing.Hash, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("marshal remote lock: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	treeHash, err := s.storeObject(&object.Tree{})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("store remote lock tree: %w", err)
+	}
+	signature := object.Signature{Name: s.remoteConfig.User, Email: s.remoteConfig.Email, When: info.UpdatedAt}
+	commit := &object.Commit{
+		Author:    signature,
+		Committer: signature,
+		Message:   string(data),
+		TreeHash:  treeHash,
+	}
+	if !parent.IsZero() {
+		commit.ParentHashes = []plumbing.Hash{parent}
+	}
+	hash, err := s.storeObject(commit)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("store remote lock commit: %w", err)
+	}
+
+	lockRef := s.remoteLockRef()
+	if err := s.repo.Storer.SetReference(plumbing.NewHashReference(lockRef, hash)); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("set remote lock ref: %w", err)
+	}
+	err = s.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: gitRemoteOrigin,
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{config.RefSpec(lockRef + ":" + lockRef)},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return plumbing.ZeroHash, fmt.Errorf("push remote lock: %w", err)
+	}
+	return hash, nil
+}
+
+// encodableObject is a git object that can be stored in the repository.
+type encodableObject interface {
+	Encode(o plumbing.EncodedObject) error
+}
+
+// storeObject stores a git object in the repository. Caller must hold s.mu.
+func (s *LocalStore) storeObject(obj encodableObject) (plumbing.Hash, error) {
+	encoded := s.repo.Storer.NewEncodedObject()
+	if err := obj.Encode(encoded); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.repo.Storer.SetEncodedObject(encoded)
+}
+
+// isNonFastForwardErr returns true if a push was rejected because the remote ref was updated in the meantime.
+func isNonFastForwardErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "non-fast-forward")
+}
+
+// refreshRemoteLock updates the remote lock periodically until it is released, so that other instances don't take
+// it over as stale.
+func (s *LocalStore) refreshRemoteLock(lock *heldRemoteLock, auth transport.AuthMethod) {
+	defer close(lock.done)
+
+	interval := max(s.remoteConfig.LockTTL/4, minRemoteLockRefresh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lock.stop:
+			return
+		case <-ticker.C:
+		}
+
+		info := lock.info
+		info.UpdatedAt = time.Now().UTC()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		hash, err := s.pushRemoteLock(ctx, auth, &info, lock.hash)
+		cancel()
+		switch {
+		case isNonFastForwardErr(err):
+			s.logger.Error("remote lock was taken over by another instance", "ref", s.remoteLockRef())
+			return
+		case err != nil:
+			s.logger.Warn("failed to refresh remote lock", "ref", s.remoteLockRef(), "error", err)
+		default:
+			lock.hash = hash
+		}
+	}
+}
+
+// ReleaseRemoteLock stops refreshing the remote lock and deletes it, if it is still held by this store.
+func (s *LocalStore) ReleaseRemoteLock(ctx context.Context) error {
+	s.remoteLockMu.Lock()
+	defer s.remoteLockMu.Unlock()
+
+	if s.remoteLock == nil {
+		return nil
+	}
+	close(s.remoteLock.stop)
+	<-s.remoteLock.done
+	hash := s.remoteLock.hash
+	s.remoteLock = nil
+
+	auth, err := s.remoteConfig.GetAuth()
+	if err != nil {
+		return fmt.Errorf("get auth: %w", err)
+	}
+	current, err := s.remoteLockHash(ctx, auth)
+	if err != nil {
+		return err
+	}
+	if current != hash {
+		return nil // Taken over by another instance, which now owns it
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockRef := s.remoteLockRef()
+	err = s.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: gitRemoteOrigin,
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{config.RefSpec(":" + lockRef)},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("delete remote lock: %w", err)
+	}
+	if err := s.repo.Storer.RemoveReference(lockRef); err != nil {
+		return fmt.Errorf("remove remote lock ref: %w", err)
+	}
+	s.logger.InfoContext(ctx, "remote lock released", "ref", lockRef)
+	return nil
+}
+
+// AcquireRemoteLock acquires the lock of the content branch on the remote, which also covers the queue branch.
+func (s *SplitStore) AcquireRemoteLock(ctx context.Context) error {
+	return s.contentStore.AcquireRemoteLock(ctx)
+}
+
+// ReleaseRemoteLock releases the lock of the content branch on the remote.
+func (s *SplitStore) ReleaseRemoteLock(ctx context.Context) error {
+	return s.contentStore.ReleaseRemoteLock(ctx)
+}
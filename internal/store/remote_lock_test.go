@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// newRemoteLockTestStore creates a store pushing to a local bare repository, with the remote lock enabled.
+func newRemoteLockTestStore(t *testing.T, remotePath string, ttl time.Duration) *LocalStore {
+	t.Helper()
+
+	st, err := NewLocalStore(filepath.Join(t.TempDir(), "mirror"), WithRemoteConfig(&RemoteConfig{
+		Storage:  StorageModeRemote,
+		URL:      remotePath,
+		Password: "test",
+		Branch:   "main",
+		User:     "test",
+		Email:    "test@local",
+		Lock:     true,
+		LockTTL:  ttl,
+	}))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	t.Cleanup(func() { _ = st.ReleaseRemoteLock(context.Background()) })
+	return st
+}
+
+func TestLocalStore_RemoteLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	remotePath := t.TempDir()
+	if _, err := git.PlainInit(remotePath, true); err != nil {
+		t.Fatalf("failed to init remote: %v", err)
+	}
+	first := newRemoteLockTestStore(t, remotePath, time.Hour)
+	second := newRemoteLockTestStore(t, remotePath, time.Hour)
+	impatient := newRemoteLockTestStore(t, remotePath, time.Nanosecond)
+
+	if err := first.AcquireRemoteLock(ctx); err != nil {
+		t.Fatalf("AcquireRemoteLock() error = %v", err)
+	}
+	if err := second.AcquireRemoteLock(ctx); !errors.Is(err, apperrors.ErrRemoteLocked) {
+		t.Fatalf("AcquireRemoteLock() error = %v, want ErrRemoteLocked", err)
+	}
+
+	// Released locks can be acquired by other instances
+	if err := first.ReleaseRemoteLock(ctx); err != nil {
+		t.Fatalf("ReleaseRemoteLock() error = %v", err)
+	}
+	if err := second.AcquireRemoteLock(ctx); err != nil {
+		t.Fatalf("AcquireRemoteLock() after release error = %v", err)
+	}
+
+	// Locks older than the TTL are taken over, and not deleted by their former holder
+	if err := impatient.AcquireRemoteLock(ctx); err != nil {
+		t.Fatalf("AcquireRemoteLock() with a stale lock error = %v", err)
+	}
+	if err := second.ReleaseRemoteLock(ctx); err != nil {
+		t.Fatalf("ReleaseRemoteLock() error = %v", err)
+	}
+	if err := first.AcquireRemoteLock(ctx); !errors.Is(err, apperrors.ErrRemoteLocked) {
+		t.Errorf("AcquireRemoteLock() error = %v, want ErrRemoteLocked", err)
+	}
+}
+
+func TestRemoteConfig_IsLockEnabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := &RemoteConfig{URL: "https://example.com/repo.git", Lock: true}
+	if !cfg.IsLockEnabled() {
+		t.Error("IsLockEnabled() = false, want true")
+	}
+	cfg.ReadOnly = true
+	if cfg.IsLockEnabled() {
+		t.Error("IsLockEnabled() = true in read-only mode, want false")
+	}
+	if (&RemoteConfig{URL: "https://example.com/repo.git"}).IsLockEnabled() {
+		t.Error("IsLockEnabled() = true without NTN_GIT_LOCK, want false")
+	}
+}
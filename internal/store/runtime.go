@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// RunFile describes the sync run in progress, for external orchestrators. It is a runtime file.
+const RunFile = ".notion-sync/run.json"
+
+// DebugDir holds the diagnostic dumps of the running process. Its files are runtime files.
+const DebugDir = ".notion-sync/debug"
+
+// runtimeFiles are the files of the mirror describing the running process. They are written outside
+// transactions and never committed, even when a process died without removing them.
+var runtimeFiles = []string{RunFile, LockFile}
+
+// RuntimeFileWriter is implemented by stores that can hold runtime files.
+type RuntimeFileWriter interface {
+	// WriteRuntimeFile atomically replaces a runtime file.
+	WriteRuntimeFile(ctx context.Context, path string, content []byte) error
+	// RemoveRuntimeFile removes a runtime file. Removing a missing file is not an error.
+	RemoveRuntimeFile(ctx context.Context, path string) error
+}
+
+// isRuntimeFile returns true if a store path is a runtime file.
+func isRuntimeFile(name string) bool {
+	name = path.Clean(filepath.ToSlash(name))
+	return slices.Contains(runtimeFiles, name) || strings.HasPrefix(name, DebugDir+"/")
+}
+
+// WriteRuntimeFile atomically replaces a runtime file.
+func (s *LocalStore) WriteRuntimeFile(_ context.Context, name string, content []byte) error {
+	if !isRuntimeFile(name) {
+		return fmt.Errorf("%w: %s", apperrors.ErrNotRuntimeFile, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fullPath := s.fullPath(name)
+	if err := os.MkdirAll(filepath.Dir(fullPath), dirPerm); err != nil {
+		return fmt.Errorf("create parent dir: %w", err)
+	}
+
+	tmpPath := fullPath + ".tmp"
+	if err := os.WriteFile(tmpPath, content, filePerm); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("rename %s: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveRuntimeFile removes a runtime file.
+func (s *LocalStore) RemoveRuntimeFile(_ context.Context, name string) error {
+	if !isRuntimeFile(name) {
+		return fmt.Errorf("%w: %s", apperrors.ErrNotRuntimeFile, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.fullPath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove %s: %w", name, err)
+	}
+	return nil
+}
+
+// unstageRuntimeFilesLocked removes the runtime files from the git index, so that they are not committed.
+// Caller must hold s.mu.
+func (s *LocalStore) unstageRuntimeFilesLocked() error {
+	idx, err := s.repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+
+	removed := false
+	for _, name := range runtimeFiles {
+		if _, err := idx.Remove(path.Join(s.subdir, name)); err == nil {
+			removed = true
+		}
+	}
+	// Debug dumps, and the temporary files of the lock (see createLockFile) left by a dead process
+	debugPrefix := path.Join(s.subdir, DebugDir) + "/"
+	lockPrefix := path.Join(s.subdir, LockFile) + "."
+	var debugFiles []string
+	for _, entry := range idx.Entries {
+		if strings.HasPrefix(entry.Name, debugPrefix) || strings.HasPrefix(entry.Name, lockPrefix) {
+			debugFiles = append(debugFiles, entry.Name)
+		}
+	}
+	for _, name := range debugFiles {
+		if _, err := idx.Remove(name); err == nil {
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	if err := s.repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	return nil
+}
+
+// WriteRuntimeFile writes a runtime file, which survives rollbacks.
+func (s *MemStore) WriteRuntimeFile(_ context.Context, name string, content []byte) error {
+	if !isRuntimeFile(name) {
+		return fmt.Errorf("%w: %s", apperrors.ErrNotRuntimeFile, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name = memPath(name)
+	file := memFile{content: slices.Clone(content), modTime: time.Now()}
+	s.files[name] = file
+	s.committed[name] = file
+	s.addDirsLocked(name)
+	return nil
+}
+
+// RemoveRuntimeFile removes a runtime file.
+func (s *MemStore) RemoveRuntimeFile(_ context.Context, name string) error {
+	if !isRuntimeFile(name) {
+		return fmt.Errorf("%w: %s", apperrors.ErrNotRuntimeFile, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name = memPath(name)
+	delete(s.files, name)
+	delete(s.committed, name)
+	return nil
+}
+
+// WriteRuntimeFile writes a runtime file to the content store.
+func (s *SplitStore) WriteRuntimeFile(ctx context.Context, name string, content []byte) error {
+	return s.contentStore.WriteRuntimeFile(ctx, name, content)
+}
+
+// RemoveRuntimeFile removes a runtime file from the content store.
+func (s *SplitStore) RemoveRuntimeFile(ctx context.Context, name string) error {
+	return s.contentStore.RemoveRuntimeFile(ctx, name)
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestLocalStore_RuntimeFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	st, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.WriteRuntimeFile(ctx, "tech/page.md", []byte("x")); !errors.Is(err, apperrors.ErrNotRuntimeFile) {
+		t.Errorf("WriteRuntimeFile(tech/page.md) error = %v, want ErrNotRuntimeFile", err)
+	}
+
+	if err := st.WriteRuntimeFile(ctx, RunFile, []byte(`{"pid":1}`)); err != nil {
+		t.Fatalf("WriteRuntimeFile() error = %v", err)
+	}
+	dumpFile := DebugDir + "/dump-state.json"
+	if err := st.WriteRuntimeFile(ctx, dumpFile, []byte(`{}`)); err != nil {
+		t.Fatalf("WriteRuntimeFile(%s) error = %v", dumpFile, err)
+	}
+	commitFiles(ctx, t, st, "sync", map[string]string{"tech/page.md": "page"})
+
+	for message, files := range historyFiles(t, st) {
+		if slices.Contains(files, RunFile) || slices.Contains(files, dumpFile) {
+			t.Errorf("commit %q contains runtime files: %v", message, files)
+		}
+	}
+	if content, err := st.Read(ctx, RunFile); err != nil || string(content) != `{"pid":1}` {
+		t.Errorf("run file = %q, %v", content, err)
+	}
+
+	// Only the run file changed: nothing to commit
+	if err := st.WriteRuntimeFile(ctx, RunFile, []byte(`{"pid":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	commitFiles(ctx, t, st, "nothing", map[string]string{})
+	if history := historyFiles(t, st); len(history) != 1 {
+		t.Errorf("history has %d commits, want 1", len(history))
+	}
+
+	if err := st.RemoveRuntimeFile(ctx, RunFile); err != nil {
+		t.Fatalf("RemoveRuntimeFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, RunFile)); !os.IsNotExist(err) {
+		t.Errorf("run file still exists: %v", err)
+	}
+	if err := st.RemoveRuntimeFile(ctx, RunFile); err != nil {
+		t.Errorf("RemoveRuntimeFile(missing) error = %v", err)
+	}
+}
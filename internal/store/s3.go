@@ -0,0 +1,177 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+const s3DefaultRegion = "us-east-1"
+
+// S3Config holds the configuration of the S3 storage.
+// Credentials come from the default AWS chain: environment, shared profiles, web identity (IRSA), IMDS...
+type S3Config struct {
+	Bucket   string // Bucket holding the mirror (NTN_S3_BUCKET)
+	Prefix   string // Key prefix of the mirror in the bucket (NTN_S3_PREFIX), empty = bucket root
+	Region   string // Bucket region (NTN_S3_REGION), empty = region of the AWS configuration, then us-east-1
+	Endpoint string // Endpoint of S3-compatible storages (NTN_S3_ENDPOINT), with path-style requests
+}
+
+// LoadS3ConfigFromEnv loads the S3 configuration from environment variables.
+// Returns nil if NTN_S3_BUCKET is not set.
+func LoadS3ConfigFromEnv() *S3Config {
+	bucket := os.Getenv("NTN_S3_BUCKET")
+	if bucket == "" {
+		return nil
+	}
+
+	return &S3Config{
+		Bucket:   bucket,
+		Prefix:   os.Getenv("NTN_S3_PREFIX"),
+		Region:   os.Getenv("NTN_S3_REGION"),
+		Endpoint: strings.TrimSuffix(os.Getenv("NTN_S3_ENDPOINT"), "/"),
+	}
+}
+
+// NewS3Store creates a store of the mirror in an S3 bucket.
+func NewS3Store(ctx context.Context, cfg *S3Config) (*ObjectStore, error) {
+	if cfg == nil || cfg.Bucket == "" {
+		return nil, apperrors.ErrS3NotConfigured
+	}
+	client, err := NewS3Client(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewObjectStore(client, cfg.Prefix), nil
+}
+
+// S3Client is an ObjectStorage on an S3 bucket.
+type S3Client struct {
+	bucket string
+	client *s3.Client
+}
+
+// NewS3Client creates a client of the bucket of cfg, with the credentials of the default AWS chain.
+func NewS3Client(ctx context.Context, cfg *S3Config) (*S3Client, error) {
+	var options []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		options = append(options, config.WithRegion(cfg.Region))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS configuration: %w", err)
+	}
+	if awsConfig.Region == "" {
+		awsConfig.Region = s3DefaultRegion
+	}
+
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+			// S3-compatible storages don't all support the checksums AWS computes by default
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+	})
+	return &S3Client{bucket: cfg.Bucket, client: client}, nil
+}
+
+// GetObject returns the content of an object.
+func (c *S3Client) GetObject(ctx context.Context, key string) ([]byte, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(c.bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, s3Error("get", key, err)
+	}
+	defer func() { _ = out.Body.Close() }()
+
+	content, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read object %s: %w", key, err)
+	}
+	return content, nil
+}
+
+// StatObject returns the size and modification time of an object.
+func (c *S3Client) StatObject(ctx context.Context, key string) (ObjectInfo, error) {
+	out, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(c.bucket), Key: aws.String(key)})
+	if err != nil {
+		return ObjectInfo{}, s3Error("head", key, err)
+	}
+	return ObjectInfo{Key: key, Size: aws.ToInt64(out.ContentLength), ModTime: aws.ToTime(out.LastModified)}, nil
+}
+
+// PutObject stores an object.
+func (c *S3Client) PutObject(ctx context.Context, key string, content []byte) error {
+	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(c.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(content),
+		ContentLength: aws.Int64(int64(len(content))),
+	})
+	if err != nil {
+		return s3Error("put", key, err)
+	}
+	return nil
+}
+
+// DeleteObject deletes an object. Deleting a missing object is not an error.
+func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(c.bucket), Key: aws.String(key)})
+	if err != nil {
+		if err = s3Error("delete", key, err); !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListObjects lists the objects and deeper prefixes under prefix, following continuation tokens.
+func (c *S3Client) ListObjects(ctx context.Context, prefix string) (ObjectListing, error) {
+	var listing ObjectListing
+	pages := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(c.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return ObjectListing{}, s3Error("list", prefix, err)
+		}
+		for _, object := range page.Contents {
+			listing.Objects = append(listing.Objects, ObjectInfo{
+				Key:     aws.ToString(object.Key),
+				Size:    aws.ToInt64(object.Size),
+				ModTime: aws.ToTime(object.LastModified),
+			})
+		}
+		for _, common := range page.CommonPrefixes {
+			listing.Prefixes = append(listing.Prefixes, aws.ToString(common.Prefix))
+		}
+	}
+	return listing, nil
+}
+
+// s3Error wraps the error of a request: fs.ErrNotExist for responses with a 404 status, apperrors.ErrS3Request
+// otherwise.
+func s3Error(operation, key string, err error) error {
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound {
+		return fmt.Errorf("object %s: %w", key, fs.ErrNotExist)
+	}
+	return fmt.Errorf("%w: %s %s: %w", apperrors.ErrS3Request, operation, key, err)
+}
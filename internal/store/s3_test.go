@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3ListResult is the response of ListObjectsV2.
+type s3ListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken,omitempty"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// fakeS3 is a minimal S3 server keeping the objects of a bucket in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	tokens  []string // Session tokens of the requests
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") ||
+		!strings.Contains(r.Header.Get("Authorization"), "/eu-west-3/s3/aws4_request") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	var key string
+	if r.URL.Path != "/"+f.bucket {
+		var inBucket bool
+		if key, inBucket = strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/"); !inBucket {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = append(f.tokens, r.Header.Get("X-Amz-Security-Token"))
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodPut:
+		content, _ := io.ReadAll(r.Body)
+		f.objects[key] = content
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		content, found := f.objects[key]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(content)
+		}
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	var result s3ListResult
+	var prefixes []string
+	for key, content := range f.objects {
+		rest, found := strings.CutPrefix(key, prefix)
+		if !found {
+			continue
+		}
+		if dir, _, deeper := strings.Cut(rest, "/"); deeper {
+			if !slices.Contains(prefixes, prefix+dir+"/") {
+				prefixes = append(prefixes, prefix+dir+"/")
+			}
+			continue
+		}
+		result.Contents = append(result.Contents, struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		}{Key: key, Size: int64(len(content)), LastModified: time.Now().UTC()})
+	}
+	for _, p := range prefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, struct {
+			Prefix string `xml:"Prefix"`
+		}{Prefix: p})
+	}
+	_ = xml.NewEncoder(w).Encode(&result)
+}
+
+// setS3Environment isolates the default AWS credential chain to environment credentials.
+func setS3Environment(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	t.Setenv("AWS_REGION", "eu-west-3")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestS3Store(t *testing.T) {
+	setS3Environment(t)
+
+	fake := &fakeS3{bucket: "mirror", objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	st, err := NewS3Store(ctx, &S3Config{Bucket: "mirror", Prefix: "/notion/", Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("NewS3Store() error = %v", err)
+	}
+
+	tx, err := st.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	files := map[string]string{
+		"tech/Page one.md":               "# Page one\n",
+		"tech/page-one/child.md":         "# Child\n",
+		".notion-sync/ids/page-abc.json": "{}\n",
+	}
+	for path, content := range files {
+		if err := tx.Write(ctx, path, []byte(content)); err != nil {
+			t.Fatalf("Write(%s) error = %v", path, err)
+		}
+	}
+	if err := tx.Commit(ctx, "sync"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if content, found := fake.objects["notion/tech/Page one.md"]; !found || string(content) != "# Page one\n" {
+		t.Errorf("objects = %v, want keys under the prefix", fake.objects)
+	}
+
+	content, err := st.Read(ctx, "tech/Page one.md")
+	if err != nil || string(content) != "# Page one\n" {
+		t.Errorf("Read() = %q, %v", content, err)
+	}
+	if _, err := st.Read(ctx, "tech/missing.md"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read(missing) error = %v, want fs.ErrNotExist", err)
+	}
+
+	entries, err := st.List(ctx, "tech")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "tech/Page one.md" || entries[1].Path != "tech/page-one" ||
+		!entries[1].IsDir {
+		t.Errorf("List() = %+v", entries)
+	}
+
+	for path, want := range map[string]bool{"tech/Page one.md": true, "tech/page-one": true, "hr": false} {
+		if got, err := st.Exists(ctx, path); err != nil || got != want {
+			t.Errorf("Exists(%s) = %v, %v, want %v", path, got, err, want)
+		}
+	}
+
+	if err := tx.Delete(ctx, "tech/Page one.md"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, _ := st.Exists(ctx, "tech/Page one.md"); exists {
+		t.Error("deleted file still exists")
+	}
+	if err := tx.Delete(ctx, "tech/Page one.md"); err != nil {
+		t.Errorf("Delete(missing) error = %v", err)
+	}
+	if slices.ContainsFunc(fake.tokens, func(token string) bool { return token != "token" }) {
+		t.Errorf("session tokens = %v, want the one of the environment", fake.tokens)
+	}
+}
+
+func TestLoadS3ConfigFromEnv(t *testing.T) {
+	t.Setenv("NTN_S3_BUCKET", "")
+	if cfg := LoadS3ConfigFromEnv(); cfg != nil {
+		t.Errorf("LoadS3ConfigFromEnv() = %+v, want nil without bucket", cfg)
+	}
+
+	t.Setenv("NTN_S3_BUCKET", "mirror")
+	t.Setenv("NTN_S3_REGION", "")
+	t.Setenv("NTN_S3_ENDPOINT", "http://localhost:9000/")
+	cfg := LoadS3ConfigFromEnv()
+	if cfg == nil || cfg.Bucket != "mirror" || cfg.Region != "" || cfg.Endpoint != "http://localhost:9000" {
+		t.Errorf("LoadS3ConfigFromEnv() = %+v", cfg)
+	}
+}
@@ -81,6 +81,16 @@ func (s *SplitStore) Push(ctx context.Context) error {
 	return nil
 }
 
+// HeadCommit returns the head commit of the content store.
+func (s *SplitStore) HeadCommit() (string, error) {
+	return s.contentStore.HeadCommit()
+}
+
+// RecentCommits returns the last commits of the content store.
+func (s *SplitStore) RecentCommits(ctx context.Context, limit int) ([]CommitInfo, error) {
+	return s.contentStore.RecentCommits(ctx, limit)
+}
+
 // Lock acquires locks on both stores (content first).
 func (s *SplitStore) Lock() {
 	s.contentStore.Lock()
@@ -114,6 +124,16 @@ func (s *SplitStore) RemoteConfig() *RemoteConfig {
 	return s.contentStore.RemoteConfig()
 }
 
+// PurgeHistory removes paths from the history of the content store. The queue branch holds no content.
+func (s *SplitStore) PurgeHistory(ctx context.Context, paths []string, dryRun bool) (*PurgeHistoryResult, error) {
+	return s.contentStore.PurgeHistory(ctx, paths, dryRun)
+}
+
+// ForcePush force pushes the content store.
+func (s *SplitStore) ForcePush(ctx context.Context) error {
+	return s.contentStore.ForcePush(ctx)
+}
+
 // ContentStore returns the underlying content store.
 func (s *SplitStore) ContentStore() *LocalStore {
 	return s.contentStore
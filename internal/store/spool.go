@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// pushSpoolFile is the file, inside the git directory, recording pushes that failed.
+// It is never committed.
+const pushSpoolFile = "ntnsync-push-spool.json"
+
+// PushSpool records the commits whose push failed, so that it can be retried later.
+type PushSpool struct {
+	Commits     []string  `json:"commits"`      // Branch heads that failed to be pushed, oldest first
+	Since       time.Time `json:"since"`        // When the first push failed
+	Attempts    int       `json:"attempts"`     // Number of failed push attempts
+	LastAttempt time.Time `json:"last_attempt"` // When the last push failed
+	LastError   string    `json:"last_error"`   // Error of the last failed push
+}
+
+// PushSpooler is implemented by stores that record failed pushes so they can be retried.
+type PushSpooler interface {
+	// PendingPush returns the failed pushes to retry, or nil if everything was pushed.
+	PendingPush() (*PushSpool, error)
+}
+
+// merge combines the spool of another store into this one.
+func (p *PushSpool) merge(other *PushSpool) *PushSpool {
+	if p == nil {
+		return other
+	}
+	if other == nil {
+		return p
+	}
+
+	merged := *p
+	merged.Commits = append(slices.Clone(p.Commits), other.Commits...)
+	merged.Attempts = max(p.Attempts, other.Attempts)
+	if other.Since.Before(p.Since) {
+		merged.Since = other.Since
+	}
+	if other.LastAttempt.After(p.LastAttempt) {
+		merged.LastAttempt = other.LastAttempt
+		merged.LastError = other.LastError
+	}
+	return &merged
+}
+
+// pushSpoolPath returns the path of the push spool of the store.
+func (s *LocalStore) pushSpoolPath() string {
+	return filepath.Join(s.rootPath, ".git", pushSpoolFile)
+}
+
+// PendingPush returns the failed pushes to retry, or nil if everything was pushed.
+func (s *LocalStore) PendingPush() (*PushSpool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readPushSpoolLocked()
+}
+
+// readPushSpoolLocked reads the push spool. Caller must hold s.mu.
+func (s *LocalStore) readPushSpoolLocked() (*PushSpool, error) {
+	data, err := os.ReadFile(s.pushSpoolPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil //nolint:nilnil // nil spool indicates nothing is pending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read push spool: %w", err)
+	}
+
+	var spool PushSpool
+	if err := json.Unmarshal(data, &spool); err != nil {
+		return nil, fmt.Errorf("parse push spool: %w", err)
+	}
+	return &spool, nil
+}
+
+// recordPushLocked updates the push spool after a push attempt: it is cleared on success,
+// and records the current branch head on failure. Caller must hold s.mu.
+func (s *LocalStore) recordPushLocked(ctx context.Context, pushErr error) {
+	if pushErr == nil {
+		if err := os.Remove(s.pushSpoolPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.WarnContext(ctx, "failed to clear push spool", "error", err)
+		}
+		return
+	}
+
+	spool, err := s.readPushSpoolLocked()
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to read push spool, starting a new one", "error", err)
+	}
+	now := time.Now()
+	if spool == nil {
+		spool = &PushSpool{Since: now}
+	}
+	spool.Attempts++
+	spool.LastAttempt = now
+	spool.LastError = pushErr.Error()
+	if head, headErr := s.repo.Head(); headErr == nil && !slices.Contains(spool.Commits, head.Hash().String()) {
+		spool.Commits = append(spool.Commits, head.Hash().String())
+	}
+
+	data, err := json.MarshalIndent(spool, "", "  ")
+	if err == nil {
+		err = os.WriteFile(s.pushSpoolPath(), data, filePerm)
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to record failed push", "error", err)
+		return
+	}
+
+	s.logger.WarnContext(ctx, "push failed, spooled for retry",
+		"pending_commits", len(spool.Commits),
+		"attempts", spool.Attempts,
+		"error", pushErr)
+}
+
+// PendingPush returns the failed pushes of both stores, or nil if everything was pushed.
+func (s *SplitStore) PendingPush() (*PushSpool, error) {
+	contentSpool, err := s.contentStore.PendingPush()
+	if err != nil {
+		return nil, fmt.Errorf("content store: %w", err)
+	}
+	queueSpool, err := s.queueStore.PendingPush()
+	if err != nil {
+		return nil, fmt.Errorf("queue store: %w", err)
+	}
+	return contentSpool.merge(queueSpool), nil
+}
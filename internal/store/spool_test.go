@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestLocalStore_PushSpool(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	remotePath := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := git.PlainInit(remotePath, true); err != nil {
+		t.Fatalf("failed to init remote: %v", err)
+	}
+
+	st, err := NewLocalStore(filepath.Join(t.TempDir(), "local"), WithRemoteConfig(&RemoteConfig{
+		URL:      remotePath,
+		Password: "unused", // Only used for HTTPS, required for non-SSH URLs
+		Branch:   "main",
+		User:     "test",
+		Email:    "test@localhost",
+	}))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	tx, _ := st.BeginTx(ctx)
+	_ = tx.Write(ctx, "page.md", []byte("# Page"))
+	if err := tx.Commit(ctx, "add page"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	// Make the remote unreachable
+	if err := os.Rename(remotePath, remotePath+".offline"); err != nil {
+		t.Fatalf("failed to move remote: %v", err)
+	}
+	if err := st.Push(ctx); err == nil {
+		t.Fatal("Push() to an unreachable remote should fail")
+	}
+	_ = st.Push(ctx)
+
+	spool, err := st.PendingPush()
+	if err != nil || spool == nil {
+		t.Fatalf("PendingPush() = %v, %v, want a pending push", spool, err)
+	}
+	if len(spool.Commits) != 1 || spool.Attempts != 2 || spool.LastError == "" {
+		t.Errorf("PendingPush() = %+v, want one commit after two attempts", spool)
+	}
+
+	// Bring the remote back
+	if err := os.Rename(remotePath+".offline", remotePath); err != nil {
+		t.Fatalf("failed to restore remote: %v", err)
+	}
+	if err := st.Push(ctx); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if spool, err := st.PendingPush(); err != nil || spool != nil {
+		t.Errorf("PendingPush() after a successful push = %+v, %v, want nil", spool, err)
+	}
+}
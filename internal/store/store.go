@@ -56,3 +56,23 @@ type Transaction interface {
 type ReadFSProvider interface {
 	FS() fs.FS
 }
+
+// HeadReader is implemented by stores that can tell which commit their content is at.
+type HeadReader interface {
+	// HeadCommit returns the hash of the commit at the head of the mirror branch.
+	HeadCommit() (string, error)
+}
+
+// CommitLogReader is implemented by stores that can list the last commits of the mirror branch.
+type CommitLogReader interface {
+	// RecentCommits returns the last commits of the mirror branch, newest first, at most limit.
+	RecentCommits(ctx context.Context, limit int) ([]CommitInfo, error)
+}
+
+// CommitInfo describes a commit of the mirror branch.
+type CommitInfo struct {
+	Hash    string    `json:"hash,omitempty"`
+	Message string    `json:"message"` // First line of the commit message
+	Author  string    `json:"author,omitempty"`
+	Time    time.Time `json:"time"`
+}
@@ -78,6 +78,7 @@ func (c *Crawler) finalizeAdd(ctx context.Context, params *finalizeAddParams) er
 	}
 
 	now := time.Now()
+	assets := c.updateAssetPages(ctx, params.itemID, c.registeredAssets(ctx, params.itemID), true)
 
 	if err := c.savePageRegistry(ctx, &PageRegistry{
 		NtnsyncVersion: version.Version,
@@ -93,6 +94,7 @@ func (c *Crawler) finalizeAdd(ctx context.Context, params *finalizeAddParams) er
 		ParentID:       "",
 		Children:       params.children,
 		ContentHash:    contentHash,
+		Assets:         assets,
 	}); err != nil {
 		c.logger.WarnContext(ctx, "failed to save page registry", "error", err)
 	}
@@ -145,7 +147,7 @@ func (c *Crawler) AddDatabase(ctx context.Context, databaseID, folder string, fo
 		"database_id", databaseID)
 
 	// Query all pages in the database
-	dbPages, err := c.client.QueryDatabase(ctx, databaseID)
+	dbPages, err := c.client.QueryDatabasePages(ctx, database)
 	if err != nil {
 		return fmt.Errorf("query database: %w", err)
 	}
@@ -156,21 +158,24 @@ func (c *Crawler) AddDatabase(ctx context.Context, databaseID, folder string, fo
 	}
 
 	dbID := normalizePageID(databaseID)
-	title := converter.SanitizeFilename(database.GetTitle())
+	title := c.converter.FilenameRules.Sanitize(database.GetTitle())
 	if title == "" {
 		title = defaultUntitledStr
 	}
 
-	filePath := filepath.Join(folder, title+".md")
+	filePath := filepath.Join(folder, title+converter.FileExtension(GetConfig().profileFor(folder)))
 
-	content := c.converter.ConvertDatabase(database, dbPages, &converter.ConvertOptions{
+	content := c.convertDatabase(ctx, database, dbPages, &converter.ConvertOptions{
 		Folder:        folder,
+		Profile:       GetConfig().profileFor(folder),
 		PageTitle:     database.GetTitle(),
 		FilePath:      filePath,
 		LastSynced:    time.Now(),
 		NotionType:    notionTypeDatabase,
 		IsRoot:        true,
 		FileProcessor: c.makeFileProcessor(ctx, filePath, dbID),
+
+		DatabaseRenderMode: GetConfig().DatabaseRender,
 	})
 
 	var children []string
@@ -221,17 +226,22 @@ func (c *Crawler) AddRootPage(ctx context.Context, pageID, folder string, forceU
 
 	filePath := c.computeFilePath(ctx, page, folder, true, "")
 
-	content := c.converter.ConvertWithOptions(page, blocks, &converter.ConvertOptions{
-		Folder:        folder,
-		PageTitle:     page.Title(),
-		FilePath:      filePath,
-		LastSynced:    time.Now(),
-		NotionType:    notionTypePage,
-		IsRoot:        true,
-		FileProcessor: c.makeFileProcessor(ctx, filePath, pageID),
+	content := c.convertPage(ctx, page, blocks, &converter.ConvertOptions{
+		Folder:           folder,
+		Profile:          GetConfig().profileFor(folder),
+		InlineDatabases:  c.fetchInlineDatabases(ctx, blocks),
+		CodeCaptions:     GetConfig().CodeCaptions,
+		HTMLTableColumns: GetConfig().htmlTableColumnsFor(GetConfig().profileFor(folder)),
+		PageTitle:        page.Title(),
+		FilePath:         filePath,
+		LastSynced:       time.Now(),
+		NotionType:       notionTypePage,
+		IsRoot:           true,
+		FileProcessor:    c.makeFileProcessor(ctx, filePath, pageID),
+		AssetProcessor:   c.makeAssetProcessor(ctx, filePath, pageID),
 	})
 
-	children := c.findChildPages(blocks)
+	children := c.findChildPages(ctx, blocks, folder)
 
 	return c.finalizeAdd(ctx, &finalizeAddParams{
 		itemID:      pageID,
@@ -276,11 +286,18 @@ func (c *Crawler) GetPage(ctx context.Context, pageID string, folder string) err
 	}
 
 	// Trace parent chain to find folder and determine hierarchy.
-	// The foundRoot return value is ignored here since the add command allows adding pages not under a root.
-	parentChain, targetFolder, _, err := c.traceParentChain(ctx, page, folder)
+	// Pages not under a root are allowed, their top-level parent becoming a root if configured.
+	parentChain, targetFolder, foundRoot, err := c.traceParentChain(ctx, page, folder)
 	if err != nil {
 		return fmt.Errorf("trace parent chain: %w", err)
 	}
+	var rootID string
+	if top := inferredRoot(page, parentChain); !foundRoot && folder == "" && top != nil {
+		if _, err := c.addInferredRoot(ctx, top, targetFolder); err != nil {
+			return fmt.Errorf("add inferred root: %w", err)
+		}
+		rootID = top.ID
+	}
 
 	c.logger.InfoContext(ctx, "traced parent chain",
 		notionKeyPageID, pageID,
@@ -297,13 +314,13 @@ func (c *Crawler) GetPage(ctx context.Context, pageID string, folder string) err
 
 	// Fetch and save all missing parents in the chain (from root to child)
 	for _, parentPage := range slices.Backward(parentChain) {
-		if err := c.savePageFromNotion(ctx, parentPage, targetFolder, false); err != nil {
+		if err := c.savePageFromNotion(ctx, parentPage, targetFolder, parentPage.ID == rootID); err != nil {
 			return fmt.Errorf("save parent page %s: %w", parentPage.ID, err)
 		}
 	}
 
 	// Now save the requested page
-	if err := c.savePageFromNotion(ctx, page, targetFolder, false); err != nil {
+	if err := c.savePageFromNotion(ctx, page, targetFolder, page.ID == rootID); err != nil {
 		return fmt.Errorf("save page: %w", err)
 	}
 
@@ -332,8 +349,8 @@ func (c *Crawler) traceParentChain(
 	for {
 		// Get parent ID
 		parentID := normalizePageID(currentPage.Parent.ID())
-		if parentID == "" {
-			// Reached workspace level - no more parents
+		if parentID == "" || currentPage.Parent.IsWorkspaceLevel() {
+			// Reached workspace level (or a teamspace) - no more parents
 			break
 		}
 
@@ -377,10 +394,10 @@ func (c *Crawler) traceParentChain(
 	}
 
 	// Reached workspace level without finding existing root
-	// Use requested folder or default
+	// Use requested folder, or infer it from the top-level parent
 	targetFolder := requestedFolder
 	if targetFolder == "" {
-		targetFolder = "default"
+		targetFolder = inferFolder(topOfChain(page, missingParents))
 	}
 
 	c.logger.DebugContext(ctx, "reached workspace level",
@@ -426,7 +443,14 @@ func (c *Crawler) resolveBlockToPage(ctx context.Context, blockID string) (strin
 	currentID := blockID
 	maxDepth := 50 // Prevent infinite loops
 
+	var chain []string // Blocks fetched, cached with the result
+
 	for i := range maxDepth {
+		if parent, ok := c.parents.block(currentID); ok {
+			c.parents.storeChain(chain, parent)
+			return parent.id, parent.parentType, nil
+		}
+
 		block, err := c.client.GetBlock(ctx, currentID)
 		if err != nil {
 			return "", "", fmt.Errorf("get block %s: %w", currentID, err)
@@ -438,20 +462,26 @@ func (c *Crawler) resolveBlockToPage(ctx context.Context, blockID string) (strin
 				"block_id", blockID,
 				notionKeyPageID, block.Parent.PageID,
 				"depth", i+1)
-			return normalizePageID(block.Parent.PageID), notionKeyPageID, nil
+			pageID := normalizePageID(block.Parent.PageID)
+			c.parents.storeChain(append(chain, currentID), blockParent{id: pageID, parentType: notionKeyPageID})
+			return pageID, notionKeyPageID, nil
 		case "database_id":
 			c.logger.DebugContext(ctx, "resolved block to database",
 				"block_id", blockID,
 				"database_id", block.Parent.DatabaseID,
 				"depth", i+1)
-			return normalizePageID(block.Parent.DatabaseID), "database_id", nil
+			dbID := normalizePageID(block.Parent.DatabaseID)
+			c.parents.storeChain(append(chain, currentID), blockParent{id: dbID, parentType: "database_id"})
+			return dbID, "database_id", nil
 		case parentTypeBlockID:
 			// Continue tracing up
+			chain = append(chain, currentID)
 			currentID = block.Parent.BlockID
 		case parentTypeWorkspace:
 			c.logger.DebugContext(ctx, "block chain leads to workspace",
 				"block_id", blockID,
 				"depth", i+1)
+			c.parents.storeChain(append(chain, currentID), blockParent{parentType: parentTypeWorkspace})
 			return "", parentTypeWorkspace, nil
 		default:
 			return "", "", fmt.Errorf("%w: %s", apperrors.ErrUnexpectedBlockParentType, block.Parent.Type)
@@ -507,6 +537,7 @@ func (c *Crawler) writeRegistryAndQueue(
 		"path", filePath)
 
 	now := time.Now()
+	assets := c.updateAssetPages(ctx, itemID, c.registeredAssets(ctx, itemID), true)
 
 	// Save page registry
 	if err := c.savePageRegistry(ctx, &PageRegistry{
@@ -522,6 +553,7 @@ func (c *Crawler) writeRegistryAndQueue(
 		ParentID:       parentID,
 		Children:       children,
 		ContentHash:    contentHash,
+		Assets:         assets,
 	}); err != nil {
 		c.logger.WarnContext(ctx, "failed to save page registry", "error", err)
 	}
@@ -567,7 +599,7 @@ func (c *Crawler) savePageFromNotion(ctx context.Context, page *notion.Page, fol
 			return fmt.Errorf("fetch database: %w", dbErr)
 		}
 
-		dbPages, dbErr := c.client.QueryDatabase(ctx, pageID)
+		dbPages, dbErr := c.client.QueryDatabasePages(ctx, database)
 		if dbErr != nil {
 			return fmt.Errorf("query database: %w", dbErr)
 		}
@@ -582,8 +614,9 @@ func (c *Crawler) savePageFromNotion(ctx context.Context, page *notion.Page, fol
 		}
 		filePath := c.computeFilePath(ctx, syntheticPage, folder, isRoot, parentID)
 
-		content := c.converter.ConvertDatabase(database, dbPages, &converter.ConvertOptions{
+		content := c.convertDatabase(ctx, database, dbPages, &converter.ConvertOptions{
 			Folder:        folder,
+			Profile:       GetConfig().profileFor(folder),
 			PageTitle:     database.GetTitle(),
 			FilePath:      filePath,
 			LastSynced:    time.Now(),
@@ -591,6 +624,8 @@ func (c *Crawler) savePageFromNotion(ctx context.Context, page *notion.Page, fol
 			IsRoot:        isRoot,
 			ParentID:      parentID,
 			FileProcessor: c.makeFileProcessor(ctx, filePath, pageID),
+
+			DatabaseRenderMode: GetConfig().DatabaseRender,
 		})
 
 		var children []string
@@ -608,49 +643,34 @@ func (c *Crawler) savePageFromNotion(ctx context.Context, page *notion.Page, fol
 	parentID := c.resolveParentID(ctx, pageID, notionKeyPageID, page.Parent)
 	filePath := c.computeFilePath(ctx, page, folder, isRoot, parentID)
 
-	content := c.converter.ConvertWithOptions(page, blocks, &converter.ConvertOptions{
-		Folder:        folder,
-		PageTitle:     page.Title(),
-		FilePath:      filePath,
-		LastSynced:    time.Now(),
-		NotionType:    notionTypePage,
-		IsRoot:        isRoot,
-		ParentID:      parentID,
-		FileProcessor: c.makeFileProcessor(ctx, filePath, pageID),
+	content := c.convertPage(ctx, page, blocks, &converter.ConvertOptions{
+		Folder:           folder,
+		Profile:          GetConfig().profileFor(folder),
+		InlineDatabases:  c.fetchInlineDatabases(ctx, blocks),
+		CodeCaptions:     GetConfig().CodeCaptions,
+		HTMLTableColumns: GetConfig().htmlTableColumnsFor(GetConfig().profileFor(folder)),
+		PageTitle:        page.Title(),
+		FilePath:         filePath,
+		LastSynced:       time.Now(),
+		NotionType:       notionTypePage,
+		IsRoot:           isRoot,
+		ParentID:         parentID,
+		Ancestors:        c.pageAncestors(ctx, parentID, blocks),
+		FileProcessor:    c.makeFileProcessor(ctx, filePath, pageID),
+		AssetProcessor:   c.makeAssetProcessor(ctx, filePath, pageID),
 	})
 
-	children := c.findChildPages(blocks)
+	children := c.findChildPages(ctx, blocks, folder)
 
 	return c.writeRegistryAndQueue(ctx, filePath, pageID, notionTypePage,
 		page.Title(), folder, parentID, page.LastEditedTime, isRoot, content, children)
 }
 
-// findChildPages extracts child page and child database IDs from blocks.
-func (c *Crawler) findChildPages(blocks []notion.Block) []string {
+// findChildPages extracts child page and child database IDs from blocks, except those excluded in the folder.
+func (c *Crawler) findChildPages(ctx context.Context, blocks []notion.Block, folder string) []string {
 	var children []string
-	var traverse func([]notion.Block)
-
-	traverse = func(blocks []notion.Block) {
-		for i := range blocks {
-			block := &blocks[i]
-			if block.Type == "child_page" && block.ChildPage != nil {
-				childID := normalizePageID(block.ID)
-				if !slices.Contains(children, childID) {
-					children = append(children, childID)
-				}
-			}
-			if block.Type == "child_database" && block.ChildDatabase != nil {
-				childID := normalizePageID(block.ID)
-				if !slices.Contains(children, childID) {
-					children = append(children, childID)
-				}
-			}
-			if len(block.Children) > 0 {
-				traverse(block.Children)
-			}
-		}
+	for _, ref := range c.excludeChildren(ctx, folder, findChildPageRefs(blocks)) {
+		children = append(children, ref.id)
 	}
-
-	traverse(blocks)
 	return children
 }
@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+const defaultAdoptFolder = "default"
+
+// defaultAdoptIDKeys are the frontmatter keys holding the Notion ID (or URL) of a page, as written by
+// ntnsync and common Notion exporters.
+var defaultAdoptIDKeys = []string{"notion_id", "notion-id", "notionId", "notion_page_id", "id", "notion_url", "url"}
+
+// adoptLastEditedKeys are the frontmatter keys holding the last edit time of a page.
+var adoptLastEditedKeys = []string{"last_edited", "last_edited_time", "last-edited", "lastEdited", "updated"}
+
+// AdoptOptions configures the adoption of markdown files generated by another exporter.
+type AdoptOptions struct {
+	IDKeys        []string // Frontmatter keys holding the Notion ID or URL, in order (default: common keys)
+	DefaultFolder string   // Folder of files at the top of the repository (default: "default")
+	DryRun        bool     // Report what would be adopted without saving registries
+}
+
+// AdoptResult contains the result of an adoption.
+type AdoptResult struct {
+	Adopted    []*PageRegistry // Registries created for adopted files
+	Tracked    int             // Files of pages that already have a registry
+	NoID       []string        // Files without a Notion ID in their frontmatter
+	Duplicates []string        // Files of pages adopted from another file
+}
+
+// Adopt builds page registries from the frontmatter of existing markdown files, generated by another
+// Notion exporter, and marks them as synced. The first ntnsync run then only downloads pages edited in
+// Notion after their last_edited time (or after the adoption, without one) instead of rewriting the
+// whole repository. Pages that already have a registry are left untouched.
+func (c *Crawler) Adopt(ctx context.Context, opts AdoptOptions) (*AdoptResult, error) {
+	if len(opts.IDKeys) == 0 {
+		opts.IDKeys = defaultAdoptIDKeys
+	}
+	if opts.DefaultFolder == "" {
+		opts.DefaultFolder = defaultAdoptFolder
+	}
+	if err := c.loadState(ctx); err != nil {
+		c.logger.WarnContext(ctx, "could not load state, starting fresh", "error", err)
+	}
+
+	mdFiles, err := c.findMarkdownFiles(ctx, ".")
+	if err != nil {
+		return nil, fmt.Errorf("find markdown files: %w", err)
+	}
+	slices.Sort(mdFiles)
+
+	result := &AdoptResult{}
+	adopted := make(map[string]bool)
+	now := time.Now()
+	for _, filePath := range mdFiles {
+		reg := c.adoptFile(ctx, filePath, &opts, now)
+		switch {
+		case reg == nil:
+			result.NoID = append(result.NoID, filePath)
+		case adopted[reg.ID]:
+			result.Duplicates = append(result.Duplicates, filePath)
+		case c.isTracked(ctx, reg.ID):
+			result.Tracked++
+		default:
+			adopted[reg.ID] = true
+			result.Adopted = append(result.Adopted, reg)
+		}
+	}
+
+	c.logger.InfoContext(ctx, "adoption summary",
+		"files", len(mdFiles),
+		"adopted", len(result.Adopted),
+		"tracked", result.Tracked,
+		"no_id", len(result.NoID),
+		"duplicates", len(result.Duplicates),
+		"dry_run", opts.DryRun)
+
+	if opts.DryRun || len(result.Adopted) == 0 {
+		return result, nil
+	}
+	if err := c.saveAdoptedRegistries(ctx, result.Adopted); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// adoptFile builds the registry of a markdown file from its frontmatter. Returns nil if the file has
+// no frontmatter or no Notion ID.
+func (c *Crawler) adoptFile(ctx context.Context, filePath string, opts *AdoptOptions, now time.Time) *PageRegistry {
+	content, err := c.store.Read(ctx, filePath)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to read file", "path", filePath, "error", err)
+		return nil
+	}
+	lines := strings.Split(string(content), "\n")
+	endIdx, err := c.findFrontmatterEnd(lines)
+	if err != nil {
+		return nil
+	}
+	fields := frontmatterScalars(lines[1:endIdx])
+
+	pageID := adoptPageID(fields, opts.IDKeys)
+	if pageID == "" {
+		return nil
+	}
+
+	hash := sha256.Sum256(content)
+	reg := &PageRegistry{
+		NtnsyncVersion: version.Version,
+		ID:             pageID,
+		Type:           notionTypePage,
+		Folder:         adoptFolder(fields, filePath, opts.DefaultFolder),
+		FilePath:       filePath,
+		Title:          fields["title"],
+		LastEdited:     adoptLastEdited(fields, now),
+		LastSynced:     now,
+		IsRoot:         fields["is_root"] == "true",
+		ParentID:       normalizePageID(fields["notion_parent_id"]),
+		ContentHash:    hex.EncodeToString(hash[:]),
+		Size:           int64(len(content)),
+	}
+	if fields["notion_type"] == notionTypeDatabase {
+		reg.Type = notionTypeDatabase
+	}
+	if reg.Title == "" {
+		c.extractTitle(lines, endIdx, filePath, reg)
+	}
+	return reg
+}
+
+// isTracked returns true if the page already has a registry.
+func (c *Crawler) isTracked(ctx context.Context, pageID string) bool {
+	_, err := c.loadPageRegistry(ctx, pageID)
+	return err == nil
+}
+
+// saveAdoptedRegistries saves the registries of adopted pages and records their folders in the state.
+func (c *Crawler) saveAdoptedRegistries(ctx context.Context, registries []*PageRegistry) error {
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return fmt.Errorf("ensure transaction: %w", err)
+	}
+	for _, reg := range registries {
+		if err := c.savePageRegistry(ctx, reg); err != nil {
+			return fmt.Errorf("save registry %s: %w", reg.ID, err)
+		}
+		c.state.AddFolder(reg.Folder)
+	}
+	if err := c.saveState(ctx); err != nil {
+		return fmt.Errorf("save state: %w", err)
+	}
+	return nil
+}
+
+// frontmatterScalars returns the top-level scalar fields of frontmatter lines, unquoted.
+func frontmatterScalars(lines []string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range lines {
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		fields[strings.TrimSpace(key)] = unquoteFrontmatterValue(value)
+	}
+	return fields
+}
+
+// adoptPageID returns the Notion ID of the first ID key holding a valid ID or Notion URL.
+func adoptPageID(fields map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := fields[key]; value != "" {
+			if pageID, err := notion.ParsePageIDOrURL(value); err == nil {
+				return pageID
+			}
+		}
+	}
+	return ""
+}
+
+// adoptFolder returns the folder of an adopted file: its notion_folder, or its top-level directory.
+func adoptFolder(fields map[string]string, filePath, defaultFolder string) string {
+	if folder := fields["notion_folder"]; folder != "" {
+		return folder
+	}
+	if folder, _, found := strings.Cut(filePath, "/"); found {
+		return folder
+	}
+	return defaultFolder
+}
+
+// adoptLastEdited returns the last edit time of an adopted file, or the adoption time without one.
+func adoptLastEdited(fields map[string]string, now time.Time) time.Time {
+	for _, key := range adoptLastEditedKeys {
+		for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+			if t, err := time.Parse(layout, fields[key]); err == nil {
+				return t
+			}
+		}
+	}
+	return now
+}
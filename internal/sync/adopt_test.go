@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAdopt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	files := map[string]string{
+		"docs/guide.md": "---\nnotion_id: \"1234567890abcdef1234567890abcdef\"\ntitle: Guide\n" +
+			"last_edited_time: 2026-02-01T10:00:00Z\n---\n\n# Guide\n",
+		"docs/guide-copy.md": "---\nid: 12345678-90ab-cdef-1234-567890abcdef\n---\n\n# Guide copy\n",
+		"wiki/faq.md":        "---\nurl: https://www.notion.so/FAQ-abcdefabcdefabcdefabcdefabcdefab\n---\n\n# FAQ\n",
+		"readme.md":          "---\nnotion_id: fedcbafedcbafedcbafedcbafedcbafe\n---\n\n# Readme\n",
+		"notes.md":           "# No frontmatter\n",
+		"tracked.md":         "---\nnotion_id: 00000000000000000000000000000001\n---\n\n# Tracked\n",
+	}
+	for path, content := range files {
+		if err := crawler.tx.Write(ctx, path, []byte(content)); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{
+		ID: "00000000000000000000000000000001", Type: notionTypePage, Folder: "other", FilePath: "other/tracked.md",
+	}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	dryRun, err := crawler.Adopt(ctx, AdoptOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Adopt(dry run) error = %v", err)
+	}
+	if len(dryRun.Adopted) != 3 {
+		t.Fatalf("dry run adopted %d pages, want 3", len(dryRun.Adopted))
+	}
+	if _, err := crawler.loadPageRegistry(ctx, "fedcbafedcbafedcbafedcbafedcbafe"); err == nil {
+		t.Fatal("dry run saved a registry")
+	}
+
+	result, err := crawler.Adopt(ctx, AdoptOptions{DefaultFolder: "root"})
+	if err != nil {
+		t.Fatalf("Adopt() error = %v", err)
+	}
+	if len(result.Adopted) != 3 || result.Tracked != 1 || len(result.NoID) != 1 || len(result.Duplicates) != 1 {
+		t.Fatalf("result = %d adopted, %d tracked, %v without ID, %v duplicates",
+			len(result.Adopted), result.Tracked, result.NoID, result.Duplicates)
+	}
+	if result.Duplicates[0] != "docs/guide.md" {
+		t.Errorf("duplicate = %s, want docs/guide.md (docs/guide-copy.md sorts first)", result.Duplicates[0])
+	}
+
+	guide, err := crawler.loadPageRegistry(ctx, "1234567890abcdef1234567890abcdef")
+	if err != nil {
+		t.Fatalf("loadPageRegistry(guide) error = %v", err)
+	}
+	if guide.Folder != "docs" || guide.FilePath != "docs/guide-copy.md" || guide.Title != "Guide copy" ||
+		guide.ContentHash == "" || guide.LastSynced.IsZero() {
+		t.Errorf("guide registry = %+v", guide)
+	}
+
+	faq, err := crawler.loadPageRegistry(ctx, "abcdefabcdefabcdefabcdefabcdefab")
+	if err != nil {
+		t.Fatalf("loadPageRegistry(faq) error = %v", err)
+	}
+	if faq.Folder != "wiki" || faq.Title != "FAQ" {
+		t.Errorf("faq registry = %+v", faq)
+	}
+
+	readme, err := crawler.loadPageRegistry(ctx, "fedcbafedcbafedcbafedcbafedcbafe")
+	if err != nil {
+		t.Fatalf("loadPageRegistry(readme) error = %v", err)
+	}
+	if readme.Folder != "root" {
+		t.Errorf("readme folder = %q, want root", readme.Folder)
+	}
+
+	tracked, err := crawler.loadPageRegistry(ctx, "00000000000000000000000000000001")
+	if err != nil || tracked.Folder != "other" {
+		t.Errorf("tracked registry = %+v, %v, want it untouched", tracked, err)
+	}
+
+	again, err := crawler.Adopt(ctx, AdoptOptions{})
+	if err != nil {
+		t.Fatalf("Adopt(again) error = %v", err)
+	}
+	if len(again.Adopted) != 0 || again.Tracked != 5 {
+		t.Errorf("second adoption = %d adopted, %d tracked, want 0 and 5", len(again.Adopted), again.Tracked)
+	}
+}
+
+func TestAdoptLastEdited(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   time.Time
+	}{
+		{"rfc3339", map[string]string{"last_edited": "2026-02-01T10:00:00Z"}, time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{"date", map[string]string{"updated": "2026-03-04"}, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"invalid", map[string]string{"last_edited": "yesterday"}, now},
+		{"missing", map[string]string{}, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := adoptLastEdited(tt.fields, now); !got.Equal(tt.want) {
+				t.Errorf("adoptLastEdited() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
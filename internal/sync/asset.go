@@ -0,0 +1,285 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+const (
+	// assetsDir holds the icons and covers of pages, and their files with NTN_FILE_STORAGE=assets, shared by
+	// all pages of the mirror.
+	assetsDir = "assets"
+	// assetHashLength is the number of hex characters of the content hash naming an asset.
+	assetHashLength = 16
+)
+
+// File storage modes, selecting where the files of pages (images, PDFs, attachments) are stored.
+const (
+	// FileStoragePage stores the files of a page in its files directory (<page>/files/<name>).
+	FileStoragePage = "page"
+	// FileStorageAssets stores them in the assets directory, named after their content, so that a file used by
+	// several pages is stored once.
+	FileStorageAssets = "assets"
+)
+
+// processAssetURL stores a Notion-hosted file of a page in the assets directory and returns its path.
+// Assets are named after their content, so that a file used by several pages is stored once, and are only
+// downloaded again when Notion gives them a new file ID. The assets a page uses are recorded while it is
+// converted, and written to their registries with the page (see updateAssetPages), so that cleanup can remove
+// the assets no page uses anymore (see collectAssets).
+// Other URLs, and files that cannot be downloaded, are returned as is.
+func (c *Crawler) processAssetURL(ctx context.Context, fileURL, pageID string) string {
+	fileID := extractFileIDFromURL(fileURL)
+	if fileID == "" {
+		return fileURL
+	}
+	if reg, err := c.loadFileRegistry(ctx, fileID); err == nil {
+		c.recordAsset(pageID, reg)
+		return reg.FilePath
+	}
+	if c.skipDownloads {
+		return fileURL
+	}
+
+	content, err := c.fetchAsset(ctx, fileURL)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to download asset", "url", fileURL, "error", err)
+		return fileURL
+	}
+
+	assetPath := assetPathFor(fileURL, content)
+	if exists, _ := c.store.Exists(ctx, assetPath); !exists {
+		if err := c.tx.Write(ctx, assetPath, content); err != nil {
+			c.logger.WarnContext(ctx, "failed to write asset", "path", assetPath, "error", err)
+			return fileURL
+		}
+		c.logger.InfoContext(ctx, "downloaded asset", "path", assetPath, "size", FormatBytes(int64(len(content))))
+	}
+
+	reg := &FileRegistry{
+		NtnsyncVersion: version.Version,
+		ID:             fileID,
+		FilePath:       assetPath,
+		SourceURL:      fileURL,
+		LastSynced:     time.Now(),
+	}
+	if pageID != "" {
+		reg.PageIDs = []string{normalizePageID(pageID)}
+	}
+	if err := c.saveFileRegistry(ctx, reg); err != nil {
+		c.logger.WarnContext(ctx, "failed to save file registry", "error", err)
+	}
+	c.recordAsset(pageID, reg)
+	return assetPath
+}
+
+// recordAsset records that the page being converted uses a file of the assets directory.
+func (c *Crawler) recordAsset(pageID string, reg *FileRegistry) {
+	if pageID == "" || filepath.Dir(reg.FilePath) != assetsDir {
+		return
+	}
+
+	c.assetsMu.Lock()
+	defer c.assetsMu.Unlock()
+	pageID = normalizePageID(pageID)
+	if c.renderedAssets == nil {
+		c.renderedAssets = make(map[string]map[string]bool)
+	}
+	if c.renderedAssets[pageID] == nil {
+		c.renderedAssets[pageID] = make(map[string]bool)
+	}
+	c.renderedAssets[pageID][reg.ID] = true
+}
+
+// takeRenderedAssets returns the file IDs of the assets recorded for a page since the last call, sorted. The
+// result is never nil: an empty list records that the page uses no asset (see PageRegistry.Assets).
+func (c *Crawler) takeRenderedAssets(pageID string) []string {
+	c.assetsMu.Lock()
+	defer c.assetsMu.Unlock()
+	pageID = normalizePageID(pageID)
+	assets := slices.Sorted(maps.Keys(c.renderedAssets[pageID]))
+	delete(c.renderedAssets, pageID)
+	if assets == nil {
+		return []string{}
+	}
+	return assets
+}
+
+// registeredAssets returns the assets recorded in the registry of a page, if it has one.
+func (c *Crawler) registeredAssets(ctx context.Context, pageID string) []string {
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		return nil
+	}
+	return reg.Assets
+}
+
+// updateAssetPages writes the page to the registries of the assets used by its conversion, and returns the file
+// IDs to record in the page registry. previous are the assets recorded at its last sync (nil = not recorded).
+// When replace is set, the conversion is the whole page: the page is removed from the registries of the
+// previous assets it does not use anymore, and registries no page uses are deleted, leaving their file to
+// cleanup. Otherwise (partial conversions, like property refreshes) the previous assets are kept.
+func (c *Crawler) updateAssetPages(ctx context.Context, pageID string, previous []string, replace bool) []string {
+	pageID = normalizePageID(pageID)
+	rendered := c.takeRenderedAssets(pageID)
+	assets := rendered
+	if !replace && previous == nil {
+		assets = nil // Still not recorded: the rest of the page may use other assets
+	} else if !replace {
+		assets = slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(previous), rendered...))))
+	}
+	isPage := func(id string) bool { return normalizePageID(id) == pageID }
+
+	c.assetsMu.Lock()
+	defer c.assetsMu.Unlock()
+	for _, fileID := range rendered {
+		reg, err := c.loadFileRegistry(ctx, fileID)
+		if err != nil || slices.ContainsFunc(reg.PageIDs, isPage) {
+			continue
+		}
+		reg.PageIDs = append(reg.PageIDs, pageID)
+		if err := c.saveFileRegistry(ctx, reg); err != nil {
+			c.logger.WarnContext(ctx, "failed to save file registry", "file_id", fileID, "error", err)
+		}
+	}
+	for _, fileID := range previous {
+		if !replace || slices.Contains(rendered, fileID) {
+			continue
+		}
+		reg, err := c.loadFileRegistry(ctx, fileID)
+		if err != nil {
+			continue
+		}
+		reg.PageIDs = slices.DeleteFunc(reg.PageIDs, isPage)
+		if len(reg.PageIDs) == 0 {
+			err = c.deleteFileRegistry(ctx, fileID)
+		} else {
+			err = c.saveFileRegistry(ctx, reg)
+		}
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to update file registry", "file_id", fileID, "error", err)
+		}
+	}
+	return assets
+}
+
+// assetPathFor returns the path of an asset: its content hash, with the extension of its URL.
+func assetPathFor(fileURL string, content []byte) string {
+	hash := sha256.Sum256(content)
+	ext := ""
+	if parsed, err := url.Parse(fileURL); err == nil {
+		ext = strings.ToLower(path.Ext(parsed.Path))
+	}
+	return filepath.Join(assetsDir, hex.EncodeToString(hash[:])[:assetHashLength]+ext)
+}
+
+// fetchAsset downloads an asset in memory, so that it can be named after its content.
+func (c *Crawler) fetchAsset(ctx context.Context, fileURL string) ([]byte, error) {
+	body, err := c.openDownload(ctx, fileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	maxSize := getMaxFileSize()
+	content, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read asset: %w", err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, ErrFileTooLarge
+	}
+	return content, nil
+}
+
+// makeAssetProcessor creates a converter.FileProcessor storing the icon and cover of a page as local assets,
+// referenced relative to the page's directory. It returns nil when asset downloading is disabled.
+func (c *Crawler) makeAssetProcessor(ctx context.Context, pageFilePath, pageID string) converter.FileProcessor {
+	if !GetConfig().DownloadAssets {
+		return nil
+	}
+	return func(fileURL string) string {
+		assetPath := c.processAssetURL(ctx, fileURL, pageID)
+		if assetPath == fileURL {
+			return fileURL
+		}
+		relPath, err := filepath.Rel(filepath.Dir(pageFilePath), assetPath)
+		if err != nil {
+			return assetPath
+		}
+		return filepath.ToSlash(relPath)
+	}
+}
+
+// collectAssets deletes the registries of the files of the assets directory no live page uses anymore, then the
+// assets no registry references anymore. livePages are the registries of the pages kept by the cleanup, by ID: a
+// page uses an asset if it is listed in the asset registry and, when the page records its assets (pages synced
+// since assets were recorded), among them. Registries that don't record their pages (icons and covers stored
+// before pages were recorded) are kept.
+func (c *Crawler) collectAssets(
+	ctx context.Context, livePages map[string]*PageRegistry, dryRun bool, result *CleanupResult,
+) error {
+	registries, err := c.listFileRegistries(ctx)
+	if err != nil {
+		return fmt.Errorf("list file registries: %w", err)
+	}
+
+	used := make(map[string]bool, len(registries))
+	for _, reg := range registries {
+		usedBy := func(pageID string) bool {
+			page := livePages[normalizePageID(pageID)]
+			return page != nil && (page.Assets == nil || slices.Contains(page.Assets, reg.ID))
+		}
+		if len(reg.PageIDs) == 0 || slices.ContainsFunc(reg.PageIDs, usedBy) {
+			used[filepath.ToSlash(reg.FilePath)] = true
+			continue
+		}
+		if filepath.Dir(reg.FilePath) != assetsDir || dryRun {
+			continue
+		}
+		if err := c.deleteFileRegistry(ctx, reg.ID); err != nil {
+			c.logger.WarnContext(ctx, "failed to delete file registry", "file_id", reg.ID, "error", err)
+			used[filepath.ToSlash(reg.FilePath)] = true
+			continue
+		}
+		result.DeletedRegistries++
+	}
+
+	entries, err := c.store.List(ctx, assetsDir)
+	if err != nil {
+		return nil //nolint:nilerr // No assets directory, nothing to collect
+	}
+	for i := range entries {
+		assetPath := filepath.ToSlash(entries[i].Path)
+		if entries[i].IsDir || used[assetPath] {
+			continue
+		}
+
+		result.UnusedAssets++
+		c.logger.InfoContext(ctx, "found unused asset", "path", assetPath, "dry_run", dryRun)
+		if dryRun {
+			continue
+		}
+		if err := c.deleteFile(ctx, assetPath); err != nil {
+			c.logger.WarnContext(ctx, "failed to delete unused asset", "path", assetPath, "error", err)
+			continue
+		}
+		result.DeletedFiles++
+	}
+	return nil
+}
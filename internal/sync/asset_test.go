@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAssetPathFor(t *testing.T) {
+	t.Parallel()
+
+	first := assetPathFor("https://s3.us-west-2.amazonaws.com/ws/id-1/Cover.PNG?X-Amz-Expires=3600", []byte("image"))
+	same := assetPathFor("https://s3.us-west-2.amazonaws.com/ws/id-2/copy.png", []byte("image"))
+	other := assetPathFor("https://s3.us-west-2.amazonaws.com/ws/id-3/Cover.png", []byte("other image"))
+
+	if !strings.HasPrefix(first, "assets/") || !strings.HasSuffix(first, ".png") {
+		t.Errorf("assetPathFor() = %q, want assets/<hash>.png", first)
+	}
+	if first != same {
+		t.Errorf("identical images have different paths: %q and %q", first, same)
+	}
+	if first == other {
+		t.Errorf("different images have the same path %q", first)
+	}
+}
+
+func TestProcessAssetURL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	// Other URLs are kept
+	external := "https://images.unsplash.com/photo.jpg"
+	if got := crawler.processAssetURL(ctx, external, "page1"); got != external {
+		t.Errorf("processAssetURL(external) = %q, want it unchanged", got)
+	}
+
+	// Assets already stored are not downloaded again
+	fileURL := "https://prod-files-secure.s3.us-west-2.amazonaws.com/ws/7d399803-3851-448f-ac8e-c40d666389ee/icon.png"
+	if err := crawler.saveFileRegistry(ctx, &FileRegistry{
+		ID: "7d3998033851448fac8ec40d666389ee", FilePath: "assets/0123456789abcdef.png", SourceURL: fileURL,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := crawler.processAssetURL(ctx, fileURL, "page1"); got != "assets/0123456789abcdef.png" {
+		t.Errorf("processAssetURL(registered) = %q, want the registered asset", got)
+	}
+
+	// The pages using an asset are recorded when they are written
+	if assets := crawler.updateAssetPages(ctx, "page1", nil, true); !slices.Equal(assets, []string{
+		"7d3998033851448fac8ec40d666389ee",
+	}) {
+		t.Errorf("updateAssetPages() = %v, want the registered asset", assets)
+	}
+	reg, err := crawler.loadFileRegistry(ctx, "7d3998033851448fac8ec40d666389ee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(reg.PageIDs, []string{"page1"}) {
+		t.Errorf("PageIDs = %v, want [page1]", reg.PageIDs)
+	}
+}
+
+func TestUpdateAssetPages(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	for _, reg := range []*FileRegistry{
+		{ID: "shared", FilePath: "assets/shared.png", PageIDs: []string{"page1", "page2"}},
+		{ID: "dropped", FilePath: "assets/dropped.png", PageIDs: []string{"page1"}},
+		{ID: "icon", FilePath: "assets/icon.png", PageIDs: []string{"page2"}},
+	} {
+		if err := crawler.saveFileRegistry(ctx, reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pageIDs := func(fileID string) []string {
+		reg, err := crawler.loadFileRegistry(ctx, fileID)
+		if err != nil {
+			return nil
+		}
+		return reg.PageIDs
+	}
+
+	// page1 now only uses the icon: it leaves the other registries, and the one nobody uses is deleted
+	crawler.recordAsset("page1", &FileRegistry{ID: "icon", FilePath: "assets/icon.png"})
+	assets := crawler.updateAssetPages(ctx, "page1", []string{"dropped", "shared"}, true)
+	if !slices.Equal(assets, []string{"icon"}) {
+		t.Errorf("updateAssetPages() = %v, want [icon]", assets)
+	}
+	for fileID, want := range map[string][]string{
+		"shared": {"page2"}, "dropped": nil, "icon": {"page2", "page1"},
+	} {
+		if got := pageIDs(fileID); !slices.Equal(got, want) {
+			t.Errorf("%s PageIDs = %v, want %v", fileID, got, want)
+		}
+	}
+
+	// A page using no asset records it, and partial conversions keep the previous assets
+	if assets := crawler.updateAssetPages(ctx, "page3", nil, true); assets == nil || len(assets) != 0 {
+		t.Errorf("updateAssetPages() without assets = %#v, want an empty list", assets)
+	}
+	if assets := crawler.updateAssetPages(ctx, "page2", []string{"icon", "shared"}, false); !slices.Equal(
+		assets, []string{"icon", "shared"}) {
+		t.Errorf("updateAssetPages() of a partial conversion = %v, want the previous assets", assets)
+	}
+	if assets := crawler.updateAssetPages(ctx, "page4", nil, false); assets != nil {
+		t.Errorf("updateAssetPages() of a partial conversion = %v, want assets still not recorded", assets)
+	}
+}
+
+func TestCollectAssets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	for _, reg := range []*FileRegistry{
+		{ID: "shared", FilePath: "assets/shared.png", PageIDs: []string{"gone", "kept"}},
+		{ID: "unused", FilePath: "assets/unused.png", PageIDs: []string{"gone"}},
+		{ID: "icon", FilePath: "assets/icon.png"}, // Pages not recorded
+		{ID: "stale", FilePath: "assets/stale.png", PageIDs: []string{"kept"}},
+	} {
+		if err := crawler.saveFileRegistry(ctx, reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, assetPath := range []string{
+		"assets/shared.png", "assets/unused.png", "assets/icon.png", "assets/stray.pdf", "assets/stale.png",
+	} {
+		if err := crawler.tx.Write(ctx, assetPath, []byte(assetPath)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := &CleanupResult{}
+	// kept records its assets: its stale entry in another registry does not keep that asset
+	livePages := map[string]*PageRegistry{"kept": {ID: "kept", Assets: []string{"shared"}}}
+	if err := crawler.collectAssets(ctx, livePages, false, result); err != nil {
+		t.Fatalf("collectAssets() error = %v", err)
+	}
+
+	if result.UnusedAssets != 3 || result.DeletedFiles != 3 || result.DeletedRegistries != 2 {
+		t.Errorf("result = %+v, want 3 unused assets and 2 registries deleted", result)
+	}
+	for assetPath, want := range map[string]bool{
+		"assets/shared.png": true, "assets/icon.png": true, "assets/unused.png": false, "assets/stray.pdf": false,
+		"assets/stale.png": false,
+	} {
+		if exists, _ := crawler.store.Exists(ctx, assetPath); exists != want {
+			t.Errorf("%s exists = %v, want %v", assetPath, exists, want)
+		}
+	}
+	if _, err := crawler.loadFileRegistry(ctx, "unused"); err == nil {
+		t.Error("the registry of the unused asset should be deleted")
+	}
+}
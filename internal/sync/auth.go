@@ -0,0 +1,15 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+)
+
+// CheckAuth checks that Notion accepts the token. It returns an error wrapping apperrors.ErrNotionUnauthorized
+// if the token is rejected.
+func (c *Crawler) CheckAuth(ctx context.Context) error {
+	if _, err := c.client.GetMe(ctx); err != nil {
+		return fmt.Errorf("check notion token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// blockCacheMaxAge is how long a block cache is used for block diffs. Older caches are refreshed with a full
+// fetch, in case a change was missed.
+const blockCacheMaxAge = 24 * time.Hour
+
+// blockCachePrefix is the registry prefix of block caches.
+const blockCachePrefix = "blocks"
+
+// contextKey is the type of the context keys of the sync package.
+type contextKey string
+
+// updatedBlocksKey is the context key for storing the blocks changed since the last sync of a page.
+const updatedBlocksKey contextKey = "updatedBlocks"
+
+// withUpdatedBlocks returns a new context with the blocks changed since the last sync of the page being processed.
+func withUpdatedBlocks(ctx context.Context, blockIDs []string) context.Context {
+	if len(blockIDs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, updatedBlocksKey, blockIDs)
+}
+
+// updatedBlocksFromContext extracts the changed blocks from context, returns nil if they are unknown.
+func updatedBlocksFromContext(ctx context.Context) []string {
+	if blockIDs, ok := ctx.Value(updatedBlocksKey).([]string); ok {
+		return blockIDs
+	}
+	return nil
+}
+
+// BlockCache is the block tree of a page as it was last fetched, so that only changed subtrees are fetched again.
+type BlockCache struct {
+	PageID     string        `json:"page_id"`
+	LastEdited time.Time     `json:"last_edited"` // Last edited time of the page when its blocks were fetched
+	FetchedAt  time.Time     `json:"fetched_at"`
+	MaxDepth   int           `json:"max_depth"` // Block depth limit of the fetch (0 = unlimited)
+	Blocks     []cachedBlock `json:"blocks"`
+}
+
+// cachedBlock is a block as returned by the API, with its children.
+type cachedBlock struct {
+	Block    json.RawMessage `json:"block"`
+	Children []cachedBlock   `json:"children,omitempty"`
+}
+
+// cachedBlockNode locates a cached block in the tree.
+type cachedBlockNode struct {
+	parentID string
+	children []cachedBlock
+}
+
+// newCachedBlocks converts fetched blocks to their cached form.
+func newCachedBlocks(blocks []notion.Block) ([]cachedBlock, error) {
+	cached := make([]cachedBlock, 0, len(blocks))
+	for i := range blocks {
+		raw := blocks[i].Raw
+		if len(raw) == 0 {
+			var err error
+			if raw, err = json.Marshal(&blocks[i]); err != nil {
+				return nil, fmt.Errorf("marshal block %s: %w", blocks[i].ID, err)
+			}
+		}
+		children, err := newCachedBlocks(blocks[i].Children)
+		if err != nil {
+			return nil, err
+		}
+		cached = append(cached, cachedBlock{Block: raw, Children: children})
+	}
+	return cached, nil
+}
+
+// decodeCachedBlocks converts cached blocks back to blocks.
+func decodeCachedBlocks(cached []cachedBlock) ([]notion.Block, error) {
+	blocks := make([]notion.Block, 0, len(cached))
+	for i := range cached {
+		var block notion.Block
+		if err := json.Unmarshal(cached[i].Block, &block); err != nil {
+			return nil, fmt.Errorf("unmarshal cached block: %w", err)
+		}
+		children, err := decodeCachedBlocks(cached[i].Children)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) > 0 {
+			block.Children = children
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// indexCachedBlocks indexes the cached blocks under parentID by normalized block ID.
+func indexCachedBlocks(index map[string]*cachedBlockNode, parentID string, cached []cachedBlock) {
+	for i := range cached {
+		var block struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(cached[i].Block, &block); err != nil || block.ID == "" {
+			continue
+		}
+		blockID := normalizePageID(block.ID)
+		index[blockID] = &cachedBlockNode{parentID: parentID, children: cached[i].Children}
+		indexCachedBlocks(index, blockID, cached[i].Children)
+	}
+}
+
+// loadBlockCache loads the block cache of a page.
+func (c *Crawler) loadBlockCache(ctx context.Context, pageID string) (*BlockCache, error) {
+	return loadRegistry[BlockCache](ctx, c, blockCachePrefix, normalizePageID(pageID))
+}
+
+// saveBlockCache saves the fetched blocks of a page as its block cache.
+func (c *Crawler) saveBlockCache(ctx context.Context, page *notion.Page, result notion.BlockFetchResult) error {
+	blocks, err := newCachedBlocks(result.Blocks)
+	if err != nil {
+		return err
+	}
+	cache := &BlockCache{
+		PageID:     normalizePageID(page.ID),
+		LastEdited: page.LastEditedTime,
+		FetchedAt:  time.Now().UTC(),
+		MaxDepth:   result.MaxDepth,
+		Blocks:     blocks,
+	}
+	return saveRegistry(ctx, c, blockCachePrefix, cache.PageID, cache)
+}
+
+// blockCachePath returns the path of the block cache of a page.
+func blockCachePath(pageID string) string {
+	return filepath.Join(stateDir, idsDir, fmt.Sprintf("%s-%s.json", blockCachePrefix, normalizePageID(pageID)))
+}
+
+// fetchPageBlocks fetches the blocks of a page. With block diff enabled, when the blocks changed since the last
+// sync are known (from webhook events) and the block cache matches the synced version of the page, only the
+// top-level blocks and the children of changed blocks are listed: other subtrees come from the cache.
+func (c *Crawler) fetchPageBlocks(
+	ctx context.Context, page *notion.Page, pageID string, maxDepth int,
+) (notion.BlockFetchResult, error) {
+	if !GetConfig().BlockDiff {
+		return c.client.GetAllBlockChildrenWithLimit(ctx, pageID, maxDepth)
+	}
+
+	result, diffed, err := c.fetchChangedBlocks(ctx, pageID, maxDepth)
+	if err != nil {
+		return notion.BlockFetchResult{}, err
+	}
+	if !diffed {
+		if result, err = c.client.GetAllBlockChildrenWithLimit(ctx, pageID, maxDepth); err != nil {
+			return notion.BlockFetchResult{}, err
+		}
+	}
+
+	if err := c.saveBlockCache(ctx, page, result); err != nil {
+		c.logger.WarnContext(ctx, "failed to save block cache", notionKeyPageID, pageID, "error", err)
+	}
+	return result, nil
+}
+
+// fetchChangedBlocks fetches the blocks of a page, refetching only the changed subtrees.
+// Returns false if a full fetch is needed instead.
+func (c *Crawler) fetchChangedBlocks(
+	ctx context.Context, pageID string, maxDepth int,
+) (notion.BlockFetchResult, bool, error) {
+	updated := updatedBlocksFromContext(ctx)
+	if len(updated) == 0 {
+		return notion.BlockFetchResult{}, false, nil
+	}
+	cache, err := c.loadBlockCache(ctx, pageID)
+	if err != nil || !c.blockCacheUsable(ctx, pageID, cache, maxDepth) {
+		c.logger.DebugContext(ctx, "no usable block cache, fetching all blocks", notionKeyPageID, pageID)
+		return notion.BlockFetchResult{}, false, nil
+	}
+
+	index := make(map[string]*cachedBlockNode)
+	indexCachedBlocks(index, normalizePageID(pageID), cache.Blocks)
+
+	relist, ok := c.blocksToRelist(ctx, pageID, updated, index)
+	if !ok {
+		return notion.BlockFetchResult{}, false, nil
+	}
+
+	// Ancestors of relisted blocks are walked through, from the cache
+	descend := make(map[string]bool)
+	for blockID := range relist {
+		for node := index[blockID]; node != nil; node = index[node.parentID] {
+			if descend[node.parentID] {
+				break
+			}
+			descend[node.parentID] = true
+		}
+	}
+
+	differ := &blockDiffer{
+		crawler:  c,
+		index:    index,
+		relist:   relist,
+		descend:  descend,
+		maxDepth: maxDepth,
+	}
+	blocks, err := differ.walk(ctx, pageID, cache.Blocks, true, 0)
+	if err != nil {
+		return notion.BlockFetchResult{}, false, err
+	}
+
+	c.logger.DebugContext(ctx, "fetched changed blocks",
+		notionKeyPageID, pageID,
+		"updated_blocks", len(updated),
+		"listed", differ.listed,
+		"reused", differ.reused)
+	return notion.BlockFetchResult{Blocks: blocks, WasLimited: differ.wasLimited, MaxDepth: maxDepth}, true, nil
+}
+
+// blockCacheUsable returns true if the block cache is the one of the synced version of the page.
+func (c *Crawler) blockCacheUsable(ctx context.Context, pageID string, cache *BlockCache, maxDepth int) bool {
+	if cache.MaxDepth != maxDepth || time.Since(cache.FetchedAt) > blockCacheMaxAge {
+		return false
+	}
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	return err == nil && reg.LastEdited.Equal(cache.LastEdited)
+}
+
+// blocksToRelist returns the blocks whose children must be listed again: changed blocks, for their children, and
+// their parents, for their content. Blocks missing from the cache (new blocks) are looked up to find their parent.
+// Returns false if a changed block cannot be located in the cache.
+func (c *Crawler) blocksToRelist(
+	ctx context.Context, pageID string, updated []string, index map[string]*cachedBlockNode,
+) (map[string]bool, bool) {
+	pageKey := normalizePageID(pageID)
+	relist := make(map[string]bool)
+	for _, blockID := range updated {
+		blockID = normalizePageID(blockID)
+		if node, found := index[blockID]; found {
+			relist[blockID] = true
+			relist[node.parentID] = true
+			continue
+		}
+
+		block, err := c.client.GetBlock(ctx, blockID)
+		if err != nil {
+			c.logger.DebugContext(ctx, "failed to locate new block, fetching all blocks",
+				notionKeyPageID, pageID, "block_id", blockID, "error", err)
+			return nil, false
+		}
+		parentID := normalizePageID(block.Parent.BlockID)
+		if parentID == "" {
+			parentID = normalizePageID(block.Parent.PageID)
+		}
+		if _, found := index[parentID]; parentID != pageKey && !found {
+			c.logger.DebugContext(ctx, "new block outside the cached blocks, fetching all blocks",
+				notionKeyPageID, pageID, "block_id", blockID, "parent_id", parentID)
+			return nil, false
+		}
+		relist[parentID] = true
+	}
+	return relist, true
+}
+
+// blockDiffer rebuilds the block tree of a page from its cache and the changed blocks.
+type blockDiffer struct {
+	crawler    *Crawler
+	index      map[string]*cachedBlockNode
+	relist     map[string]bool // Blocks whose children are listed again
+	descend    map[string]bool // Blocks with a relisted descendant
+	maxDepth   int
+	wasLimited bool
+	listed     int // Blocks whose children were listed
+	reused     int // Subtrees reused from the cache
+}
+
+// walk returns the children of a block: listed from the API if list is true, from the cache otherwise.
+func (d *blockDiffer) walk(
+	ctx context.Context, blockID string, cached []cachedBlock, list bool, depth int,
+) ([]notion.Block, error) {
+	var blocks []notion.Block
+	var err error
+	if list {
+		d.listed++
+		blocks, err = d.crawler.listBlockChildren(ctx, blockID)
+	} else {
+		blocks, err = decodeCachedBlocks(stripCachedChildren(cached))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range blocks {
+		block := &blocks[i]
+		if !block.HasChildren {
+			continue
+		}
+		if d.maxDepth > 0 && depth >= d.maxDepth {
+			d.wasLimited = true
+			continue
+		}
+
+		childID := normalizePageID(block.ID)
+		node, known := d.index[childID]
+		switch {
+		case !known:
+			// New block: its whole subtree is fetched
+			block.Children, err = d.walk(ctx, block.ID, nil, true, depth+1)
+		case d.relist[childID] || d.descend[childID]:
+			block.Children, err = d.walk(ctx, block.ID, node.children, d.relist[childID], depth+1)
+		default:
+			d.reused++
+			block.Children, err = decodeCachedBlocks(node.children)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// stripCachedChildren returns cached blocks without their children, which walk resolves itself.
+func stripCachedChildren(cached []cachedBlock) []cachedBlock {
+	stripped := make([]cachedBlock, len(cached))
+	for i := range cached {
+		stripped[i] = cachedBlock{Block: cached[i].Block}
+	}
+	return stripped
+}
+
+// listBlockChildren lists the children of a block, without their own children.
+func (c *Crawler) listBlockChildren(ctx context.Context, blockID string) ([]notion.Block, error) {
+	var blocks []notion.Block
+	var cursor string
+	for {
+		result, err := c.client.GetBlockChildren(ctx, blockID, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("list block children: %w", err)
+		}
+		blocks = append(blocks, result.Results...)
+		if !result.HasMore || result.NextCursor == nil {
+			return blocks, nil
+		}
+		cursor = *result.NextCursor
+	}
+}
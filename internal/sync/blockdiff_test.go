@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// testBlockJSON returns the JSON of a paragraph block.
+func testBlockJSON(id, text string, hasChildren bool) string {
+	return fmt.Sprintf(
+		`{"object":"block","id":%q,"type":"paragraph","has_children":%t,`+
+			`"paragraph":{"rich_text":[{"type":"text","plain_text":%q}]}}`,
+		id, hasChildren, text)
+}
+
+// Cannot use t.Parallel() with t.Setenv
+func TestFetchPageBlocks_BlockDiff(t *testing.T) {
+	t.Setenv("NTN_BLOCK_DIFF", "true")
+	ResetConfig()
+	defer ResetConfig()
+
+	var mu stdsync.Mutex
+	children := map[string][]string{
+		"page1": {testBlockJSON("a", "A", false), testBlockJSON("t", "T", true), testBlockJSON("u", "U", true)},
+		"t":     {testBlockJSON("t1", "old", false)},
+		"u":     {testBlockJSON("u1", "U1", false)},
+	}
+	listed := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blockID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/blocks/"), "/children")
+		mu.Lock()
+		defer mu.Unlock()
+		listed[blockID]++
+		fmt.Fprintf(w, `{"object":"list","results":[%s],"has_more":false}`, strings.Join(children[blockID], ","))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	lastEdited := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := &notion.Page{ID: "page1", LastEditedTime: lastEdited}
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{ID: "page1", LastEdited: lastEdited}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	// Without changed blocks, all the blocks are fetched and cached
+	if _, err := crawler.fetchPageBlocks(ctx, page, "page1", 0); err != nil {
+		t.Fatalf("fetchPageBlocks() error = %v", err)
+	}
+	if listed["page1"] != 1 || listed["t"] != 1 || listed["u"] != 1 {
+		t.Fatalf("full fetch listed %v", listed)
+	}
+
+	// A nested block changes: only its parent and the page are listed again
+	mu.Lock()
+	children["t"] = []string{testBlockJSON("t1", "new", false)}
+	mu.Unlock()
+	result, err := crawler.fetchPageBlocks(withUpdatedBlocks(ctx, []string{"t1"}), page, "page1", 0)
+	if err != nil {
+		t.Fatalf("fetchPageBlocks() error = %v", err)
+	}
+	if listed["page1"] != 2 || listed["t"] != 2 || listed["u"] != 1 {
+		t.Errorf("block diff listed %v, want page1 and t only", listed)
+	}
+
+	if len(result.Blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(result.Blocks))
+	}
+	if got := result.Blocks[1].Children[0].Paragraph.RichText[0].PlainText; got != "new" {
+		t.Errorf("changed block text = %q, want %q", got, "new")
+	}
+	if got := result.Blocks[2].Children[0].Paragraph.RichText[0].PlainText; got != "U1" {
+		t.Errorf("cached block text = %q, want %q", got, "U1")
+	}
+}
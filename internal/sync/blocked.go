@@ -0,0 +1,281 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+// Reasons recorded in BlockedRegistry.
+const (
+	blockedReasonPermanentError = "permanent_error"
+	blockedReasonArchived       = "archived"
+	blockedReasonParent         = "blocked_parent"
+)
+
+// blockedParentError is returned when a page cannot be synced because its parent is blocked.
+// It carries the blocked ancestor so the whole subtree points at the same origin.
+type blockedParentError struct {
+	parentID  string
+	blockedBy string
+}
+
+// Error implements the error interface.
+func (e *blockedParentError) Error() string {
+	return fmt.Sprintf("%s: %s (blocked by %s)", apperrors.ErrParentBlocked, e.parentID, e.blockedBy)
+}
+
+// Unwrap allows errors.Is(err, apperrors.ErrParentBlocked).
+func (e *blockedParentError) Unwrap() error {
+	return apperrors.ErrParentBlocked
+}
+
+// BlockedInfo contains displayable information about a blocked page.
+type BlockedInfo struct {
+	ID        string    `json:"id"`
+	Folder    string    `json:"folder"`
+	Reason    string    `json:"reason"`
+	Error     string    `json:"error,omitempty"`
+	BlockedBy string    `json:"blocked_by,omitempty"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// saveBlockedRegistry saves a blocked registry file.
+func (c *Crawler) saveBlockedRegistry(ctx context.Context, reg *BlockedRegistry) error {
+	reg.ID = normalizePageID(reg.ID)
+	return saveRegistry(ctx, c, "blocked", reg.ID, reg)
+}
+
+// loadBlockedRegistry loads a blocked registry file.
+func (c *Crawler) loadBlockedRegistry(ctx context.Context, pageID string) (*BlockedRegistry, error) {
+	return loadRegistry[BlockedRegistry](ctx, c, "blocked", normalizePageID(pageID))
+}
+
+// deleteBlockedRegistry deletes the blocked registry file of a page.
+func (c *Crawler) deleteBlockedRegistry(ctx context.Context, pageID string) error {
+	path := filepath.Join(stateDir, idsDir, fmt.Sprintf("blocked-%s.json", normalizePageID(pageID)))
+	return c.tx.Delete(ctx, path)
+}
+
+// unblockPage removes the blocked marker of a page once it has been synced successfully.
+// The pages blocked under it are unblocked too, and queued again: they were dropped from the queue.
+func (c *Crawler) unblockPage(ctx context.Context, pageID string) {
+	if _, err := c.loadBlockedRegistry(ctx, pageID); err != nil {
+		return // Not blocked
+	}
+
+	if err := c.deleteBlockedRegistry(ctx, pageID); err != nil {
+		c.logger.WarnContext(ctx, "failed to delete blocked registry", notionKeyPageID, pageID, "error", err)
+		return
+	}
+	c.logger.InfoContext(ctx, "page is no longer blocked", notionKeyPageID, pageID)
+
+	c.requeueBlockedSubtree(ctx, normalizePageID(pageID))
+}
+
+// requeueBlockedSubtree unblocks the pages recorded as blocked by a blocked ancestor, and queues them by folder.
+func (c *Crawler) requeueBlockedSubtree(ctx context.Context, blockedBy string) {
+	registries, err := c.listBlockedRegistries(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to list blocked registries", "error", err)
+		return
+	}
+
+	byFolder := make(map[string][]string)
+	var folders []string
+	for _, reg := range registries {
+		if reg.Reason != blockedReasonParent || normalizePageID(reg.BlockedBy) != blockedBy {
+			continue
+		}
+		if err := c.deleteBlockedRegistry(ctx, reg.ID); err != nil {
+			c.logger.WarnContext(ctx, "failed to delete blocked registry", notionKeyPageID, reg.ID, "error", err)
+			continue
+		}
+		if _, ok := byFolder[reg.Folder]; !ok {
+			folders = append(folders, reg.Folder)
+		}
+		byFolder[reg.Folder] = append(byFolder[reg.Folder], reg.ID)
+	}
+
+	for _, folder := range folders {
+		c.logger.InfoContext(ctx, "queueing pages no longer under a blocked parent",
+			"blocked_by", blockedBy,
+			"folder", folder,
+			"pages", len(byFolder[folder]))
+		if _, err := c.queueManager.CreateEntry(ctx, queue.Entry{
+			Type:    "update",
+			Folder:  folder,
+			PageIDs: byFolder[folder],
+		}); err != nil {
+			c.logger.WarnContext(ctx, "failed to queue unblocked pages", "folder", folder, "error", err)
+		}
+	}
+}
+
+// blockReason classifies a processing error.
+// Returns the blocked reason and the blocked ancestor, or an empty reason if the error is transient.
+func blockReason(err error, pageID string) (string, string) {
+	var parentErr *blockedParentError
+	switch {
+	case errors.As(err, &parentErr):
+		return blockedReasonParent, parentErr.blockedBy
+	case errors.Is(err, apperrors.ErrPageArchived):
+		return blockedReasonArchived, pageID
+	case errors.Is(err, apperrors.ErrNotionUnauthorized):
+		return "", "" // The token was rejected, not the page
+	case notion.IsPermanentError(err):
+		return blockedReasonPermanentError, pageID
+	default:
+		return "", ""
+	}
+}
+
+// markPageBlocked records a page as blocked so its subtree stops being retried.
+func (c *Crawler) markPageBlocked(ctx context.Context, pageID, folder, reason, blockedBy string, cause error) {
+	reg := &BlockedRegistry{
+		NtnsyncVersion: version.Version,
+		ID:             pageID,
+		Folder:         folder,
+		Reason:         reason,
+		BlockedAt:      time.Now(),
+	}
+	if normalizePageID(blockedBy) != normalizePageID(pageID) {
+		reg.BlockedBy = normalizePageID(blockedBy)
+	}
+	if cause != nil {
+		reg.Error = cause.Error()
+	}
+
+	if err := c.saveBlockedRegistry(ctx, reg); err != nil {
+		c.logger.WarnContext(ctx, "failed to save blocked registry", notionKeyPageID, pageID, "error", err)
+	}
+}
+
+// checkParentBlocked returns a blockedParentError if the given parent is blocked.
+func (c *Crawler) checkParentBlocked(ctx context.Context, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+
+	reg, loadErr := c.loadBlockedRegistry(ctx, parentID)
+	if loadErr != nil {
+		return nil // Parent is not blocked
+	}
+
+	blockedBy := reg.BlockedBy
+	if blockedBy == "" {
+		blockedBy = reg.ID
+	}
+	return &blockedParentError{parentID: reg.ID, blockedBy: blockedBy}
+}
+
+// shouldSkipBlockedPage checks whether a queued page is blocked.
+// A page blocked on its own is retried when the queue reports an edit more recent than
+// the block (e.g. it was restored or shared again), unless it was purged. A page under a blocked parent is
+// skipped and recorded as blocked itself, so the entire subtree is surfaced.
+func (c *Crawler) shouldSkipBlockedPage(
+	ctx context.Context, pageID, folder, parentID string, queueLastEdited time.Time,
+) bool {
+	if reg, err := c.loadBlockedRegistry(ctx, pageID); err == nil {
+		if reg.Reason != blockedReasonPurged && !queueLastEdited.IsZero() && queueLastEdited.After(reg.BlockedAt) {
+			c.logger.InfoContext(ctx, "retrying blocked page edited since it was blocked",
+				notionKeyPageID, pageID,
+				"reason", reg.Reason)
+			return false
+		}
+		c.logger.DebugContext(ctx, "skipping blocked page",
+			notionKeyPageID, pageID,
+			"reason", reg.Reason)
+		return true
+	}
+
+	if parentErr := c.checkParentBlocked(ctx, parentID); parentErr != nil {
+		reason, blockedBy := blockReason(parentErr, pageID)
+		c.logger.InfoContext(ctx, "skipping page under blocked parent",
+			notionKeyPageID, pageID,
+			"parent_id", parentID,
+			"blocked_by", blockedBy)
+		c.markPageBlocked(ctx, pageID, folder, reason, blockedBy, parentErr)
+		return true
+	}
+
+	return false
+}
+
+// blockedSinceEdit returns the reason a page is blocked, if it was not edited since it was blocked.
+// Returns an empty string if the page is not blocked, or was edited since (purged pages stay blocked).
+func (c *Crawler) blockedSinceEdit(ctx context.Context, pageID string, lastEdited time.Time) string {
+	reg, err := c.loadBlockedRegistry(ctx, pageID)
+	if err != nil || (reg.Reason != blockedReasonPurged && lastEdited.After(reg.BlockedAt)) {
+		return ""
+	}
+	c.logger.DebugContext(ctx, "skipping blocked page",
+		notionKeyPageID, pageID,
+		"reason", reg.Reason)
+	return reg.Reason
+}
+
+// handleProcessError decides what to do with a page that failed processing.
+// Returns true if the page should be kept in the queue for a later retry.
+func (c *Crawler) handleProcessError(
+	ctx context.Context, pageID, folder string, err error, stats *queueProcessingStats,
+) bool {
+	if errors.Is(err, apperrors.ErrNotionUnauthorized) {
+		c.logger.ErrorContext(ctx, "notion token rejected, stopping the run", notionKeyPageID, pageID, "error", err)
+		stats.authErr = err
+		return true
+	}
+
+	reason, blockedBy := blockReason(err, pageID)
+	if reason == "" {
+		c.logger.ErrorContext(ctx, "failed to process page (will retry)", notionKeyPageID, pageID, "error", err)
+		return true
+	}
+
+	c.logger.WarnContext(ctx, "dropping page from queue (blocked)",
+		notionKeyPageID, pageID,
+		"reason", reason,
+		"error", err)
+	c.markPageBlocked(ctx, pageID, folder, reason, blockedBy, err)
+	stats.totalDropped++
+	return false
+}
+
+// listBlockedRegistries lists all blocked registries.
+func (c *Crawler) listBlockedRegistries(ctx context.Context) ([]*BlockedRegistry, error) {
+	entries, err := c.store.List(ctx, filepath.Join(stateDir, idsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var registries []*BlockedRegistry
+	for i := range entries {
+		entry := &entries[i]
+		if entry.IsDir || !strings.HasPrefix(filepath.Base(entry.Path), "blocked-") {
+			continue
+		}
+
+		data, err := c.store.Read(ctx, entry.Path)
+		if err != nil {
+			continue
+		}
+
+		var reg BlockedRegistry
+		if err := json.Unmarshal(data, &reg); err != nil {
+			continue
+		}
+
+		registries = append(registries, &reg)
+	}
+
+	return registries, nil
+}
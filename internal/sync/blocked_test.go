@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestBlockReason(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		err           error
+		wantReason    string
+		wantBlockedBy string
+	}{
+		{
+			name:          "not found",
+			err:           fmt.Errorf("fetch page: %w", &notion.APIError{Status: 404, Code: "object_not_found"}),
+			wantReason:    blockedReasonPermanentError,
+			wantBlockedBy: "page1",
+		},
+		{
+			name:          "archived",
+			err:           fmt.Errorf("page page1: %w", apperrors.ErrPageArchived),
+			wantReason:    blockedReasonArchived,
+			wantBlockedBy: "page1",
+		},
+		{
+			name:          "blocked parent",
+			err:           fmt.Errorf("wrapped: %w", &blockedParentError{parentID: "parent", blockedBy: "grandparent"}),
+			wantReason:    blockedReasonParent,
+			wantBlockedBy: "grandparent",
+		},
+		{
+			name: "rate limited",
+			err:  &notion.APIError{Status: 429, Code: "rate_limited"},
+		},
+		{
+			name: "generic",
+			err:  errors.New("connection reset"), //nolint:err113 // test error
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reason, blockedBy := blockReason(tc.err, "page1")
+			if reason != tc.wantReason {
+				t.Errorf("reason = %q, want %q", reason, tc.wantReason)
+			}
+			if blockedBy != tc.wantBlockedBy {
+				t.Errorf("blockedBy = %q, want %q", blockedBy, tc.wantBlockedBy)
+			}
+		})
+	}
+}
+
+func TestProcessQueue_BlockedParentBlocksSubtree(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+
+	// The parent was blocked because its own parent (the grandparent) is archived
+	crawler.markPageBlocked(ctx, "parent", "test", blockedReasonParent, "grandparent", nil)
+
+	if _, err := qm.CreateEntry(ctx, queue.Entry{
+		Type:     queueTypeInit,
+		Folder:   "test",
+		PageIDs:  []string{"child1", "child2"},
+		ParentID: "parent",
+	}); err != nil {
+		t.Fatalf("failed to create queue entry: %v", err)
+	}
+
+	// No client: any attempt to fetch a page would panic
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue failed: %v", err)
+	}
+
+	files, err := qm.ListEntries(ctx)
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected queue to be empty, got %d files", len(files))
+	}
+
+	for _, childID := range []string{"child1", "child2"} {
+		reg, err := crawler.loadBlockedRegistry(ctx, childID)
+		if err != nil {
+			t.Fatalf("expected %s to be blocked: %v", childID, err)
+		}
+		if reg.Reason != blockedReasonParent {
+			t.Errorf("%s reason = %q, want %q", childID, reg.Reason, blockedReasonParent)
+		}
+		if reg.BlockedBy != "grandparent" {
+			t.Errorf("%s blocked_by = %q, want %q", childID, reg.BlockedBy, "grandparent")
+		}
+	}
+
+	status, err := crawler.GetStatus(ctx, "test")
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if len(status.BlockedPages) != 3 {
+		t.Errorf("expected 3 blocked pages in status, got %d", len(status.BlockedPages))
+	}
+}
+
+func TestUnblockPage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	crawler.markPageBlocked(ctx, "page1", "test", blockedReasonArchived, "page1", apperrors.ErrPageArchived)
+	if err := crawler.checkParentBlocked(ctx, "page1"); !errors.Is(err, apperrors.ErrParentBlocked) {
+		t.Fatalf("checkParentBlocked() = %v, want ErrParentBlocked", err)
+	}
+
+	crawler.unblockPage(ctx, "page1")
+
+	if err := crawler.checkParentBlocked(ctx, "page1"); err != nil {
+		t.Errorf("checkParentBlocked() after unblock = %v, want nil", err)
+	}
+}
+
+func TestUnblockPage_RequeuesSubtree(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+
+	crawler.markPageBlocked(ctx, "parent", "test", blockedReasonArchived, "parent", apperrors.ErrPageArchived)
+	crawler.markPageBlocked(ctx, "child", "test", blockedReasonParent, "parent", apperrors.ErrParentBlocked)
+	crawler.markPageBlocked(ctx, "grandchild", "test", blockedReasonParent, "parent", apperrors.ErrParentBlocked)
+	crawler.markPageBlocked(ctx, "other", "test", blockedReasonParent, "elsewhere", apperrors.ErrParentBlocked)
+
+	crawler.unblockPage(ctx, "parent")
+
+	for _, id := range []string{"child", "grandchild"} {
+		if _, err := crawler.loadBlockedRegistry(ctx, id); err == nil {
+			t.Errorf("%s should no longer be blocked", id)
+		}
+	}
+	if _, err := crawler.loadBlockedRegistry(ctx, "other"); err != nil {
+		t.Errorf("other should stay blocked: %v", err)
+	}
+
+	files, err := qm.ListEntries(ctx)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListEntries() = %v, %v, want one entry", files, err)
+	}
+	entry, err := qm.ReadEntry(ctx, files[0])
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	var queued []string
+	for _, page := range entry.Pages {
+		queued = append(queued, page.ID)
+	}
+	slices.Sort(queued)
+	if entry.Folder != "test" || !slices.Equal(queued, []string{"child", "grandchild"}) {
+		t.Errorf("queued = %s %v, want test [child grandchild]", entry.Folder, queued)
+	}
+}
@@ -0,0 +1,44 @@
+package sync
+
+import (
+	"context"
+	"slices"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// maxAncestors bounds the walk up the page hierarchy, in case of a registry cycle.
+const maxAncestors = 32
+
+// pageAncestors returns the titles of the synced ancestors of a page, from its root to parentID, for the breadcrumb
+// blocks of the page. Returns nil if blocks contain no breadcrumb.
+func (c *Crawler) pageAncestors(ctx context.Context, parentID string, blocks []notion.Block) []string {
+	if parentID == "" || !containsBlockType(blocks, "breadcrumb") {
+		return nil
+	}
+
+	var titles []string
+	for currentID := parentID; currentID != "" && len(titles) < maxAncestors; {
+		reg, err := c.loadPageRegistry(ctx, currentID)
+		if err != nil {
+			break
+		}
+		titles = append(titles, reg.Title)
+		if reg.IsRoot {
+			break
+		}
+		currentID = reg.ParentID
+	}
+	slices.Reverse(titles)
+	return titles
+}
+
+// containsBlockType returns true if blocks or their children contain a block of the given type.
+func containsBlockType(blocks []notion.Block, blockType string) bool {
+	for i := range blocks {
+		if blocks[i].Type == blockType || containsBlockType(blocks[i].Children, blockType) {
+			return true
+		}
+	}
+	return false
+}
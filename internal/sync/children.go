@@ -0,0 +1,129 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// webhookSyncKey is the context key marking the processing of webhook queue entries.
+const webhookSyncKey contextKey = "webhookSync"
+
+// withWebhookSync returns a new context marking the processing of webhook queue entries.
+func withWebhookSync(ctx context.Context) context.Context {
+	return context.WithValue(ctx, webhookSyncKey, true)
+}
+
+// isWebhookSync returns true if the context is the processing of webhook queue entries.
+func isWebhookSync(ctx context.Context) bool {
+	webhook, _ := ctx.Value(webhookSyncKey).(bool)
+	return webhook
+}
+
+// removedChildren returns the previous children that are not in current.
+func removedChildren(previous, current []string) []string {
+	var removed []string
+	for _, childID := range previous {
+		childID = normalizePageID(childID)
+		if !slices.ContainsFunc(current, func(id string) bool { return normalizePageID(id) == childID }) {
+			removed = append(removed, childID)
+		}
+	}
+	return removed
+}
+
+// reconcileRemovedChildren handles the synced children a page no longer has: children moved to another parent are
+// unlinked from the page and queued to be synced under their new parent, trashed or deleted children are removed
+// from the mirror. Their own descendants are left to `ntnsync cleanup`.
+// Children still under the page in Notion (e.g. beyond NTN_BLOCK_DEPTH) are kept.
+func (c *Crawler) reconcileRemovedChildren(ctx context.Context, parentID string, previous, current []string) {
+	for _, childID := range removedChildren(previous, current) {
+		reg, err := c.loadPageRegistry(ctx, childID)
+		if err != nil || normalizePageID(reg.ParentID) != normalizePageID(parentID) {
+			continue // Not synced, or already synced under another parent
+		}
+
+		newParentID, trashed, err := c.childParent(ctx, reg)
+		switch {
+		case err != nil:
+			c.logger.WarnContext(ctx, "failed to check removed child",
+				notionKeyPageID, childID, "parent_id", parentID, "error", err)
+		case trashed:
+			c.trashChild(ctx, reg)
+		case newParentID != normalizePageID(parentID):
+			c.unlinkChild(ctx, reg, newParentID)
+		}
+	}
+}
+
+// childParent returns the current parent of a synced child in Notion, or true if it was trashed or deleted.
+func (c *Crawler) childParent(ctx context.Context, reg *PageRegistry) (string, bool, error) {
+	var parent notion.Parent
+	var trashed bool
+	var err error
+	if reg.Type == notionTypeDatabase {
+		var database *notion.Database
+		if database, err = c.client.GetDatabase(ctx, reg.ID); err == nil {
+			parent, trashed = database.Parent, database.Archived || database.InTrash
+		}
+	} else {
+		var page *notion.Page
+		if page, err = c.client.GetPage(ctx, reg.ID); err == nil {
+			parent, trashed = page.Parent, page.Archived || page.InTrash
+		}
+	}
+
+	var apiErr *notion.APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return "", true, nil
+	}
+	if err != nil || trashed {
+		return "", trashed, err
+	}
+	return c.resolveParentID(ctx, reg.ID, notionKeyPageID, parent), false, nil
+}
+
+// trashChild removes a trashed child from the mirror: its file and its registries. It is recorded as blocked, so
+// that its pending queue entries are dropped, until it is restored.
+func (c *Crawler) trashChild(ctx context.Context, reg *PageRegistry) {
+	c.logger.InfoContext(ctx, "removing trashed child page",
+		notionKeyPageID, reg.ID,
+		notionKeyTitle, reg.Title,
+		"file_path", reg.FilePath,
+		"parent_id", reg.ParentID)
+
+	for _, path := range []string{reg.FilePath, blockCachePath(reg.ID)} {
+		if path == "" {
+			continue
+		}
+		if err := c.deleteFile(ctx, path); err != nil {
+			c.logger.WarnContext(ctx, "failed to delete file of trashed child", "file_path", path, "error", err)
+		}
+	}
+	if err := c.deletePageRegistry(ctx, reg.ID); err != nil {
+		c.logger.WarnContext(ctx, "failed to delete registry of trashed child", notionKeyPageID, reg.ID, "error", err)
+	}
+	c.markPageBlocked(ctx, reg.ID, reg.Folder, blockedReasonArchived, reg.ID, nil)
+	c.addFolderUsage(reg.Folder, -1, -reg.Size)
+}
+
+// unlinkChild detaches a moved child from its former parent and queues it, to sync it under its new parent.
+func (c *Crawler) unlinkChild(ctx context.Context, reg *PageRegistry, newParentID string) {
+	c.logger.InfoContext(ctx, "child page moved to another parent",
+		notionKeyPageID, reg.ID,
+		notionKeyTitle, reg.Title,
+		"old_parent_id", reg.ParentID,
+		"new_parent_id", newParentID)
+
+	reg.ParentID = newParentID
+	if err := c.savePageRegistry(ctx, reg); err != nil {
+		c.logger.WarnContext(ctx, "failed to unlink moved child", notionKeyPageID, reg.ID, "error", err)
+	}
+	if _, err := c.queueManager.CreateWebhookEntryWithType(ctx, reg.ID, reg.Folder, queue.TypeUpdate); err != nil {
+		c.logger.WarnContext(ctx, "failed to queue moved child", notionKeyPageID, reg.ID, "error", err)
+	}
+}
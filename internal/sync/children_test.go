@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestRemovedChildren(t *testing.T) {
+	t.Parallel()
+
+	got := removedChildren([]string{"a", "b-1", "c"}, []string{"c", "a"})
+	if len(got) != 1 || got[0] != "b1" {
+		t.Errorf("removedChildren() = %v, want [b1]", got)
+	}
+}
+
+func TestReconcileRemovedChildren(t *testing.T) {
+	t.Parallel()
+
+	parents := map[string]string{
+		"trashed": `"archived":true,"parent":{"type":"page_id","page_id":"parent"}`,
+		"moved":   `"parent":{"type":"page_id","page_id":"other"}`,
+		"deep":    `"parent":{"type":"page_id","page_id":"parent"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageID := strings.TrimPrefix(r.URL.Path, "/pages/")
+		fields, found := parents[pageID]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"object":"error","status":404,"code":"object_not_found","message":"not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"object":"page","id":%q,%s}`, pageID, fields)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	children := []string{"kept", "trashed", "moved", "deep", "deleted"}
+	for _, id := range children {
+		filePath := "test/parent/" + id + ".md"
+		if err := crawler.tx.Write(ctx, filePath, []byte("# "+id+"\n")); err != nil {
+			t.Fatalf("write %s: %v", filePath, err)
+		}
+		reg := &PageRegistry{ID: id, Type: notionTypePage, Folder: "test", FilePath: filePath, ParentID: "parent"}
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	crawler.reconcileRemovedChildren(ctx, "parent", children, []string{"kept"})
+
+	for _, id := range []string{"trashed", "deleted"} {
+		if _, err := crawler.loadPageRegistry(ctx, id); err == nil {
+			t.Errorf("registry of %s should be deleted", id)
+		}
+		if _, err := crawler.store.Read(ctx, "test/parent/"+id+".md"); err == nil {
+			t.Errorf("file of %s should be deleted", id)
+		}
+		if _, err := crawler.loadBlockedRegistry(ctx, id); err != nil {
+			t.Errorf("%s should be blocked: %v", id, err)
+		}
+	}
+
+	moved, err := crawler.loadPageRegistry(ctx, "moved")
+	if err != nil || moved.ParentID != "other" {
+		t.Errorf("moved child = %+v, %v, want parent other", moved, err)
+	}
+	if queued, err := qm.IsPageQueued(ctx, "moved", "update"); err != nil || !queued {
+		t.Errorf("moved child should be queued: %v", err)
+	}
+
+	for _, id := range []string{"kept", "deep"} {
+		if reg, err := crawler.loadPageRegistry(ctx, id); err != nil || reg.ParentID != "parent" {
+			t.Errorf("child %s = %+v, %v, want unchanged", id, reg, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package sync
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	stdsync "sync"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// pendingChanges tracks the files written or deleted since the last commit, so that large syncs can be
+// committed in chunks that git hosts accept.
+type pendingChanges struct {
+	mu        stdsync.Mutex
+	files     map[string]int64 // Size of the changed files, by path (0 for deletions)
+	committed map[string]bool  // Files committed since the last push notification
+}
+
+// record records a changed file.
+func (p *pendingChanges) record(path string, size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.files == nil {
+		p.files = make(map[string]int64)
+	}
+	p.files[path] = size
+}
+
+// commit marks the changes as committed, so that they are part of the next push notification.
+func (p *pendingChanges) commit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.committed == nil {
+		p.committed = make(map[string]bool)
+	}
+	for path := range p.files {
+		p.committed[path] = true
+	}
+	p.files = nil
+}
+
+// takeCommitted returns the files committed since the last call, sorted.
+func (p *pendingChanges) takeCommitted() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	paths := slices.Sorted(maps.Keys(p.committed))
+	p.committed = nil
+	return paths
+}
+
+// restoreCommitted records again files returned by takeCommitted, when they could not be notified.
+func (p *pendingChanges) restoreCommitted(paths []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.committed == nil {
+		p.committed = make(map[string]bool)
+	}
+	for _, path := range paths {
+		p.committed[path] = true
+	}
+}
+
+// reset forgets the uncommitted changes, after a rollback.
+func (p *pendingChanges) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files = nil
+}
+
+// totals returns the number and the total size of the changed files.
+func (p *pendingChanges) totals() (int, int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var size int64
+	for _, fileSize := range p.files {
+		size += fileSize
+	}
+	return len(p.files), size
+}
+
+// countingTx is a transaction recording its changes as pending until they are committed.
+type countingTx struct {
+	store.Transaction
+	pending *pendingChanges
+}
+
+// Write writes a file and records it.
+func (t *countingTx) Write(ctx context.Context, path string, content []byte) error {
+	if err := t.Transaction.Write(ctx, path, content); err != nil {
+		return err
+	}
+	t.pending.record(path, int64(len(content)))
+	return nil
+}
+
+// WriteStream writes a file from a reader and records it.
+func (t *countingTx) WriteStream(ctx context.Context, path string, reader io.Reader) (int64, error) {
+	written, err := t.Transaction.WriteStream(ctx, path, reader)
+	if err == nil {
+		t.pending.record(path, written)
+	}
+	return written, err
+}
+
+// Delete deletes a file and records it.
+func (t *countingTx) Delete(ctx context.Context, path string) error {
+	if err := t.Transaction.Delete(ctx, path); err != nil {
+		return err
+	}
+	t.pending.record(path, 0)
+	return nil
+}
+
+// Commit commits the changes, which are no longer pending.
+func (t *countingTx) Commit(ctx context.Context, message string) error {
+	if err := t.Transaction.Commit(ctx, message); err != nil {
+		return err
+	}
+	t.pending.commit()
+	return nil
+}
+
+// Rollback discards the changes, which are no longer pending.
+func (t *countingTx) Rollback(ctx context.Context) error {
+	err := t.Transaction.Rollback(ctx)
+	t.pending.reset()
+	return err
+}
+
+// PendingChanges returns the number and the total size of the files changed since the last commit.
+func (c *Crawler) PendingChanges() (int, int64) {
+	return c.pending.totals()
+}
+
+// CommitChunkReached returns true if the changes since the last commit reached NTN_COMMIT_MAX_FILES or
+// NTN_COMMIT_MAX_SIZE, so that they should be committed (and pushed) before the sync goes on.
+func (c *Crawler) CommitChunkReached() bool {
+	files, size := c.PendingChanges()
+	cfg := GetConfig()
+	return (cfg.CommitMaxFiles > 0 && files >= cfg.CommitMaxFiles) ||
+		(cfg.CommitMaxSize > 0 && size >= cfg.CommitMaxSize)
+}
+
+// CommitChunking returns true if large syncs are committed in chunks.
+func (cfg *Config) CommitChunking() bool {
+	return cfg.CommitMaxFiles > 0 || cfg.CommitMaxSize > 0
+}
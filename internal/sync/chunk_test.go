@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCrawler_PendingChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	if err := crawler.tx.Write(ctx, "tech/a.md", []byte("12345")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crawler.tx.WriteStream(ctx, "tech/a/files/b.png", strings.NewReader("123")); err != nil {
+		t.Fatal(err)
+	}
+	// Rewriting a file counts it once, with its last size
+	if err := crawler.tx.Write(ctx, "tech/a.md", []byte("1234567")); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.tx.Delete(ctx, "tech/a/files/b.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.tx.Write(ctx, "tech/c.md", []byte("12")); err != nil {
+		t.Fatal(err)
+	}
+
+	if files, size := crawler.PendingChanges(); files != 3 || size != 9 {
+		t.Errorf("PendingChanges() = %d, %d, want 3, 9", files, size)
+	}
+
+	if err := crawler.CommitChanges(ctx, "chunk"); err != nil {
+		t.Fatal(err)
+	}
+	if files, size := crawler.PendingChanges(); files != 0 || size != 0 {
+		t.Errorf("PendingChanges() after commit = %d, %d, want none", files, size)
+	}
+
+	if err := crawler.tx.Write(ctx, "tech/d.md", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.tx.Commit(ctx, "commit"); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := crawler.PendingChanges(); files != 0 {
+		t.Errorf("PendingChanges() after transaction commit = %d files, want none", files)
+	}
+}
+
+func TestConfig_CommitChunking(t *testing.T) {
+	t.Parallel()
+
+	if (&Config{}).CommitChunking() {
+		t.Error("CommitChunking() without limits = true")
+	}
+	if !(&Config{CommitMaxFiles: 500}).CommitChunking() || !(&Config{CommitMaxSize: bytesPerMB}).CommitChunking() {
+		t.Error("CommitChunking() with a limit = false")
+	}
+}
@@ -10,12 +10,13 @@ import (
 
 // CleanupResult contains the result of a cleanup operation.
 type CleanupResult struct {
-	OrphanedPages     int
-	DeletedRegistries int
-	DeletedFiles      int
+	OrphanedPages     int `json:"orphaned_pages"`
+	UnusedAssets      int `json:"unused_assets"` // Files of the assets directory used by no page anymore
+	DeletedRegistries int `json:"deleted_registries"`
+	DeletedFiles      int `json:"deleted_files"`
 }
 
-// Cleanup deletes orphaned pages that don't trace back to a root in root.md.
+// Cleanup deletes orphaned pages that don't trace back to a root in root.md, then the assets no page uses anymore.
 func (c *Crawler) Cleanup(ctx context.Context, dryRun bool) (*CleanupResult, error) {
 	c.logger.InfoContext(ctx, "starting cleanup", "dry_run", dryRun)
 
@@ -41,6 +42,7 @@ func (c *Crawler) Cleanup(ctx context.Context, dryRun bool) (*CleanupResult, err
 	c.logger.InfoContext(ctx, "found page registries", "count", len(registries))
 
 	result := &CleanupResult{}
+	livePages := make(map[string]*PageRegistry, len(registries))
 
 	// Check each registry
 	for _, reg := range registries {
@@ -50,12 +52,14 @@ func (c *Crawler) Cleanup(ctx context.Context, dryRun bool) (*CleanupResult, err
 			c.logger.WarnContext(ctx, "failed to trace to root",
 				"page_id", reg.ID,
 				"error", err)
+			livePages[reg.ID] = reg // Kept, as well as its assets
 			continue
 		}
 
 		// Check if root is in root.md
 		if rootID != "" && rootIDs[rootID] {
 			// This page traces to a valid root
+			livePages[reg.ID] = reg
 			continue
 		}
 
@@ -93,8 +97,13 @@ func (c *Crawler) Cleanup(ctx context.Context, dryRun bool) (*CleanupResult, err
 		}
 	}
 
+	if err := c.collectAssets(ctx, livePages, dryRun, result); err != nil {
+		c.logger.WarnContext(ctx, "failed to collect unused assets", "error", err)
+	}
+
 	c.logger.InfoContext(ctx, "cleanup complete",
 		"orphaned_pages", result.OrphanedPages,
+		"unused_assets", result.UnusedAssets,
 		"deleted_registries", result.DeletedRegistries,
 		"deleted_files", result.DeletedFiles,
 		"dry_run", dryRun)
@@ -147,8 +156,14 @@ func (c *Crawler) deleteFile(ctx context.Context, filePath string) error {
 	return nil
 }
 
+// deleteFileRegistry deletes a file registry.
+func (c *Crawler) deleteFileRegistry(ctx context.Context, fileID string) error {
+	return c.deleteFile(ctx, fmt.Sprintf("%s/%s/file-%s.json", stateDir, idsDir, fileID))
+}
+
 // deletePageRegistry deletes a page registry file.
 func (c *Crawler) deletePageRegistry(ctx context.Context, pageID string) error {
+	c.parents.setRegistry(normalizePageID(pageID), false)
 	path := fmt.Sprintf("%s/%s/page-%s.json", stateDir, idsDir, pageID)
 	if err := c.tx.Delete(ctx, path); err != nil {
 		if os.IsNotExist(err) {
@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// CODEOWNERS files of the mirror, in the locations GitHub reads them from: .github/ at the repository root, or
+// the root of the docs/ directory of the repository, which a mirror in the "docs" subdirectory can write.
+const (
+	codeOwnersFile     = ".github/CODEOWNERS"
+	codeOwnersDocsFile = "CODEOWNERS"
+	codeOwnersDocsDir  = "docs"
+)
+
+// The generated rules are kept between these lines, so that the rules written by hand around them are kept.
+const (
+	codeOwnersBegin = "# BEGIN ntnsync: generated from NTN_CODEOWNERS, edits are overwritten"
+	codeOwnersEnd   = "# END ntnsync"
+)
+
+// parseCodeOwnersEnv parses the owners of folders from a string like "tech=@org/eng @org/arch,*=@org/docs".
+// Owners are separated by spaces; the "*" folder applies to the folders without their own owners. Entries without
+// owners are ignored.
+func parseCodeOwnersEnv(val string) map[string][]string {
+	owners := make(map[string][]string)
+	for item := range strings.SplitSeq(val, ",") {
+		folder, list, found := strings.Cut(item, "=")
+		folder = strings.TrimSpace(folder)
+		fields := strings.Fields(list)
+		if !found || folder == "" || len(fields) == 0 {
+			continue
+		}
+		owners[folder] = fields
+	}
+	return owners
+}
+
+// ownersFor returns the owners of a folder: its own owners if configured, the "*" owners otherwise.
+func (cfg *Config) ownersFor(folder string) []string {
+	if owners, ok := cfg.CodeOwners[folder]; ok {
+		return owners
+	}
+	return cfg.CodeOwners[excludeAllFolders]
+}
+
+// formatCodeOwners returns the generated CODEOWNERS rules of the folders, with their paths under the subdirectory
+// of the repository holding the mirror.
+func formatCodeOwners(cfg *Config, folders []string, subdir string) string {
+	var sb strings.Builder
+	sb.WriteString(codeOwnersBegin + "\n")
+	for _, folder := range slices.Sorted(slices.Values(folders)) {
+		owners := cfg.ownersFor(folder)
+		if len(owners) == 0 {
+			continue
+		}
+		pattern := "/" + path.Join(subdir, folder) + "/"
+		sb.WriteString(strings.ReplaceAll(pattern, " ", `\ `) + " " + strings.Join(owners, " ") + "\n")
+	}
+	sb.WriteString(codeOwnersEnd + "\n")
+	return sb.String()
+}
+
+// mergeCodeOwners replaces the generated rules of a CODEOWNERS file, or appends them when it has none.
+func mergeCodeOwners(existing, generated string) string {
+	before, rest, found := strings.Cut(existing, codeOwnersBegin)
+	if !found {
+		if existing != "" && !strings.HasSuffix(existing, "\n") {
+			existing += "\n"
+		}
+		if existing != "" {
+			existing += "\n"
+		}
+		return existing + generated
+	}
+	after := ""
+	if _, tail, ok := strings.Cut(rest, codeOwnersEnd); ok {
+		after = strings.TrimPrefix(tail, "\n")
+	}
+	return before + generated + after
+}
+
+// updateCodeOwners writes the owners of the synced folders (NTN_CODEOWNERS) to the CODEOWNERS file of the mirror,
+// so that the review requirements of the mirror repository follow the folders. Does nothing without owners.
+func (c *Crawler) updateCodeOwners(ctx context.Context) error {
+	cfg := GetConfig()
+	if len(cfg.CodeOwners) == 0 || c.state == nil {
+		return nil
+	}
+
+	subdir := strings.Trim(path.Clean("/"+strings.TrimSpace(store.LoadRemoteConfigFromEnv().Subdir)), "/")
+	file := codeOwnersFile
+	if subdir == codeOwnersDocsDir {
+		file = codeOwnersDocsFile
+	}
+
+	var existing string
+	if data, err := c.store.Read(ctx, file); err == nil {
+		existing = string(data)
+	}
+	content := mergeCodeOwners(existing, formatCodeOwners(cfg, c.state.Folders, subdir))
+	if content == existing {
+		return nil
+	}
+
+	if err := c.tx.Write(ctx, file, []byte(content)); err != nil {
+		return fmt.Errorf("write %s: %w", file, err)
+	}
+	c.logger.InfoContext(ctx, "updated CODEOWNERS", "folders", len(c.state.Folders))
+	return nil
+}
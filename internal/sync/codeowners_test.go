@@ -0,0 +1,42 @@
+package sync
+
+import "testing"
+
+func TestFormatCodeOwners(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{CodeOwners: parseCodeOwnersEnv("tech=@org/eng  @org/arch, *=@org/docs, hr=, =@org/nobody")}
+	got := formatCodeOwners(cfg, []string{"tech", "hr", "team notes"}, "docs/notion")
+	want := codeOwnersBegin + "\n" +
+		"/docs/notion/hr/ @org/docs\n" +
+		`/docs/notion/team\ notes/ @org/docs` + "\n" +
+		"/docs/notion/tech/ @org/eng @org/arch\n" +
+		codeOwnersEnd + "\n"
+	if got != want {
+		t.Errorf("formatCodeOwners() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeCodeOwners(t *testing.T) {
+	t.Parallel()
+
+	generated := codeOwnersBegin + "\n/tech/ @org/eng\n" + codeOwnersEnd + "\n"
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{name: "new file", existing: "", want: generated},
+		{name: "manual rules", existing: "* @org/admins", want: "* @org/admins\n\n" + generated},
+		{
+			name:     "replaced section",
+			existing: "* @org/admins\n\n" + codeOwnersBegin + "\n/old/ @org/old\n" + codeOwnersEnd + "\n/root.md @me\n",
+			want:     "* @org/admins\n\n" + generated + "/root.md @me\n",
+		},
+	}
+	for _, tc := range tests {
+		if got := mergeCodeOwners(tc.existing, generated); got != tc.want {
+			t.Errorf("%s: mergeCodeOwners() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// countOpenComments returns the number of unresolved comments of a page when NTN_COMMENT_COUNTS is enabled.
+// Counting errors don't fail the sync of the page: the count of its registry is kept instead. When the integration
+// lacks the "Read comments" capability, counting stops for the rest of the run.
+func (c *Crawler) countOpenComments(ctx context.Context, pageID string) int {
+	if !GetConfig().CommentCounts || c.commentsDenied.Load() {
+		return 0
+	}
+
+	count, err := c.client.CountOpenComments(ctx, pageID)
+	if err == nil {
+		return count
+	}
+
+	var apiErr *notion.APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden {
+		if !c.commentsDenied.Swap(true) {
+			c.logger.WarnContext(ctx, "integration can't read comments, open comments are not counted",
+				"error", err)
+		}
+		return 0
+	}
+
+	c.logger.WarnContext(ctx, "failed to count open comments", notionKeyPageID, pageID, "error", err)
+	if reg, regErr := c.loadPageRegistry(ctx, pageID); regErr == nil && reg != nil {
+		return reg.OpenComments
+	}
+	return 0
+}
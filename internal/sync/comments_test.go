@@ -0,0 +1,65 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Cannot use t.Parallel() with t.Setenv
+func TestCountOpenComments(t *testing.T) {
+	t.Setenv("NTN_COMMENT_COUNTS", "true")
+	ResetConfig()
+	defer ResetConfig()
+
+	var status atomic.Int32
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		switch code := int(status.Load()); code {
+		case http.StatusOK:
+			fmt.Fprint(w, `{"object":"list","results":[{"object":"comment","id":"c1"},{"object":"comment","id":"c2"}],
+				"has_more":false}`)
+		default:
+			w.WriteHeader(code)
+			fmt.Fprintf(w, `{"object":"error","status":%d,"code":"error","message":"failed"}`, code)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	status.Store(http.StatusOK)
+	if got := crawler.countOpenComments(ctx, "page1"); got != 2 {
+		t.Errorf("countOpenComments() = %d, want 2", got)
+	}
+
+	// Other errors keep the count of the registry
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{ID: "page1", OpenComments: 5}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+	status.Store(http.StatusBadRequest)
+	if got := crawler.countOpenComments(ctx, "page1"); got != 5 {
+		t.Errorf("countOpenComments() after an error = %d, want the registry count 5", got)
+	}
+
+	// Without the comment capability, counting stops
+	status.Store(http.StatusForbidden)
+	if got := crawler.countOpenComments(ctx, "page1"); got != 0 {
+		t.Errorf("countOpenComments() without capability = %d, want 0", got)
+	}
+	before := requests.Load()
+	status.Store(http.StatusOK)
+	if got := crawler.countOpenComments(ctx, "page1"); got != 0 || requests.Load() != before {
+		t.Errorf("countOpenComments() after a 403 = %d with %d requests, want 0 without request", got,
+			requests.Load()-before)
+	}
+}
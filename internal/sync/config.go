@@ -1,20 +1,137 @@
 package sync
 
 import (
+	"cmp"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/converter/postprocess"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
 )
 
 // Config holds sync-related configuration loaded from environment variables.
 type Config struct {
 	// BlockDepth is the maximum depth for block discovery (0 = unlimited).
 	BlockDepth int
+	// BlockDiff caches the blocks of pages, so that content changes notified by webhooks only fetch the changed
+	// subtrees again.
+	BlockDiff bool
 	// QueueDelay is the delay between processing queue files.
 	QueueDelay time.Duration
+	// QueueDelayAuto adapts the delay between queue files to the throttling of the API (NTN_QUEUE_DELAY=auto).
+	QueueDelayAuto bool
+	// SyncConcurrency is the number of pages of a queue file fetched in parallel (1 = sequential).
+	SyncConcurrency int
 	// MaxFileSize is the maximum file size to download in bytes.
 	MaxFileSize int64
+	// ContentLossGuard is the share (in percent) of a page's content that a new conversion may
+	// lose before it is held for review instead of written (0 = disabled).
+	ContentLossGuard int
+	// FilenameRules controls how titles are turned into filenames. Only used when a mirror
+	// has no rules recorded in its state yet.
+	FilenameRules converter.FilenameRules
+	// DefaultFolderQuota applies to folders without their own quota (zero = unlimited).
+	DefaultFolderQuota FolderQuota
+	// FolderQuotas are per-folder quotas.
+	FolderQuotas map[string]FolderQuota
+	// FolderExclusions are the per-folder rules excluding pages from child discovery ("*" = other folders).
+	FolderExclusions map[string]FolderExclusion
+	// CodeOwners are the owners of folders written to the CODEOWNERS file of the mirror ("*" = other folders,
+	// empty = no CODEOWNERS file).
+	CodeOwners map[string][]string
+	// MaxMirrorSize is the maximum size of the markdown files of all folders (zero = unlimited).
+	MaxMirrorSize int64
+	// PrunePolicy tells what to do when the mirror exceeds MaxMirrorSize: PrunePolicyNone or PrunePolicyOldestLeaves.
+	PrunePolicy string
+	// QuotaNotifyURL receives a JSON POST when a folder exceeds its quota (empty = disabled).
+	QuotaNotifyURL string
+	// FolderInference names the folder of pages outside any root: FolderInferenceNone or FolderInferenceTitle.
+	FolderInference string
+	// FolderInferenceRoots adds the top-level parent of pages outside any root to root.md, in its inferred folder.
+	FolderInferenceRoots bool
+	// SkipTemplates skips new pages whose title marks them as templates (see isTemplateTitle).
+	SkipTemplates bool
+	// SkipCopies skips new pages whose title marks them as duplicated ("Copy of ..." or "... (Copy)").
+	SkipCopies bool
+	// PullTeamspaces are the teamspaces whose new pages are discovered by pull --all: teamspace IDs, "*" for any
+	// teamspace and "private" for the pages outside any teamspace (empty = all pages).
+	PullTeamspaces []string
+	// DefaultProfile is the output profile of folders without their own profile (NTN_PROFILE, or its alias
+	// NTN_OUTPUT_FORMAT).
+	DefaultProfile string
+	// FolderProfiles are per-folder output profiles.
+	FolderProfiles map[string]string
+	// FolderPostProcessors are the per-folder chains of processors transforming converted markdown documents
+	// before they are written ("*" = other folders).
+	FolderPostProcessors map[string]postprocess.Chain
+	// HTMLTableColumns are the per-profile numbers of columns above which tables are written as HTML tables
+	// ("*" = other profiles, 0 = never).
+	HTMLTableColumns map[string]int
+	// InlineDatabaseRows is the number of rows of child databases shown as a table in their parent page
+	// (0 = disabled).
+	InlineDatabaseRows int
+	// InlineDatabaseColumns are the properties shown in inline database tables, after the title
+	// (empty = the first few properties by name).
+	InlineDatabaseColumns []string
+	// CodeCaptions is how code block captions are rendered: converter.CodeCaptionBold, CodeCaptionTitle
+	// or CodeCaptionNone.
+	CodeCaptions string
+	// DatabaseRender is how the pages of databases are listed in their document: converter.DatabaseRenderList or
+	// DatabaseRenderTable.
+	DatabaseRender string
+	// ConverterPlugins are the paths of the Go plugins rendering custom block types.
+	ConverterPlugins []string
+	// Properties selects the database properties written in the frontmatter of database pages.
+	Properties converter.PropertySelection
+	// Links controls how links to child pages and databases are rendered.
+	Links converter.LinkStyle
+	// CommentCounts counts the unresolved comments of pages, written as open_comments in their frontmatter.
+	CommentCounts bool
+	// DownloadAssets stores the Notion-hosted icons and covers of pages in the assets directory, instead of
+	// referencing their expiring URLs.
+	DownloadAssets bool
+	// FileStorage selects where the files of pages are stored: FileStoragePage or FileStorageAssets.
+	FileStorage string
+	// RenameFiles moves the files of pages renamed or moved in Notion to the path of their new title and parent,
+	// with their directory, instead of keeping their first path.
+	RenameFiles bool
+	// CommitMaxFiles is the number of changed files after which a sync commits and pushes a chunk
+	// (0 = unlimited).
+	CommitMaxFiles int
+	// CommitMaxSize is the size of changed files after which a sync commits and pushes a chunk (0 = unlimited).
+	CommitMaxSize int64
+	// PushNotifyURL receives a JSON POST describing each push of the mirror (empty = disabled).
+	PushNotifyURL string
+	// PushNotifySecret signs the push notifications (empty = unsigned).
+	PushNotifySecret string
+	// StatusPageID is the Notion page receiving a report of each sync run (empty = disabled).
+	StatusPageID string
+	// HealthThreshold is the health score (0 to 100) below which a sync run is unhealthy: the sync command then
+	// exits with an error (0 = disabled).
+	HealthThreshold int
+	// HealthStaleAfter is the age of the oldest queued entry after which the health score decreases (0 = never).
+	HealthStaleAfter time.Duration
+	// HealthNotifyURL receives a JSON POST when a sync run is unhealthy (empty = disabled).
+	HealthNotifyURL string
+	// ReadOnly refuses Notion writes and pushes of the mirror, for mirrors synced with a read-only token.
+	ReadOnly bool
+	// TimeFormat is the time zone and layout of timestamps in frontmatter and reports.
+	TimeFormat converter.TimeFormat
+	// QueueBatchSize is the maximum number of pages per queue file.
+	QueueBatchSize int
+	// QueueWebhookThreshold is the first number of regular queue files; lower ones are for webhook events.
+	QueueWebhookThreshold int
+	// QueueWebhookWindow is the time during which webhook events of a folder share a queue file (0 = disabled).
+	QueueWebhookWindow time.Duration
+	// QueueWebhookMaxPages is the maximum number of pages of a shared webhook queue file (0 = QueueBatchSize).
+	QueueWebhookMaxPages int
+	// ShutdownGrace is the time given to a sync to finish its pages in progress, save its progress and commit
+	// when the process is asked to stop (0 = stop immediately).
+	ShutdownGrace time.Duration
 }
 
 // globalConfig is the singleton config instance.
@@ -24,9 +141,63 @@ var globalConfig *Config
 // It should be called once at application startup.
 func LoadConfig() error {
 	globalConfig = &Config{
-		BlockDepth:  parseIntEnv(os.Getenv("NTN_BLOCK_DEPTH"), 0),
-		QueueDelay:  parseDurationEnv(os.Getenv("NTN_QUEUE_DELAY"), 0),
-		MaxFileSize: parseFileSizeEnv(os.Getenv("NTN_MAX_FILE_SIZE"), defaultMaxFileSize),
+		BlockDepth:       parseIntEnv(os.Getenv("NTN_BLOCK_DEPTH"), 0),
+		BlockDiff:        parseBoolEnv(os.Getenv("NTN_BLOCK_DIFF")),
+		QueueDelay:       parseDurationEnv(os.Getenv("NTN_QUEUE_DELAY"), 0),
+		QueueDelayAuto:   strings.EqualFold(strings.TrimSpace(os.Getenv("NTN_QUEUE_DELAY")), queueDelayAuto),
+		SyncConcurrency:  max(parseIntEnv(os.Getenv("NTN_SYNC_CONCURRENCY"), 1), 1),
+		MaxFileSize:      parseFileSizeEnv(os.Getenv("NTN_MAX_FILE_SIZE"), defaultMaxFileSize),
+		ContentLossGuard: parseIntEnv(os.Getenv("NTN_CONTENT_LOSS_GUARD"), 0),
+		FilenameRules:    parseFilenameRulesEnv(),
+		DefaultFolderQuota: FolderQuota{
+			MaxPages: parseIntEnv(os.Getenv("NTN_FOLDER_MAX_PAGES"), 0),
+			MaxBytes: parseFileSizeEnv(os.Getenv("NTN_FOLDER_MAX_SIZE"), 0),
+		},
+		FolderQuotas:     parseFolderQuotasEnv(os.Getenv("NTN_FOLDER_QUOTAS")),
+		FolderExclusions: parseFolderExclusionsEnv(os.Getenv("NTN_FOLDER_EXCLUDE")),
+		CodeOwners:       parseCodeOwnersEnv(os.Getenv("NTN_CODEOWNERS")),
+		MaxMirrorSize:    parseFileSizeEnv(os.Getenv("NTN_MAX_MIRROR_SIZE"), 0),
+		PrunePolicy:      parsePrunePolicyEnv(os.Getenv("NTN_PRUNE_POLICY")),
+		QuotaNotifyURL:   strings.TrimSpace(os.Getenv("NTN_QUOTA_NOTIFY_URL")),
+		DefaultProfile:   parseProfileEnv(cmp.Or(os.Getenv("NTN_PROFILE"), os.Getenv("NTN_OUTPUT_FORMAT"))),
+		FolderProfiles:   parseFolderProfilesEnv(os.Getenv("NTN_FOLDER_PROFILES")),
+		HTMLTableColumns: parseHTMLTableColumnsEnv(os.Getenv("NTN_HTML_TABLE_COLUMNS")),
+
+		FolderPostProcessors: parseFolderPostProcessorsEnv(os.Getenv("NTN_FOLDER_POSTPROCESS")),
+
+		FolderInference:       parseFolderInferenceEnv(os.Getenv("NTN_FOLDER_INFERENCE")),
+		FolderInferenceRoots:  parseBoolEnv(os.Getenv("NTN_FOLDER_INFERENCE_ROOTS")),
+		SkipTemplates:         parseBoolEnv(os.Getenv("NTN_SKIP_TEMPLATES")),
+		SkipCopies:            parseBoolEnv(os.Getenv("NTN_SKIP_COPIES")),
+		PullTeamspaces:        parseTeamspacesEnv(os.Getenv("NTN_PULL_TEAMSPACES")),
+		InlineDatabaseRows:    parseIntEnv(os.Getenv("NTN_INLINE_DATABASE_ROWS"), 0),
+		InlineDatabaseColumns: parseListEnv(os.Getenv("NTN_INLINE_DATABASE_COLUMNS")),
+		CodeCaptions:          parseCodeCaptionsEnv(os.Getenv("NTN_CODE_CAPTIONS")),
+		DatabaseRender:        parseDatabaseRenderEnv(os.Getenv("NTN_DATABASE_RENDER")),
+		ConverterPlugins:      parseListEnv(os.Getenv("NTN_CONVERTER_PLUGINS")),
+		Properties: parsePropertySelectionEnv(os.Getenv("NTN_DATABASE_PROPERTIES"),
+			parseIntEnv(os.Getenv("NTN_MAX_PROPERTIES"), converter.DefaultMaxProperties)),
+		Links: parseLinkStyleEnv(os.Getenv("NTN_LINK_TEXT"), os.Getenv("NTN_LINK_LAYOUT"),
+			os.Getenv("NTN_LINK_PATH_CASE")),
+		CommentCounts:         parseBoolEnv(os.Getenv("NTN_COMMENT_COUNTS")),
+		DownloadAssets:        parseBoolEnv(os.Getenv("NTN_DOWNLOAD_ASSETS")),
+		FileStorage:           parseFileStorageEnv(os.Getenv("NTN_FILE_STORAGE")),
+		RenameFiles:           parseBoolEnv(os.Getenv("NTN_RENAME_FILES")),
+		CommitMaxFiles:        parseIntEnv(os.Getenv("NTN_COMMIT_MAX_FILES"), 0),
+		CommitMaxSize:         parseFileSizeEnv(os.Getenv("NTN_COMMIT_MAX_SIZE"), 0),
+		PushNotifyURL:         strings.TrimSpace(os.Getenv("NTN_PUSH_NOTIFY_URL")),
+		PushNotifySecret:      os.Getenv("NTN_PUSH_NOTIFY_SECRET"),
+		StatusPageID:          parseStatusPageEnv(os.Getenv("NTN_STATUS_PAGE")),
+		HealthThreshold:       min(parseIntEnv(os.Getenv("NTN_HEALTH_THRESHOLD"), 0), healthMaxScore),
+		HealthStaleAfter:      parseDurationEnv(os.Getenv("NTN_HEALTH_STALE_AFTER"), defaultHealthStaleAfter),
+		HealthNotifyURL:       strings.TrimSpace(os.Getenv("NTN_HEALTH_NOTIFY_URL")),
+		ReadOnly:              parseBoolEnv(os.Getenv("NTN_READ_ONLY")),
+		TimeFormat:            parseTimeFormatEnv(os.Getenv("NTN_TIMEZONE"), os.Getenv("NTN_DATE_FORMAT")),
+		QueueBatchSize:        parseIntEnv(os.Getenv("NTN_QUEUE_BATCH_SIZE"), queue.DefaultBatchSize),
+		QueueWebhookThreshold: parseIntEnv(os.Getenv("NTN_QUEUE_WEBHOOK_THRESHOLD"), queue.DefaultWebhookThreshold),
+		QueueWebhookWindow:    parseDurationEnv(os.Getenv("NTN_QUEUE_WEBHOOK_WINDOW"), 0),
+		QueueWebhookMaxPages:  parseIntEnv(os.Getenv("NTN_QUEUE_WEBHOOK_MAX_PAGES"), 0),
+		ShutdownGrace:         parseDurationEnv(os.Getenv("NTN_SHUTDOWN_GRACE"), defaultShutdownGrace),
 	}
 
 	return nil
@@ -105,3 +276,268 @@ func parseFileSizeEnv(val string, defaultVal int64) int64 {
 
 	return defaultVal
 }
+
+// parseFilenameRulesEnv reads the filename rules from NTN_FILENAME_* environment variables.
+func parseFilenameRulesEnv() converter.FilenameRules {
+	rules := converter.DefaultFilenameRules()
+
+	if val := strings.ToLower(strings.TrimSpace(os.Getenv("NTN_FILENAME_CASE"))); val != "" {
+		rules.Case = val
+	}
+	if val := strings.TrimSpace(os.Getenv("NTN_FILENAME_SEPARATOR")); val != "" {
+		rules.Separator = val
+	}
+	rules.MaxLength = parseIntEnv(os.Getenv("NTN_FILENAME_MAX_LENGTH"), rules.MaxLength)
+
+	for word := range strings.SplitSeq(os.Getenv("NTN_FILENAME_STOPWORDS"), ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			rules.Stopwords = append(rules.Stopwords, word)
+		}
+	}
+
+	return rules
+}
+
+// parseFolderInferenceEnv parses the folder inference mode, returning FolderInferenceNone if it is unknown.
+func parseFolderInferenceEnv(val string) string {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == FolderInferenceTitle {
+		return val
+	}
+	return FolderInferenceNone
+}
+
+// parseStatusPageEnv parses the status page, given by ID or URL. An invalid page disables the status page.
+func parseStatusPageEnv(val string) string {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return ""
+	}
+	pageID, err := notion.ParsePageIDOrURL(val)
+	if err != nil {
+		return ""
+	}
+	return pageID
+}
+
+// parseFileStorageEnv parses the file storage mode, returning FileStoragePage if it is unknown.
+func parseFileStorageEnv(val string) string {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == FileStorageAssets {
+		return val
+	}
+	return FileStoragePage
+}
+
+// parseProfileEnv parses an output profile, returning the default profile if it is unknown.
+func parseProfileEnv(val string) string {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == "" || !converter.IsValidProfile(val) {
+		return converter.ProfileDefault
+	}
+	return val
+}
+
+// QueueLimits returns the configured queue limits, or the default ones if they are invalid.
+func (cfg *Config) QueueLimits() queue.Limits {
+	limits := queue.Limits{
+		BatchSize:        cfg.QueueBatchSize,
+		WebhookThreshold: cfg.QueueWebhookThreshold,
+		WebhookWindow:    cfg.QueueWebhookWindow,
+		WebhookMaxPages:  cfg.QueueWebhookMaxPages,
+	}
+	if err := limits.Validate(); err != nil {
+		return queue.DefaultLimits()
+	}
+	return limits
+}
+
+// parseCodeCaptionsEnv parses a code caption style, returning the bold style if it is unknown.
+func parseCodeCaptionsEnv(val string) string {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == "" || !converter.IsValidCodeCaptionStyle(val) {
+		return converter.CodeCaptionBold
+	}
+	return val
+}
+
+// parseDatabaseRenderEnv parses a database render mode, returning the list mode if it is unknown.
+func parseDatabaseRenderEnv(val string) string {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == "" || !converter.IsValidDatabaseRenderMode(val) {
+		return converter.DatabaseRenderList
+	}
+	return val
+}
+
+// parseLinkStyleEnv parses the link style. Unknown values fall back to the default style.
+func parseLinkStyleEnv(text, layout, pathCase string) converter.LinkStyle {
+	style := converter.LinkStyle{
+		Text:     strings.ToLower(strings.TrimSpace(text)),
+		Layout:   strings.ToLower(strings.TrimSpace(layout)),
+		PathCase: strings.ToLower(strings.TrimSpace(pathCase)),
+	}
+	if !converter.IsValidLinkText(style.Text) {
+		style.Text = ""
+	}
+	if !converter.IsValidLinkLayout(style.Layout) {
+		style.Layout = ""
+	}
+	if !converter.IsValidLinkPathCase(style.PathCase) {
+		style.PathCase = ""
+	}
+	return style
+}
+
+// parsePropertySelectionEnv parses per-database property filters from a string like
+// "a1b2...=Status|Owner,c3d4...=!Cost|!Internal notes,*=!Secret". Each filter lists the properties to write,
+// in order, and the ones never written prefixed with "!". The "*" filter applies to the other databases.
+// Entries without a property are ignored.
+func parsePropertySelectionEnv(val string, maxProperties int) converter.PropertySelection {
+	selection := converter.PropertySelection{
+		Databases: make(map[string]converter.PropertyFilter),
+		Max:       maxProperties,
+	}
+	for item := range strings.SplitSeq(val, ",") {
+		database, names, found := strings.Cut(item, "=")
+		database = strings.TrimSpace(database)
+		if !found || database == "" {
+			continue
+		}
+
+		var filter converter.PropertyFilter
+		for name := range strings.SplitSeq(names, "|") {
+			name = strings.TrimSpace(name)
+			if denied, ok := strings.CutPrefix(name, "!"); ok && strings.TrimSpace(denied) != "" {
+				filter.Deny = append(filter.Deny, strings.TrimSpace(denied))
+			} else if name != "" && !ok {
+				filter.Allow = append(filter.Allow, name)
+			}
+		}
+		if len(filter.Allow) == 0 && len(filter.Deny) == 0 {
+			continue
+		}
+
+		if database == "*" {
+			selection.Default = filter
+		} else {
+			selection.Databases[normalizePageID(database)] = filter
+		}
+	}
+	return selection
+}
+
+// parseBoolEnv parses a boolean from a string: "true", "1" or "yes" (any case) are true.
+func parseBoolEnv(val string) bool {
+	val = strings.ToLower(strings.TrimSpace(val))
+	return val == "true" || val == "1" || val == "yes"
+}
+
+// parseTimeFormatEnv parses the time zone and date format of timestamps. An invalid time zone falls back
+// to UTC, and an invalid date format to RFC 3339.
+func parseTimeFormatEnv(zone, format string) converter.TimeFormat {
+	timeFormat, err := converter.ParseTimeFormat(zone, "")
+	if err != nil {
+		timeFormat = converter.TimeFormat{}
+	}
+	if withLayout, err := converter.ParseTimeFormat("", format); err == nil {
+		timeFormat.Layout = withLayout.Layout
+	}
+	return timeFormat
+}
+
+// parseFolderProfilesEnv parses per-folder output profiles from a string like "engineering=mkdocs,handbook=github".
+// Entries with an unknown profile are ignored.
+func parseFolderProfilesEnv(val string) map[string]string {
+	profiles := make(map[string]string)
+	for item := range strings.SplitSeq(val, ",") {
+		folder, profile, found := strings.Cut(item, "=")
+		folder = strings.TrimSpace(folder)
+		profile = strings.ToLower(strings.TrimSpace(profile))
+		if !found || folder == "" || profile == "" || !converter.IsValidProfile(profile) {
+			continue
+		}
+		profiles[folder] = profile
+	}
+	return profiles
+}
+
+// profileFor returns the output profile of a folder: its own profile if configured, the default one otherwise.
+func (cfg *Config) profileFor(folder string) string {
+	if profile, ok := cfg.FolderProfiles[folder]; ok {
+		return profile
+	}
+	return cfg.DefaultProfile
+}
+
+// parseFolderPostProcessorsEnv parses per-folder post-processor chains from a string like
+// "tech=toc|headings:1,*=redact:emails". Processors are separated by "|" and run in order (see the postprocess
+// package); their regular expressions may contain "|" but not ",". The "*" folder applies to the folders without
+// their own chain. Invalid processors are ignored.
+func parseFolderPostProcessorsEnv(val string) map[string]postprocess.Chain {
+	chains := make(map[string]postprocess.Chain)
+	for item := range strings.SplitSeq(val, ",") {
+		folder, spec, found := strings.Cut(strings.TrimSpace(item), "=")
+		folder = strings.TrimSpace(folder)
+		if !found || folder == "" {
+			continue
+		}
+
+		var chain postprocess.Chain
+		for _, name := range postprocess.Split(spec) {
+			if processor, err := postprocess.New(name); err == nil {
+				chain = append(chain, processor)
+			}
+		}
+		chains[folder] = chain
+	}
+	return chains
+}
+
+// postProcessorsFor returns the post-processor chain of a folder: its own chain if configured, the "*" one otherwise.
+func (cfg *Config) postProcessorsFor(folder string) postprocess.Chain {
+	if chain, ok := cfg.FolderPostProcessors[folder]; ok {
+		return chain
+	}
+	return cfg.FolderPostProcessors["*"]
+}
+
+// parseHTMLTableColumnsEnv parses the numbers of columns above which tables are written as HTML tables, from a
+// string like "8" (all profiles) or "6,github=10,mkdocs=0". Entries with an unknown profile or an invalid number are
+// ignored.
+func parseHTMLTableColumnsEnv(val string) map[string]int {
+	columns := make(map[string]int)
+	for item := range strings.SplitSeq(val, ",") {
+		profile, count, found := strings.Cut(item, "=")
+		if !found {
+			profile, count = "*", profile
+		}
+		profile = strings.ToLower(strings.TrimSpace(profile))
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if profile == "" || err != nil || n < 0 || (profile != "*" && !converter.IsValidProfile(profile)) {
+			continue
+		}
+		columns[profile] = n
+	}
+	return columns
+}
+
+// htmlTableColumnsFor returns the number of columns above which the tables of a profile are written as HTML tables
+// (0 = never).
+func (cfg *Config) htmlTableColumnsFor(profile string) int {
+	if columns, ok := cfg.HTMLTableColumns[profile]; ok {
+		return columns
+	}
+	return cfg.HTMLTableColumns["*"]
+}
+
+// parseListEnv parses a comma-separated list, ignoring empty items.
+func parseListEnv(val string) []string {
+	var items []string
+	for item := range strings.SplitSeq(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,182 @@
+package sync
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestParseFolderProfilesEnv(t *testing.T) {
+	t.Parallel()
+
+	profiles := parseFolderProfilesEnv("engineering=mkdocs, handbook=GitHub,legacy=hugo,=github,invalid")
+
+	expected := map[string]string{
+		"engineering": converter.ProfileMkDocs,
+		"handbook":    converter.ProfileGitHub,
+	}
+	if !maps.Equal(profiles, expected) {
+		t.Errorf("parseFolderProfilesEnv() = %v, want %v", profiles, expected)
+	}
+
+	cfg := &Config{DefaultProfile: converter.ProfileDefault, FolderProfiles: profiles}
+	if got := cfg.profileFor("engineering"); got != converter.ProfileMkDocs {
+		t.Errorf("profileFor(engineering) = %q, want %q", got, converter.ProfileMkDocs)
+	}
+	if got := cfg.profileFor("product"); got != converter.ProfileDefault {
+		t.Errorf("profileFor(product) = %q, want %q", got, converter.ProfileDefault)
+	}
+}
+
+func TestParseFolderPostProcessorsEnv(t *testing.T) {
+	t.Parallel()
+
+	chains := parseFolderPostProcessorsEnv("tech=headings:2|unknown|redact:/foo|bar/, *=headings:1,=toc,invalid")
+	if len(chains) != 2 || len(chains["tech"]) != 2 || len(chains["*"]) != 1 {
+		t.Fatalf("parseFolderPostProcessorsEnv() = %v, want 2 processors for tech and 1 for *", chains)
+	}
+
+	cfg := &Config{FolderPostProcessors: chains}
+	doc := []byte("# Title\n\nfoo or bar\n")
+	if got := string(cfg.postProcessorsFor("tech").Process(doc)); got != "### Title\n\n[redacted] or [redacted]\n" {
+		t.Errorf("tech chain = %q", got)
+	}
+	if got := string(cfg.postProcessorsFor("hr").Process(doc)); got != "## Title\n\nfoo or bar\n" {
+		t.Errorf("default chain = %q", got)
+	}
+	if got := string((&Config{}).postProcessorsFor("hr").Process(doc)); got != string(doc) {
+		t.Errorf("without configuration = %q, want the document unchanged", got)
+	}
+}
+
+func TestParseHTMLTableColumnsEnv(t *testing.T) {
+	t.Parallel()
+
+	columns := parseHTMLTableColumnsEnv("6, GitHub=10,mkdocs=0,hugo=4,obsidian=-1,docusaurus=x,=3")
+
+	expected := map[string]int{
+		"*":                     6,
+		converter.ProfileGitHub: 10,
+		converter.ProfileMkDocs: 0,
+	}
+	if !maps.Equal(columns, expected) {
+		t.Errorf("parseHTMLTableColumnsEnv() = %v, want %v", columns, expected)
+	}
+
+	cfg := &Config{HTMLTableColumns: columns}
+	for profile, want := range map[string]int{
+		converter.ProfileGitHub:   10,
+		converter.ProfileMkDocs:   0,
+		converter.ProfileObsidian: 6,
+	} {
+		if got := cfg.htmlTableColumnsFor(profile); got != want {
+			t.Errorf("htmlTableColumnsFor(%s) = %d, want %d", profile, got, want)
+		}
+	}
+	if got := (&Config{}).htmlTableColumnsFor(converter.ProfileDefault); got != 0 {
+		t.Errorf("htmlTableColumnsFor() without configuration = %d, want 0", got)
+	}
+}
+
+func TestParseCodeCaptionsEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":        converter.CodeCaptionBold,
+		"Title":   converter.CodeCaptionTitle,
+		" none ":  converter.CodeCaptionNone,
+		"unknown": converter.CodeCaptionBold,
+	}
+	for val, want := range tests {
+		if got := parseCodeCaptionsEnv(val); got != want {
+			t.Errorf("parseCodeCaptionsEnv(%q) = %q, want %q", val, got, want)
+		}
+	}
+}
+
+func TestParseDatabaseRenderEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":        converter.DatabaseRenderList,
+		" Table ": converter.DatabaseRenderTable,
+		"gallery": converter.DatabaseRenderList,
+	}
+	for val, want := range tests {
+		if got := parseDatabaseRenderEnv(val); got != want {
+			t.Errorf("parseDatabaseRenderEnv(%q) = %q, want %q", val, got, want)
+		}
+	}
+}
+
+func TestParseLinkStyleEnv(t *testing.T) {
+	t.Parallel()
+
+	if got := parseLinkStyleEnv("", "", ""); got != (converter.LinkStyle{}) {
+		t.Errorf("parseLinkStyleEnv() = %+v, want the default style", got)
+	}
+	got := parseLinkStyleEnv(" Path ", "inline", "sideways")
+	want := converter.LinkStyle{Text: converter.LinkTextPath, Layout: converter.LinkLayoutInline}
+	if got != want {
+		t.Errorf("parseLinkStyleEnv() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTimeFormatEnv(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		zone, format, want string
+	}{
+		{"", "", "2026-03-14T09:30:00Z"},
+		{"Europe/Paris", "datetime", "2026-03-14 10:30:00"},
+		{"Unknown/Zone", "datetime", "2026-03-14 09:30:00"},
+		{"Europe/Paris", "2006", "2026-03-14T10:30:00+01:00"},
+	}
+	for _, tt := range tests {
+		if got := parseTimeFormatEnv(tt.zone, tt.format).Format(ts); got != tt.want {
+			t.Errorf("parseTimeFormatEnv(%q, %q).Format() = %q, want %q", tt.zone, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestParsePropertySelectionEnv(t *testing.T) {
+	t.Parallel()
+
+	selection := parsePropertySelectionEnv(
+		"11111111-2222-3333-4444-555555555555=Status| Owner ,*=!Cost|!Internal notes,other=|!,invalid", 10)
+
+	want := map[string]converter.PropertyFilter{
+		"11111111222233334444555555555555": {Allow: []string{"Status", "Owner"}},
+	}
+	if !maps.EqualFunc(selection.Databases, want, func(a, b converter.PropertyFilter) bool {
+		return slices.Equal(a.Allow, b.Allow) && slices.Equal(a.Deny, b.Deny)
+	}) {
+		t.Errorf("Databases = %+v, want %+v", selection.Databases, want)
+	}
+	if !slices.Equal(selection.Default.Deny, []string{"Cost", "Internal notes"}) || len(selection.Default.Allow) != 0 {
+		t.Errorf("Default = %+v", selection.Default)
+	}
+	if selection.Max != 10 {
+		t.Errorf("Max = %d, want 10", selection.Max)
+	}
+}
+
+func TestConfig_QueueLimits(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{QueueBatchSize: 50, QueueWebhookThreshold: 100000}
+	if got := cfg.QueueLimits(); got != (queue.Limits{BatchSize: 50, WebhookThreshold: 100000}) {
+		t.Errorf("QueueLimits() = %+v, want the configured limits", got)
+	}
+
+	cfg = &Config{QueueBatchSize: 50, QueueWebhookThreshold: 1}
+	if got := cfg.QueueLimits(); got != queue.DefaultLimits() {
+		t.Errorf("QueueLimits() with an invalid threshold = %+v, want the default limits", got)
+	}
+}
@@ -0,0 +1,206 @@
+package sync
+
+import (
+	"context"
+	"math"
+	stdsync "sync"
+	"time"
+)
+
+const (
+	// crawlCommitReserveShare is the share of the time budget of a crawl run (1/10) kept for the final commit and
+	// push.
+	crawlCommitReserveShare = 10
+
+	// crawlEstimateWeight is the weight of the last run in the average cost of a page over the runs of a crawl.
+	crawlEstimateWeight = 0.5
+)
+
+// Reasons a run of a crawl stopped (CrawlProgress.LastStop).
+const (
+	CrawlStopBudget  = "budget"  // The budget of the run was spent
+	CrawlStopDrained = "drained" // The queue is empty: the crawl is complete
+	CrawlStopLimit   = "limit"   // Another limit stopped the run (--max-pages, shutdown, ...)
+)
+
+// CrawlBudget bounds a run of an incremental deep crawl (sync --crawl-budget). The run stops taking queued pages
+// when the next one is not expected to fit in the budget, and they stay queued for the next run. A run always
+// takes at least one page, so that a crawl moves forward even when a page costs more than the budget.
+type CrawlBudget struct {
+	Deadline time.Time // End of the run, final commit included (zero = no time budget)
+	APICalls int       // Notion API requests of the run (0 = unlimited)
+}
+
+// CrawlProgress is the progress of an incremental deep crawl, kept in the state across its runs. The frontier of
+// the crawl is the queue itself: the children of a synced page are queued after the pages already queued, so the
+// crawl goes breadth first, and each run resumes where the previous one stopped.
+type CrawlProgress struct {
+	StartedAt   time.Time  `json:"started_at"`             // First run of the crawl
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // Run that drained the queue
+	Runs        int        `json:"runs"`
+	Pages       int        `json:"pages"`     // Pages synced by the runs of the crawl
+	APICalls    int        `json:"api_calls"` // Notion API requests of the runs of the crawl
+	Queued      int        `json:"queued"`    // Pages left in the queue after the last run
+	LastRunAt   time.Time  `json:"last_run_at"`
+	LastStop    string     `json:"last_stop"` // Why the last run stopped (CrawlStopBudget, ...)
+	// PageTime and PageCalls are the average time and Notion API requests taken by a queued page, over the runs
+	PageTime  time.Duration `json:"page_time"`
+	PageCalls float64       `json:"page_calls"`
+}
+
+// crawlRun tracks the budget of the current run of a crawl.
+type crawlRun struct {
+	budget    CrawlBudget
+	startedAt time.Time
+	requests  int // Requests of the client when the run started
+
+	mu        stdsync.Mutex
+	taken     int  // Pages taken from the queue, synced or skipped
+	exhausted bool // The budget was spent: no page is taken anymore
+}
+
+// WithCrawlBudget bounds the queue processing with a budget, as a run of an incremental deep crawl whose
+// progress is kept in the state (see CrawlProgress).
+func WithCrawlBudget(budget CrawlBudget) CrawlerOption {
+	return func(c *Crawler) {
+		c.crawlBudget = &budget
+	}
+}
+
+// startCrawlRun starts tracking the budget of a run, if the crawler has one.
+func (c *Crawler) startCrawlRun() {
+	if c.crawlBudget == nil {
+		c.crawl = nil
+		return
+	}
+	c.crawl = &crawlRun{budget: *c.crawlBudget, startedAt: time.Now(), requests: c.clientThrottle().Requests}
+}
+
+// crawlPageTaken counts a page taken from the queue by the run of a crawl.
+func (c *Crawler) crawlPageTaken() {
+	if c.crawl == nil {
+		return
+	}
+	c.crawl.mu.Lock()
+	c.crawl.taken++
+	c.crawl.mu.Unlock()
+}
+
+// crawlBudgetExhausted returns whether the run of a crawl must stop taking pages: the next ones, started as the
+// pages taken so far, are not expected to fit in the time budget (less the commit reserve) or the API budget.
+func (c *Crawler) crawlBudgetExhausted(ctx context.Context) bool {
+	run := c.crawl
+	if run == nil {
+		return false
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.exhausted || run.taken == 0 {
+		return run.exhausted
+	}
+
+	// Pages are processed concurrency at a time: a page started now ends after about concurrency average pages
+	elapsed := time.Since(run.startedAt)
+	pageTime := elapsed / time.Duration(run.taken) * time.Duration(c.concurrency)
+	requests := c.clientThrottle().Requests - run.requests
+	pageCalls := int(math.Ceil(float64(requests) / float64(run.taken)))
+
+	reserve := run.budget.Deadline.Sub(run.startedAt) / crawlCommitReserveShare
+	overTime := !run.budget.Deadline.IsZero() && time.Now().Add(pageTime+reserve).After(run.budget.Deadline)
+	overCalls := run.budget.APICalls > 0 && requests+pageCalls*c.concurrency > run.budget.APICalls
+	run.exhausted = overTime || overCalls
+	if run.exhausted {
+		c.logger.InfoContext(ctx, "crawl budget spent, the remaining pages stay queued for the next run",
+			"pages", run.taken, "elapsed", elapsed, "api_calls", requests,
+			"page_time", pageTime, "page_calls", pageCalls)
+	}
+	return run.exhausted
+}
+
+// crawlStopped returns whether the budget of the current run of a crawl was spent.
+func (c *Crawler) crawlStopped() bool {
+	if c.crawl == nil {
+		return false
+	}
+	c.crawl.mu.Lock()
+	defer c.crawl.mu.Unlock()
+	return c.crawl.exhausted
+}
+
+// finishCrawlRun records the run of a crawl in its progress: the pages synced, the API requests, the pages left
+// in the queue of the folder (all folders if empty), and the average cost of a page. A run that drains the queue
+// completes the crawl, and the next run starts a new one.
+func (c *Crawler) finishCrawlRun(ctx context.Context, folder string, synced int) {
+	run := c.crawl
+	if run == nil {
+		return
+	}
+	run.mu.Lock()
+	taken, exhausted := run.taken, run.exhausted
+	run.mu.Unlock()
+
+	now := time.Now()
+	progress := c.state.Crawl
+	if progress == nil || progress.CompletedAt != nil {
+		progress = &CrawlProgress{StartedAt: run.startedAt}
+		if c.state.Crawl != nil {
+			progress.PageTime, progress.PageCalls = c.state.Crawl.PageTime, c.state.Crawl.PageCalls
+		}
+	}
+
+	requests := c.clientThrottle().Requests - run.requests
+	progress.Runs++
+	progress.Pages += synced
+	progress.APICalls += requests
+	progress.Queued = c.queuedPageCount(ctx, folder)
+	progress.LastRunAt = now
+	if taken > 0 {
+		pageTime := time.Since(run.startedAt) / time.Duration(taken)
+		pageCalls := float64(requests) / float64(taken)
+		if progress.PageTime > 0 {
+			pageTime = time.Duration(crawlEstimateWeight*float64(pageTime) +
+				(1-crawlEstimateWeight)*float64(progress.PageTime))
+			pageCalls = crawlEstimateWeight*pageCalls + (1-crawlEstimateWeight)*progress.PageCalls
+		}
+		progress.PageTime, progress.PageCalls = pageTime, pageCalls
+	}
+
+	switch {
+	case progress.Queued == 0:
+		progress.LastStop = CrawlStopDrained
+		progress.CompletedAt = &now
+	case exhausted:
+		progress.LastStop = CrawlStopBudget
+	default:
+		progress.LastStop = CrawlStopLimit
+	}
+	c.state.Crawl = progress
+
+	c.logger.InfoContext(ctx, "crawl run finished",
+		"run", progress.Runs,
+		"stop", progress.LastStop,
+		"pages", synced,
+		"api_calls", requests,
+		"crawl_pages", progress.Pages,
+		"queued", progress.Queued,
+		"page_time", progress.PageTime)
+}
+
+// queuedPageCount returns the number of pages of the queue entries of a folder (all folders if empty).
+func (c *Crawler) queuedPageCount(ctx context.Context, folder string) int {
+	files, err := c.queueManager.ListEntries(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to list queue entries", "error", err)
+		return 0
+	}
+
+	count := 0
+	for _, file := range files {
+		entry, err := c.queueManager.ReadEntry(ctx, file)
+		if err != nil || (folder != "" && entry.Folder != folder) {
+			continue
+		}
+		count += entry.GetPageCount()
+	}
+	return count
+}
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestProcessQueue_CrawlBudget(t *testing.T) {
+	t.Parallel()
+	// Every page is missing: a page taken from the queue is dropped, not retried
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"object":"error","status":404,"code":"object_not_found","message":"not found"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	var started []string
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+	WithProgressHooks(ProgressHooks{
+		OnPageStart: func(_ context.Context, pageID, _ string) {
+			started = append(started, pageID)
+		},
+	})(crawler)
+
+	ctx := context.Background()
+	if _, err := qm.CreateEntry(ctx, queue.Entry{
+		Type:   "update",
+		Folder: "test",
+		Pages:  []queue.Page{{ID: "page1"}, {ID: "page2"}, {ID: "page3"}},
+	}); err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	// A spent budget still takes a page, so that the crawl moves forward
+	WithCrawlBudget(CrawlBudget{Deadline: time.Now()})(crawler)
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if !slices.Equal(started, []string{"page1"}) {
+		t.Errorf("pages started = %v, want [page1]", started)
+	}
+	progress := crawler.state.Crawl
+	if progress == nil || progress.Runs != 1 || progress.LastStop != CrawlStopBudget || progress.Queued != 2 ||
+		progress.CompletedAt != nil {
+		t.Fatalf("crawl progress = %+v, want 1 run stopped by its budget, with 2 pages queued", progress)
+	}
+	if progress.PageCalls == 0 {
+		t.Errorf("crawl progress = %+v, want the API calls of a page", progress)
+	}
+
+	// The next run resumes the crawl, and completes it by draining the queue
+	WithCrawlBudget(CrawlBudget{Deadline: time.Now().Add(time.Hour)})(crawler)
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if !slices.Equal(started, []string{"page1", "page2", "page3"}) {
+		t.Errorf("pages started = %v, want [page1 page2 page3]", started)
+	}
+	progress = crawler.state.Crawl
+	if progress.Runs != 2 || progress.LastStop != CrawlStopDrained || progress.Queued != 0 ||
+		progress.CompletedAt == nil {
+		t.Errorf("crawl progress = %+v, want 2 runs, completed", progress)
+	}
+}
@@ -3,8 +3,12 @@ package sync
 import (
 	"context"
 	"log/slog"
+	stdsync "sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/converter/plugin"
 	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
 	"github.com/fclairamb/ntnsync/internal/store"
@@ -16,6 +20,7 @@ const (
 	idsDir    = "ids"
 
 	queueTypeInit       = "init"
+	queueTypeProperties = queue.TypeProperties
 	parentTypeBlockID   = "block_id"
 	parentTypeWorkspace = "workspace"
 
@@ -50,6 +55,45 @@ type Crawler struct {
 	queueManager *queue.Manager
 	converter    *converter.Converter
 	logger       *slog.Logger
+	hooks        ProgressHooks
+
+	quotaMu        stdsync.Mutex           // Protects folderUsage, quotaExceeded and mirrorOverSize
+	folderUsage    map[string]*folderUsage // Lazily loaded usage for folder quotas
+	quotaExceeded  []string                // Folders that exceeded their quota during this run
+	mirrorOverSize bool                    // Whether the mirror reached its size cap during this run
+	health         *RunHealth              // Health of the last queue processing (nil = none)
+	skipDownloads  bool                    // Keep the URL of files not downloaded yet (verification)
+	commentsDenied atomic.Bool             // The integration can't read comments: stop counting them this run
+
+	scanMu stdsync.Mutex // Serializes queue writes of folders scanned in parallel
+
+	concurrency int           // Number of queued pages processed in parallel
+	writeMu     stdsync.Mutex // Serializes the writes of pages processed in parallel (see lockWrites)
+
+	perfMu        stdsync.Mutex              // Protects perfSamples and perfPageCalls
+	perfSamples   map[string][]time.Duration // Pipeline phase durations of the current run
+	perfPageCalls []PageAPICalls             // Notion API calls of the pages synced in the current run
+
+	runMu stdsync.Mutex // Protects run
+	run   *RunStatus    // Run in progress, reported in the run file (nil = none)
+	// runGit are the git metrics of the store when the run started (nil = not backed by git)
+	runGit *store.GitMetrics
+
+	inFlightMu stdsync.Mutex           // Protects inFlight
+	inFlight   map[string]InFlightPage // Queued pages being processed, by page ID (see DumpDebugState)
+
+	assetsMu       stdsync.Mutex              // Protects renderedAssets and the pages of asset registries
+	renderedAssets map[string]map[string]bool // Assets of the pages being converted, by page ID (see recordAsset)
+
+	warningsMu stdsync.Mutex  // Protects warnings
+	warnings   map[string]int // Conversion warnings of the pages converted in the current run, by kind
+
+	pending pendingChanges // Files changed since the last commit
+
+	parents parentCache // Parent resolutions of the current run
+
+	crawlBudget *CrawlBudget // Budget of each queue processing, as a run of a crawl (nil = none)
+	crawl       *crawlRun    // Run of a crawl in progress (nil = none)
 }
 
 // CrawlerOption configures the crawler.
@@ -62,6 +106,13 @@ func WithCrawlerLogger(l *slog.Logger) CrawlerOption {
 	}
 }
 
+// WithConcurrency sets the number of queued pages processed in parallel, instead of NTN_SYNC_CONCURRENCY.
+func WithConcurrency(n int) CrawlerOption {
+	return func(c *Crawler) {
+		c.concurrency = max(n, 1)
+	}
+}
+
 // NewCrawler creates a new crawler.
 func NewCrawler(client *notion.Client, st store.Store, opts ...CrawlerOption) *Crawler {
 	crawler := &Crawler{
@@ -71,17 +122,44 @@ func NewCrawler(client *notion.Client, st store.Store, opts ...CrawlerOption) *C
 		queueManager: queue.NewManager(st, slog.Default()),
 		converter:    converter.NewConverter(),
 		logger:       slog.Default(),
+		concurrency:  GetConfig().SyncConcurrency,
 	}
 
 	for _, opt := range opts {
 		opt(crawler)
 	}
 
+	crawler.converter.Plugins = loadConverterPlugins(crawler.logger)
+	crawler.converter.TimeFormat = GetConfig().TimeFormat
+	crawler.converter.Properties = GetConfig().Properties
+	crawler.converter.Links = GetConfig().Links
 	crawler.queueManager.Logger = crawler.logger
+	_ = crawler.queueManager.SetLimits(GetConfig().QueueLimits())
 
 	return crawler
 }
 
+var (
+	converterPluginsOnce stdsync.Once
+	converterPlugins     *plugin.Registry
+)
+
+// loadConverterPlugins loads the configured converter plugins, once per process.
+// Plugins that cannot be loaded are logged, and their blocks get the built-in rendering.
+func loadConverterPlugins(logger *slog.Logger) *plugin.Registry {
+	converterPluginsOnce.Do(func() {
+		paths := GetConfig().ConverterPlugins
+		if len(paths) == 0 {
+			return
+		}
+		converterPlugins = plugin.NewRegistry(logger)
+		if err := converterPlugins.Load(paths); err != nil {
+			logger.Error("Cannot load converter plugins", "error", err)
+		}
+	})
+	return converterPlugins
+}
+
 // EnsureTransaction ensures a transaction is available.
 // If no transaction exists, creates a new one.
 func (c *Crawler) EnsureTransaction(ctx context.Context) error {
@@ -92,15 +170,16 @@ func (c *Crawler) EnsureTransaction(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	c.tx = tx
-	c.queueManager.SetTransaction(tx)
+	c.SetTransaction(tx)
 	return nil
 }
 
 // SetTransaction sets an external transaction.
+// Writes through the crawler's transaction are retried on transient errors and counted until committed.
 func (c *Crawler) SetTransaction(tx store.Transaction) {
-	c.tx = tx
-	c.queueManager.SetTransaction(tx)
+	retrying := &retryingTx{Transaction: tx, logger: c.logger, delay: storeRetryDelay}
+	c.tx = &countingTx{Transaction: retrying, pending: &c.pending}
+	c.queueManager.SetTransaction(c.tx)
 }
 
 // Commit commits the current transaction with the given message.
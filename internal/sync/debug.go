@@ -0,0 +1,243 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	stdsync "sync"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+const (
+	// maxDebugDumps is the number of diagnostic dumps kept in the debug directory.
+	maxDebugDumps = 10
+	// debugDumpTimeFormat names the files of a dump after its time, so that they sort chronologically.
+	debugDumpTimeFormat = "20060102T150405.000Z"
+	// goroutineStacksDebug is the pprof debug level printing the goroutine stacks like an unrecovered panic.
+	goroutineStacksDebug = 2
+)
+
+// activeRuns are the crawlers with a run in progress in this process, for the diagnostic dumps taken from
+// signal handlers, which have no other way to reach them.
+var activeRuns struct {
+	mu       stdsync.Mutex
+	crawlers []*Crawler
+}
+
+// InFlightPage is a queued page being processed.
+type InFlightPage struct {
+	PageID    string        `json:"page_id"`
+	Folder    string        `json:"folder"`
+	StartedAt time.Time     `json:"started_at"`
+	Elapsed   time.Duration `json:"elapsed"`
+}
+
+// DebugRun is the state of a sync run in progress, in a diagnostic dump.
+type DebugRun struct {
+	Run         RunStatus             `json:"run"`
+	InFlight    []InFlightPage        `json:"in_flight"`
+	Phases      map[string]PhaseStats `json:"phases,omitempty"`    // Pipeline phase durations of the run so far
+	APICalls    map[string]int        `json:"api_calls,omitempty"` // Notion API calls of the run so far, by call type
+	Requests    int                   `json:"requests"`            // Requests answered by the API since the start
+	RateLimited int                   `json:"rate_limited"`        // Rate limit (429) responses since the start
+	Throttled   time.Duration         `json:"throttled"`           // Time requests waited for the rate limit
+	Latency     time.Duration         `json:"latency"`             // Total time the API took to answer the requests
+}
+
+// DebugState is a diagnostic dump of the sync runs of the process, to investigate hung syncs without a debugger.
+type DebugState struct {
+	Time       time.Time  `json:"time"`
+	PID        int        `json:"pid"`
+	Hostname   string     `json:"hostname,omitempty"`
+	Version    string     `json:"version"`
+	Goroutines int        `json:"goroutines"`
+	Runs       []DebugRun `json:"runs"`
+}
+
+// registerActiveRun adds a crawler whose run started to the diagnostic dumps.
+func registerActiveRun(c *Crawler) {
+	activeRuns.mu.Lock()
+	defer activeRuns.mu.Unlock()
+	activeRuns.crawlers = append(activeRuns.crawlers, c)
+}
+
+// unregisterActiveRun removes a crawler whose run finished from the diagnostic dumps.
+func unregisterActiveRun(c *Crawler) {
+	activeRuns.mu.Lock()
+	defer activeRuns.mu.Unlock()
+	activeRuns.crawlers = slices.DeleteFunc(activeRuns.crawlers, func(other *Crawler) bool { return other == c })
+}
+
+// trackInFlight records that a queued page is being processed, until the returned function is called.
+func (c *Crawler) trackInFlight(pageID, folder string) func() {
+	c.inFlightMu.Lock()
+	defer c.inFlightMu.Unlock()
+	if c.inFlight == nil {
+		c.inFlight = make(map[string]InFlightPage)
+	}
+	c.inFlight[pageID] = InFlightPage{PageID: pageID, Folder: folder, StartedAt: time.Now()}
+
+	return func() {
+		c.inFlightMu.Lock()
+		defer c.inFlightMu.Unlock()
+		delete(c.inFlight, pageID)
+	}
+}
+
+// debugRun returns the state of the run in progress, or nil if there is none.
+func (c *Crawler) debugRun(now time.Time) *DebugRun {
+	c.runMu.Lock()
+	if c.run == nil {
+		c.runMu.Unlock()
+		return nil
+	}
+	run := &DebugRun{Run: *c.run, InFlight: []InFlightPage{}}
+	c.runMu.Unlock()
+
+	c.inFlightMu.Lock()
+	for _, page := range c.inFlight {
+		page.Elapsed = now.Sub(page.StartedAt)
+		run.InFlight = append(run.InFlight, page)
+	}
+	c.inFlightMu.Unlock()
+	slices.SortFunc(run.InFlight, func(a, b InFlightPage) int { return a.StartedAt.Compare(b.StartedAt) })
+
+	c.perfMu.Lock()
+	if len(c.perfSamples) > 0 {
+		run.Phases = make(map[string]PhaseStats, len(c.perfSamples))
+		for phase, durations := range c.perfSamples {
+			run.Phases[phase] = summarizeDurations(durations)
+		}
+	}
+	run.APICalls, _ = summarizeAPICalls(c.perfPageCalls)
+	c.perfMu.Unlock()
+
+	throttle := c.clientThrottle()
+	run.Requests = throttle.Requests
+	run.RateLimited = throttle.RateLimited
+	run.Throttled = throttle.Waited
+	run.Latency = throttle.Latency
+	return run
+}
+
+// CaptureDebugState captures the state of the sync runs in progress in the process.
+func CaptureDebugState() *DebugState {
+	activeRuns.mu.Lock()
+	crawlers := slices.Clone(activeRuns.crawlers)
+	activeRuns.mu.Unlock()
+
+	now := time.Now()
+	hostname, _ := os.Hostname()
+	state := &DebugState{
+		Time:       now.UTC(),
+		PID:        os.Getpid(),
+		Hostname:   hostname,
+		Version:    version.Version,
+		Goroutines: runtime.NumGoroutine(),
+		Runs:       []DebugRun{},
+	}
+	for _, c := range crawlers {
+		if run := c.debugRun(now); run != nil {
+			state.Runs = append(state.Runs, *run)
+		}
+	}
+	return state
+}
+
+// WriteDebugDump writes a diagnostic dump to the debug directory of a store: the state, in
+// {time}-state.json, and the stacks of all goroutines, in {time}-goroutines.txt. Only the last
+// maxDebugDumps dumps are kept. Returns the paths of the files written.
+func WriteDebugDump(ctx context.Context, st store.Store, state *DebugState) ([]string, error) {
+	writer, ok := st.(store.RuntimeFileWriter)
+	if !ok {
+		return nil, apperrors.ErrRuntimeFilesUnsupported
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal debug state: %w", err)
+	}
+	var stacks bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&stacks, goroutineStacksDebug); err != nil {
+		return nil, fmt.Errorf("dump goroutine stacks: %w", err)
+	}
+
+	prefix := path.Join(store.DebugDir, state.Time.UTC().Format(debugDumpTimeFormat))
+	files := []string{prefix + "-state.json", prefix + "-goroutines.txt"}
+	for i, content := range [][]byte{data, stacks.Bytes()} {
+		if err := writer.WriteRuntimeFile(ctx, files[i], content); err != nil {
+			return nil, fmt.Errorf("write %s: %w", files[i], err)
+		}
+	}
+
+	pruneDebugDumps(ctx, st, writer)
+	return files, nil
+}
+
+// pruneDebugDumps removes the oldest dumps of the debug directory, keeping the last maxDebugDumps. Failures are
+// ignored: they only leave more dumps.
+func pruneDebugDumps(ctx context.Context, st store.Store, writer store.RuntimeFileWriter) {
+	entries, err := st.List(ctx, store.DebugDir)
+	if err != nil {
+		return
+	}
+
+	var dumps []string
+	for _, entry := range entries {
+		dump, _, _ := strings.Cut(path.Base(entry.Path), "-")
+		if !entry.IsDir && !slices.Contains(dumps, dump) {
+			dumps = append(dumps, dump)
+		}
+	}
+	slices.Sort(dumps)
+	if len(dumps) <= maxDebugDumps {
+		return
+	}
+
+	stale := dumps[:len(dumps)-maxDebugDumps]
+	for _, entry := range entries {
+		dump, _, _ := strings.Cut(path.Base(entry.Path), "-")
+		if !entry.IsDir && slices.Contains(stale, dump) {
+			_ = writer.RemoveRuntimeFile(ctx, entry.Path)
+		}
+	}
+}
+
+// DumpDebugState writes a diagnostic dump of the sync runs in progress to the stores they sync, as done on
+// SIGQUIT. Returns the paths of the files written, or ErrNoActiveRun if no run is in progress.
+func DumpDebugState(ctx context.Context) ([]string, error) {
+	activeRuns.mu.Lock()
+	var stores []store.Store
+	for _, c := range activeRuns.crawlers {
+		if !slices.Contains(stores, c.store) {
+			stores = append(stores, c.store)
+		}
+	}
+	activeRuns.mu.Unlock()
+
+	if len(stores) == 0 {
+		return nil, apperrors.ErrNoActiveRun
+	}
+
+	state := CaptureDebugState()
+	var files []string
+	for _, st := range stores {
+		written, err := WriteDebugDump(ctx, st, state)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, written...)
+	}
+	return files, nil
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+func TestDumpDebugState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	if !crawler.StartRun(ctx, "tech") {
+		t.Fatal("StartRun() = false")
+	}
+	crawler.updateRun(ctx, func(status *RunStatus) { status.QueueFile = "00000001.json" })
+	done := crawler.trackInFlight("page1", "tech")
+	crawler.recordPhase(PerfPhaseFetch, time.Second)
+	crawler.recordAPICalls("page1", map[string]int{"blocks": 3})
+
+	files, err := DumpDebugState(ctx)
+	if err != nil || len(files) < 2 {
+		t.Fatalf("DumpDebugState() = %v, %v", files, err)
+	}
+
+	run := crawler.debugRun(time.Now())
+	if run == nil || run.Run.QueueFile != "00000001.json" || len(run.InFlight) != 1 ||
+		run.InFlight[0].PageID != "page1" || run.Phases[PerfPhaseFetch].Count != 1 || run.APICalls["blocks"] != 3 {
+		t.Errorf("debugRun() = %+v", run)
+	}
+	done()
+	crawler.FinishRun(ctx)
+	if run := crawler.debugRun(time.Now()); run != nil {
+		t.Errorf("debugRun() after the run = %+v, want nil", run)
+	}
+
+	// Only the last dumps are kept
+	state := CaptureDebugState()
+	base := time.Now().Add(time.Hour).UTC()
+	for i := range maxDebugDumps + 2 {
+		state.Time = base.Add(time.Duration(i) * time.Second)
+		if _, err := WriteDebugDump(ctx, crawler.store, state); err != nil {
+			t.Fatalf("WriteDebugDump() error = %v", err)
+		}
+	}
+	entries, err := crawler.store.List(ctx, store.DebugDir)
+	if err != nil || len(entries) != 2*maxDebugDumps {
+		t.Fatalf("debug dir has %d files, want %d (%v)", len(entries), 2*maxDebugDumps, err)
+	}
+	oldest := path.Join(store.DebugDir, base.Add(2*time.Second).Format(debugDumpTimeFormat)) + "-goroutines.txt"
+	if entries[0].Path != oldest {
+		t.Errorf("oldest dump kept = %s, want %s", entries[0].Path, oldest)
+	}
+}
@@ -0,0 +1,68 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/converter"
+)
+
+// DeletePage removes a page or database deleted in Notion from the mirror: its file, its downloaded files (and
+// the assets only it uses), its block cache and its registries, and detaches it from the children of its parent
+// and from the assets it shares. Its child pages are left to their own deletion events, or to cleanup. Returns the
+// registry of the removed page and the files deleted.
+// Changes are written to the crawler's transaction, which the caller commits.
+func (c *Crawler) DeletePage(ctx context.Context, pageID string) (*PageRegistry, []string, error) {
+	pageID = normalizePageID(pageID)
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", apperrors.ErrPageNotSynced, pageID)
+	}
+
+	var paths, filesDirs []string
+	if reg.FilePath != "" {
+		filesDir := filepath.Join(converter.TrimPageExt(reg.FilePath), "files")
+		filesDirs = append(filesDirs, filesDir)
+		paths = append(paths, reg.FilePath, filesDir)
+	}
+	paths = append(paths, blockCachePath(pageID))
+	files, err := c.purgedFiles(ctx, pageID, filesDirs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list file registries: %w", err)
+	}
+	paths = append(paths, files.paths...)
+
+	deleted, err := c.existingFiles(ctx, paths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.logger.InfoContext(ctx, "deleting page deleted in Notion",
+		notionKeyPageID, pageID,
+		notionKeyTitle, reg.Title,
+		"file_path", reg.FilePath,
+		"files", len(deleted))
+
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+	for _, path := range deleted {
+		if err := c.deleteFile(ctx, path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := c.deletePageRegistry(ctx, pageID); err != nil {
+		return nil, nil, err
+	}
+	if err := c.detachFromAssets(ctx, pageID, files.shared); err != nil {
+		return nil, nil, err
+	}
+	if err := c.detachFromParent(ctx, reg); err != nil {
+		return nil, nil, err
+	}
+
+	c.addFolderUsage(reg.Folder, -1, -reg.Size)
+	return reg, deleted, nil
+}
@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// diffContextLines is the number of unchanged lines shown around changes.
+const diffContextLines = 3
+
+// DiffOptions configures a diff.
+type DiffOptions struct {
+	PageID string // Page to diff (empty = all synced pages)
+	Folder string // Folder to diff when no page is given (empty = all folders)
+}
+
+// PageDiff is the difference between the file of a page and what current Notion content converts to.
+type PageDiff struct {
+	PageID   string
+	FilePath string
+	Diff     string // Unified diff, from the file to the converted content
+	Err      error  // Why the page could not be diffed
+}
+
+// Diff fetches synced pages and converts them in memory, without writing anything or downloading files, and
+// returns the pages whose file would change, in the unified diff format. Volatile frontmatter fields (like
+// last_synced) are ignored, so the result shows how much a sync would churn the mirror.
+func (c *Crawler) Diff(ctx context.Context, opts DiffOptions) ([]*PageDiff, error) {
+	var registries []*PageRegistry
+	if opts.PageID != "" {
+		reg, err := c.loadPageRegistry(ctx, opts.PageID)
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", opts.PageID, apperrors.ErrPageNotSynced)
+		}
+		registries = []*PageRegistry{reg}
+	} else {
+		all, err := c.listPageRegistries(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list registries: %w", err)
+		}
+		registries = all
+		if opts.Folder != "" {
+			registries = filterRegistriesByFolder(registries, opts.Folder)
+		}
+	}
+
+	// User lookups are cached in registries, files are never downloaded
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+	c.skipDownloads = true
+	defer func() { c.skipDownloads = false }()
+
+	var diffs []*PageDiff
+	for _, reg := range registries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		pageDiff := c.diffPage(ctx, reg)
+		if pageDiff.Err != nil && opts.PageID != "" {
+			return nil, pageDiff.Err
+		}
+		if pageDiff.Diff != "" || pageDiff.Err != nil {
+			diffs = append(diffs, pageDiff)
+		}
+	}
+	return diffs, nil
+}
+
+// diffPage compares the file of a page with what current Notion content converts to.
+func (c *Crawler) diffPage(ctx context.Context, reg *PageRegistry) *PageDiff {
+	pageDiff := &PageDiff{PageID: reg.ID, FilePath: reg.FilePath}
+
+	params, _, err := c.buildItemParams(ctx, reg.ID, reg.Folder)
+	if err != nil {
+		pageDiff.Err = fmt.Errorf("fetch page %s: %w", reg.ID, err)
+		return pageDiff
+	}
+	expected := params.convert(reg.FilePath, reg.IsRoot, reg.ParentID, reg.Aliases)
+
+	// A missing file diffs as empty
+	var localLines []string
+	if local, readErr := c.store.Read(ctx, reg.FilePath); readErr == nil {
+		localLines = stableContent(local)
+	}
+	pageDiff.Diff = unifiedDiff(reg.FilePath, localLines, stableContent(expected))
+	return pageDiff
+}
+
+// diffLine is a line of a diff, with its prefix: ' ' (unchanged), '-' (removed) or '+' (added).
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the unified diff turning the lines of from into the lines of to, or "" if they are equal.
+func unifiedDiff(path string, from, to []string) string {
+	lines := diffLines(from, to)
+
+	var b strings.Builder
+	oldLine, newLine := 0, 0 // Lines consumed before lines[start]
+	start := 0
+	for start < len(lines) {
+		// Find the next change, and extend the hunk while changes are close enough to share context
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first + 1; i < len(lines) && i <= last+2*diffContextLines; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+		hunkStart := max(first-diffContextLines, start)
+		hunkEnd := min(last+diffContextLines+1, len(lines))
+
+		for _, line := range lines[start:hunkStart] {
+			oldLine, newLine = advanceDiffLine(line, oldLine, newLine)
+		}
+		oldStart, newStart := oldLine, newLine
+		var hunk strings.Builder
+		for _, line := range lines[hunkStart:hunkEnd] {
+			oldLine, newLine = advanceDiffLine(line, oldLine, newLine)
+			hunk.WriteByte(line.op)
+			hunk.WriteString(line.text)
+			hunk.WriteByte('\n')
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldStart, oldLine-oldStart), hunkRange(newStart, newLine-newStart))
+		b.WriteString(hunk.String())
+		start = hunkEnd
+	}
+	return b.String()
+}
+
+// diffLines computes the line diff turning from into to.
+func diffLines(from, to []string) []diffLine {
+	join := func(lines []string) string {
+		if len(lines) == 0 {
+			return ""
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	var lines []diffLine
+	for _, chunk := range diff.Do(join(from), join(to)) {
+		op := byte(' ')
+		switch chunk.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		case diffmatchpatch.DiffEqual:
+		}
+		for text := range strings.SplitSeq(strings.TrimSuffix(chunk.Text, "\n"), "\n") {
+			lines = append(lines, diffLine{op: op, text: text})
+		}
+	}
+	return lines
+}
+
+// advanceDiffLine returns the numbers of old and new lines consumed after a diff line.
+func advanceDiffLine(line diffLine, oldLine, newLine int) (int, int) {
+	if line.op != '+' {
+		oldLine++
+	}
+	if line.op != '-' {
+		newLine++
+	}
+	return oldLine, newLine
+}
+
+// hunkRange formats the range of a hunk header from the number of lines before it and its length.
+// An empty range refers to the line before it, as in diff(1).
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	var from []string
+	for i := 1; i <= 20; i++ {
+		from = append(from, fmt.Sprintf("line %d", i))
+	}
+	to := append([]string{}, from...)
+	to[1] = "changed"
+	to = append(to[:15], to[16:]...)
+
+	want := "--- a/test/page.md\n+++ b/test/page.md\n" +
+		"@@ -1,5 +1,5 @@\n line 1\n-line 2\n+changed\n line 3\n line 4\n line 5\n" +
+		"@@ -13,7 +13,6 @@\n line 13\n line 14\n line 15\n-line 16\n line 17\n line 18\n line 19\n"
+	if got := unifiedDiff("test/page.md", from, to); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := unifiedDiff("test/page.md", from, from); got != "" {
+		t.Errorf("unifiedDiff() of equal contents = %q, want \"\"", got)
+	}
+	want = "--- a/new.md\n+++ b/new.md\n@@ -0,0 +1 @@\n+a\n"
+	if got := unifiedDiff("new.md", nil, []string{"a"}); got != want {
+		t.Errorf("unifiedDiff() of a new file = %q, want %q", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	text := "Hello"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/pages/page":
+			fmt.Fprint(w, `{"object":"page","id":"page","last_edited_time":"2026-01-01T00:00:00Z",`+
+				`"parent":{"type":"workspace","workspace":true},`+
+				`"properties":{"title":{"type":"title","title":[{"plain_text":"Page"}]}}}`)
+		case "/blocks/page/children":
+			fmt.Fprintf(w, `{"object":"list","results":[{"object":"block","id":"b1","type":"paragraph",`+
+				`"paragraph":{"rich_text":[{"type":"text","plain_text":%q}]}}],"has_more":false}`, text)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	params, _, err := crawler.buildItemParams(ctx, "page", "test")
+	if err != nil {
+		t.Fatalf("buildItemParams() error = %v", err)
+	}
+	if err := crawler.tx.Write(ctx, "test/page.md", params.convert("test/page.md", true, "", nil)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{
+		ID: "page", Type: notionTypePage, Folder: "test", FilePath: "test/page.md", IsRoot: true,
+	}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	diffs, err := crawler.Diff(ctx, DiffOptions{PageID: "page"})
+	if err != nil || len(diffs) != 0 {
+		t.Fatalf("Diff() of an unchanged page = %v, %v, want no diff", diffs, err)
+	}
+
+	text = "World"
+	diffs, err = crawler.Diff(ctx, DiffOptions{Folder: "test"})
+	if err != nil || len(diffs) != 1 {
+		t.Fatalf("Diff() = %v, %v, want 1 diff", diffs, err)
+	}
+	if !strings.Contains(diffs[0].Diff, "\n-Hello\n+World\n") {
+		t.Errorf("Diff() =\n%s\nwant Hello replaced with World", diffs[0].Diff)
+	}
+	if content, _ := crawler.store.Read(ctx, "test/page.md"); !strings.Contains(string(content), "Hello") {
+		t.Error("Diff() should not write the file")
+	}
+
+	if _, err := crawler.Diff(ctx, DiffOptions{PageID: "unknown"}); !errors.Is(err, apperrors.ErrPageNotSynced) {
+		t.Errorf("Diff() of an unknown page error = %v, want ErrPageNotSynced", err)
+	}
+}
@@ -0,0 +1,142 @@
+package sync
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Reasons of excluded child pages, see FolderExclusion.
+const (
+	excludedByTitle    = "title"
+	excludedByDatabase = "database"
+	excludedByArchived = "archived"
+)
+
+// excludeAllFolders is the folder name of the exclusion rules of folders without their own rules.
+const excludeAllFolders = "*"
+
+// FolderExclusion holds the rules excluding pages from the child discovery of a folder. Excluded pages are never
+// queued, so their whole subtree stays out of the mirror.
+type FolderExclusion struct {
+	Titles    []*regexp.Regexp // Patterns of the titles of excluded pages
+	Databases bool             // Exclude child databases
+	Archived  bool             // Exclude archived and trashed pages
+}
+
+// match returns why a child page is excluded: excludedByTitle, excludedByDatabase, excludedByArchived, or an empty
+// string if it is not.
+func (e FolderExclusion) match(ref childPageRef) string {
+	switch {
+	case e.Archived && ref.archived:
+		return excludedByArchived
+	case e.Databases && ref.database:
+		return excludedByDatabase
+	}
+	for _, pattern := range e.Titles {
+		if pattern.MatchString(ref.title) {
+			return excludedByTitle
+		}
+	}
+	return ""
+}
+
+// exclusionFor returns the exclusion rules of a folder: its own rules if configured, the "*" rules otherwise.
+func (cfg *Config) exclusionFor(folder string) FolderExclusion {
+	if exclusion, ok := cfg.FolderExclusions[folder]; ok {
+		return exclusion
+	}
+	return cfg.FolderExclusions[excludeAllFolders]
+}
+
+// parseFolderExclusionsEnv parses per-folder exclusion rules from a string like
+// "tech=title:Draft*|title:/^(old|wip) /|databases,*=archived". Rules are separated by "|": "databases",
+// "archived", and "title:" followed by a glob pattern (case-insensitive) or a regular expression between slashes,
+// which may contain "|" but not ",". The "*" folder applies to the folders without their own rules. Invalid rules
+// are ignored.
+func parseFolderExclusionsEnv(val string) map[string]FolderExclusion {
+	exclusions := make(map[string]FolderExclusion)
+	for item := range strings.SplitSeq(val, ",") {
+		folder, rules, found := strings.Cut(strings.TrimSpace(item), "=")
+		folder = strings.TrimSpace(folder)
+		if !found || folder == "" {
+			continue
+		}
+
+		var exclusion FolderExclusion
+		for _, rule := range splitExclusionRules(rules) {
+			switch strings.ToLower(rule) {
+			case "databases":
+				exclusion.Databases = true
+			case "archived":
+				exclusion.Archived = true
+			default:
+				if pattern, ok := strings.CutPrefix(rule, "title:"); ok {
+					if re := compileTitlePattern(pattern); re != nil {
+						exclusion.Titles = append(exclusion.Titles, re)
+					}
+				}
+			}
+		}
+		exclusions[folder] = exclusion
+	}
+	return exclusions
+}
+
+// splitExclusionRules splits rules separated by "|", keeping together the regular expressions that contain "|".
+func splitExclusionRules(rules string) []string {
+	var split []string
+	inRegexp := false
+	for part := range strings.SplitSeq(rules, "|") {
+		if inRegexp {
+			split[len(split)-1] += "|" + part
+		} else {
+			split = append(split, part)
+		}
+		rule := strings.TrimSpace(split[len(split)-1])
+		pattern, isTitle := strings.CutPrefix(rule, "title:/")
+		inRegexp = isTitle && !strings.HasSuffix(pattern, "/")
+	}
+	for i := range split {
+		split[i] = strings.TrimSpace(split[i])
+	}
+	return split
+}
+
+// compileTitlePattern compiles a title pattern: a regular expression between slashes, or else a case-insensitive
+// glob pattern matching the whole title ("*" matches any text, "?" any character). Returns nil if it is invalid.
+func compileTitlePattern(pattern string) *regexp.Regexp {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil
+		}
+		return re
+	}
+	if pattern == "" {
+		return nil
+	}
+
+	glob := regexp.QuoteMeta(pattern)
+	glob = strings.ReplaceAll(glob, `\*`, ".*")
+	glob = strings.ReplaceAll(glob, `\?`, ".")
+	return regexp.MustCompile("(?i)^" + glob + "$")
+}
+
+// excludeChildren returns the child pages that are not excluded by the rules of the folder.
+func (c *Crawler) excludeChildren(ctx context.Context, folder string, refs []childPageRef) []childPageRef {
+	exclusion := GetConfig().exclusionFor(folder)
+	var kept []childPageRef
+	for _, ref := range refs {
+		if reason := exclusion.match(ref); reason != "" {
+			c.logger.DebugContext(ctx, "skipping excluded child page",
+				notionKeyPageID, ref.id,
+				notionKeyTitle, ref.title,
+				"folder", folder,
+				"reason", reason)
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	return kept
+}
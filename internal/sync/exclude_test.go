@@ -0,0 +1,39 @@
+package sync
+
+import "testing"
+
+func TestParseFolderExclusionsEnv(t *testing.T) {
+	t.Parallel()
+
+	exclusions := parseFolderExclusionsEnv("tech=title:Draft*|title:/^(old|wip) /|databases, *=archived|title:/[/,bad")
+	cfg := &Config{FolderExclusions: exclusions}
+
+	tests := []struct {
+		folder string
+		ref    childPageRef
+		want   string
+	}{
+		{folder: "tech", ref: childPageRef{title: "Roadmap"}, want: ""},
+		{folder: "tech", ref: childPageRef{title: "draft: Q3 plan"}, want: excludedByTitle},
+		{folder: "tech", ref: childPageRef{title: "A draft"}, want: ""},
+		{folder: "tech", ref: childPageRef{title: "wip API"}, want: excludedByTitle},
+		{folder: "tech", ref: childPageRef{title: "WIP API"}, want: ""},
+		{folder: "tech", ref: childPageRef{title: "Tasks", database: true}, want: excludedByDatabase},
+		{folder: "tech", ref: childPageRef{title: "Old", archived: true}, want: ""},
+		{folder: "hr", ref: childPageRef{title: "Old", archived: true}, want: excludedByArchived},
+		{folder: "hr", ref: childPageRef{title: "Draft", database: true}, want: ""},
+	}
+	for _, tc := range tests {
+		if got := cfg.exclusionFor(tc.folder).match(tc.ref); got != tc.want {
+			t.Errorf("exclusionFor(%q).match(%+v) = %q, want %q", tc.folder, tc.ref, got, tc.want)
+		}
+	}
+
+	// The invalid regular expression is ignored, like the entry without rules
+	if got := len(exclusions[excludeAllFolders].Titles); got != 0 {
+		t.Errorf("title patterns of * = %d, want the invalid one ignored", got)
+	}
+	if _, ok := exclusions["bad"]; ok {
+		t.Error("entry without '=' must be ignored")
+	}
+}
@@ -36,8 +36,8 @@ func getMaxFileSize() int64 {
 	return GetConfig().MaxFileSize
 }
 
-// formatBytes formats bytes in a human-readable format.
-func formatBytes(bytes int64) string {
+// FormatBytes formats bytes in a human-readable format.
+func FormatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -75,70 +75,85 @@ func extractFileIDFromURL(rawURL string) string {
 	return ""
 }
 
-// downloadFile downloads a file from URL and saves it locally using streaming.
-// This avoids loading the entire file into memory.
-// Respects NTN_MAX_FILE_SIZE environment variable (default 5MB).
-func (c *Crawler) downloadFile(ctx context.Context, fileURL, localPath string) error {
+// openDownload starts the download of a file, checking its announced size against NTN_MAX_FILE_SIZE
+// (default 5MB). The returned body is limited to the max size plus one byte, so that callers can detect
+// files larger than announced.
+func (c *Crawler) openDownload(ctx context.Context, fileURL string) (io.ReadCloser, error) {
 	maxSize := getMaxFileSize()
-	c.logger.DebugContext(ctx, "downloading file", "url", fileURL, "path", localPath, "max_size", formatBytes(maxSize))
 
 	// First, do a HEAD request to check size before downloading
 	headReq, err := http.NewRequestWithContext(ctx, http.MethodHead, fileURL, nil)
 	if err != nil {
-		return fmt.Errorf("create HEAD request: %w", err)
+		return nil, fmt.Errorf("create HEAD request: %w", err)
 	}
 
 	headResp, err := http.DefaultClient.Do(headReq)
 	if err == nil {
-		defer func() {
-			_ = headResp.Body.Close()
-		}()
+		_ = headResp.Body.Close()
 
 		if headResp.ContentLength > maxSize {
 			c.logger.WarnContext(ctx, "file exceeds size limit, skipping",
 				"url", fileURL,
-				"size", formatBytes(headResp.ContentLength),
-				"limit", formatBytes(maxSize),
+				"size", FormatBytes(headResp.ContentLength),
+				"limit", FormatBytes(maxSize),
 			)
-			return ErrFileTooLarge
+			return nil, ErrFileTooLarge
 		}
 	}
 	// If HEAD fails, proceed with GET and check during download
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("download file: %w", err)
+		return nil, fmt.Errorf("download file: %w", err)
 	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			c.logger.WarnContext(ctx, "failed to close response body", "error", closeErr)
-		}
-	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return apperrors.NewHTTPError(resp.StatusCode, "download failed")
+		_ = resp.Body.Close()
+		return nil, apperrors.NewHTTPError(resp.StatusCode, "download failed")
 	}
 
 	// Check Content-Length from GET response as well
 	if resp.ContentLength > maxSize {
+		_ = resp.Body.Close()
 		c.logger.WarnContext(ctx, "file exceeds size limit, skipping",
 			"url", fileURL,
-			"size", formatBytes(resp.ContentLength),
-			"limit", formatBytes(maxSize),
+			"size", FormatBytes(resp.ContentLength),
+			"limit", FormatBytes(maxSize),
 		)
-		return ErrFileTooLarge
+		return nil, ErrFileTooLarge
 	}
 
 	// Use LimitReader as a safety net (server might send more than advertised)
-	// Stream directly to file instead of loading into memory
-	limitedReader := io.LimitReader(resp.Body, maxSize+1)
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, maxSize+1), resp.Body}, nil
+}
+
+// downloadFile downloads a file from URL and saves it locally using streaming.
+// This avoids loading the entire file into memory.
+// Respects NTN_MAX_FILE_SIZE environment variable (default 5MB).
+func (c *Crawler) downloadFile(ctx context.Context, fileURL, localPath string) error {
+	maxSize := getMaxFileSize()
+	c.logger.DebugContext(ctx, "downloading file", "url", fileURL, "path", localPath, "max_size", FormatBytes(maxSize))
+
+	body, err := c.openDownload(ctx, fileURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := body.Close(); closeErr != nil {
+			c.logger.WarnContext(ctx, "failed to close response body", "error", closeErr)
+		}
+	}()
 
-	written, err := c.tx.WriteStream(ctx, localPath, limitedReader)
+	// Stream directly to file instead of loading into memory
+	written, err := c.tx.WriteStream(ctx, localPath, body)
 	if err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
@@ -147,8 +162,8 @@ func (c *Crawler) downloadFile(ctx context.Context, fileURL, localPath string) e
 	if written > maxSize {
 		c.logger.WarnContext(ctx, "file exceeds size limit during download, removing",
 			"url", fileURL,
-			"size_read", formatBytes(written),
-			"limit", formatBytes(maxSize),
+			"size_read", FormatBytes(written),
+			"limit", FormatBytes(maxSize),
 		)
 		// Clean up the oversized file
 		if delErr := c.tx.Delete(ctx, localPath); delErr != nil {
@@ -157,7 +172,7 @@ func (c *Crawler) downloadFile(ctx context.Context, fileURL, localPath string) e
 		return ErrFileTooLarge
 	}
 
-	c.logger.InfoContext(ctx, "downloaded file", "path", localPath, "size", formatBytes(written))
+	c.logger.InfoContext(ctx, "downloaded file", "path", localPath, "size", FormatBytes(written))
 	return nil
 }
 
@@ -217,7 +232,8 @@ func (c *Crawler) resolveFileConflict(ctx context.Context, filesDir, filename, f
 // If the file is new, downloads it and returns the new local path.
 // pageFilePath is the full path to the page's markdown file (e.g., "dir/page.md").
 // pageID is the ID of the page/database containing this file.
-// Files are saved in a "files" subdirectory under the page name (e.g., "dir/page/files/image.png").
+// Files are saved in a "files" subdirectory under the page name (e.g., "dir/page/files/image.png"), or in the
+// assets directory with NTN_FILE_STORAGE=assets (see processAssetURL).
 //
 //nolint:unparam // error return kept for API consistency
 func (c *Crawler) processFileURL(ctx context.Context, fileURL, pageFilePath, pageID string) (string, error) {
@@ -230,8 +246,15 @@ func (c *Crawler) processFileURL(ctx context.Context, fileURL, pageFilePath, pag
 	// Check if file is already registered
 	if reg, err := c.loadFileRegistry(ctx, fileID); err == nil {
 		// File already downloaded, return local path
+		c.recordAsset(pageID, reg)
 		return reg.FilePath, nil
 	}
+	if c.skipDownloads {
+		return fileURL, nil
+	}
+	if GetConfig().FileStorage == FileStorageAssets {
+		return c.processAssetURL(ctx, fileURL, pageID), nil
+	}
 
 	// Extract filename from URL
 	parsed, _ := url.Parse(fileURL)
@@ -248,7 +271,7 @@ func (c *Crawler) processFileURL(ctx context.Context, fileURL, pageFilePath, pag
 	// Sanitize filename but keep extension
 	ext := filepath.Ext(filename)
 	baseName := strings.TrimSuffix(filename, ext)
-	sanitized := converter.SanitizeFilename(baseName)
+	sanitized := c.converter.FilenameRules.Sanitize(baseName)
 	if sanitized == "" {
 		sanitized = "file"
 	}
@@ -257,7 +280,7 @@ func (c *Crawler) processFileURL(ctx context.Context, fileURL, pageFilePath, pag
 	// Build local path: dir/page/files/filename
 	// From page path like "dir/page.md", create "dir/page/files/filename"
 	pageDir := filepath.Dir(pageFilePath)
-	pageBase := strings.TrimSuffix(filepath.Base(pageFilePath), ".md")
+	pageBase := converter.TrimPageExt(filepath.Base(pageFilePath))
 	filesDir := filepath.Join(pageDir, pageBase, "files")
 
 	// Check for naming conflicts with existing files
@@ -0,0 +1,64 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+const (
+	// gitLooseObjectsWarn is the number of loose objects above which the mirror repository needs a gc, as the
+	// default gc.auto of git.
+	gitLooseObjectsWarn = 6700
+	// gitPacksWarn is the number of packs above which the mirror repository needs a gc, as the default
+	// gc.autoPackLimit of git.
+	gitPacksWarn = 50
+)
+
+// gitMetrics returns the git metrics of the store, or nil if it is not backed by git repositories.
+func (c *Crawler) gitMetrics(ctx context.Context) *store.GitMetrics {
+	reader, ok := c.store.(store.GitMetricsReader)
+	if !ok {
+		return nil
+	}
+	metrics, err := reader.GitMetrics()
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to read git metrics", "error", err)
+		return nil
+	}
+	return metrics
+}
+
+// logGitMetrics logs the git operations of a run and the growth of the repository since it started, and warns
+// when the repository needs a gc.
+func (c *Crawler) logGitMetrics(ctx context.Context, start *store.GitMetrics) {
+	if start == nil {
+		return
+	}
+	end := c.gitMetrics(ctx)
+	if end == nil {
+		return
+	}
+
+	run := end.Sub(*start)
+	c.logger.InfoContext(ctx, "git metrics of the run",
+		"commits", run.Commit.Count,
+		"commit_ms", run.Commit.Duration.Milliseconds(),
+		"pushes", run.Push.Count,
+		"push_ms", run.Push.Duration.Milliseconds(),
+		"pulls", run.Pull.Count,
+		"pull_ms", run.Pull.Duration.Milliseconds(),
+		"failures", run.Commit.Failures+run.Push.Failures+run.Pull.Failures,
+		"repo_size", end.RepoSize,
+		"repo_growth", end.RepoSize-start.RepoSize,
+		"pack_size", end.PackSize,
+		"pack_growth", end.PackSize-start.PackSize,
+		"loose_objects", end.LooseObjects)
+
+	if end.LooseObjects > gitLooseObjectsWarn || end.Packs > gitPacksWarn {
+		c.logger.WarnContext(ctx, "the mirror repository needs a gc to pack its objects (git gc)",
+			"loose_objects", end.LooseObjects,
+			"loose_size", end.LooseSize,
+			"packs", end.Packs)
+	}
+}
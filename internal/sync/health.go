@@ -0,0 +1,126 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// defaultHealthStaleAfter is the age of the oldest queued entry after which the health of a run decreases.
+const defaultHealthStaleAfter = 24 * time.Hour
+
+// healthMaxScore is the score of a healthy run.
+const healthMaxScore = 100
+
+// RunHealth scores a sync run, so that scheduled syncs can report a degradation instead of silently limping:
+// pages that keep failing, or a queue that is no longer drained.
+type RunHealth struct {
+	Score     int     `json:"score"`      // From 0 to 100: share of successfully synced pages, lowered by staleness
+	Synced    int     `json:"synced"`     // Pages successfully synced
+	Failed    int     `json:"failed"`     // Pages that failed and are queued again for a retry
+	Dropped   int     `json:"dropped"`    // Pages dropped from the queue (blocked)
+	ErrorRate float64 `json:"error_rate"` // Share of the attempted pages that failed or were dropped
+	// OldestQueued is the age of the oldest queue entry left after the run (zero when the queue is drained)
+	OldestQueued time.Duration `json:"oldest_queued"`
+}
+
+// computeHealth scores a run. The score is the share of the attempted pages that were synced (100% when no page
+// was attempted), multiplied by staleAfter / OldestQueued when the oldest queued entry is older than staleAfter.
+func computeHealth(synced, failed, dropped int, oldestQueued, staleAfter time.Duration) RunHealth {
+	health := RunHealth{Synced: synced, Failed: failed, Dropped: dropped, OldestQueued: oldestQueued}
+
+	success := 1.0
+	if attempted := synced + failed + dropped; attempted > 0 {
+		success = float64(synced) / float64(attempted)
+		health.ErrorRate = float64(failed+dropped) / float64(attempted)
+	}
+	freshness := 1.0
+	if staleAfter > 0 && oldestQueued > staleAfter {
+		freshness = float64(staleAfter) / float64(oldestQueued)
+	}
+	health.Score = int(math.Round(healthMaxScore * success * freshness))
+	return health
+}
+
+// oldestQueuedAge returns the age of the oldest queue entry, or zero when the queue is empty.
+func (c *Crawler) oldestQueuedAge(ctx context.Context) time.Duration {
+	files, err := c.queueManager.ListEntries(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to list queue entries", "error", err)
+		return 0
+	}
+
+	var oldest time.Time
+	for _, file := range files {
+		entry, err := c.queueManager.ReadEntry(ctx, file)
+		if err != nil || entry.CreatedAt.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || entry.CreatedAt.Before(oldest) {
+			oldest = entry.CreatedAt
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return time.Since(oldest)
+}
+
+// recordHealth scores the run and notifies NTN_HEALTH_NOTIFY_URL when the score is below NTN_HEALTH_THRESHOLD.
+func (c *Crawler) recordHealth(ctx context.Context, synced, failed, dropped int) RunHealth {
+	cfg := GetConfig()
+	health := computeHealth(synced, failed, dropped, c.oldestQueuedAge(ctx), cfg.HealthStaleAfter)
+	c.health = &health
+
+	if cfg.HealthThreshold <= 0 || health.Score >= cfg.HealthThreshold {
+		return health
+	}
+	c.logger.WarnContext(ctx, "sync run is unhealthy",
+		"score", health.Score,
+		"threshold", cfg.HealthThreshold,
+		"failed", failed,
+		"dropped", dropped,
+		"oldest_queued", health.OldestQueued.Round(time.Second))
+	if cfg.HealthNotifyURL != "" {
+		if err := notifyUnhealthy(ctx, cfg.HealthNotifyURL, &health, cfg.HealthThreshold); err != nil {
+			c.logger.WarnContext(ctx, "failed to send health notification", "error", err)
+		}
+	}
+	return health
+}
+
+// healthNotification is the JSON payload posted when a run is unhealthy.
+type healthNotification struct {
+	Event     string `json:"event"`
+	Threshold int    `json:"threshold"`
+	RunHealth
+}
+
+// notifyUnhealthy posts a health notification to the configured URL.
+func notifyUnhealthy(ctx context.Context, url string, health *RunHealth, threshold int) error {
+	body, err := json.Marshal(&healthNotification{Event: "sync_unhealthy", Threshold: threshold, RunHealth: *health})
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	return postNotification(ctx, url, "", body)
+}
+
+// Health returns the health of the last queue processing of the crawler, or nil if it didn't process the queue.
+func (c *Crawler) Health() *RunHealth {
+	return c.health
+}
+
+// CheckHealth returns ErrUnhealthyRun when the health score of the last queue processing is below
+// NTN_HEALTH_THRESHOLD, so that scheduled syncs exit with a non-zero status.
+func (c *Crawler) CheckHealth() error {
+	threshold := GetConfig().HealthThreshold
+	if c.health == nil || threshold <= 0 || c.health.Score >= threshold {
+		return nil
+	}
+	return fmt.Errorf("%w: score %d, below %d (%d synced, %d failed, %d dropped)", apperrors.ErrUnhealthyRun,
+		c.health.Score, threshold, c.health.Synced, c.health.Failed, c.health.Dropped)
+}
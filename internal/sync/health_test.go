@@ -0,0 +1,37 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeHealth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                    string
+		synced, failed, dropped int
+		oldestQueued            time.Duration
+		wantScore               int
+		wantErrorRate           float64
+	}{
+		{name: "nothing to do", wantScore: 100},
+		{name: "all synced", synced: 10, oldestQueued: time.Hour, wantScore: 100},
+		{name: "failures", synced: 6, failed: 3, dropped: 1, wantScore: 60, wantErrorRate: 0.4},
+		{name: "stale queue", synced: 10, oldestQueued: 48 * time.Hour, wantScore: 50},
+		{name: "failures and stale queue", synced: 1, failed: 1, oldestQueued: 96 * time.Hour, wantScore: 13,
+			wantErrorRate: 0.5},
+	}
+	for _, tc := range tests {
+		health := computeHealth(tc.synced, tc.failed, tc.dropped, tc.oldestQueued, defaultHealthStaleAfter)
+		if health.Score != tc.wantScore || health.ErrorRate != tc.wantErrorRate {
+			t.Errorf("%s: computeHealth() = score %d, error rate %v; want %d, %v",
+				tc.name, health.Score, health.ErrorRate, tc.wantScore, tc.wantErrorRate)
+		}
+	}
+
+	// Staleness is ignored when disabled
+	if got := computeHealth(1, 0, 0, 96*time.Hour, 0).Score; got != 100 {
+		t.Errorf("computeHealth() without staleness = %d, want 100", got)
+	}
+}
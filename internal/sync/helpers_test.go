@@ -0,0 +1,29 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// newTestCrawler creates a crawler on an in-memory store with an open transaction.
+func newTestCrawler(t *testing.T) (*Crawler, *queue.Manager) {
+	t.Helper()
+
+	st := store.NewMemStore()
+	tx, err := st.BeginTx(context.Background())
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	qm := queue.NewManager(st, slog.Default())
+	qm.SetTransaction(tx)
+
+	crawler := NewCrawler(nil, st, WithCrawlerLogger(slog.Default()))
+	crawler.SetTransaction(tx)
+
+	return crawler, qm
+}
@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// ProgressHooks are optional callbacks observing queue processing, so that embedders and user interfaces
+// can follow the progress of a sync without parsing logs. They are called synchronously from the
+// processing loop and should return quickly. With NTN_SYNC_CONCURRENCY above 1, OnPageStart and OnPageDone are
+// called concurrently from the workers processing the pages.
+type ProgressHooks struct {
+	// OnPageStart is called before a queued page is fetched.
+	OnPageStart func(ctx context.Context, pageID, folder string)
+	// OnPageDone is called after a queued page was processed, with the error if it failed.
+	OnPageDone func(ctx context.Context, pageID, folder string, err error)
+	// OnQueueDrained is called when queue processing stops because no queue entry is left to process.
+	OnQueueDrained func(ctx context.Context)
+	// OnQueueFileDone is called after each queue file is processed (written or deleted). An error stops
+	// processing, which makes it suited to periodic commits.
+	OnQueueFileDone func(ctx context.Context, queueFile string) error
+}
+
+// WithProgressHooks sets callbacks observing queue processing.
+func WithProgressHooks(hooks ProgressHooks) CrawlerOption {
+	return func(c *Crawler) {
+		c.hooks = hooks
+	}
+}
+
+// SetProgressHooks replaces the callbacks observing queue processing, for the next runs of a crawler created
+// elsewhere. It must not be called while the queue is processed.
+func (c *Crawler) SetProgressHooks(hooks ProgressHooks) {
+	c.hooks = hooks
+}
+
+// processQueuedPage processes a page taken from the queue, notifying the progress hooks.
+func (c *Crawler) processQueuedPage(
+	ctx context.Context, pageID, folder, queueType, parentID string,
+) (int, error) {
+	if c.hooks.OnPageStart != nil {
+		c.hooks.OnPageStart(ctx, pageID, folder)
+	}
+	c.updateRun(ctx, func(status *RunStatus) {
+		status.CurrentPage = pageID
+	})
+	defer c.trackInFlight(pageID, folder)()
+
+	calls := &notion.CallCounter{}
+	ctx = notion.WithCallCounter(ctx, calls)
+
+	var filesCount int
+	var err error
+	if queueType == queueTypeProperties {
+		// Property refreshes are cheap: they are done entirely under the write lock
+		lockedCtx, unlock := c.lockWrites(ctx)
+		filesCount, err = c.refreshPageProperties(lockedCtx, pageID, folder)
+		unlock()
+	} else {
+		filesCount, err = c.processPage(ctx, pageID, folder, queueType == queueTypeInit, parentID)
+	}
+
+	c.logger.DebugContext(ctx, "page API calls", "page_id", pageID, "total", calls.Total(), "calls", calls.Counts())
+	c.recordAPICalls(pageID, calls.Counts())
+
+	if c.hooks.OnPageDone != nil {
+		c.hooks.OnPageDone(ctx, pageID, folder, err)
+	}
+	return filesCount, err
+}
+
+// queueDrained notifies the progress hooks that the queue is empty.
+func (c *Crawler) queueDrained(ctx context.Context) {
+	if c.hooks.OnQueueDrained != nil {
+		c.hooks.OnQueueDrained(ctx)
+	}
+}
+
+// queueFileDone notifies the progress hooks that a queue file was processed.
+func (c *Crawler) queueFileDone(ctx context.Context, queueFile string) error {
+	if c.hooks.OnQueueFileDone == nil {
+		return nil
+	}
+	if err := c.hooks.OnQueueFileDone(ctx, queueFile); err != nil {
+		return err
+	}
+	c.SetRunPhase(ctx, RunPhaseQueue)
+	return nil
+}
@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+var errHookFailed = errors.New("hook failed")
+
+func TestProgressHooks(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	var events []string
+	var failures int
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+	crawler.SetProgressHooks(ProgressHooks{
+		OnPageStart: func(_ context.Context, pageID, folder string) {
+			events = append(events, "start:"+folder+"/"+pageID)
+		},
+		OnPageDone: func(_ context.Context, pageID, _ string, err error) {
+			events = append(events, "done:"+pageID)
+			if err != nil {
+				failures++
+			}
+		},
+		OnQueueDrained: func(context.Context) {
+			events = append(events, "drained")
+		},
+		OnQueueFileDone: func(_ context.Context, queueFile string) error {
+			events = append(events, "file:"+queueFile)
+			return nil
+		},
+	})
+
+	ctx := context.Background()
+	queueFile, err := qm.CreateEntry(ctx, queue.Entry{
+		Type:   "update",
+		Folder: "test",
+		Pages:  []queue.Page{{ID: "page1"}, {ID: "page2"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+
+	want := []string{
+		"start:test/page1", "done:page1", "start:test/page2", "done:page2", "file:" + queueFile, "drained",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("hook events = %v, want %v", events, want)
+	}
+	if failures != 2 {
+		t.Errorf("failed pages = %d, want 2 (pages are not found)", failures)
+	}
+
+	// An error of OnQueueFileDone stops processing, e.g. when a periodic commit fails
+	crawler.SetProgressHooks(ProgressHooks{
+		OnQueueFileDone: func(context.Context, string) error { return errHookFailed },
+	})
+	if _, err := qm.CreateEntry(ctx, queue.Entry{Type: "update", Folder: "test", Pages: []queue.Page{{ID: "page3"}}}); err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); !errors.Is(err, errHookFailed) {
+		t.Errorf("ProcessQueue() error = %v, want the error of the hook", err)
+	}
+}
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"context"
+	"strings"
+)
+
+// blockedReasonIgnored is recorded for the new template and duplicated pages skipped by NTN_SKIP_TEMPLATES and
+// NTN_SKIP_COPIES. Like other blocked pages, they are retried once edited (e.g. renamed after being duplicated).
+const blockedReasonIgnored = "ignored"
+
+// Kinds of ignored pages.
+const (
+	ignoredKindTemplate = "template"
+	ignoredKindCopy     = "copy"
+)
+
+// ignoredPageKind returns why a page with this title is ignored by the configuration: ignoredKindTemplate,
+// ignoredKindCopy, or an empty string if it is not.
+// The Notion API doesn't flag template pages, so they are detected by title, like duplicated pages.
+func ignoredPageKind(cfg *Config, title string) string {
+	switch {
+	case cfg.SkipTemplates && isTemplateTitle(title):
+		return ignoredKindTemplate
+	case cfg.SkipCopies && isCopyTitle(title):
+		return ignoredKindCopy
+	default:
+		return ""
+	}
+}
+
+// isTemplateTitle returns true for titles like "Template", "Template: Meeting notes", "[Template] Meeting notes"
+// or "Meeting notes (template)".
+func isTemplateTitle(title string) bool {
+	title = strings.ToLower(strings.TrimSpace(title))
+	return title == "template" ||
+		strings.HasPrefix(title, "template:") ||
+		strings.HasPrefix(title, "[template]") ||
+		strings.HasSuffix(title, "(template)")
+}
+
+// isCopyTitle returns true for the titles Notion gives to duplicated pages: "Copy of Roadmap" or "Roadmap (Copy)".
+func isCopyTitle(title string) bool {
+	title = strings.ToLower(strings.TrimSpace(title))
+	return strings.HasPrefix(title, "copy of ") || strings.HasSuffix(title, "(copy)")
+}
+
+// skipIgnoredPage checks whether a new page is a template or duplicated page to skip. Skipped pages are recorded as
+// blocked, so that neither they nor their subtree are fetched again until they are edited.
+func (c *Crawler) skipIgnoredPage(ctx context.Context, pageID, folder, title string) bool {
+	kind := ignoredPageKind(GetConfig(), title)
+	if kind == "" {
+		return false
+	}
+
+	c.logger.InfoContext(ctx, "skipping ignored page",
+		notionKeyPageID, pageID,
+		notionKeyTitle, title,
+		"kind", kind)
+	c.markPageBlocked(ctx, pageID, folder, blockedReasonIgnored, pageID, nil)
+	return true
+}
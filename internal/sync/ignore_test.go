@@ -0,0 +1,32 @@
+package sync
+
+import "testing"
+
+func TestIgnoredPageKind(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{SkipTemplates: true, SkipCopies: true}
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Roadmap", want: ""},
+		{title: "Template", want: ignoredKindTemplate},
+		{title: "Template: Meeting notes", want: ignoredKindTemplate},
+		{title: "[Template] Meeting notes", want: ignoredKindTemplate},
+		{title: "Meeting notes (template)", want: ignoredKindTemplate},
+		{title: "Templates for interviews", want: ""},
+		{title: "Copy of Roadmap", want: ignoredKindCopy},
+		{title: "Roadmap (Copy)", want: ignoredKindCopy},
+		{title: "Copyright notice", want: ""},
+	}
+	for _, tc := range tests {
+		if got := ignoredPageKind(cfg, tc.title); got != tc.want {
+			t.Errorf("ignoredPageKind(%q) = %q, want %q", tc.title, got, tc.want)
+		}
+	}
+
+	if got := ignoredPageKind(&Config{}, "Copy of Roadmap"); got != "" {
+		t.Errorf("ignoredPageKind() without the toggles = %q, want none", got)
+	}
+}
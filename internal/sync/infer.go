@@ -0,0 +1,115 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// Folder inference modes, naming the folder of pages that are not under any root.
+const (
+	// FolderInferenceNone puts them in the default folder.
+	FolderInferenceNone = "none"
+	// FolderInferenceTitle names the folder after the title of their top-level parent. The Notion API only
+	// gives the ID of a page's teamspace, not its name, so the top-level page is the closest equivalent.
+	FolderInferenceTitle = "title"
+)
+
+// defaultFolder is the folder of pages without a known or inferred folder.
+const defaultFolder = "default"
+
+// topOfChain returns the top-level page of a parent chain: its last missing parent, or the page itself.
+func topOfChain(page *notion.Page, missingParents []*notion.Page) *notion.Page {
+	if len(missingParents) == 0 {
+		return page
+	}
+	return missingParents[len(missingParents)-1]
+}
+
+// inferFolder returns the folder of a page that is not under any root, from its top-level parent.
+func inferFolder(top *notion.Page) string {
+	if GetConfig().FolderInference != FolderInferenceTitle {
+		return defaultFolder
+	}
+	title := strings.TrimSpace(top.Title())
+	if title == "" {
+		return defaultFolder
+	}
+	folder := converter.DefaultFilenameRules().Sanitize(title)
+	if validateFolderName(folder) != nil {
+		return defaultFolder
+	}
+	return folder
+}
+
+// addInferredRoot adds the top-level parent of a page outside any root to root.md, in its inferred folder,
+// so that it is synced like the other roots. It returns true if the root was added.
+func (c *Crawler) addInferredRoot(ctx context.Context, top *notion.Page, folder string) (bool, error) {
+	manifest, err := c.ParseRootMd(ctx)
+	if err != nil {
+		return false, fmt.Errorf("parse root.md: %w", err)
+	}
+	if manifest == nil {
+		manifest = &RootManifest{}
+	}
+
+	pageID := normalizePageID(top.ID)
+	for i := range manifest.Entries {
+		if manifest.Entries[i].PageID == pageID {
+			return false, nil
+		}
+	}
+
+	url := top.URL
+	if url == "" {
+		url = "https://www.notion.so/" + pageID
+	}
+	entry := RootEntry{Folder: folder, Enabled: true, URL: url, PageID: pageID}
+	manifest.Entries = append(manifest.Entries, entry)
+	if err := c.WriteRootMd(ctx, manifest); err != nil {
+		return false, err
+	}
+	c.reconcileRootEntry(ctx, &entry)
+	c.state.AddFolder(folder)
+
+	c.logger.InfoContext(ctx, "added inferred root to root.md",
+		notionKeyPageID, pageID,
+		notionKeyTitle, top.Title(),
+		"folder", folder)
+	return true, nil
+}
+
+// inferredRoot returns the top-level parent of a page outside any root that should be added to root.md, or nil
+// unless NTN_FOLDER_INFERENCE_ROOTS is set. Pages under a disabled root have none.
+func inferredRoot(page *notion.Page, missingParents []*notion.Page) *notion.Page {
+	top := topOfChain(page, missingParents)
+	if !GetConfig().FolderInferenceRoots || normalizePageID(top.Parent.ID()) != "" {
+		return nil
+	}
+	return top
+}
+
+// queueInferredRoot adds the top-level parent of a page outside any root as a root, and queues it for its
+// initial sync, which brings the page.
+func (c *Crawler) queueInferredRoot(ctx context.Context, page *notion.Page, missingParents []*notion.Page) {
+	top := inferredRoot(page, missingParents)
+	if top == nil {
+		return
+	}
+
+	folder := inferFolder(top)
+	added, err := c.addInferredRoot(ctx, top, folder)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to add inferred root", notionKeyPageID, top.ID, "error", err)
+		return
+	}
+	if added {
+		c.queueRootPages(ctx, map[string][]queue.Page{
+			folder: {{ID: normalizePageID(top.ID)}},
+		})
+	}
+}
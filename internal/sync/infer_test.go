@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// inferTopID is the ID of the top-level parent of the tests.
+const inferTopID = "2c536f5e48f44234ad8d73a1a148e95d"
+
+func newInferTestPage(id, title, parentID string) *notion.Page {
+	parent := notion.Parent{Type: "workspace", Workspace: true}
+	if parentID != "" {
+		parent = notion.Parent{Type: "page_id", PageID: parentID}
+	}
+	return &notion.Page{
+		ID:     id,
+		Parent: parent,
+		Properties: notion.Properties{
+			"title": {Type: "title", Title: []notion.RichText{{PlainText: title}}},
+		},
+	}
+}
+
+func TestInferFolder(t *testing.T) {
+	// Cannot use t.Parallel() with t.Setenv
+	top := newInferTestPage(inferTopID, "Engineering Wiki", "")
+
+	t.Setenv("NTN_FOLDER_INFERENCE", "")
+	ResetConfig()
+	defer ResetConfig()
+	if got := inferFolder(top); got != defaultFolder {
+		t.Errorf("inferFolder() without inference = %q, want %q", got, defaultFolder)
+	}
+
+	t.Setenv("NTN_FOLDER_INFERENCE", "title")
+	ResetConfig()
+	if got := inferFolder(top); got != "engineering-wiki" {
+		t.Errorf("inferFolder() = %q, want engineering-wiki", got)
+	}
+	if got := inferFolder(newInferTestPage("top2", "  ", "")); got != defaultFolder {
+		t.Errorf("inferFolder(untitled) = %q, want %q", got, defaultFolder)
+	}
+}
+
+func TestQueueInferredRoot(t *testing.T) {
+	// Cannot use t.Parallel() with t.Setenv
+	t.Setenv("NTN_FOLDER_INFERENCE", "title")
+	t.Setenv("NTN_FOLDER_INFERENCE_ROOTS", "true")
+	ResetConfig()
+	defer ResetConfig()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	page := newInferTestPage("1f2e3d4c5b6a79881f2e3d4c5b6a7988", "Meeting notes", inferTopID)
+	top := newInferTestPage(inferTopID, "Engineering Wiki", "")
+
+	crawler.queueInferredRoot(ctx, page, []*notion.Page{top})
+	// A second page under the same top-level parent doesn't add it again
+	crawler.queueInferredRoot(ctx, page, []*notion.Page{top})
+
+	manifest, err := crawler.ParseRootMd(ctx)
+	if err != nil || manifest == nil {
+		t.Fatalf("ParseRootMd() = %v, %v", manifest, err)
+	}
+	if len(manifest.Entries) != 1 {
+		t.Fatalf("root.md has %d entries, want 1", len(manifest.Entries))
+	}
+	if entry := manifest.Entries[0]; entry.Folder != "engineering-wiki" || entry.PageID != inferTopID || !entry.Enabled {
+		t.Errorf("root.md entry = %+v", entry)
+	}
+	if enabled, rootID, _ := crawler.isRootEnabled(ctx, inferTopID); !enabled || rootID != inferTopID {
+		t.Errorf("isRootEnabled(top1) = %v, %q, want an enabled root", enabled, rootID)
+	}
+
+	// Pages under a registered but disabled root have no top-level parent to add
+	orphan := newInferTestPage("page2", "Orphan", "disabled-root")
+	if got := inferredRoot(orphan, nil); got != nil {
+		t.Errorf("inferredRoot(orphan) = %v, want nil", got.ID)
+	}
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"context"
+	"slices"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// defaultInlineDatabaseColumns is the number of properties shown in inline database tables
+// when no columns are configured.
+const defaultInlineDatabaseColumns = 3
+
+// fetchInlineDatabases fetches the top rows of the child databases found in blocks, to show them
+// in the parent page. It returns nil when inline databases are disabled.
+// Databases that cannot be fetched are only linked.
+func (c *Crawler) fetchInlineDatabases(
+	ctx context.Context, blocks []notion.Block,
+) map[string]*converter.InlineDatabase {
+	cfg := GetConfig()
+	if cfg.InlineDatabaseRows == 0 {
+		return nil
+	}
+
+	inline := make(map[string]*converter.InlineDatabase)
+	for _, id := range findChildDatabaseIDs(blocks) {
+		database, err := c.client.GetDatabase(ctx, id)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to fetch inline database", "database_id", id, "error", err)
+			continue
+		}
+		rows, hasMore, err := c.client.QueryDatabaseTopPages(ctx, database, cfg.InlineDatabaseRows)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to query inline database", "database_id", id, "error", err)
+			continue
+		}
+
+		titleColumn, _ := database.TitleProperty()
+		inline[id] = &converter.InlineDatabase{
+			TitleColumn: titleColumn,
+			Columns:     inlineDatabaseColumns(database, cfg.InlineDatabaseColumns),
+			Rows:        rows[:min(len(rows), cfg.InlineDatabaseRows)],
+			HasMore:     hasMore || len(rows) > cfg.InlineDatabaseRows,
+		}
+	}
+	return inline
+}
+
+// findChildDatabaseIDs returns the normalized IDs of the child databases found in blocks.
+func findChildDatabaseIDs(blocks []notion.Block) []string {
+	var ids []string
+	for i := range blocks {
+		block := &blocks[i]
+		if id := normalizePageID(block.ID); block.Type == "child_database" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+		for _, id := range findChildDatabaseIDs(block.Children) {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// inlineDatabaseColumns returns the properties of a database to show in its inline table:
+// the configured ones that exist, or the first properties by name. The title property is always excluded.
+func inlineDatabaseColumns(database *notion.Database, configured []string) []string {
+	titleColumn, _ := database.TitleProperty()
+
+	var columns []string
+	if len(configured) > 0 {
+		for _, name := range configured {
+			if _, ok := database.Properties[name]; ok && name != titleColumn {
+				columns = append(columns, name)
+			}
+		}
+		return columns
+	}
+
+	for name := range database.Properties {
+		if name != titleColumn {
+			columns = append(columns, name)
+		}
+	}
+	slices.Sort(columns)
+	return columns[:min(len(columns), defaultInlineDatabaseColumns)]
+}
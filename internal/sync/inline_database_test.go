@@ -0,0 +1,28 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestInlineDatabaseColumns(t *testing.T) {
+	t.Parallel()
+
+	database := &notion.Database{Properties: map[string]any{
+		"Name":     map[string]any{"type": "title"},
+		"Status":   map[string]any{"type": "status"},
+		"Assignee": map[string]any{"type": "people"},
+		"Due":      map[string]any{"type": "date"},
+		"Priority": map[string]any{"type": "select"},
+	}}
+
+	if got := inlineDatabaseColumns(database, nil); !slices.Equal(got, []string{"Assignee", "Due", "Priority"}) {
+		t.Errorf("default columns = %v", got)
+	}
+	if got := inlineDatabaseColumns(database, []string{"Status", "Missing", "Name", "Due"}); !slices.Equal(got,
+		[]string{"Status", "Due"}) {
+		t.Errorf("configured columns = %v, want existing non-title columns in order", got)
+	}
+}
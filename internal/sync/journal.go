@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// journalDir holds a record of each queued page being processed, in .notion-sync/journal/{page_id}.json. A record
+// is written before the page and removed once it is done, so the records left by a killed run tell the next run
+// which pages may be half written (file written but registry missing, children not queued).
+const journalDir = "journal"
+
+// journalRecord records that a queued page is being processed.
+type journalRecord struct {
+	PageID    string    `json:"page_id"`
+	Folder    string    `json:"folder"`
+	Type      string    `json:"type"` // Type of the queue entry
+	StartedAt time.Time `json:"started_at"`
+}
+
+// journalPath returns the path of the journal record of a page.
+func journalPath(pageID string) string {
+	return filepath.Join(stateDir, journalDir, normalizePageID(pageID)+".json")
+}
+
+// journalStart records that a queued page is being processed. Failures are only logged: the journal must not
+// fail a sync.
+func (c *Crawler) journalStart(ctx context.Context, pageID, folder, queueType string) {
+	data, err := json.MarshalIndent(&journalRecord{
+		PageID:    normalizePageID(pageID),
+		Folder:    folder,
+		Type:      queueType,
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+	}, "", "  ")
+	if err == nil {
+		err = c.tx.Write(ctx, journalPath(pageID), data)
+	}
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to write journal record", notionKeyPageID, pageID, "error", err)
+	}
+}
+
+// journalEnd removes the journal record of a page, once it was processed (or failed).
+func (c *Crawler) journalEnd(ctx context.Context, pageID string) {
+	if err := c.deleteFile(ctx, journalPath(pageID)); err != nil {
+		c.logger.WarnContext(ctx, "failed to remove journal record", notionKeyPageID, pageID, "error", err)
+	}
+}
+
+// recoverJournal queues again the pages whose processing was interrupted, as recorded in the journal, and returns
+// their number. They are queued as webhook updates, which are processed first and are never skipped as unchanged:
+// their registry may already be saved while their children were not queued.
+func (c *Crawler) recoverJournal(ctx context.Context) int {
+	entries, err := c.store.List(ctx, filepath.Join(stateDir, journalDir))
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to list journal records", "error", err)
+		return 0
+	}
+
+	recovered := 0
+	for i := range entries {
+		entry := &entries[i]
+		if entry.IsDir || !strings.HasSuffix(entry.Path, ".json") {
+			continue
+		}
+
+		var record journalRecord
+		data, err := c.store.Read(ctx, entry.Path)
+		if err == nil {
+			err = json.Unmarshal(data, &record)
+		}
+		if err != nil || record.PageID == "" {
+			c.logger.WarnContext(ctx, "dropping invalid journal record", "file", entry.Path, "error", err)
+			_ = c.deleteFile(ctx, entry.Path)
+			continue
+		}
+
+		c.logger.WarnContext(ctx, "queueing page interrupted by a previous run",
+			notionKeyPageID, record.PageID,
+			"folder", record.Folder,
+			"started_at", record.StartedAt)
+		if _, err := c.queueManager.CreateWebhookEntryWithType(
+			ctx, record.PageID, record.Folder, queue.TypeUpdate,
+		); err != nil {
+			c.logger.WarnContext(ctx, "failed to queue interrupted page", notionKeyPageID, record.PageID, "error", err)
+			continue // The record is kept for the next run
+		}
+		c.journalEnd(ctx, record.PageID)
+		recovered++
+	}
+	return recovered
+}
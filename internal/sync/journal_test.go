@@ -0,0 +1,45 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestRecoverJournal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+
+	// The run was killed while processing page1, page2 was done
+	crawler.journalStart(ctx, "page1", "tech", queueTypeInit)
+	crawler.journalStart(ctx, "page2", "tech", queueTypeInit)
+	crawler.journalEnd(ctx, "page2")
+
+	if got := crawler.recoverJournal(ctx); got != 1 {
+		t.Fatalf("recoverJournal() = %d, want 1", got)
+	}
+
+	files, err := qm.ListEntries(ctx)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListEntries() = %v, %v; want one queue file", files, err)
+	}
+	entry, err := qm.ReadEntry(ctx, files[0])
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	if entry.Type != queue.TypeUpdate || entry.Folder != "tech" || len(entry.Pages) != 1 || entry.Pages[0].ID != "page1" {
+		t.Errorf("queued entry = %+v, want an update of page1 in tech", entry)
+	}
+
+	records, err := crawler.store.List(ctx, filepath.Join(stateDir, journalDir))
+	if err != nil || len(records) != 0 {
+		t.Errorf("journal records = %v, %v; want none left", records, err)
+	}
+	if got := crawler.recoverJournal(ctx); got != 0 {
+		t.Errorf("recoverJournal() again = %d, want 0", got)
+	}
+}
@@ -0,0 +1,259 @@
+package sync
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+// Statuses of checked notion_url links.
+const (
+	// LinkStatusOK is recorded for links that resolve without redirect.
+	LinkStatusOK = "ok"
+	// LinkStatusRedirected is recorded for links that resolve after a redirect, e.g. after a page move.
+	LinkStatusRedirected = "redirected"
+	// LinkStatusDead is recorded for links Notion answers with 404 or 410.
+	LinkStatusDead = "dead"
+	// LinkStatusError is recorded when a link could not be checked (network error, rate limit, server error).
+	LinkStatusError = "error"
+)
+
+const (
+	linkReportFile = "link-report.json"
+
+	defaultLinkCheckInterval = time.Second
+	linkCheckTimeout         = 30 * time.Second
+)
+
+// LinkCheck is the result of checking the notion_url of a page.
+type LinkCheck struct {
+	PageID     string    `json:"page_id"`
+	FilePath   string    `json:"file_path"`
+	URL        string    `json:"notion_url"`
+	Status     string    `json:"status"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	FinalURL   string    `json:"final_url,omitempty"` // Where the link redirects to
+	Error      string    `json:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// LinkReport is the report of the notion_url links of the mirror, stored in .notion-sync/link-report.json.
+type LinkReport struct {
+	UpdatedAt time.Time             `json:"updated_at"`
+	Links     map[string]*LinkCheck `json:"links"` // key: page ID
+}
+
+// LinkCheckOptions configures a link check.
+type LinkCheckOptions struct {
+	Interval     time.Duration // Minimum delay between two requests (default 1s)
+	RecheckAfter time.Duration // Links checked more recently are not checked again (0 = check all)
+	MaxLinks     int           // Maximum number of links checked in this run (0 = unlimited)
+	DryRun       bool          // Check links without saving the report
+	HTTPClient   *http.Client  // Client used for requests (default: one with a 30s timeout)
+}
+
+// LinkCheckResult contains the result of a link check.
+type LinkCheckResult struct {
+	Checked    int          // Links checked in this run
+	Skipped    int          // Links checked recently, or beyond MaxLinks
+	Errors     int          // Links that could not be checked in this run
+	Dead       []*LinkCheck // Dead links of the report, including ones checked in previous runs
+	Redirected []*LinkCheck // Redirected links of the report, including ones checked in previous runs
+}
+
+// CheckLinks validates the notion_url of synced pages with HEAD requests, rate limited, and records
+// the results in the link report. It is meant to run periodically: with RecheckAfter, each run only
+// checks links not checked recently, so the mirror does not propagate broken canonical links unnoticed.
+func (c *Crawler) CheckLinks(ctx context.Context, opts LinkCheckOptions) (*LinkCheckResult, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultLinkCheckInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: linkCheckTimeout}
+	}
+
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list registries: %w", err)
+	}
+
+	report := c.loadLinkReport(ctx)
+	previous := report.Links
+	report.Links = make(map[string]*LinkCheck, len(registries))
+
+	result := &LinkCheckResult{}
+	now := time.Now()
+	for _, reg := range registries {
+		check := previous[reg.ID]
+		recent := check != nil && opts.RecheckAfter > 0 && now.Sub(check.CheckedAt) < opts.RecheckAfter
+		limited := opts.MaxLinks > 0 && result.Checked >= opts.MaxLinks
+		if recent || limited {
+			if check != nil {
+				report.Links[reg.ID] = check
+			}
+			result.Skipped++
+			continue
+		}
+
+		link := c.readNotionURL(ctx, reg.FilePath)
+		if link == "" {
+			continue
+		}
+		if result.Checked > 0 && !sleepContext(ctx, opts.Interval) {
+			return nil, ctx.Err()
+		}
+
+		check = checkLink(ctx, opts.HTTPClient, link)
+		check.PageID, check.FilePath = reg.ID, reg.FilePath
+		report.Links[reg.ID] = check
+		result.Checked++
+		if check.Status == LinkStatusError {
+			result.Errors++
+		}
+		c.logger.DebugContext(ctx, "checked link",
+			notionKeyPageID, reg.ID,
+			"url", link,
+			"status", check.Status,
+			"http_status", check.HTTPStatus)
+	}
+
+	result.Dead = report.linksWithStatus(LinkStatusDead)
+	result.Redirected = report.linksWithStatus(LinkStatusRedirected)
+	c.logger.InfoContext(ctx, "checked links",
+		"checked", result.Checked,
+		"skipped", result.Skipped,
+		"errors", result.Errors,
+		"dead", len(result.Dead),
+		"redirected", len(result.Redirected),
+		"dry_run", opts.DryRun)
+
+	if opts.DryRun || result.Checked == 0 {
+		return result, nil
+	}
+	if err := c.saveLinkReport(ctx, report); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkLink sends a HEAD request to a link, following redirects, and classifies the response.
+func checkLink(ctx context.Context, client *http.Client, link string) *LinkCheck {
+	check := &LinkCheck{URL: link, CheckedAt: time.Now().UTC()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, link, nil)
+	if err != nil {
+		check.Status, check.Error = LinkStatusError, err.Error()
+		return check
+	}
+	req.Header.Set("User-Agent", "ntnsync/"+version.Version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status, check.Error = LinkStatusError, err.Error()
+		return check
+	}
+	_ = resp.Body.Close()
+
+	check.HTTPStatus = resp.StatusCode
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		check.Status = LinkStatusDead
+	case resp.StatusCode >= http.StatusBadRequest:
+		check.Status, check.Error = LinkStatusError, resp.Status
+	case resp.Request.URL.String() != link:
+		check.Status, check.FinalURL = LinkStatusRedirected, resp.Request.URL.String()
+	default:
+		check.Status = LinkStatusOK
+	}
+	return check
+}
+
+// sleepContext waits for the given duration. Returns false if the context was canceled first.
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// readNotionURL returns the notion_url of a markdown file's frontmatter, or an empty string.
+func (c *Crawler) readNotionURL(ctx context.Context, filePath string) string {
+	if filePath == "" {
+		return ""
+	}
+	content, err := c.store.Read(ctx, filePath)
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(string(content), "\n")
+	endIdx, err := c.findFrontmatterEnd(lines)
+	if err != nil {
+		return ""
+	}
+	for _, line := range lines[1:endIdx] {
+		if value, found := strings.CutPrefix(line, "notion_url:"); found {
+			return unquoteFrontmatterValue(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// linksWithStatus returns the links of the report with the given status, sorted by file path.
+func (r *LinkReport) linksWithStatus(status string) []*LinkCheck {
+	var links []*LinkCheck
+	for _, check := range r.Links {
+		if check.Status == status {
+			links = append(links, check)
+		}
+	}
+	slices.SortFunc(links, func(a, b *LinkCheck) int {
+		return cmp.Or(strings.Compare(a.FilePath, b.FilePath), strings.Compare(a.PageID, b.PageID))
+	})
+	return links
+}
+
+// loadLinkReport loads the link report, or returns an empty one.
+func (c *Crawler) loadLinkReport(ctx context.Context) *LinkReport {
+	report := &LinkReport{}
+	if data, err := c.store.Read(ctx, filepath.Join(stateDir, linkReportFile)); err == nil {
+		if err := json.Unmarshal(data, report); err != nil {
+			c.logger.WarnContext(ctx, "ignoring invalid link report", "error", err)
+			report = &LinkReport{}
+		}
+	}
+	if report.Links == nil {
+		report.Links = make(map[string]*LinkCheck)
+	}
+	return report
+}
+
+// saveLinkReport saves the link report.
+func (c *Crawler) saveLinkReport(ctx context.Context, report *LinkReport) error {
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return fmt.Errorf("ensure transaction: %w", err)
+	}
+
+	report.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal link report: %w", err)
+	}
+	if err := c.tx.Write(ctx, filepath.Join(stateDir, linkReportFile), data); err != nil {
+		return fmt.Errorf("write link report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckLinks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/live", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/throttled", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	crawler, _ := newTestCrawler(t)
+	pages := map[string]string{
+		"live":      server.URL + "/live",
+		"moved":     server.URL + "/moved",
+		"dead":      server.URL + "/dead",
+		"throttled": server.URL + "/throttled",
+		"nourl":     "",
+	}
+	for id, link := range pages {
+		filePath := "test/" + id + ".md"
+		content := "---\nnotion_id: " + id + "\n"
+		if link != "" {
+			content += "notion_url: " + link + "\n"
+		}
+		content += "---\n# " + id + "\n"
+		if err := crawler.tx.Write(ctx, filePath, []byte(content)); err != nil {
+			t.Fatalf("write %s: %v", filePath, err)
+		}
+		if err := crawler.savePageRegistry(ctx, &PageRegistry{ID: id, Folder: "test", FilePath: filePath}); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	opts := LinkCheckOptions{Interval: time.Millisecond, RecheckAfter: time.Hour}
+	result, err := crawler.CheckLinks(ctx, opts)
+	if err != nil {
+		t.Fatalf("CheckLinks() error = %v", err)
+	}
+	if result.Checked != 4 || result.Errors != 1 || result.Skipped != 0 {
+		t.Errorf("checked/errors/skipped = %d/%d/%d, want 4/1/0", result.Checked, result.Errors, result.Skipped)
+	}
+	if len(result.Dead) != 1 || result.Dead[0].PageID != "dead" || result.Dead[0].HTTPStatus != http.StatusNotFound {
+		t.Errorf("dead links = %+v, want the dead page", result.Dead)
+	}
+	if len(result.Redirected) != 1 || result.Redirected[0].FinalURL != server.URL+"/live" {
+		t.Errorf("redirected links = %+v, want the moved page redirected to /live", result.Redirected)
+	}
+
+	report := crawler.loadLinkReport(ctx)
+	if len(report.Links) != 4 || report.Links["live"].Status != LinkStatusOK {
+		t.Errorf("report links = %+v, want 4 links with live ok", report.Links)
+	}
+
+	// Links checked recently are skipped, and stay in the report
+	result, err = crawler.CheckLinks(ctx, opts)
+	if err != nil {
+		t.Fatalf("CheckLinks() error = %v", err)
+	}
+	if result.Checked != 0 || result.Skipped != 4 || len(result.Dead) != 1 {
+		t.Errorf("second run checked/skipped/dead = %d/%d/%d, want 0/4/1",
+			result.Checked, result.Skipped, len(result.Dead))
+	}
+}
+
+func TestCheckLinks_MaxLinks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	crawler, _ := newTestCrawler(t)
+	for _, id := range []string{"a", "b", "c"} {
+		filePath := "test/" + id + ".md"
+		content := "---\nnotion_id: " + id + "\nnotion_url: " + server.URL + "/" + id + "\n---\n"
+		if err := crawler.tx.Write(ctx, filePath, []byte(content)); err != nil {
+			t.Fatalf("write %s: %v", filePath, err)
+		}
+		if err := crawler.savePageRegistry(ctx, &PageRegistry{ID: id, FilePath: filePath}); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	result, err := crawler.CheckLinks(ctx, LinkCheckOptions{Interval: time.Millisecond, MaxLinks: 2, DryRun: true})
+	if err != nil {
+		t.Fatalf("CheckLinks() error = %v", err)
+	}
+	if result.Checked != 2 || result.Skipped != 1 || requests.Load() != 2 {
+		t.Errorf("checked/skipped/requests = %d/%d/%d, want 2/1/2", result.Checked, result.Skipped, requests.Load())
+	}
+	if report := crawler.loadLinkReport(ctx); len(report.Links) != 0 {
+		t.Errorf("dry run saved %d links, want none", len(report.Links))
+	}
+}
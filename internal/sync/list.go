@@ -1,58 +1,83 @@
 package sync
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
 )
 
 // PageInfo contains displayable information about a page.
 type PageInfo struct {
-	ID         string
-	Title      string
-	Path       string
-	LastSynced time.Time
-	IsRoot     bool
-	IsOrphaned bool
-	ParentID   string
-	Children   []*PageInfo
+	ID           string      `json:"id"`
+	Title        string      `json:"title"`
+	Path         string      `json:"path"`
+	LastSynced   time.Time   `json:"last_synced"`
+	IsRoot       bool        `json:"is_root"`
+	IsOrphaned   bool        `json:"is_orphaned"`
+	ParentID     string      `json:"parent_id,omitempty"`
+	OpenComments int         `json:"open_comments,omitempty"` // Unresolved comments (with NTN_COMMENT_COUNTS)
+	Children     []*PageInfo `json:"children,omitempty"`
 }
 
 // FolderInfo contains information about a folder.
 type FolderInfo struct {
-	Name          string
-	RootPages     int
-	TotalPages    int
-	OrphanedPages int
-	Pages         []*PageInfo
+	Name          string      `json:"name"`
+	RootPages     int         `json:"root_pages"`
+	TotalPages    int         `json:"total_pages"`
+	OrphanedPages int         `json:"orphaned_pages"`
+	Pages         []*PageInfo `json:"pages"`
 }
 
 // QueueInfo contains information about queue entries.
 type QueueInfo struct {
-	Folder    string
-	Type      string
-	PageCount int
-	QueueFile string
+	Folder    string `json:"folder"`
+	Type      string `json:"type"`
+	PageCount int    `json:"page_count"`
+	QueueFile string `json:"queue_file"`
 }
 
 // StatusInfo contains sync status information.
 type StatusInfo struct {
-	FolderCount    int
-	TotalPages     int
-	TotalRootPages int
-	QueueEntries   []*QueueInfo
-	Folders        map[string]*FolderStatus
+	FolderCount    int                      `json:"folder_count"`
+	TotalPages     int                      `json:"total_pages"`
+	TotalRootPages int                      `json:"total_root_pages"`
+	QueueEntries   []*QueueInfo             `json:"queue_entries"`
+	Folders        map[string]*FolderStatus `json:"folders"`
+	BlockedPages   []*BlockedInfo           `json:"blocked_pages,omitempty"`
+	// PendingReviews are the pages held back by the content loss guard
+	PendingReviews []string `json:"pending_reviews,omitempty"`
+	MirrorSize     int64    `json:"mirror_size"`               // Size of the markdown files of all folders
+	MaxMirrorSize  int64    `json:"max_mirror_size,omitempty"` // Configured mirror size cap (zero = unlimited)
+	// SuggestedExclusions are the largest folders, suggested for exclusion when the mirror reached its cap
+	SuggestedExclusions []string     `json:"suggested_exclusions,omitempty"`
+	QueueLimits         queue.Limits `json:"queue_limits"`             // Effective queue limits
+	QueueWarnings       []string     `json:"queue_warnings,omitempty"` // Queue files created with other limits
+	// Perf holds the pipeline metrics of the last sync runs, oldest first
+	Perf []RunPerf `json:"perf,omitempty"`
+	// Crawl is the progress of the incremental deep crawl (sync --crawl-budget), if one was run
+	Crawl *CrawlProgress `json:"crawl,omitempty"`
 }
 
 // FolderStatus contains status for a specific folder.
 type FolderStatus struct {
-	Name        string
-	PageCount   int
-	RootPages   int
-	LastSynced  *time.Time
-	QueuedPages int
+	Name        string     `json:"name"`
+	PageCount   int        `json:"page_count"`
+	RootPages   int        `json:"root_pages"`
+	LastSynced  *time.Time `json:"last_synced,omitempty"`
+	QueuedPages int        `json:"queued_pages"`
+	// TotalBytes is the size of the folder's markdown files (as recorded in registries)
+	TotalBytes int64       `json:"total_bytes"`
+	Quota      FolderQuota `json:"quota"`                // Configured quota (zero = unlimited)
+	OverQuota  bool        `json:"over_quota,omitempty"` // Whether the folder reached its quota
 }
 
 // ListPages returns page information for display.
@@ -77,6 +102,14 @@ func (c *Crawler) ListPages(ctx context.Context, folderFilter string, asTree boo
 		folderPages[reg.Folder] = append(folderPages[reg.Folder], reg)
 	}
 
+	// Root pages are listed in root.md order
+	rootOrder := make(map[string]int)
+	if manifest, err := c.ParseRootMd(ctx); err == nil && manifest != nil {
+		for i := range manifest.Entries {
+			rootOrder[manifest.Entries[i].PageID] = i
+		}
+	}
+
 	var folders []*FolderInfo
 
 	for _, folderName := range c.state.Folders {
@@ -112,42 +145,20 @@ func (c *Crawler) ListPages(ctx context.Context, folderFilter string, asTree boo
 			}
 
 			pageInfoMap[reg.ID] = &PageInfo{
-				ID:         reg.ID,
-				Title:      reg.Title,
-				Path:       reg.FilePath,
-				LastSynced: reg.LastSynced,
-				IsRoot:     reg.IsRoot,
-				IsOrphaned: isOrphaned,
-				ParentID:   reg.ParentID,
-				Children:   []*PageInfo{},
+				ID:           reg.ID,
+				Title:        reg.Title,
+				Path:         reg.FilePath,
+				LastSynced:   reg.LastSynced,
+				IsRoot:       reg.IsRoot,
+				IsOrphaned:   isOrphaned,
+				ParentID:     reg.ParentID,
+				OpenComments: reg.OpenComments,
+				Children:     []*PageInfo{},
 			}
 		}
 
-		// Build tree structure if requested
-		var rootPages []*PageInfo
-		if asTree {
-			// Link children to parents
-			for _, reg := range regs {
-				info := pageInfoMap[reg.ID]
-				for _, childID := range reg.Children {
-					if childInfo, exists := pageInfoMap[childID]; exists {
-						info.Children = append(info.Children, childInfo)
-					}
-				}
-			}
-
-			// Collect root pages
-			for _, info := range pageInfoMap {
-				if info.IsRoot {
-					rootPages = append(rootPages, info)
-				}
-			}
-		} else {
-			// Flat list - just collect all pages
-			for _, info := range pageInfoMap {
-				rootPages = append(rootPages, info)
-			}
-		}
+		// Order pages like the Notion sidebar, as a tree or as a flat list
+		rootPages := orderPages(regs, pageInfoMap, rootOrder, asTree)
 
 		folders = append(folders, &FolderInfo{
 			Name:          folderName,
@@ -161,86 +172,163 @@ func (c *Crawler) ListPages(ctx context.Context, folderFilter string, asTree boo
 	return folders, nil
 }
 
-// ScanPage re-scans a page to discover all child pages and queues them.
-// This is useful for discovering new child pages under an existing root page.
-func (c *Crawler) ScanPage(ctx context.Context, pageID string) error {
-	c.logger.InfoContext(ctx, "scanning page for children", "page_id", pageID)
-
-	// Load state
-	if err := c.loadState(ctx); err != nil {
-		c.logger.WarnContext(ctx, "could not load state, starting fresh", "error", err)
+// orderPages orders the pages of a folder like the Notion sidebar: root pages in root.md order, each followed by
+// its children in Notion order (block order for pages, query order for databases), as recorded in the registries.
+// Pages missing from their parent's children, and top-level pages that are not roots, come last by title.
+// As a tree, it links children to their parents and returns the root pages. Otherwise, it returns all pages.
+func orderPages(
+	regs []*PageRegistry, infos map[string]*PageInfo, rootOrder map[string]int, asTree bool,
+) []*PageInfo {
+	byTitle := func(a, b *PageRegistry) int {
+		return cmp.Or(strings.Compare(a.Title, b.Title), strings.Compare(a.FilePath, b.FilePath))
 	}
-
-	// Load page registry to get folder
-	reg, err := c.loadPageRegistry(ctx, pageID)
-	if err != nil {
-		return fmt.Errorf("page not found in registry (use 'add' to add a new root page): %w", err)
+	sorted := slices.SortedFunc(slices.Values(regs), byTitle)
+
+	// Children in registry order, followed by the other pages pointing to the parent
+	children := make(map[string][]string)
+	listed := make(map[string]bool)
+	for _, reg := range sorted {
+		for _, childID := range reg.Children {
+			if _, exists := infos[childID]; exists && !listed[childID] {
+				listed[childID] = true
+				children[reg.ID] = append(children[reg.ID], childID)
+			}
+		}
 	}
-
-	folder := reg.Folder
-
-	// Fetch blocks to discover children
-	blocks, err := c.client.GetAllBlockChildren(ctx, pageID, 0)
-	if err != nil {
-		return fmt.Errorf("fetch blocks: %w", err)
+	var topLevel []*PageRegistry
+	for _, reg := range sorted {
+		_, parentExists := infos[reg.ParentID]
+		switch {
+		case reg.IsRoot:
+			topLevel = append(topLevel, reg)
+		case listed[reg.ID]:
+		case parentExists:
+			children[reg.ParentID] = append(children[reg.ParentID], reg.ID)
+		case !asTree:
+			topLevel = append(topLevel, reg)
+		}
 	}
-
-	// Discover child pages
-	children := c.findChildPages(blocks)
-
-	if len(children) == 0 {
-		c.logger.InfoContext(ctx, "no child pages found", "page_id", pageID)
-		return nil
+	slices.SortStableFunc(topLevel, func(a, b *PageRegistry) int {
+		orderA, isRootA := rootOrder[a.ID]
+		orderB, isRootB := rootOrder[b.ID]
+		if !isRootA || !a.IsRoot {
+			orderA = len(rootOrder)
+		}
+		if !isRootB || !b.IsRoot {
+			orderB = len(rootOrder)
+		}
+		return cmp.Compare(orderA, orderB)
+	})
+
+	var pages []*PageInfo
+	visited := make(map[string]bool)
+	var visit func(pageID string) *PageInfo
+	visit = func(pageID string) *PageInfo {
+		visited[pageID] = true
+		info := infos[pageID]
+		if !asTree {
+			pages = append(pages, info)
+		}
+		for _, childID := range children[pageID] {
+			if !visited[childID] {
+				if child := visit(childID); asTree {
+					info.Children = append(info.Children, child)
+				}
+			}
+		}
+		return info
 	}
-
-	c.logger.InfoContext(ctx, "discovered child pages",
-		"page_id", pageID,
-		"children_count", len(children))
-
-	// Filter out children that are already tracked
-	var newChildren []string
-	for _, childID := range children {
-		if _, err := c.loadPageRegistry(ctx, childID); err != nil {
-			// Child doesn't exist yet
-			newChildren = append(newChildren, childID)
+	for _, reg := range topLevel {
+		if info := visit(reg.ID); asTree {
+			pages = append(pages, info)
 		}
 	}
 
-	if len(newChildren) == 0 {
-		c.logger.InfoContext(ctx, "all child pages are already tracked", "page_id", pageID)
-		return nil
+	// Pages only reachable through a cycle
+	if !asTree {
+		for _, reg := range sorted {
+			if !visited[reg.ID] {
+				visit(reg.ID)
+			}
+		}
 	}
 
-	c.logger.InfoContext(ctx, "queueing new child pages",
-		"page_id", pageID,
-		"new_children_count", len(newChildren))
+	return pages
+}
+
+// QueuePageResync queues a tracked page for a forced re-sync on the next sync run.
+func (c *Crawler) QueuePageResync(ctx context.Context, pageID string) error {
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		return fmt.Errorf("page not found in registry: %w", err)
+	}
 
-	// Ensure transaction is available
 	if err := c.EnsureTransaction(ctx); err != nil {
 		return fmt.Errorf("ensure transaction: %w", err)
 	}
 
-	// Queue new children
+	// A last_edited of now makes the page newer than its registry, so it is never skipped
 	entry := queue.Entry{
-		Type:     queueTypeInit,
-		Folder:   folder,
-		PageIDs:  newChildren,
-		ParentID: pageID,
+		Type:   "update",
+		Folder: reg.Folder,
+		Pages:  []queue.Page{{ID: reg.ID, LastEdited: time.Now()}},
 	}
 
 	if _, err := c.queueManager.CreateEntry(ctx, entry); err != nil {
 		return fmt.Errorf("create queue entry: %w", err)
 	}
 
-	c.logger.InfoContext(ctx, "scan complete",
-		"page_id", pageID,
-		"total_children", len(children),
-		"new_children", len(newChildren),
-		"already_tracked", len(children)-len(newChildren))
+	c.logger.InfoContext(ctx, "queued page for re-sync",
+		notionKeyPageID, reg.ID,
+		"folder", reg.Folder)
 
 	return nil
 }
 
+// FindPage returns the synced page designated by a page ID, a Notion URL, or the path of its file in the mirror.
+// Former file paths of the page (see PageRegistry.Aliases) are found too, so that old links still resolve.
+func (c *Crawler) FindPage(ctx context.Context, ref string) (*PageInfo, error) {
+	if pageID, err := notion.ParsePageIDOrURL(ref); err == nil {
+		reg, err := c.loadPageRegistry(ctx, pageID)
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", pageID, apperrors.ErrPageNotSynced)
+		}
+		return pageInfoFromRegistry(reg), nil
+	}
+
+	filePath := path.Clean(strings.TrimPrefix(filepath.ToSlash(ref), "./"))
+	regs, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list registries: %w", err)
+	}
+	var formerPath *PageRegistry
+	for _, reg := range regs {
+		if reg.FilePath == filePath {
+			return pageInfoFromRegistry(reg), nil
+		}
+		if formerPath == nil && slices.Contains(reg.Aliases, filePath) {
+			formerPath = reg
+		}
+	}
+	if formerPath != nil {
+		return pageInfoFromRegistry(formerPath), nil
+	}
+	return nil, fmt.Errorf("file %s: %w", filePath, apperrors.ErrPageNotSynced)
+}
+
+// pageInfoFromRegistry returns the displayable information of a page, without its children.
+func pageInfoFromRegistry(reg *PageRegistry) *PageInfo {
+	return &PageInfo{
+		ID:           reg.ID,
+		Title:        reg.Title,
+		Path:         reg.FilePath,
+		LastSynced:   reg.LastSynced,
+		IsRoot:       reg.IsRoot,
+		ParentID:     reg.ParentID,
+		OpenComments: reg.OpenComments,
+	}
+}
+
 // GetStatus returns status information.
 func (c *Crawler) GetStatus(ctx context.Context, folderFilter string) (*StatusInfo, error) {
 	// Load state
@@ -257,12 +345,21 @@ func (c *Crawler) GetStatus(ctx context.Context, folderFilter string) (*StatusIn
 
 	status := &StatusInfo{
 		Folders: make(map[string]*FolderStatus),
+		Perf:    c.state.Perf,
+		Crawl:   c.state.Crawl,
 	}
 
 	// Group registries by folder
 	folderPages := make(map[string][]*PageRegistry)
+	sizes := make(map[string]int64)
 	for _, reg := range registries {
 		folderPages[reg.Folder] = append(folderPages[reg.Folder], reg)
+		sizes[reg.Folder] += reg.Size
+		status.MirrorSize += reg.Size
+	}
+	status.MaxMirrorSize = GetConfig().MaxMirrorSize
+	if status.MaxMirrorSize > 0 && status.MirrorSize >= status.MaxMirrorSize {
+		status.SuggestedExclusions = largestFolders(sizes, maxSuggestedExclusions)
 	}
 
 	// Gather folder statistics
@@ -276,7 +373,9 @@ func (c *Crawler) GetStatus(ctx context.Context, folderFilter string) (*StatusIn
 		// Find most recent sync time and count roots
 		var lastSynced *time.Time
 		rootCount := 0
+		var totalBytes int64
 		for _, reg := range regs {
+			totalBytes += reg.Size
 			if lastSynced == nil || reg.LastSynced.After(*lastSynced) {
 				t := reg.LastSynced
 				lastSynced = &t
@@ -286,11 +385,15 @@ func (c *Crawler) GetStatus(ctx context.Context, folderFilter string) (*StatusIn
 			}
 		}
 
+		quota := GetConfig().quotaFor(folderName)
 		status.Folders[folderName] = &FolderStatus{
 			Name:       folderName,
 			PageCount:  len(regs),
 			RootPages:  rootCount,
 			LastSynced: lastSynced,
+			TotalBytes: totalBytes,
+			Quota:      quota,
+			OverQuota:  quota.exceededBy(len(regs), totalBytes),
 		}
 
 		status.FolderCount++
@@ -298,36 +401,71 @@ func (c *Crawler) GetStatus(ctx context.Context, folderFilter string) (*StatusIn
 		status.TotalRootPages += rootCount
 	}
 
-	// Get queue information
+	c.addQueueStatus(ctx, status, folderFilter)
+
+	// Get blocked pages
+	blocked, err := c.listBlockedRegistries(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to list blocked pages", "error", err)
+	}
+	for _, reg := range blocked {
+		if folderFilter != "" && reg.Folder != folderFilter {
+			continue
+		}
+		status.BlockedPages = append(status.BlockedPages, &BlockedInfo{
+			ID:        reg.ID,
+			Folder:    reg.Folder,
+			Reason:    reg.Reason,
+			Error:     reg.Error,
+			BlockedBy: reg.BlockedBy,
+			BlockedAt: reg.BlockedAt,
+		})
+	}
+
+	// Get pages held for review (no review directory means none)
+	if reviews, err := c.listPendingReviews(ctx); err == nil {
+		status.PendingReviews = reviews
+	}
+
+	return status, nil
+}
+
+// addQueueStatus adds the queue entries, limits and limit warnings to the status.
+func (c *Crawler) addQueueStatus(ctx context.Context, status *StatusInfo, folderFilter string) {
+	status.QueueLimits = c.queueManager.Limits()
+	warnings, err := c.queueManager.CheckLimits(ctx)
+	if err != nil {
+		c.logger.DebugContext(ctx, "could not check queue limits", "error", err)
+	}
+	status.QueueWarnings = warnings
+
 	queueFiles, err := c.queueManager.ListEntries(ctx)
 	if err != nil {
 		c.logger.WarnContext(ctx, "failed to list queue entries", "error", err)
-	} else {
-		for _, queueFile := range queueFiles {
-			entry, err := c.queueManager.ReadEntry(ctx, queueFile)
-			if err != nil {
-				c.logger.WarnContext(ctx, "failed to read queue entry", "file", queueFile, "error", err)
-				continue
-			}
+		return
+	}
+	for _, queueFile := range queueFiles {
+		entry, err := c.queueManager.ReadEntry(ctx, queueFile)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to read queue entry", "file", queueFile, "error", err)
+			continue
+		}
 
-			// Filter by folder if specified
-			if folderFilter != "" && entry.Folder != folderFilter {
-				continue
-			}
+		// Filter by folder if specified
+		if folderFilter != "" && entry.Folder != folderFilter {
+			continue
+		}
 
-			status.QueueEntries = append(status.QueueEntries, &QueueInfo{
-				Folder:    entry.Folder,
-				Type:      entry.Type,
-				PageCount: len(entry.PageIDs),
-				QueueFile: queueFile,
-			})
+		status.QueueEntries = append(status.QueueEntries, &QueueInfo{
+			Folder:    entry.Folder,
+			Type:      entry.Type,
+			PageCount: len(entry.Pages),
+			QueueFile: queueFile,
+		})
 
-			// Add to folder queued pages count
-			if folderStatus, exists := status.Folders[entry.Folder]; exists {
-				folderStatus.QueuedPages += len(entry.PageIDs)
-			}
+		// Add to folder queued pages count
+		if folderStatus, exists := status.Folders[entry.Folder]; exists {
+			folderStatus.QueuedPages += len(entry.Pages)
 		}
 	}
-
-	return status, nil
 }
@@ -0,0 +1,133 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestOrderPages(t *testing.T) {
+	t.Parallel()
+
+	regs := []*PageRegistry{
+		{ID: "root-b", Title: "A root listed second", IsRoot: true, Children: []string{"zeta", "alpha"}},
+		{ID: "root-a", Title: "B root listed first", IsRoot: true, Children: []string{"db"}},
+		{ID: "zeta", Title: "Zeta", ParentID: "root-b"},
+		{ID: "alpha", Title: "Alpha", ParentID: "root-b", Children: []string{"gone"}},
+		{ID: "late", Title: "Late child", ParentID: "root-b"},
+		{ID: "db", Title: "Database", ParentID: "root-a", Children: []string{"row2", "row1"}},
+		{ID: "row1", Title: "Row 1", ParentID: "db"},
+		{ID: "row2", Title: "Row 2", ParentID: "db"},
+		{ID: "orphan", Title: "Orphan", ParentID: "missing"},
+	}
+	rootOrder := map[string]int{"root-a": 0, "root-b": 1}
+
+	newInfos := func() map[string]*PageInfo {
+		infos := make(map[string]*PageInfo)
+		for _, reg := range regs {
+			infos[reg.ID] = &PageInfo{ID: reg.ID, Title: reg.Title}
+		}
+		return infos
+	}
+	ids := func(pages []*PageInfo) []string {
+		var result []string
+		for _, page := range pages {
+			result = append(result, page.ID)
+		}
+		return result
+	}
+
+	flat := orderPages(regs, newInfos(), rootOrder, false)
+	want := []string{"root-a", "db", "row2", "row1", "root-b", "zeta", "alpha", "late", "orphan"}
+	if got := ids(flat); !slices.Equal(got, want) {
+		t.Errorf("orderPages(flat) = %v, want %v", got, want)
+	}
+
+	tree := orderPages(regs, newInfos(), rootOrder, true)
+	if got := ids(tree); !slices.Equal(got, []string{"root-a", "root-b"}) {
+		t.Fatalf("orderPages(tree) roots = %v, want [root-a root-b]", got)
+	}
+	if got := ids(tree[1].Children); !slices.Equal(got, []string{"zeta", "alpha", "late"}) {
+		t.Errorf("children of root-b = %v, want block order followed by unlisted children", got)
+	}
+	if got := ids(tree[0].Children[0].Children); !slices.Equal(got, []string{"row2", "row1"}) {
+		t.Errorf("rows of the database = %v, want query order", got)
+	}
+}
+
+func TestFindPage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	reg := &PageRegistry{
+		ID: "2c536f5e48f44234ad8d73a1a148e95d", Type: notionTypePage, Folder: "tech", Title: "Wiki",
+		FilePath: "tech/wiki.md", Aliases: []string{"Old wiki", "tech/old-wiki.md"},
+	}
+	if err := crawler.savePageRegistry(ctx, reg); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	for _, ref := range []string{
+		"2c536f5e-48f4-4234-ad8d-73a1a148e95d",
+		"https://www.notion.so/acme/Wiki-2c536f5e48f44234ad8d73a1a148e95d",
+		"tech/wiki.md",
+		"./tech/wiki.md",
+		"tech/old-wiki.md",
+	} {
+		page, err := crawler.FindPage(ctx, ref)
+		if err != nil {
+			t.Errorf("FindPage(%q) error = %v", ref, err)
+			continue
+		}
+		if page.ID != reg.ID || page.Path != "tech/wiki.md" {
+			t.Errorf("FindPage(%q) = %+v, want the wiki page", ref, page)
+		}
+	}
+
+	for _, ref := range []string{"tech/unknown.md", "ffffffffffffffffffffffffffffffff"} {
+		if _, err := crawler.FindPage(ctx, ref); !errors.Is(err, apperrors.ErrPageNotSynced) {
+			t.Errorf("FindPage(%q) error = %v, want ErrPageNotSynced", ref, err)
+		}
+	}
+}
+
+func TestQueuePageResync(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{
+		ID: "page1", Type: notionTypePage, Folder: "tech", Title: "Wiki", FilePath: "tech/wiki.md",
+		LastEdited: time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	if err := crawler.QueuePageResync(ctx, "unknown"); err == nil {
+		t.Error("QueuePageResync() of a page without registry should fail")
+	}
+
+	before := time.Now()
+	if err := crawler.QueuePageResync(ctx, "page1"); err != nil {
+		t.Fatalf("QueuePageResync() error = %v", err)
+	}
+	files, err := qm.ListEntries(ctx)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListEntries() = %v, %v, want one entry", files, err)
+	}
+	entry, err := qm.ReadEntry(ctx, files[0])
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	// The page is newer than its registry, so that it is synced and not skipped
+	if entry.Type != queue.TypeUpdate || entry.Folder != "tech" || len(entry.Pages) != 1 ||
+		entry.Pages[0].ID != "page1" || entry.Pages[0].LastEdited.Before(before.Truncate(time.Second)) {
+		t.Errorf("queue entry = %+v, want an update of page1 edited now", entry)
+	}
+}
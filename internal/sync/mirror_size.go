@@ -0,0 +1,219 @@
+package sync
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Prune policies, applied when the mirror exceeds its size cap.
+const (
+	// PrunePolicyNone never deletes pages: new pages are only no longer queued.
+	PrunePolicyNone = "none"
+	// PrunePolicyOldestLeaves deletes the least recently edited leaf pages until the mirror fits its cap.
+	PrunePolicyOldestLeaves = "oldest-leaves"
+)
+
+const (
+	// pruneTargetPercent is the share of the size cap pruning aims for, leaving headroom for updates.
+	pruneTargetPercent = 90
+	// maxSuggestedExclusions is the number of folders suggested for exclusion when the mirror is too large.
+	maxSuggestedExclusions = 3
+	// blockedReasonPruned is recorded for pages deleted to keep the mirror under its size cap.
+	blockedReasonPruned = "pruned"
+)
+
+// PruneResult contains the result of pruning the mirror.
+type PruneResult struct {
+	SizeBefore  int64           `json:"size_before"`
+	SizeAfter   int64           `json:"size_after"`
+	PrunedPages []*PageRegistry `json:"pruned_pages"`
+}
+
+// parsePrunePolicyEnv parses a prune policy, returning PrunePolicyNone if it is unknown.
+func parsePrunePolicyEnv(val string) string {
+	if strings.ToLower(strings.TrimSpace(val)) == PrunePolicyOldestLeaves {
+		return PrunePolicyOldestLeaves
+	}
+	return PrunePolicyNone
+}
+
+// mirrorSize returns the total size of the markdown files of all folders.
+func mirrorSize(usage map[string]*folderUsage) int64 {
+	var total int64
+	for _, folder := range usage {
+		total += folder.bytes
+	}
+	return total
+}
+
+// largestFolders returns the names of the largest folders, largest first. They are suggested for exclusion
+// when the mirror exceeds its size cap.
+func largestFolders(sizes map[string]int64, count int) []string {
+	folders := slices.Collect(func(yield func(string) bool) {
+		for folder, size := range sizes {
+			if size > 0 && !yield(folder) {
+				return
+			}
+		}
+	})
+	slices.SortFunc(folders, func(a, b string) int {
+		return cmp.Or(cmp.Compare(sizes[b], sizes[a]), strings.Compare(a, b))
+	})
+	return folders[:min(count, len(folders))]
+}
+
+// folderSizes returns the size of each folder.
+func folderSizes(usage map[string]*folderUsage) map[string]int64 {
+	sizes := make(map[string]int64, len(usage))
+	for folder, folderUsage := range usage {
+		sizes[folder] = folderUsage.bytes
+	}
+	return sizes
+}
+
+// mirrorSizeExceeded returns true if the mirror reached its size cap, in which case no new pages
+// should be queued. The first time it happens during a run, a warning suggesting folders to exclude is logged.
+func (c *Crawler) mirrorSizeExceeded(ctx context.Context) bool {
+	maxSize := GetConfig().MaxMirrorSize
+	if maxSize <= 0 {
+		return false
+	}
+
+	c.quotaMu.Lock()
+	usage := c.loadFolderUsageLocked(ctx)
+	size := mirrorSize(usage)
+	first := size >= maxSize && !c.mirrorOverSize
+	var sizes map[string]int64
+	if first {
+		c.mirrorOverSize = true
+		sizes = folderSizes(usage)
+	}
+	c.quotaMu.Unlock()
+
+	if size < maxSize {
+		return false
+	}
+
+	if first {
+		c.logger.WarnContext(ctx, "mirror size cap reached, no longer queueing new pages",
+			"size", FormatBytes(size),
+			"max_size", FormatBytes(maxSize),
+			"consider_excluding", largestFolders(sizes, maxSuggestedExclusions))
+	}
+	return true
+}
+
+// MirrorSizeExceeded returns true if the mirror reached its size cap during this run.
+func (c *Crawler) MirrorSizeExceeded() bool {
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	return c.mirrorOverSize
+}
+
+// pruneLeaves returns the pages that can be pruned: pages without tracked children that are not roots,
+// least recently edited first.
+func pruneLeaves(registries []*PageRegistry) []*PageRegistry {
+	parents := make(map[string]bool)
+	for _, reg := range registries {
+		if reg.ParentID != "" {
+			parents[normalizePageID(reg.ParentID)] = true
+		}
+	}
+
+	var leaves []*PageRegistry
+	for _, reg := range registries {
+		if !reg.IsRoot && !parents[normalizePageID(reg.ID)] {
+			leaves = append(leaves, reg)
+		}
+	}
+	slices.SortFunc(leaves, func(a, b *PageRegistry) int {
+		return cmp.Or(a.LastEdited.Compare(b.LastEdited), strings.Compare(a.ID, b.ID))
+	})
+	return leaves
+}
+
+// PruneMirror deletes the least recently edited leaf pages until the mirror is back under its size cap,
+// if the mirror exceeds it and the given prune policy allows it. Pruned pages are recorded as blocked,
+// so that they are not synced again unless they are edited.
+func (c *Crawler) PruneMirror(ctx context.Context, policy string, dryRun bool) (*PruneResult, error) {
+	cfg := GetConfig()
+	result := &PruneResult{}
+	if cfg.MaxMirrorSize <= 0 || policy != PrunePolicyOldestLeaves {
+		return result, nil
+	}
+
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list registries: %w", err)
+	}
+	for _, reg := range registries {
+		result.SizeBefore += reg.Size
+	}
+	result.SizeAfter = result.SizeBefore
+	if result.SizeBefore < cfg.MaxMirrorSize {
+		return result, nil
+	}
+
+	if !dryRun {
+		if err := c.EnsureTransaction(ctx); err != nil {
+			return nil, fmt.Errorf("ensure transaction: %w", err)
+		}
+	}
+
+	target := cfg.MaxMirrorSize * pruneTargetPercent / 100
+	for _, reg := range pruneLeaves(registries) {
+		if result.SizeAfter <= target {
+			break
+		}
+		if !dryRun {
+			if err := c.prunePage(ctx, reg); err != nil {
+				return nil, err
+			}
+		}
+		result.SizeAfter -= reg.Size
+		result.PrunedPages = append(result.PrunedPages, reg)
+	}
+
+	c.logger.InfoContext(ctx, "pruned mirror",
+		"pages", len(result.PrunedPages),
+		"size_before", FormatBytes(result.SizeBefore),
+		"size_after", FormatBytes(result.SizeAfter),
+		"dry_run", dryRun)
+	return result, nil
+}
+
+// prunePage deletes a page from the mirror and its parent's children, and records it as blocked.
+func (c *Crawler) prunePage(ctx context.Context, reg *PageRegistry) error {
+	c.logger.InfoContext(ctx, "pruning page",
+		notionKeyPageID, reg.ID,
+		"title", reg.Title,
+		"file_path", reg.FilePath,
+		"last_edited", reg.LastEdited)
+
+	if reg.FilePath != "" {
+		if err := c.deleteFile(ctx, reg.FilePath); err != nil {
+			return err
+		}
+	}
+	if err := c.deletePageRegistry(ctx, reg.ID); err != nil {
+		return err
+	}
+
+	if reg.ParentID != "" {
+		if parent, err := c.loadPageRegistry(ctx, reg.ParentID); err == nil {
+			parent.Children = slices.DeleteFunc(parent.Children, func(id string) bool {
+				return normalizePageID(id) == normalizePageID(reg.ID)
+			})
+			if err := c.savePageRegistry(ctx, parent); err != nil {
+				return fmt.Errorf("update parent registry: %w", err)
+			}
+		}
+	}
+
+	c.markPageBlocked(ctx, reg.ID, reg.Folder, blockedReasonPruned, "", nil)
+	c.addFolderUsage(reg.Folder, -1, -reg.Size)
+	return nil
+}
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLargestFolders(t *testing.T) {
+	t.Parallel()
+
+	sizes := map[string]int64{"small": 10, "big": 300, "empty": 0, "medium": 100, "other": 100}
+	got := largestFolders(sizes, 3)
+	if want := []string{"big", "medium", "other"}; !slices.Equal(got, want) {
+		t.Errorf("largestFolders() = %v, want %v", got, want)
+	}
+}
+
+func TestPruneLeaves(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+	registries := []*PageRegistry{
+		{ID: "root", IsRoot: true},
+		{ID: "parent", ParentID: "root", LastEdited: time.Unix(0, 0)},
+		{ID: "recent", ParentID: "parent", LastEdited: time.Unix(0, 0).Add(2 * day)},
+		{ID: "old", ParentID: "parent", LastEdited: time.Unix(0, 0).Add(day)},
+	}
+
+	var ids []string
+	for _, reg := range pruneLeaves(registries) {
+		ids = append(ids, reg.ID)
+	}
+	if want := []string{"old", "recent"}; !slices.Equal(ids, want) {
+		t.Errorf("pruneLeaves() = %v, want %v (no roots nor parents, oldest first)", ids, want)
+	}
+}
+
+func TestPruneMirror(t *testing.T) {
+	// Cannot use t.Parallel() with t.Setenv
+	t.Setenv("NTN_MAX_MIRROR_SIZE", "1000")
+	t.Setenv("NTN_FOLDER_MAX_PAGES", "")
+	t.Setenv("NTN_FOLDER_QUOTAS", "")
+	ResetConfig()
+	defer ResetConfig()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	registries := []*PageRegistry{
+		{ID: "root", Folder: "test", IsRoot: true, Size: 400, Children: []string{"old", "mid", "new"}},
+		{ID: "old", Folder: "test", ParentID: "root", FilePath: "test/old.md", Size: 300, LastEdited: base},
+		{ID: "mid", Folder: "test", ParentID: "root", FilePath: "test/mid.md", Size: 300, LastEdited: base.Add(time.Hour)},
+		{ID: "new", Folder: "test", ParentID: "root", FilePath: "test/new.md", Size: 300, LastEdited: base.Add(2 * time.Hour)},
+	}
+	for _, reg := range registries {
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	if !crawler.folderQuotaExceeded(ctx, "test") || !crawler.MirrorSizeExceeded() {
+		t.Fatal("a mirror of 1300 bytes should exceed a cap of 1000 bytes")
+	}
+
+	// Without an explicit policy, nothing is deleted
+	result, err := crawler.PruneMirror(ctx, PrunePolicyNone, false)
+	if err != nil || len(result.PrunedPages) != 0 {
+		t.Fatalf("PruneMirror(none) = %+v, %v, want nothing pruned", result, err)
+	}
+
+	result, err = crawler.PruneMirror(ctx, PrunePolicyOldestLeaves, false)
+	if err != nil {
+		t.Fatalf("PruneMirror() error = %v", err)
+	}
+	// Pruning aims for 90% of the cap: 1300 -> 1000 -> 700
+	if len(result.PrunedPages) != 2 || result.SizeAfter != 700 {
+		t.Fatalf("PruneMirror() pruned %d pages down to %d bytes, want 2 pages and 700 bytes",
+			len(result.PrunedPages), result.SizeAfter)
+	}
+
+	if reg, _ := crawler.loadPageRegistry(ctx, "old"); reg != nil {
+		t.Error("pruned page registry should be deleted")
+	}
+	if blocked, err := crawler.loadBlockedRegistry(ctx, "mid"); err != nil || blocked.Reason != blockedReasonPruned {
+		t.Errorf("pruned page should be blocked as pruned, got %+v (%v)", blocked, err)
+	}
+	root, err := crawler.loadPageRegistry(ctx, "root")
+	if err != nil {
+		t.Fatalf("loadPageRegistry(root) error = %v", err)
+	}
+	if !slices.Equal(root.Children, []string{"new"}) {
+		t.Errorf("root children = %v, want [new]", root.Children)
+	}
+}
@@ -0,0 +1,72 @@
+package sync
+
+import "context"
+
+// queuedPageParents returns the parents of the pages of a queue entry that are themselves in the entry.
+// They are known from the registries (the recorded parent of synced pages, or else the recorded children of synced
+// pages) and from the parent of the entry, if it is one of its pages.
+func (c *Crawler) queuedPageParents(ctx context.Context, pageIDs []string, entryParentID string) map[string]string {
+	inEntry := make(map[string]bool, len(pageIDs))
+	for _, id := range pageIDs {
+		inEntry[normalizePageID(id)] = true
+	}
+
+	parents := make(map[string]string)
+	setParent := func(id, parentID string) {
+		if inEntry[parentID] && parentID != id {
+			parents[id] = parentID
+		}
+	}
+
+	entryParentID = normalizePageID(entryParentID)
+	registries := make(map[string]*PageRegistry, len(pageIDs))
+	for _, id := range pageIDs {
+		id = normalizePageID(id)
+		setParent(id, entryParentID)
+		if reg, err := c.loadPageRegistry(ctx, id); err == nil {
+			registries[id] = reg
+		}
+	}
+	for id, reg := range registries {
+		for _, childID := range reg.Children {
+			setParent(normalizePageID(childID), id)
+		}
+	}
+	// The recorded parent of a page wins over the children lists, which may be outdated
+	for id, reg := range registries {
+		if reg.ParentID != "" {
+			delete(parents, id)
+			setParent(id, normalizePageID(reg.ParentID))
+		}
+	}
+
+	return parents
+}
+
+// dependencyOrder returns the indexes of pageIDs ordered so that parents come before their children, keeping the
+// queue order otherwise. Syncing parents first spares children from fetching them while resolving their path.
+func dependencyOrder(pageIDs []string, parents map[string]string) []int {
+	index := make(map[string]int, len(pageIDs))
+	for i, id := range pageIDs {
+		index[normalizePageID(id)] = i
+	}
+
+	order := make([]int, 0, len(pageIDs))
+	visited := make([]bool, len(pageIDs))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return // Already ordered, or a cycle
+		}
+		visited[i] = true
+		if parentID, ok := parents[normalizePageID(pageIDs[i])]; ok {
+			visit(index[parentID])
+		}
+		order = append(order, i)
+	}
+	for i := range pageIDs {
+		visit(i)
+	}
+
+	return order
+}
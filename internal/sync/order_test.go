@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestDependencyOrder(t *testing.T) {
+	t.Parallel()
+
+	pageIDs := []string{"grandchild", "other", "child", "parent", "loop1", "loop2"}
+	parents := map[string]string{
+		"grandchild": "child",
+		"child":      "parent",
+		"loop1":      "loop2",
+		"loop2":      "loop1",
+	}
+
+	var got []string
+	for _, i := range dependencyOrder(pageIDs, parents) {
+		got = append(got, pageIDs[i])
+	}
+	want := []string{"parent", "child", "grandchild", "other", "loop2", "loop1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("dependencyOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestQueuedPageParents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	for _, reg := range []*PageRegistry{
+		{ID: "parent", Type: notionTypePage, Folder: "test", Children: []string{"new", "moved"}},
+		{ID: "moved", Type: notionTypePage, Folder: "test", ParentID: "elsewhere"},
+		{ID: "synced", Type: notionTypePage, Folder: "test", ParentID: "parent"},
+	} {
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	got := crawler.queuedPageParents(ctx, []string{"synced", "new", "moved", "parent", "unknown"}, "")
+	want := map[string]string{"new": "parent", "synced": "parent"}
+	if !maps.Equal(got, want) {
+		t.Errorf("queuedPageParents() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package sync
+
+import (
+	"context"
+	stdsync "sync"
+)
+
+// parentCache memoizes the parent resolutions of a run, so that siblings sharing a parent chain resolve it once:
+// the page or database containing a block, and the pages known to have a registry. Only successful lookups are
+// cached, as a missing registry may be written later in the run.
+type parentCache struct {
+	mu         stdsync.Mutex
+	blocks     map[string]blockParent // Block ID -> page or database containing it
+	registries map[string]bool        // Page ID -> has a registry
+	hits       int                    // Lookups answered from the cache
+	avoided    int                    // Notion API calls avoided by the hits
+}
+
+// blockParent is the page or database containing a block.
+type blockParent struct {
+	id         string // Page or database ID (empty for the workspace)
+	parentType string // notionKeyPageID, "database_id" or parentTypeWorkspace
+	calls      int    // Notion API calls it took to resolve it from the block
+}
+
+// reset empties the cache and its stats, at the start of a run.
+func (pc *parentCache) reset() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.blocks = nil
+	pc.registries = nil
+	pc.hits = 0
+	pc.avoided = 0
+}
+
+// stats returns the number of lookups answered from the cache and of Notion API calls they avoided.
+func (pc *parentCache) stats() (int, int) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.hits, pc.avoided
+}
+
+// block returns the cached page or database containing a block.
+func (pc *parentCache) block(blockID string) (blockParent, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	parent, ok := pc.blocks[blockID]
+	if ok {
+		pc.hits++
+		pc.avoided += parent.calls
+	}
+	return parent, ok
+}
+
+// storeChain caches the page or database containing the blocks of a resolved chain, ordered from the resolved
+// block up. parent is the one of the block after the chain: each block of the chain is one API call farther.
+func (pc *parentCache) storeChain(chain []string, parent blockParent) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.blocks == nil {
+		pc.blocks = make(map[string]blockParent)
+	}
+	for i, blockID := range chain {
+		pc.blocks[blockID] = blockParent{id: parent.id, parentType: parent.parentType, calls: parent.calls + len(chain) - i}
+	}
+}
+
+// hasRegistry returns true if a page is known to have a registry.
+func (pc *parentCache) hasRegistry(pageID string) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.registries[pageID] {
+		pc.hits++
+		return true
+	}
+	return false
+}
+
+// setRegistry records whether a page has a registry.
+func (pc *parentCache) setRegistry(pageID string, exists bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if !exists {
+		delete(pc.registries, pageID)
+		return
+	}
+	if pc.registries == nil {
+		pc.registries = make(map[string]bool)
+	}
+	pc.registries[pageID] = true
+}
+
+// hasPageRegistry returns true if a page has a registry, looking it up once per run.
+func (c *Crawler) hasPageRegistry(ctx context.Context, pageID string) bool {
+	pageID = normalizePageID(pageID)
+	if c.parents.hasRegistry(pageID) {
+		return true
+	}
+	if _, err := c.loadPageRegistry(ctx, pageID); err != nil {
+		return false
+	}
+	c.parents.setRegistry(pageID, true)
+	return true
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestResolveBlockToPage_Cache(t *testing.T) {
+	t.Parallel()
+
+	parents := map[string]string{
+		"column1": `{"type":"block_id","block_id":"columns"}`,
+		"column2": `{"type":"block_id","block_id":"columns"}`,
+		"columns": `{"type":"page_id","page_id":"page1"}`,
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		blockID := strings.TrimPrefix(r.URL.Path, "/blocks/")
+		fmt.Fprintf(w, `{"object":"block","id":%q,"type":"column","parent":%s}`, blockID, parents[blockID])
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	// The second column shares the parent chain of the first one: only its own block is fetched
+	for _, blockID := range []string{"column1", "column2", "column1", "columns"} {
+		pageID, parentType, err := crawler.resolveBlockToPage(ctx, blockID)
+		if err != nil || pageID != "page1" || parentType != notionKeyPageID {
+			t.Fatalf("resolveBlockToPage(%s) = %q, %q, %v; want page1", blockID, pageID, parentType, err)
+		}
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("API requests = %d, want 3", got)
+	}
+	if hits, avoided := crawler.parents.stats(); hits != 3 || avoided != 4 {
+		t.Errorf("stats() = %d hits, %d avoided; want 3, 4", hits, avoided)
+	}
+
+	crawler.parents.reset()
+	if _, _, err := crawler.resolveBlockToPage(ctx, "column1"); err != nil {
+		t.Fatalf("resolveBlockToPage() after reset error = %v", err)
+	}
+	if got := requests.Load(); got != 5 {
+		t.Errorf("API requests after reset = %d, want 5", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/fclairamb/ntnsync/internal/converter"
@@ -24,12 +25,13 @@ func (c *Crawler) computeParentDir(ctx context.Context, parentID, defaultFolder
 
 	// Place in parent's directory
 	parentDir := filepath.Dir(parentReg.FilePath)
-	parentBase := strings.TrimSuffix(filepath.Base(parentReg.FilePath), ".md")
+	parentBase := converter.TrimPageExt(filepath.Base(parentReg.FilePath))
 	return filepath.Join(parentDir, parentBase)
 }
 
 // computeFilePath determines the file path for a page or database.
-// CRITICAL: Enforces file path stability - existing paths are never changed.
+// CRITICAL: Enforces file path stability - existing paths are never changed. Only syncs with NTN_RENAME_FILES
+// move the files of renamed and moved pages afterwards (see renamePage).
 // resolvedParentID is the parent page/database ID (already resolved from blocks).
 func (c *Crawler) computeFilePath(
 	ctx context.Context, page *notion.Page, folder string, isRoot bool, resolvedParentID string,
@@ -44,8 +46,17 @@ func (c *Crawler) computeFilePath(
 		return reg.FilePath
 	}
 
-	// Compute new path for new page
-	title := converter.SanitizeFilename(page.Title())
+	ext := converter.FileExtension(GetConfig().profileFor(folder))
+	return c.newFilePath(ctx, page, folder, isRoot, resolvedParentID, ext)
+}
+
+// newFilePath computes the file path of a page or database from its title and parent, ignoring the path it may
+// already have. ext is the extension of the file.
+func (c *Crawler) newFilePath(
+	ctx context.Context, page *notion.Page, folder string, isRoot bool, resolvedParentID, ext string,
+) string {
+	pageID := normalizePageID(page.ID)
+	title := c.converter.FilenameRules.Sanitize(page.Title())
 	if title == "" {
 		title = defaultUntitledStr
 	}
@@ -53,7 +64,7 @@ func (c *Crawler) computeFilePath(
 	var dir string
 
 	if isRoot {
-		// Root page: $folder/$title.md
+		// Root page: $folder/$title.md (.mdx with the docusaurus profile)
 		dir = folder
 	} else {
 		// Child page: $folder/$parent-dir/$title.md
@@ -64,7 +75,25 @@ func (c *Crawler) computeFilePath(
 	// Check for conflicts and add short ID if needed
 	filename = c.resolveFilenameConflict(ctx, folder, dir, filename, pageID)
 
-	return filepath.Join(dir, filename+".md")
+	return filepath.Join(dir, filename+ext)
+}
+
+// pageAliases returns the aliases of a page: its previous titles and file paths, oldest first.
+// They are carried over from the registry, so that renamed pages keep all their former names.
+func pageAliases(existing *PageRegistry, title, filePath string) []string {
+	if existing == nil {
+		return nil
+	}
+
+	aliases := slices.Clone(existing.Aliases)
+	for _, previous := range []string{existing.Title, existing.FilePath} {
+		if previous != "" && !slices.Contains(aliases, previous) {
+			aliases = append(aliases, previous)
+		}
+	}
+	return slices.DeleteFunc(aliases, func(alias string) bool {
+		return alias == title || alias == filePath
+	})
 }
 
 // resolveFilenameConflict checks for filename conflicts and adds ID suffix if needed.
@@ -87,7 +116,7 @@ func (c *Crawler) resolveFilenameConflict(ctx context.Context, _, dir, baseFilen
 		}
 		regDir := filepath.Dir(reg.FilePath)
 		if regDir == dir {
-			name := strings.TrimSuffix(filepath.Base(reg.FilePath), ".md")
+			name := converter.TrimPageExt(filepath.Base(reg.FilePath))
 			usedNames[strings.ToLower(name)] = reg.ID
 		}
 	}
@@ -0,0 +1,161 @@
+package sync
+
+import (
+	"slices"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Pipeline phases measured for each synced page or database.
+const (
+	PerfPhaseFetch   = "fetch"   // Download of the page metadata and blocks (or database rows)
+	PerfPhaseConvert = "convert" // Conversion to markdown
+	PerfPhaseWrite   = "write"   // Write of the markdown file
+)
+
+// PerfPhases lists the pipeline phases, in pipeline order.
+var PerfPhases = []string{PerfPhaseFetch, PerfPhaseConvert, PerfPhaseWrite}
+
+const (
+	// maxPerfRuns is the number of runs whose pipeline metrics are kept in state.
+	maxPerfRuns = 30
+	// maxHeaviestPages is the number of pages making the most API calls kept in the metrics of a run.
+	maxHeaviestPages = 5
+)
+
+// PhaseStats summarizes the durations of a pipeline phase over a run.
+type PhaseStats struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P95   time.Duration `json:"p95"`
+	Max   time.Duration `json:"max"`
+}
+
+// PageAPICalls is the number of Notion API calls made to sync a page, by call type.
+type PageAPICalls struct {
+	PageID string         `json:"page_id"`
+	Total  int            `json:"total"`
+	Calls  map[string]int `json:"calls"`
+}
+
+// RunPerf holds the pipeline metrics of a sync run.
+type RunPerf struct {
+	StartedAt     time.Time             `json:"started_at"`
+	Duration      time.Duration         `json:"duration"`
+	Pages         int                   `json:"pages"`
+	Phases        map[string]PhaseStats `json:"phases"`
+	APICalls      map[string]int        `json:"api_calls,omitempty"`      // Notion API calls, by call type
+	HeaviestPages []PageAPICalls        `json:"heaviest_pages,omitempty"` // Pages making the most API calls
+	Throttled     time.Duration         `json:"throttled,omitempty"`      // Time API requests waited for the rate limit
+	RateLimited   int                   `json:"rate_limited,omitempty"`   // Rate limit (429) responses of the API
+	ParentHits    int                   `json:"parent_hits,omitempty"`    // Parent lookups answered from the cache
+	AvoidedCalls  int                   `json:"avoided_calls,omitempty"`  // API calls avoided by the parent cache
+}
+
+// recordPhase records the duration of a pipeline phase for the current run.
+func (c *Crawler) recordPhase(phase string, duration time.Duration) {
+	c.perfMu.Lock()
+	defer c.perfMu.Unlock()
+	if c.perfSamples == nil {
+		c.perfSamples = make(map[string][]time.Duration)
+	}
+	c.perfSamples[phase] = append(c.perfSamples[phase], duration)
+}
+
+// recordAPICalls records the Notion API calls made to sync a page for the current run.
+func (c *Crawler) recordAPICalls(pageID string, calls map[string]int) {
+	if len(calls) == 0 {
+		return
+	}
+	page := PageAPICalls{PageID: pageID, Calls: calls}
+	for _, count := range calls {
+		page.Total += count
+	}
+
+	c.perfMu.Lock()
+	defer c.perfMu.Unlock()
+	c.perfPageCalls = append(c.perfPageCalls, page)
+}
+
+// summarizeAPICalls returns the API calls of a run by call type, and its pages making the most calls.
+func summarizeAPICalls(pages []PageAPICalls) (map[string]int, []PageAPICalls) {
+	if len(pages) == 0 {
+		return nil, nil
+	}
+
+	totals := make(map[string]int)
+	for _, page := range pages {
+		for callType, count := range page.Calls {
+			totals[callType] += count
+		}
+	}
+
+	heaviest := slices.Clone(pages)
+	slices.SortStableFunc(heaviest, func(a, b PageAPICalls) int {
+		return b.Total - a.Total
+	})
+	return totals, heaviest[:min(len(heaviest), maxHeaviestPages)]
+}
+
+// clientThrottle returns how much the requests of the Notion client were throttled (none without a client).
+func (c *Crawler) clientThrottle() notion.ThrottleStats {
+	if c.client == nil {
+		return notion.ThrottleStats{}
+	}
+	return c.client.Throttle()
+}
+
+// recordRunPerf summarizes the phase durations recorded since the run started into the state, with the
+// throttling of the API requests during the run, keeping the last maxPerfRuns runs. Runs that synced nothing
+// are not recorded.
+func (c *Crawler) recordRunPerf(startedAt time.Time, throttle notion.ThrottleStats) {
+	c.perfMu.Lock()
+	samples := c.perfSamples
+	pageCalls := c.perfPageCalls
+	c.perfSamples = nil
+	c.perfPageCalls = nil
+	c.perfMu.Unlock()
+
+	if len(samples[PerfPhaseFetch]) == 0 {
+		return
+	}
+
+	run := RunPerf{
+		StartedAt:   startedAt,
+		Duration:    time.Since(startedAt),
+		Pages:       len(samples[PerfPhaseFetch]),
+		Phases:      make(map[string]PhaseStats, len(samples)),
+		Throttled:   throttle.Waited,
+		RateLimited: throttle.RateLimited,
+	}
+	for phase, durations := range samples {
+		run.Phases[phase] = summarizeDurations(durations)
+	}
+	run.APICalls, run.HeaviestPages = summarizeAPICalls(pageCalls)
+	run.ParentHits, run.AvoidedCalls = c.parents.stats()
+
+	c.state.Perf = append(c.state.Perf, run)
+	if len(c.state.Perf) > maxPerfRuns {
+		c.state.Perf = c.state.Perf[len(c.state.Perf)-maxPerfRuns:]
+	}
+}
+
+// summarizeDurations computes the percentiles of durations.
+func summarizeDurations(durations []time.Duration) PhaseStats {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	return PhaseStats{
+		Count: len(sorted),
+		P50:   percentile(sorted, 50), //nolint:mnd // Median
+		P95:   percentile(sorted, 95), //nolint:mnd // 95th percentile
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted, non-empty durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	const hundred = 100
+	rank := (p*len(sorted) + hundred - 1) / hundred
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,89 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestSummarizeDurations(t *testing.T) {
+	t.Parallel()
+
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := summarizeDurations(durations)
+	if stats.Count != 100 || stats.P50 != 50*time.Millisecond || stats.P95 != 95*time.Millisecond ||
+		stats.Max != 100*time.Millisecond {
+		t.Errorf("summarizeDurations() = %+v", stats)
+	}
+	if durations[0] != 100*time.Millisecond {
+		t.Error("summarizeDurations() sorted its input")
+	}
+
+	single := summarizeDurations([]time.Duration{time.Second})
+	if single.P50 != time.Second || single.P95 != time.Second {
+		t.Errorf("summarizeDurations(single) = %+v", single)
+	}
+}
+
+func TestRecordRunPerf(t *testing.T) {
+	t.Parallel()
+
+	crawler, _ := newTestCrawler(t)
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A run that synced nothing is not recorded
+	crawler.recordRunPerf(started, notion.ThrottleStats{})
+	if len(crawler.state.Perf) != 0 {
+		t.Fatalf("recorded %d runs, want none", len(crawler.state.Perf))
+	}
+
+	for range maxPerfRuns + 2 {
+		crawler.recordPhase(PerfPhaseFetch, 200*time.Millisecond)
+		crawler.recordPhase(PerfPhaseFetch, 400*time.Millisecond)
+		crawler.recordPhase(PerfPhaseConvert, time.Millisecond)
+		crawler.recordPhase(PerfPhaseWrite, 2*time.Millisecond)
+		crawler.recordRunPerf(started, notion.ThrottleStats{Waited: time.Second, RateLimited: 1})
+		started = started.Add(time.Hour)
+	}
+
+	if len(crawler.state.Perf) != maxPerfRuns {
+		t.Fatalf("kept %d runs, want %d", len(crawler.state.Perf), maxPerfRuns)
+	}
+	last := crawler.state.Perf[len(crawler.state.Perf)-1]
+	if !last.StartedAt.Equal(started.Add(-time.Hour)) || last.Pages != 2 || last.Throttled != time.Second ||
+		last.RateLimited != 1 {
+		t.Errorf("last run = %+v", last)
+	}
+	if fetch := last.Phases[PerfPhaseFetch]; fetch.P50 != 200*time.Millisecond || fetch.P95 != 400*time.Millisecond {
+		t.Errorf("fetch stats = %+v", fetch)
+	}
+	if first := crawler.state.Perf[0]; !first.StartedAt.Equal(time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("oldest run started at %v, want the third run", first.StartedAt)
+	}
+}
+
+func TestSummarizeAPICalls(t *testing.T) {
+	t.Parallel()
+
+	crawler, _ := newTestCrawler(t)
+	crawler.recordAPICalls("light", map[string]int{"page": 1, "block_children": 1})
+	crawler.recordAPICalls("skipped", nil)
+	crawler.recordAPICalls("heavy", map[string]int{"page": 1, "block_children": 40})
+
+	totals, heaviest := summarizeAPICalls(crawler.perfPageCalls)
+	if totals["page"] != 2 || totals["block_children"] != 41 {
+		t.Errorf("totals = %v", totals)
+	}
+	if len(heaviest) != 2 || heaviest[0].PageID != "heavy" || heaviest[0].Total != 41 {
+		t.Errorf("heaviest = %+v", heaviest)
+	}
+
+	if totals, heaviest := summarizeAPICalls(nil); totals != nil || heaviest != nil {
+		t.Errorf("summarizeAPICalls(nil) = %v, %v, want nothing", totals, heaviest)
+	}
+}
@@ -0,0 +1,267 @@
+package sync
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// Actions of the files of a sync plan.
+const (
+	PlanActionCreate = "create"
+	PlanActionUpdate = "update"
+	PlanActionDelete = "delete"
+)
+
+// Minimum number of API requests needed to sync an item. Pages with nested blocks, more than 100 blocks or
+// unsynced parents need more.
+const (
+	planPageCalls       = 2 // Page metadata and its first page of blocks
+	planDatabaseCalls   = 4 // Failed page fetch, database and data source metadata, and its first page of rows
+	planPropertiesCalls = 1 // Page metadata
+)
+
+// SyncPlanOptions limits the queue walked by a sync plan, like the limits of a sync.
+type SyncPlanOptions struct {
+	Folder        string // Only plan the queue entries of this folder
+	MaxPages      int    // Maximum number of queued pages to plan (0 = unlimited)
+	MaxQueueFiles int    // Maximum number of queue files to plan (0 = unlimited)
+}
+
+// PlannedFile is a file a sync would write or delete.
+type PlannedFile struct {
+	Action string `json:"action"` // create, update or delete
+	Path   string `json:"path"`
+	PageID string `json:"page_id"`
+	Title  string `json:"title,omitempty"`
+	Folder string `json:"folder"`
+	Reason string `json:"reason,omitempty"` // properties (frontmatter refresh) or pruned
+}
+
+// SyncPlan is the result of a sync dry run: what processing the queue would do.
+type SyncPlan struct {
+	QueueFiles        int            `json:"queue_files"`
+	Pages             int            `json:"pages"` // Queued pages considered
+	Files             []*PlannedFile `json:"files"`
+	Skipped           int            `json:"skipped"`             // Unchanged since their last sync, or blocked
+	Deferred          int            `json:"deferred"`            // Waiting for their retry backoff
+	Dropped           int            `json:"dropped"`             // Archived, deleted or inaccessible
+	Failed            int            `json:"failed"`              // Metadata couldn't be fetched
+	APICalls          int            `json:"api_calls"`           // Requests made by the dry run
+	EstimatedAPICalls int            `json:"estimated_api_calls"` // Minimum number of requests of the sync
+}
+
+// PlanQueue walks the queue like a sync would and reports the files it would create, update or delete, fetching
+// the metadata of the queued pages but not their blocks. Nothing is written to the store and the queue entries are
+// kept. Dropped pages are not deleted by a sync, they are only recorded as blocked.
+func (c *Crawler) PlanQueue(ctx context.Context, opts SyncPlanOptions) (*SyncPlan, error) {
+	c.logger.InfoContext(ctx, "planning queue",
+		"folder_filter", opts.Folder,
+		"max_pages", opts.MaxPages,
+		"max_queue_files", opts.MaxQueueFiles)
+
+	queueFiles, err := c.queueManager.ListEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list queue entries: %w", err)
+	}
+
+	plan := &SyncPlan{Files: []*PlannedFile{}}
+	throttleStart := c.clientThrottle()
+	now := time.Now()
+	limitReached := func() bool {
+		return opts.MaxPages > 0 && plan.Pages >= opts.MaxPages
+	}
+
+	for _, queueFile := range queueFiles {
+		if limitReached() || (opts.MaxQueueFiles > 0 && plan.QueueFiles >= opts.MaxQueueFiles) {
+			break
+		}
+		entry, err := c.queueManager.ReadEntry(ctx, queueFile)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to read queue entry", "file", queueFile, "error", err)
+			continue
+		}
+		if opts.Folder != "" && entry.Folder != opts.Folder {
+			continue
+		}
+		plan.QueueFiles++
+
+		start := len(plan.Files)
+		pageParents := make(map[string]string)
+		for i := range entry.Pages {
+			if limitReached() {
+				break
+			}
+			plan.Pages++
+			queuePage := &entry.Pages[i]
+			switch {
+			case queuePage.NotBefore.After(now):
+				plan.Deferred++
+			case c.planSkipsPage(ctx, entry, queuePage):
+				plan.Skipped++
+			default:
+				if err := c.planPage(ctx, plan, entry, queuePage.ID, pageParents); err != nil {
+					return nil, err
+				}
+			}
+		}
+		c.orderPlannedFiles(ctx, plan.Files[start:], entry.ParentID, pageParents)
+	}
+
+	// Registries are read-only in a dry run, the pages over the mirror size cap are only listed
+	prune, err := c.PruneMirror(ctx, GetConfig().PrunePolicy, true)
+	if err != nil {
+		return nil, fmt.Errorf("plan prune: %w", err)
+	}
+	for _, reg := range prune.PrunedPages {
+		plan.Files = append(plan.Files, &PlannedFile{
+			Action: PlanActionDelete,
+			Path:   reg.FilePath,
+			PageID: reg.ID,
+			Title:  reg.Title,
+			Folder: reg.Folder,
+			Reason: "pruned",
+		})
+	}
+
+	plan.APICalls = c.clientThrottle().Sub(throttleStart).Requests
+	c.logger.InfoContext(ctx, "queue planned",
+		"pages", plan.Pages,
+		"files", len(plan.Files),
+		"skipped", plan.Skipped,
+		"dropped", plan.Dropped,
+		"api_calls", plan.APICalls,
+		"estimated_api_calls", plan.EstimatedAPICalls)
+	return plan, nil
+}
+
+// planSkipsPage checks whether a sync would skip a queued page, like shouldSkipQueuedPage but without recording
+// the pages under a blocked parent as blocked.
+func (c *Crawler) planSkipsPage(ctx context.Context, entry *queue.Entry, queuePage *queue.Page) bool {
+	if entry.Type == queueTypeProperties {
+		if c.shouldSkipPropertyRefresh(ctx, queuePage.ID, queuePage.LastEdited) {
+			return true
+		}
+	} else {
+		blockDiff := GetConfig().BlockDiff && len(queuePage.UpdatedBlocks) > 0
+		if !blockDiff && c.shouldSkipUnchangedPage(ctx, queuePage.ID, queuePage.LastEdited, entry.Type == queueTypeInit) {
+			return true
+		}
+	}
+	if _, err := c.loadBlockedRegistry(ctx, queuePage.ID); err == nil {
+		return c.blockedSinceEdit(ctx, queuePage.ID, queuePage.LastEdited) != ""
+	}
+	return c.checkParentBlocked(ctx, entry.ParentID) != nil
+}
+
+// planPage fetches the metadata of a queued page or database and adds the file a sync would write to the plan.
+// The parent of the fetched page is recorded in pageParents.
+func (c *Crawler) planPage(
+	ctx context.Context,
+	plan *SyncPlan,
+	entry *queue.Entry,
+	pageID string,
+	pageParents map[string]string,
+) error {
+	calls := planPageCalls
+	if GetConfig().CommentCounts {
+		calls++
+	}
+
+	page, err := c.client.GetPage(ctx, pageID)
+	if err != nil && strings.Contains(err.Error(), "is a database, not a page") {
+		database, dbErr := c.client.GetDatabase(ctx, pageID)
+		if dbErr == nil {
+			page = &notion.Page{
+				ID:       database.ID,
+				Parent:   database.Parent,
+				Archived: database.Archived,
+				InTrash:  database.InTrash,
+				Properties: notion.Properties{
+					notionKeyTitle: {Type: notionKeyTitle, Title: database.Title},
+				},
+			}
+		}
+		err = dbErr
+		calls = planDatabaseCalls
+	}
+	switch {
+	case errors.Is(err, apperrors.ErrNotionUnauthorized):
+		return fmt.Errorf("fetch page %s: %w", pageID, err)
+	case err != nil:
+		if reason, _ := blockReason(err, pageID); reason != "" {
+			plan.Dropped++
+		} else {
+			c.logger.WarnContext(ctx, "failed to fetch page metadata", notionKeyPageID, pageID, "error", err)
+			plan.Failed++
+		}
+		return nil
+	case page.Archived || page.InTrash:
+		plan.Dropped++
+		return nil
+	}
+
+	file := &PlannedFile{
+		Action: PlanActionCreate,
+		PageID: normalizePageID(pageID),
+		Title:  page.Title(),
+		Folder: entry.Folder,
+	}
+	if reg, err := c.loadPageRegistry(ctx, pageID); err == nil {
+		file.Action = PlanActionUpdate
+		file.Folder = reg.Folder
+		if entry.Type == queueTypeProperties {
+			file.Reason = "properties"
+			calls = planPropertiesCalls
+		}
+	}
+
+	parentID := ""
+	if page.Parent.Type != parentTypeBlockID && page.Parent.Type != parentTypeWorkspace {
+		parentID = normalizePageID(page.Parent.ID())
+	}
+	parentID = cmp.Or(normalizePageID(entry.ParentID), parentID)
+	file.Path = c.computeFilePath(ctx, page, file.Folder, parentID == "", parentID)
+	if parentID != "" {
+		pageParents[file.PageID] = parentID
+	}
+
+	plan.Files = append(plan.Files, file)
+	plan.EstimatedAPICalls += calls
+	return nil
+}
+
+// orderPlannedFiles orders the planned files of a queue entry like processEntry syncs them, parents before their
+// children. The fetched parents complete the registries, which do not know the pages that are not synced yet.
+func (c *Crawler) orderPlannedFiles(
+	ctx context.Context,
+	files []*PlannedFile,
+	entryParentID string,
+	pageParents map[string]string,
+) {
+	pageIDs := make([]string, len(files))
+	planned := make(map[string]bool, len(files))
+	for i, file := range files {
+		pageIDs[i] = file.PageID
+		planned[file.PageID] = true
+	}
+	parents := c.queuedPageParents(ctx, pageIDs, entryParentID)
+	for id, parentID := range pageParents {
+		if planned[id] && planned[parentID] && parentID != id {
+			parents[id] = parentID
+		}
+	}
+
+	ordered := make([]*PlannedFile, 0, len(files))
+	for _, i := range dependencyOrder(pageIDs, parents) {
+		ordered = append(ordered, files[i])
+	}
+	copy(files, ordered)
+}
@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestPlanQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	edited := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	pages := map[string]notion.Page{
+		"/pages/parent": {ID: "parent", LastEditedTime: edited, Parent: notion.Parent{Type: "workspace"}},
+		"/pages/child":  {ID: "child", LastEditedTime: edited, Parent: notion.Parent{Type: "page_id", PageID: "parent"}},
+		"/pages/gone":   {ID: "gone", LastEditedTime: edited, Archived: true},
+	}
+	var otherRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			otherRequests.Add(1)
+			http.NotFound(w, r)
+			return
+		}
+		page.Object = "page"
+		page.Properties = notion.Properties{
+			"title": {Type: "title", Title: []notion.RichText{{PlainText: map[string]string{
+				"parent": "Parent", "child": "Roadmap", "gone": "Gone",
+			}[page.ID]}}},
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	synced := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, reg := range []*PageRegistry{
+		{ID: "parent", Folder: "test", FilePath: "test/parent.md", Title: "Parent", LastEdited: synced},
+		{ID: "unchanged", Folder: "test", FilePath: "test/unchanged.md", Title: "Unchanged", LastEdited: edited},
+	} {
+		reg.Type = notionTypePage
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+	if _, err := qm.CreateEntry(ctx, queue.Entry{
+		Type:   queue.TypeUpdate,
+		Folder: "test",
+		Pages: []queue.Page{
+			{ID: "parent", LastEdited: edited},
+			{ID: "unchanged", LastEdited: edited},
+			{ID: "child", LastEdited: edited},
+			{ID: "gone", LastEdited: edited},
+		},
+	}); err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	plan, err := crawler.PlanQueue(ctx, SyncPlanOptions{})
+	if err != nil {
+		t.Fatalf("PlanQueue() error = %v", err)
+	}
+
+	want := []PlannedFile{
+		{Action: PlanActionUpdate, Path: "test/parent.md", PageID: "parent", Title: "Parent", Folder: "test"},
+		{Action: PlanActionCreate, Path: "test/parent/roadmap.md", PageID: "child", Title: "Roadmap", Folder: "test"},
+	}
+	if len(plan.Files) != len(want) {
+		t.Fatalf("PlanQueue() files = %+v, want %+v", plan.Files, want)
+	}
+	for i := range want {
+		if *plan.Files[i] != want[i] {
+			t.Errorf("file %d = %+v, want %+v", i, *plan.Files[i], want[i])
+		}
+	}
+	if plan.Pages != 4 || plan.Skipped != 1 || plan.Dropped != 1 || plan.Failed != 0 {
+		t.Errorf("PlanQueue() = %+v, want 4 pages, 1 skipped and 1 dropped", plan)
+	}
+	if plan.APICalls != 3 || plan.EstimatedAPICalls != 2*planPageCalls {
+		t.Errorf("PlanQueue() api calls = %d, estimated %d, want 3 and %d",
+			plan.APICalls, plan.EstimatedAPICalls, 2*planPageCalls)
+	}
+	if got := otherRequests.Load(); got != 0 {
+		t.Errorf("blocks requests = %d, want none", got)
+	}
+
+	// Nothing was written: the queue entry is kept and the new page is not registered
+	if files, err := qm.ListEntries(ctx); err != nil || len(files) != 1 {
+		t.Errorf("ListEntries() = %v, %v, want the queue entry kept", files, err)
+	}
+	if _, err := crawler.loadPageRegistry(ctx, "child"); err == nil {
+		t.Error("planned page should not be registered")
+	}
+}
@@ -4,16 +4,24 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
 	"github.com/fclairamb/ntnsync/internal/converter"
 	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/shutdown"
 	"github.com/fclairamb/ntnsync/internal/version"
 )
 
+// defaultShutdownGrace is the time given to a sync to finish its pages in progress when the process is asked to
+// stop. It leaves time for the final commit within the default termination grace period of Kubernetes (30s).
+const defaultShutdownGrace = 20 * time.Second
+
 // getQueueDelay returns the delay between processing queue files.
 func getQueueDelay() time.Duration {
 	return GetConfig().QueueDelay
@@ -25,27 +33,29 @@ func getBlockDepthLimit() int {
 	return GetConfig().BlockDepth
 }
 
-// QueueCallback is called after each queue file is processed (written or deleted).
-type QueueCallback func() error
+// MigrateQueue converts the queue files using the legacy list of page IDs to the current format, and returns
+// their names. With dryRun, they are only listed.
+func (c *Crawler) MigrateQueue(ctx context.Context, dryRun bool) ([]string, error) {
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+	migrated, err := c.queueManager.Migrate(ctx, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("migrate queue: %w", err)
+	}
+	return migrated, nil
+}
 
-// ProcessQueue processes all queue entries, optionally filtered by folder.
+// ProcessQueue processes all queue entries, optionally filtered by folder, notifying the progress hooks.
 // maxPages limits the number of pages to fetch (0 = unlimited).
 // maxTime limits the duration of the sync (0 = unlimited).
-func (c *Crawler) ProcessQueue(
-	ctx context.Context, folderFilter string, maxPages int, maxFiles int, maxQueueFiles int, maxTime time.Duration,
-) error {
-	return c.ProcessQueueWithCallback(ctx, folderFilter, maxPages, maxFiles, maxQueueFiles, maxTime, nil)
-}
-
-// ProcessQueueWithCallback is like ProcessQueue but calls the callback after each queue file is processed.
+// When a shutdown is requested (see the shutdown package), it stops taking new pages, finishes the pages in
+// progress and saves the queue and the state. If the context is canceled meanwhile, the queue and the state are
+// still saved, and the pages interrupted stay queued.
 //
 //nolint:funlen,gocognit // Complex queue processing with multiple conditions and callbacks
-func (c *Crawler) ProcessQueueWithCallback(
-	ctx context.Context,
-	folderFilter string,
-	maxPages, maxFiles, maxQueueFiles int,
-	maxTime time.Duration,
-	callback QueueCallback,
+func (c *Crawler) ProcessQueue(
+	ctx context.Context, folderFilter string, maxPages int, maxFiles int, maxQueueFiles int, maxTime time.Duration,
 ) error {
 	c.logger.InfoContext(ctx, "processing queue",
 		"folder_filter", folderFilter,
@@ -53,28 +63,50 @@ func (c *Crawler) ProcessQueueWithCallback(
 		"max_files", maxFiles,
 		"max_queue_files", maxQueueFiles,
 		"max_time", maxTime,
-		"queue_delay", getQueueDelay())
+		"queue_delay", getQueueDelay(),
+		"queue_delay_auto", GetConfig().QueueDelayAuto)
+
+	defer shutdown.Begin(ctx)()
+	if c.StartRun(ctx, folderFilter) {
+		defer c.FinishRun(ctx)
+	}
 
 	// Ensure transaction is available
 	if err := c.EnsureTransaction(ctx); err != nil {
 		return fmt.Errorf("ensure transaction: %w", err)
 	}
+	c.SetRunPhase(ctx, RunPhaseQueue)
 
 	// Load state
 	if err := c.loadState(ctx); err != nil {
 		c.logger.WarnContext(ctx, "could not load state, starting fresh", "error", err)
 	}
 
+	c.parents.reset()
+	c.startCrawlRun()
+
+	// Pages left half processed by a killed run are synced again first
+	if recovered := c.recoverJournal(ctx); recovered > 0 {
+		c.logger.WarnContext(ctx, "queued pages interrupted by a previous run", "count", recovered)
+	}
+
 	totalProcessed := 0
 	totalSkipped := 0
 	totalDropped := 0
+	totalFailed := 0
 	totalFilesWritten := 0
 	totalQueueFilesProcessed := 0
+	var authErr error // Set when the Notion token was rejected
 	startTime := time.Now()
+	throttleStart := c.clientThrottle()
+	delayTuner := &queueDelayTuner{last: throttleStart}
 	skippedFiles := make(map[string]bool) // Track files skipped due to folder filter or read errors
 
 	// Check if we should stop based on limits
 	shouldStop := func() bool {
+		if authErr != nil || ctx.Err() != nil || shutdown.Requested(ctx) {
+			return true
+		}
 		if maxPages > 0 && totalProcessed >= maxPages {
 			return true
 		}
@@ -84,7 +116,7 @@ func (c *Crawler) ProcessQueueWithCallback(
 		if maxTime > 0 && time.Since(startTime) >= maxTime {
 			return true
 		}
-		return false
+		return c.crawlBudgetExhausted(ctx)
 	}
 
 	// Process queue files in alphabetical order, re-fetching after each file
@@ -111,6 +143,7 @@ func (c *Crawler) ProcessQueueWithCallback(
 
 		if queueFile == "" {
 			c.logger.InfoContext(ctx, "queue is empty")
+			c.queueDrained(ctx)
 			break
 		}
 
@@ -132,8 +165,19 @@ func (c *Crawler) ProcessQueueWithCallback(
 			continue
 		}
 
+		// Leave entries whose pages are all waiting for their retry backoff for a later run
+		if entryDeferred(entry, time.Now()) {
+			c.logger.DebugContext(ctx, "skipping queue entry of pages waiting for their retry",
+				"file", queueFile)
+			skippedFiles[queueFile] = true
+			continue
+		}
+
 		// Apply queue delay before processing (if configured)
 		queueDelay := getQueueDelay()
+		if GetConfig().QueueDelayAuto {
+			queueDelay = delayTuner.next(c.clientThrottle())
+		}
 		if queueDelay > 0 {
 			c.logger.InfoContext(ctx, "waiting before processing queue entry",
 				"delay", queueDelay,
@@ -141,7 +185,9 @@ func (c *Crawler) ProcessQueueWithCallback(
 
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				continue // Stops, saving the progress
+			case <-shutdown.Stopping(ctx):
+				continue
 			case <-time.After(queueDelay):
 			}
 		}
@@ -150,7 +196,10 @@ func (c *Crawler) ProcessQueueWithCallback(
 			"file", queueFile,
 			"type", entry.Type,
 			"folder", entry.Folder,
-			"pages", len(entry.PageIDs))
+			"pages", len(entry.Pages))
+		c.updateRun(ctx, func(status *RunStatus) {
+			status.QueueFile = queueFile
+		})
 
 		// Ensure folder is in state
 		c.state.AddFolder(entry.Folder)
@@ -162,55 +211,115 @@ func (c *Crawler) ProcessQueueWithCallback(
 			totalFilesWritten: totalFilesWritten,
 		}
 
-		var remainingPageIDs []string
-		var remainingPages []queue.Page
-
-		if len(entry.Pages) > 0 {
-			remainingPages = c.processNewFormatEntry(ctx, entry, stats, shouldStop)
-		} else {
-			remainingPageIDs = c.processLegacyFormatEntry(ctx, entry, stats, shouldStop)
+		entryCtx := ctx
+		if c.queueManager.IsWebhookEntry(queueFile) {
+			entryCtx = withWebhookSync(ctx)
 		}
+		remainingPages := c.processEntry(entryCtx, entry, stats, shouldStop)
 
 		totalProcessed = stats.totalProcessed
 		totalSkipped = stats.totalSkipped
 		totalDropped += stats.totalDropped
+		totalFailed += stats.totalFailed
 		totalFilesWritten = stats.totalFilesWritten
+		authErr = stats.authErr
 
-		// Update or delete queue entry based on remaining pages
-		c.updateOrDeleteQueueEntry(ctx, queueFile, entry, remainingPages, remainingPageIDs)
+		// Update or delete queue entry based on remaining pages, even if the context was canceled meanwhile
+		c.updateOrDeleteQueueEntry(context.WithoutCancel(ctx), queueFile, entry, remainingPages)
 
 		// Mark as processed if there are remaining pages (will retry next sync cycle)
-		if len(remainingPages) > 0 || len(remainingPageIDs) > 0 {
+		if len(remainingPages) > 0 {
 			skippedFiles[queueFile] = true
 		}
 
 		totalQueueFilesProcessed++
+		c.updateRun(ctx, func(status *RunStatus) {
+			status.Processed = totalProcessed
+			status.Skipped = totalSkipped
+			status.Dropped = totalDropped
+			status.FilesWritten = totalFilesWritten
+			status.QueueFiles = totalQueueFilesProcessed
+		})
+
+		// Notify the hooks after the queue file is processed (e.g. for periodic commits)
+		if err := c.queueFileDone(ctx, queueFile); err != nil {
+			return fmt.Errorf("queue file hook: %w", err)
+		}
+	}
 
-		// Call callback after queue file is processed (for periodic commits)
-		if callback != nil {
-			if err := callback(); err != nil {
-				return fmt.Errorf("queue callback: %w", err)
-			}
+	// Canceled, after the shutdown grace period: the progress is saved, and the final steps are left to the next run
+	if err := ctx.Err(); err != nil {
+		if saveErr := c.saveState(context.WithoutCancel(ctx)); saveErr != nil {
+			c.logger.ErrorContext(ctx, "failed to save state", "error", saveErr)
 		}
+		c.logger.WarnContext(ctx, "queue processing canceled, the remaining pages stay queued",
+			"processed", totalProcessed, "queue_files", totalQueueFilesProcessed)
+		return fmt.Errorf("process queue: %w", err)
+	}
+
+	// Keep the mirror under its size cap, if the prune policy allows it
+	c.SetRunPhase(ctx, RunPhasePrune)
+	if _, err := c.PruneMirror(ctx, GetConfig().PrunePolicy, false); err != nil {
+		c.logger.WarnContext(ctx, "failed to prune mirror", "error", err)
 	}
 
 	// Final state save
+	c.SetRunPhase(ctx, RunPhaseSave)
+	if err := c.updateCodeOwners(ctx); err != nil {
+		c.logger.WarnContext(ctx, "failed to update CODEOWNERS", "error", err)
+	}
+	throttle := c.clientThrottle().Sub(throttleStart)
+	c.recordRunPerf(startTime, throttle)
+	health := c.recordHealth(ctx, totalProcessed, totalFailed, totalDropped)
+	warnings := c.takeWarnings()
+	c.finishCrawlRun(ctx, folderFilter, totalProcessed)
+	if authErr == nil {
+		c.reportStatus(ctx, GetConfig().StatusPageID, syncReport{
+			FinishedAt:   time.Now(),
+			Duration:     time.Since(startTime),
+			Processed:    totalProcessed,
+			Dropped:      totalDropped,
+			FilesWritten: totalFilesWritten,
+			Warnings:     countWarnings(warnings),
+		})
+	}
 	if err := c.saveState(ctx); err != nil {
 		return fmt.Errorf("save state: %w", err)
 	}
+	if authErr != nil {
+		c.logger.ErrorContext(ctx, "queue processing stopped, the remaining pages stay queued",
+			"processed", totalProcessed, "error", authErr)
+		return fmt.Errorf("process queue: %w", authErr)
+	}
 
 	// Log completion with limit status
 	logAttrs := []any{
 		"processed", totalProcessed,
 		"skipped", totalSkipped,
 		"dropped", totalDropped,
+		"failed", totalFailed,
+		"health_score", health.Score,
 		"files_written", totalFilesWritten,
 		"queue_files", totalQueueFilesProcessed,
 		"duration_ms", time.Since(startTime).Milliseconds(),
+		"throttled_ms", throttle.Waited.Milliseconds(),
+		"rate_limited", throttle.RateLimited,
+	}
+	if quotaExceeded := c.QuotaExceededFolders(); len(quotaExceeded) > 0 {
+		logAttrs = append(logAttrs, "quota_exceeded", quotaExceeded)
+	}
+	if c.MirrorSizeExceeded() {
+		logAttrs = append(logAttrs, "mirror_size_exceeded", true)
+	}
+	if len(warnings) > 0 {
+		logAttrs = append(logAttrs, "conversion_warnings", warnings)
 	}
 
 	limitReached := false
 	switch {
+	case shutdown.Requested(ctx):
+		logAttrs = append(logAttrs, "limit_reached", "shutdown")
+		limitReached = true
 	case maxPages > 0 && totalProcessed >= maxPages:
 		logAttrs = append(logAttrs, "limit_reached", "max_pages")
 		limitReached = true
@@ -223,6 +332,9 @@ func (c *Crawler) ProcessQueueWithCallback(
 	case maxTime > 0 && time.Since(startTime) >= maxTime:
 		logAttrs = append(logAttrs, "limit_reached", "max_time")
 		limitReached = true
+	case c.crawlStopped():
+		logAttrs = append(logAttrs, "limit_reached", "crawl_budget")
+		limitReached = true
 	}
 
 	if limitReached {
@@ -235,15 +347,22 @@ func (c *Crawler) ProcessQueueWithCallback(
 }
 
 // updateOrDeleteQueueEntry updates the queue entry with remaining pages or deletes it if complete.
+// Pages added to a webhook entry by events received while it was processed are kept.
 func (c *Crawler) updateOrDeleteQueueEntry(
 	ctx context.Context,
 	queueFile string,
 	entry *queue.Entry,
 	remainingPages []queue.Page,
-	remainingPageIDs []string,
 ) {
-	hasRemaining := len(remainingPages) > 0 || len(remainingPageIDs) > 0
-	if !hasRemaining {
+	if c.queueManager.IsWebhookEntry(queueFile) {
+		for _, page := range c.queueManager.PagesAddedSince(ctx, queueFile, entry) {
+			if !slices.ContainsFunc(remainingPages, func(p queue.Page) bool { return p.ID == page.ID }) {
+				remainingPages = append(remainingPages, page)
+			}
+		}
+	}
+
+	if len(remainingPages) == 0 {
 		if err := c.queueManager.DeleteEntry(ctx, queueFile); err != nil {
 			c.logger.WarnContext(ctx, "failed to delete queue entry", "error", err)
 		}
@@ -251,12 +370,7 @@ func (c *Crawler) updateOrDeleteQueueEntry(
 	}
 
 	// Update entry with remaining pages
-	if len(remainingPages) > 0 {
-		entry.Pages = remainingPages
-		entry.PageIDs = nil // Clear legacy field
-	} else {
-		entry.PageIDs = remainingPageIDs
-	}
+	entry.Pages = remainingPages
 	if err := c.queueManager.UpdateEntry(ctx, queueFile, entry); err != nil {
 		c.logger.WarnContext(ctx, "failed to update queue entry", "error", err)
 	}
@@ -266,118 +380,129 @@ func (c *Crawler) updateOrDeleteQueueEntry(
 type queueProcessingStats struct {
 	totalProcessed    int
 	totalSkipped      int
-	totalDropped      int // pages dropped due to permanent errors
+	totalDropped      int // pages dropped because they are blocked (permanent errors, archived, blocked parent)
+	totalFailed       int // pages that failed and are queued again for a retry
 	totalFilesWritten int
+	authErr           error // set when the Notion token was rejected: the run stops, keeping the queue
 }
 
-// processNewFormatEntry processes pages in new format and returns remaining pages.
-func (c *Crawler) processNewFormatEntry(
+// processEntry processes the pages of a queue entry and returns the remaining pages.
+// Parents are processed before their children, and pages in parallel up to NTN_SYNC_CONCURRENCY (see pagePool).
+func (c *Crawler) processEntry(
 	ctx context.Context,
 	entry *queue.Entry,
 	stats *queueProcessingStats,
 	shouldStop func() bool,
 ) []queue.Page {
 	var remaining []queue.Page
-
+	pageIDs := make([]string, len(entry.Pages))
 	for i := range entry.Pages {
-		queuePage := &entry.Pages[i]
-		pageID := queuePage.ID
-
-		if shouldStop() {
-			remaining = append(remaining, *queuePage)
-			continue
-		}
+		pageIDs[i] = entry.Pages[i].ID
+	}
+	parents := c.queuedPageParents(ctx, pageIDs, entry.ParentID)
+	pool := c.newPagePool(parents)
 
-		if c.shouldSkipNewFormatPage(ctx, pageID, queuePage.LastEdited) {
-			stats.totalSkipped++
-			continue
-		}
+	for _, i := range dependencyOrder(pageIDs, parents) {
+		queuePage := entry.Pages[i]
+		pageID := queuePage.ID
 
-		filesCount, err := c.processPage(ctx, pageID, entry.Folder, entry.Type == queueTypeInit, entry.ParentID)
-		if err != nil {
-			if notion.IsPermanentError(err) {
-				c.logger.WarnContext(ctx, "dropping page from queue (permanent error)",
-					notionKeyPageID, pageID, "error", err)
-				stats.totalDropped++
-				continue
+		process := false
+		pool.locked(ctx, func(ctx context.Context) {
+			switch {
+			case shouldStop() || stats.authErr != nil:
+				remaining = append(remaining, queuePage)
+			case queuePage.NotBefore.After(time.Now()):
+				c.logger.DebugContext(ctx, "page waiting for its retry",
+					notionKeyPageID, pageID, "failures", queuePage.Failures, "not_before", queuePage.NotBefore)
+				remaining = append(remaining, queuePage)
+			case c.shouldSkipQueuedPage(ctx, entry, &queuePage):
+				stats.totalSkipped++
+				c.crawlPageTaken()
+			default:
+				process = true
+				c.crawlPageTaken()
+				c.journalStart(ctx, pageID, entry.Folder, entry.Type)
 			}
-			c.logger.ErrorContext(ctx, "failed to process page (will retry)", notionKeyPageID, pageID, "error", err)
-			remaining = append(remaining, *queuePage)
+		})
+		if !process {
 			continue
 		}
 
-		stats.totalProcessed++
-		stats.totalFilesWritten += filesCount
-
-		if stats.totalProcessed%10 == 0 {
-			if err := c.saveState(ctx); err != nil {
-				c.logger.WarnContext(ctx, "failed to save state", "error", err)
-			}
-		}
+		pageCtx := withUpdatedBlocks(ctx, queuePage.UpdatedBlocks)
+		pool.run(pageCtx, pageID, entry.Folder, entry.Type, entry.ParentID,
+			func(ctx context.Context, filesCount int, err error) {
+				c.journalEnd(ctx, pageID)
+				if err != nil && ctx.Err() != nil && errors.Is(err, context.Canceled) {
+					// Interrupted by the end of the shutdown grace period, not failed
+					remaining = append(remaining, queuePage)
+					return
+				}
+				if err != nil {
+					if c.handleProcessError(ctx, pageID, entry.Folder, err, stats) {
+						// Failures of the token are not the page's: it is retried with the next run
+						if stats.authErr == nil {
+							deferFailedPage(&queuePage, time.Now())
+							stats.totalFailed++
+						}
+						remaining = append(remaining, queuePage)
+					}
+					return
+				}
+				c.pageProcessed(ctx, stats, filesCount)
+			})
 	}
+	pool.wait()
 
 	return remaining
 }
 
-// processLegacyFormatEntry processes pages in legacy format and returns remaining page IDs.
-func (c *Crawler) processLegacyFormatEntry(
-	ctx context.Context,
-	entry *queue.Entry,
-	stats *queueProcessingStats,
-	shouldStop func() bool,
-) []string {
-	var remaining []string
-
-	for _, pageID := range entry.PageIDs {
-		if shouldStop() {
-			remaining = append(remaining, pageID)
-			continue
-		}
-
-		switch c.shouldSkipLegacyPage(ctx, pageID, entry.Type == queueTypeInit) {
-		case legacyPageSkip:
-			stats.totalSkipped++
-			continue
-		case legacyPageSkipAndRequeue:
-			remaining = append(remaining, pageID)
-			continue
-		case legacyPageProcess:
-			// Continue to processing below
-		}
-
-		filesCount, err := c.processPage(ctx, pageID, entry.Folder, entry.Type == queueTypeInit, entry.ParentID)
-		if err != nil {
-			if notion.IsPermanentError(err) {
-				c.logger.WarnContext(ctx, "dropping page from queue (permanent error)",
-					notionKeyPageID, pageID, "error", err)
-				stats.totalDropped++
-				continue
-			}
-			c.logger.ErrorContext(ctx, "failed to process page (will retry)", notionKeyPageID, pageID, "error", err)
-			remaining = append(remaining, pageID)
-			continue
-		}
+// shouldSkipQueuedPage checks if a queued page should be skipped: unchanged since its last sync, or blocked.
+func (c *Crawler) shouldSkipQueuedPage(ctx context.Context, entry *queue.Entry, queuePage *queue.Page) bool {
+	pageID := queuePage.ID
+	if entry.Type == queueTypeProperties && c.shouldSkipPropertyRefresh(ctx, pageID, queuePage.LastEdited) {
+		return true
+	}
+	// Content changes notified with their blocks are cheap to check with block diff, and are not skipped as
+	// their changes may be missing from a sync done in between
+	blockDiff := GetConfig().BlockDiff && len(queuePage.UpdatedBlocks) > 0
+	if entry.Type != queueTypeProperties && !blockDiff &&
+		c.shouldSkipUnchangedPage(ctx, pageID, queuePage.LastEdited, entry.Type == queueTypeInit) {
+		return true
+	}
+	return c.shouldSkipBlockedPage(ctx, pageID, entry.Folder, entry.ParentID, queuePage.LastEdited)
+}
 
-		stats.totalProcessed++
-		stats.totalFilesWritten += filesCount
+// pageProcessed counts a processed page, saving the state every 10 pages.
+func (c *Crawler) pageProcessed(ctx context.Context, stats *queueProcessingStats, filesCount int) {
+	stats.totalProcessed++
+	stats.totalFilesWritten += filesCount
 
-		if stats.totalProcessed%10 == 0 {
-			if err := c.saveState(ctx); err != nil {
-				c.logger.WarnContext(ctx, "failed to save state", "error", err)
-			}
+	if stats.totalProcessed%10 == 0 {
+		if err := c.saveState(ctx); err != nil {
+			c.logger.WarnContext(ctx, "failed to save state", "error", err)
 		}
 	}
-
-	return remaining
 }
 
-// shouldSkipNewFormatPage checks if a new format queue page should be skipped.
-// Returns true if the page exists and hasn't been edited since last sync.
-func (c *Crawler) shouldSkipNewFormatPage(ctx context.Context, pageID string, queueLastEdited time.Time) bool {
+// shouldSkipUnchangedPage checks if a queued page should be skipped.
+// Returns true if the page exists and hasn't been edited since last sync. When the last edited time of the
+// queued page is unknown (entries converted from the legacy format), existing pages are only skipped by "init"
+// entries, which discover new pages: "update" entries force their sync.
+func (c *Crawler) shouldSkipUnchangedPage(
+	ctx context.Context, pageID string, queueLastEdited time.Time, isInit bool,
+) bool {
 	reg, err := c.loadPageRegistry(ctx, pageID)
 	if err != nil {
 		return false // Page not in registry, should process
 	}
+	if queueLastEdited.IsZero() {
+		if isInit {
+			c.logger.DebugContext(ctx, "skipping existing page in init mode (using cache)",
+				notionKeyPageID, pageID,
+				notionKeyTitle, reg.Title)
+		}
+		return isInit
+	}
 
 	// Page exists - check if it needs updating by comparing with queued last_edited
 	if !queueLastEdited.After(reg.LastEdited) {
@@ -391,35 +516,6 @@ func (c *Crawler) shouldSkipNewFormatPage(ctx context.Context, pageID string, qu
 	return false
 }
 
-// legacyPageSkipResult indicates what to do with a legacy page.
-type legacyPageSkipResult int
-
-const (
-	legacyPageProcess        legacyPageSkipResult = iota // Process the page
-	legacyPageSkip                                       // Skip and count as skipped
-	legacyPageSkipAndRequeue                             // Skip but keep in queue for retry
-)
-
-// shouldSkipLegacyPage checks if a legacy format page should be skipped for init queue type.
-func (c *Crawler) shouldSkipLegacyPage(ctx context.Context, pageID string, isInit bool) legacyPageSkipResult {
-	if !isInit {
-		return legacyPageProcess
-	}
-
-	reg, err := c.loadPageRegistry(ctx, pageID)
-	if err != nil {
-		return legacyPageProcess // Page not in registry, should process
-	}
-
-	// Page exists in registry - in init mode, skip it to avoid unnecessary API calls.
-	// Init mode is for discovering new pages, not updating existing ones.
-	// Use "update" queue type for forcing re-sync of existing pages.
-	c.logger.DebugContext(ctx, "skipping existing page in init mode (using cache)",
-		notionKeyPageID, pageID,
-		notionKeyTitle, reg.Title)
-	return legacyPageSkip
-}
-
 // parentResolutionResult holds the result of parent resolution.
 type parentResolutionResult struct {
 	parentID     string
@@ -490,6 +586,11 @@ func (c *Crawler) resolveAndFetchParent(
 		return result, nil
 	}
 
+	// A blocked parent (archived, inaccessible) blocks its whole subtree
+	if err := c.checkParentBlocked(ctx, parentID); err != nil {
+		return nil, err
+	}
+
 	// If expectedParentID matches, skip the check - we know parent exists from queue
 	if expectedParentID != "" && normalizePageID(expectedParentID) == parentID {
 		c.logger.DebugContext(ctx, "using expected parent from queue",
@@ -499,7 +600,7 @@ func (c *Crawler) resolveAndFetchParent(
 	}
 
 	// Check if parent is in this folder via registry
-	if _, err := c.loadPageRegistry(ctx, parentID); err == nil {
+	if c.hasPageRegistry(ctx, parentID) {
 		return result, nil
 	}
 
@@ -542,6 +643,11 @@ func (c *Crawler) resolveAndFetchParent(
 
 	// If parent is a block (not a page), resolve to containing page
 	if !strings.Contains(err.Error(), "is a block, not a page") {
+		if reason, blockedBy := blockReason(err, parentID); reason != "" {
+			// The parent cannot be synced: block it, and with it the whole subtree
+			c.markPageBlocked(ctx, parentID, folder, reason, blockedBy, err)
+			return nil, &blockedParentError{parentID: parentID, blockedBy: blockedBy}
+		}
 		c.logger.ErrorContext(ctx, "failed to fetch parent, failing",
 			itemType, itemID,
 			"parent_id", parentID,
@@ -574,7 +680,7 @@ func (c *Crawler) resolveAndFetchParent(
 			itemType, itemID,
 			"resolved_parent_id", resolvedID)
 		// Now try to fetch/process the resolved parent
-		if _, loadErr := c.loadPageRegistry(ctx, resolvedID); loadErr == nil {
+		if c.hasPageRegistry(ctx, resolvedID) {
 			// Resolved parent is in registry, we're done
 			return result, nil
 		}
@@ -633,12 +739,17 @@ type writeAndRegisterParams struct {
 	existingReg      *PageRegistry
 	enabled          bool
 
-	// convert generates the markdown content given the resolved file path, isRoot, and parentID.
-	convert          func(filePath string, isRoot bool, parentID string) []byte
+	// convert generates the markdown content given the resolved file path, isRoot, parentID and aliases.
+	convert          func(filePath string, isRoot bool, parentID string, aliases []string) []byte
 	downloadDuration time.Duration
 
 	// Children
 	children []string
+
+	// schemaEdited is the last schema edit time of a database (zero for pages)
+	schemaEdited time.Time
+	// openComments is the number of unresolved comments of a page
+	openComments int
 }
 
 // writeAndRegister handles parent resolution, file path computation, conversion, writing,
@@ -683,16 +794,33 @@ func (c *Crawler) writeAndRegister(
 		},
 	}
 	filePath := c.computeFilePath(ctx, syntheticPage, params.folder, isRoot, parentID)
+	if params.existingReg != nil && params.existingReg.FilePath == filePath {
+		filePath = c.renamePage(ctx, params.existingReg, syntheticPage, params.folder, isRoot, parentID)
+	}
+	aliases := pageAliases(params.existingReg, params.title, filePath)
 
 	now := time.Now()
 
-	// Convert to markdown with resolved path, isRoot, and parentID
-	content := params.convert(filePath, isRoot, parentID)
+	// Convert to markdown with resolved path, isRoot, parentID and aliases
+	convertStart := time.Now()
+	content := params.convert(filePath, isRoot, parentID, aliases)
+	convertDuration := time.Since(convertStart)
 
 	// Compute content hash
 	hash := sha256.Sum256(content)
 	contentHash := hex.EncodeToString(hash[:])
 
+	// Content loss guard: hold suspicious conversions for review instead of overwriting the mirror.
+	// The registry is left untouched so the page is converted again on its next update.
+	if report := c.checkContentLoss(ctx, filePath, content); report != nil {
+		c.takeRenderedAssets(params.itemID)
+		if err := c.holdForReview(ctx, params.itemID, filePath, content, report); err != nil {
+			return 0, err
+		}
+		return filesWritten, nil
+	}
+	c.clearReview(ctx, params.itemID)
+
 	// Write file
 	writeStart := time.Now()
 	if err := c.tx.Write(ctx, filePath, content); err != nil {
@@ -701,6 +829,10 @@ func (c *Crawler) writeAndRegister(
 	writeDuration := time.Since(writeStart)
 	filesWritten++
 
+	c.recordPhase(PerfPhaseFetch, params.downloadDuration)
+	c.recordPhase(PerfPhaseConvert, convertDuration)
+	c.recordPhase(PerfPhaseWrite, writeDuration)
+
 	totalDuration := time.Since(startTime)
 	c.logger.InfoContext(ctx, "downloaded "+params.itemType,
 		logKey, params.itemID,
@@ -711,10 +843,15 @@ func (c *Crawler) writeAndRegister(
 		"write_ms", writeDuration.Milliseconds())
 
 	// Preserve IsRoot and Enabled from existing registry (set by ReconcileRootMd)
-	if params.existingReg != nil && params.existingReg.IsRoot {
-		isRoot = true
-		params.enabled = params.existingReg.Enabled
+	var previousAssets []string
+	if params.existingReg != nil {
+		previousAssets = params.existingReg.Assets
+		if params.existingReg.IsRoot {
+			isRoot = true
+			params.enabled = params.existingReg.Enabled
+		}
 	}
+	assets := c.updateAssetPages(ctx, params.itemID, previousAssets, true)
 
 	// Save page registry
 	if err := c.savePageRegistry(ctx, &PageRegistry{
@@ -731,10 +868,25 @@ func (c *Crawler) writeAndRegister(
 		ParentID:       parentID,
 		Children:       params.children,
 		ContentHash:    contentHash,
+		Size:           int64(len(content)),
+		Aliases:        aliases,
+		SchemaEdited:   params.schemaEdited,
+		OpenComments:   params.openComments,
+		Assets:         assets,
 	}); err != nil {
 		c.logger.WarnContext(ctx, "failed to save page registry", "error", err)
 	}
 
+	// Keep folder quota usage up to date
+	if params.existingReg != nil {
+		c.addFolderUsage(params.folder, 0, int64(len(content))-params.existingReg.Size)
+	} else {
+		c.addFolderUsage(params.folder, 1, int64(len(content)))
+	}
+
+	// The item synced successfully, so it is no longer blocked
+	c.unblockPage(ctx, params.itemID)
+
 	// Self-heal: an earlier run may have stored this page under the legacy dashed
 	// ID form (page-{uuid-with-dashes}.json). Now that the canonical registry is
 	// saved, drop the stale dashed one so the page is not listed — and counted as
@@ -747,6 +899,11 @@ func (c *Crawler) writeAndRegister(
 		}
 	}
 
+	// Children removed since the last sync are only looked for on webhook events, that sync a single page
+	if params.existingReg != nil && isWebhookSync(ctx) {
+		c.reconcileRemovedChildren(ctx, params.itemID, params.existingReg.Children, params.children)
+	}
+
 	// Queue children if they don't exist yet
 	var newChildren []string
 	for _, childID := range params.children {
@@ -755,6 +912,14 @@ func (c *Crawler) writeAndRegister(
 		}
 	}
 
+	if len(newChildren) > 0 && c.folderQuotaExceeded(ctx, params.folder) {
+		c.logger.WarnContext(ctx, "not queueing child pages, folder or mirror quota exceeded",
+			logKey, params.itemID,
+			"folder", params.folder,
+			"children", len(newChildren))
+		newChildren = nil
+	}
+
 	if len(newChildren) > 0 {
 		entry := queue.Entry{
 			Type:     queueTypeInit,
@@ -770,6 +935,8 @@ func (c *Crawler) writeAndRegister(
 		}
 	}
 
+	c.queueSchemaRefresh(ctx, params)
+
 	return filesWritten, nil
 }
 
@@ -778,7 +945,7 @@ func (c *Crawler) writeAndRegister(
 func (c *Crawler) verifyNewItemRoot(
 	ctx context.Context, page *notion.Page, itemID, logKey, folder string,
 ) (string, bool) {
-	_, detectedFolder, foundRoot, err := c.traceParentChain(ctx, page, folder)
+	parentChain, detectedFolder, foundRoot, err := c.traceParentChain(ctx, page, folder)
 	if err != nil {
 		c.logger.WarnContext(ctx, "failed to trace parent chain",
 			logKey, itemID,
@@ -788,6 +955,7 @@ func (c *Crawler) verifyNewItemRoot(
 	if !foundRoot {
 		c.logger.InfoContext(ctx, "skipping item not under any root in root.md",
 			logKey, itemID)
+		c.queueInferredRoot(ctx, page, parentChain)
 		return folder, false
 	}
 	if folder != detectedFolder {
@@ -822,29 +990,15 @@ func (c *Crawler) processPage(
 		return 0, nil
 	}
 
-	// Try to fetch as page first
-	fetchStart := time.Now()
-	page, fetchErr := c.client.GetPage(ctx, pageID)
-	isDatabase := fetchErr != nil && strings.Contains(fetchErr.Error(), "is a database, not a page")
-	if fetchErr != nil && !isDatabase {
-		return 0, fmt.Errorf("fetch page: %w", fetchErr)
-	}
-
-	var params *writeAndRegisterParams
-
-	if isDatabase {
-		c.logger.InfoContext(ctx, "detected database, processing as database", notionKeyPageID, pageID)
-		params, folder, err = c.buildDatabaseParams(ctx, pageID, folder, fetchStart)
-	} else {
-		c.logger.DebugContext(ctx, "fetched page metadata",
-			notionKeyPageID, pageID, "duration_ms", time.Since(fetchStart).Milliseconds())
-		c.enrichUsers(ctx, &page.CreatedBy, &page.LastEditedBy)
-		params, folder, err = c.buildPageParams(ctx, page, pageID, folder, fetchStart)
-	}
+	params, folder, err := c.buildItemParams(ctx, pageID, folder)
 	if err != nil {
 		return 0, err
 	}
 
+	// Pages processed in parallel are fetched concurrently, but written one at a time
+	ctx, unlock := c.lockWrites(ctx)
+	defer unlock()
+
 	// For new items (not in registry), verify they belong to an enabled root
 	existingReg, _ := c.loadPageRegistry(ctx, pageID)
 	if existingReg == nil {
@@ -853,6 +1007,9 @@ func (c *Crawler) processPage(
 		if folder, ok = c.verifyNewItemRoot(ctx, syntheticPage, pageID, params.itemType+"_id", folder); !ok {
 			return 0, nil
 		}
+		if c.skipIgnoredPage(ctx, pageID, folder, params.title) {
+			return 0, nil
+		}
 	}
 
 	params.folder = folder
@@ -864,6 +1021,31 @@ func (c *Crawler) processPage(
 	return c.writeAndRegister(ctx, startTime, params)
 }
 
+// buildItemParams fetches a page, or a database if the ID is one, and builds writeAndRegisterParams.
+func (c *Crawler) buildItemParams(
+	ctx context.Context, pageID, folder string,
+) (*writeAndRegisterParams, string, error) {
+	// Try to fetch as page first
+	fetchStart := time.Now()
+	page, fetchErr := c.client.GetPage(ctx, pageID)
+	isDatabase := fetchErr != nil && strings.Contains(fetchErr.Error(), "is a database, not a page")
+	if fetchErr != nil && !isDatabase {
+		return nil, folder, fmt.Errorf("fetch page: %w", fetchErr)
+	}
+	if !isDatabase && (page.Archived || page.InTrash) {
+		return nil, folder, fmt.Errorf("page %s: %w", pageID, apperrors.ErrPageArchived)
+	}
+
+	if isDatabase {
+		c.logger.InfoContext(ctx, "detected database, processing as database", notionKeyPageID, pageID)
+		return c.buildDatabaseParams(ctx, pageID, folder, fetchStart)
+	}
+	c.logger.DebugContext(ctx, "fetched page metadata",
+		notionKeyPageID, pageID, "duration_ms", time.Since(fetchStart).Milliseconds())
+	c.enrichUsers(ctx, &page.CreatedBy, &page.LastEditedBy)
+	return c.buildPageParams(ctx, page, pageID, folder, fetchStart)
+}
+
 // buildPageParams fetches blocks and builds writeAndRegisterParams for a page.
 func (c *Crawler) buildPageParams(
 	ctx context.Context, page *notion.Page, pageID, folder string, fetchStart time.Time,
@@ -872,7 +1054,7 @@ func (c *Crawler) buildPageParams(
 
 	fetchBlocksStart := time.Now()
 	maxDepth := getBlockDepthLimit()
-	blockResult, err := c.client.GetAllBlockChildrenWithLimit(ctx, pageID, maxDepth)
+	blockResult, err := c.fetchPageBlocks(ctx, page, pageID, maxDepth)
 	if err != nil {
 		return nil, folder, fmt.Errorf("fetch blocks: %w", err)
 	}
@@ -895,30 +1077,41 @@ func (c *Crawler) buildPageParams(
 	}
 
 	downloadDuration := fetchPageDuration + fetchBlocksDuration
-	children := c.findChildPages(blocks)
+	children := c.findChildPages(ctx, blocks, folder)
+	inlineDatabases := c.fetchInlineDatabases(ctx, blocks)
+	openComments := c.countOpenComments(ctx, pageID)
 
 	return &writeAndRegisterParams{
 		itemID:   pageID,
 		itemType: notionTypePage,
 		title:    page.Title(),
-		convert: func(filePath string, isRoot bool, parentID string) []byte {
-			return c.converter.ConvertWithOptions(page, blocks, &converter.ConvertOptions{
+		convert: func(filePath string, isRoot bool, parentID string, aliases []string) []byte {
+			return c.convertPage(ctx, page, blocks, &converter.ConvertOptions{
 				Folder:           folder,
+				Profile:          GetConfig().profileFor(folder),
 				PageTitle:        page.Title(),
+				Aliases:          aliases,
 				FilePath:         filePath,
 				LastSynced:       time.Now(),
 				NotionType:       notionTypePage,
 				IsRoot:           isRoot,
 				ParentID:         parentID,
 				FileProcessor:    c.makeFileProcessor(ctx, filePath, pageID),
+				AssetProcessor:   c.makeAssetProcessor(ctx, filePath, pageID),
 				SimplifiedDepth:  simplifiedDepth,
 				DownloadDuration: downloadDuration,
+				InlineDatabases:  inlineDatabases,
+				CodeCaptions:     GetConfig().CodeCaptions,
+				HTMLTableColumns: GetConfig().htmlTableColumnsFor(GetConfig().profileFor(folder)),
+				Ancestors:        c.pageAncestors(ctx, parentID, blocks),
+				OpenComments:     openComments,
 			})
 		},
 		lastEdited:       page.LastEditedTime,
 		parent:           page.Parent,
 		downloadDuration: downloadDuration,
 		children:         children,
+		openComments:     openComments,
 	}, folder, nil
 }
 
@@ -938,7 +1131,7 @@ func (c *Crawler) buildDatabaseParams(
 	c.enrichUsers(ctx, &database.CreatedBy, &database.LastEditedBy)
 
 	queryDBStart := time.Now()
-	dbPages, err := c.client.QueryDatabase(ctx, databaseID)
+	dbPages, err := c.client.QueryDatabasePages(ctx, database)
 	queryDBDuration := time.Since(queryDBStart)
 	if err != nil {
 		return nil, folder, fmt.Errorf("query database: %w", err)
@@ -951,31 +1144,45 @@ func (c *Crawler) buildDatabaseParams(
 	dbID := normalizePageID(databaseID)
 	downloadDuration := fetchDBDuration + queryDBDuration
 
-	var children []string
+	rows := make([]childPageRef, 0, len(dbPages))
 	for i := range dbPages {
-		children = append(children, normalizePageID(dbPages[i].ID))
+		rows = append(rows, childPageRef{
+			id:         normalizePageID(dbPages[i].ID),
+			lastEdited: dbPages[i].LastEditedTime,
+			title:      dbPages[i].Title(),
+			archived:   dbPages[i].Archived || dbPages[i].InTrash,
+		})
+	}
+	var children []string
+	for _, row := range c.excludeChildren(ctx, folder, rows) {
+		children = append(children, row.id)
 	}
 
 	return &writeAndRegisterParams{
 		itemID:   dbID,
 		itemType: notionTypeDatabase,
 		title:    database.GetTitle(),
-		convert: func(filePath string, isRoot bool, parentID string) []byte {
-			return c.converter.ConvertDatabase(database, dbPages, &converter.ConvertOptions{
-				Folder:           folder,
-				PageTitle:        database.GetTitle(),
-				FilePath:         filePath,
-				LastSynced:       time.Now(),
-				NotionType:       notionTypeDatabase,
-				IsRoot:           isRoot,
-				ParentID:         parentID,
-				FileProcessor:    c.makeFileProcessor(ctx, filePath, dbID),
-				DownloadDuration: downloadDuration,
+		convert: func(filePath string, isRoot bool, parentID string, aliases []string) []byte {
+			return c.convertDatabase(ctx, database, dbPages, &converter.ConvertOptions{
+				Folder:             folder,
+				Profile:            GetConfig().profileFor(folder),
+				PageTitle:          database.GetTitle(),
+				Aliases:            aliases,
+				FilePath:           filePath,
+				LastSynced:         time.Now(),
+				NotionType:         notionTypeDatabase,
+				IsRoot:             isRoot,
+				ParentID:           parentID,
+				FileProcessor:      c.makeFileProcessor(ctx, filePath, dbID),
+				AssetProcessor:     c.makeAssetProcessor(ctx, filePath, dbID),
+				DownloadDuration:   downloadDuration,
+				DatabaseRenderMode: GetConfig().DatabaseRender,
 			})
 		},
 		lastEdited:       database.LastEditedTime,
 		parent:           database.Parent,
 		downloadDuration: downloadDuration,
 		children:         children,
+		schemaEdited:     database.SchemaEditedTime,
 	}, folder, nil
 }
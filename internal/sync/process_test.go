@@ -5,10 +5,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/shutdown"
 	"github.com/fclairamb/ntnsync/internal/store"
 )
 
@@ -152,3 +154,46 @@ func TestProcessQueue_MaxQueueFiles_DeletedFilesAreCounted(t *testing.T) {
 		t.Errorf("expected 2 remaining queue files (1 should have been processed and deleted), got %d", len(remainingFiles))
 	}
 }
+
+func TestProcessQueue_StopsOnShutdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, controller := shutdown.New(context.Background())
+	defer shutdown.Begin(ctx)()
+	crawler, qm := newTestCrawler(t)
+	entry := queue.Entry{Type: queueTypeInit, Folder: "test", PageIDs: []string{"page1"}}
+	if _, err := qm.CreateEntry(ctx, entry); err != nil {
+		t.Fatalf("failed to create queue entry: %v", err)
+	}
+	controller.Request(time.Hour)
+
+	// No client: any attempt to fetch a page would panic
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if files, err := qm.ListEntries(ctx); err != nil || len(files) != 1 {
+		t.Errorf("ListEntries() = %v, %v, want the queue entry kept", files, err)
+	}
+	if ctx.Err() != nil {
+		t.Error("context canceled while an operation is in progress")
+	}
+}
+
+func TestPageAliases(t *testing.T) {
+	t.Parallel()
+
+	if aliases := pageAliases(nil, "Title", "tech/title.md"); aliases != nil {
+		t.Errorf("pageAliases() of a new page = %v, want none", aliases)
+	}
+
+	existing := &PageRegistry{Title: "Roadmap", FilePath: "tech/roadmap.md"}
+	if aliases := pageAliases(existing, "Roadmap", "tech/roadmap.md"); len(aliases) != 0 {
+		t.Errorf("pageAliases() of an unchanged page = %v, want none", aliases)
+	}
+
+	existing = &PageRegistry{Title: "Roadmap v2", FilePath: "tech/roadmap.md", Aliases: []string{"Roadmap", "Plans"}}
+	got := pageAliases(existing, "Plans", "tech/roadmap.md")
+	if want := []string{"Roadmap", "Roadmap v2"}; !slices.Equal(got, want) {
+		t.Errorf("pageAliases() = %v, want %v (previous titles kept, current one dropped)", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/converter"
+)
+
+// refreshedFrontmatterFields are the frontmatter fields rewritten by a property-only refresh.
+// last_edited is kept, as it is the edit time of the synced content.
+var refreshedFrontmatterFields = []string{"last_synced", "icon", "cover", "notion_url", "properties"}
+
+// shouldSkipPropertyRefresh returns true if a page was synced after the change that queued its
+// property refresh (a property or schema edit), so its frontmatter is already up to date. Refreshes of
+// unknown changes are never skipped.
+func (c *Crawler) shouldSkipPropertyRefresh(ctx context.Context, pageID string, changed time.Time) bool {
+	if changed.IsZero() {
+		return false
+	}
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil || !reg.LastSynced.After(changed) {
+		return false
+	}
+	c.logger.DebugContext(ctx, "skipping property refresh of page synced since the change",
+		notionKeyPageID, pageID,
+		"changed", changed,
+		"last_synced", reg.LastSynced)
+	return true
+}
+
+// refreshPageProperties rewrites the metadata fields of the frontmatter of a synced page, keeping the rest
+// of the file. It only fetches the page metadata, not its blocks, so that property changes (a status, a
+// label) and database schema changes are cheap to apply. Pages that are not synced yet, or whose title
+// changed, get a full sync instead, as well as the pages of folders with the obsidian profile, whose properties
+// are top-level fields that cannot be told apart from the others, and with the html profile, which has no
+// frontmatter.
+func (c *Crawler) refreshPageProperties(ctx context.Context, pageID, folder string) (int, error) {
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		return c.processPage(ctx, pageID, folder, false, "")
+	}
+	if profile := GetConfig().profileFor(reg.Folder); profile == converter.ProfileObsidian ||
+		profile == converter.ProfileHTML {
+		return c.processPage(ctx, pageID, folder, false, reg.ParentID)
+	}
+
+	page, err := c.client.GetPage(ctx, pageID)
+	if err != nil {
+		return 0, fmt.Errorf("fetch page: %w", err)
+	}
+	if page.Archived || page.InTrash {
+		return 0, fmt.Errorf("page %s: %w", pageID, apperrors.ErrPageArchived)
+	}
+	existing, readErr := c.store.Read(ctx, reg.FilePath)
+	if readErr != nil || page.Title() != reg.Title {
+		return c.processPage(ctx, pageID, folder, false, reg.ParentID)
+	}
+
+	// The body was converted from the last synced version: keep its edit time in the frontmatter
+	page.LastEditedTime = reg.LastEdited
+	generated := c.converter.ConvertWithOptions(page, nil, &converter.ConvertOptions{
+		Folder:         reg.Folder,
+		Profile:        GetConfig().profileFor(reg.Folder),
+		PageTitle:      reg.Title,
+		Aliases:        reg.Aliases,
+		FilePath:       reg.FilePath,
+		LastSynced:     time.Now(),
+		NotionType:     notionTypePage,
+		IsRoot:         reg.IsRoot,
+		ParentID:       reg.ParentID,
+		AssetProcessor: c.makeAssetProcessor(ctx, reg.FilePath, reg.ID),
+	})
+
+	content, err := c.replaceFrontmatterFields(existing, generated, refreshedFrontmatterFields)
+	if err != nil {
+		c.logger.WarnContext(ctx, "cannot refresh properties, syncing the whole page",
+			notionKeyPageID, pageID, "error", err)
+		return c.processPage(ctx, pageID, folder, false, reg.ParentID)
+	}
+
+	if err := c.tx.Write(ctx, reg.FilePath, content); err != nil {
+		return 0, fmt.Errorf("write page: %w", err)
+	}
+
+	hash := sha256.Sum256(content)
+	c.addFolderUsage(reg.Folder, 0, int64(len(content))-reg.Size)
+	reg.ContentHash = hex.EncodeToString(hash[:])
+	reg.Size = int64(len(content))
+	reg.LastSynced = time.Now()
+	reg.Assets = c.updateAssetPages(ctx, reg.ID, reg.Assets, false)
+	if err := c.savePageRegistry(ctx, reg); err != nil {
+		c.logger.WarnContext(ctx, "failed to save page registry", "error", err)
+	}
+
+	c.logger.InfoContext(ctx, "refreshed page properties",
+		notionKeyPageID, pageID,
+		notionKeyTitle, reg.Title,
+		"path", reg.FilePath)
+	return 1, nil
+}
+
+// replaceFrontmatterFields replaces the given top-level fields (with their nested lines) of the
+// frontmatter of existing by the ones of generated. Fields missing from generated are removed, and
+// fields missing from existing are appended to its frontmatter.
+func (c *Crawler) replaceFrontmatterFields(existing, generated []byte, keys []string) ([]byte, error) {
+	lines := strings.Split(string(existing), "\n")
+	endIdx, err := c.findFrontmatterEnd(lines)
+	if err != nil {
+		return nil, err
+	}
+	genLines := strings.Split(string(generated), "\n")
+	genEndIdx, err := c.findFrontmatterEnd(genLines)
+	if err != nil {
+		return nil, err
+	}
+
+	frontmatter := lines[1:endIdx]
+	for _, key := range keys {
+		replacement := frontmatterField(genLines[1:genEndIdx], key)
+		start, end := frontmatterFieldRange(frontmatter, key)
+		if start < 0 {
+			start, end = len(frontmatter), len(frontmatter)
+		}
+		frontmatter = slices.Concat(frontmatter[:start], replacement, frontmatter[end:])
+	}
+
+	result := slices.Concat([]string{"---"}, frontmatter, lines[endIdx:])
+	return []byte(strings.Join(result, "\n")), nil
+}
+
+// frontmatterField returns the lines of a top-level frontmatter field, or nil.
+func frontmatterField(frontmatter []string, key string) []string {
+	start, end := frontmatterFieldRange(frontmatter, key)
+	if start < 0 {
+		return nil
+	}
+	return frontmatter[start:end]
+}
+
+// frontmatterFieldRange returns the range of lines of a top-level frontmatter field, including its
+// indented nested lines. Returns -1, -1 if the field is missing.
+func frontmatterFieldRange(frontmatter []string, key string) (int, int) {
+	for start, line := range frontmatter {
+		if line != key+":" && !strings.HasPrefix(line, key+": ") {
+			continue
+		}
+		end := start + 1
+		for end < len(frontmatter) && strings.HasPrefix(frontmatter[end], " ") {
+			end++
+		}
+		return start, end
+	}
+	return -1, -1
+}
@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestReplaceFrontmatterFields(t *testing.T) {
+	t.Parallel()
+
+	crawler, _ := newTestCrawler(t)
+	existing := "---\nnotion_id: row\nlast_synced: 2026-01-01T00:00:00Z\nsimplified_depth: 3\n" +
+		"properties:\n  Status: \"Todo\"\n---\n\n# Row\n\nBody\n"
+	generated := "---\nnotion_id: row\nlast_synced: 2026-02-01T00:00:00Z\n" +
+		"properties:\n  Priority: \"High\"\n  Status: \"Doing\"\n---\n\n# Row\n\n"
+
+	content, err := crawler.replaceFrontmatterFields([]byte(existing), []byte(generated),
+		[]string{"last_synced", "properties"})
+	if err != nil {
+		t.Fatalf("replaceFrontmatterFields() error = %v", err)
+	}
+	want := "---\nnotion_id: row\nlast_synced: 2026-02-01T00:00:00Z\nsimplified_depth: 3\n" +
+		"properties:\n  Priority: \"High\"\n  Status: \"Doing\"\n---\n\n# Row\n\nBody\n"
+	if string(content) != want {
+		t.Errorf("content =\n%s\nwant\n%s", content, want)
+	}
+
+	// Fields missing from the generated frontmatter are removed
+	content, err = crawler.replaceFrontmatterFields([]byte(existing), []byte("---\nnotion_id: row\n---\n"),
+		[]string{"properties"})
+	if err != nil {
+		t.Fatalf("replaceFrontmatterFields() error = %v", err)
+	}
+	if strings.Contains(string(content), "Status") || !strings.HasSuffix(string(content), "---\n\n# Row\n\nBody\n") {
+		t.Errorf("content = %q, want properties removed and body kept", content)
+	}
+}
+
+func TestRefreshPageProperties(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var blockRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pages/page" {
+			blockRequests.Add(1)
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(notion.Page{
+			Object:         "page",
+			ID:             "page",
+			LastEditedTime: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			URL:            "https://www.notion.so/Page-page",
+			Icon:           &notion.Icon{Type: "emoji", Emoji: "🚀"},
+			Parent:         notion.Parent{Type: "page_id", PageID: "parent"},
+			Properties: notion.Properties{
+				"title": {Type: "title", Title: []notion.RichText{{PlainText: "Page"}}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	synced := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldContent := "---\nnotion_id: page\nlast_edited: 2026-01-01T00:00:00Z\nlast_synced: 2026-01-01T00:00:00Z\n" +
+		"icon: \"emoji:📝\"\nnotion_parent_id: parent\nnotion_url: https://www.notion.so/Page-page\n---\n\n" +
+		"# Page\n\nBody\n"
+	if err := crawler.tx.Write(ctx, "test/page.md", []byte(oldContent)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{
+		ID: "page", Type: notionTypePage, Folder: "test", FilePath: "test/page.md", Title: "Page",
+		LastEdited: synced, LastSynced: synced, ParentID: "parent",
+	}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	if _, err := crawler.queueManager.CreateWebhookEntryWithType(ctx, "page", "test", queue.TypeProperties); err != nil {
+		t.Fatalf("CreateWebhookEntryWithType() error = %v", err)
+	}
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+
+	content, err := crawler.store.Read(ctx, "test/page.md")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(content), "icon: \"emoji:🚀\"") ||
+		!strings.Contains(string(content), "last_edited: 2026-01-01T00:00:00Z") ||
+		!strings.HasSuffix(string(content), "# Page\n\nBody\n") {
+		t.Errorf("content =\n%s\nwant refreshed icon and unchanged body", content)
+	}
+	if blockRequests.Load() != 0 {
+		t.Errorf("made %d requests besides the page metadata, want none", blockRequests.Load())
+	}
+
+	// A page synced since the change is not refreshed again
+	reg, err := crawler.loadPageRegistry(ctx, "page")
+	if err != nil {
+		t.Fatalf("loadPageRegistry() error = %v", err)
+	}
+	if !crawler.shouldSkipPropertyRefresh(ctx, "page", reg.LastSynced.Add(-time.Second)) {
+		t.Error("shouldSkipPropertyRefresh() = false for a page synced since the change, want true")
+	}
+}
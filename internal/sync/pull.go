@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/converter"
 	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
 	"github.com/fclairamb/ntnsync/internal/version"
@@ -23,14 +25,53 @@ type PullOptions struct {
 	Verbose  bool          // Show detailed output
 }
 
+// Reasons for skipping pages during a pull.
+const (
+	// SkipReasonUnchanged is for pages not edited since the cutoff time.
+	SkipReasonUnchanged = "unchanged"
+	// SkipReasonUntracked is for pages not tracked yet, when untracked pages are not included.
+	SkipReasonUntracked = "untracked"
+	// SkipReasonNoEnabledRoot is for pages not under any root of root.md, or under a disabled one.
+	SkipReasonNoEnabledRoot = "not_under_enabled_root"
+	// SkipReasonFolderFilter is for pages of another folder than the requested one.
+	SkipReasonFolderFilter = "folder_filter"
+	// SkipReasonPermanentError is for pages blocked after a permanent error, and not edited since.
+	SkipReasonPermanentError = "permanent_error"
+	// SkipReasonPruned is for pages pruned to keep the mirror under its size cap, and not edited since.
+	SkipReasonPruned = "pruned"
+	// SkipReasonTraceError is for new pages whose parent chain could not be traced.
+	SkipReasonTraceError = "trace_error"
+	// SkipReasonTeamspace is for new pages of a teamspace not allowed by NTN_PULL_TEAMSPACES.
+	SkipReasonTeamspace = "teamspace_filter"
+)
+
+// PullSkipReasons lists the reasons for skipping pages during a pull, in display order.
+var PullSkipReasons = []string{
+	SkipReasonUnchanged,
+	SkipReasonUntracked,
+	SkipReasonNoEnabledRoot,
+	SkipReasonFolderFilter,
+	SkipReasonPermanentError,
+	SkipReasonPruned,
+	SkipReasonTraceError,
+	SkipReasonTeamspace,
+}
+
 // PullResult contains the result of a pull operation.
 type PullResult struct {
-	PagesFound   int
-	PagesQueued  int
-	PagesSkipped int
-	NewPages     int
-	UpdatedPages int
-	CutoffTime   time.Time
+	PagesFound      int            `json:"pages_found"`
+	PagesQueued     int            `json:"pages_queued"`
+	PagesSkipped    int            `json:"pages_skipped"`
+	SkippedByReason map[string]int `json:"skipped_by_reason"` // Skipped pages by reason (SkipReason* constants)
+	NewPages        int            `json:"new_pages"`
+	UpdatedPages    int            `json:"updated_pages"`
+	CutoffTime      time.Time      `json:"cutoff_time"`
+}
+
+// skip counts a skipped page.
+func (r *PullResult) skip(reason string) {
+	r.PagesSkipped++
+	r.SkippedByReason[reason]++
 }
 
 // Pull fetches all pages changed since the last pull and queues them for sync.
@@ -106,12 +147,14 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 	c.logger.InfoContext(ctx, "search complete", "pages_found", len(allPages))
 
 	result := &PullResult{
-		PagesFound: len(allPages),
-		CutoffTime: cutoffTime,
+		PagesFound:      len(allPages),
+		CutoffTime:      cutoffTime,
+		SkippedByReason: make(map[string]int),
 	}
 
 	// Group pages by folder and filter by changes
 	pagesToQueue := make(map[string][]queue.Page) // folder -> []queue.Page
+	teamspaces := make(map[string]string)         // page ID -> teamspace ID, for new pages
 	var oldestPageSeen *time.Time
 	pagesQueued := 0
 
@@ -129,7 +172,7 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 
 		// Check if page was edited after cutoff
 		if !page.LastEditedTime.After(cutoffTime) {
-			result.PagesSkipped++
+			result.skip(SkipReasonUnchanged)
 			continue
 		}
 
@@ -148,7 +191,18 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 			c.logger.DebugContext(ctx, "skipping untracked page",
 				"page_id", pageID,
 				"title", page.Title())
-			result.PagesSkipped++
+			result.skip(SkipReasonUntracked)
+			continue
+		}
+
+		// Skip blocked pages, unless they were edited since
+		switch reason := c.blockedSinceEdit(ctx, pageID, page.LastEditedTime); reason {
+		case "":
+		case blockedReasonPruned:
+			result.skip(SkipReasonPruned)
+			continue
+		default:
+			result.skip(SkipReasonPermanentError)
 			continue
 		}
 
@@ -159,7 +213,7 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 				c.logger.DebugContext(ctx, "skipping page with disabled root",
 					"page_id", pageID,
 					"root_id", rootID)
-				result.PagesSkipped++
+				result.skip(SkipReasonNoEnabledRoot)
 				continue
 			}
 		}
@@ -177,7 +231,7 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 					"page_id", pageID,
 					"title", page.Title(),
 					"error", err)
-				result.PagesSkipped++
+				result.skip(SkipReasonTraceError)
 				continue
 			}
 
@@ -186,7 +240,12 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 				c.logger.DebugContext(ctx, "skipping page not under any root",
 					"page_id", pageID,
 					"title", page.Title())
-				result.PagesSkipped++
+				result.skip(SkipReasonNoEnabledRoot)
+				continue
+			}
+
+			if reason := c.teamspaceSkipReason(ctx, page, parentChain, teamspaces); reason != "" {
+				result.skip(reason)
 				continue
 			}
 
@@ -205,7 +264,7 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 			c.logger.DebugContext(ctx, "skipping page in different folder",
 				"page_id", pageID,
 				"folder", folder)
-			result.PagesSkipped++
+			result.skip(SkipReasonFolderFilter)
 			continue
 		}
 
@@ -245,12 +304,42 @@ func (c *Crawler) Pull(ctx context.Context, opts PullOptions) (*PullResult, erro
 		"pages_found", result.PagesFound,
 		"pages_queued", result.PagesQueued,
 		"pages_skipped", result.PagesSkipped,
+		"skipped_by_reason", result.SkippedByReason,
 		"new_pages", result.NewPages,
 		"updated_pages", result.UpdatedPages)
 
 	return result, nil
 }
 
+// teamspaceSkipReason returns why a new page is not discovered because of its teamspace, or an empty string if
+// it is. Its missing parents, fetched while tracing its parent chain, share its teamspace: the walk starts from
+// the top of the chain.
+func (c *Crawler) teamspaceSkipReason(
+	ctx context.Context, page *notion.Page, missingParents []*notion.Page, teamspaces map[string]string,
+) string {
+	cfg := GetConfig()
+	if len(cfg.PullTeamspaces) == 0 {
+		return ""
+	}
+
+	teamspace, err := c.pageTeamspace(ctx, topOfChain(page, missingParents), teamspaces)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to find the teamspace of a new page, skipping",
+			"page_id", normalizePageID(page.ID),
+			"title", page.Title(),
+			"error", err)
+		return SkipReasonTraceError
+	}
+	if !cfg.teamspaceAllowed(teamspace) {
+		c.logger.DebugContext(ctx, "skipping page of a teamspace not pulled",
+			"page_id", normalizePageID(page.ID),
+			"title", page.Title(),
+			"teamspace", cmp.Or(teamspace, teamspacePrivate))
+		return SkipReasonTeamspace
+	}
+	return ""
+}
+
 // countPagesToQueue counts the total number of pages to be queued.
 func (c *Crawler) countPagesToQueue(pagesToQueue map[string][]queue.Page) int {
 	total := 0
@@ -316,6 +405,8 @@ func (c *Crawler) queuePagesForPull(
 
 // loadState loads the state from disk.
 func (c *Crawler) loadState(ctx context.Context) error {
+	defer c.initFilenameRules(ctx)
+
 	path := filepath.Join(stateDir, stateFile)
 	data, err := c.store.Read(ctx, path)
 	if err != nil {
@@ -332,6 +423,27 @@ func (c *Crawler) loadState(ctx context.Context) error {
 	return nil
 }
 
+// initFilenameRules sets the filename rules from the state, recording them on first use.
+// Mirrors created before rules were recorded keep the default rules, so that existing
+// files are never renamed by a configuration change.
+func (c *Crawler) initFilenameRules(ctx context.Context) {
+	configured := GetConfig().FilenameRules
+
+	if c.state.FilenameRules == nil {
+		rules := configured
+		if len(c.state.Folders) > 0 {
+			rules = converter.DefaultFilenameRules()
+		}
+		c.state.FilenameRules = &rules
+	} else if !c.state.FilenameRules.Equal(configured) {
+		c.logger.WarnContext(ctx, "filename rules differ from the ones recorded in state, keeping recorded rules",
+			"recorded", *c.state.FilenameRules,
+			"configured", configured)
+	}
+
+	c.converter.FilenameRules = *c.state.FilenameRules
+}
+
 // saveState saves the state to disk.
 func (c *Crawler) saveState(ctx context.Context) error {
 	// Always update version to current version when saving
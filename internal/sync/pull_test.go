@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestInitFilenameRules(t *testing.T) {
+	// Cannot use t.Parallel() with t.Setenv
+	t.Setenv("NTN_FILENAME_SEPARATOR", "_")
+	t.Setenv("NTN_FILENAME_STOPWORDS", "The, of")
+	ResetConfig()
+	defer ResetConfig()
+
+	ctx := context.Background()
+	configured := converter.FilenameRules{
+		Case:      converter.FilenameCaseLower,
+		Separator: "_",
+		MaxLength: converter.DefaultFilenameRules().MaxLength,
+		Stopwords: []string{"the", "of"},
+	}
+
+	t.Run("new mirror records configured rules", func(t *testing.T) {
+		crawler, _ := newTestCrawler(t)
+
+		if err := crawler.loadState(ctx); err == nil {
+			t.Fatal("expected no state file")
+		}
+		if !crawler.state.FilenameRules.Equal(configured) {
+			t.Errorf("recorded rules = %+v, want %+v", *crawler.state.FilenameRules, configured)
+		}
+		if got := crawler.converter.FilenameRules.Sanitize("The Lord of the Rings"); got != "lord_rings" {
+			t.Errorf("Sanitize() = %q, want %q", got, "lord_rings")
+		}
+	})
+
+	t.Run("existing mirror without recorded rules keeps defaults", func(t *testing.T) {
+		crawler, _ := newTestCrawler(t)
+		crawler.state.Folders = []string{"test"}
+		if err := crawler.saveState(ctx); err != nil {
+			t.Fatalf("saveState() error = %v", err)
+		}
+
+		if err := crawler.loadState(ctx); err != nil {
+			t.Fatalf("loadState() error = %v", err)
+		}
+		if !crawler.state.FilenameRules.Equal(converter.DefaultFilenameRules()) {
+			t.Errorf("recorded rules = %+v, want defaults", *crawler.state.FilenameRules)
+		}
+	})
+
+	t.Run("recorded rules win over configuration", func(t *testing.T) {
+		crawler, _ := newTestCrawler(t)
+		recorded := converter.FilenameRules{Case: converter.FilenameCasePreserve}
+		crawler.state.FilenameRules = &recorded
+		if err := crawler.saveState(ctx); err != nil {
+			t.Fatalf("saveState() error = %v", err)
+		}
+
+		if err := crawler.loadState(ctx); err != nil {
+			t.Fatalf("loadState() error = %v", err)
+		}
+		if got := crawler.converter.FilenameRules.Sanitize("My Page"); got != "My-Page" {
+			t.Errorf("Sanitize() = %q, want %q", got, "My-Page")
+		}
+	})
+}
+
+func TestPull_SkipReasons(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	recent, old := now.Add(-10*time.Minute), now.Add(-2*time.Hour)
+	pages := []notion.Page{
+		{Object: "page", ID: "queued", LastEditedTime: recent},
+		{Object: "page", ID: "other", LastEditedTime: recent},
+		{Object: "page", ID: "failed", LastEditedTime: recent},
+		{Object: "page", ID: "pruned", LastEditedTime: recent},
+		{Object: "page", ID: "disabled", LastEditedTime: recent},
+		{Object: "page", ID: "untracked", LastEditedTime: recent},
+		{Object: "page", ID: "old", LastEditedTime: old},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(notion.SearchResponse{Object: "list", Results: pages})
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+	registries := []*PageRegistry{
+		{ID: "roota", Folder: "test", IsRoot: true, Enabled: true},
+		{ID: "rootb", Folder: "other", IsRoot: true, Enabled: true},
+		{ID: "rootc", Folder: "test", IsRoot: true},
+		{ID: "queued", Folder: "test", ParentID: "roota"},
+		{ID: "other", Folder: "other", ParentID: "rootb"},
+		{ID: "failed", Folder: "test", ParentID: "roota"},
+		{ID: "pruned", Folder: "test", ParentID: "roota"},
+		{ID: "disabled", Folder: "test", ParentID: "rootc"},
+		{ID: "old", Folder: "test", ParentID: "roota"},
+	}
+	for _, reg := range registries {
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+	crawler.markPageBlocked(ctx, "failed", "test", blockedReasonPermanentError, "", nil)
+	crawler.markPageBlocked(ctx, "pruned", "test", blockedReasonPruned, "", nil)
+
+	result, err := crawler.Pull(ctx, PullOptions{Folder: "test", Since: time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	want := map[string]int{
+		SkipReasonUnchanged:      1,
+		SkipReasonUntracked:      1,
+		SkipReasonNoEnabledRoot:  1,
+		SkipReasonFolderFilter:   1,
+		SkipReasonPermanentError: 1,
+		SkipReasonPruned:         1,
+	}
+	if !maps.Equal(result.SkippedByReason, want) {
+		t.Errorf("SkippedByReason = %v, want %v", result.SkippedByReason, want)
+	}
+	if result.PagesSkipped != 6 || result.PagesQueued != 1 {
+		t.Errorf("skipped/queued = %d/%d, want 6/1", result.PagesSkipped, result.PagesQueued)
+	}
+}
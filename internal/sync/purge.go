@@ -0,0 +1,205 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/converter"
+)
+
+// blockedReasonPurged is recorded for pages purged from the mirror. Unlike other blocked pages,
+// they are not synced again when they are edited.
+const blockedReasonPurged = "purged"
+
+// PurgeResult describes a page purged from the mirror.
+type PurgeResult struct {
+	Page *PageRegistry
+	// Paths held the content of the page: its current and former files, their downloaded files, the assets
+	// only it uses, and its registries. A history rewrite removes them from every commit.
+	Paths []string
+	// Deleted are the files deleted from the mirror.
+	Deleted []string
+}
+
+// Purge deletes a page from the mirror: its file, its downloaded files (and the assets only it uses) and its
+// registries. The page is removed from the registries of the assets it shares with other pages, and recorded as
+// blocked, so that it is never synced again. Child pages are not purged.
+// The returned paths can be removed from the git history afterwards.
+func (c *Crawler) Purge(ctx context.Context, pageID string, dryRun bool) (*PurgeResult, error) {
+	pageID = normalizePageID(pageID)
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrPageNotSynced, pageID)
+	}
+	if reg.IsRoot {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrPurgeRootPage, pageID)
+	}
+
+	result := &PurgeResult{Page: reg}
+	var filesDirs []string
+	for _, filePath := range purgeFilePaths(reg) {
+		filesDir := filepath.Join(converter.TrimPageExt(filePath), "files")
+		filesDirs = append(filesDirs, filesDir)
+		result.Paths = append(result.Paths, filePath, filesDir)
+	}
+	result.Paths = append(result.Paths, filepath.Join(stateDir, idsDir, fmt.Sprintf("page-%s.json", pageID)),
+		blockCachePath(pageID))
+	files, err := c.purgedFiles(ctx, pageID, filesDirs)
+	if err != nil {
+		return nil, fmt.Errorf("list file registries: %w", err)
+	}
+	result.Paths = append(result.Paths, files.paths...)
+
+	result.Deleted, err = c.existingFiles(ctx, result.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.WarnContext(ctx, "purging page",
+		notionKeyPageID, pageID,
+		"file_path", reg.FilePath,
+		"files", len(result.Deleted),
+		"dry_run", dryRun)
+	if dryRun {
+		return result, nil
+	}
+
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+	for _, path := range result.Deleted {
+		if err := c.deleteFile(ctx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.detachFromAssets(ctx, pageID, files.shared); err != nil {
+		return nil, err
+	}
+	if err := c.detachFromParent(ctx, reg); err != nil {
+		return nil, err
+	}
+
+	c.markPageBlocked(ctx, pageID, reg.Folder, blockedReasonPurged, "", nil)
+	c.addFolderUsage(reg.Folder, -1, -reg.Size)
+	return result, nil
+}
+
+// detachFromParent removes a page from the children of its parent's registry, if it has a synced parent.
+func (c *Crawler) detachFromParent(ctx context.Context, reg *PageRegistry) error {
+	if reg.ParentID == "" {
+		return nil
+	}
+	parent, err := c.loadPageRegistry(ctx, reg.ParentID)
+	if err != nil {
+		return nil //nolint:nilerr // Parent not synced, nothing to detach from
+	}
+	pageID := normalizePageID(reg.ID)
+	parent.Children = slices.DeleteFunc(parent.Children, func(id string) bool {
+		return normalizePageID(id) == pageID
+	})
+	if err := c.savePageRegistry(ctx, parent); err != nil {
+		return fmt.Errorf("update parent registry: %w", err)
+	}
+	return nil
+}
+
+// detachFromAssets removes a page from the registries of the assets it shares with other pages.
+func (c *Crawler) detachFromAssets(ctx context.Context, pageID string, shared []*FileRegistry) error {
+	for _, reg := range shared {
+		reg.PageIDs = slices.DeleteFunc(reg.PageIDs, func(id string) bool { return normalizePageID(id) == pageID })
+		if err := c.saveFileRegistry(ctx, reg); err != nil {
+			return fmt.Errorf("update file registry: %w", err)
+		}
+	}
+	return nil
+}
+
+// purgeFilePaths returns the current and former file paths of a page.
+func purgeFilePaths(reg *PageRegistry) []string {
+	var paths []string
+	for _, path := range append(slices.Clone(reg.Aliases), reg.FilePath) {
+		// Aliases also hold former titles
+		if converter.IsPageFile(path) && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// purgedFiles are the downloaded files of a purged page.
+type purgedFiles struct {
+	// paths are the registries of the files in the page's files directories, and the assets only the page uses
+	// with their registries.
+	paths []string
+	// shared are the registries of the assets other pages use too: the page is removed from them.
+	shared []*FileRegistry
+}
+
+// purgedFiles returns the downloaded files of a page: the registries of the files stored in the given
+// directories, and the assets of the assets directory the page uses. An asset is purged when its registry only
+// lists the page, and no other registry references the same file (assets are named after their content).
+func (c *Crawler) purgedFiles(ctx context.Context, pageID string, dirs []string) (*purgedFiles, error) {
+	registries, err := c.listFileRegistries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := &purgedFiles{}
+	isPage := func(id string) bool { return normalizePageID(id) == pageID }
+	isOtherPage := func(id string) bool { return !isPage(id) }
+	var assets []string
+	kept := make(map[string]bool)
+	for _, reg := range registries {
+		registryPath := filepath.Join(stateDir, idsDir, fmt.Sprintf("file-%s.json", reg.ID))
+		switch {
+		case slices.Contains(dirs, filepath.Dir(reg.FilePath)):
+			files.paths = append(files.paths, registryPath)
+		case filepath.Dir(reg.FilePath) != assetsDir:
+			continue // Files of other pages
+		case len(reg.PageIDs) > 0 && !slices.ContainsFunc(reg.PageIDs, isOtherPage):
+			files.paths = append(files.paths, registryPath)
+			assets = append(assets, reg.FilePath)
+		case slices.ContainsFunc(reg.PageIDs, isPage):
+			files.shared = append(files.shared, reg)
+			kept[reg.FilePath] = true
+		default:
+			kept[reg.FilePath] = true
+		}
+	}
+	for _, asset := range assets {
+		if !kept[asset] && !slices.Contains(files.paths, asset) {
+			files.paths = append(files.paths, asset)
+		}
+	}
+	return files, nil
+}
+
+// existingFiles returns the files of the mirror among the given paths, listing directories.
+func (c *Crawler) existingFiles(ctx context.Context, paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		exists, err := c.store.Exists(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", path, err)
+		}
+		if !exists {
+			continue
+		}
+
+		entries, err := c.store.List(ctx, path)
+		if err != nil || len(entries) == 0 {
+			files = append(files, path) // Not a directory
+			continue
+		}
+		for i := range entries {
+			if !entries[i].IsDir {
+				files = append(files, entries[i].Path)
+			}
+		}
+	}
+	return files, nil
+}
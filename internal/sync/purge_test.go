@@ -0,0 +1,167 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestPurge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	registries := []*PageRegistry{
+		{ID: "root", Folder: "tech", FilePath: "tech/root.md", IsRoot: true, Children: []string{"secret", "public"}},
+		{
+			ID: "secret", Folder: "tech", ParentID: "root", FilePath: "tech/root/secret-v2.md",
+			Aliases: []string{"Secret", "tech/root/secret.md"},
+		},
+		{ID: "public", Folder: "tech", ParentID: "root", FilePath: "tech/root/public.md"},
+	}
+	for _, reg := range registries {
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"tech/root/secret-v2.md":            "secret",
+		"tech/root/secret-v2/files/a.png":   "image",
+		"tech/root/public.md":               "public",
+		"tech/root/public/files/b.png":      "image",
+		".notion-sync/ids/file-a.json":      `{"id":"a","file_path":"tech/root/secret-v2/files/a.png"}`,
+		".notion-sync/ids/file-old.json":    `{"id":"old","file_path":"tech/root/secret/files/old.png"}`,
+		".notion-sync/ids/file-b.json":      `{"id":"b","file_path":"tech/root/public/files/b.png"}`,
+		"tech/root/secret-v2/child-page.md": "child",
+	}
+	for path, content := range files {
+		if err := crawler.tx.Write(ctx, path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := crawler.Purge(ctx, "root", false); !errors.Is(err, apperrors.ErrPurgeRootPage) {
+		t.Errorf("Purge(root) error = %v, want ErrPurgeRootPage", err)
+	}
+	if _, err := crawler.Purge(ctx, "unknown", false); !errors.Is(err, apperrors.ErrPageNotSynced) {
+		t.Errorf("Purge(unknown) error = %v, want ErrPageNotSynced", err)
+	}
+
+	dryRun, err := crawler.Purge(ctx, "secret", true)
+	if err != nil {
+		t.Fatalf("Purge(dry run) error = %v", err)
+	}
+	wantPaths := []string{
+		"tech/root/secret.md", "tech/root/secret/files",
+		"tech/root/secret-v2.md", "tech/root/secret-v2/files",
+		".notion-sync/ids/page-secret.json", ".notion-sync/ids/blocks-secret.json",
+		".notion-sync/ids/file-a.json", ".notion-sync/ids/file-old.json",
+	}
+	slices.Sort(wantPaths)
+	gotPaths := slices.Sorted(slices.Values(dryRun.Paths))
+	if !slices.Equal(gotPaths, wantPaths) {
+		t.Errorf("Paths = %v, want %v", gotPaths, wantPaths)
+	}
+	wantDeleted := []string{
+		".notion-sync/ids/file-a.json", ".notion-sync/ids/file-old.json", ".notion-sync/ids/page-secret.json",
+		"tech/root/secret-v2.md", "tech/root/secret-v2/files/a.png",
+	}
+	if got := slices.Sorted(slices.Values(dryRun.Deleted)); !slices.Equal(got, wantDeleted) {
+		t.Errorf("Deleted = %v, want %v", got, wantDeleted)
+	}
+	if exists, _ := crawler.store.Exists(ctx, "tech/root/secret-v2.md"); !exists {
+		t.Error("dry run deleted the page file")
+	}
+
+	if _, err := crawler.Purge(ctx, "secret", false); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	for _, path := range wantDeleted {
+		if exists, _ := crawler.store.Exists(ctx, path); exists {
+			t.Errorf("%s was not deleted", path)
+		}
+	}
+	for _, path := range []string{"tech/root/public.md", ".notion-sync/ids/file-b.json", "tech/root/secret-v2/child-page.md"} {
+		if exists, _ := crawler.store.Exists(ctx, path); !exists {
+			t.Errorf("%s was deleted", path)
+		}
+	}
+
+	root, err := crawler.loadPageRegistry(ctx, "root")
+	if err != nil || !slices.Equal(root.Children, []string{"public"}) {
+		t.Errorf("root children = %v, %v", root.Children, err)
+	}
+
+	// A purged page is not synced again, even when edited
+	if !crawler.shouldSkipBlockedPage(ctx, "secret", "tech", "root", time.Now().Add(time.Hour)) {
+		t.Error("purged page edited later should still be skipped")
+	}
+}
+
+func TestPurge_Assets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	for _, reg := range []*PageRegistry{
+		{ID: "secret", Folder: "tech", FilePath: "tech/secret.md"},
+		{ID: "public", Folder: "tech", FilePath: "tech/public.md"},
+	} {
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"tech/secret.md":   "secret",
+		"tech/public.md":   "public",
+		"assets/own.png":   "own",
+		"assets/both.png":  "both",
+		"assets/copy.png":  "copy",
+		"assets/other.png": "other",
+	}
+	for path, content := range files {
+		if err := crawler.tx.Write(ctx, path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, reg := range []*FileRegistry{
+		{ID: "own", FilePath: "assets/own.png", PageIDs: []string{"secret"}},
+		{ID: "both", FilePath: "assets/both.png", PageIDs: []string{"public", "secret"}},
+		// Same content as a file of another page
+		{ID: "copy-secret", FilePath: "assets/copy.png", PageIDs: []string{"secret"}},
+		{ID: "copy-public", FilePath: "assets/copy.png", PageIDs: []string{"public"}},
+		{ID: "other", FilePath: "assets/other.png", PageIDs: []string{"public"}},
+	} {
+		if err := crawler.saveFileRegistry(ctx, reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := crawler.Purge(ctx, "secret", false)
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	wantDeleted := []string{
+		".notion-sync/ids/file-copy-secret.json", ".notion-sync/ids/file-own.json",
+		".notion-sync/ids/page-secret.json", "assets/own.png", "tech/secret.md",
+	}
+	if got := slices.Sorted(slices.Values(result.Deleted)); !slices.Equal(got, wantDeleted) {
+		t.Errorf("Deleted = %v, want %v", got, wantDeleted)
+	}
+	for _, path := range []string{"assets/both.png", "assets/copy.png", "assets/other.png"} {
+		if exists, _ := crawler.store.Exists(ctx, path); !exists {
+			t.Errorf("%s was deleted", path)
+		}
+	}
+
+	both, err := crawler.loadFileRegistry(ctx, "both")
+	if err != nil || !slices.Equal(both.PageIDs, []string{"public"}) {
+		t.Errorf("shared asset pages = %v, %v, want [public]", both.PageIDs, err)
+	}
+}
@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// PushNotification is posted to NTN_PUSH_NOTIFY_URL after a push, so that downstream jobs (site build,
+// search indexing) know precisely what changed.
+type PushNotification struct {
+	Event    string    `json:"event"`
+	Commit   string    `json:"commit,omitempty"`
+	Paths    []string  `json:"paths"`
+	PageIDs  []string  `json:"page_ids"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// NotifyPush posts the files committed since the last notification to NTN_PUSH_NOTIFY_URL. It must be
+// called after a successful push. If the notification fails, its files are part of the next one.
+func (c *Crawler) NotifyPush(ctx context.Context) error {
+	cfg := GetConfig()
+	if cfg.PushNotifyURL == "" {
+		return nil
+	}
+
+	committed := c.pending.takeCommitted()
+	if len(committed) == 0 {
+		return nil
+	}
+
+	notification := newPushNotification(committed)
+	if reader, ok := c.store.(store.HeadReader); ok {
+		commit, err := reader.HeadCommit()
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to get head commit", "error", err)
+		}
+		notification.Commit = commit
+	}
+
+	body, err := json.Marshal(notification)
+	if err == nil {
+		err = postNotification(ctx, cfg.PushNotifyURL, cfg.PushNotifySecret, body)
+	}
+	if err != nil {
+		c.pending.restoreCommitted(committed)
+		return fmt.Errorf("notify push: %w", err)
+	}
+
+	c.logger.InfoContext(ctx, "notified push",
+		"commit", notification.Commit,
+		"paths", len(notification.Paths),
+		"pages", len(notification.PageIDs))
+	return nil
+}
+
+// newPushNotification describes committed files: the changed content files, and the pages whose
+// registry changed.
+func newPushNotification(committed []string) *PushNotification {
+	notification := &PushNotification{
+		Event:    "push",
+		Paths:    []string{},
+		PageIDs:  []string{},
+		PushedAt: time.Now(),
+	}
+	for _, path := range committed {
+		path = filepath.ToSlash(path)
+		if pageID, ok := strings.CutPrefix(path, stateDir+"/"+idsDir+"/page-"); ok {
+			notification.PageIDs = append(notification.PageIDs, strings.TrimSuffix(pageID, ".json"))
+			continue
+		}
+		if !strings.HasPrefix(path, stateDir+"/") {
+			notification.Paths = append(notification.Paths, path)
+		}
+	}
+	return notification
+}
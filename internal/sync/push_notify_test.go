@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestNewPushNotification(t *testing.T) {
+	t.Parallel()
+
+	notification := newPushNotification([]string{
+		".notion-sync/ids/page-abc123.json",
+		".notion-sync/queue/00000001.json",
+		".notion-sync/state.json",
+		"assets/0123456789abcdef.png",
+		"tech/a.md",
+	})
+
+	if want := []string{"assets/0123456789abcdef.png", "tech/a.md"}; !slices.Equal(notification.Paths, want) {
+		t.Errorf("Paths = %v, want %v", notification.Paths, want)
+	}
+	if want := []string{"abc123"}; !slices.Equal(notification.PageIDs, want) {
+		t.Errorf("PageIDs = %v, want %v", notification.PageIDs, want)
+	}
+}
+
+func TestCrawler_NotifyPush(t *testing.T) {
+	// Cannot use t.Parallel() with t.Setenv
+	status := http.StatusInternalServerError
+	var notifications []PushNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read notification: %v", err)
+		}
+		timestamp := r.Header.Get(notifyTimestampHeader)
+		if got, want := r.Header.Get(notifySignatureHeader), signNotification("s3cret", timestamp, body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		var notification PushNotification
+		if err := json.Unmarshal(body, &notification); err != nil {
+			t.Errorf("invalid notification: %v", err)
+		}
+		notifications = append(notifications, notification)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	t.Setenv("NTN_PUSH_NOTIFY_URL", server.URL)
+	t.Setenv("NTN_PUSH_NOTIFY_SECRET", "s3cret")
+	ResetConfig()
+	defer ResetConfig()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	// Nothing committed: nothing to notify
+	if err := crawler.NotifyPush(ctx); err != nil || len(notifications) != 0 {
+		t.Fatalf("NotifyPush() = %v with %d notifications, want none", err, len(notifications))
+	}
+
+	if err := crawler.tx.Write(ctx, "tech/a.md", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.CommitChanges(ctx, "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed notification keeps its files for the next one
+	if err := crawler.NotifyPush(ctx); err == nil {
+		t.Fatal("NotifyPush() should fail when the endpoint fails")
+	}
+	if err := crawler.tx.Write(ctx, "tech/b.md", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.CommitChanges(ctx, "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	status = http.StatusNoContent
+	if err := crawler.NotifyPush(ctx); err != nil {
+		t.Fatalf("NotifyPush() error = %v", err)
+	}
+	if err := crawler.NotifyPush(ctx); err != nil {
+		t.Fatalf("NotifyPush() error = %v", err)
+	}
+
+	if len(notifications) != 2 {
+		t.Fatalf("got %d notifications, want 2", len(notifications))
+	}
+	if got, want := notifications[1].Paths, []string{"tech/a.md", "tech/b.md"}; !slices.Equal(got, want) {
+		t.Errorf("Paths = %v, want %v", got, want)
+	}
+	if notifications[1].Event != "push" {
+		t.Errorf("Event = %q, want push", notifications[1].Event)
+	}
+}
@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+const (
+	// queueDelayAuto is the NTN_QUEUE_DELAY value adapting the delay between queue files to the API throttling.
+	queueDelayAuto = "auto"
+
+	// minAutoQueueDelay is the shortest delay after a rate limit response, and below which the delay is dropped.
+	minAutoQueueDelay = time.Second
+	// maxAutoQueueDelay caps the delay between queue files.
+	maxAutoQueueDelay = time.Minute
+	// slowLatencyFactor is how much slower than the fastest queue file of the run the API must answer for the
+	// delay to grow: Notion slows down before rate limiting.
+	slowLatencyFactor = 2
+)
+
+// queueDelayTuner adapts the delay between queue files to the throttling of the Notion API during a run.
+// The delay doubles after a queue file got rate limit responses (and is at least as long as the pauses they
+// asked for), grows by half when the API answered much slower than its fastest queue file, and halves
+// otherwise, so that throughput stays as high as the rate limits allow without manual tuning.
+type queueDelayTuner struct {
+	delay    time.Duration
+	baseline time.Duration        // Lowest average API latency of a queue file during the run
+	last     notion.ThrottleStats // Throttling of the client when the previous delay was computed
+}
+
+// next returns the delay before the next queue file, from the throttling of the client since the last call.
+func (t *queueDelayTuner) next(throttle notion.ThrottleStats) time.Duration {
+	since := throttle.Sub(t.last)
+	t.last = throttle
+	latency := since.AverageLatency()
+
+	switch {
+	case since.RateLimited > 0:
+		asked := since.Paused / time.Duration(since.RateLimited)
+		t.delay = max(t.delay*2, asked, minAutoQueueDelay)
+	case latency > 0 && t.baseline > 0 && latency > t.baseline*slowLatencyFactor:
+		t.delay = max(t.delay+t.delay/2, minAutoQueueDelay)
+	default:
+		t.delay /= 2
+		if t.delay < minAutoQueueDelay {
+			t.delay = 0
+		}
+	}
+	t.delay = min(t.delay, maxAutoQueueDelay)
+
+	if latency > 0 && (t.baseline == 0 || latency < t.baseline) {
+		t.baseline = latency
+	}
+	return t.delay
+}
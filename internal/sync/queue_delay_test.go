@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestQueueDelayTuner(t *testing.T) {
+	t.Parallel()
+
+	tuner := &queueDelayTuner{}
+	var throttle notion.ThrottleStats
+	step := func(requests int, latency time.Duration, rateLimited int, paused time.Duration) time.Duration {
+		throttle.Requests += requests
+		throttle.Latency += time.Duration(requests) * latency
+		throttle.RateLimited += rateLimited
+		throttle.Paused += paused
+		return tuner.next(throttle)
+	}
+
+	steps := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"fast queue file", step(10, 100*time.Millisecond, 0, 0), 0},
+		{"rate limited", step(10, 100*time.Millisecond, 1, 3*time.Second), 3 * time.Second},
+		{"rate limited again", step(10, 100*time.Millisecond, 2, 2*time.Second), 6 * time.Second},
+		{"slow answers", step(10, 300*time.Millisecond, 0, 0), 9 * time.Second},
+		{"back to normal", step(10, 100*time.Millisecond, 0, 0), 4500 * time.Millisecond},
+		{"normal", step(10, 100*time.Millisecond, 0, 0), 2250 * time.Millisecond},
+		{"normal again", step(10, 100*time.Millisecond, 0, 0), 1125 * time.Millisecond},
+		{"dropped", step(10, 100*time.Millisecond, 0, 0), 0},
+		{"capped", step(1, 100*time.Millisecond, 1, 5*time.Minute), maxAutoQueueDelay},
+	}
+	for _, s := range steps {
+		if s.got != s.want {
+			t.Errorf("%s: delay = %v, want %v", s.name, s.got, s.want)
+		}
+	}
+}
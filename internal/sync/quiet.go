@@ -0,0 +1,183 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	daysPerWeek    = 7
+	maxQuietWindow = daysPerWeek * minutesPerDay
+)
+
+// weekdayNames maps the day names accepted in quiet hours to weekdays.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// quietWindow is a daily time range, in minutes since midnight, on some days of the week.
+// A window ending before it starts spans midnight and belongs to the day it starts.
+type quietWindow struct {
+	days  [daysPerWeek]bool
+	start int
+	end   int
+}
+
+// QuietHours are the time windows during which no sync must happen, to keep API quota available
+// for other integrations.
+type QuietHours struct {
+	windows  []quietWindow
+	location *time.Location
+}
+
+// ParseQuietHours parses quiet hours from a string like "mon-fri 09:00-18:00,sat 10:00-12:00".
+// Days are optional (every day) and can be a single day or a range. Times are in the given
+// timezone, or in the local one if empty.
+func ParseQuietHours(spec, timezone string) (*QuietHours, error) {
+	location := time.Local
+	if timezone = strings.TrimSpace(timezone); timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("load timezone %q: %w", timezone, err)
+		}
+		location = loc
+	}
+
+	quiet := &QuietHours{location: location}
+	for item := range strings.SplitSeq(spec, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		window, err := parseQuietWindow(item)
+		if err != nil {
+			return nil, err
+		}
+		quiet.windows = append(quiet.windows, window)
+	}
+
+	if len(quiet.windows) == 0 {
+		return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidQuietHours, spec)
+	}
+	return quiet, nil
+}
+
+// parseQuietWindow parses a window like "mon-fri 09:00-18:00" or "22:00-06:00".
+func parseQuietWindow(item string) (quietWindow, error) {
+	var window quietWindow
+
+	days, hours, found := strings.Cut(item, " ")
+	if !found {
+		days, hours = "", item
+	}
+	if err := window.parseDays(strings.ToLower(strings.TrimSpace(days))); err != nil {
+		return window, fmt.Errorf("%w: %q", err, item)
+	}
+
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(hours), "-")
+	start, startErr := parseClock(startStr)
+	end, endErr := parseClock(endStr)
+	if !found || startErr != nil || endErr != nil || start == end {
+		return window, fmt.Errorf("%w: %q", apperrors.ErrInvalidQuietHours, item)
+	}
+	window.start, window.end = start, end
+
+	return window, nil
+}
+
+// parseDays parses the days of a window: empty for every day, a day, or a range of days.
+func (w *quietWindow) parseDays(days string) error {
+	if days == "" {
+		for day := range w.days {
+			w.days[day] = true
+		}
+		return nil
+	}
+
+	firstStr, lastStr, isRange := strings.Cut(days, "-")
+	if !isRange {
+		lastStr = firstStr
+	}
+	first, okFirst := weekdayNames[firstStr]
+	last, okLast := weekdayNames[lastStr]
+	if !okFirst || !okLast {
+		return apperrors.ErrInvalidQuietHours
+	}
+
+	for day := first; ; day = (day + 1) % daysPerWeek {
+		w.days[day] = true
+		if day == last {
+			return nil
+		}
+	}
+}
+
+// parseClock parses a "HH:MM" time into minutes since midnight.
+func parseClock(val string) (int, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(val))
+	if err != nil {
+		return 0, fmt.Errorf("parse time %q: %w", val, err)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// contains returns true if the window covers the given minute of the given weekday.
+func (w *quietWindow) contains(day time.Weekday, minute int) bool {
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+
+	// Spanning midnight: the evening of a window day, or the morning after it
+	previous := (day + daysPerWeek - 1) % daysPerWeek
+	return (w.days[day] && minute >= w.start) || (w.days[previous] && minute < w.end)
+}
+
+// Active returns true if the given time is within quiet hours.
+func (q *QuietHours) Active(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	local := now.In(q.location)
+	minute := local.Hour()*60 + local.Minute()
+	for i := range q.windows {
+		if q.windows[i].contains(local.Weekday(), minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// End returns when the quiet hours active at the given time end, or the given time if they are not active.
+// Quiet hours covering the whole week never end; the returned time is then a week later.
+func (q *QuietHours) End(now time.Time) time.Time {
+	if !q.Active(now) {
+		return now
+	}
+
+	end := now.In(q.location).Truncate(time.Minute)
+	for range maxQuietWindow {
+		end = end.Add(time.Minute)
+		if !q.Active(end) {
+			return end
+		}
+	}
+	return end
+}
+
+// String returns a description of the quiet hours, for logging.
+func (q *QuietHours) String() string {
+	if q == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d window(s) in %s", len(q.windows), q.location)
+}
@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestQuietHours_Active(t *testing.T) {
+	t.Parallel()
+
+	quiet, err := ParseQuietHours("mon-fri 09:00-18:00, sat 22:00-02:00", "UTC")
+	if err != nil {
+		t.Fatalf("ParseQuietHours() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		time string
+		want bool
+	}{
+		{"monday morning", "2026-10-12T09:00:00Z", true},
+		{"friday afternoon", "2026-10-16T17:59:00Z", true},
+		{"friday evening", "2026-10-16T18:00:00Z", false},
+		{"weekday night", "2026-10-14T08:59:00Z", false},
+		{"saturday night", "2026-10-17T23:00:00Z", true},
+		{"sunday early morning", "2026-10-18T01:30:00Z", true},
+		{"sunday morning", "2026-10-18T02:00:00Z", false},
+		{"saturday afternoon", "2026-10-17T12:00:00Z", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			now, _ := time.Parse(time.RFC3339, tt.time)
+			if got := quiet.Active(now); got != tt.want {
+				t.Errorf("Active(%s) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHours_End(t *testing.T) {
+	t.Parallel()
+
+	quiet, err := ParseQuietHours("09:00-12:00,12:00-18:00", "Europe/Paris")
+	if err != nil {
+		t.Fatalf("ParseQuietHours() error = %v", err)
+	}
+
+	// 10:30 in Paris (UTC+2 in October): adjacent windows end at 18:00 Paris time
+	now := time.Date(2026, 10, 14, 8, 30, 0, 0, time.UTC)
+	want := time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC)
+	if got := quiet.End(now); !got.Equal(want) {
+		t.Errorf("End() = %v, want %v", got, want)
+	}
+
+	outside := time.Date(2026, 10, 14, 20, 0, 0, 0, time.UTC)
+	if got := quiet.End(outside); !got.Equal(outside) {
+		t.Errorf("End() outside quiet hours = %v, want %v", got, outside)
+	}
+
+	var none *QuietHours
+	if none.Active(now) {
+		t.Error("nil quiet hours should never be active")
+	}
+}
+
+func TestParseQuietHours_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{"", "mon-fri", "funday 09:00-10:00", "09:00-09:00", "9h-18h", "mon-fri 09:00"} {
+		if _, err := ParseQuietHours(spec, ""); !errors.Is(err, apperrors.ErrInvalidQuietHours) {
+			t.Errorf("ParseQuietHours(%q) error = %v, want ErrInvalidQuietHours", spec, err)
+		}
+	}
+	if _, err := ParseQuietHours("09:00-10:00", "Mars/Olympus"); err == nil {
+		t.Error("ParseQuietHours() with an unknown timezone should fail")
+	}
+}
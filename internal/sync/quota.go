@@ -0,0 +1,239 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+const (
+	// notifyTimeout bounds the notification requests.
+	notifyTimeout = 10 * time.Second
+	// notifyTimestampHeader is the header holding the timestamp of a signed notification.
+	notifyTimestampHeader = "X-Ntnsync-Timestamp"
+	// notifySignatureHeader is the header holding the signature of a signed notification.
+	notifySignatureHeader = "X-Ntnsync-Signature"
+)
+
+// FolderQuota limits the size of a folder. Zero values mean unlimited.
+type FolderQuota struct {
+	MaxPages int   `json:"max_pages,omitempty"`
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// enabled returns true if the quota has at least one limit.
+func (q FolderQuota) enabled() bool {
+	return q.MaxPages > 0 || q.MaxBytes > 0
+}
+
+// exceededBy returns true if the usage reaches one of the quota limits.
+func (q FolderQuota) exceededBy(pages int, size int64) bool {
+	return (q.MaxPages > 0 && pages >= q.MaxPages) || (q.MaxBytes > 0 && size >= q.MaxBytes)
+}
+
+// folderUsage is the number of pages and bytes of markdown of a folder.
+type folderUsage struct {
+	pages int
+	bytes int64
+}
+
+// quotaFor returns the quota of a folder: its own quota if configured, the default quota otherwise.
+func (cfg *Config) quotaFor(folder string) FolderQuota {
+	if quota, ok := cfg.FolderQuotas[folder]; ok {
+		return quota
+	}
+	return cfg.DefaultFolderQuota
+}
+
+// parseFolderQuotasEnv parses per-folder quotas from a string like "tech=500/100MB,product=200".
+// Each quota is a maximum number of pages, optionally followed by a maximum size.
+// Invalid entries are ignored.
+func parseFolderQuotasEnv(val string) map[string]FolderQuota {
+	quotas := make(map[string]FolderQuota)
+	for item := range strings.SplitSeq(val, ",") {
+		folder, limits, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || folder == "" {
+			continue
+		}
+
+		pagesStr, sizeStr, _ := strings.Cut(limits, "/")
+		var quota FolderQuota
+		if pages, err := strconv.Atoi(strings.TrimSpace(pagesStr)); err == nil && pages > 0 {
+			quota.MaxPages = pages
+		}
+		quota.MaxBytes = parseFileSizeEnv(strings.TrimSpace(sizeStr), 0)
+
+		quotas[strings.TrimSpace(folder)] = quota
+	}
+	return quotas
+}
+
+// loadFolderUsageLocked computes the usage of all folders from the page registries.
+// The result is cached for the crawler's lifetime and kept up to date as pages are written.
+// Caller must hold c.quotaMu.
+func (c *Crawler) loadFolderUsageLocked(ctx context.Context) map[string]*folderUsage {
+	if c.folderUsage != nil {
+		return c.folderUsage
+	}
+
+	c.folderUsage = make(map[string]*folderUsage)
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to list registries for folder quotas", "error", err)
+		return c.folderUsage
+	}
+	for _, reg := range registries {
+		c.addFolderUsageLocked(reg.Folder, 1, reg.Size)
+	}
+	return c.folderUsage
+}
+
+// addFolderUsage updates the cached usage of a folder, if it was loaded.
+func (c *Crawler) addFolderUsage(folder string, pages int, size int64) {
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	c.addFolderUsageLocked(folder, pages, size)
+}
+
+// addFolderUsageLocked updates the cached usage of a folder, if it was loaded. Caller must hold c.quotaMu.
+func (c *Crawler) addFolderUsageLocked(folder string, pages int, size int64) {
+	if c.folderUsage == nil {
+		return
+	}
+	usage, ok := c.folderUsage[folder]
+	if !ok {
+		usage = &folderUsage{}
+		c.folderUsage[folder] = usage
+	}
+	usage.pages += pages
+	usage.bytes += size
+}
+
+// folderQuotaExceeded returns true if the folder (or the whole mirror) reached its quota, in which case no new
+// children should be queued for it. The first time a folder exceeds its quota during a run,
+// a warning is logged and a notification is sent if configured.
+func (c *Crawler) folderQuotaExceeded(ctx context.Context, folder string) bool {
+	if c.mirrorSizeExceeded(ctx) {
+		return true
+	}
+
+	cfg := GetConfig()
+	quota := cfg.quotaFor(folder)
+	if !quota.enabled() {
+		return false
+	}
+
+	c.quotaMu.Lock()
+	var usage folderUsage
+	if loaded := c.loadFolderUsageLocked(ctx)[folder]; loaded != nil {
+		usage = *loaded
+	}
+	exceeded := quota.exceededBy(usage.pages, usage.bytes)
+	first := exceeded && !slices.Contains(c.quotaExceeded, folder)
+	if first {
+		c.quotaExceeded = append(c.quotaExceeded, folder)
+	}
+	c.quotaMu.Unlock()
+
+	if !exceeded {
+		return false
+	}
+
+	if first {
+		c.logger.WarnContext(ctx, "folder quota exceeded, no longer queueing new pages",
+			"folder", folder,
+			"pages", usage.pages,
+			"max_pages", quota.MaxPages,
+			"size", FormatBytes(usage.bytes),
+			"max_size", FormatBytes(quota.MaxBytes))
+
+		if cfg.QuotaNotifyURL != "" {
+			if err := notifyQuotaExceeded(ctx, cfg.QuotaNotifyURL, folder, &usage, quota); err != nil {
+				c.logger.WarnContext(ctx, "failed to send quota notification", "folder", folder, "error", err)
+			}
+		}
+	}
+
+	return true
+}
+
+// quotaNotification is the JSON payload posted when a folder exceeds its quota.
+type quotaNotification struct {
+	Event    string `json:"event"`
+	Folder   string `json:"folder"`
+	Pages    int    `json:"pages"`
+	Bytes    int64  `json:"bytes"`
+	MaxPages int    `json:"max_pages,omitempty"`
+	MaxBytes int64  `json:"max_bytes,omitempty"`
+}
+
+// notifyQuotaExceeded posts a quota notification to the configured URL.
+func notifyQuotaExceeded(ctx context.Context, url, folder string, usage *folderUsage, quota FolderQuota) error {
+	body, err := json.Marshal(&quotaNotification{
+		Event:    "folder_quota_exceeded",
+		Folder:   folder,
+		Pages:    usage.pages,
+		Bytes:    usage.bytes,
+		MaxPages: quota.MaxPages,
+		MaxBytes: quota.MaxBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+	return postNotification(ctx, url, "", body)
+}
+
+// postNotification posts a JSON notification. If a secret is given, the request carries the
+// timestamp and the HMAC-SHA256 signature of the timestamp followed by the body, like Notion webhooks.
+func postNotification(ctx context.Context, url, secret string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(notifyTimestampHeader, timestamp)
+		req.Header.Set(notifySignatureHeader, signNotification(secret, timestamp, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return apperrors.NewHTTPError(resp.StatusCode, "notification failed")
+	}
+	return nil
+}
+
+// signNotification computes the HMAC-SHA256 signature of a notification.
+func signNotification(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// QuotaExceededFolders returns the folders that exceeded their quota during this run.
+func (c *Crawler) QuotaExceededFolders() []string {
+	c.quotaMu.Lock()
+	defer c.quotaMu.Unlock()
+	return slices.Clone(c.quotaExceeded)
+}
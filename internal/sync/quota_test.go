@@ -0,0 +1,130 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	stdsync "sync"
+	"testing"
+)
+
+func TestParseFolderQuotasEnv(t *testing.T) {
+	t.Parallel()
+
+	quotas := parseFolderQuotasEnv("tech=500/100MB, product=200,invalid,=3,docs=/1KB")
+
+	expected := map[string]FolderQuota{
+		"tech":    {MaxPages: 500, MaxBytes: 100 * bytesPerMB},
+		"product": {MaxPages: 200},
+		"docs":    {MaxBytes: bytesPerKB},
+	}
+	if len(quotas) != len(expected) {
+		t.Fatalf("parseFolderQuotasEnv() = %+v, want %+v", quotas, expected)
+	}
+	for folder, want := range expected {
+		if got := quotas[folder]; got != want {
+			t.Errorf("quota for %s = %+v, want %+v", folder, got, want)
+		}
+	}
+}
+
+func TestFolderQuotaExceededBy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		quota FolderQuota
+		pages int
+		size  int64
+		want  bool
+	}{
+		{name: "unlimited", quota: FolderQuota{}, pages: 1000, size: 1 << 30, want: false},
+		{name: "under page limit", quota: FolderQuota{MaxPages: 10}, pages: 9, want: false},
+		{name: "page limit reached", quota: FolderQuota{MaxPages: 10}, pages: 10, want: true},
+		{name: "size limit reached", quota: FolderQuota{MaxBytes: 100}, pages: 1, size: 150, want: true},
+		{name: "under both limits", quota: FolderQuota{MaxPages: 10, MaxBytes: 100}, pages: 5, size: 50, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tc.quota.exceededBy(tc.pages, tc.size); got != tc.want {
+				t.Errorf("exceededBy(%d, %d) = %v, want %v", tc.pages, tc.size, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFolderQuotaExceeded(t *testing.T) {
+	// Cannot use t.Parallel() with t.Setenv
+	var notifications []quotaNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification quotaNotification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("invalid notification: %v", err)
+		}
+		notifications = append(notifications, notification)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Setenv("NTN_FOLDER_MAX_PAGES", "")
+	t.Setenv("NTN_FOLDER_QUOTAS", "test=2")
+	t.Setenv("NTN_QUOTA_NOTIFY_URL", server.URL)
+	ResetConfig()
+	defer ResetConfig()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	for _, id := range []string{"page1", "page2"} {
+		if err := crawler.savePageRegistry(ctx, &PageRegistry{ID: id, Folder: "test", Size: 100}); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	if crawler.folderQuotaExceeded(ctx, "other") {
+		t.Error("folder without quota should never exceed it")
+	}
+	if !crawler.folderQuotaExceeded(ctx, "test") {
+		t.Fatal("folder with 2 pages should exceed a quota of 2 pages")
+	}
+	// A second check must not notify again
+	crawler.folderQuotaExceeded(ctx, "test")
+
+	if len(notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifications))
+	}
+	if n := notifications[0]; n.Folder != "test" || n.Pages != 2 || n.Bytes != 200 || n.MaxPages != 2 {
+		t.Errorf("notification = %+v", n)
+	}
+	if got := crawler.QuotaExceededFolders(); len(got) != 1 || got[0] != "test" {
+		t.Errorf("QuotaExceededFolders() = %v, want [test]", got)
+	}
+
+	crawler.state.AddFolder("test")
+	if err := crawler.saveState(ctx); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+	status, err := crawler.GetStatus(ctx, "")
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if folder := status.Folders["test"]; folder == nil || !folder.OverQuota || folder.TotalBytes != 200 {
+		t.Errorf("folder status = %+v, want over quota with 200 bytes", folder)
+	}
+
+	// Page workers update and check the usage concurrently (see go test -race)
+	var wg stdsync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			crawler.addFolderUsage("test", 1, 10)
+			crawler.folderQuotaExceeded(ctx, "test")
+		})
+	}
+	wg.Wait()
+	if len(notifications) != 1 {
+		t.Errorf("got %d notifications after concurrent checks, want 1", len(notifications))
+	}
+}
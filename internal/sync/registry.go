@@ -203,3 +203,65 @@ func (c *Crawler) listPageRegistries(ctx context.Context) ([]*PageRegistry, erro
 
 	return registries, nil
 }
+
+// listUserRegistries lists the registries of known users.
+func (c *Crawler) listUserRegistries(ctx context.Context) ([]*UserRegistry, error) {
+	entries, err := c.store.List(ctx, filepath.Join(stateDir, idsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var registries []*UserRegistry
+	for i := range entries {
+		entry := &entries[i]
+		if entry.IsDir || !strings.HasPrefix(filepath.Base(entry.Path), "user-") ||
+			!strings.HasSuffix(entry.Path, ".json") {
+			continue
+		}
+
+		data, err := c.store.Read(ctx, entry.Path)
+		if err != nil {
+			continue
+		}
+
+		var reg UserRegistry
+		if err := json.Unmarshal(data, &reg); err != nil {
+			continue
+		}
+
+		registries = append(registries, &reg)
+	}
+
+	return registries, nil
+}
+
+// listFileRegistries lists the registries of downloaded files.
+func (c *Crawler) listFileRegistries(ctx context.Context) ([]*FileRegistry, error) {
+	entries, err := c.store.List(ctx, filepath.Join(stateDir, idsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var registries []*FileRegistry
+	for i := range entries {
+		entry := &entries[i]
+		if entry.IsDir || !strings.HasPrefix(filepath.Base(entry.Path), "file-") ||
+			!strings.HasSuffix(entry.Path, ".json") {
+			continue
+		}
+
+		data, err := c.store.Read(ctx, entry.Path)
+		if err != nil {
+			continue
+		}
+
+		var reg FileRegistry
+		if err := json.Unmarshal(data, &reg); err != nil {
+			continue
+		}
+
+		registries = append(registries, &reg)
+	}
+
+	return registries, nil
+}
@@ -0,0 +1,292 @@
+package sync
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+// registryBundleFormat is the version of the registry bundle format.
+const registryBundleFormat = 1
+
+// Prefixes of the registry files of each kind, in .notion-sync/ids/.
+const (
+	registryPrefixPage    = "page"
+	registryPrefixFile    = "file"
+	registryPrefixUser    = "user"
+	registryPrefixBlocked = "blocked"
+)
+
+// registryIDPattern matches the IDs usable in registry filenames.
+var registryIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// RegistryBundle holds all the registries of a mirror in a single portable JSON document, to move them between
+// storage modes, or to inspect and patch them with scripts.
+type RegistryBundle struct {
+	Format         int                `json:"format"`
+	NtnsyncVersion string             `json:"ntnsync_version"`
+	ExportedAt     time.Time          `json:"exported_at"`
+	Pages          []*PageRegistry    `json:"pages"`
+	Files          []*FileRegistry    `json:"files"`
+	Users          []*UserRegistry    `json:"users"`
+	Blocked        []*BlockedRegistry `json:"blocked"`
+}
+
+// RegistryImportResult contains the result of a registry import.
+type RegistryImportResult struct {
+	Pages   int `json:"pages"`
+	Files   int `json:"files"`
+	Users   int `json:"users"`
+	Blocked int `json:"blocked"`
+	Removed int `json:"removed"` // Registries absent from the bundle, removed with Replace
+}
+
+// RegistryImportOptions configures a registry import.
+type RegistryImportOptions struct {
+	DryRun  bool // Validate the bundle without writing anything
+	Replace bool // Remove the registries absent from the bundle
+}
+
+// ExportRegistries returns the page, file, user and blocked registries of the mirror, ordered by ID.
+func (c *Crawler) ExportRegistries(ctx context.Context) (*RegistryBundle, error) {
+	bundle := &RegistryBundle{
+		Format:         registryBundleFormat,
+		NtnsyncVersion: version.Version,
+		ExportedAt:     time.Now().UTC(),
+	}
+
+	var err error
+	if bundle.Pages, err = c.listPageRegistries(ctx); err != nil {
+		return nil, fmt.Errorf("list page registries: %w", err)
+	}
+	if bundle.Files, err = c.listFileRegistries(ctx); err != nil {
+		return nil, fmt.Errorf("list file registries: %w", err)
+	}
+	if bundle.Users, err = c.listUserRegistries(ctx); err != nil {
+		return nil, fmt.Errorf("list user registries: %w", err)
+	}
+	if bundle.Blocked, err = c.listBlockedRegistries(ctx); err != nil {
+		return nil, fmt.Errorf("list blocked registries: %w", err)
+	}
+
+	// Lists are never null in the bundle, to keep it easy to patch with scripts
+	bundle.Pages = nonNil(bundle.Pages)
+	bundle.Files = nonNil(bundle.Files)
+	bundle.Users = nonNil(bundle.Users)
+	bundle.Blocked = nonNil(bundle.Blocked)
+
+	slices.SortFunc(bundle.Pages, func(a, b *PageRegistry) int { return cmp.Compare(a.ID, b.ID) })
+	slices.SortFunc(bundle.Files, func(a, b *FileRegistry) int { return cmp.Compare(a.ID, b.ID) })
+	slices.SortFunc(bundle.Users, func(a, b *UserRegistry) int { return cmp.Compare(a.ID, b.ID) })
+	slices.SortFunc(bundle.Blocked, func(a, b *BlockedRegistry) int { return cmp.Compare(a.ID, b.ID) })
+	return bundle, nil
+}
+
+// ParseRegistryBundle decodes a registry bundle and validates it: unknown fields, missing or malformed IDs and
+// paths, and duplicate registries are all reported, wrapping apperrors.ErrInvalidRegistryBundle.
+func ParseRegistryBundle(data []byte) (*RegistryBundle, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var bundle RegistryBundle
+	if err := decoder.Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrInvalidRegistryBundle, err)
+	}
+	if bundle.Format != registryBundleFormat {
+		return nil, fmt.Errorf("%w: format %d, expected %d", apperrors.ErrInvalidRegistryBundle, bundle.Format,
+			registryBundleFormat)
+	}
+
+	var errs []error
+	invalid := func(kind string, index int, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s[%d]: %s", kind, index, fmt.Sprintf(format, args...)))
+	}
+	checkID := func(kind string, index int, id string, seen map[string]bool) {
+		switch {
+		case !registryIDPattern.MatchString(id):
+			invalid(kind, index, "invalid id %q", id)
+		case seen[id]:
+			invalid(kind, index, "duplicate id %q", id)
+		}
+		seen[id] = true
+	}
+
+	seen := map[string]bool{}
+	for i, reg := range bundle.Pages {
+		if reg == nil {
+			invalid("pages", i, "null registry")
+			continue
+		}
+		checkID("pages", i, normalizePageID(reg.ID), seen)
+		if reg.Type != notionTypePage && reg.Type != notionTypeDatabase {
+			invalid("pages", i, "invalid type %q", reg.Type)
+		}
+		if reg.Folder == "" {
+			invalid("pages", i, "missing folder")
+		}
+		if !isLocalPath(reg.FilePath) {
+			invalid("pages", i, "invalid file_path %q", reg.FilePath)
+		}
+	}
+	seen = map[string]bool{}
+	for i, reg := range bundle.Files {
+		if reg == nil {
+			invalid("files", i, "null registry")
+			continue
+		}
+		checkID("files", i, reg.ID, seen)
+		if !isLocalPath(reg.FilePath) {
+			invalid("files", i, "invalid file_path %q", reg.FilePath)
+		}
+	}
+	seen = map[string]bool{}
+	for i, reg := range bundle.Users {
+		if reg == nil {
+			invalid("users", i, "null registry")
+			continue
+		}
+		checkID("users", i, reg.ID, seen)
+	}
+	seen = map[string]bool{}
+	for i, reg := range bundle.Blocked {
+		if reg == nil {
+			invalid("blocked", i, "null registry")
+			continue
+		}
+		checkID("blocked", i, normalizePageID(reg.ID), seen)
+		if reg.Reason == "" {
+			invalid("blocked", i, "missing reason")
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrInvalidRegistryBundle, errors.Join(errs...))
+	}
+	return &bundle, nil
+}
+
+// isLocalPath returns true if a slash-separated path is a non-empty path inside the mirror.
+func isLocalPath(p string) bool {
+	return p != "" && filepath.IsLocal(filepath.FromSlash(p)) && path.Clean(p) == p
+}
+
+// ImportRegistries writes the registries of a validated bundle, overwriting the registries with the same IDs.
+func (c *Crawler) ImportRegistries(
+	ctx context.Context, bundle *RegistryBundle, opts RegistryImportOptions,
+) (*RegistryImportResult, error) {
+	result := &RegistryImportResult{
+		Pages:   len(bundle.Pages),
+		Files:   len(bundle.Files),
+		Users:   len(bundle.Users),
+		Blocked: len(bundle.Blocked),
+	}
+	c.logger.InfoContext(ctx, "importing registries",
+		"pages", result.Pages,
+		"files", result.Files,
+		"users", result.Users,
+		"blocked", result.Blocked,
+		"replace", opts.Replace,
+		"dry_run", opts.DryRun)
+
+	// Registries written by the import, to find the ones to remove
+	kept := make(map[string]bool)
+	keep := func(prefix, id string) {
+		kept[filepath.Join(stateDir, idsDir, fmt.Sprintf("%s-%s.json", prefix, id))] = true
+	}
+	for _, reg := range bundle.Pages {
+		keep(registryPrefixPage, normalizePageID(reg.ID))
+	}
+	for _, reg := range bundle.Files {
+		keep(registryPrefixFile, reg.ID)
+	}
+	for _, reg := range bundle.Users {
+		keep(registryPrefixUser, reg.ID)
+	}
+	for _, reg := range bundle.Blocked {
+		keep(registryPrefixBlocked, normalizePageID(reg.ID))
+	}
+
+	var removed []string
+	if opts.Replace {
+		entries, err := c.store.List(ctx, filepath.Join(stateDir, idsDir))
+		if err != nil {
+			return nil, fmt.Errorf("list registries: %w", err)
+		}
+		for i := range entries {
+			entry := &entries[i]
+			if entry.IsDir || kept[entry.Path] || !strings.HasSuffix(entry.Path, ".json") ||
+				!isRegistryFile(entry.Path) {
+				continue
+			}
+			removed = append(removed, entry.Path)
+		}
+		result.Removed = len(removed)
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+	for _, reg := range bundle.Pages {
+		if err := c.savePageRegistry(ctx, reg); err != nil {
+			return nil, fmt.Errorf("page %s: %w", reg.ID, err)
+		}
+	}
+	for _, reg := range bundle.Files {
+		if err := c.saveFileRegistry(ctx, reg); err != nil {
+			return nil, fmt.Errorf("file %s: %w", reg.ID, err)
+		}
+	}
+	for _, reg := range bundle.Users {
+		if err := c.saveUserRegistry(ctx, reg); err != nil {
+			return nil, fmt.Errorf("user %s: %w", reg.ID, err)
+		}
+	}
+	for _, reg := range bundle.Blocked {
+		if err := c.saveBlockedRegistry(ctx, reg); err != nil {
+			return nil, fmt.Errorf("blocked %s: %w", reg.ID, err)
+		}
+	}
+	for _, p := range removed {
+		if err := c.tx.Delete(ctx, p); err != nil {
+			return nil, fmt.Errorf("remove registry %s: %w", p, err)
+		}
+	}
+
+	c.logger.InfoContext(ctx, "registries imported", "removed", result.Removed)
+	return result, nil
+}
+
+// nonNil returns an empty slice instead of a nil one.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// isRegistryFile returns true if a file of the ids directory is a page, file, user or blocked registry. Other files,
+// like block caches, are not part of registry bundles.
+func isRegistryFile(p string) bool {
+	name := filepath.Base(p)
+	for _, prefix := range []string{registryPrefixPage, registryPrefixFile, registryPrefixUser, registryPrefixBlocked} {
+		if strings.HasPrefix(name, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+func TestRegistryBundle_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	source, _ := newTestCrawler(t)
+	for _, reg := range []*PageRegistry{
+		{ID: "page2", Type: notionTypePage, Folder: "tech", FilePath: "tech/b.md", Title: "B"},
+		{ID: "page1", Type: notionTypeDatabase, Folder: "tech", FilePath: "tech/a.md", Title: "A", IsRoot: true},
+	} {
+		if err := source.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+	if err := source.saveFileRegistry(ctx, &FileRegistry{ID: "file1", FilePath: "tech/a/image.png"}); err != nil {
+		t.Fatalf("saveFileRegistry() error = %v", err)
+	}
+	if err := source.saveUserRegistry(ctx, &UserRegistry{ID: "user1", Name: "Alice"}); err != nil {
+		t.Fatalf("saveUserRegistry() error = %v", err)
+	}
+	if err := source.saveBlockedRegistry(ctx, &BlockedRegistry{ID: "page3", Reason: blockedReasonArchived}); err != nil {
+		t.Fatalf("saveBlockedRegistry() error = %v", err)
+	}
+
+	bundle, err := source.ExportRegistries(ctx)
+	if err != nil {
+		t.Fatalf("ExportRegistries() error = %v", err)
+	}
+	if len(bundle.Pages) != 2 || bundle.Pages[0].ID != "page1" || len(bundle.Files) != 1 ||
+		len(bundle.Users) != 1 || len(bundle.Blocked) != 1 {
+		t.Fatalf("ExportRegistries() = %+v, want 2 sorted pages, 1 file, 1 user, 1 blocked", bundle)
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("marshal bundle: %v", err)
+	}
+	parsed, err := ParseRegistryBundle(data)
+	if err != nil {
+		t.Fatalf("ParseRegistryBundle() error = %v", err)
+	}
+
+	target, _ := newTestCrawler(t)
+	if err := target.saveUserRegistry(ctx, &UserRegistry{ID: "user2", Name: "Bob"}); err != nil {
+		t.Fatalf("saveUserRegistry() error = %v", err)
+	}
+	result, err := target.ImportRegistries(ctx, parsed, RegistryImportOptions{Replace: true})
+	if err != nil {
+		t.Fatalf("ImportRegistries() error = %v", err)
+	}
+	if result.Pages != 2 || result.Files != 1 || result.Users != 1 || result.Blocked != 1 || result.Removed != 1 {
+		t.Errorf("ImportRegistries() = %+v, want 2 pages, 1 file, 1 user, 1 blocked, 1 removed", result)
+	}
+
+	reg, err := target.loadPageRegistry(ctx, "page1")
+	if err != nil || reg.Title != "A" || !reg.IsRoot {
+		t.Errorf("loadPageRegistry() = %+v, %v, want the imported registry", reg, err)
+	}
+	if _, err := target.loadUserRegistry(ctx, "user2"); err == nil {
+		t.Error("user2 registry should be removed by the replace import")
+	}
+}
+
+func TestParseRegistryBundle_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		bundle  string
+		wantErr string
+	}{
+		{
+			name:    "unknown field",
+			bundle:  `{"format":1,"pages":[],"extra":true}`,
+			wantErr: `unknown field "extra"`,
+		},
+		{
+			name:    "unsupported format",
+			bundle:  `{"format":2}`,
+			wantErr: "format 2",
+		},
+		{
+			name:    "path outside the mirror",
+			bundle:  `{"format":1,"pages":[{"id":"page1","type":"page","folder":"tech","file_path":"../a.md"}]}`,
+			wantErr: `invalid file_path "../a.md"`,
+		},
+		{
+			name:    "invalid id",
+			bundle:  `{"format":1,"users":[{"id":"../user"}]}`,
+			wantErr: `invalid id "../user"`,
+		},
+		{
+			name: "duplicate id",
+			bundle: `{"format":1,"files":[{"id":"file1","file_path":"a.png"},` +
+				`{"id":"file1","file_path":"b.png"}]}`,
+			wantErr: `files[1]: duplicate id "file1"`,
+		},
+		{
+			name:    "missing reason",
+			bundle:  `{"format":1,"blocked":[{"id":"page1"}]}`,
+			wantErr: "missing reason",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseRegistryBundle([]byte(tt.bundle))
+			if !errors.Is(err, apperrors.ErrInvalidRegistryBundle) {
+				t.Fatalf("ParseRegistryBundle() error = %v, want ErrInvalidRegistryBundle", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRegistryBundle() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
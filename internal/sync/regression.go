@@ -0,0 +1,184 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/converter/postprocess"
+)
+
+const (
+	// reviewDir holds converted pages held back by the content loss guard.
+	reviewDir = "review"
+
+	// minGuardedContentSize is the minimum size (in bytes) of a previous page body for the
+	// content loss guard to apply. Tiny pages legitimately lose most of their content on edits.
+	minGuardedContentSize = 512
+
+	// percentScale converts ratios to percentages.
+	percentScale = 100
+)
+
+// contentSection is a markdown section (text between two headings) with its content hash.
+type contentSection struct {
+	heading string
+	hash    string
+	size    int
+}
+
+// contentLossReport describes how much of a page's previous content is missing from a new version.
+type contentLossReport struct {
+	oldSize         int
+	newSize         int
+	lostBytes       int      // bytes of previous sections no longer present
+	removedSections []string // headings of previous sections no longer present
+}
+
+// lossPercent returns the share of the previous content that was lost, as a percentage.
+// Content counts as lost only if the page shrank and its sections disappeared.
+func (r *contentLossReport) lossPercent() int {
+	if r.oldSize == 0 || r.newSize >= r.oldSize {
+		return 0
+	}
+	shrink := (r.oldSize - r.newSize) * percentScale / r.oldSize
+	lost := r.lostBytes * percentScale / r.oldSize
+	return min(shrink, lost)
+}
+
+// markdownBody returns the markdown content without its frontmatter, which changes on every sync.
+func markdownBody(content []byte) []byte {
+	_, body := postprocess.SplitFrontmatter(string(content))
+	return []byte(body)
+}
+
+// splitSections splits a markdown body into sections starting at each heading, and hashes them.
+func splitSections(body []byte) []contentSection {
+	var sections []contentSection
+	var current strings.Builder
+	heading := ""
+
+	flush := func() {
+		text := strings.TrimSpace(current.String())
+		if text != "" {
+			hash := sha256.Sum256([]byte(text))
+			sections = append(sections, contentSection{
+				heading: heading,
+				hash:    hex.EncodeToString(hash[:]),
+				size:    len(text),
+			})
+		}
+		current.Reset()
+	}
+
+	inCode := false
+	for line := range strings.SplitSeq(string(body), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+		}
+		if !inCode && strings.HasPrefix(line, "#") {
+			flush()
+			heading = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	flush()
+
+	return sections
+}
+
+// compareContent compares the sections of the previous and new versions of a page.
+func compareContent(oldContent, newContent []byte) *contentLossReport {
+	oldSections := splitSections(markdownBody(oldContent))
+	newSections := splitSections(markdownBody(newContent))
+
+	newHashes := make(map[string]bool, len(newSections))
+	report := &contentLossReport{}
+	for _, section := range newSections {
+		newHashes[section.hash] = true
+		report.newSize += section.size
+	}
+
+	for _, section := range oldSections {
+		report.oldSize += section.size
+		if !newHashes[section.hash] {
+			report.lostBytes += section.size
+			report.removedSections = append(report.removedSections, section.heading)
+		}
+	}
+
+	return report
+}
+
+// checkContentLoss compares a freshly converted page with the version currently in the store.
+// It returns a report if the guard is enabled and the new version loses more content than allowed.
+func (c *Crawler) checkContentLoss(ctx context.Context, filePath string, content []byte) *contentLossReport {
+	threshold := GetConfig().ContentLossGuard
+	if threshold <= 0 {
+		return nil
+	}
+
+	previous, err := c.store.Read(ctx, filePath)
+	if err != nil {
+		return nil // New file, nothing to compare with
+	}
+
+	report := compareContent(previous, content)
+	if report.oldSize < minGuardedContentSize || report.lossPercent() < threshold {
+		return nil
+	}
+
+	return report
+}
+
+// holdForReview writes a suspicious conversion to the review directory instead of the mirror.
+func (c *Crawler) holdForReview(
+	ctx context.Context, itemID, filePath string, content []byte, report *contentLossReport,
+) error {
+	reviewPath := filepath.Join(stateDir, reviewDir, itemID+".md")
+	if err := c.tx.Write(ctx, reviewPath, content); err != nil {
+		return fmt.Errorf("write review file: %w", err)
+	}
+
+	c.logger.WarnContext(ctx, "suspicious content loss, holding page for review",
+		notionKeyPageID, itemID,
+		"path", filePath,
+		"review_path", reviewPath,
+		"loss_percent", report.lossPercent(),
+		"old_size", report.oldSize,
+		"new_size", report.newSize,
+		"removed_sections", len(report.removedSections))
+
+	return nil
+}
+
+// clearReview removes a previous review file once the page has been written normally.
+func (c *Crawler) clearReview(ctx context.Context, itemID string) {
+	reviewPath := filepath.Join(stateDir, reviewDir, itemID+".md")
+	if exists, err := c.store.Exists(ctx, reviewPath); err != nil || !exists {
+		return
+	}
+	if err := c.tx.Delete(ctx, reviewPath); err != nil {
+		c.logger.WarnContext(ctx, "failed to delete review file", "path", reviewPath, "error", err)
+	}
+}
+
+// listPendingReviews lists the pages held back by the content loss guard.
+func (c *Crawler) listPendingReviews(ctx context.Context) ([]string, error) {
+	entries, err := c.store.List(ctx, filepath.Join(stateDir, reviewDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for i := range entries {
+		if !entries[i].IsDir && strings.HasSuffix(entries[i].Path, ".md") {
+			paths = append(paths, entries[i].Path)
+		}
+	}
+	return paths, nil
+}
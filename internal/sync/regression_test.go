@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSections(t *testing.T) {
+	t.Parallel()
+
+	body := []byte("Intro text\n\n## First\n\nfirst body\n\n```bash\n# not a heading\n```\n\n## Second\n\nsecond body\n")
+	sections := splitSections(body)
+
+	// The "# not a heading" line is inside a code block and must not start a section
+	if len(sections) != 3 {
+		t.Fatalf("expected 3 sections, got %d", len(sections))
+	}
+
+	wantHeadings := []string{"", "First", "Second"}
+	for i, want := range wantHeadings {
+		if sections[i].heading != want {
+			t.Errorf("section %d heading = %q, want %q", i, sections[i].heading, want)
+		}
+	}
+}
+
+func TestCompareContent(t *testing.T) {
+	t.Parallel()
+
+	section := func(name string) string {
+		return "## " + name + "\n\n" + strings.Repeat(name+" content line.\n", 20) + "\n"
+	}
+	frontmatter := "---\nlast_synced: %s\n---\n"
+	oldContent := []byte(strings.Replace(frontmatter, "%s", "2026-01-01", 1) +
+		section("Alpha") + section("Beta") + section("Gamma") + section("Delta") + section("Epsilon"))
+
+	tests := []struct {
+		name        string
+		newContent  string
+		wantAtLeast int
+		wantAtMost  int
+	}{
+		{
+			name:       "unchanged body with new frontmatter",
+			newContent: strings.Replace(frontmatter, "%s", "2026-02-01", 1) + string(markdownBody(oldContent)),
+			wantAtMost: 0,
+		},
+		{
+			name:       "one section edited",
+			newContent: section("Alpha") + section("Beta") + section("Gamma") + section("Delta") + "## Epsilon\n\nshort\n",
+			wantAtMost: 25, // One of five sections, Epsilon being slightly longer than the others
+		},
+		{
+			name:        "mass deletion",
+			newContent:  section("Alpha"),
+			wantAtLeast: 80,
+			wantAtMost:  100,
+		},
+		{
+			name:        "everything removed",
+			newContent:  "",
+			wantAtLeast: 100,
+			wantAtMost:  100,
+		},
+		{
+			name:       "content rewritten but longer",
+			newContent: strings.Repeat(section("Zeta"), 10),
+			wantAtMost: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			loss := compareContent(oldContent, []byte(tc.newContent)).lossPercent()
+			if loss < tc.wantAtLeast || loss > tc.wantAtMost {
+				t.Errorf("lossPercent() = %d, want between %d and %d", loss, tc.wantAtLeast, tc.wantAtMost)
+			}
+		})
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/converter"
 	"github.com/fclairamb/ntnsync/internal/store"
 )
 
@@ -208,7 +209,7 @@ func (c *Crawler) findMarkdownFiles(ctx context.Context, rootDir string) ([]stri
 				if err := walkDir(entry.Path); err != nil {
 					return err
 				}
-			} else if strings.HasSuffix(entry.Path, ".md") {
+			} else if converter.IsPageFile(entry.Path) {
 				mdFiles = append(mdFiles, entry.Path)
 			}
 		}
@@ -269,23 +270,47 @@ func (c *Crawler) findFrontmatterEnd(lines []string) (int, error) {
 
 // parseFrontmatterFields parses the frontmatter fields into a registry.
 func (c *Crawler) parseFrontmatterFields(lines []string, endIdx int, reg *PageRegistry) {
+	listKey := "" // Top-level key of the sequence being parsed
 	for i := 1; i < endIdx; i++ {
 		line := strings.TrimSpace(lines[i])
 		if line == "" {
 			continue
 		}
 
+		// Items of top-level sequences (only aliases are needed)
+		if item, isItem := strings.CutPrefix(lines[i], "  - "); isItem {
+			if listKey == "aliases" {
+				reg.Aliases = append(reg.Aliases, unquoteFrontmatterValue(strings.TrimSpace(item)))
+			}
+			continue
+		}
+		if !strings.HasPrefix(lines[i], " ") {
+			listKey, _, _ = strings.Cut(line, ":")
+		}
+
 		parts := strings.SplitN(line, ":", frontmatterFieldCount)
 		if len(parts) != frontmatterFieldCount {
 			continue
 		}
 
 		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+		value := unquoteFrontmatterValue(strings.TrimSpace(parts[1]))
 		c.setRegistryField(reg, key, value)
 	}
 }
 
+// unquoteFrontmatterValue returns the content of a double-quoted YAML scalar.
+// Plain scalars, and quoted ones using YAML-only escapes, are returned as-is.
+func unquoteFrontmatterValue(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		return unquoted
+	}
+	return value
+}
+
 // setRegistryField sets a single field on the registry based on key-value pair.
 func (c *Crawler) setRegistryField(reg *PageRegistry, key, value string) {
 	switch key {
@@ -300,11 +325,11 @@ func (c *Crawler) setRegistryField(reg *PageRegistry, key, value string) {
 			reg.FilePath = value
 		}
 	case "last_edited":
-		if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if t, err := GetConfig().TimeFormat.Parse(value); err == nil {
 			reg.LastEdited = t
 		}
 	case "last_synced":
-		if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if t, err := GetConfig().TimeFormat.Parse(value); err == nil {
 			reg.LastSynced = t
 		}
 	case "is_root":
@@ -330,7 +355,7 @@ func (c *Crawler) extractTitle(lines []string, endIdx int, filePath string, reg
 
 	// Use filename as title if no heading found
 	base := filepath.Base(filePath)
-	reg.Title = strings.TrimSuffix(base, ".md")
+	reg.Title = converter.TrimPageExt(base)
 }
 
 // CommitChanges commits pending changes to git.
@@ -345,6 +370,7 @@ func (c *Crawler) CommitChanges(ctx context.Context, message string) error {
 	if err := tx.Commit(ctx, message); err != nil {
 		return fmt.Errorf("commit: %w", err)
 	}
+	c.pending.commit()
 
 	c.logger.InfoContext(ctx, "changes committed")
 	return nil
@@ -0,0 +1,192 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// notionLinkPattern matches the markdown link targets pointing to Notion: the notion:// links of link_to_page
+// blocks, notion.so and notion.site URLs, and the "/<page-id>" links Notion gives to links between pages.
+var notionLinkPattern = regexp.MustCompile(
+	`\]\((notion://(?:page|database)/[0-9A-Fa-f-]+|https?://(?:[\w-]+\.)?notion\.(?:so|site)/[^)\s]*|` +
+		`/[0-9a-f]{32}(?:[?#][^)\s]*)?)\)`)
+
+// RelinkOptions configures a relink pass.
+type RelinkOptions struct {
+	Folder string // Only relink the files of this folder (empty = all folders)
+	DryRun bool   // Count the links without writing the files
+}
+
+// RelinkResult is the result of a relink pass.
+type RelinkResult struct {
+	Files      int      `json:"files"`      // Files scanned
+	Changed    []string `json:"changed"`    // Files whose links were rewritten
+	Links      int      `json:"links"`      // Links rewritten
+	Unresolved int      `json:"unresolved"` // Links to pages that are not synced
+}
+
+// Relink rewrites the links to Notion pages of the files of the mirror into relative links to their files, for the
+// pages that are synced. It catches up on the links written before their target page was synced, which the sync
+// of a page only resolves for the pages already synced.
+func (c *Crawler) Relink(ctx context.Context, opts RelinkOptions) (*RelinkResult, error) {
+	c.logger.InfoContext(ctx, "relinking", "folder", opts.Folder, "dry_run", opts.DryRun)
+
+	if !opts.DryRun {
+		if err := c.EnsureTransaction(ctx); err != nil {
+			return nil, fmt.Errorf("ensure transaction: %w", err)
+		}
+	}
+
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list page registries: %w", err)
+	}
+	paths := make(map[string]string, len(registries))
+	for _, reg := range registries {
+		paths[normalizePageID(reg.ID)] = reg.FilePath
+	}
+	resolve := func(pageID string) string { return paths[pageID] }
+
+	result := &RelinkResult{Changed: []string{}}
+	for _, reg := range registries {
+		if opts.Folder != "" && reg.Folder != opts.Folder {
+			continue
+		}
+		if filepath.Ext(reg.FilePath) == converter.FileExtension(converter.ProfileHTML) {
+			continue // HTML documents are not post-processed
+		}
+		content, err := c.store.Read(ctx, reg.FilePath)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to read page file", "path", reg.FilePath, "error", err)
+			continue
+		}
+		result.Files++
+
+		relinked, links, unresolved := rewriteNotionLinks(content, reg.FilePath, resolve)
+		result.Unresolved += unresolved
+		if links == 0 {
+			continue
+		}
+		result.Links += links
+		result.Changed = append(result.Changed, reg.FilePath)
+		if opts.DryRun {
+			continue
+		}
+
+		if err := c.writePageFile(ctx, reg, relinked); err != nil {
+			return nil, err
+		}
+	}
+
+	c.logger.InfoContext(ctx, "relink complete",
+		"files", result.Files,
+		"changed", len(result.Changed),
+		"links", result.Links,
+		"unresolved", result.Unresolved)
+	return result, nil
+}
+
+// writePageFile writes the file of a synced page changed outside of its sync, and updates the content hash and
+// size of its registry. Failures to save the registry are only logged.
+func (c *Crawler) writePageFile(ctx context.Context, reg *PageRegistry, content []byte) error {
+	if err := c.tx.Write(ctx, reg.FilePath, content); err != nil {
+		return fmt.Errorf("write page %s: %w", reg.FilePath, err)
+	}
+	hash := sha256.Sum256(content)
+	reg.ContentHash = hex.EncodeToString(hash[:])
+	reg.Size = int64(len(content))
+	if err := c.savePageRegistry(ctx, reg); err != nil {
+		c.logger.WarnContext(ctx, "failed to save page registry", "error", err)
+	}
+	return nil
+}
+
+// resolveLinks rewrites the links to Notion pages of a document written to filePath into relative links, for the
+// pages that are already synced. The other links are kept, for a later relink.
+func (c *Crawler) resolveLinks(ctx context.Context, filePath string, content []byte) []byte {
+	known := make(map[string]string)
+	relinked, _, _ := rewriteNotionLinks(content, filePath, func(pageID string) string {
+		if path, ok := known[pageID]; ok {
+			return path
+		}
+		path := ""
+		if reg, err := c.loadPageRegistry(ctx, pageID); err == nil {
+			path = reg.FilePath
+		}
+		known[pageID] = path
+		return path
+	})
+	return relinked
+}
+
+// rewriteNotionLinks rewrites the links to Notion pages of a document into links relative to its file fromFile.
+// resolve returns the file path of a page, or "" if it is not synced. Returns the document, the number of links
+// rewritten and the number of links to pages that are not synced.
+func rewriteNotionLinks(content []byte, fromFile string, resolve func(pageID string) string) ([]byte, int, int) {
+	rewritten, unresolved := 0, 0
+	relinked := notionLinkPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		target := string(match[2 : len(match)-1])
+		pageID := notionLinkPageID(target)
+		if pageID == "" {
+			return match
+		}
+		path := resolve(pageID)
+		if path == "" {
+			unresolved++
+			return match
+		}
+		rewritten++
+		return []byte("](" + relativeLink(fromFile, path) + ")")
+	})
+	return relinked, rewritten, unresolved
+}
+
+// notionLinkPageID returns the normalized ID of the page or database a Notion link points to, or "".
+func notionLinkPageID(target string) string {
+	if rest, ok := strings.CutPrefix(target, "notion://"); ok {
+		_, id, _ := strings.Cut(rest, "/")
+		return normalizePageID(id)
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	// Pages opened in a side peek: the page is the p parameter, the path is the page it was opened from
+	if peek := parsed.Query().Get("p"); peek != "" {
+		if id, err := notion.ParsePageIDOrURL(peek); err == nil {
+			return normalizePageID(id)
+		}
+	}
+	parsed.RawQuery, parsed.Fragment = "", ""
+	if parsed.Host == "" {
+		return normalizePageID(strings.TrimPrefix(parsed.Path, "/"))
+	}
+	id, err := notion.ParsePageIDOrURL(parsed.String())
+	if err != nil {
+		return ""
+	}
+	return normalizePageID(id)
+}
+
+// relativeLink returns the path of toFile relative to the directory of fromFile, as a markdown link target.
+func relativeLink(fromFile, toFile string) string {
+	rel, err := filepath.Rel(filepath.Dir(fromFile), toFile)
+	if err != nil {
+		return toFile
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestRewriteNotionLinks(t *testing.T) {
+	t.Parallel()
+
+	paths := map[string]string{
+		"0123456789abcdef0123456789abcdef": "tech/wiki/setup.md",
+		"fedcba9876543210fedcba9876543210": "tech/wiki.md",
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": "hr/handbook.md",
+	}
+	resolve := func(pageID string) string { return paths[pageID] }
+
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"[Page Link](notion://page/01234567-89ab-cdef-0123-456789abcdef)", "[Page Link](./wiki/setup.md)"},
+		{"[Database Link](notion://database/fedcba9876543210fedcba9876543210)", "[Database Link](./wiki.md)"},
+		{"[Setup](https://www.notion.so/acme/Setup-0123456789abcdef0123456789abcdef?pvs=21)",
+			"[Setup](./wiki/setup.md)"},
+		{"[Setup](https://www.notion.so/0123456789abcdef0123456789abcdef#1111)", "[Setup](./wiki/setup.md)"},
+		{"[HR](https://acme.notion.site/Handbook-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa)", "[HR](../hr/handbook.md)"},
+		{"[HR](/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa)", "[HR](../hr/handbook.md)"},
+		{"[Peek](https://www.notion.so/Wiki-fedcba9876543210fedcba9876543210?p=aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa)",
+			"[Peek](../hr/handbook.md)"},
+		{"[Other](https://www.notion.so/Other-bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb)",
+			"[Other](https://www.notion.so/Other-bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb)"},
+		{"[Site](https://example.com/0123456789abcdef0123456789abcdef)",
+			"[Site](https://example.com/0123456789abcdef0123456789abcdef)"},
+	}
+	for _, tt := range tests {
+		got, _, _ := rewriteNotionLinks([]byte(tt.link), "tech/wiki.md", resolve)
+		if string(got) != tt.want {
+			t.Errorf("rewriteNotionLinks(%s) = %s, want %s", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestRelink(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	const targetID, sourceID = "dddddddddddddddddddddddddddddddd", "eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	files := map[string]string{
+		targetID: "# Target\n",
+		sourceID: "# Source\n\nSee [Target](notion://page/" + targetID + ") and " +
+			"[Gone](https://www.notion.so/Gone-cccccccccccccccccccccccccccccccc)\n",
+	}
+	for id, content := range files {
+		reg := &PageRegistry{ID: id, Type: notionTypePage, Folder: "test", FilePath: "test/" + id[:1] + ".md"}
+		if err := crawler.tx.Write(ctx, reg.FilePath, []byte(content)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	result, err := crawler.Relink(ctx, RelinkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Relink() error = %v", err)
+	}
+	if result.Files != 2 || result.Links != 1 || result.Unresolved != 1 || len(result.Changed) != 1 {
+		t.Errorf("Relink(dry run) = %+v, want 2 files, 1 link and 1 unresolved", result)
+	}
+	if content, _ := crawler.store.Read(ctx, "test/e.md"); string(content) != files[sourceID] {
+		t.Errorf("dry run wrote %q", content)
+	}
+
+	if _, err := crawler.Relink(ctx, RelinkOptions{}); err != nil {
+		t.Fatalf("Relink() error = %v", err)
+	}
+	want := "# Source\n\nSee [Target](./d.md) and " +
+		"[Gone](https://www.notion.so/Gone-cccccccccccccccccccccccccccccccc)\n"
+	content, err := crawler.store.Read(ctx, "test/e.md")
+	if err != nil || string(content) != want {
+		t.Errorf("source = %q, %v, want %q", content, err, want)
+	}
+	reg, err := crawler.loadPageRegistry(ctx, sourceID)
+	hash := sha256.Sum256([]byte(want))
+	if err != nil || reg.ContentHash != hex.EncodeToString(hash[:]) {
+		t.Errorf("registry content hash not updated: %+v, %v", reg, err)
+	}
+}
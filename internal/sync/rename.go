@@ -0,0 +1,323 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// renamePage returns the path of a synced page given its current title and parent, with NTN_RENAME_FILES. When it
+// differs from its registry path, the page was renamed or moved in Notion: its file and its directory (child pages
+// and downloaded files) are moved there, in the transaction. Otherwise, or if the move fails, the registry path is
+// kept.
+func (c *Crawler) renamePage(
+	ctx context.Context, existing *PageRegistry, page *notion.Page, folder string, isRoot bool, parentID string,
+) string {
+	oldPath := existing.FilePath
+	if !GetConfig().RenameFiles || oldPath == "" {
+		return oldPath
+	}
+	// Paths differing only by case are kept: the move would remove the file on case-insensitive file systems
+	newPath := c.newFilePath(ctx, page, folder, isRoot, parentID, filepath.Ext(oldPath))
+	if strings.EqualFold(newPath, oldPath) {
+		return oldPath
+	}
+
+	if err := c.movePage(ctx, existing, newPath, parentID); err != nil {
+		c.logger.WarnContext(ctx, "failed to move renamed page, keeping its path",
+			notionKeyPageID, existing.ID,
+			"path", oldPath,
+			"new_path", newPath,
+			"error", err)
+		return oldPath
+	}
+	return newPath
+}
+
+// movePage moves the file of a page to newPath, with its directory, and updates the registries of the pages and
+// files moved and the links to the page in its parents. Files are moved by writing them to their new path and
+// deleting them from the former one in the same transaction, so that git reports them as renamed. The relative
+// links of the pages moved, and the links of the other pages to the files moved, are rewritten to their new path.
+func (c *Crawler) movePage(ctx context.Context, existing *PageRegistry, newPath, parentID string) error {
+	oldPath := existing.FilePath
+	oldDir := converter.TrimPageExt(oldPath)
+	move := pageMove{oldPath: oldPath, newPath: newPath, fold: c.converter.Links.PathCase == converter.LinkPathLower}
+	relocate := pageMove{oldPath: oldPath, newPath: newPath}.relocate
+
+	c.logger.InfoContext(ctx, "moving renamed page",
+		notionKeyPageID, existing.ID,
+		"path", oldPath,
+		"new_path", newPath)
+
+	files, err := c.filesUnder(ctx, oldDir)
+	if err != nil {
+		return fmt.Errorf("list %s: %w", oldDir, err)
+	}
+	if exists, err := c.store.Exists(ctx, oldPath); err == nil && exists {
+		files = append(files, oldPath)
+	}
+
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return fmt.Errorf("list page registries: %w", err)
+	}
+	moved := make(map[string]*PageRegistry) // Registries of the pages moved, by former file path
+	var kept []*PageRegistry
+	for _, reg := range registries {
+		if reg.FilePath != "" && relocate(reg.FilePath) != reg.FilePath {
+			moved[reg.FilePath] = reg
+		} else {
+			kept = append(kept, reg)
+		}
+	}
+
+	movedContent := make(map[string][]byte) // Documents of the pages moved, by former file path
+	for _, filePath := range files {
+		content, err := c.store.Read(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", filePath, err)
+		}
+		if _, ok := moved[filePath]; ok {
+			content = relocateFrontmatterPath(content, filePath, relocate(filePath))
+			content = relocateLinks(content, filePath, relocate(filePath), move)
+			movedContent[filePath] = content
+		}
+		if err := c.tx.Write(ctx, relocate(filePath), content); err != nil {
+			return fmt.Errorf("write %s: %w", relocate(filePath), err)
+		}
+		if err := c.deleteFile(ctx, filePath); err != nil {
+			return err
+		}
+	}
+
+	for filePath, reg := range moved {
+		reg.FilePath = relocate(filePath)
+		if !slices.Contains(reg.Aliases, filePath) {
+			reg.Aliases = append(reg.Aliases, filePath)
+		}
+		if content, ok := movedContent[filePath]; ok {
+			hash := sha256.Sum256(content)
+			reg.ContentHash = hex.EncodeToString(hash[:])
+			reg.Size = int64(len(content))
+		}
+		if err := c.savePageRegistry(ctx, reg); err != nil {
+			return fmt.Errorf("save page registry %s: %w", reg.ID, err)
+		}
+	}
+
+	fileRegistries, err := c.listFileRegistries(ctx)
+	if err != nil {
+		return fmt.Errorf("list file registries: %w", err)
+	}
+	for _, reg := range fileRegistries {
+		if newFilePath := relocate(reg.FilePath); newFilePath != reg.FilePath {
+			reg.FilePath = newFilePath
+			if err := c.saveFileRegistry(ctx, reg); err != nil {
+				return fmt.Errorf("save file registry %s: %w", reg.ID, err)
+			}
+		}
+	}
+
+	for _, reg := range kept {
+		c.relinkMovedFiles(ctx, reg, move)
+	}
+	for _, id := range slices.Compact([]string{existing.ParentID, normalizePageID(parentID)}) {
+		c.relinkChild(ctx, id, existing.ID, newPath)
+	}
+	return nil
+}
+
+// pageMove is the move of the file of a page and of its directory.
+type pageMove struct {
+	oldPath string
+	newPath string
+	fold    bool // Match paths regardless of case, for the links written in lowercase
+}
+
+// relocate returns the path of a file, or of a page file without its extension, after the move.
+func (m pageMove) relocate(filePath string) string {
+	oldDir, newDir := converter.TrimPageExt(m.oldPath), converter.TrimPageExt(m.newPath)
+	equal := func(a, b string) bool { return a == b || (m.fold && strings.EqualFold(a, b)) }
+
+	switch {
+	case equal(filePath, m.oldPath):
+		return m.newPath
+	case equal(filePath, oldDir):
+		return newDir
+	case len(filePath) > len(oldDir) && equal(filePath[:len(oldDir)+1], oldDir+string(filepath.Separator)):
+		return filepath.Join(newDir, filePath[len(oldDir)+1:])
+	}
+	return filePath
+}
+
+// relinkMovedFiles rewrites the links of the file of a page that was not moved to the files moved. Failures are
+// only logged: the next sync of the page renders its links again.
+func (c *Crawler) relinkMovedFiles(ctx context.Context, reg *PageRegistry, move pageMove) {
+	if reg.FilePath == "" {
+		return
+	}
+	content, err := c.store.Read(ctx, reg.FilePath)
+	if err != nil {
+		return
+	}
+
+	relinked := relocateLinks(content, reg.FilePath, reg.FilePath, move)
+	if string(relinked) == string(content) {
+		return
+	}
+	if err := c.writePageFile(ctx, reg, relinked); err != nil {
+		c.logger.WarnContext(ctx, "failed to update links to moved page", notionKeyPageID, reg.ID, "error", err)
+	}
+}
+
+var (
+	// markdownLinkPattern matches the targets of markdown links and images.
+	markdownLinkPattern = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+	// wikiLinkPattern matches the targets of wikilinks, from the root of the mirror and without extension.
+	wikiLinkPattern = regexp.MustCompile(`\[\[([^\]|]+)`)
+	// frontmatterFilePattern matches the local files of the icon and cover of a page, relative to its directory.
+	frontmatterFilePattern = regexp.MustCompile(`(?m)^((?:icon|cover): "file:)([^"]+)"$`)
+)
+
+// relocateLinks rewrites the links of a document moved from fromFile to toFile (the same path for a document that
+// was not moved): its relative links keep pointing to their target, at its new path if it was moved too.
+func relocateLinks(content []byte, fromFile, toFile string, move pageMove) []byte {
+	content = markdownLinkPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		target := string(match[2 : len(match)-1])
+		return []byte("](" + relocateLinkTarget(target, fromFile, toFile, move) + ")")
+	})
+	content = frontmatterFilePattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		groups := frontmatterFilePattern.FindSubmatch(match)
+		return []byte(string(groups[1]) + relocateLinkTarget(string(groups[2]), fromFile, toFile, move) + `"`)
+	})
+	return wikiLinkPattern.ReplaceAllFunc(content, func(match []byte) []byte {
+		target := string(match[2:])
+		relocated := move.relocate(filepath.FromSlash(target))
+		if relocated == filepath.FromSlash(target) {
+			return match
+		}
+		relocated = filepath.ToSlash(relocated)
+		if move.fold && target == strings.ToLower(target) {
+			relocated = strings.ToLower(relocated)
+		}
+		return []byte("[[" + relocated)
+	})
+}
+
+// relocateLinkTarget returns the target of a relative link of a document moved from fromFile to toFile, pointing
+// to the new path of its target. URLs and absolute paths are kept.
+func relocateLinkTarget(target, fromFile, toFile string, move pageMove) string {
+	if target == "" || strings.HasPrefix(target, "/") || strings.HasPrefix(target, "#") ||
+		strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+		return target
+	}
+	linkPath, suffix := target, "" // Anchors and queries are kept
+	if i := strings.IndexAny(target, "#?"); i >= 0 {
+		linkPath, suffix = target[:i], target[i:]
+	}
+
+	oldTarget := filepath.Join(filepath.Dir(fromFile), filepath.FromSlash(linkPath))
+	newTarget := move.relocate(oldTarget)
+	if fromFile == toFile && newTarget == oldTarget {
+		return target
+	}
+
+	rel := relativeLink(toFile, newTarget)
+	if !strings.HasPrefix(linkPath, "./") && !strings.HasPrefix(linkPath, "../") {
+		rel = strings.TrimPrefix(rel, "./") // Links to downloaded files have no "./" prefix
+	}
+	if move.fold && linkPath == strings.ToLower(linkPath) {
+		rel = strings.ToLower(rel)
+	}
+	return rel + suffix
+}
+
+// filesUnder returns the files of a directory of the mirror and of its subdirectories.
+func (c *Crawler) filesUnder(ctx context.Context, dir string) ([]string, error) {
+	entries, err := c.store.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for i := range entries {
+		if !entries[i].IsDir {
+			files = append(files, entries[i].Path)
+			continue
+		}
+		subFiles, err := c.filesUnder(ctx, entries[i].Path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, subFiles...)
+	}
+	return files, nil
+}
+
+// relocateFrontmatterPath replaces the file_path field of the frontmatter of a document moved to newPath.
+func relocateFrontmatterPath(content []byte, oldPath, newPath string) []byte {
+	lines := strings.Split(string(content), "\n")
+	if len(lines) == 0 || lines[0] != "---" {
+		return content
+	}
+	for i, line := range lines[1:] {
+		if line == "---" {
+			break
+		}
+		if strings.HasPrefix(line, "file_path: ") {
+			lines[i+1] = strings.Replace(line, oldPath, newPath, 1)
+			break
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// relinkChild points the links to a child page of the file of its parent, identified by their page ID comment, to
+// the new path of the child. Failures are only logged: the parent's next sync renders its links again.
+func (c *Crawler) relinkChild(ctx context.Context, parentID, childID, childPath string) {
+	if parentID == "" {
+		return
+	}
+	parent, err := c.loadPageRegistry(ctx, parentID)
+	if err != nil || parent.FilePath == "" {
+		return
+	}
+	content, err := c.store.Read(ctx, parent.FilePath)
+	if err != nil {
+		return
+	}
+
+	relinked := rewriteChildLinks(content, childID, relativeLink(parent.FilePath, childPath),
+		filepath.ToSlash(converter.TrimPageExt(childPath)), c.converter.Links.PathCase == converter.LinkPathLower)
+	if string(relinked) == string(content) {
+		return
+	}
+	if err := c.writePageFile(ctx, parent, relinked); err != nil {
+		c.logger.WarnContext(ctx, "failed to update links of parent page", notionKeyPageID, parentID, "error", err)
+	}
+}
+
+// rewriteChildLinks rewrites the links to a page identified by their page ID comment: markdown links point to
+// target, and wikilinks to wikiTarget (the path of the page from the root of the mirror, without extension).
+func rewriteChildLinks(content []byte, pageID, target, wikiTarget string, lower bool) []byte {
+	if lower {
+		target, wikiTarget = strings.ToLower(target), strings.ToLower(wikiTarget)
+	}
+	comment := "<!-- page_id:" + normalizePageID(pageID) + " -->"
+	markdownLink := regexp.MustCompile(`\]\([^)\s]*\)` + regexp.QuoteMeta(comment))
+	wikiLink := regexp.MustCompile(`\[\[[^\]|]*(\|[^\]]*)?\]\]` + regexp.QuoteMeta(comment))
+
+	content = markdownLink.ReplaceAllLiteral(content, []byte("]("+target+")"+comment))
+	return wikiLink.ReplaceAllFunc(content, func(match []byte) []byte {
+		text := wikiLink.FindSubmatch(match)[1]
+		return []byte("[[" + wikiTarget + string(text) + "]]" + comment)
+	})
+}
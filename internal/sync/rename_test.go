@@ -0,0 +1,166 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Cannot use t.Parallel() with t.Setenv
+func TestRenamePage(t *testing.T) {
+	t.Setenv("NTN_RENAME_FILES", "true")
+	ResetConfig()
+	defer ResetConfig()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	const parentID, pageID, childID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"cccccccccccccccccccccccccccccccc"
+	pages := []struct {
+		reg     *PageRegistry
+		content string
+	}{
+		{&PageRegistry{ID: parentID, FilePath: "test/parent.md", IsRoot: true},
+			"# Parent\n\n- [Old](./parent/old.md)<!-- page_id:" + pageID + " -->\n"},
+		{&PageRegistry{ID: pageID, ParentID: parentID, FilePath: "test/parent/old.md"},
+			"---\nfile_path: test/parent/old.md\n---\n# Old\n\n![](old/files/img.png)\n"},
+		{&PageRegistry{ID: childID, ParentID: pageID, FilePath: "test/parent/old/child.md"},
+			"---\nfile_path: test/parent/old/child.md\n---\n# Child\n"},
+	}
+	for _, page := range pages {
+		page.reg.Type, page.reg.Folder = notionTypePage, "test"
+		if err := crawler.tx.Write(ctx, page.reg.FilePath, []byte(page.content)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := crawler.savePageRegistry(ctx, page.reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+	if err := crawler.tx.Write(ctx, "test/parent/old/files/img.png", []byte("png")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	fileReg := &FileRegistry{ID: "img", FilePath: "test/parent/old/files/img.png"}
+	if err := crawler.saveFileRegistry(ctx, fileReg); err != nil {
+		t.Fatalf("saveFileRegistry() error = %v", err)
+	}
+
+	renamed := &notion.Page{
+		ID: pageID,
+		Properties: notion.Properties{
+			notionKeyTitle: {Type: notionKeyTitle, Title: []notion.RichText{{PlainText: "New"}}},
+		},
+	}
+	existing, err := crawler.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := crawler.renamePage(ctx, existing, renamed, "test", false, parentID); got != "test/parent/new.md" {
+		t.Fatalf("renamePage() = %s, want test/parent/new.md", got)
+	}
+
+	for filePath, want := range map[string]string{
+		"test/parent.md":                "# Parent\n\n- [Old](./parent/new.md)<!-- page_id:" + pageID + " -->\n",
+		"test/parent/new.md":            "---\nfile_path: test/parent/new.md\n---\n# Old\n\n![](new/files/img.png)\n",
+		"test/parent/new/child.md":      "---\nfile_path: test/parent/new/child.md\n---\n# Child\n",
+		"test/parent/new/files/img.png": "png",
+	} {
+		if content, err := crawler.store.Read(ctx, filePath); err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", filePath, content, err, want)
+		}
+	}
+	for _, filePath := range []string{"test/parent/old.md", "test/parent/old/child.md", "test/parent/old/files/img.png"} {
+		if exists, _ := crawler.store.Exists(ctx, filePath); exists {
+			t.Errorf("%s still exists", filePath)
+		}
+	}
+
+	child, err := crawler.loadPageRegistry(ctx, childID)
+	if err != nil || child.FilePath != "test/parent/new/child.md" || len(child.Aliases) != 1 {
+		t.Errorf("child registry = %+v, %v", child, err)
+	}
+	file, err := crawler.loadFileRegistry(ctx, "img")
+	if err != nil || file.FilePath != "test/parent/new/files/img.png" {
+		t.Errorf("file registry = %+v, %v", file, err)
+	}
+	if existing.FilePath != "test/parent/old.md" {
+		t.Errorf("existing registry changed: %s", existing.FilePath)
+	}
+}
+
+func TestMovePage_Deeper(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	const homeID, pageID, childID, sectionID, otherID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", "cccccccccccccccccccccccccccccccc", "dddddddddddddddddddddddddddddddd",
+		"eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	pages := []struct {
+		reg     *PageRegistry
+		content string
+	}{
+		{&PageRegistry{ID: homeID, FilePath: "test/home.md", IsRoot: true},
+			"# Home\n\n- [Page](./home/page.md)<!-- page_id:" + pageID + " -->\n"},
+		{&PageRegistry{ID: pageID, ParentID: homeID, FilePath: "test/home/page.md"},
+			"---\nfile_path: test/home/page.md\nicon: \"file:../../assets/icon.png\"\n---\n# Page\n\n" +
+				"[Section](./section.md) [Home](../home.md#intro) [Web](https://example.com/a.md)\n\n" +
+				"![](page/files/img.png)\n\n- [Child](./page/child.md)<!-- page_id:" + childID + " -->\n"},
+		{&PageRegistry{ID: childID, ParentID: pageID, FilePath: "test/home/page/child.md"},
+			"---\nfile_path: test/home/page/child.md\n---\n# Child\n\n[Page](../page.md) [Home](../../home.md)\n"},
+		{&PageRegistry{ID: sectionID, ParentID: homeID, FilePath: "test/home/section.md"}, "# Section\n"},
+		{&PageRegistry{ID: otherID, FilePath: "test/other.md", IsRoot: true},
+			"# Other\n\n[Page](./home/page.md) [Image](home/page/files/img.png) [[test/home/page/child|Child]]\n"},
+	}
+	for _, page := range pages {
+		page.reg.Type, page.reg.Folder = notionTypePage, "test"
+		if err := crawler.tx.Write(ctx, page.reg.FilePath, []byte(page.content)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := crawler.savePageRegistry(ctx, page.reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+	if err := crawler.tx.Write(ctx, "test/home/page/files/img.png", []byte("png")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	existing, err := crawler.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crawler.movePage(ctx, existing, "test/home/section/page.md", sectionID); err != nil {
+		t.Fatalf("movePage() error = %v", err)
+	}
+
+	for filePath, want := range map[string]string{
+		"test/home.md": "# Home\n\n- [Page](./home/section/page.md)<!-- page_id:" + pageID + " -->\n",
+		"test/home/section/page.md": "---\nfile_path: test/home/section/page.md\n" +
+			"icon: \"file:../../../assets/icon.png\"\n---\n# Page\n\n" +
+			"[Section](../section.md) [Home](../../home.md#intro) [Web](https://example.com/a.md)\n\n" +
+			"![](page/files/img.png)\n\n- [Child](./page/child.md)<!-- page_id:" + childID + " -->\n",
+		"test/home/section/page/child.md": "---\nfile_path: test/home/section/page/child.md\n---\n# Child\n\n" +
+			"[Page](../page.md) [Home](../../../home.md)\n",
+		"test/home/section.md": "# Section\n",
+		"test/other.md": "# Other\n\n[Page](./home/section/page.md) [Image](home/section/page/files/img.png) " +
+			"[[test/home/section/page/child|Child]]\n",
+	} {
+		if content, err := crawler.store.Read(ctx, filePath); err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", filePath, content, err, want)
+		}
+	}
+
+	// The registries of the documents rewritten match their content
+	for _, id := range []string{homeID, pageID, childID, otherID} {
+		reg, err := crawler.loadPageRegistry(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := crawler.store.Read(ctx, reg.FilePath)
+		if err != nil || reg.Size != int64(len(content)) {
+			t.Errorf("%s registry size = %d, want %d (%v)", reg.FilePath, reg.Size, len(content), err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"context"
+	"maps"
+
+	"github.com/fclairamb/ntnsync/internal/converter"
+	"github.com/fclairamb/ntnsync/internal/converter/html"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// convertPage converts a page with the converter of its output profile: an HTML document for the html profile,
+// markdown otherwise, post-processed.
+func (c *Crawler) convertPage(
+	ctx context.Context, page *notion.Page, blocks []notion.Block, opts *converter.ConvertOptions,
+) []byte {
+	if opts.Profile == converter.ProfileHTML {
+		return html.NewConverter(c.converter).ConvertWithOptions(page, blocks, opts)
+	}
+	content := c.converter.ConvertWithOptions(page, blocks, opts)
+	c.recordWarnings(ctx, opts)
+	return c.postProcess(ctx, content, opts)
+}
+
+// convertDatabase converts a database with the converter of its output profile.
+func (c *Crawler) convertDatabase(
+	ctx context.Context, database *notion.Database, dbPages []notion.DatabasePage, opts *converter.ConvertOptions,
+) []byte {
+	if opts.Profile == converter.ProfileHTML {
+		return html.NewConverter(c.converter).ConvertDatabase(database, dbPages, opts)
+	}
+	content := c.converter.ConvertDatabase(database, dbPages, opts)
+	c.recordWarnings(ctx, opts)
+	return c.postProcess(ctx, content, opts)
+}
+
+// postProcess resolves the links to synced pages of a markdown document, then runs the post-processors of its
+// folder.
+func (c *Crawler) postProcess(ctx context.Context, content []byte, opts *converter.ConvertOptions) []byte {
+	content = c.resolveLinks(ctx, opts.FilePath, content)
+	return GetConfig().postProcessorsFor(opts.Folder).Process(content)
+}
+
+// recordWarnings counts the warnings of a conversion, which are listed in the frontmatter of the page, for the
+// report of the run.
+func (c *Crawler) recordWarnings(ctx context.Context, opts *converter.ConvertOptions) {
+	if len(opts.Warnings) == 0 {
+		return
+	}
+
+	c.warningsMu.Lock()
+	if c.warnings == nil {
+		c.warnings = make(map[string]int)
+	}
+	for _, warning := range opts.Warnings {
+		c.warnings[warning.Kind]++
+	}
+	counts := maps.Clone(c.warnings)
+	c.warningsMu.Unlock()
+
+	for _, warning := range opts.Warnings {
+		c.logger.DebugContext(ctx, "conversion warning", "path", opts.FilePath, "warning", warning)
+	}
+	c.updateRun(ctx, func(status *RunStatus) {
+		status.Warnings = counts
+	})
+}
+
+// takeWarnings returns the conversion warnings counted since the last call, by kind, and resets them.
+func (c *Crawler) takeWarnings() map[string]int {
+	c.warningsMu.Lock()
+	defer c.warningsMu.Unlock()
+	warnings := c.warnings
+	c.warnings = nil
+	return warnings
+}
+
+// countWarnings returns the total of warning counts by kind.
+func countWarnings(warnings map[string]int) int {
+	total := 0
+	for _, count := range warnings {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+const (
+	// storeRetryAttempts is the number of attempts of a store write failing with a transient error.
+	storeRetryAttempts = 4
+	// storeRetryDelay is the delay before the first retry of a store write. It doubles after each retry.
+	storeRetryDelay = 200 * time.Millisecond
+
+	// pageRetryBackoff is the delay before retrying a queued page that failed once. It doubles after each failure.
+	pageRetryBackoff = time.Minute
+	// pageRetryMaxBackoff caps the delay before retrying a queued page.
+	pageRetryMaxBackoff = 6 * time.Hour
+)
+
+// transientErrnos are the system errors that a network filesystem or a concurrent git process can cause
+// for a short time.
+var transientErrnos = []error{syscall.EAGAIN, syscall.EBUSY, syscall.EINTR, syscall.ESTALE, syscall.ETIMEDOUT}
+
+// isTransientStoreError returns true if a store write may succeed when retried.
+func isTransientStoreError(err error) bool {
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	// Git index lock, held by another git process
+	return strings.Contains(err.Error(), "index.lock")
+}
+
+// retryingTx is a transaction retrying the writes and deletions failing with a transient error, so that
+// a filesystem blip doesn't send the page back to the queue for a later run.
+type retryingTx struct {
+	store.Transaction
+	logger *slog.Logger
+	delay  time.Duration // Delay before the first retry
+}
+
+// Write writes a file, retrying on transient errors.
+func (t *retryingTx) Write(ctx context.Context, path string, content []byte) error {
+	return t.retry(ctx, "write", path, func() error {
+		return t.Transaction.Write(ctx, path, content)
+	})
+}
+
+// Delete deletes a file, retrying on transient errors.
+func (t *retryingTx) Delete(ctx context.Context, path string) error {
+	return t.retry(ctx, "delete", path, func() error {
+		return t.Transaction.Delete(ctx, path)
+	})
+}
+
+// retry runs an operation until it succeeds, fails with a non-transient error, or runs out of attempts.
+func (t *retryingTx) retry(ctx context.Context, operation, path string, run func() error) error {
+	delay := t.delay
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = run()
+		if err == nil || attempt == storeRetryAttempts || !isTransientStoreError(err) {
+			return err
+		}
+
+		t.logger.WarnContext(ctx, "transient store error, retrying",
+			"operation", operation, "path", path, "attempt", attempt, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+}
+
+// pageRetryDelay returns the delay before retrying a queued page after consecutive failures. It doubles after each
+// failure up to pageRetryMaxBackoff, minus a random jitter of up to half of it, so that pages failing together
+// (e.g. during a Notion outage) are not all retried together.
+func pageRetryDelay(failures int) time.Duration {
+	delay := pageRetryBackoff
+	for i := 1; i < failures && delay < pageRetryMaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, pageRetryMaxBackoff)
+	//nolint:gosec // Jitter, not security sensitive
+	return delay - rand.N(delay/2+1)
+}
+
+// deferFailedPage records a failed attempt to sync a queued page, and when it is retried.
+func deferFailedPage(page *queue.Page, now time.Time) {
+	page.Failures++
+	page.NotBefore = now.Add(pageRetryDelay(page.Failures))
+}
+
+// entryDeferred returns true if none of the pages of a queue entry can be retried yet.
+func entryDeferred(entry *queue.Entry, now time.Time) bool {
+	for i := range entry.Pages {
+		if !entry.Pages[i].NotBefore.After(now) {
+			return false
+		}
+	}
+	return len(entry.Pages) > 0
+}
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// flakyTx fails its first writes with an error.
+type flakyTx struct {
+	store.Transaction
+	failures int
+	err      error
+	calls    int
+}
+
+func (t *flakyTx) Write(ctx context.Context, path string, content []byte) error {
+	t.calls++
+	if t.calls <= t.failures {
+		return t.err
+	}
+	return t.Transaction.Write(ctx, path, content)
+}
+
+func newFlakyTx(t *testing.T, failures int, err error) (*flakyTx, *retryingTx) {
+	t.Helper()
+
+	tx, txErr := store.NewMemStore().BeginTx(context.Background())
+	if txErr != nil {
+		t.Fatal(txErr)
+	}
+	flaky := &flakyTx{Transaction: tx, failures: failures, err: err}
+	return flaky, &retryingTx{Transaction: flaky, logger: slog.Default(), delay: time.Millisecond}
+}
+
+func TestRetryingTx_Write(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pathErr := &fs.PathError{Op: "open", Path: "tech/a.md", Err: syscall.ESTALE}
+	lockErr := fmt.Errorf("add: %w", errors.New("unable to create .git/index.lock: file exists"))
+
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantErr   bool
+		wantCalls int
+	}{
+		{"stale file handle", 2, pathErr, false, 3},
+		{"git lock", 1, lockErr, false, 2},
+		{"retries exhausted", storeRetryAttempts, pathErr, true, storeRetryAttempts},
+		{"permanent error", 1, fs.ErrPermission, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flaky, tx := newFlakyTx(t, tt.failures, tt.err)
+			err := tx.Write(ctx, "tech/a.md", []byte("a"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if flaky.calls != tt.wantCalls {
+				t.Errorf("Write() made %d attempts, want %d", flaky.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPageRetryDelay(t *testing.T) {
+	t.Parallel()
+
+	for failures, want := range map[int]time.Duration{
+		1:   pageRetryBackoff,
+		2:   2 * pageRetryBackoff,
+		4:   8 * pageRetryBackoff,
+		100: pageRetryMaxBackoff,
+	} {
+		for range 10 {
+			if delay := pageRetryDelay(failures); delay < want/2 || delay > want {
+				t.Errorf("pageRetryDelay(%d) = %s, want between %s and %s", failures, delay, want/2, want)
+			}
+		}
+	}
+}
+
+func TestProcessQueue_RetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"object":"error","status":503,"code":"service_unavailable","message":"unavailable"}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	filename, err := qm.CreateEntry(ctx, queue.Entry{
+		Type:   queue.TypeUpdate,
+		Folder: "test",
+		Pages:  []queue.Page{{ID: "page1", LastEdited: time.Now()}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	failedRequests := requests.Load()
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+	if got := requests.Load(); failedRequests == 0 || got != failedRequests {
+		t.Errorf("requests = %d, then %d: the failed page should wait for its retry", failedRequests, got)
+	}
+
+	entry, err := qm.ReadEntry(ctx, filename)
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	page := entry.Pages[0]
+	if page.Failures != 1 || !page.NotBefore.After(time.Now()) {
+		t.Errorf("queued page = %+v, want 1 failure and a retry time in the future", page)
+	}
+}
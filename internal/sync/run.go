@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// Phases of a sync run, as reported in the run file.
+const (
+	RunPhaseStarting = "starting" // Run started, before the queue is processed
+	RunPhaseQueue    = "queue"    // Processing the queue
+	RunPhasePrune    = "prune"    // Keeping the mirror under its size cap
+	RunPhaseSave     = "save"     // Saving the state
+	RunPhaseCommit   = "commit"   // Committing and pushing the changes
+)
+
+// RunStatus describes the sync run in progress. It is written to the run file (.notion-sync/run.json)
+// while a run is in progress and removed when it ends, so that external orchestrators can follow runs
+// and detect stuck ones without parsing logs.
+type RunStatus struct {
+	PID          int       `json:"pid"`
+	Hostname     string    `json:"hostname,omitempty"`
+	Phase        string    `json:"phase"`
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"` // Updated on every phase change and processed page
+	Folder       string    `json:"folder,omitempty"`
+	QueueFile    string    `json:"queue_file,omitempty"` // Queue file being processed
+	CurrentPage  string    `json:"current_page,omitempty"`
+	Processed    int       `json:"processed"`
+	Skipped      int       `json:"skipped"`
+	Dropped      int       `json:"dropped"`
+	FilesWritten int       `json:"files_written"`
+	QueueFiles   int       `json:"queue_files"`
+	// Warnings are the conversion warnings of the pages synced so far, by kind (see converter.ConversionWarning)
+	Warnings map[string]int `json:"warnings,omitempty"`
+}
+
+// StartRun starts reporting the run in the run file, until FinishRun is called. Queue processing starts
+// and finishes a run by itself when none is in progress; commands start their own to cover the phases
+// around it, like committing. It returns false if a run is already in progress.
+func (c *Crawler) StartRun(ctx context.Context, folder string) bool {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.run != nil {
+		return false
+	}
+
+	if previous, err := c.store.Read(ctx, store.RunFile); err == nil {
+		var status RunStatus
+		_ = json.Unmarshal(previous, &status)
+		c.logger.WarnContext(ctx, "previous sync run did not finish cleanly",
+			"pid", status.PID,
+			"phase", status.Phase,
+			"started_at", status.StartedAt)
+	}
+
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	c.run = &RunStatus{
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		Phase:     RunPhaseStarting,
+		StartedAt: now,
+		UpdatedAt: now,
+		Folder:    folder,
+	}
+	c.writeRunLocked(ctx)
+	registerActiveRun(c)
+	c.runGit = c.gitMetrics(ctx)
+	return true
+}
+
+// ActiveRun returns the run in progress in another process, as reported by the run file, or nil if there is
+// none. Run files not updated for staleAfter were left by processes that died, and are ignored.
+func (c *Crawler) ActiveRun(ctx context.Context, staleAfter time.Duration) *RunStatus {
+	data, err := c.store.Read(ctx, store.RunFile)
+	if err != nil {
+		return nil
+	}
+	var status RunStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil
+	}
+
+	hostname, _ := os.Hostname()
+	if status.PID == os.Getpid() && status.Hostname == hostname {
+		return nil
+	}
+	if time.Since(status.UpdatedAt) > staleAfter {
+		return nil
+	}
+	return &status
+}
+
+// FinishRun removes the run file.
+func (c *Crawler) FinishRun(ctx context.Context) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.run == nil {
+		return
+	}
+	c.run = nil
+	unregisterActiveRun(c)
+	c.logGitMetrics(ctx, c.runGit)
+	c.runGit = nil
+
+	if writer, ok := c.store.(store.RuntimeFileWriter); ok {
+		if err := writer.RemoveRuntimeFile(ctx, store.RunFile); err != nil {
+			c.logger.WarnContext(ctx, "failed to remove run file", "error", err)
+		}
+	}
+}
+
+// SetRunPhase reports the phase of the run in progress.
+func (c *Crawler) SetRunPhase(ctx context.Context, phase string) {
+	c.updateRun(ctx, func(status *RunStatus) {
+		status.Phase = phase
+		status.QueueFile = ""
+		status.CurrentPage = ""
+	})
+}
+
+// updateRun updates the run in progress, if any, and writes the run file.
+func (c *Crawler) updateRun(ctx context.Context, update func(status *RunStatus)) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if c.run == nil {
+		return
+	}
+	update(c.run)
+	c.run.UpdatedAt = time.Now()
+	c.writeRunLocked(ctx)
+}
+
+// writeRunLocked writes the run file. Failures are only logged: reporting must not stop a sync.
+// Caller must hold c.runMu.
+func (c *Crawler) writeRunLocked(ctx context.Context) {
+	writer, ok := c.store.(store.RuntimeFileWriter)
+	if !ok {
+		return
+	}
+
+	data, err := json.MarshalIndent(c.run, "", "  ")
+	if err == nil {
+		err = writer.WriteRuntimeFile(ctx, store.RunFile, data)
+	}
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to write run file", "error", err)
+	}
+}
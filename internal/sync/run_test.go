@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+func TestCrawler_RunFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	readRun := func() *RunStatus {
+		t.Helper()
+		data, err := crawler.store.Read(ctx, store.RunFile)
+		if err != nil {
+			return nil
+		}
+		var status RunStatus
+		if err := json.Unmarshal(data, &status); err != nil {
+			t.Fatal(err)
+		}
+		return &status
+	}
+
+	if !crawler.StartRun(ctx, "tech") {
+		t.Fatal("StartRun() = false, want true")
+	}
+	if crawler.StartRun(ctx, "other") {
+		t.Error("StartRun() during a run = true, want false")
+	}
+
+	status := readRun()
+	if status == nil || status.PID != os.Getpid() || status.Phase != RunPhaseStarting || status.Folder != "tech" {
+		t.Fatalf("run file = %+v", status)
+	}
+
+	crawler.SetRunPhase(ctx, RunPhaseQueue)
+	crawler.updateRun(ctx, func(status *RunStatus) {
+		status.CurrentPage = "page1"
+		status.Processed = 3
+	})
+	status = readRun()
+	if status.Phase != RunPhaseQueue || status.CurrentPage != "page1" || status.Processed != 3 ||
+		status.UpdatedAt.Before(status.StartedAt) {
+		t.Errorf("run file = %+v", status)
+	}
+
+	crawler.FinishRun(ctx)
+	if status := readRun(); status != nil {
+		t.Errorf("run file after FinishRun = %+v, want none", status)
+	}
+
+	// Without a run, updates are ignored
+	crawler.SetRunPhase(ctx, RunPhaseCommit)
+	if status := readRun(); status != nil {
+		t.Errorf("run file without a run = %+v, want none", status)
+	}
+}
+
+func TestCrawler_ActiveRun(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	writeRun := func(status *RunStatus) {
+		t.Helper()
+		data, err := json.Marshal(status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := crawler.store.(store.RuntimeFileWriter).WriteRuntimeFile(ctx, store.RunFile, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if run := crawler.ActiveRun(ctx, time.Hour); run != nil {
+		t.Errorf("ActiveRun() without run file = %+v, want nil", run)
+	}
+
+	// Our own run is not reported
+	crawler.StartRun(ctx, "")
+	if run := crawler.ActiveRun(ctx, time.Hour); run != nil {
+		t.Errorf("ActiveRun() during our run = %+v, want nil", run)
+	}
+	crawler.FinishRun(ctx)
+
+	writeRun(&RunStatus{PID: os.Getpid() + 1, Phase: RunPhaseQueue, UpdatedAt: time.Now().Add(-time.Minute)})
+	if run := crawler.ActiveRun(ctx, time.Hour); run == nil || run.Phase != RunPhaseQueue {
+		t.Errorf("ActiveRun() = %+v, want the run of the other process", run)
+	}
+	// A run file not updated for a while was left by a dead process
+	if run := crawler.ActiveRun(ctx, 30*time.Second); run != nil {
+		t.Errorf("ActiveRun() with a stale run file = %+v, want nil", run)
+	}
+}
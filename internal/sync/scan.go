@@ -0,0 +1,335 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	stdsync "sync"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// ScanOptions configures the scan operation.
+type ScanOptions struct {
+	Full        bool // Descend into every tracked subtree, not only the ones whose parent changed
+	Concurrency int  // Number of folders scanned in parallel by ScanRoots (0 or 1 = sequential)
+}
+
+// ScanResult contains the result of a scan operation.
+type ScanResult struct {
+	RootsScanned    int // Root pages scanned (ScanRoots only)
+	PagesScanned    int // Pages whose children were listed
+	NewChildren     int // Untracked pages queued with type "init"
+	ChangedPages    int // Tracked pages edited since their last sync, queued with type "update"
+	SkippedSubtrees int // Tracked pages unchanged since their last sync, not descended into
+}
+
+// childPageRef is a child page or database found in a page's blocks.
+type childPageRef struct {
+	id         string
+	lastEdited time.Time
+	title      string
+	database   bool
+	archived   bool // Archived or trashed
+}
+
+// ScanPage re-scans a page to discover child pages and queues them.
+// New children are queued for an initial sync. Tracked children edited since their last sync
+// are queued for update and scanned in turn, since adding a child page edits its parent.
+// Unchanged subtrees are skipped, unless opts.Full is set.
+func (c *Crawler) ScanPage(ctx context.Context, pageID string, opts ScanOptions) (*ScanResult, error) {
+	c.logger.InfoContext(ctx, "scanning page for children", "page_id", pageID, "full", opts.Full)
+
+	// Load state
+	if err := c.loadState(ctx); err != nil {
+		c.logger.WarnContext(ctx, "could not load state, starting fresh", "error", err)
+	}
+
+	// Load page registry to get folder
+	reg, err := c.loadPageRegistry(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("page not found in registry (use 'add' to add a new root page): %w", err)
+	}
+
+	// Ensure transaction is available
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+
+	result := &ScanResult{}
+	if err := c.scanTree(ctx, reg, opts, result); err != nil {
+		return nil, err
+	}
+
+	c.logger.InfoContext(ctx, "scan complete",
+		"page_id", pageID,
+		"pages_scanned", result.PagesScanned,
+		"new_children", result.NewChildren,
+		"changed_pages", result.ChangedPages,
+		"skipped_subtrees", result.SkippedSubtrees)
+
+	return result, nil
+}
+
+// ScanRoots scans every enabled root page of root.md, like ScanPage.
+// Folders are scanned in parallel up to opts.Concurrency, the roots of a folder one after the other.
+// Roots that are not synced yet are skipped: their children are discovered by their initial sync.
+func (c *Crawler) ScanRoots(ctx context.Context, opts ScanOptions) (*ScanResult, error) {
+	c.logger.InfoContext(ctx, "scanning all root pages", "full", opts.Full, "concurrency", opts.Concurrency)
+
+	// Load state
+	if err := c.loadState(ctx); err != nil {
+		c.logger.WarnContext(ctx, "could not load state, starting fresh", "error", err)
+	}
+
+	rootsByFolder, folders, err := c.enabledRootsByFolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure transaction is available
+	if err := c.EnsureTransaction(ctx); err != nil {
+		return nil, fmt.Errorf("ensure transaction: %w", err)
+	}
+
+	results := make([]ScanResult, len(folders))
+	errs := make([]error, len(folders))
+	sem := make(chan struct{}, max(opts.Concurrency, 1))
+	var wg stdsync.WaitGroup
+
+	for i, folder := range folders {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, reg := range rootsByFolder[folder] {
+				if err := c.scanTree(ctx, reg, opts, &results[i]); err != nil {
+					errs[i] = fmt.Errorf("scan root %s of folder %s: %w", reg.ID, folder, err)
+					return
+				}
+				results[i].RootsScanned++
+			}
+		})
+	}
+	wg.Wait()
+
+	result := &ScanResult{}
+	for i := range results {
+		result.add(&results[i])
+	}
+
+	c.logger.InfoContext(ctx, "scan of all roots complete",
+		"roots_scanned", result.RootsScanned,
+		"pages_scanned", result.PagesScanned,
+		"new_children", result.NewChildren,
+		"changed_pages", result.ChangedPages,
+		"skipped_subtrees", result.SkippedSubtrees)
+
+	for _, err := range errs {
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// enabledRootsByFolder returns the registries of the enabled roots of root.md grouped by folder,
+// and the sorted list of folders.
+func (c *Crawler) enabledRootsByFolder(ctx context.Context) (map[string][]*PageRegistry, []string, error) {
+	manifest, err := c.ParseRootMd(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse root.md: %w", err)
+	}
+	if manifest == nil {
+		return nil, nil, nil
+	}
+
+	rootsByFolder := make(map[string][]*PageRegistry)
+	var folders []string
+	for _, entry := range manifest.Entries {
+		if !entry.Enabled {
+			continue
+		}
+
+		reg, err := c.loadPageRegistry(ctx, entry.PageID)
+		if err != nil {
+			c.logger.InfoContext(ctx, "skipping root not synced yet", "page_id", entry.PageID, "folder", entry.Folder)
+			continue
+		}
+
+		if _, ok := rootsByFolder[reg.Folder]; !ok {
+			folders = append(folders, reg.Folder)
+		}
+		rootsByFolder[reg.Folder] = append(rootsByFolder[reg.Folder], reg)
+	}
+	slices.Sort(folders)
+
+	return rootsByFolder, folders, nil
+}
+
+// add accumulates another result into this one.
+func (r *ScanResult) add(other *ScanResult) {
+	r.RootsScanned += other.RootsScanned
+	r.PagesScanned += other.PagesScanned
+	r.NewChildren += other.NewChildren
+	r.ChangedPages += other.ChangedPages
+	r.SkippedSubtrees += other.SkippedSubtrees
+}
+
+// scanTree scans a tracked page and its changed descendants.
+func (c *Crawler) scanTree(ctx context.Context, reg *PageRegistry, opts ScanOptions, result *ScanResult) error {
+	visited := map[string]bool{reg.ID: true}
+	toScan := []string{reg.ID}
+
+	for len(toScan) > 0 {
+		current := toScan[0]
+		toScan = toScan[1:]
+
+		descend, err := c.scanChildren(ctx, current, reg.Folder, opts, result)
+		if err != nil {
+			return err
+		}
+		for _, childID := range descend {
+			if !visited[childID] {
+				visited[childID] = true
+				toScan = append(toScan, childID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// scanChildren lists the children of a page, queues the new and changed ones, and returns
+// the tracked children that should be scanned next.
+func (c *Crawler) scanChildren(
+	ctx context.Context, pageID, folder string, opts ScanOptions, result *ScanResult,
+) ([]string, error) {
+	blocks, err := c.client.GetAllBlockChildren(ctx, pageID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("fetch blocks of %s: %w", pageID, err)
+	}
+	result.PagesScanned++
+
+	var newChildren []string
+	var changed []queue.Page
+	var descend []string
+
+	for _, child := range c.excludeChildren(ctx, folder, findChildPageRefs(blocks)) {
+		childReg, err := c.loadPageRegistry(ctx, child.id)
+		if err != nil {
+			// Child doesn't exist yet: its own children are discovered when it is synced
+			newChildren = append(newChildren, child.id)
+			continue
+		}
+
+		edited := child.lastEdited.After(childReg.LastEdited)
+		if edited {
+			changed = append(changed, queue.Page{ID: child.id, LastEdited: child.lastEdited})
+		}
+
+		switch {
+		case childReg.Type == notionTypeDatabase:
+			// Database rows are discovered when the database is synced
+		case edited || opts.Full:
+			descend = append(descend, child.id)
+		default:
+			result.SkippedSubtrees++
+		}
+	}
+
+	if err := c.queueScanResults(ctx, pageID, folder, newChildren, changed, result); err != nil {
+		return nil, err
+	}
+
+	return descend, nil
+}
+
+// queueScanResults queues the new and changed children found under a page.
+// Queue writes and quota checks are serialized, as ScanRoots scans folders in parallel.
+func (c *Crawler) queueScanResults(
+	ctx context.Context, pageID, folder string, newChildren []string, changed []queue.Page, result *ScanResult,
+) error {
+	c.scanMu.Lock()
+	defer c.scanMu.Unlock()
+
+	if len(newChildren) > 0 && c.folderQuotaExceeded(ctx, folder) {
+		c.logger.WarnContext(ctx, "not queueing new child pages, folder or mirror quota exceeded",
+			"page_id", pageID,
+			"folder", folder,
+			"new_children_count", len(newChildren))
+		newChildren = nil
+	}
+
+	if len(newChildren) > 0 {
+		c.logger.InfoContext(ctx, "queueing new child pages",
+			"page_id", pageID,
+			"new_children_count", len(newChildren))
+
+		if _, err := c.queueManager.CreateEntry(ctx, queue.Entry{
+			Type:     queueTypeInit,
+			Folder:   folder,
+			PageIDs:  newChildren,
+			ParentID: pageID,
+		}); err != nil {
+			return fmt.Errorf("create queue entry: %w", err)
+		}
+		result.NewChildren += len(newChildren)
+	}
+
+	if len(changed) > 0 {
+		c.logger.InfoContext(ctx, "queueing changed child pages",
+			"page_id", pageID,
+			"changed_count", len(changed))
+
+		if _, err := c.queueManager.CreateEntry(ctx, queue.Entry{
+			Type:   "update",
+			Folder: folder,
+			Pages:  changed,
+		}); err != nil {
+			return fmt.Errorf("create queue entry: %w", err)
+		}
+		result.ChangedPages += len(changed)
+	}
+
+	return nil
+}
+
+// findChildPageRefs extracts child pages and databases, with their last edit time and title, from blocks.
+func findChildPageRefs(blocks []notion.Block) []childPageRef {
+	var refs []childPageRef
+	seen := make(map[string]bool)
+
+	var traverse func([]notion.Block)
+	traverse = func(blocks []notion.Block) {
+		for i := range blocks {
+			block := &blocks[i]
+			isChild := (block.Type == "child_page" && block.ChildPage != nil) ||
+				(block.Type == "child_database" && block.ChildDatabase != nil)
+			if childID := normalizePageID(block.ID); isChild && !seen[childID] {
+				seen[childID] = true
+				ref := childPageRef{
+					id:         childID,
+					lastEdited: block.LastEditedTime,
+					database:   block.Type == "child_database",
+					archived:   block.Archived || block.InTrash,
+				}
+				if ref.database {
+					ref.title = block.ChildDatabase.Title
+				} else {
+					ref.title = block.ChildPage.Title
+				}
+				refs = append(refs, ref)
+			}
+			if len(block.Children) > 0 {
+				traverse(block.Children)
+			}
+		}
+	}
+
+	traverse(blocks)
+	return refs
+}
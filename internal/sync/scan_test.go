@@ -0,0 +1,181 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// newScanTestCrawler returns a crawler backed by a fake Notion API serving the given page children.
+// Children are "id@last_edited" strings, and the returned function lists the pages whose children were fetched.
+func newScanTestCrawler(t *testing.T, children map[string][]string) (*Crawler, *queue.Manager, func() []string) {
+	t.Helper()
+
+	var mu stdsync.Mutex
+	var fetched []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/blocks/"), "/children")
+		mu.Lock()
+		fetched = append(fetched, pageID)
+		mu.Unlock()
+
+		blocks := make([]string, 0, len(children[pageID]))
+		for _, child := range children[pageID] {
+			id, lastEdited, _ := strings.Cut(child, "@")
+			blocks = append(blocks, fmt.Sprintf(
+				`{"object":"block","id":%q,"type":"child_page","last_edited_time":%q,"child_page":{"title":%q}}`,
+				id, lastEdited, id))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"object":"list","results":[%s],"has_more":false}`, strings.Join(blocks, ","))
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	return crawler, qm, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(fetched)
+	}
+}
+
+func saveScanTestRegistry(t *testing.T, crawler *Crawler, folder, id string, lastEdited time.Time) {
+	t.Helper()
+
+	if err := crawler.savePageRegistry(context.Background(), &PageRegistry{
+		ID:         id,
+		Type:       notionTypePage,
+		Folder:     folder,
+		FilePath:   folder + "/" + id + ".md",
+		LastEdited: lastEdited,
+	}); err != nil {
+		t.Fatalf("failed to save registry: %v", err)
+	}
+}
+
+func TestScanPage_OnlyDescendsIntoChangedSubtrees(t *testing.T) {
+	t.Parallel()
+
+	synced := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	edited := synced.Add(time.Hour)
+	at := func(id string, lastEdited time.Time) string { return id + "@" + lastEdited.Format(time.RFC3339) }
+	children := map[string][]string{
+		"root":      {at("changed", edited), at("unchanged", synced), at("new1", edited)},
+		"changed":   {at("new2", edited)},
+		"unchanged": {at("new3", edited)},
+	}
+
+	tests := []struct {
+		name        string
+		full        bool
+		wantFetched []string
+		wantResult  ScanResult
+	}{
+		{
+			name:        "smart",
+			wantFetched: []string{"root", "changed"},
+			wantResult:  ScanResult{PagesScanned: 2, NewChildren: 2, ChangedPages: 1, SkippedSubtrees: 1},
+		},
+		{
+			name:        "full",
+			full:        true,
+			wantFetched: []string{"root", "changed", "unchanged"},
+			wantResult:  ScanResult{PagesScanned: 3, NewChildren: 3, ChangedPages: 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			crawler, qm, fetched := newScanTestCrawler(t, children)
+			for _, id := range []string{"root", "changed", "unchanged"} {
+				saveScanTestRegistry(t, crawler, "test", id, synced)
+			}
+
+			result, err := crawler.ScanPage(ctx, "root", ScanOptions{Full: tc.full})
+			if err != nil {
+				t.Fatalf("ScanPage() error = %v", err)
+			}
+			if *result != tc.wantResult {
+				t.Errorf("ScanPage() = %+v, want %+v", *result, tc.wantResult)
+			}
+			if got := fetched(); !slices.Equal(got, tc.wantFetched) {
+				t.Errorf("fetched children of %v, want %v", got, tc.wantFetched)
+			}
+
+			queued, err := qm.IsPageQueued(ctx, "changed", "update")
+			if err != nil || !queued {
+				t.Errorf("changed page should be queued for update (queued=%v, err=%v)", queued, err)
+			}
+			queued, err = qm.IsPageQueued(ctx, "unchanged", "update")
+			if err != nil || queued {
+				t.Errorf("unchanged page should not be queued (queued=%v, err=%v)", queued, err)
+			}
+		})
+	}
+}
+
+func TestScanRoots_ScansEnabledRoots(t *testing.T) {
+	t.Parallel()
+
+	const (
+		techRoot     = "11111111111111111111111111111111"
+		productRoot  = "22222222222222222222222222222222"
+		disabledRoot = "33333333333333333333333333333333"
+		pendingRoot  = "44444444444444444444444444444444"
+	)
+
+	synced := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(id string) string { return id + "@" + synced.Add(time.Hour).Format(time.RFC3339) }
+	children := map[string][]string{
+		techRoot:     {at("tech1"), at("tech2")},
+		productRoot:  {at("product1")},
+		disabledRoot: {at("disabled1")},
+	}
+
+	ctx := context.Background()
+	crawler, _, fetched := newScanTestCrawler(t, children)
+	saveScanTestRegistry(t, crawler, "tech", techRoot, synced)
+	saveScanTestRegistry(t, crawler, "product", productRoot, synced)
+	saveScanTestRegistry(t, crawler, "archive", disabledRoot, synced)
+
+	if err := crawler.WriteRootMd(ctx, &RootManifest{Entries: []RootEntry{
+		{Folder: "tech", Enabled: true, URL: techRoot, PageID: techRoot},
+		{Folder: "product", Enabled: true, URL: productRoot, PageID: productRoot},
+		{Folder: "archive", Enabled: false, URL: disabledRoot, PageID: disabledRoot},
+		{Folder: "tech", Enabled: true, URL: pendingRoot, PageID: pendingRoot},
+	}}); err != nil {
+		t.Fatalf("failed to write root.md: %v", err)
+	}
+
+	result, err := crawler.ScanRoots(ctx, ScanOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("ScanRoots() error = %v", err)
+	}
+
+	want := ScanResult{RootsScanned: 2, PagesScanned: 2, NewChildren: 3}
+	if *result != want {
+		t.Errorf("ScanRoots() = %+v, want %+v", *result, want)
+	}
+
+	got := fetched()
+	slices.Sort(got)
+	if wantFetched := []string{techRoot, productRoot}; !slices.Equal(got, wantFetched) {
+		t.Errorf("fetched children of %v, want %v", got, wantFetched)
+	}
+}
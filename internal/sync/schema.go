@@ -0,0 +1,44 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+// queueSchemaRefresh queues a property-only refresh of the synced rows of a database whose schema
+// changed since its last sync. Rows keep their frontmatter until they are edited otherwise, so a new
+// property or a renamed select option would stay stale. Databases synced before schema tracking are
+// only recorded, not refreshed.
+func (c *Crawler) queueSchemaRefresh(ctx context.Context, params *writeAndRegisterParams) {
+	if params.existingReg == nil || params.existingReg.SchemaEdited.IsZero() ||
+		!params.schemaEdited.After(params.existingReg.SchemaEdited) {
+		return
+	}
+
+	var rows []queue.Page
+	for _, childID := range params.children {
+		if _, err := c.loadPageRegistry(ctx, childID); err == nil {
+			rows = append(rows, queue.Page{ID: childID, LastEdited: params.schemaEdited})
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	entry := queue.Entry{
+		Type:     queueTypeProperties,
+		Folder:   params.folder,
+		Pages:    rows,
+		ParentID: params.itemID,
+	}
+	if _, err := c.queueManager.CreateEntry(ctx, entry); err != nil {
+		c.logger.WarnContext(ctx, "failed to queue property refresh", "error", err)
+		return
+	}
+	c.logger.InfoContext(ctx, "database schema changed, queued property refresh of its rows",
+		"database_id", params.itemID,
+		"schema_edited", params.schemaEdited,
+		"previous_schema_edited", params.existingReg.SchemaEdited,
+		"rows", len(rows))
+}
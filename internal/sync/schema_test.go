@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestSchemaChange_RefreshesRowProperties(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	status := "Doing"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pages/row" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(notion.Page{
+			Object:         "page",
+			ID:             "row",
+			LastEditedTime: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			Parent:         notion.Parent{Type: "database_id", DatabaseID: "db"},
+			Properties: notion.Properties{
+				"Name":   {Type: "title", Title: []notion.RichText{{PlainText: "Row"}}},
+				"Status": {Type: "select", Select: &notion.SelectOption{Name: status}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	synced := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldContent := "---\nnotion_id: row\nlast_edited: 2026-01-01T00:00:00Z\nlast_synced: 2026-01-01T00:00:00Z\n" +
+		"properties:\n  Status: \"Todo\"\n---\n\n# Row\n\nBody\n"
+	if err := crawler.tx.Write(ctx, "test/db/row.md", []byte(oldContent)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{
+		ID: "row", Type: notionTypePage, Folder: "test", FilePath: "test/db/row.md", Title: "Row",
+		LastEdited: synced, LastSynced: synced, ParentID: "db",
+	}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	// A database synced before schema tracking is not refreshed
+	params := &writeAndRegisterParams{
+		itemID: "db", folder: "test", children: []string{"row", "unsynced"},
+		existingReg:  &PageRegistry{ID: "db"},
+		schemaEdited: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	crawler.queueSchemaRefresh(ctx, params)
+	if files, _ := crawler.queueManager.ListEntries(ctx); len(files) != 0 {
+		t.Fatalf("queued %d entries without a previous schema time, want none", len(files))
+	}
+
+	params.existingReg.SchemaEdited = synced
+	crawler.queueSchemaRefresh(ctx, params)
+	files, err := crawler.queueManager.ListEntries(ctx)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListEntries() = %v, %v, want 1 entry", files, err)
+	}
+	entry, err := crawler.queueManager.ReadEntry(ctx, files[0])
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	if entry.Type != queueTypeProperties || len(entry.Pages) != 1 || entry.Pages[0].ID != "row" {
+		t.Fatalf("entry = %+v, want a properties entry with the synced row", entry)
+	}
+
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+
+	content, err := crawler.store.Read(ctx, "test/db/row.md")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(content), "Status: \"Doing\"") ||
+		!strings.Contains(string(content), "last_edited: 2026-01-01T00:00:00Z") ||
+		!strings.HasSuffix(string(content), "# Row\n\nBody\n") {
+		t.Errorf("content =\n%s\nwant refreshed properties and unchanged body", content)
+	}
+	reg, err := crawler.loadPageRegistry(ctx, "row")
+	if err != nil {
+		t.Fatalf("loadPageRegistry() error = %v", err)
+	}
+	if !reg.LastSynced.After(synced) || !reg.LastEdited.Equal(synced) {
+		t.Errorf("registry last synced/edited = %v/%v, want refreshed sync and unchanged edit",
+			reg.LastSynced, reg.LastEdited)
+	}
+}
@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Fields of search matches.
+const (
+	searchFieldTitle   = "title"
+	searchFieldContent = "content"
+)
+
+const (
+	// searchMaxSnippets is the maximum number of content and property matches returned per page.
+	searchMaxSnippets = 3
+	// searchSnippetContext is the number of characters kept around a match in snippets.
+	searchSnippetContext = 60
+)
+
+// SearchMatch is a match of a search query in a page.
+type SearchMatch struct {
+	Field   string `json:"field"`          // "title", "content", or the frontmatter property ("properties.Status")
+	Line    int    `json:"line,omitempty"` // Line of the match in the file (1-based, 0 for the title)
+	Snippet string `json:"snippet"`        // Text around the match
+}
+
+// SearchResult is a page matching a search query.
+type SearchResult struct {
+	ID      string        `json:"id"`
+	Title   string        `json:"title"`
+	Folder  string        `json:"folder"`
+	Path    string        `json:"path"`
+	Matches []SearchMatch `json:"matches"`
+	Total   int           `json:"total"` // Number of matches, including the ones not returned
+}
+
+// Search searches the titles, frontmatter properties and content of the synced pages for a query, without any
+// Notion API call. The search is case-insensitive. Results are ordered by title matches first, then by number of
+// matches. An empty folder searches all folders.
+func (c *Crawler) Search(ctx context.Context, query, folder string) ([]*SearchResult, error) {
+	needle := strings.ToLower(strings.TrimSpace(query))
+	if needle == "" {
+		return nil, nil
+	}
+
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list registries: %w", err)
+	}
+
+	var results []*SearchResult
+	for _, reg := range registries {
+		if folder != "" && reg.Folder != folder {
+			continue
+		}
+		result := &SearchResult{ID: reg.ID, Title: reg.Title, Folder: reg.Folder, Path: reg.FilePath}
+		if strings.Contains(strings.ToLower(reg.Title), needle) {
+			result.addMatch(SearchMatch{Field: searchFieldTitle, Snippet: reg.Title})
+		}
+		if reg.FilePath != "" {
+			data, err := c.store.Read(ctx, reg.FilePath)
+			if err != nil {
+				c.logger.DebugContext(ctx, "skipping unreadable page file", "path", reg.FilePath, "error", err)
+			} else {
+				searchFile(result, string(data), needle)
+			}
+		}
+		if result.Total > 0 {
+			results = append(results, result)
+		}
+	}
+
+	slices.SortFunc(results, func(a, b *SearchResult) int {
+		aTitle, bTitle := a.Matches[0].Field == searchFieldTitle, b.Matches[0].Field == searchFieldTitle
+		if aTitle != bTitle {
+			if aTitle {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(cmp.Compare(b.Total, a.Total), cmp.Compare(a.Path, b.Path))
+	})
+	return results, nil
+}
+
+// addMatch records a match, keeping the title match and up to searchMaxSnippets other ones.
+func (r *SearchResult) addMatch(match SearchMatch) {
+	r.Total++
+	if match.Field == searchFieldTitle || len(r.Matches) < searchMaxSnippets {
+		r.Matches = append(r.Matches, match)
+	}
+}
+
+// searchFile adds the matches of a lowercase query in the frontmatter properties and the content of a page file.
+func searchFile(result *SearchResult, content, needle string) {
+	lines := strings.Split(content, "\n")
+	frontmatterEnd := -1
+	if len(lines) > 2 && lines[0] == "---" {
+		for i := 1; i < len(lines); i++ {
+			if lines[i] == "---" {
+				frontmatterEnd = i
+				break
+			}
+		}
+	}
+
+	topKey := ""
+	for i, line := range lines {
+		if i > 0 && i < frontmatterEnd && !strings.HasPrefix(line, " ") {
+			topKey, _, _ = strings.Cut(line, ":")
+		}
+		idx := strings.Index(strings.ToLower(line), needle)
+		if idx < 0 || i == 0 || i == frontmatterEnd {
+			continue
+		}
+
+		field := searchFieldContent
+		if i < frontmatterEnd {
+			field = topKey
+			if nested, _, found := strings.Cut(strings.TrimSpace(line), ":"); found && strings.HasPrefix(line, " ") {
+				field += "." + nested
+			}
+		}
+		result.addMatch(SearchMatch{Field: field, Line: i + 1, Snippet: searchSnippet(line, idx, len(needle))})
+	}
+}
+
+// searchSnippet returns the text around a match in a line, cut at searchSnippetContext characters around it.
+func searchSnippet(line string, idx, length int) string {
+	idx = min(idx, len(line)) // Lowercase text may be longer
+	start := max(idx-searchSnippetContext, 0)
+	end := min(idx+length+searchSnippetContext, len(line))
+	// Don't cut multi-byte characters
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+
+	snippet := strings.TrimSpace(line[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(line) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	pages := []struct {
+		reg     *PageRegistry
+		content string
+	}{
+		{
+			reg:     &PageRegistry{ID: "page1", Folder: "tech", Title: "Deploy guide", FilePath: "tech/deploy-guide.md"},
+			content: "---\ntitle: \"Deploy guide\"\n---\n# Deploy guide\n\nRun the deploy script.\n",
+		},
+		{
+			reg: &PageRegistry{ID: "page2", Folder: "tech", Title: "Tasks", FilePath: "tech/tasks.md"},
+			content: "---\ntitle: Tasks\nproperties:\n  Status: Deploying\n---\n# Tasks\n\n" +
+				strings.Repeat("x", 100) + " deploy " + strings.Repeat("y", 100) + "\n",
+		},
+		{
+			reg:     &PageRegistry{ID: "page3", Folder: "hr", Title: "Onboarding", FilePath: "hr/onboarding.md"},
+			content: "---\ntitle: Onboarding\n---\n# Onboarding\n\nAsk IT to DEPLOY your laptop.\n",
+		},
+		{
+			reg:     &PageRegistry{ID: "page4", Folder: "hr", Title: "Holidays", FilePath: "hr/holidays.md"},
+			content: "---\ntitle: Holidays\n---\n# Holidays\n",
+		},
+	}
+	for _, page := range pages {
+		if err := crawler.savePageRegistry(ctx, page.reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+		if err := crawler.tx.Write(ctx, page.reg.FilePath, []byte(page.content)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	results, err := crawler.Search(ctx, "Deploy", "")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	var paths []string
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	// Title matches first, then by number of matches
+	if got := strings.Join(paths, ","); got != "tech/deploy-guide.md,tech/tasks.md,hr/onboarding.md" {
+		t.Fatalf("Search() paths = %s", got)
+	}
+	if got := results[0].Matches[0]; got.Field != searchFieldTitle || got.Line != 0 {
+		t.Errorf("first match = %+v, want the title", got)
+	}
+	tasks := results[1].Matches
+	if len(tasks) != 2 || tasks[0].Field != "properties.Status" || tasks[0].Line != 4 {
+		t.Fatalf("matches of tasks = %+v, want the Status property then the content", tasks)
+	}
+	if got := tasks[1].Snippet; !strings.HasPrefix(got, "…x") || !strings.HasSuffix(got, "y…") ||
+		!strings.Contains(got, " deploy ") {
+		t.Errorf("content snippet = %q, want the text around the match", got)
+	}
+
+	results, err = crawler.Search(ctx, "deploy", "hr")
+	if err != nil || len(results) != 1 || results[0].ID != "page3" {
+		t.Errorf("Search() in hr = %+v, %v; want the onboarding page", results, err)
+	}
+}
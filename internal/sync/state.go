@@ -5,6 +5,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/converter"
 	"github.com/fclairamb/ntnsync/internal/version"
 )
 
@@ -24,6 +25,14 @@ type State struct {
 	Folders          []string   `json:"folders"`
 	LastPullTime     *time.Time `json:"last_pull_time,omitempty"`
 	OldestPullResult *time.Time `json:"oldest_pull_result,omitempty"` // Oldest page seen in last pull
+	// FilenameRules are the filename rules this mirror was created with.
+	FilenameRules *converter.FilenameRules `json:"filename_rules,omitempty"`
+	// Perf holds the pipeline metrics of the last sync runs, oldest first.
+	Perf []RunPerf `json:"perf,omitempty"`
+	// Crawl is the progress of the incremental deep crawl (sync --crawl-budget), if one was run.
+	Crawl *CrawlProgress `json:"crawl,omitempty"`
+	// StatusBlockIDs are the blocks of the last sync report written to the status page (see reportStatus).
+	StatusBlockIDs []string `json:"status_block_ids,omitempty"`
 }
 
 // NewState creates a new empty state.
@@ -63,6 +72,11 @@ type PageRegistry struct {
 	ParentID       string    `json:"parent_id,omitempty"`
 	Children       []string  `json:"children,omitempty"`
 	ContentHash    string    `json:"content_hash,omitempty"`
+	Size           int64     `json:"size,omitempty"`          // Size of the markdown file in bytes
+	Aliases        []string  `json:"aliases,omitempty"`       // Previous titles and file paths, oldest first
+	SchemaEdited   time.Time `json:"schema_edited,omitzero"`  // Databases only: last schema edit time
+	OpenComments   int       `json:"open_comments,omitempty"` // Unresolved comments (with NTN_COMMENT_COUNTS)
+	Assets         []string  `json:"assets,omitzero"`         // File IDs of the assets used (nil = not recorded yet)
 }
 
 // FileRegistry is stored in .notion-sync/ids/file-{id}.json
@@ -73,6 +87,7 @@ type FileRegistry struct {
 	FilePath       string    `json:"file_path"`  // Local file path (directory + name)
 	SourceURL      string    `json:"source_url"` // Original S3 URL
 	LastSynced     time.Time `json:"last_synced"`
+	PageIDs        []string  `json:"page_ids,omitempty"` // Pages using the file, for files in the assets directory
 }
 
 // UserRegistry is stored in .notion-sync/ids/user-{id}.json
@@ -86,6 +101,19 @@ type UserRegistry struct {
 	LastFetched    time.Time `json:"last_fetched"`
 }
 
+// BlockedRegistry is stored in .notion-sync/ids/blocked-{id}.json
+// Marks a page that cannot be synced (archived, inaccessible, or under a blocked parent)
+// so that it and its subtree are not retried on every sync cycle.
+type BlockedRegistry struct {
+	NtnsyncVersion string    `json:"ntnsync_version"`
+	ID             string    `json:"id"`
+	Folder         string    `json:"folder,omitempty"`
+	Reason         string    `json:"reason"`               // One of the blockedReason* values, e.g. "archived"
+	Error          string    `json:"error,omitempty"`      // Last error message
+	BlockedBy      string    `json:"blocked_by,omitempty"` // Blocked ancestor (for "blocked_parent")
+	BlockedAt      time.Time `json:"blocked_at"`
+}
+
 // FileManifest is stored alongside downloaded files as {filename}.meta.json
 // Contains metadata for local file identification.
 type FileManifest struct {
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// syncReport summarizes a queue processing run for the status page.
+type syncReport struct {
+	FinishedAt   time.Time
+	Duration     time.Duration
+	Processed    int
+	Dropped      int // Pages dropped because of errors, see queueProcessingStats
+	FilesWritten int
+	QueueFiles   int // Queue files left
+	Warnings     int // Conversion warnings of the pages synced, listed in their frontmatter
+}
+
+// text renders the report as the text of the status block.
+func (r syncReport) text() string {
+	text := fmt.Sprintf("ntnsync: last sync %s (took %s): %d pages synced, %d files written",
+		r.FinishedAt.UTC().Format(time.RFC3339), r.Duration.Round(time.Second), r.Processed, r.FilesWritten)
+	if r.Dropped > 0 {
+		text += fmt.Sprintf(", %d pages in error", r.Dropped)
+	}
+	if r.Warnings > 0 {
+		text += fmt.Sprintf(", %d conversion warnings", r.Warnings)
+	}
+	if r.QueueFiles > 0 {
+		text += fmt.Sprintf(", %d queue files left", r.QueueFiles)
+	}
+	return text
+}
+
+// emoji returns the icon of the status block, showing whether the run had errors.
+func (r syncReport) emoji() string {
+	if r.Dropped > 0 {
+		return "⚠️"
+	}
+	return "✅"
+}
+
+// reportStatus writes the report of a run to the status page (NTN_STATUS_PAGE, empty = disabled), replacing the
+// report of the previous run, so that people who don't use the mirror can see the health of the sync in Notion.
+// Runs that synced nothing are not reported. Failures are only logged: reporting must not fail a sync.
+func (c *Crawler) reportStatus(ctx context.Context, pageID string, report syncReport) {
+	if pageID == "" || c.client == nil || (report.Processed == 0 && report.Dropped == 0) {
+		return
+	}
+	if files, err := c.queueManager.ListEntries(ctx); err == nil {
+		report.QueueFiles = len(files)
+	}
+
+	blocks, err := c.client.AppendBlockChildren(ctx, pageID, []notion.BlockInput{
+		notion.NewTextBlock("callout", report.text(), report.emoji()),
+	})
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to write the sync report to the status page",
+			notionKeyPageID, pageID, "error", err)
+		return
+	}
+
+	// The previous reports are removed once the new one is written, so that the page shows one. Those that
+	// could not be removed are tried again after the next run.
+	var blockIDs []string
+	for _, blockID := range c.state.StatusBlockIDs {
+		if err := c.client.DeleteBlock(ctx, blockID); err != nil && !notion.IsPermanentError(err) {
+			c.logger.WarnContext(ctx, "failed to remove the previous sync report", "block_id", blockID, "error", err)
+			blockIDs = append(blockIDs, blockID)
+		}
+	}
+	for _, block := range blocks {
+		blockIDs = append(blockIDs, block.ID)
+	}
+	c.state.StatusBlockIDs = blockIDs
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestSyncReport_Text(t *testing.T) {
+	t.Parallel()
+
+	report := syncReport{
+		FinishedAt:   time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Duration:     90 * time.Second,
+		Processed:    12,
+		Dropped:      1,
+		FilesWritten: 14,
+		QueueFiles:   2,
+		Warnings:     3,
+	}
+	want := "ntnsync: last sync 2024-01-15T10:30:00Z (took 1m30s): 12 pages synced, 14 files written, " +
+		"1 pages in error, 3 conversion warnings, 2 queue files left"
+	if got := report.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	if got := report.emoji(); got != "⚠️" {
+		t.Errorf("emoji() = %q, want a warning", got)
+	}
+}
+
+func TestReportStatus_ReplacesPreviousReport(t *testing.T) {
+	t.Parallel()
+
+	var mu stdsync.Mutex
+	var requests []string
+	appended := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPatch {
+			appended++
+			fmt.Fprintf(w, `{"object":"list","results":[{"object":"block","id":"report%d"}]}`, appended)
+			return
+		}
+		fmt.Fprint(w, `{"object":"block"}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	crawler.reportStatus(ctx, "status", syncReport{FinishedAt: time.Now()})
+	if len(requests) != 0 {
+		t.Errorf("runs that synced nothing should not be reported, got %v", requests)
+	}
+
+	crawler.reportStatus(ctx, "status", syncReport{FinishedAt: time.Now(), Processed: 1})
+	crawler.reportStatus(ctx, "status", syncReport{FinishedAt: time.Now(), Processed: 2})
+
+	want := []string{"PATCH /blocks/status/children", "PATCH /blocks/status/children", "DELETE /blocks/report1"}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+	if got := strings.Join(crawler.state.StatusBlockIDs, ","); got != "report2" {
+		t.Errorf("StatusBlockIDs = %q, want report2", got)
+	}
+}
@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// Special values of NTN_PULL_TEAMSPACES.
+const (
+	// teamspaceAny allows the pages of any teamspace.
+	teamspaceAny = "*"
+	// teamspacePrivate allows the pages outside any teamspace: private pages, and the other pages at
+	// workspace level.
+	teamspacePrivate = "private"
+)
+
+// maxTeamspaceDepth is the maximum number of parents walked up to find the teamspace of a page.
+const maxTeamspaceDepth = 50
+
+// parseTeamspacesEnv parses the teamspaces whose new pages are discovered: teamspace IDs, teamspaceAny or
+// teamspacePrivate, comma-separated. Returns nil if all pages are discovered.
+func parseTeamspacesEnv(val string) []string {
+	var teamspaces []string
+	for _, item := range parseListEnv(val) {
+		switch item = strings.ToLower(item); item {
+		case teamspaceAny, teamspacePrivate:
+			teamspaces = append(teamspaces, item)
+		default:
+			teamspaces = append(teamspaces, normalizePageID(item))
+		}
+	}
+	return teamspaces
+}
+
+// teamspaceAllowed returns true if new pages of the teamspace are discovered ("" = outside any teamspace).
+func (cfg *Config) teamspaceAllowed(teamspace string) bool {
+	switch {
+	case len(cfg.PullTeamspaces) == 0:
+		return true
+	case teamspace == "":
+		return slices.Contains(cfg.PullTeamspaces, teamspacePrivate)
+	default:
+		return slices.Contains(cfg.PullTeamspaces, teamspaceAny) || slices.Contains(cfg.PullTeamspaces, teamspace)
+	}
+}
+
+// pageTeamspace returns the ID of the teamspace of a page, given by the parent of its top-level page, or an empty
+// string for pages outside any teamspace. Registered parents are walked up from their registry, so that only
+// the top-level pages are fetched. The teamspaces found are cached by page ID in known.
+func (c *Crawler) pageTeamspace(ctx context.Context, page *notion.Page, known map[string]string) (string, error) {
+	var visited []string
+	remember := func(teamspace string) string {
+		for _, id := range visited {
+			known[id] = teamspace
+		}
+		return teamspace
+	}
+
+	id, parent := normalizePageID(page.ID), page.Parent
+	for range maxTeamspaceDepth {
+		visited = append(visited, id)
+		if parent.IsWorkspaceLevel() {
+			return remember(normalizePageID(parent.SpaceID)), nil
+		}
+
+		parentID := normalizePageID(parent.ID())
+		if parent.Type == parentTypeBlockID {
+			resolvedID, resolvedType, err := c.resolveBlockToPage(ctx, parent.BlockID)
+			if err != nil {
+				return "", err
+			}
+			if resolvedType == parentTypeWorkspace {
+				return remember(""), nil
+			}
+			parentID = resolvedID
+		}
+		if parentID == "" {
+			return remember(""), nil
+		}
+		if teamspace, ok := known[parentID]; ok {
+			return remember(teamspace), nil
+		}
+
+		next, err := c.parentOfPage(ctx, parentID)
+		if err != nil {
+			return "", err
+		}
+		id, parent = parentID, next
+	}
+	return "", fmt.Errorf("teamspace of page %s: %w", page.ID, apperrors.ErrMaxDepthExceeded)
+}
+
+// parentOfPage returns the parent of a page or database, from its registry if it has a registered parent.
+func (c *Crawler) parentOfPage(ctx context.Context, pageID string) (notion.Parent, error) {
+	if reg, err := c.loadPageRegistry(ctx, pageID); err == nil && reg.ParentID != "" {
+		return notion.Parent{Type: notionKeyPageID, PageID: reg.ParentID}, nil
+	}
+
+	page, err := c.client.GetPage(ctx, pageID)
+	if err == nil {
+		return page.Parent, nil
+	}
+	if !strings.Contains(err.Error(), "is a database, not a page") {
+		return notion.Parent{}, fmt.Errorf("fetch parent page %s: %w", pageID, err)
+	}
+	database, err := c.client.GetDatabase(ctx, pageID)
+	if err != nil {
+		return notion.Parent{}, fmt.Errorf("fetch parent database %s: %w", pageID, err)
+	}
+	return database.Parent, nil
+}
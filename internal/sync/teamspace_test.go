@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestConfig_TeamspaceAllowed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		env       string
+		teamspace string
+		want      bool
+	}{
+		{"", "", true},
+		{"", "team1", true},
+		{"team1, team2", "team2", true},
+		{"team1", "team2", false},
+		{"team1", "", false},
+		{"*", "team2", true},
+		{"*", "", false},
+		{"team1,Private", "", true},
+	}
+	for _, tt := range tests {
+		cfg := &Config{PullTeamspaces: parseTeamspacesEnv(tt.env)}
+		if got := cfg.teamspaceAllowed(tt.teamspace); got != tt.want {
+			t.Errorf("teamspaceAllowed(%q) with %q = %v, want %v", tt.teamspace, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestPageTeamspace(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	parents := map[string]notion.Parent{
+		"/pages/teamroot":    {Type: "space", SpaceID: "team1"},
+		"/pages/privateroot": {Type: "workspace", Workspace: true},
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		parent, ok := parents[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(notion.Page{Object: "page", ID: r.URL.Path[len("/pages/"):], Parent: parent})
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+	for _, reg := range []*PageRegistry{
+		{ID: "teamroot", Folder: "team", IsRoot: true, Enabled: true},
+		{ID: "section", Folder: "team", ParentID: "teamroot"},
+	} {
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+
+	known := make(map[string]string)
+	tests := []struct {
+		page *notion.Page
+		want string
+	}{
+		{&notion.Page{ID: "new1", Parent: notion.Parent{Type: "page_id", PageID: "section"}}, "team1"},
+		{&notion.Page{ID: "new2", Parent: notion.Parent{Type: "page_id", PageID: "section"}}, "team1"},
+		{&notion.Page{ID: "new3", Parent: notion.Parent{Type: "page_id", PageID: "privateroot"}}, ""},
+		{&notion.Page{ID: "top", Parent: notion.Parent{Type: "space", SpaceID: "team2"}}, "team2"},
+	}
+	for _, tt := range tests {
+		got, err := crawler.pageTeamspace(ctx, tt.page, known)
+		if err != nil {
+			t.Fatalf("pageTeamspace(%s) error = %v", tt.page.ID, err)
+		}
+		if got != tt.want {
+			t.Errorf("pageTeamspace(%s) = %q, want %q", tt.page.ID, got, tt.want)
+		}
+	}
+
+	// Registered parents are walked up from their registry, and the top-level pages are fetched once
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+	if known["section"] != "team1" || known["teamroot"] != "team1" {
+		t.Errorf("known = %v, want the parents cached", known)
+	}
+}
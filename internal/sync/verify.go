@@ -0,0 +1,190 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// Verification mismatch reasons.
+const (
+	VerifyMissing  = "missing"  // The file of the page does not exist
+	VerifyModified = "modified" // The file was changed since it was synced
+	VerifyOutdated = "outdated" // The page was edited in Notion since it was synced (missed update)
+	VerifyDiffers  = "differs"  // The page was not edited, but converts to different content
+)
+
+// volatileFrontmatterFields change on every conversion, or without any edit of the page (open_comments), and are
+// ignored when comparing content.
+var volatileFrontmatterFields = []string{"ntnsync_version", "last_synced", "download_duration", "open_comments"}
+
+// VerifyOptions configures a verification.
+type VerifyOptions struct {
+	Folder string // Folder to verify (empty = all folders)
+	Remote bool   // Compare files with what current Notion content converts to, instead of their synced hash
+	Sample int    // Number of pages to verify, picked randomly (0 = all)
+}
+
+// VerifyMismatch is a page whose file does not match what was expected.
+type VerifyMismatch struct {
+	PageID   string
+	FilePath string
+	Reason   string
+	Line     int // First differing line ignoring volatile fields, for remote verification
+}
+
+// VerifyResult contains the result of a verification.
+type VerifyResult struct {
+	Checked    int               // Pages verified
+	Skipped    int               // Pages that could not be verified (no hash recorded)
+	Errors     map[string]string // Pages that could not be fetched, with the error (key: page ID)
+	Mismatches []*VerifyMismatch
+}
+
+// Verify checks that the files of synced pages still match their content. Locally, the hash of each
+// file is compared with the one recorded when it was synced. With Remote, pages are fetched and
+// converted again, without writing anything or downloading files, and compared with the files
+// ignoring volatile frontmatter fields: this catches silent divergence caused by missed webhooks or
+// converter upgrades.
+func (c *Crawler) Verify(ctx context.Context, opts VerifyOptions) (*VerifyResult, error) {
+	registries, err := c.listPageRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list registries: %w", err)
+	}
+	if opts.Folder != "" {
+		registries = filterRegistriesByFolder(registries, opts.Folder)
+	}
+	if opts.Sample > 0 && opts.Sample < len(registries) {
+		//nolint:gosec // Sampling, not security sensitive
+		rand.Shuffle(len(registries), func(i, j int) { registries[i], registries[j] = registries[j], registries[i] })
+		registries = registries[:opts.Sample]
+	}
+
+	if opts.Remote {
+		// User lookups are cached in registries, files are never downloaded
+		if err := c.EnsureTransaction(ctx); err != nil {
+			return nil, fmt.Errorf("ensure transaction: %w", err)
+		}
+		c.skipDownloads = true
+		defer func() { c.skipDownloads = false }()
+	}
+
+	result := &VerifyResult{Errors: make(map[string]string)}
+	for _, reg := range registries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		mismatch, verified := c.verifyPage(ctx, reg, opts.Remote, result)
+		if !verified {
+			continue
+		}
+		result.Checked++
+		if mismatch != nil {
+			result.Mismatches = append(result.Mismatches, mismatch)
+		}
+	}
+
+	c.logger.InfoContext(ctx, "verification complete",
+		"folder", opts.Folder,
+		"remote", opts.Remote,
+		"checked", result.Checked,
+		"mismatches", len(result.Mismatches),
+		"errors", len(result.Errors))
+	return result, nil
+}
+
+// verifyPage verifies the file of a page. Returns false if the page could not be verified.
+func (c *Crawler) verifyPage(
+	ctx context.Context, reg *PageRegistry, remote bool, result *VerifyResult,
+) (*VerifyMismatch, bool) {
+	local, err := c.store.Read(ctx, reg.FilePath)
+	if err != nil {
+		return &VerifyMismatch{PageID: reg.ID, FilePath: reg.FilePath, Reason: VerifyMissing}, true
+	}
+
+	if !remote {
+		if reg.ContentHash == "" {
+			result.Skipped++
+			return nil, false
+		}
+		hash := sha256.Sum256(local)
+		if hex.EncodeToString(hash[:]) != reg.ContentHash {
+			return &VerifyMismatch{PageID: reg.ID, FilePath: reg.FilePath, Reason: VerifyModified}, true
+		}
+		return nil, true
+	}
+
+	params, _, err := c.buildItemParams(ctx, reg.ID, reg.Folder)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to fetch page for verification", notionKeyPageID, reg.ID, "error", err)
+		result.Errors[reg.ID] = err.Error()
+		return nil, false
+	}
+	expected := params.convert(reg.FilePath, reg.IsRoot, reg.ParentID, reg.Aliases)
+
+	line := firstDifferingLine(stableContent(local), stableContent(expected))
+	if line == 0 {
+		return nil, true
+	}
+	reason := VerifyDiffers
+	if params.lastEdited.After(reg.LastEdited) {
+		reason = VerifyOutdated
+	}
+	return &VerifyMismatch{PageID: reg.ID, FilePath: reg.FilePath, Reason: reason, Line: line}, true
+}
+
+// filterRegistriesByFolder returns the registries of a folder.
+func filterRegistriesByFolder(registries []*PageRegistry, folder string) []*PageRegistry {
+	var filtered []*PageRegistry
+	for _, reg := range registries {
+		if reg.Folder == folder {
+			filtered = append(filtered, reg)
+		}
+	}
+	return filtered
+}
+
+// stableContent returns the lines of markdown content, without the volatile frontmatter fields.
+func stableContent(content []byte) []string {
+	lines := strings.Split(string(content), "\n")
+	if len(lines) == 0 || lines[0] != "---" {
+		return lines
+	}
+
+	stable := make([]string, 0, len(lines))
+	inFrontmatter := true
+	for i, line := range lines {
+		if i > 0 && line == "---" {
+			inFrontmatter = false
+		}
+		if inFrontmatter && isVolatileFrontmatterLine(line) {
+			continue
+		}
+		stable = append(stable, line)
+	}
+	return stable
+}
+
+// isVolatileFrontmatterLine returns true if a frontmatter line holds a volatile field.
+func isVolatileFrontmatterLine(line string) bool {
+	key, _, found := strings.Cut(line, ":")
+	if !found {
+		return false
+	}
+	return slices.Contains(volatileFrontmatterFields, key)
+}
+
+// firstDifferingLine returns the 1-based number of the first line differing between two contents,
+// or 0 if they are equal.
+func firstDifferingLine(a, b []string) int {
+	for i := range max(len(a), len(b)) {
+		if i >= len(a) || i >= len(b) || a[i] != b[i] {
+			return i + 1
+		}
+	}
+	return 0
+}
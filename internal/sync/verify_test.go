@@ -0,0 +1,162 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+func TestVerify_Local(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	crawler, _ := newTestCrawler(t)
+
+	for _, id := range []string{"same", "modified", "missing", "nohash"} {
+		content := []byte("# " + id + "\n")
+		hash := sha256.Sum256(content)
+		reg := &PageRegistry{ID: id, Type: notionTypePage, Folder: "test", FilePath: "test/" + id + ".md"}
+		if id != "nohash" {
+			reg.ContentHash = hex.EncodeToString(hash[:])
+		}
+		if id != "missing" {
+			if err := crawler.tx.Write(ctx, reg.FilePath, content); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+		if err := crawler.savePageRegistry(ctx, reg); err != nil {
+			t.Fatalf("savePageRegistry() error = %v", err)
+		}
+	}
+	if err := crawler.tx.Write(ctx, "test/modified.md", []byte("# edited by hand\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	result, err := crawler.Verify(ctx, VerifyOptions{Folder: "test"})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Checked != 3 || result.Skipped != 1 {
+		t.Errorf("checked/skipped = %d/%d, want 3/1", result.Checked, result.Skipped)
+	}
+	reasons := make(map[string]string)
+	for _, mismatch := range result.Mismatches {
+		reasons[mismatch.PageID] = mismatch.Reason
+	}
+	if len(reasons) != 2 || reasons["modified"] != VerifyModified || reasons["missing"] != VerifyMissing {
+		t.Errorf("mismatches = %v, want modified and missing", reasons)
+	}
+
+	if result, err = crawler.Verify(ctx, VerifyOptions{Folder: "other"}); err != nil || result.Checked != 0 {
+		t.Errorf("Verify(other) = %+v, %v, want nothing checked", result, err)
+	}
+}
+
+func TestVerify_Remote(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	synced := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var mu stdsync.Mutex
+	text, lastEdited := "Hello", synced
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/pages/page":
+			_ = json.NewEncoder(w).Encode(notion.Page{
+				Object:         "page",
+				ID:             "page",
+				LastEditedTime: lastEdited,
+				Parent:         notion.Parent{Type: "workspace", Workspace: true},
+				Properties: notion.Properties{
+					"title": {Type: "title", Title: []notion.RichText{{PlainText: "Page"}}},
+				},
+			})
+		case "/blocks/page/children":
+			fmt.Fprintf(w, `{"object":"list","results":[{"object":"block","id":"b1","type":"paragraph",`+
+				`"paragraph":{"rich_text":[{"type":"text","plain_text":%q}]}}],"has_more":false}`, text)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	crawler, _ := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+
+	// Sync the page as it is in Notion
+	params, _, err := crawler.buildItemParams(ctx, "page", "test")
+	if err != nil {
+		t.Fatalf("buildItemParams() error = %v", err)
+	}
+	if err := crawler.tx.Write(ctx, "test/page.md", params.convert("test/page.md", true, "", nil)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := crawler.savePageRegistry(ctx, &PageRegistry{
+		ID: "page", Type: notionTypePage, Folder: "test", FilePath: "test/page.md", IsRoot: true,
+		LastEdited: synced, LastSynced: synced,
+	}); err != nil {
+		t.Fatalf("savePageRegistry() error = %v", err)
+	}
+
+	verify := func() *VerifyResult {
+		t.Helper()
+		result, err := crawler.Verify(ctx, VerifyOptions{Remote: true, Sample: 1})
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if result.Checked != 1 {
+			t.Fatalf("checked %d pages, want 1", result.Checked)
+		}
+		return result
+	}
+
+	// Volatile fields (last_synced, download_duration) are ignored
+	if result := verify(); len(result.Mismatches) != 0 {
+		t.Fatalf("mismatches = %+v, want none", result.Mismatches[0])
+	}
+
+	// Same last edit time, different content: converter drift
+	mu.Lock()
+	text = "Hello world"
+	mu.Unlock()
+	result := verify()
+	if len(result.Mismatches) != 1 || result.Mismatches[0].Reason != VerifyDiffers || result.Mismatches[0].Line == 0 {
+		t.Fatalf("mismatches = %+v, want one differing page", result.Mismatches)
+	}
+
+	// Edited in Notion since the sync: missed update
+	mu.Lock()
+	lastEdited = synced.Add(time.Hour)
+	mu.Unlock()
+	if result := verify(); len(result.Mismatches) != 1 || result.Mismatches[0].Reason != VerifyOutdated {
+		t.Fatalf("mismatches = %+v, want one outdated page", result.Mismatches)
+	}
+}
+
+func TestStableContent(t *testing.T) {
+	t.Parallel()
+
+	a := stableContent([]byte("---\nntnsync_version: 1\nnotion_id: x\nlast_synced: 2026-01-01T00:00:00Z\n---\n" +
+		"last_synced: kept in body\n"))
+	b := stableContent([]byte("---\nntnsync_version: 2\nnotion_id: x\nlast_synced: 2026-02-01T00:00:00Z\n" +
+		"download_duration: 1s\n---\nlast_synced: kept in body\n"))
+	if line := firstDifferingLine(a, b); line != 0 {
+		t.Errorf("firstDifferingLine() = %d, want 0 (%q vs %q)", line, a, b)
+	}
+	if line := firstDifferingLine(a, append(b, "more")); line != len(a)+1 {
+		t.Errorf("firstDifferingLine() = %d, want %d", line, len(a)+1)
+	}
+}
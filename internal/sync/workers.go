@@ -0,0 +1,87 @@
+package sync
+
+import (
+	"context"
+	stdsync "sync"
+)
+
+// writesLockedKey is the context key marking that the crawler's write lock is held.
+const writesLockedKey contextKey = "writesLocked"
+
+// lockWrites acquires the crawler's write lock, unless the context shows that it is already held (e.g. when a
+// page syncs its parent first). It returns the context to use while the lock is held, and the function releasing it.
+func (c *Crawler) lockWrites(ctx context.Context) (context.Context, func()) {
+	if locked, _ := ctx.Value(writesLockedKey).(bool); locked {
+		return ctx, func() {}
+	}
+	c.writeMu.Lock()
+	return context.WithValue(ctx, writesLockedKey, true), c.writeMu.Unlock
+}
+
+// pagePool processes the pages of a queue entry with up to NTN_SYNC_CONCURRENCY workers.
+// Pages are fetched in parallel, while their writes and the bookkeeping of the entry are serialized by the
+// crawler's write lock. With a single worker, pages are processed one after the other, in the caller's goroutine.
+// Pages whose parent is processed by the pool wait for it, so that they find it synced.
+type pagePool struct {
+	crawler  *Crawler
+	workers  chan struct{}
+	wg       stdsync.WaitGroup
+	parents  map[string]string        // Parents of the pages that are processed by the pool too
+	finished map[string]chan struct{} // Closed when a page was processed, by page ID
+}
+
+// newPagePool creates a pool processing queued pages. parents are the parents of the pages that are in the queue
+// entry too (see queuedPageParents); they must be processed first.
+func (c *Crawler) newPagePool(parents map[string]string) *pagePool {
+	return &pagePool{
+		crawler:  c,
+		workers:  make(chan struct{}, max(c.concurrency, 1)),
+		parents:  parents,
+		finished: make(map[string]chan struct{}),
+	}
+}
+
+// locked calls fn holding the crawler's write lock.
+func (p *pagePool) locked(ctx context.Context, fn func(ctx context.Context)) {
+	lockedCtx, unlock := p.crawler.lockWrites(ctx)
+	defer unlock()
+	fn(lockedCtx)
+}
+
+// run processes a queued page, then calls done with the result holding the crawler's write lock.
+// It waits for a free worker if they are all busy. Pages must be run after their parent (see dependencyOrder).
+func (p *pagePool) run(
+	ctx context.Context, pageID, folder, queueType, parentID string,
+	done func(ctx context.Context, filesCount int, err error),
+) {
+	process := func() {
+		filesCount, err := p.crawler.processQueuedPage(ctx, pageID, folder, queueType, parentID)
+		p.locked(ctx, func(ctx context.Context) {
+			done(ctx, filesCount, err)
+		})
+	}
+
+	if cap(p.workers) == 1 {
+		process()
+		return
+	}
+
+	finished := make(chan struct{})
+	p.finished[normalizePageID(pageID)] = finished
+	parentFinished := p.finished[p.parents[normalizePageID(pageID)]]
+
+	p.workers <- struct{}{}
+	p.wg.Go(func() {
+		defer func() { <-p.workers }()
+		defer close(finished)
+		if parentFinished != nil {
+			<-parentFinished
+		}
+		process()
+	})
+}
+
+// wait waits for the pages being processed.
+func (p *pagePool) wait() {
+	p.wg.Wait()
+}
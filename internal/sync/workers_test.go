@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/queue"
+)
+
+func TestProcessQueue_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	var mu stdsync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		// Slower than the rate limiter, so that the requests of parallel workers overlap
+		time.Sleep(500 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"object":"error","status":404,"code":"object_not_found","message":"not found"}`)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	crawler, qm := newTestCrawler(t)
+	crawler.client = notion.NewClient("token", notion.WithBaseURL(server.URL))
+	crawler.concurrency = 2
+
+	if _, err := qm.CreateEntry(ctx, queue.Entry{
+		Type:   queueTypeInit,
+		Folder: "test",
+		Pages:  []queue.Page{{ID: "page1"}, {ID: "page2"}},
+	}); err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	if err := crawler.ProcessQueue(ctx, "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("ProcessQueue() error = %v", err)
+	}
+
+	if maxInFlight != 2 {
+		t.Errorf("pages fetched in parallel = %d, want 2", maxInFlight)
+	}
+	for _, id := range []string{"page1", "page2"} {
+		if _, err := crawler.loadBlockedRegistry(ctx, id); err != nil {
+			t.Errorf("%s should be blocked: %v", id, err)
+		}
+	}
+	if files, _ := qm.ListEntries(ctx); len(files) != 0 {
+		t.Errorf("queue = %v, want it empty", files)
+	}
+}
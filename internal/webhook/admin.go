@@ -0,0 +1,311 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"slices"
+	stdsync "sync"
+	"time"
+
+	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+// Paths of the admin UI and of the JSON API it uses.
+const (
+	AdminPath        = "/admin/"
+	AdminStatusPath  = "/api/admin/status"
+	AdminSyncPath    = "/api/admin/sync"
+	AdminPullPath    = "/api/admin/pull"
+	AdminCleanupPath = "/api/admin/cleanup"
+)
+
+// DefaultAdminUser is the default basic auth user of the admin UI.
+const DefaultAdminUser = "admin"
+
+const (
+	// adminRecentEvents is the number of recent webhook events kept for the admin UI.
+	adminRecentEvents = 50
+	// adminRecentCommits is the number of commits of the mirror shown by the admin UI.
+	adminRecentCommits = 10
+)
+
+// adminAssets are the static files of the admin UI.
+//
+//go:embed admin
+var adminAssets embed.FS
+
+// RecentEvent is a webhook event received recently, as shown by the admin UI.
+type RecentEvent struct {
+	Time       time.Time `json:"time"`
+	ID         string    `json:"id,omitempty"`
+	Type       string    `json:"type"`
+	EntityID   string    `json:"entity_id,omitempty"`
+	EntityType string    `json:"entity_type,omitempty"`
+}
+
+// eventLog keeps the last webhook events received.
+type eventLog struct {
+	mu     stdsync.Mutex
+	events []RecentEvent // Oldest first, at most adminRecentEvents
+}
+
+// add records an event received.
+func (l *eventLog) add(event *Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, RecentEvent{
+		Time:       time.Now(),
+		ID:         event.ID,
+		Type:       event.Type,
+		EntityID:   event.GetEntityID(),
+		EntityType: event.GetEntityType(),
+	})
+	if len(l.events) > adminRecentEvents {
+		l.events = slices.Delete(l.events, 0, len(l.events)-adminRecentEvents)
+	}
+}
+
+// recent returns the events received, newest first.
+func (l *eventLog) recent() []RecentEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := slices.Clone(l.events)
+	slices.Reverse(events)
+	return events
+}
+
+// AdminStatus is the response of the status endpoint of the admin UI.
+type AdminStatus struct {
+	Version     string             `json:"version"`
+	AutoSync    bool               `json:"auto_sync"`          // Whether the sync worker runs, and the actions work
+	Degraded    string             `json:"degraded,omitempty"` // Why the sync worker cannot sync
+	Runs        []sync.DebugRun    `json:"runs"`               // Sync runs in progress
+	QueuedPages int                `json:"queued_pages"`       // Pages of all the queue entries
+	Status      *sync.StatusInfo   `json:"status"`             // Folders and queue entries
+	Commits     []store.CommitInfo `json:"commits"`            // Last commits of the mirror, newest first
+	Events      []RecentEvent      `json:"events"`             // Last webhook events, newest first
+	Metrics     map[string]int64   `json:"metrics"`            // Event counters, as served by /api/metrics
+}
+
+// adminUI serves the admin UI and its JSON API.
+type adminUI struct {
+	handler   *Handler
+	crawler   *sync.Crawler // Read-only crawler used for the status
+	crawlerMu stdsync.Mutex // The crawler reloads its state on every call
+	user      string
+	password  string // Basic auth password (empty = no auth)
+}
+
+// newAdminUI creates the admin UI of a handler, with the basic auth credentials of cfg.
+func newAdminUI(handler *Handler, crawler *sync.Crawler, cfg *ServerConfig) *adminUI {
+	user := cfg.AdminUser
+	if user == "" {
+		user = DefaultAdminUser
+	}
+	return &adminUI{handler: handler, crawler: crawler, user: user, password: cfg.AdminPassword}
+}
+
+// register registers the routes of the admin UI.
+func (ui *adminUI) register(mux *http.ServeMux) {
+	assets, _ := fs.Sub(adminAssets, "admin")
+	mux.HandleFunc(AdminPath, ui.authorize(http.StripPrefix(AdminPath, http.FileServerFS(assets)).ServeHTTP))
+	mux.HandleFunc(AdminStatusPath, ui.authorize(ui.handleStatus))
+	mux.HandleFunc(AdminSyncPath, ui.authorize(ui.handleAction("sync", ui.syncNow)))
+	mux.HandleFunc(AdminPullPath, ui.authorize(ui.handleAction("pull", ui.pull)))
+	mux.HandleFunc(AdminCleanupPath, ui.authorize(ui.handleAction("cleanup", ui.cleanup)))
+}
+
+// authorize checks the basic auth credentials of the requests, when a password is configured.
+func (ui *adminUI) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		if ui.password != "" {
+			user, password, ok := req.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(ui.user)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(password), []byte(ui.password)) != 1 {
+				writer.Header().Set("WWW-Authenticate", `Basic realm="ntnsync admin", charset="UTF-8"`)
+				http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(writer, req)
+	}
+}
+
+// handleStatus handles the status endpoint: the runs in progress, the folders and the queue, the last commits
+// and the last webhook events.
+func (ui *adminUI) handleStatus(writer http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	if req.Method != http.MethodGet {
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ui.crawlerMu.Lock()
+	info, err := ui.crawler.GetStatus(ctx, "")
+	ui.crawlerMu.Unlock()
+	if err != nil {
+		ui.handler.logger.ErrorContext(ctx, "failed to get status", "error", err)
+		http.Error(writer, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	status := AdminStatus{
+		Version:  version.Version,
+		AutoSync: ui.handler.syncWorker != nil,
+		Runs:     sync.CaptureDebugState().Runs,
+		Status:   info,
+		Commits:  []store.CommitInfo{},
+		Events:   ui.handler.events.recent(),
+		Metrics:  ui.handler.metricsSnapshot(),
+	}
+	if ui.handler.syncWorker != nil {
+		status.Degraded = ui.handler.syncWorker.Degraded()
+	}
+	for _, entry := range info.QueueEntries {
+		status.QueuedPages += entry.PageCount
+	}
+	if reader, ok := ui.handler.store.(store.CommitLogReader); ok {
+		if commits, err := reader.RecentCommits(ctx, adminRecentCommits); err != nil {
+			ui.handler.logger.WarnContext(ctx, "failed to read recent commits", "error", err)
+		} else if commits != nil {
+			status.Commits = commits
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(status); err != nil {
+		ui.handler.logger.ErrorContext(ctx, "failed to encode admin status", "error", err)
+	}
+}
+
+// handleAction returns a handler of an action endpoint, which runs the action and returns its result. Actions
+// need the sync worker, and are refused to pages of other sites, which browsers send the credentials to.
+func (ui *adminUI) handleAction(name string, action func(ctx context.Context) (any, error)) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if req.Method != http.MethodPost {
+			http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !sameOrigin(req) {
+			http.Error(writer, "Cross-origin request refused", http.StatusForbidden)
+			return
+		}
+		if ui.handler.syncWorker == nil {
+			http.Error(writer, "Auto-sync is disabled", http.StatusConflict)
+			return
+		}
+
+		ui.handler.logger.InfoContext(ctx, "admin action requested", "action", name)
+		// The action completes even if the browser gives up waiting
+		result, err := action(context.WithoutCancel(ctx))
+		if err != nil {
+			ui.handler.logger.ErrorContext(ctx, "admin action failed", "action", name, "error", err)
+			http.Error(writer, fmt.Sprintf("%s failed: %v", name, err), http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(writer).Encode(result); err != nil {
+			ui.handler.logger.ErrorContext(ctx, "failed to encode admin action result", "error", err)
+		}
+	}
+}
+
+// sameOrigin returns false for the requests sent by pages of another origin than the server.
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == req.Host
+}
+
+// syncNow notifies the sync worker to process the whole queue.
+func (ui *adminUI) syncNow(_ context.Context) (any, error) {
+	ui.handler.syncWorker.Notify()
+	return map[string]string{"status": "sync requested"}, nil
+}
+
+// pull queues the pages changed in Notion since the last pull.
+func (ui *adminUI) pull(ctx context.Context) (any, error) {
+	return ui.handler.syncWorker.Pull(ctx)
+}
+
+// cleanup deletes the orphaned pages and the unused assets.
+func (ui *adminUI) cleanup(ctx context.Context) (any, error) {
+	return ui.handler.syncWorker.Cleanup(ctx)
+}
+
+// Pull queues the pages changed in Notion since the last pull, between sync runs, and notifies the worker to
+// sync them.
+func (w *SyncWorker) Pull(ctx context.Context) (*sync.PullResult, error) {
+	var result *sync.PullResult
+	err := w.runExclusive(ctx, "pull", func() error {
+		var err error
+		result, err = w.crawler.Pull(ctx, sync.PullOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pull: %w", err)
+	}
+	if result.PagesQueued > 0 {
+		w.Notify()
+	}
+	return result, nil
+}
+
+// Cleanup deletes the orphaned pages and the assets no page uses anymore, between sync runs.
+func (w *SyncWorker) Cleanup(ctx context.Context) (*sync.CleanupResult, error) {
+	var result *sync.CleanupResult
+	err := w.runExclusive(ctx, "cleanup", func() error {
+		var err error
+		result, err = w.crawler.Cleanup(ctx, false)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cleanup: %w", err)
+	}
+	return result, nil
+}
+
+// runExclusive runs an operation on the mirror between sync runs, holding the remote lock like a run, then
+// commits and pushes its changes like a run.
+func (w *SyncWorker) runExclusive(ctx context.Context, reason string, operation func() error) error {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+	if err := w.beginRun(ctx); err != nil {
+		return err
+	}
+	defer w.releaseStoreLock(ctx)
+
+	if locker, ok := w.store.(store.RemoteLocker); ok {
+		if err := locker.AcquireRemoteLock(ctx); err != nil {
+			return fmt.Errorf("lock remote: %w", err)
+		}
+		defer func() {
+			if err := locker.ReleaseRemoteLock(ctx); err != nil {
+				w.logger.WarnContext(ctx, "failed to release remote lock", "error", err)
+			}
+		}()
+	}
+
+	if err := operation(); err != nil {
+		return err
+	}
+	if w.remoteConfig.IsCommitEnabled() {
+		return w.commitAndPush(ctx, reason)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Admin UI of ntnsync serve: renders /api/admin/status every few seconds, and runs the actions of the buttons.
+"use strict";
+
+const refreshInterval = 5000;
+
+function cell(text) {
+  const td = document.createElement("td");
+  td.textContent = text ?? "";
+  return td;
+}
+
+function codeCell(text) {
+  const td = document.createElement("td");
+  const code = document.createElement("code");
+  code.textContent = text ?? "";
+  td.appendChild(code);
+  return td;
+}
+
+function fillTable(id, items, columns, emptyText) {
+  const body = document.querySelector(`#${id} tbody`);
+  body.replaceChildren();
+  if (!items || items.length === 0) {
+    const tr = document.createElement("tr");
+    const td = cell(emptyText);
+    td.className = "empty";
+    td.colSpan = document.querySelectorAll(`#${id} th`).length;
+    tr.appendChild(td);
+    body.appendChild(tr);
+    return;
+  }
+  for (const item of items) {
+    const tr = document.createElement("tr");
+    for (const column of columns) {
+      tr.appendChild(column(item));
+    }
+    body.appendChild(tr);
+  }
+}
+
+function formatTime(value) {
+  if (!value || value.startsWith("0001-")) {
+    return "";
+  }
+  return new Date(value).toLocaleString();
+}
+
+function formatSize(bytes) {
+  const units = ["B", "KiB", "MiB", "GiB"];
+  let size = bytes || 0;
+  let unit = 0;
+  while (size >= 1024 && unit < units.length - 1) {
+    size /= 1024;
+    unit++;
+  }
+  return `${size.toFixed(unit === 0 ? 0 : 1)} ${units[unit]}`;
+}
+
+function render(status) {
+  document.getElementById("version").textContent = status.version;
+
+  const worker = document.getElementById("worker");
+  worker.className = "badge";
+  if (!status.auto_sync) {
+    worker.textContent = "auto-sync disabled";
+    worker.classList.add("warning");
+  } else if (status.degraded) {
+    worker.textContent = `degraded: ${status.degraded}`;
+    worker.classList.add("warning");
+  } else {
+    worker.textContent = status.runs.length > 0 ? "syncing" : "idle";
+  }
+  for (const button of document.querySelectorAll("button[data-action]")) {
+    button.disabled = !status.auto_sync || button.dataset.running === "true";
+  }
+
+  fillTable("runs", status.runs, [
+    (r) => cell(r.run.phase),
+    (r) => cell(formatTime(r.run.started_at)),
+    (r) => cell(r.run.folder || "all"),
+    (r) => codeCell(r.run.queue_file),
+    (r) => cell(r.in_flight.length),
+    (r) => cell(r.run.processed),
+    (r) => cell(r.run.files_written),
+  ], "No sync in progress");
+
+  document.getElementById("queue-depth").textContent = `${status.queued_pages} pages`;
+  fillTable("queue", status.status.queue_entries, [
+    (e) => codeCell(e.queue_file),
+    (e) => cell(e.folder),
+    (e) => cell(e.type),
+    (e) => cell(e.page_count),
+  ], "The queue is empty");
+
+  const folders = Object.values(status.status.folders || {}).sort((a, b) => a.name.localeCompare(b.name));
+  fillTable("folders", folders, [
+    (f) => cell(f.name),
+    (f) => cell(f.page_count),
+    (f) => cell(f.root_pages),
+    (f) => cell(f.queued_pages),
+    (f) => cell(formatSize(f.total_bytes)),
+    (f) => cell(formatTime(f.last_synced)),
+  ], "No folder synced yet");
+
+  fillTable("commits", status.commits, [
+    (c) => cell(formatTime(c.time)),
+    (c) => codeCell((c.hash || "").slice(0, 8)),
+    (c) => cell(c.message),
+  ], "No commit");
+
+  document.getElementById("events-received").textContent = `${status.metrics.events_received} received`;
+  fillTable("events", status.events, [
+    (e) => cell(formatTime(e.time)),
+    (e) => cell(e.type || "verification"),
+    (e) => codeCell(e.entity_id),
+  ], "No event received since the server started");
+}
+
+function showMessage(text, isError) {
+  const message = document.getElementById("message");
+  message.textContent = text;
+  message.className = isError ? "error" : "";
+  message.hidden = false;
+}
+
+async function refresh() {
+  try {
+    const response = await fetch("../api/admin/status");
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    render(await response.json());
+  } catch (err) {
+    showMessage(`Failed to load the status: ${err.message}`, true);
+  }
+}
+
+async function runAction(button) {
+  const action = button.dataset.action;
+  button.dataset.running = "true";
+  button.disabled = true;
+  showMessage(`Running ${action}...`, false);
+  try {
+    const response = await fetch(`../api/admin/${action}`, { method: "POST" });
+    const text = await response.text();
+    if (!response.ok) {
+      throw new Error(text);
+    }
+    showMessage(`${action}: ${JSON.stringify(JSON.parse(text), null, 2)}`, false);
+  } catch (err) {
+    showMessage(err.message, true);
+  } finally {
+    button.dataset.running = "false";
+    button.disabled = false;
+    refresh();
+  }
+}
+
+for (const button of document.querySelectorAll("button[data-action]")) {
+  button.addEventListener("click", () => runAction(button));
+}
+refresh();
+setInterval(refresh, refreshInterval);
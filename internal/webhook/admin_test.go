@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// TestAdminUI verifies the basic auth, the status, the refused actions and the assets of the admin UI.
+func TestAdminUI(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandlerWithoutSecret(t)
+
+	tx, err := handler.store.BeginTx(t.Context())
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if err := tx.Write(t.Context(), "default/page.md", []byte("# Page")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := tx.Commit(t.Context(), "[ntnsync] Synced 1 page"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	body := []byte(`{"id":"event-1","type":"page.updated","entity":{"id":"page-1","type":"page"}}`)
+	handler.HandleWebhook(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodPost, "/webhooks/notion", bytes.NewReader(body)))
+
+	mux := http.NewServeMux()
+	cfg := &ServerConfig{AdminUI: true, AdminPassword: "secret"}
+	newAdminUI(handler, sync.NewCrawler(nil, handler.store), cfg).register(mux)
+
+	serve := func(req *http.Request, authenticated bool) *httptest.ResponseRecorder {
+		if authenticated {
+			req.SetBasicAuth(DefaultAdminUser, "secret")
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(httptest.NewRequest(http.MethodGet, AdminStatusPath, nil), false); rr.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	rr := serve(httptest.NewRequest(http.MethodGet, AdminStatusPath, nil), true)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: code = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var status AdminStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+	if status.AutoSync || status.Status == nil || status.Metrics["events_received"] != 1 {
+		t.Errorf("status = %+v, want no auto-sync, the sync status and 1 event received", status)
+	}
+	if len(status.Events) != 1 || status.Events[0].EntityID != "page-1" {
+		t.Errorf("events = %+v, want the page-1 event", status.Events)
+	}
+	if len(status.Commits) != 1 || status.Commits[0].Message != "[ntnsync] Synced 1 page" {
+		t.Errorf("commits = %+v, want the commit of the page", status.Commits)
+	}
+
+	if rr := serve(httptest.NewRequest(http.MethodPost, AdminSyncPath, nil), true); rr.Code != http.StatusConflict {
+		t.Errorf("sync without worker: status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, AdminPullPath, nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	if rr := serve(req, true); rr.Code != http.StatusForbidden {
+		t.Errorf("cross-origin pull: status = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+
+	rr = serve(httptest.NewRequest(http.MethodGet, AdminPath, nil), true)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "admin.js") {
+		t.Errorf("index: status = %d, body = %q", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultAuthProbeDelay is the default delay between checks of a rejected Notion token.
+const DefaultAuthProbeDelay = time.Minute
+
+// WithAuthProbeDelay sets the delay between checks of the Notion token, after it was rejected.
+func WithAuthProbeDelay(d time.Duration) SyncWorkerOption {
+	return func(w *SyncWorker) {
+		w.authProbeDelay = d
+	}
+}
+
+// Degraded returns why the worker cannot sync, or an empty string if it can.
+func (w *SyncWorker) Degraded() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.degraded
+}
+
+// enterDegraded stops syncing after Notion rejected the token. Notifications keep being batched, and the
+// token is checked periodically: syncing resumes once it is accepted again.
+func (w *SyncWorker) enterDegraded(ctx context.Context, cause error) {
+	w.mu.Lock()
+	alreadyDegraded := w.degraded != ""
+	w.degraded = cause.Error()
+	w.mu.Unlock()
+
+	if alreadyDegraded {
+		return
+	}
+	w.logger.ErrorContext(ctx, "notion token rejected, sync suspended until it works again",
+		"error", cause,
+		"probe_delay", w.authProbeDelay)
+	go w.probeAuth(ctx)
+}
+
+// probeAuth checks the Notion token until it is accepted, then resumes syncing.
+func (w *SyncWorker) probeAuth(ctx context.Context) {
+	timer := time.NewTimer(w.authProbeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := w.crawler.CheckAuth(ctx); err != nil {
+			w.logger.DebugContext(ctx, "notion token still rejected", "error", err)
+			timer.Reset(w.authProbeDelay)
+			continue
+		}
+
+		w.mu.Lock()
+		w.degraded = ""
+		w.mu.Unlock()
+		w.logger.InfoContext(ctx, "notion token accepted again, resuming sync")
+		w.signal()
+		return
+	}
+}
@@ -2,6 +2,7 @@
 package webhook
 
 import (
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
@@ -11,6 +12,9 @@ import (
 const (
 	// defaultWebhookPort is the default HTTP port for the webhook server.
 	defaultWebhookPort = 8080
+
+	// DefaultSyncMaxRunTime is the default cap of the duration of an automatic sync run.
+	DefaultSyncMaxRunTime = 5 * time.Minute
 )
 
 // ServerConfig holds configuration for the webhook server.
@@ -20,6 +24,44 @@ type ServerConfig struct {
 	Secret    string        // Webhook secret for signature verification (NTN_WEBHOOK_SECRET, optional)
 	AutoSync  bool          // Automatically run sync after queuing webhook events (NTN_WEBHOOK_AUTO_SYNC, default true)
 	SyncDelay time.Duration // Delay before processing queue (NTN_WEBHOOK_SYNC_DELAY, default 0)
+	GRPCPort  int           // gRPC port for internal tooling (NTN_GRPC_PORT, default 0 = disabled)
+	// GRPCAddress is the address the gRPC server listens on (NTN_GRPC_ADDRESS, default 127.0.0.1)
+	GRPCAddress string
+	// GRPCToken is the bearer token required by every gRPC call (NTN_GRPC_TOKEN, required with a gRPC port)
+	GRPCToken string
+	// SyncMaxRunTime caps the duration of an automatic sync run (NTN_WEBHOOK_SYNC_MAX_RUN_TIME, default 5m,
+	// 0 = unlimited)
+	SyncMaxRunTime time.Duration
+	// IgnoreOwnEvents ignores events triggered only by our own integration (NTN_WEBHOOK_IGNORE_OWN_EVENTS,
+	// default true)
+	IgnoreOwnEvents bool
+	// SimulateToken is the bearer token of the /api/simulate endpoint (NTN_WEBHOOK_SIMULATE_TOKEN,
+	// default empty = endpoint disabled)
+	SimulateToken string
+	// DebugToken is the bearer token of the /api/debug/state endpoint (NTN_WEBHOOK_DEBUG_TOKEN,
+	// default empty = endpoint disabled)
+	DebugToken string
+	// AllowedCIDRs are the sources allowed to call the webhook endpoint (NTN_WEBHOOK_ALLOWED_CIDRS,
+	// default empty = all)
+	AllowedCIDRs []netip.Prefix
+	// MaxBodySize is the maximum size of a webhook request body (NTN_WEBHOOK_MAX_BODY_SIZE, default 1 MiB,
+	// 0 = unlimited)
+	MaxBodySize int64
+	// RateLimit is the number of webhook requests allowed per minute and source IP (NTN_WEBHOOK_RATE_LIMIT,
+	// default 0 = unlimited)
+	RateLimit int
+	// TrustProxy uses the last X-Forwarded-For address as the source of requests (NTN_WEBHOOK_TRUST_PROXY,
+	// default false)
+	TrustProxy bool
+	// AuthProbeDelay is the delay between checks of the Notion token once it was rejected
+	// (NTN_WEBHOOK_AUTH_PROBE_DELAY, default 1m)
+	AuthProbeDelay time.Duration
+	// AdminUI serves the admin UI on /admin/ (NTN_ADMIN_UI, default false)
+	AdminUI bool
+	// AdminUser is the basic auth user of the admin UI (NTN_ADMIN_USER, default admin)
+	AdminUser string
+	// AdminPassword is the basic auth password of the admin UI (NTN_ADMIN_PASSWORD, default empty = no auth)
+	AdminPassword string
 }
 
 // LoadConfigFromEnv loads webhook configuration from environment variables.
@@ -29,6 +71,18 @@ func LoadConfigFromEnv() *ServerConfig {
 		Path:     "/webhooks/notion",
 		Secret:   os.Getenv("NTN_WEBHOOK_SECRET"),
 		AutoSync: true,
+
+		SyncMaxRunTime: DefaultSyncMaxRunTime,
+		AuthProbeDelay: DefaultAuthProbeDelay,
+		MaxBodySize:    DefaultMaxBodySize,
+		RateLimit:      DefaultRateLimit,
+		SimulateToken:  os.Getenv("NTN_WEBHOOK_SIMULATE_TOKEN"),
+		DebugToken:     os.Getenv("NTN_WEBHOOK_DEBUG_TOKEN"),
+		AdminUI:        parseBoolEnv(os.Getenv("NTN_ADMIN_UI")),
+		AdminUser:      DefaultAdminUser,
+		AdminPassword:  os.Getenv("NTN_ADMIN_PASSWORD"),
+
+		IgnoreOwnEvents: true,
 	}
 
 	if portStr := os.Getenv("NTN_WEBHOOK_PORT"); portStr != "" {
@@ -51,6 +105,52 @@ func LoadConfigFromEnv() *ServerConfig {
 		}
 	}
 
+	if maxRunTimeStr := os.Getenv("NTN_WEBHOOK_SYNC_MAX_RUN_TIME"); maxRunTimeStr != "" {
+		if d, err := time.ParseDuration(maxRunTimeStr); err == nil && d >= 0 {
+			cfg.SyncMaxRunTime = d
+		}
+	}
+
+	if probeDelayStr := os.Getenv("NTN_WEBHOOK_AUTH_PROBE_DELAY"); probeDelayStr != "" {
+		if d, err := time.ParseDuration(probeDelayStr); err == nil && d > 0 {
+			cfg.AuthProbeDelay = d
+		}
+	}
+
+	if ignoreStr := os.Getenv("NTN_WEBHOOK_IGNORE_OWN_EVENTS"); ignoreStr != "" {
+		cfg.IgnoreOwnEvents = parseBoolEnv(ignoreStr)
+	}
+
+	if cidrs, err := ParseCIDRs(os.Getenv("NTN_WEBHOOK_ALLOWED_CIDRS")); err == nil {
+		cfg.AllowedCIDRs = cidrs
+	}
+
+	if sizeStr := os.Getenv("NTN_WEBHOOK_MAX_BODY_SIZE"); sizeStr != "" {
+		if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil && size >= 0 {
+			cfg.MaxBodySize = size
+		}
+	}
+
+	if limitStr := os.Getenv("NTN_WEBHOOK_RATE_LIMIT"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit >= 0 {
+			cfg.RateLimit = limit
+		}
+	}
+
+	if user := os.Getenv("NTN_ADMIN_USER"); user != "" {
+		cfg.AdminUser = user
+	}
+
+	if trustStr := os.Getenv("NTN_WEBHOOK_TRUST_PROXY"); trustStr != "" {
+		cfg.TrustProxy = parseBoolEnv(trustStr)
+	}
+
+	if portStr := os.Getenv("NTN_GRPC_PORT"); portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil && port > 0 {
+			cfg.GRPCPort = port
+		}
+	}
+
 	return cfg
 }
 
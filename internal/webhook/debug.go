@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/fclairamb/ntnsync/client"
+	"github.com/fclairamb/ntnsync/internal/sync"
+)
+
+// DebugStatePath is the path of the endpoint dumping the state of the sync runs, to investigate hung syncs.
+const DebugStatePath = client.DebugPath
+
+// DebugDump is the response of the debug endpoint (client.DebugDump, with the state typed).
+type DebugDump struct {
+	State *sync.DebugState `json:"state"`
+	Files []string         `json:"files"` // Files of the dump written to the mirror
+}
+
+// EnableDebug enables the debug endpoint, protected by the given bearer token.
+func (h *Handler) EnableDebug(token string) {
+	h.debugToken = token
+}
+
+// HandleDebugState handles the debug endpoint: like a SIGQUIT, it writes the state of the sync runs in progress
+// and the goroutine stacks to .notion-sync/debug/, and returns the state.
+func (h *Handler) HandleDebugState(writer http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	if req.Method != http.MethodGet {
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if h.debugToken == "" || !found || subtle.ConstantTimeCompare([]byte(token), []byte(h.debugToken)) != 1 {
+		h.logger.WarnContext(ctx, "unauthorized debug request")
+		http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dump := DebugDump{State: sync.CaptureDebugState(), Files: []string{}}
+	files, err := sync.WriteDebugDump(ctx, h.store, dump.State)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to write debug dump", "error", err)
+	} else {
+		dump.Files = files
+		h.logger.InfoContext(ctx, "wrote debug dump", "files", files)
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(dump); err != nil {
+		h.logger.ErrorContext(ctx, "failed to encode debug response", "error", err)
+	}
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHandleDebugState verifies that the debug endpoint requires its bearer token and writes the dump to the store.
+func TestHandleDebugState(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandler(t)
+
+	newRequest := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, DebugStatePath, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleDebugState(rr, newRequest(""))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("disabled endpoint: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	handler.EnableDebug("debug-token")
+	rr = httptest.NewRecorder()
+	handler.HandleDebugState(rr, newRequest("wrong-token"))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.HandleDebugState(rr, newRequest("debug-token"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var dump DebugDump
+	if err := json.Unmarshal(rr.Body.Bytes(), &dump); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if dump.State == nil || dump.State.Goroutines == 0 || len(dump.Files) != 2 {
+		t.Fatalf("dump = %+v, want the state and 2 files", dump)
+	}
+	stacks, err := handler.store.Read(t.Context(), dump.Files[1])
+	if err != nil || !strings.Contains(string(stacks), "goroutine") {
+		t.Errorf("goroutine stacks = %q, %v", stacks, err)
+	}
+}
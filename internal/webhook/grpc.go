@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	stdsync "sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/sync"
+	"github.com/fclairamb/ntnsync/rpc/ntnsyncv1"
+)
+
+// DefaultGRPCAddress is the default address of the gRPC server: only local clients can reach it.
+const DefaultGRPCAddress = "127.0.0.1"
+
+// GRPCServer exposes crawler operations over gRPC for internal tooling.
+type GRPCServer struct {
+	ntnsyncv1.UnimplementedSyncServiceServer
+
+	handler    *Handler
+	crawler    *sync.Crawler // Read-only crawler used for status and listing
+	crawlerMu  stdsync.Mutex // The crawler reloads its state on every call
+	logger     *slog.Logger
+	grpcServer *grpc.Server
+}
+
+// NewGRPCServer creates a gRPC server sharing the webhook handler's queue and sync worker. Every call must carry
+// the bearer token in its "authorization" metadata.
+// The crawler is only used to read the status and pages, and must not be shared with the sync worker.
+func NewGRPCServer(handler *Handler, crawler *sync.Crawler, token string, logger *slog.Logger) *GRPCServer {
+	srv := &GRPCServer{
+		handler:    handler,
+		crawler:    crawler,
+		logger:     logger,
+		grpcServer: grpc.NewServer(grpc.UnaryInterceptor(grpcAuthInterceptor(token, logger))),
+	}
+	ntnsyncv1.RegisterSyncServiceServer(srv.grpcServer, srv)
+	return srv
+}
+
+// grpcAuthInterceptor rejects the calls without the bearer token, like the simulate and debug endpoints. An empty
+// token rejects every call.
+func grpcAuthInterceptor(token string, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (any, error) {
+		var provided string
+		if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+			provided, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			logger.WarnContext(ctx, "unauthorized grpc call", "method", info.FullMethod)
+			return nil, status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Serve accepts gRPC connections on the listener until Stop is called.
+func (s *GRPCServer) Serve(listener net.Listener) error {
+	if err := s.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("serve grpc: %w", err)
+	}
+	return nil
+}
+
+// Stop gracefully stops the server, waiting for pending calls to complete.
+func (s *GRPCServer) Stop() {
+	s.grpcServer.GracefulStop()
+}
+
+// EnqueuePage queues a page for a forced sync, like a webhook event.
+func (s *GRPCServer) EnqueuePage(
+	ctx context.Context, req *ntnsyncv1.EnqueuePageRequest,
+) (*ntnsyncv1.EnqueuePageResponse, error) {
+	pageID, err := notion.ParsePageIDOrURL(req.GetPageId())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page ID or URL: %v", err)
+	}
+
+	folder, filename, err := s.handler.EnqueuePage(ctx, pageID, req.GetFolder())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "enqueue page: %v", err)
+	}
+
+	return &ntnsyncv1.EnqueuePageResponse{
+		PageId:    pageID,
+		Folder:    folder,
+		QueueFile: filename,
+	}, nil
+}
+
+// SyncNow triggers the sync worker to process the queue.
+func (s *GRPCServer) SyncNow(ctx context.Context, _ *ntnsyncv1.SyncNowRequest) (*ntnsyncv1.SyncNowResponse, error) {
+	if s.handler.syncWorker == nil {
+		return nil, status.Error(codes.FailedPrecondition, "auto-sync is disabled")
+	}
+
+	s.logger.InfoContext(ctx, "sync requested over grpc")
+	s.handler.syncWorker.Notify()
+	return &ntnsyncv1.SyncNowResponse{}, nil
+}
+
+// GetStatus returns the sync status of the folders and the queue.
+func (s *GRPCServer) GetStatus(
+	ctx context.Context, req *ntnsyncv1.GetStatusRequest,
+) (*ntnsyncv1.GetStatusResponse, error) {
+	s.crawlerMu.Lock()
+	info, err := s.crawler.GetStatus(ctx, req.GetFolder())
+	s.crawlerMu.Unlock()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get status: %v", err)
+	}
+
+	resp := &ntnsyncv1.GetStatusResponse{
+		BlockedPages: int32(len(info.BlockedPages)), //nolint:gosec // Page counts fit in int32
+	}
+
+	names := make([]string, 0, len(info.Folders))
+	for name := range info.Folders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		folder := info.Folders[name]
+		folderStatus := &ntnsyncv1.FolderStatus{
+			Name:        folder.Name,
+			PageCount:   int32(folder.PageCount),   //nolint:gosec // Page counts fit in int32
+			RootPages:   int32(folder.RootPages),   //nolint:gosec // Page counts fit in int32
+			QueuedPages: int32(folder.QueuedPages), //nolint:gosec // Page counts fit in int32
+			TotalBytes:  folder.TotalBytes,
+			OverQuota:   folder.OverQuota,
+		}
+		if folder.LastSynced != nil {
+			folderStatus.LastSynced = timestamppb.New(*folder.LastSynced)
+		}
+		resp.Folders = append(resp.Folders, folderStatus)
+	}
+
+	for _, entry := range info.QueueEntries {
+		resp.QueueEntries = append(resp.QueueEntries, &ntnsyncv1.QueueEntry{
+			QueueFile: entry.QueueFile,
+			Folder:    entry.Folder,
+			Type:      entry.Type,
+			PageCount: int32(entry.PageCount), //nolint:gosec // Page counts fit in int32
+		})
+	}
+
+	return resp, nil
+}
+
+// ListPages returns the synced pages.
+func (s *GRPCServer) ListPages(
+	ctx context.Context, req *ntnsyncv1.ListPagesRequest,
+) (*ntnsyncv1.ListPagesResponse, error) {
+	s.crawlerMu.Lock()
+	folders, err := s.crawler.ListPages(ctx, req.GetFolder(), false)
+	s.crawlerMu.Unlock()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list pages: %v", err)
+	}
+
+	resp := &ntnsyncv1.ListPagesResponse{}
+	for _, folder := range folders {
+		pages := slices.Clone(folder.Pages)
+		slices.SortFunc(pages, func(a, b *sync.PageInfo) int { return strings.Compare(a.Path, b.Path) })
+		for _, page := range pages {
+			resp.Pages = append(resp.Pages, &ntnsyncv1.Page{
+				Id:         page.ID,
+				Title:      page.Title,
+				Folder:     folder.Name,
+				Path:       page.Path,
+				LastSynced: timestamppb.New(page.LastSynced),
+				IsRoot:     page.IsRoot,
+				IsOrphaned: page.IsOrphaned,
+				ParentId:   page.ParentID,
+			})
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/fclairamb/ntnsync/internal/sync"
+	"github.com/fclairamb/ntnsync/rpc/ntnsyncv1"
+)
+
+// testGRPCToken is the bearer token of the gRPC servers of the tests.
+const testGRPCToken = "grpc-secret"
+
+// bearerToken sends a bearer token with every call, like a client of the gRPC server would.
+type bearerToken string
+
+func (b bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+func (bearerToken) RequireTransportSecurity() bool {
+	return false
+}
+
+// newTestGRPCClient starts a gRPC server for the handler over an in-memory connection, and returns a client
+// authenticated with its token.
+func newTestGRPCClient(t *testing.T, handler *Handler) ntnsyncv1.SyncServiceClient {
+	t.Helper()
+	return newTestGRPCClientWithToken(t, handler, testGRPCToken)
+}
+
+// newTestGRPCClientWithToken is like newTestGRPCClient, with the token sent by the client (empty = none).
+func newTestGRPCClientWithToken(t *testing.T, handler *Handler, token string) ntnsyncv1.SyncServiceClient {
+	t.Helper()
+
+	crawler := sync.NewCrawler(nil, handler.store, sync.WithCrawlerLogger(slog.Default()))
+	srv := NewGRPCServer(handler, crawler, testGRPCToken, slog.Default())
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			t.Errorf("grpc server error: %v", err)
+		}
+	}()
+	t.Cleanup(srv.Stop)
+
+	options := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(bearerToken(token)))
+	}
+	conn, err := grpc.NewClient("passthrough:///bufnet", options...)
+	if err != nil {
+		t.Fatalf("failed to create grpc client: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return ntnsyncv1.NewSyncServiceClient(conn)
+}
+
+// TestGRPC_EnqueuePage verifies that pages enqueued over gRPC show up in the status.
+func TestGRPC_EnqueuePage(t *testing.T) {
+	t.Parallel()
+	client := newTestGRPCClient(t, createTestHandlerWithoutSecret(t))
+	ctx := context.Background()
+
+	resp, err := client.EnqueuePage(ctx, &ntnsyncv1.EnqueuePageRequest{
+		PageId: "https://www.notion.so/My-Page-2c536f5e48f44234ad8d73a1a148e95d",
+	})
+	if err != nil {
+		t.Fatalf("EnqueuePage() error = %v", err)
+	}
+	if resp.GetPageId() != "2c536f5e48f44234ad8d73a1a148e95d" {
+		t.Errorf("page ID = %q, want normalized ID", resp.GetPageId())
+	}
+	if resp.GetFolder() != defaultFolderName {
+		t.Errorf("folder = %q, want %q for an untracked page", resp.GetFolder(), defaultFolderName)
+	}
+
+	statusResp, err := client.GetStatus(ctx, &ntnsyncv1.GetStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	entries := statusResp.GetQueueEntries()
+	if len(entries) != 1 || entries[0].GetQueueFile() != resp.GetQueueFile() {
+		t.Errorf("queue entries = %v, want the enqueued file %s", entries, resp.GetQueueFile())
+	}
+}
+
+// TestGRPC_EnqueuePage_InvalidID verifies that invalid page IDs are rejected.
+func TestGRPC_EnqueuePage_InvalidID(t *testing.T) {
+	t.Parallel()
+	client := newTestGRPCClient(t, createTestHandlerWithoutSecret(t))
+
+	_, err := client.EnqueuePage(context.Background(), &ntnsyncv1.EnqueuePageRequest{PageId: "not a page"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("EnqueuePage() error = %v, want InvalidArgument", err)
+	}
+}
+
+// TestGRPC_SyncNow_WithoutWorker verifies that SyncNow fails when auto-sync is disabled.
+func TestGRPC_SyncNow_WithoutWorker(t *testing.T) {
+	t.Parallel()
+	client := newTestGRPCClient(t, createTestHandlerWithoutSecret(t))
+
+	_, err := client.SyncNow(context.Background(), &ntnsyncv1.SyncNowRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("SyncNow() error = %v, want FailedPrecondition", err)
+	}
+}
+
+// TestGRPC_Unauthenticated verifies that calls without the bearer token are rejected, before enqueuing anything.
+func TestGRPC_Unauthenticated(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandlerWithoutSecret(t)
+	ctx := context.Background()
+
+	for _, token := range []string{"", "wrong"} {
+		client := newTestGRPCClientWithToken(t, handler, token)
+		_, err := client.EnqueuePage(ctx, &ntnsyncv1.EnqueuePageRequest{PageId: "2c536f5e48f44234ad8d73a1a148e95d"})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("EnqueuePage() with token %q error = %v, want Unauthenticated", token, err)
+		}
+	}
+
+	if files, _ := handler.queueManager.ListEntries(ctx); len(files) != 0 {
+		t.Errorf("queue files = %v, want none", files)
+	}
+}
+
+// TestGRPC_GoPackage verifies that the stubs are generated for their public import path.
+func TestGRPC_GoPackage(t *testing.T) {
+	t.Parallel()
+
+	options, ok := ntnsyncv1.File_ntnsync_v1_ntnsync_proto.Options().(*descriptorpb.FileOptions)
+	if want := "github.com/fclairamb/ntnsync/rpc/ntnsyncv1"; !ok || options.GetGoPackage() != want {
+		t.Errorf("go_package = %q, want %q", options.GetGoPackage(), want)
+	}
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	stdsync "sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+const (
+	// DefaultMaxBodySize is the default maximum size of a webhook request body.
+	DefaultMaxBodySize = 1 << 20 // 1 MiB
+
+	// DefaultRateLimit is the default number of webhook requests allowed per minute and source IP: unlimited, as
+	// Notion delivers bursts of events from a few IPs.
+	DefaultRateLimit = 0
+
+	// Rate limiters of IPs idle for this long are dropped when there are too many of them.
+	rateLimiterIdle       = 10 * time.Minute
+	rateLimiterMaxEntries = 10000
+)
+
+// ParseCIDRs parses comma-separated CIDRs (or single IP addresses) of allowed webhook sources.
+func ParseCIDRs(spec string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidCIDR, part)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidCIDR, part)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// requestGuard protects the webhook endpoint from unwanted sources and floods: it rejects requests
+// from sources outside the allowed CIDRs, bodies larger than the max body size, and sources exceeding
+// their rate limit, before any signature check or processing.
+type requestGuard struct {
+	allowed     []netip.Prefix // Allowed sources (empty = all)
+	maxBodySize int64          // Maximum body size (0 = unlimited)
+	rateLimit   int            // Requests per minute and source IP (0 = unlimited)
+	trustProxy  bool           // Use the last X-Forwarded-For address as the source
+	rejected    *atomic.Int64  // Counter of rejected requests
+	logger      *slog.Logger
+
+	mu       stdsync.Mutex
+	limiters map[netip.Addr]*ipLimiter
+}
+
+// ipLimiter is the rate limiter of a source IP.
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newRequestGuard creates a request guard from the server configuration.
+func newRequestGuard(cfg *ServerConfig, rejected *atomic.Int64, logger *slog.Logger) *requestGuard {
+	return &requestGuard{
+		allowed:     cfg.AllowedCIDRs,
+		maxBodySize: cfg.MaxBodySize,
+		rateLimit:   cfg.RateLimit,
+		trustProxy:  cfg.TrustProxy,
+		rejected:    rejected,
+		logger:      logger,
+		limiters:    make(map[netip.Addr]*ipLimiter),
+	}
+}
+
+// wrap returns a handler applying the guard before calling next.
+func (g *requestGuard) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		source, ok := g.sourceAddr(req)
+		if !ok || !g.isAllowed(source) {
+			g.rejected.Add(1)
+			g.logger.WarnContext(ctx, "webhook request from a source not allowed",
+				"remote_addr", req.RemoteAddr, "source", source)
+			http.Error(writer, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !g.allowRequest(source, time.Now()) {
+			g.rejected.Add(1)
+			g.logger.DebugContext(ctx, "webhook request rate limited", "source", source)
+			writer.Header().Set("Retry-After", "60")
+			http.Error(writer, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		if g.maxBodySize > 0 {
+			body, err := io.ReadAll(http.MaxBytesReader(writer, req.Body, g.maxBodySize))
+			if err != nil {
+				if maxErr := (*http.MaxBytesError)(nil); errors.As(err, &maxErr) {
+					g.rejected.Add(1)
+					g.logger.WarnContext(ctx, "webhook request body too large",
+						"source", source, "max_body_size", g.maxBodySize)
+					http.Error(writer, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(writer, "Invalid payload", http.StatusBadRequest)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		next(writer, req)
+	}
+}
+
+// sourceAddr returns the source address of a request: the last X-Forwarded-For address behind a
+// trusted proxy, the remote address otherwise.
+func (g *requestGuard) sourceAddr(req *http.Request) (netip.Addr, bool) {
+	if g.trustProxy {
+		if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			addr, err := netip.ParseAddr(strings.TrimSpace(parts[len(parts)-1]))
+			return addr.Unmap(), err == nil
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return addr.Unmap(), err == nil
+}
+
+// isAllowed returns true if the source is in the allowed CIDRs, or if all sources are allowed.
+func (g *requestGuard) isAllowed(source netip.Addr) bool {
+	if len(g.allowed) == 0 {
+		return true
+	}
+	for _, prefix := range g.allowed {
+		if prefix.Contains(source) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowRequest returns true if the source has not exceeded its rate limit.
+func (g *requestGuard) allowRequest(source netip.Addr, now time.Time) bool {
+	if g.rateLimit <= 0 {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	entry := g.limiters[source]
+	if entry == nil {
+		if len(g.limiters) >= rateLimiterMaxEntries {
+			g.dropIdleLimiters(now)
+		}
+		entry = &ipLimiter{limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(g.rateLimit)), g.rateLimit)}
+		g.limiters[source] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
+
+// dropIdleLimiters drops the rate limiters of sources idle for a while.
+func (g *requestGuard) dropIdleLimiters(now time.Time) {
+	for addr, entry := range g.limiters {
+		if now.Sub(entry.lastSeen) > rateLimiterIdle {
+			delete(g.limiters, addr)
+		}
+	}
+}
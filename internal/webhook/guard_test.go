@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+// TestParseCIDRs verifies parsing of allowed source CIDRs and single addresses.
+func TestParseCIDRs(t *testing.T) {
+	t.Parallel()
+
+	prefixes, err := ParseCIDRs(" 10.0.0.0/8, 192.168.1.7 ,, 2001:db8::/32")
+	if err != nil {
+		t.Fatalf("ParseCIDRs() error = %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.7/32", "2001:db8::/32"}
+	if len(prefixes) != len(want) {
+		t.Fatalf("ParseCIDRs() = %v, want %v", prefixes, want)
+	}
+	for i, prefix := range prefixes {
+		if prefix.String() != want[i] {
+			t.Errorf("prefix %d = %s, want %s", i, prefix, want[i])
+		}
+	}
+
+	if prefixes, err := ParseCIDRs(""); err != nil || len(prefixes) != 0 {
+		t.Errorf("ParseCIDRs(\"\") = %v, %v, want none", prefixes, err)
+	}
+
+	if _, err := ParseCIDRs("10.0.0.0/8,not-an-ip"); !errors.Is(err, apperrors.ErrInvalidCIDR) {
+		t.Errorf("ParseCIDRs(invalid) error = %v, want ErrInvalidCIDR", err)
+	}
+}
+
+// newTestGuardServer returns a guarded handler echoing the request body, and its rejection counter.
+func newTestGuardServer(t *testing.T, cfg *ServerConfig) (http.HandlerFunc, *atomic.Int64) {
+	t.Helper()
+
+	rejected := &atomic.Int64{}
+	guard := newRequestGuard(cfg, rejected, slog.New(slog.DiscardHandler))
+	return guard.wrap(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		_, _ = writer.Write(body)
+	}), rejected
+}
+
+// serveGuarded sends a request from the given remote address to a guarded handler.
+func serveGuarded(handler http.HandlerFunc, remoteAddr, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/notion", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// TestRequestGuard_AllowedCIDRs verifies that sources outside the allowed CIDRs are rejected.
+func TestRequestGuard_AllowedCIDRs(t *testing.T) {
+	t.Parallel()
+
+	allowed, err := ParseCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, rejected := newTestGuardServer(t, &ServerConfig{AllowedCIDRs: allowed})
+
+	if rec := serveGuarded(handler, "10.1.2.3:4567", "ok", nil); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("allowed source: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	if rec := serveGuarded(handler, "203.0.113.5:4567", "ok", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("forbidden source: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if got := rejected.Load(); got != 1 {
+		t.Errorf("rejected = %d, want 1", got)
+	}
+}
+
+// TestRequestGuard_TrustProxy verifies that the X-Forwarded-For header is only used behind a trusted proxy.
+func TestRequestGuard_TrustProxy(t *testing.T) {
+	t.Parallel()
+
+	allowed, err := ParseCIDRs("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"X-Forwarded-For": []string{"203.0.113.5, 10.1.2.3"}}
+
+	direct, _ := newTestGuardServer(t, &ServerConfig{AllowedCIDRs: allowed})
+	if rec := serveGuarded(direct, "127.0.0.1:4567", "ok", header); rec.Code != http.StatusForbidden {
+		t.Errorf("untrusted proxy: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	proxied, _ := newTestGuardServer(t, &ServerConfig{AllowedCIDRs: allowed, TrustProxy: true})
+	if rec := serveGuarded(proxied, "127.0.0.1:4567", "ok", header); rec.Code != http.StatusOK {
+		t.Errorf("trusted proxy: status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// TestRequestGuard_RateLimit verifies that each source is rate limited separately.
+func TestRequestGuard_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	handler, rejected := newTestGuardServer(t, &ServerConfig{RateLimit: 2})
+
+	for i := range 2 {
+		if rec := serveGuarded(handler, "10.0.0.1:1000", "ok", nil); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want %d", i, rec.Code, http.StatusOK)
+		}
+	}
+	rec := serveGuarded(handler, "10.0.0.1:1000", "ok", nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("rate limited: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := serveGuarded(handler, "10.0.0.2:1000", "ok", nil); rec.Code != http.StatusOK {
+		t.Errorf("other source: status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rejected.Load(); got != 1 {
+		t.Errorf("rejected = %d, want 1", got)
+	}
+}
+
+// TestRequestGuard_RateLimitOffByDefault verifies that bursts from a single source pass with the default limit.
+func TestRequestGuard_RateLimitOffByDefault(t *testing.T) {
+	t.Parallel()
+
+	handler, rejected := newTestGuardServer(t, &ServerConfig{RateLimit: DefaultRateLimit})
+
+	for i := range 500 {
+		if rec := serveGuarded(handler, "10.0.0.1:1000", "ok", nil); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want %d", i, rec.Code, http.StatusOK)
+		}
+	}
+	if got := rejected.Load(); got != 0 {
+		t.Errorf("rejected = %d, want 0", got)
+	}
+}
+
+// TestRequestGuard_MaxBodySize verifies that large bodies are rejected and smaller ones passed through.
+func TestRequestGuard_MaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	handler, _ := newTestGuardServer(t, &ServerConfig{MaxBodySize: 16})
+
+	if rec := serveGuarded(handler, "10.0.0.1:1000", "small", nil); rec.Code != http.StatusOK ||
+		rec.Body.String() != "small" {
+		t.Errorf("small body: status %d, body %q", rec.Code, rec.Body.String())
+	}
+	rec := serveGuarded(handler, "10.0.0.1:1000", strings.Repeat("x", 17), nil)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body: status %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -7,15 +7,20 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
 	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
 	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
 	"github.com/fclairamb/ntnsync/internal/version"
 )
 
@@ -27,6 +32,12 @@ const (
 
 	// eventTypePageContentUpdated is the Notion webhook event type for page content changes.
 	eventTypePageContentUpdated = "page.content_updated"
+	// eventTypePagePropertiesUpdated is the Notion webhook event type for page property changes.
+	eventTypePagePropertiesUpdated = "page.properties_updated"
+	// eventTypeDataSourceSchemaUpdated is the Notion webhook event type for database schema changes.
+	eventTypeDataSourceSchemaUpdated = "data_source.schema_updated"
+	// eventTypeDatabaseSchemaUpdated is the event type of database schema changes before data sources.
+	eventTypeDatabaseSchemaUpdated = "database.schema_updated"
 )
 
 // Event represents a Notion webhook event payload.
@@ -61,6 +72,7 @@ type Entity struct {
 // EventData contains event-specific details.
 type EventData struct {
 	Parent        *Parent        `json:"parent,omitempty"`
+	PageID        string         `json:"page_id,omitempty"`        // For comment events
 	UpdatedBlocks []UpdatedBlock `json:"updated_blocks,omitempty"` // For content_updated events
 }
 
@@ -84,6 +96,17 @@ func (e *Event) GetEntityID() string {
 	return ""
 }
 
+// updatedBlockIDs returns the normalized IDs of the blocks changed by a content_updated event.
+func (e *Event) updatedBlockIDs() []string {
+	ids := make([]string, 0, len(e.Data.UpdatedBlocks))
+	for _, block := range e.Data.UpdatedBlocks {
+		if id := notion.NormalizeID(block.ID); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // GetEntityType returns the entity type from the event.
 func (e *Event) GetEntityType() string {
 	if e.Entity != nil {
@@ -101,6 +124,12 @@ type Handler struct {
 	autoSync     bool
 	syncWorker   *SyncWorker
 	remoteConfig *store.RemoteConfig
+	loopGuard    *loopGuard // Suppresses our own integration's events (nil = disabled)
+	metrics      eventMetrics
+	events       eventLog // Last events received, shown by the admin UI
+
+	simulateToken string // Bearer token of the simulate endpoint (empty = disabled)
+	debugToken    string // Bearer token of the debug endpoint (empty = disabled)
 }
 
 // NewHandler creates a new webhook handler.
@@ -143,7 +172,6 @@ func (h *Handler) HandleWebhook(writer http.ResponseWriter, req *http.Request) {
 	}
 
 	// Parse webhook payload
-	var event Event
 	rawJSON, err := io.ReadAll(req.Body)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "failed to read webhook payload", "error", err)
@@ -151,25 +179,37 @@ func (h *Handler) HandleWebhook(writer http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	event, err := h.acceptEvent(ctx, rawJSON)
+	if err != nil {
+		http.Error(writer, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Process event asynchronously with a detached context
+	// We use context.WithoutCancel to allow the goroutine to complete even if the request context is canceled
+	go h.processEvent(context.WithoutCancel(ctx), event)
+
+	// Acknowledge receipt immediately
+	writer.WriteHeader(http.StatusOK)
+}
+
+// acceptEvent decodes a webhook payload whose signature was verified, and counts it as received.
+func (h *Handler) acceptEvent(ctx context.Context, rawJSON []byte) (*Event, error) {
 	h.logger.DebugContext(ctx, "received webhook", "rawJson", string(rawJSON))
 
+	var event Event
 	if err := json.Unmarshal(rawJSON, &event); err != nil {
 		h.logger.ErrorContext(ctx, "failed to decode webhook", "error", err)
-		http.Error(writer, "Invalid payload", http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("decode webhook: %w", err)
 	}
 
+	h.metrics.received.Add(1)
+	h.events.add(&event)
 	h.logger.InfoContext(ctx, "received webhook event",
 		"event_type", event.Type,
 		"entity_id", event.GetEntityID(),
 		"entity_type", event.GetEntityType())
-
-	// Process event asynchronously with a detached context
-	// We use context.WithoutCancel to allow the goroutine to complete even if the request context is canceled
-	go h.processEvent(context.WithoutCancel(ctx), &event)
-
-	// Acknowledge receipt immediately
-	writer.WriteHeader(http.StatusOK)
+	return &event, nil
 }
 
 // handleURLVerification handles Notion's webhook URL verification request.
@@ -210,6 +250,28 @@ func (h *Handler) HandleHealth(writer http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// HandleReady handles the /readyz endpoint. It fails while the sync worker is degraded, e.g. because Notion
+// rejects the token: webhooks are still queued, but nothing is synced.
+func (h *Handler) HandleReady(writer http.ResponseWriter, req *http.Request) {
+	response := map[string]string{
+		"status": "ok",
+	}
+	status := http.StatusOK
+	if h.syncWorker != nil {
+		if reason := h.syncWorker.Degraded(); reason != "" {
+			response["status"] = "degraded"
+			response["reason"] = reason
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(response); err != nil {
+		h.logger.ErrorContext(req.Context(), "failed to encode ready response", "error", err)
+	}
+}
+
 // verifySignature verifies the webhook signature using HMAC-SHA256.
 // If no secret is configured, signature verification is skipped.
 func (h *Handler) verifySignature(req *http.Request) bool {
@@ -271,33 +333,46 @@ func (h *Handler) processEvent(ctx context.Context, event *Event) {
 		"entity_type", event.GetEntityType(),
 		"workspace", event.WorkspaceName)
 
+	if h.suppressOwnEvent(ctx, event) {
+		return
+	}
+
 	// Create a transaction for write operations
 	transaction, err := h.store.BeginTx(ctx)
 	if err != nil {
 		h.logger.ErrorContext(ctx, "failed to begin transaction", "error", err)
 		return
 	}
+	defer h.releaseStoreLock(ctx)
 	h.queueManager.SetTransaction(transaction)
 
 	switch event.Type {
-	case "page.created", "page.updated", eventTypePageContentUpdated, "page.properties_updated":
+	case "page.created", "page.updated", eventTypePageContentUpdated, eventTypePagePropertiesUpdated:
+		h.handlePageChange(ctx, event, transaction)
+	case "page.undeleted":
 		h.handlePageChange(ctx, event, transaction)
-	case "page.deleted", "page.undeleted":
-		h.handlePageDeletion(ctx, event)
-	case "database.created", "database.updated", "database.content_updated", "database.properties_updated":
+	case "page.deleted":
+		h.handleDeletion(ctx, event, transaction)
+	case "database.created", "database.updated", "database.content_updated", "database.properties_updated",
+		"database.undeleted":
 		h.handleDatabaseChange(ctx, event, transaction)
-	case "database.deleted", "database.undeleted":
-		h.handleDatabaseDeletion(ctx, event)
+	case "database.deleted":
+		h.handleDeletion(ctx, event, transaction)
+	case eventTypeDataSourceSchemaUpdated, eventTypeDatabaseSchemaUpdated:
+		h.handleSchemaUpdate(ctx, event, transaction)
+	case "comment.created", "comment.updated", "comment.deleted":
+		h.handleCommentChange(ctx, event)
 	case "":
 		if event.VerificationToken != "" {
 			h.handleURLVerification(ctx, event)
 		}
 	default:
-		h.logger.WarnContext(ctx, "unhandled event type", "type", event.Type)
+		h.handleUnknownEvent(ctx, event)
 	}
 }
 
-// handlePageChange handles page.created, page.updated, and eventTypePageContentUpdated events.
+// handlePageChange handles page.created, page.updated, eventTypePageContentUpdated and
+// eventTypePagePropertiesUpdated events. Property changes only refresh the frontmatter of synced pages.
 func (h *Handler) handlePageChange(ctx context.Context, event *Event, transaction store.Transaction) {
 	// Notion delivers IDs in dashed UUID form; normalize so the queue entry and
 	// every downstream registry lookup use the canonical (dash-less) key.
@@ -329,8 +404,19 @@ func (h *Handler) handlePageChange(ctx context.Context, event *Event, transactio
 		folder = defaultFolderName
 	}
 
-	// Create webhook queue entry (uses decrementing IDs for priority)
-	filename, err := h.queueManager.CreateWebhookEntry(ctx, pageID, folder)
+	queueType := queue.TypeUpdate
+	if event.Type == eventTypePagePropertiesUpdated {
+		queueType = queue.TypeProperties
+	}
+
+	// Create webhook queue entry (uses decrementing IDs for priority). Content changes keep their blocks, so
+	// that only their subtrees are fetched again.
+	var filename string
+	if updatedBlocks := event.updatedBlockIDs(); event.Type == eventTypePageContentUpdated && len(updatedBlocks) > 0 {
+		filename, err = h.queueManager.CreateWebhookBlocksEntry(ctx, pageID, folder, updatedBlocks)
+	} else {
+		filename, err = h.queueManager.CreateWebhookEntryWithType(ctx, pageID, folder, queueType)
+	}
 	if err != nil {
 		h.logger.ErrorContext(ctx, "failed to create queue entry",
 			"page_id", pageID,
@@ -341,38 +427,94 @@ func (h *Handler) handlePageChange(ctx context.Context, event *Event, transactio
 	h.logger.InfoContext(ctx, "page queued for sync",
 		"page_id", pageID,
 		"queue_file", filename,
-		"folder", folder)
+		"folder", folder,
+		"type", queueType)
 
 	// Commit queue files immediately
 	h.commitQueueFiles(ctx, transaction, "queued page "+pageID)
 
 	// Notify sync worker if configured
 	if h.syncWorker != nil {
-		h.syncWorker.Notify()
+		h.syncWorker.NotifyFolder(folder)
 	}
 }
 
-// handlePageDeletion handles page.deleted and page.undeleted events.
-func (h *Handler) handlePageDeletion(ctx context.Context, event *Event) {
-	pageID := notion.NormalizeID(event.GetEntityID())
-	if pageID == "" {
-		h.logger.WarnContext(ctx, "page deletion event missing entity ID")
-		return
+// EnqueuePage queues a page for a forced sync, as a page change event would.
+// If folder is empty, the folder of the page's registry is used, or the default folder.
+// Returns the folder and the queue file.
+func (h *Handler) EnqueuePage(ctx context.Context, pageID, folder string) (string, string, error) {
+	pageID = notion.NormalizeID(pageID)
+	if folder == "" {
+		var err error
+		if folder, err = h.lookupPageFolder(ctx, pageID); err != nil {
+			folder = defaultFolderName
+		}
 	}
 
-	h.logger.DebugContext(ctx, "handling page deletion",
-		"page_id", pageID,
-		"event_type", event.Type)
+	transaction, err := h.store.BeginTx(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("begin transaction: %w", err)
+	}
+	defer h.releaseStoreLock(ctx)
+	h.queueManager.SetTransaction(transaction)
 
-	// For now, just log the deletion. Full implementation would:
-	// 1. Look up the page in registry
-	// 2. Delete the markdown file
-	// 3. Delete the registry entry
-	// This requires access to the sync package's registry methods
+	filename, err := h.queueManager.CreateWebhookEntry(ctx, pageID, folder)
+	if err != nil {
+		return "", "", fmt.Errorf("create queue entry: %w", err)
+	}
 
-	h.logger.InfoContext(ctx, "page deletion event received (not yet implemented)",
+	h.logger.InfoContext(ctx, "page queued for sync",
 		"page_id", pageID,
+		"queue_file", filename,
+		"folder", folder)
+
+	h.commitQueueFiles(ctx, transaction, "queued page "+pageID)
+
+	if h.syncWorker != nil {
+		h.syncWorker.NotifyFolder(folder)
+	}
+
+	return folder, filename, nil
+}
+
+// handleDeletion handles page.deleted and database.deleted events: the page or database is removed from the
+// mirror, and the deletion is committed and pushed. Restored pages (*.undeleted events) are queued like changes.
+func (h *Handler) handleDeletion(ctx context.Context, event *Event, transaction store.Transaction) {
+	entityID := notion.NormalizeID(event.GetEntityID())
+	if entityID == "" {
+		h.logger.WarnContext(ctx, "deletion event missing entity ID", "event_type", event.Type)
+		return
+	}
+
+	h.logger.DebugContext(ctx, "handling deletion",
+		"entity_id", entityID,
 		"event_type", event.Type)
+
+	crawler := sync.NewCrawler(nil, h.store, sync.WithCrawlerLogger(h.logger))
+	crawler.SetTransaction(transaction)
+	reg, deleted, err := crawler.DeletePage(ctx, entityID)
+	if errors.Is(err, apperrors.ErrPageNotSynced) {
+		h.logger.DebugContext(ctx, "deleted entity is not in the mirror", "entity_id", entityID)
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to delete page",
+			"entity_id", entityID,
+			"error", err)
+		return
+	}
+
+	h.logger.InfoContext(ctx, "page deleted from the mirror",
+		"entity_id", entityID,
+		"file_path", reg.FilePath,
+		"folder", reg.Folder,
+		"files", len(deleted))
+
+	kind := "page"
+	if event.Type == "database.deleted" {
+		kind = "database"
+	}
+	h.commitQueueFiles(ctx, transaction, fmt.Sprintf("deleted %s %s", kind, entityID))
 }
 
 // handleDatabaseChange handles database.* events.
@@ -387,6 +529,33 @@ func (h *Handler) handleDatabaseChange(ctx context.Context, event *Event, transa
 		"database_id", databaseID,
 		"event_type", event.Type)
 
+	h.queueDatabase(ctx, databaseID, transaction)
+}
+
+// handleSchemaUpdate handles data_source.schema_updated events. The database is synced again, which records
+// its new schema time and queues a property refresh of its synced rows.
+func (h *Handler) handleSchemaUpdate(ctx context.Context, event *Event, transaction store.Transaction) {
+	// The entity is the data source; the registry tracks the database holding it
+	databaseID := event.GetEntityID()
+	if parent := event.Data.Parent; parent != nil && parent.Type == "database" && parent.ID != "" {
+		databaseID = parent.ID
+	}
+	databaseID = notion.NormalizeID(databaseID)
+	if databaseID == "" {
+		h.logger.WarnContext(ctx, "schema update event missing entity ID")
+		return
+	}
+
+	h.logger.DebugContext(ctx, "handling schema update",
+		"database_id", databaseID,
+		"entity_id", event.GetEntityID(),
+		"event_type", event.Type)
+
+	h.queueDatabase(ctx, databaseID, transaction)
+}
+
+// queueDatabase queues a database for sync in the folder of its registry.
+func (h *Handler) queueDatabase(ctx context.Context, databaseID string, transaction store.Transaction) {
 	// Look up the database's folder from registry
 	folder, err := h.lookupPageFolder(ctx, databaseID)
 	if err != nil {
@@ -415,25 +584,29 @@ func (h *Handler) handleDatabaseChange(ctx context.Context, event *Event, transa
 
 	// Notify sync worker if configured
 	if h.syncWorker != nil {
-		h.syncWorker.Notify()
+		h.syncWorker.NotifyFolder(folder)
 	}
 }
 
-// handleDatabaseDeletion handles database.deleted and database.undeleted events.
-func (h *Handler) handleDatabaseDeletion(ctx context.Context, event *Event) {
-	databaseID := notion.NormalizeID(event.GetEntityID())
-	if databaseID == "" {
-		h.logger.WarnContext(ctx, "database deletion event missing entity ID")
-		return
-	}
-
-	h.logger.DebugContext(ctx, "handling database deletion",
-		"database_id", databaseID,
+// handleCommentChange handles comment.* events. Comments are not part of the mirror, so their events
+// don't queue anything: they are only counted, instead of being reported as unknown.
+func (h *Handler) handleCommentChange(ctx context.Context, event *Event) {
+	h.metrics.ignored.Add(1)
+	h.logger.DebugContext(ctx, "ignoring comment event, comments are not mirrored",
+		"comment_id", event.GetEntityID(),
+		"page_id", notion.NormalizeID(event.Data.PageID),
 		"event_type", event.Type)
+}
 
-	h.logger.InfoContext(ctx, "database deletion event received (not yet implemented)",
-		"database_id", databaseID,
-		"event_type", event.Type)
+// handleUnknownEvent counts events of unknown types. Each type is only logged as a warning the first time,
+// so that new Notion event types don't flood the logs.
+func (h *Handler) handleUnknownEvent(ctx context.Context, event *Event) {
+	if count := h.metrics.addUnknown(event.Type); count == 1 {
+		h.logger.WarnContext(ctx, "unhandled event type, further events of this type are only counted",
+			"type", event.Type)
+		return
+	}
+	h.logger.DebugContext(ctx, "unhandled event type", "type", event.Type)
 }
 
 // lookupPageFolder attempts to find the folder for a page from the registry.
@@ -471,6 +644,16 @@ func (h *Handler) lookupPageFolder(ctx context.Context, pageID string) (string,
 	return registry.Folder, nil
 }
 
+// releaseStoreLock releases the hold of the transaction of an event on the lock of the store directory, so that
+// other commands can write to it once no event or sync run holds it anymore.
+func (h *Handler) releaseStoreLock(ctx context.Context) {
+	if releaser, ok := h.store.(store.LockReleaser); ok {
+		if err := releaser.ReleaseLock(); err != nil {
+			h.logger.WarnContext(ctx, "failed to release store lock", "error", err)
+		}
+	}
+}
+
 // commitQueueFiles commits webhook queue files to git immediately and pushes to remote.
 // This ensures queue files are persisted before sync processing begins.
 func (h *Handler) commitQueueFiles(ctx context.Context, transaction store.Transaction, description string) {
@@ -11,7 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
 	"testing"
 	"time"
@@ -277,6 +277,34 @@ func TestHandleVersion(t *testing.T) {
 	}
 }
 
+// TestHandleReady verifies that the readiness endpoint fails while the sync worker is degraded.
+func TestHandleReady(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandler(t)
+	handler.syncWorker = createTestWorker(t)
+
+	rr := httptest.NewRecorder()
+	handler.HandleReady(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+
+	handler.syncWorker.degraded = "notion token rejected"
+	rr = httptest.NewRecorder()
+	handler.HandleReady(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+
+	var response map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if response["status"] != "degraded" || response["reason"] != "notion token rejected" {
+		t.Errorf("unexpected response %v", response)
+	}
+}
+
 // TestHandleWebhook_URLVerification verifies handling of URL verification requests.
 func TestHandleWebhook_URLVerification(t *testing.T) {
 	t.Parallel()
@@ -348,6 +376,154 @@ func TestHandleWebhook_PageCreated(t *testing.T) {
 	}
 }
 
+// TestProcessEvent_PagePropertiesUpdated verifies that property changes queue a property-only refresh,
+// and content changes a full sync.
+func TestProcessEvent_PagePropertiesUpdated(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	for eventType, wantType := range map[string]string{
+		eventTypePagePropertiesUpdated: queue.TypeProperties,
+		eventTypePageContentUpdated:    queue.TypeUpdate,
+	} {
+		handler := createTestHandler(t)
+		handler.processEvent(ctx, NewSimulatedEvent(eventType, "page-id"))
+
+		files, err := handler.queueManager.ListEntries(ctx)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("%s: ListEntries() = %v, %v, want 1 entry", eventType, files, err)
+		}
+		entry, err := handler.queueManager.ReadEntry(ctx, files[0])
+		if err != nil {
+			t.Fatalf("%s: ReadEntry() error = %v", eventType, err)
+		}
+		if entry.Type != wantType {
+			t.Errorf("%s: queue type = %q, want %q", eventType, entry.Type, wantType)
+		}
+	}
+}
+
+// TestProcessEvent_ContentUpdatedBlocks verifies that content changes queue the changed blocks.
+func TestProcessEvent_ContentUpdatedBlocks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	handler := createTestHandler(t)
+	event := NewSimulatedEvent(eventTypePageContentUpdated, "page-id")
+	event.Data.UpdatedBlocks = []UpdatedBlock{
+		{ID: "aaaa-bbbb", Type: "block"}, {ID: "cccc-dddd", Type: "block"}, {ID: "aaaabbbb", Type: "block"},
+	}
+	handler.processEvent(ctx, event)
+
+	files, err := handler.queueManager.ListEntries(ctx)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListEntries() = %v, %v, want 1 entry", files, err)
+	}
+	entry, err := handler.queueManager.ReadEntry(ctx, files[0])
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	if len(entry.Pages) != 1 || !slices.Equal(entry.Pages[0].UpdatedBlocks, []string{"aaaabbbb", "ccccdddd"}) {
+		t.Errorf("queued pages = %+v, want the normalized updated blocks", entry.Pages)
+	}
+}
+
+func TestProcessEvent_SchemaUpdated(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	handler := createTestHandler(t)
+	event := NewSimulatedEvent(eventTypeDataSourceSchemaUpdated, "data-source-id")
+	event.Data.Parent = &Parent{ID: "1234-5678", Type: "database"}
+	handler.processEvent(ctx, event)
+
+	files, err := handler.queueManager.ListEntries(ctx)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ListEntries() = %v, %v, want 1 entry", files, err)
+	}
+	entry, err := handler.queueManager.ReadEntry(ctx, files[0])
+	if err != nil {
+		t.Fatalf("ReadEntry() error = %v", err)
+	}
+	if len(entry.Pages) != 1 || entry.Pages[0].ID != "12345678" {
+		t.Errorf("queued pages = %+v, want the parent database", entry.Pages)
+	}
+}
+
+func TestProcessEvent_PageDeleted(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	handler := createTestHandler(t)
+	tx, err := handler.store.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	files := map[string]string{
+		".notion-sync/ids/page-parent.json": `{"id":"parent","type":"page","folder":"tech",` +
+			`"file_path":"tech/parent.md","children":["child","other"]}`,
+		".notion-sync/ids/page-child.json": `{"id":"child","type":"page","folder":"tech",` +
+			`"file_path":"tech/parent/child.md","parent_id":"parent"}`,
+		"tech/parent.md":       "# Parent\n",
+		"tech/parent/child.md": "# Child\n",
+	}
+	for path, content := range files {
+		if err := tx.Write(ctx, path, []byte(content)); err != nil {
+			t.Fatalf("Write(%s) error = %v", path, err)
+		}
+	}
+
+	handler.processEvent(ctx, NewSimulatedEvent("page.deleted", "child"))
+
+	for _, path := range []string{"tech/parent/child.md", ".notion-sync/ids/page-child.json"} {
+		if exists, _ := handler.store.Exists(ctx, path); exists {
+			t.Errorf("%s should be deleted", path)
+		}
+	}
+	data, err := handler.store.Read(ctx, ".notion-sync/ids/page-parent.json")
+	if err != nil {
+		t.Fatalf("Read(parent registry) error = %v", err)
+	}
+	var parent struct {
+		Children []string `json:"children"`
+	}
+	if err := json.Unmarshal(data, &parent); err != nil || !slices.Equal(parent.Children, []string{"other"}) {
+		t.Errorf("parent children = %v, %v; want [other]", parent.Children, err)
+	}
+
+	// Pages that are not mirrored are ignored
+	handler.processEvent(ctx, NewSimulatedEvent("database.deleted", "unknown"))
+	if exists, _ := handler.store.Exists(ctx, "tech/parent.md"); !exists {
+		t.Error("tech/parent.md should be kept")
+	}
+}
+
+func TestProcessEvent_CommentsAndUnknownTypes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	handler := createTestHandler(t)
+	handler.processEvent(ctx, NewSimulatedEvent("comment.created", "comment-id"))
+	handler.processEvent(ctx, NewSimulatedEvent("comment.deleted", "comment-id"))
+	for range 3 {
+		handler.processEvent(ctx, NewSimulatedEvent("view.created", "view-id"))
+	}
+
+	if files, _ := handler.queueManager.ListEntries(ctx); len(files) != 0 {
+		t.Errorf("queued %d entries, want none", len(files))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	var metrics map[string]int64
+	if err := json.Unmarshal(rr.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("failed to decode metrics: %v", err)
+	}
+	if metrics["events_ignored"] != 2 || metrics["events_unknown"] != 3 || metrics["events_unknown:view.created"] != 3 {
+		t.Errorf("metrics = %v, want 2 ignored and 3 unknown events", metrics)
+	}
+}
+
 // computeSignature computes the HMAC-SHA256 signature for webhook verification.
 //
 //nolint:unparam // test helper with consistent test data
@@ -361,27 +537,7 @@ func computeSignature(timestamp string, body []byte, secret string) string {
 // createTestHandlerWithoutSecret creates a Handler without a secret configured.
 func createTestHandlerWithoutSecret(t *testing.T) *Handler {
 	t.Helper()
-	tmpDir, err := os.MkdirTemp("", "webhook_test")
-	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
-	}
-	t.Cleanup(func() {
-		if rmErr := os.RemoveAll(tmpDir); rmErr != nil {
-			t.Logf("failed to remove temp dir: %v", rmErr)
-		}
-	})
-
-	st, err := store.NewLocalStore(tmpDir)
-	if err != nil {
-		t.Fatalf("failed to create store: %v", err)
-	}
-
-	// Create necessary directories
-	queueDir := filepath.Join(tmpDir, ".notion-sync", "queue")
-	if err := os.MkdirAll(queueDir, 0750); err != nil {
-		t.Fatalf("failed to create queue dir: %v", err)
-	}
-
+	st := store.NewMemStore()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	qm := queue.NewManager(st, logger)
 
@@ -392,27 +548,7 @@ func createTestHandlerWithoutSecret(t *testing.T) *Handler {
 // createTestHandler creates a Handler with a test store.
 func createTestHandler(t *testing.T) *Handler {
 	t.Helper()
-	tmpDir, err := os.MkdirTemp("", "webhook_test")
-	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
-	}
-	t.Cleanup(func() {
-		if rmErr := os.RemoveAll(tmpDir); rmErr != nil {
-			t.Logf("failed to remove temp dir: %v", rmErr)
-		}
-	})
-
-	st, err := store.NewLocalStore(tmpDir)
-	if err != nil {
-		t.Fatalf("failed to create store: %v", err)
-	}
-
-	// Create necessary directories
-	queueDir := filepath.Join(tmpDir, ".notion-sync", "queue")
-	if err := os.MkdirAll(queueDir, 0750); err != nil {
-		t.Fatalf("failed to create queue dir: %v", err)
-	}
-
+	st := store.NewMemStore()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	qm := queue.NewManager(st, logger)
 
@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	stdsync "sync"
+	"sync/atomic"
+
+	"github.com/fclairamb/ntnsync/internal/notion"
+	"github.com/fclairamb/ntnsync/internal/store"
+)
+
+// botIDResolver returns the user ID of our own integration.
+type botIDResolver func(ctx context.Context) (string, error)
+
+// loopGuard suppresses events triggered only by our own integration, so that content we write to Notion
+// does not come back as webhook events and loop.
+type loopGuard struct {
+	resolve botIDResolver
+	mu      stdsync.Mutex
+	botID   string // Cached once resolved
+}
+
+// newLoopGuard creates a loop guard resolving our integration's bot ID with the Notion API.
+func newLoopGuard(client *notion.Client) *loopGuard {
+	return &loopGuard{
+		resolve: func(ctx context.Context) (string, error) {
+			bot, err := client.GetMe(ctx)
+			if err != nil {
+				return "", err
+			}
+			return bot.ID, nil
+		},
+	}
+}
+
+// getBotID returns our integration's bot ID, resolving it on first use.
+// Failures are not cached, so that the resolution is retried with the next event.
+func (g *loopGuard) getBotID(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.botID == "" {
+		botID, err := g.resolve(ctx)
+		if err != nil {
+			return "", err
+		}
+		g.botID = notion.NormalizeID(botID)
+	}
+	return g.botID, nil
+}
+
+// isOwnEvent returns true if all the authors of the event are our own integration.
+// Events without authors are never considered our own.
+func (g *loopGuard) isOwnEvent(ctx context.Context, event *Event) (bool, error) {
+	if len(event.Authors) == 0 {
+		return false, nil
+	}
+
+	botID, err := g.getBotID(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, author := range event.Authors {
+		if notion.NormalizeID(author.ID) != botID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// eventMetrics counts the webhook events received, suppressed, ignored and of unknown types.
+type eventMetrics struct {
+	received   atomic.Int64
+	suppressed atomic.Int64
+	ignored    atomic.Int64 // Events of known types that don't affect the mirror (comments)
+	rejected   atomic.Int64 // Requests rejected by the request guard
+
+	mu      stdsync.Mutex
+	unknown map[string]int64 // Events of unknown types, by type
+}
+
+// addUnknown counts an event of an unknown type and returns the number of events of this type.
+func (m *eventMetrics) addUnknown(eventType string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.unknown == nil {
+		m.unknown = make(map[string]int64)
+	}
+	m.unknown[eventType]++
+	return m.unknown[eventType]
+}
+
+// EnableLoopPrevention makes the handler ignore events triggered only by our own integration.
+// The integration's bot ID is fetched with the client on the first event with authors, and cached.
+func (h *Handler) EnableLoopPrevention(client *notion.Client) {
+	h.loopGuard = newLoopGuard(client)
+}
+
+// suppressOwnEvent returns true if the event was triggered by our own integration and must be ignored.
+func (h *Handler) suppressOwnEvent(ctx context.Context, event *Event) bool {
+	if h.loopGuard == nil {
+		return false
+	}
+
+	own, err := h.loopGuard.isOwnEvent(ctx, event)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to resolve own integration, processing event", "error", err)
+		return false
+	}
+	if !own {
+		return false
+	}
+
+	h.metrics.suppressed.Add(1)
+	h.logger.InfoContext(ctx, "ignoring event triggered by our own integration",
+		"event_type", event.Type,
+		"entity_id", event.GetEntityID(),
+		"suppressed_events", h.metrics.suppressed.Load())
+	return true
+}
+
+// HandleMetrics handles the /api/metrics endpoint.
+func (h *Handler) HandleMetrics(writer http.ResponseWriter, req *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(h.metricsSnapshot()); err != nil {
+		h.logger.ErrorContext(req.Context(), "failed to encode metrics response", "error", err)
+	}
+}
+
+// metricsSnapshot returns the event counters and the git metrics of the store by name.
+func (h *Handler) metricsSnapshot() map[string]int64 {
+	response := map[string]int64{
+		"events_received":   h.metrics.received.Load(),
+		"events_suppressed": h.metrics.suppressed.Load(),
+		"events_ignored":    h.metrics.ignored.Load(),
+		"requests_rejected": h.metrics.rejected.Load(),
+	}
+
+	// Unknown events are counted in total and by type ("events_unknown:<type>")
+	var unknown int64
+	h.metrics.mu.Lock()
+	for eventType, count := range h.metrics.unknown {
+		response["events_unknown:"+eventType] = count
+		unknown += count
+	}
+	h.metrics.mu.Unlock()
+	response["events_unknown"] = unknown
+
+	h.addGitMetrics(response)
+	return response
+}
+
+// addGitMetrics adds the git operations and the repository size of the store to the metrics, if it is backed by
+// git repositories.
+func (h *Handler) addGitMetrics(response map[string]int64) {
+	reader, ok := h.store.(store.GitMetricsReader)
+	if !ok {
+		return
+	}
+	git, err := reader.GitMetrics()
+	if err != nil {
+		h.logger.Warn("failed to read git metrics", "error", err)
+		return
+	}
+
+	for name, op := range map[string]store.GitOpStats{"pull": git.Pull, "push": git.Push, "commit": git.Commit} {
+		response["git_"+name+"s"] = int64(op.Count)
+		response["git_"+name+"_ms"] = op.Duration.Milliseconds()
+		response["git_"+name+"_failures"] = int64(op.Failures)
+	}
+	response["git_repo_size"] = git.RepoSize
+	response["git_pack_size"] = git.PackSize
+	response["git_packs"] = int64(git.Packs)
+	response["git_loose_objects"] = int64(git.LooseObjects)
+}
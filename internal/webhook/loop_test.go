@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+const testBotID = "11111111-2222-3333-4444-555555555555"
+
+var errResolveFailed = errors.New("resolve failed")
+
+// newTestLoopGuard creates a loop guard with a fixed bot ID, counting the resolutions.
+func newTestLoopGuard(resolutions *atomic.Int32) *loopGuard {
+	return &loopGuard{resolve: func(_ context.Context) (string, error) {
+		resolutions.Add(1)
+		return testBotID, nil
+	}}
+}
+
+// TestLoopGuard_IsOwnEvent verifies which events are considered triggered by our own integration.
+func TestLoopGuard_IsOwnEvent(t *testing.T) {
+	t.Parallel()
+
+	var resolutions atomic.Int32
+	guard := newTestLoopGuard(&resolutions)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		authors []Author
+		want    bool
+	}{
+		{name: "no authors", want: false},
+		{name: "own bot", authors: []Author{{ID: "11111111222233334444555555555555", Type: "bot"}}, want: true},
+		{name: "person", authors: []Author{{ID: "person-id", Type: "person"}}, want: false},
+		{name: "own bot and person", authors: []Author{{ID: testBotID, Type: "bot"}, {ID: "person-id"}}, want: false},
+	}
+
+	for _, tc := range tests {
+		got, err := guard.isOwnEvent(ctx, &Event{Type: eventTypePageContentUpdated, Authors: tc.authors})
+		if err != nil || got != tc.want {
+			t.Errorf("%s: isOwnEvent() = %v, %v, want %v", tc.name, got, err, tc.want)
+		}
+	}
+
+	if got := resolutions.Load(); got != 1 {
+		t.Errorf("bot ID resolved %d times, want once", got)
+	}
+}
+
+// TestLoopGuard_ResolutionFailureIsRetried verifies that a failed bot ID resolution is not cached.
+func TestLoopGuard_ResolutionFailureIsRetried(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	guard := &loopGuard{resolve: func(_ context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			return "", errResolveFailed
+		}
+		return testBotID, nil
+	}}
+	event := &Event{Authors: []Author{{ID: testBotID, Type: "bot"}}}
+
+	if _, err := guard.isOwnEvent(context.Background(), event); !errors.Is(err, errResolveFailed) {
+		t.Fatalf("isOwnEvent() error = %v, want %v", err, errResolveFailed)
+	}
+	if own, err := guard.isOwnEvent(context.Background(), event); err != nil || !own {
+		t.Errorf("isOwnEvent() after a failure = %v, %v, want true", own, err)
+	}
+}
+
+// TestHandler_SuppressesOwnEvents verifies that our own events are not queued and are counted in the metrics.
+func TestHandler_SuppressesOwnEvents(t *testing.T) {
+	t.Parallel()
+
+	var resolutions atomic.Int32
+	handler := createTestHandlerWithoutSecret(t)
+	handler.loopGuard = newTestLoopGuard(&resolutions)
+	ctx := context.Background()
+
+	handler.processEvent(ctx, &Event{
+		Type:    eventTypePageContentUpdated,
+		Entity:  &Entity{ID: "2c536f5e48f44234ad8d73a1a148e95d", Type: "page"},
+		Authors: []Author{{ID: testBotID, Type: "bot"}},
+	})
+
+	files, err := handler.queueManager.ListEntries(ctx)
+	if err != nil {
+		t.Fatalf("failed to list queue entries: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("own event should not be queued, got %v", files)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleMetrics(rr, httptest.NewRequest(http.MethodGet, "/api/metrics", nil))
+	var metrics map[string]int64
+	if err := json.Unmarshal(rr.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("failed to decode metrics: %v", err)
+	}
+	if metrics["events_suppressed"] != 1 {
+		t.Errorf("metrics = %v, want one suppressed event", metrics)
+	}
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fclairamb/ntnsync/client"
+	"github.com/fclairamb/ntnsync/internal/version"
+)
+
+// OpenAPIPath is the path of the endpoint serving the OpenAPI document of the HTTP API.
+const OpenAPIPath = client.OpenAPIPath
+
+// defaultWebhookPath is the webhook path of the embedded OpenAPI document.
+const defaultWebhookPath = "/webhooks/notion"
+
+// openAPISpec is the OpenAPI document of the HTTP API, for the default webhook path.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPISpec returns the OpenAPI document of the HTTP API served with cfg: it has the configured webhook path,
+// the simulate, debug and admin endpoints only if they are enabled, and the version of the server.
+func OpenAPISpec(cfg *ServerConfig) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		return nil, fmt.Errorf("decode openapi document: %w", err)
+	}
+
+	if info, ok := doc["info"].(map[string]any); ok {
+		info["version"] = version.Version
+	}
+	if paths, ok := doc["paths"].(map[string]any); ok {
+		if cfg.Path != defaultWebhookPath {
+			paths[cfg.Path] = paths[defaultWebhookPath]
+			delete(paths, defaultWebhookPath)
+		}
+		if cfg.SimulateToken == "" {
+			delete(paths, SimulatePath)
+		}
+		if cfg.DebugToken == "" {
+			delete(paths, DebugStatePath)
+		}
+		if !cfg.AdminUI {
+			for _, path := range []string{AdminStatusPath, AdminSyncPath, AdminPullPath, AdminCleanupPath} {
+				delete(paths, path)
+			}
+		}
+	}
+
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode openapi document: %w", err)
+	}
+	return spec, nil
+}
+
+// HandleOpenAPI returns a handler of the /api/openapi.json endpoint, serving the OpenAPI document of cfg.
+func (h *Handler) HandleOpenAPI(cfg *ServerConfig) http.HandlerFunc {
+	spec, specErr := OpenAPISpec(cfg)
+	return func(writer http.ResponseWriter, req *http.Request) {
+		if specErr != nil {
+			h.logger.ErrorContext(req.Context(), "failed to build openapi document", "error", specErr)
+			http.Error(writer, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		if _, err := writer.Write(spec); err != nil {
+			h.logger.ErrorContext(req.Context(), "failed to write openapi document", "error", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHandleOpenAPI verifies that the OpenAPI document follows the server configuration.
+func TestHandleOpenAPI(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandler(t)
+
+	tests := []struct {
+		name      string
+		cfg       *ServerConfig
+		wantPaths []string
+		noPaths   []string
+	}{
+		{
+			name:      "defaults",
+			cfg:       &ServerConfig{Path: defaultWebhookPath},
+			wantPaths: []string{"/health", "/readyz", "/api/version", "/api/metrics", OpenAPIPath, defaultWebhookPath},
+			noPaths:   []string{SimulatePath, DebugStatePath, AdminStatusPath, AdminSyncPath},
+		},
+		{
+			name:      "custom webhook path, simulation and debug",
+			cfg:       &ServerConfig{Path: "/hooks/ntn", SimulateToken: "token", DebugToken: "debug"},
+			wantPaths: []string{"/hooks/ntn", SimulatePath, DebugStatePath},
+			noPaths:   []string{defaultWebhookPath},
+		},
+		{
+			name:      "admin UI",
+			cfg:       &ServerConfig{Path: defaultWebhookPath, AdminUI: true},
+			wantPaths: []string{AdminStatusPath, AdminSyncPath, AdminPullPath, AdminCleanupPath},
+			noPaths:   []string{SimulatePath, DebugStatePath},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rr := httptest.NewRecorder()
+			handler.HandleOpenAPI(tt.cfg)(rr, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rr.Code)
+			}
+
+			var doc struct {
+				OpenAPI string         `json:"openapi"`
+				Paths   map[string]any `json:"paths"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("failed to parse document: %v", err)
+			}
+			if doc.OpenAPI == "" {
+				t.Error("missing openapi version")
+			}
+			for _, path := range tt.wantPaths {
+				if doc.Paths[path] == nil {
+					t.Errorf("missing path %s", path)
+				}
+			}
+			for _, path := range tt.noPaths {
+				if _, found := doc.Paths[path]; found {
+					t.Errorf("unexpected path %s", path)
+				}
+			}
+		})
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/notion"
 	"github.com/fclairamb/ntnsync/internal/queue"
 	"github.com/fclairamb/ntnsync/internal/store"
+	"github.com/fclairamb/ntnsync/internal/sync"
 	"github.com/fclairamb/ntnsync/internal/version"
 )
 
@@ -23,11 +27,13 @@ const (
 type Server struct {
 	handler        *Handler
 	httpServer     *http.Server
+	mux            *http.ServeMux
 	config         *ServerConfig
 	logger         *slog.Logger
 	syncWorker     *SyncWorker
 	syncWorkerDone chan struct{}
 	cancelFunc     context.CancelFunc
+	grpcServer     *GRPCServer
 }
 
 // NewServer creates a new webhook server.
@@ -44,14 +50,26 @@ func NewServer(
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", handler.HandleHealth)
+	mux.HandleFunc("/readyz", handler.HandleReady)
 	mux.HandleFunc("/api/version", handler.HandleVersion)
-	mux.HandleFunc(cfg.Path, handler.HandleWebhook)
+	mux.HandleFunc("/api/metrics", handler.HandleMetrics)
+	mux.HandleFunc(OpenAPIPath, handler.HandleOpenAPI(cfg))
+	mux.HandleFunc(cfg.Path, newRequestGuard(cfg, &handler.metrics.rejected, logger).wrap(handler.HandleWebhook))
+	if cfg.SimulateToken != "" {
+		handler.EnableSimulation(cfg.SimulateToken)
+		mux.HandleFunc(SimulatePath, handler.HandleSimulate)
+	}
+	if cfg.DebugToken != "" {
+		handler.EnableDebug(cfg.DebugToken)
+		mux.HandleFunc(DebugStatePath, handler.HandleDebugState)
+	}
 
 	// Wrap with logging middleware
 	loggedHandler := loggingMiddleware(mux, logger)
 
 	return &Server{
 		handler:    handler,
+		mux:        mux,
 		config:     cfg,
 		logger:     logger,
 		syncWorker: syncWorker,
@@ -63,6 +81,23 @@ func NewServer(
 	}
 }
 
+// EnableGRPC enables the gRPC server on the configured gRPC port.
+// The crawler is used to read the status and pages, and must not be shared with the sync worker.
+func (s *Server) EnableGRPC(crawler *sync.Crawler) {
+	s.grpcServer = NewGRPCServer(s.handler, crawler, s.config.GRPCToken, s.logger)
+}
+
+// EnableAdminUI serves the admin UI on /admin/ with its JSON API, behind basic auth when a password is configured.
+// The crawler is used to read the status, and must not be shared with the sync worker.
+func (s *Server) EnableAdminUI(crawler *sync.Crawler) {
+	newAdminUI(s.handler, crawler, s.config).register(s.mux)
+}
+
+// EnableLoopPrevention makes the server ignore events triggered only by our own integration.
+func (s *Server) EnableLoopPrevention(client *notion.Client) {
+	s.handler.EnableLoopPrevention(client)
+}
+
 // Start starts the HTTP server. This method blocks until the server is stopped.
 func (s *Server) Start(ctx context.Context) error {
 	s.logger.InfoContext(ctx, "starting webhook server",
@@ -70,10 +105,20 @@ func (s *Server) Start(ctx context.Context) error {
 		"path", s.config.Path,
 		"auto_sync", s.config.AutoSync,
 		"sync_delay", s.config.SyncDelay,
+		"sync_max_run_time", s.config.SyncMaxRunTime,
+		"allowed_cidrs", len(s.config.AllowedCIDRs),
+		"max_body_size", s.config.MaxBodySize,
+		"rate_limit", s.config.RateLimit,
 		"version", version.Version,
 		"commit", version.Commit,
 		"build_time", version.GitTime)
 
+	// Start servers in goroutines so we can handle context cancellation
+	errCh := make(chan error, 2) //nolint:mnd // HTTP and gRPC servers
+	if err := s.startGRPC(ctx, errCh); err != nil {
+		return err
+	}
+
 	// Create a cancellable context for the sync worker
 	workerCtx, cancel := context.WithCancel(ctx)
 	s.cancelFunc = cancel
@@ -91,8 +136,6 @@ func (s *Server) Start(ctx context.Context) error {
 		s.syncWorker.Notify()
 	}
 
-	// Start server in a goroutine so we can handle context cancellation
-	errCh := make(chan error, 1)
 	go func() {
 		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- err
@@ -127,9 +170,36 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		s.logger.InfoContext(ctx, "sync worker finished")
 	}
 
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+
 	return s.httpServer.Shutdown(ctx)
 }
 
+// startGRPC starts the gRPC server in the background, if enabled. Serving errors are sent to errCh.
+func (s *Server) startGRPC(ctx context.Context, errCh chan<- error) error {
+	if s.grpcServer == nil || s.config.GRPCPort <= 0 {
+		return nil
+	}
+
+	var listenConfig net.ListenConfig
+	address := net.JoinHostPort(s.config.GRPCAddress, strconv.Itoa(s.config.GRPCPort))
+	listener, err := listenConfig.Listen(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("listen grpc: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "starting grpc server", "address", address)
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return nil
+}
+
 // Addr returns the server's address. Useful for testing.
 func (s *Server) Addr() string {
 	return s.httpServer.Addr
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fclairamb/ntnsync/client"
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/notion"
+)
+
+// SimulatePath is the path of the endpoint feeding synthetic events through the webhook handler.
+const SimulatePath = client.SimulatePath
+
+// simulatedEventTypes are the event types that can be simulated.
+var simulatedEventTypes = []string{
+	"page.created", "page.updated", eventTypePageContentUpdated, eventTypePagePropertiesUpdated,
+	"page.deleted", "page.undeleted",
+	"database.created", "database.updated", "database.content_updated", "database.properties_updated",
+	"database.deleted", "database.undeleted",
+	eventTypeDataSourceSchemaUpdated, eventTypeDatabaseSchemaUpdated,
+}
+
+// SimulateRequest is the payload of the simulate endpoint.
+type SimulateRequest = client.SimulateRequest
+
+// SimulateResponse is the response of the simulate endpoint.
+type SimulateResponse = client.SimulateResponse
+
+// NewSimulatedEvent creates a synthetic event of the given type about the given page or database.
+// It has no authors, so that it is never ignored as one of our own integration's events.
+func NewSimulatedEvent(eventType, entityID string) *Event {
+	entityType, _, _ := strings.Cut(eventType, ".")
+	now := time.Now().UTC()
+	return &Event{
+		ID:        "simulated-" + strconv.FormatInt(now.UnixNano(), 10),
+		Type:      eventType,
+		Timestamp: now.Format(time.RFC3339),
+		Entity:    &Entity{ID: entityID, Type: entityType},
+	}
+}
+
+// EnableSimulation enables the simulate endpoint, protected by the given bearer token.
+func (h *Handler) EnableSimulation(token string) {
+	h.simulateToken = token
+}
+
+// HandleSimulate handles the simulate endpoint: it feeds a synthetic event through the webhook handler,
+// so that deployments can be verified end-to-end without editing Notion pages. The event is processed
+// before responding.
+func (h *Handler) HandleSimulate(writer http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	if req.Method != http.MethodPost {
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.authorizeSimulation(req) {
+		h.logger.WarnContext(ctx, "unauthorized simulate request")
+		http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var simReq SimulateRequest
+	if err := json.NewDecoder(req.Body).Decode(&simReq); err != nil {
+		http.Error(writer, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(simulatedEventTypes, simReq.Event) || simReq.PageID == "" {
+		http.Error(writer, "Unsupported event type or missing page ID", http.StatusBadRequest)
+		return
+	}
+
+	event := NewSimulatedEvent(simReq.Event, notion.NormalizeID(simReq.PageID))
+	rawJSON, err := json.Marshal(event)
+	if err != nil {
+		http.Error(writer, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	signed := simReq.Sign && h.secret != ""
+	if signed && !h.verifySimulatedSignature(ctx, rawJSON) {
+		h.logger.WarnContext(ctx, "simulated event signature verification failed")
+		http.Error(writer, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	accepted, err := h.acceptEvent(ctx, rawJSON)
+	if err != nil {
+		http.Error(writer, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	h.processEvent(context.WithoutCancel(ctx), accepted)
+
+	writer.Header().Set("Content-Type", "application/json")
+	response := SimulateResponse{
+		EventID:    accepted.ID,
+		EventType:  accepted.Type,
+		EntityID:   accepted.GetEntityID(),
+		EntityType: accepted.GetEntityType(),
+		Signed:     signed,
+	}
+	if err := json.NewEncoder(writer).Encode(response); err != nil {
+		h.logger.ErrorContext(ctx, "failed to encode simulate response", "error", err)
+	}
+}
+
+// authorizeSimulation returns true if the request carries the simulate bearer token.
+func (h *Handler) authorizeSimulation(req *http.Request) bool {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return h.simulateToken != "" && found &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.simulateToken)) == 1
+}
+
+// verifySimulatedSignature signs a simulated event like Notion does, and verifies it as a delivered event.
+func (h *Handler) verifySimulatedSignature(ctx context.Context, rawJSON []byte) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, SimulatePath, bytes.NewReader(rawJSON))
+	if err != nil {
+		return false
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Notion-Webhook-Signature", signPayload(h.secret, timestamp, rawJSON))
+	req.Header.Set("Notion-Webhook-Timestamp", timestamp)
+	return h.verifySignature(req)
+}
+
+// signPayload computes the HMAC-SHA256 signature of a webhook payload.
+func signPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SimulateEvent asks a running webhook server to simulate an event, and returns its response.
+func SimulateEvent(
+	ctx context.Context, baseURL, token string, simReq *SimulateRequest,
+) (*SimulateResponse, error) {
+	simResp, err := client.New(baseURL, client.WithSimulateToken(token)).Simulate(ctx, simReq)
+	if errors.Is(err, client.ErrUnexpectedStatus) {
+		return nil, fmt.Errorf("%w: %w", apperrors.ErrSimulationFailed, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("simulate event: %w", err)
+	}
+	return simResp, nil
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+)
+
+const testSimulateToken = "test-simulate-token" //nolint:gosec // test constant
+
+// newSimulateRequest creates a request to the simulate endpoint.
+func newSimulateRequest(token, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, SimulatePath, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// TestHandleSimulate_Unauthorized verifies that the endpoint requires its bearer token.
+func TestHandleSimulate_Unauthorized(t *testing.T) {
+	t.Parallel()
+	body := `{"event":"page.updated","page_id":"abc"}`
+
+	disabled := createTestHandlerWithoutSecret(t)
+	rr := httptest.NewRecorder()
+	disabled.HandleSimulate(rr, newSimulateRequest("", body))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("disabled endpoint: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	handler := createTestHandlerWithoutSecret(t)
+	handler.EnableSimulation(testSimulateToken)
+	rr = httptest.NewRecorder()
+	handler.HandleSimulate(rr, newSimulateRequest("wrong-token", body))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+// TestHandleSimulate_InvalidEvent verifies that unknown event types and missing page IDs are rejected.
+func TestHandleSimulate_InvalidEvent(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandlerWithoutSecret(t)
+	handler.EnableSimulation(testSimulateToken)
+
+	for _, body := range []string{
+		`{"event":"page.exploded","page_id":"abc"}`,
+		`{"event":"page.updated"}`,
+		`not json`,
+	} {
+		rr := httptest.NewRecorder()
+		handler.HandleSimulate(rr, newSimulateRequest(testSimulateToken, body))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", body, rr.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestHandleSimulate_QueuesPage verifies that a simulated event is signed, verified and processed
+// like a delivered one.
+func TestHandleSimulate_QueuesPage(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandler(t)
+	handler.EnableSimulation(testSimulateToken)
+
+	rr := httptest.NewRecorder()
+	handler.HandleSimulate(rr, newSimulateRequest(testSimulateToken,
+		`{"event":"page.updated","page_id":"388aa28b-3ffb-80b6-9e5b-c6a0eeaebf64","sign":true}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"signed":true`) {
+		t.Errorf("expected signed event, got %s", rr.Body.String())
+	}
+	if received := handler.metrics.received.Load(); received != 1 {
+		t.Errorf("events received = %d, want 1", received)
+	}
+
+	files, err := handler.queueManager.ListEntries(context.Background())
+	if err != nil {
+		t.Fatalf("list entries: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 queue entry, got %d", len(files))
+	}
+	entry, err := handler.queueManager.ReadEntry(context.Background(), files[0])
+	if err != nil {
+		t.Fatalf("read entry: %v", err)
+	}
+	if len(entry.Pages) != 1 || entry.Pages[0].ID != "388aa28b3ffb80b69e5bc6a0eeaebf64" {
+		t.Errorf("unexpected queued pages: %+v", entry.Pages)
+	}
+}
+
+// TestSignPayload verifies that simulated signatures match the ones Notion computes.
+func TestSignPayload(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"type":"page.updated"}`)
+	if got, want := signPayload(testSecret, "1700000000", body),
+		computeSignature("1700000000", body, testSecret); got != want {
+		t.Errorf("signPayload = %q, want %q", got, want)
+	}
+}
+
+// TestSimulateEvent verifies the client of the simulate endpoint.
+func TestSimulateEvent(t *testing.T) {
+	t.Parallel()
+	handler := createTestHandlerWithoutSecret(t)
+	handler.EnableSimulation(testSimulateToken)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleSimulate))
+	defer server.Close()
+
+	result, err := SimulateEvent(context.Background(), server.URL+"/", testSimulateToken,
+		&SimulateRequest{Event: "database.updated", PageID: "db1"})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if result.EventType != "database.updated" || result.EntityType != "database" || result.EntityID != "db1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Signed {
+		t.Error("expected unsigned event without a webhook secret")
+	}
+
+	_, err = SimulateEvent(context.Background(), server.URL, "wrong-token",
+		&SimulateRequest{Event: "page.updated", PageID: "abc"})
+	if !errors.Is(err, apperrors.ErrSimulationFailed) {
+		t.Errorf("expected ErrSimulationFailed, got %v", err)
+	}
+}
@@ -2,23 +2,68 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	stdsync "sync"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/shutdown"
 	"github.com/fclairamb/ntnsync/internal/store"
 	"github.com/fclairamb/ntnsync/internal/sync"
 )
 
+// Delays between retries of pushes that failed, doubled after each failure.
+const (
+	defaultPushRetryDelay = time.Minute
+	maxPushRetryDelay     = 30 * time.Minute
+)
+
+// remoteLockRetryDelay is the delay before retrying a run that couldn't lock the remote branch (NTN_GIT_LOCK).
+const remoteLockRetryDelay = time.Minute
+
+// queueProcessor processes the sync queue and commits the result (implemented by sync.Crawler).
+type queueProcessor interface {
+	ProcessQueue(
+		ctx context.Context, folderFilter string, maxPages, maxFiles, maxQueueFiles int, maxTime time.Duration,
+	) error
+	SetProgressHooks(hooks sync.ProgressHooks)
+	CommitChanges(ctx context.Context, message string) error
+	StartRun(ctx context.Context, folder string) bool
+	FinishRun(ctx context.Context)
+	SetRunPhase(ctx context.Context, phase string)
+	CommitChunkReached() bool
+	NotifyPush(ctx context.Context) error
+	CheckAuth(ctx context.Context) error
+	SetTransaction(tx store.Transaction)
+	Pull(ctx context.Context, opts sync.PullOptions) (*sync.PullResult, error)
+	Cleanup(ctx context.Context, dryRun bool) (*sync.CleanupResult, error)
+}
+
 // SyncWorker processes queued items in the background.
+// Notifications received during the sync delay are batched: a run only processes the folders they
+// concern, and is capped by the max run time so that continuous editing cannot keep the worker busy.
 type SyncWorker struct {
-	crawler      *sync.Crawler
-	store        store.Store
-	remoteConfig *store.RemoteConfig
-	logger       *slog.Logger
-	syncDelay    time.Duration
-	notify       chan struct{}
+	crawler        queueProcessor
+	store          store.Store
+	remoteConfig   *store.RemoteConfig
+	logger         *slog.Logger
+	syncDelay      time.Duration
+	maxRunTime     time.Duration
+	pushRetryDelay time.Duration
+	authProbeDelay time.Duration
+	quietHours     *sync.QuietHours
+	notify         chan struct{}
+	runMu          stdsync.Mutex // Serializes the runs and the operations requested from the admin UI
+
+	mu             stdsync.Mutex   // Protects the pending batch and the degraded state
+	pendingFolders map[string]bool // Folders notified since the last run
+	pendingAll     bool            // Whether all folders must be processed
+	degraded       string          // Why the worker cannot sync (empty = healthy)
 }
 
 // SyncWorkerOption configures the SyncWorker.
@@ -32,6 +77,28 @@ func WithSyncDelay(d time.Duration) SyncWorkerOption {
 	}
 }
 
+// WithMaxRunTime caps the duration of a run (0 = unlimited). A run stopped by the cap commits its progress
+// and is continued by the next run, after the sync delay.
+func WithMaxRunTime(d time.Duration) SyncWorkerOption {
+	return func(w *SyncWorker) {
+		w.maxRunTime = d
+	}
+}
+
+// WithPushRetryDelay sets the initial delay between retries of failed pushes.
+func WithPushRetryDelay(d time.Duration) SyncWorkerOption {
+	return func(w *SyncWorker) {
+		w.pushRetryDelay = d
+	}
+}
+
+// WithQuietHours defers processing during quiet hours. Events keep being queued meanwhile.
+func WithQuietHours(quiet *sync.QuietHours) SyncWorkerOption {
+	return func(w *SyncWorker) {
+		w.quietHours = quiet
+	}
+}
+
 // NewSyncWorker creates a new sync worker.
 func NewSyncWorker(
 	crawler *sync.Crawler,
@@ -41,11 +108,13 @@ func NewSyncWorker(
 	opts ...SyncWorkerOption,
 ) *SyncWorker {
 	worker := &SyncWorker{
-		crawler:      crawler,
-		store:        storeInst,
-		remoteConfig: remoteConfig,
-		logger:       logger,
-		notify:       make(chan struct{}, 1),
+		crawler:        crawler,
+		store:          storeInst,
+		remoteConfig:   remoteConfig,
+		logger:         logger,
+		pushRetryDelay: defaultPushRetryDelay,
+		authProbeDelay: DefaultAuthProbeDelay,
+		notify:         make(chan struct{}, 1),
 	}
 
 	for _, opt := range opts {
@@ -55,9 +124,51 @@ func NewSyncWorker(
 	return worker
 }
 
-// Notify signals that there is new work to process.
+// Notify signals that there is new work to process, in any folder.
 // This is non-blocking - if a notification is already pending, it's a no-op.
 func (w *SyncWorker) Notify() {
+	w.mu.Lock()
+	w.pendingAll = true
+	w.mu.Unlock()
+	w.signal()
+}
+
+// NotifyFolder signals that there is new work to process in a folder.
+// This is non-blocking - notifications are batched until the next run.
+func (w *SyncWorker) NotifyFolder(folder string) {
+	if folder == "" {
+		w.Notify()
+		return
+	}
+
+	w.mu.Lock()
+	if w.pendingFolders == nil {
+		w.pendingFolders = make(map[string]bool)
+	}
+	w.pendingFolders[folder] = true
+	w.mu.Unlock()
+	w.signal()
+}
+
+// takeBatch returns the pending batch and resets it: the notified folders, sorted, or all folders.
+func (w *SyncWorker) takeBatch() ([]string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	all := w.pendingAll
+	folders := make([]string, 0, len(w.pendingFolders))
+	for folder := range w.pendingFolders {
+		folders = append(folders, folder)
+	}
+	slices.Sort(folders)
+
+	w.pendingAll = false
+	w.pendingFolders = nil
+	return folders, all
+}
+
+// signal wakes the worker up.
+func (w *SyncWorker) signal() {
 	select {
 	case w.notify <- struct{}{}:
 		w.logger.Debug("sync worker notified")
@@ -69,7 +180,11 @@ func (w *SyncWorker) Notify() {
 // Start runs the sync worker until the context is canceled or a fatal error occurs.
 // This method blocks and should be called in a goroutine.
 func (w *SyncWorker) Start(ctx context.Context) {
-	w.logger.InfoContext(ctx, "sync worker started", "sync_delay", w.syncDelay)
+	w.logger.InfoContext(ctx, "sync worker started", "sync_delay", w.syncDelay, "quiet_hours", w.quietHours)
+
+	if spooler, ok := w.store.(store.PushSpooler); ok && w.remoteConfig.IsPushEnabled() {
+		go w.retryPendingPushes(ctx, spooler)
+	}
 
 	for {
 		select {
@@ -85,7 +200,7 @@ func (w *SyncWorker) Start(ctx context.Context) {
 	}
 }
 
-// processWithDelay waits for the sync delay (if configured) then processes the queue.
+// processWithDelay waits for the sync delay (if configured) and the end of quiet hours, then processes the queue.
 func (w *SyncWorker) processWithDelay(ctx context.Context) error {
 	if w.syncDelay > 0 {
 		w.logger.DebugContext(ctx, "waiting for sync delay", "delay", w.syncDelay)
@@ -101,36 +216,129 @@ func (w *SyncWorker) processWithDelay(ctx context.Context) error {
 		}
 	}
 
-	return w.processQueue(ctx)
+	if !w.waitQuietHours(ctx) {
+		return nil
+	}
+	if w.Degraded() != "" {
+		return nil // Notifications stay batched until the token works again
+	}
+
+	folders, all := w.takeBatch()
+	if !all && len(folders) == 0 {
+		return nil // Already processed by the previous run
+	}
+	if all {
+		folders = []string{""}
+	}
+
+	return w.processQueue(ctx, folders)
 }
 
-// processQueue processes all queued items with periodic commits.
-func (w *SyncWorker) processQueue(ctx context.Context) error {
-	w.logger.InfoContext(ctx, "sync worker processing queue")
+// waitQuietHours waits until quiet hours are over, if they are active.
+// Returns false if the context was canceled meanwhile.
+func (w *SyncWorker) waitQuietHours(ctx context.Context) bool {
+	now := time.Now()
+	if !w.quietHours.Active(now) {
+		return true
+	}
 
-	var err error
-	commitPeriod := w.remoteConfig.GetCommitPeriod()
+	end := w.quietHours.End(now)
+	w.logger.InfoContext(ctx, "quiet hours, deferring sync", "until", end)
 
-	if commitPeriod > 0 {
-		// Use periodic commit callback
-		tracker := newCommitTracker(commitPeriod)
-		err = w.crawler.ProcessQueueWithCallback(ctx, "", 0, 0, 0, 0,
-			func() error {
-				if tracker.shouldCommit() {
-					if commitErr := w.commitAndPush(ctx, "periodic sync"); commitErr != nil {
-						return commitErr
-					}
-					tracker.markCommitted()
+	timer := time.NewTimer(end.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// processQueue processes the queued items of folders ("" = all folders) with periodic commits, within the
+// max run time. Folders not processed, or not finished, when the run time is over are notified again.
+func (w *SyncWorker) processQueue(ctx context.Context, folders []string) error {
+	// On shutdown, the pages in progress are finished and committed within the grace period
+	defer shutdown.Begin(ctx)()
+
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+	// The store directory is only locked during runs, so that other commands can write to it between them
+	if err := w.beginRun(ctx); err != nil {
+		return err
+	}
+	defer w.releaseStoreLock(ctx)
+
+	w.logger.InfoContext(ctx, "sync worker processing queue", "folders", folders, "max_run_time", w.maxRunTime)
+	if locker, ok := w.store.(store.RemoteLocker); ok {
+		err := locker.AcquireRemoteLock(ctx)
+		if errors.Is(err, apperrors.ErrRemoteLocked) {
+			// Another instance is syncing the same remote branch: the folders are processed once it is done
+			w.logger.WarnContext(ctx, "remote branch is locked, deferring sync", "error", err,
+				"retry_in", remoteLockRetryDelay)
+			time.AfterFunc(remoteLockRetryDelay, func() {
+				for _, folder := range folders {
+					w.NotifyFolder(folder)
 				}
-				return nil
 			})
-	} else {
-		err = w.crawler.ProcessQueue(ctx, "", 0, 0, 0, 0)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("lock remote: %w", err)
+		}
+		defer func() {
+			if err := locker.ReleaseRemoteLock(ctx); err != nil {
+				w.logger.WarnContext(ctx, "failed to release remote lock", "error", err)
+			}
+		}()
+	}
+	if w.crawler.StartRun(ctx, strings.Join(folders, ",")) {
+		defer w.crawler.FinishRun(ctx)
 	}
 
-	if err != nil {
-		w.logger.ErrorContext(ctx, "sync worker failed to process queue", "error", err)
-		return fmt.Errorf("process queue: %w", err)
+	startTime := time.Now()
+	var tracker *commitTracker
+	var chunkReached func() bool
+	if w.remoteConfig.IsCommitEnabled() && sync.GetConfig().CommitChunking() {
+		chunkReached = w.crawler.CommitChunkReached
+	}
+	if commitPeriod := w.remoteConfig.GetCommitPeriod(); commitPeriod > 0 || chunkReached != nil {
+		tracker = newCommitTracker(commitPeriod, chunkReached)
+		w.crawler.SetProgressHooks(sync.ProgressHooks{OnQueueFileDone: w.periodicCommit(tracker)})
+		defer w.crawler.SetProgressHooks(sync.ProgressHooks{})
+	}
+
+	for i, folder := range folders {
+		if shutdown.Requested(ctx) {
+			break // The remaining folders stay queued for the next start
+		}
+		var maxTime time.Duration
+		if w.maxRunTime > 0 {
+			maxTime = w.maxRunTime - time.Since(startTime)
+			if maxTime <= 0 {
+				w.deferFolders(ctx, folders[i:])
+				break
+			}
+		}
+
+		err := w.crawler.ProcessQueue(ctx, folder, 0, 0, 0, maxTime)
+		if errors.Is(err, apperrors.ErrNotionUnauthorized) {
+			for _, pending := range folders[i:] {
+				w.NotifyFolder(pending)
+			}
+			w.enterDegraded(ctx, err)
+			break
+		}
+		if err != nil {
+			w.logger.ErrorContext(ctx, "sync worker failed to process queue", "folder", folder, "error", err)
+			return fmt.Errorf("process queue: %w", err)
+		}
+
+		if w.maxRunTime > 0 && time.Since(startTime) >= w.maxRunTime {
+			w.deferFolders(ctx, folders[i:])
+			break
+		}
 	}
 
 	// Final commit if enabled
@@ -145,24 +353,128 @@ func (w *SyncWorker) processQueue(ctx context.Context) error {
 	return nil
 }
 
+// periodicCommit returns the progress hook committing the queue files processed when the tracker requires it.
+func (w *SyncWorker) periodicCommit(tracker *commitTracker) func(ctx context.Context, queueFile string) error {
+	return func(ctx context.Context, _ string) error {
+		if !tracker.shouldCommit() {
+			return nil
+		}
+		if err := w.commitAndPush(ctx, "periodic sync"); err != nil {
+			return err
+		}
+		tracker.markCommitted()
+		return nil
+	}
+}
+
+// beginRun begins the transaction of a run, holding the lock of the store directory until releaseStoreLock.
+func (w *SyncWorker) beginRun(ctx context.Context) error {
+	tx, err := w.store.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	w.crawler.SetTransaction(tx)
+	return nil
+}
+
+// releaseStoreLock releases the hold of the transaction of a run on the lock of the store directory.
+func (w *SyncWorker) releaseStoreLock(ctx context.Context) {
+	if releaser, ok := w.store.(store.LockReleaser); ok {
+		if err := releaser.ReleaseLock(); err != nil {
+			w.logger.WarnContext(ctx, "failed to release store lock", "error", err)
+		}
+	}
+}
+
+// deferFolders notifies folders again, to continue their processing in the next run.
+func (w *SyncWorker) deferFolders(ctx context.Context, folders []string) {
+	w.logger.InfoContext(ctx, "sync run reached its max run time, deferring to the next run",
+		"max_run_time", w.maxRunTime,
+		"folders", folders)
+	for _, folder := range folders {
+		w.NotifyFolder(folder)
+	}
+}
+
 // commitAndPush commits changes and optionally pushes to remote.
 func (w *SyncWorker) commitAndPush(ctx context.Context, reason string) error {
-	message := fmt.Sprintf("[ntnsync] %s at %s", reason, time.Now().Format(time.RFC3339))
+	w.crawler.SetRunPhase(ctx, sync.RunPhaseCommit)
+	message := fmt.Sprintf("[ntnsync] %s at %s", reason, sync.GetConfig().TimeFormat.Format(time.Now()))
 	if err := w.crawler.CommitChanges(ctx, message); err != nil {
 		w.logger.WarnContext(ctx, "failed to commit changes", "error", err, "reason", reason)
 		return nil // Don't fail the sync for commit errors
 	}
 
-	// Push if enabled
+	// Push if enabled. Failed pushes are spooled by the store and retried in the background.
 	if w.remoteConfig.IsPushEnabled() {
 		if err := w.pushWithRetry(ctx); err != nil {
-			return fmt.Errorf("push to remote: %w", err)
+			if _, spooled := w.store.(store.PushSpooler); !spooled {
+				return fmt.Errorf("push to remote: %w", err)
+			}
+			w.logger.WarnContext(ctx, "push failed, will retry in the background", "error", err)
+			return nil
 		}
+		w.notifyPush(ctx)
 	}
 
 	return nil
 }
 
+// retryPendingPushes retries the pushes that failed until the context is canceled.
+// The delay between retries doubles after each failure, up to maxPushRetryDelay.
+func (w *SyncWorker) retryPendingPushes(ctx context.Context, spooler store.PushSpooler) {
+	delay := w.pushRetryDelay
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		delay = w.retryPendingPush(ctx, spooler, delay)
+		timer.Reset(delay)
+	}
+}
+
+// retryPendingPush pushes if a previous push failed, and returns the delay before the next retry.
+func (w *SyncWorker) retryPendingPush(
+	ctx context.Context, spooler store.PushSpooler, delay time.Duration,
+) time.Duration {
+	spool, err := spooler.PendingPush()
+	if err != nil {
+		w.logger.WarnContext(ctx, "failed to read pending pushes", "error", err)
+		return w.pushRetryDelay
+	}
+	if spool == nil {
+		return w.pushRetryDelay
+	}
+
+	w.logger.InfoContext(ctx, "retrying pending push",
+		"pending_commits", len(spool.Commits),
+		"pending_since", spool.Since,
+		"attempts", spool.Attempts)
+
+	if err := w.store.Push(ctx); err != nil {
+		next := min(delay*2, maxPushRetryDelay)
+		w.logger.WarnContext(ctx, "pending push failed", "error", err, "next_retry", next)
+		return next
+	}
+
+	w.logger.InfoContext(ctx, "pending push succeeded", "pending_commits", len(spool.Commits))
+	w.notifyPush(ctx)
+	return w.pushRetryDelay
+}
+
+// notifyPush notifies downstream jobs of a successful push.
+func (w *SyncWorker) notifyPush(ctx context.Context) {
+	if err := w.crawler.NotifyPush(ctx); err != nil {
+		w.logger.WarnContext(ctx, "failed to notify push", "error", err)
+	}
+}
+
 // pushWithRetry attempts to push to remote with exponential backoff retry logic.
 func (w *SyncWorker) pushWithRetry(ctx context.Context) error {
 	const (
@@ -216,22 +528,28 @@ func (w *SyncWorker) pushWithRetry(ctx context.Context) error {
 	return fmt.Errorf("push failed after %d attempts: %w", maxRetries+1, lastErr)
 }
 
-// commitTracker tracks time since last commit for periodic commits.
+// commitTracker tracks time since last commit for periodic commits, and the changes pending for chunked commits.
 type commitTracker struct {
-	lastCommit time.Time
-	period     time.Duration
+	lastCommit   time.Time
+	period       time.Duration
+	chunkReached func() bool // Whether enough changes are pending to commit a chunk (nil = no chunking)
 }
 
-// newCommitTracker creates a new commit tracker with the given period.
-func newCommitTracker(period time.Duration) *commitTracker {
+// newCommitTracker creates a new commit tracker with the given period (0 = no periodic commits) and
+// chunk check (nil = no chunked commits).
+func newCommitTracker(period time.Duration, chunkReached func() bool) *commitTracker {
 	return &commitTracker{
-		lastCommit: time.Now(),
-		period:     period,
+		lastCommit:   time.Now(),
+		period:       period,
+		chunkReached: chunkReached,
 	}
 }
 
-// shouldCommit returns true if enough time has passed since last commit.
+// shouldCommit returns true if enough time has passed since last commit, or enough changes are pending.
 func (t *commitTracker) shouldCommit() bool {
+	if t.chunkReached != nil && t.chunkReached() {
+		return true
+	}
 	if t.period == 0 {
 		return false
 	}
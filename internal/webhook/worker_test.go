@@ -2,12 +2,19 @@ package webhook
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	stdsync "sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/fclairamb/ntnsync/internal/apperrors"
+	"github.com/fclairamb/ntnsync/internal/store"
 	"github.com/fclairamb/ntnsync/internal/sync"
 )
 
@@ -15,10 +22,20 @@ import (
 type mockCrawler struct {
 	processCount atomic.Int32
 	processDelay time.Duration
+
+	mu       stdsync.Mutex
+	folders  []string        // Folder filters of the runs
+	maxTimes []time.Duration // Max times of the runs
+	err      error           // Error returned by the runs
+	authErr  error           // Error returned by token checks
 }
 
-func (m *mockCrawler) ProcessQueue(ctx context.Context, _ string, _ int, _ int, _ int, _ time.Duration) error {
+func (m *mockCrawler) ProcessQueue(ctx context.Context, folder string, _ int, _ int, _ int, maxTime time.Duration) error {
 	m.processCount.Add(1)
+	m.mu.Lock()
+	m.folders = append(m.folders, folder)
+	m.maxTimes = append(m.maxTimes, maxTime)
+	m.mu.Unlock()
 	if m.processDelay > 0 {
 		select {
 		case <-ctx.Done():
@@ -26,17 +43,49 @@ func (m *mockCrawler) ProcessQueue(ctx context.Context, _ string, _ int, _ int,
 		case <-time.After(m.processDelay):
 		}
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (m *mockCrawler) SetProgressHooks(_ sync.ProgressHooks) {}
+
+func (m *mockCrawler) CommitChanges(_ context.Context, _ string) error {
 	return nil
 }
 
-func (m *mockCrawler) ProcessQueueWithCallback(ctx context.Context, folderFilter string, maxPages int, maxFiles int, maxQueueFiles int, maxTime time.Duration, _ sync.QueueCallback) error {
-	return m.ProcessQueue(ctx, folderFilter, maxPages, maxFiles, maxQueueFiles, maxTime)
+func (m *mockCrawler) StartRun(_ context.Context, _ string) bool {
+	return true
 }
 
-func (m *mockCrawler) CommitChanges(_ context.Context, _ string) error {
+func (m *mockCrawler) FinishRun(_ context.Context) {}
+
+func (m *mockCrawler) SetRunPhase(_ context.Context, _ string) {}
+
+func (m *mockCrawler) CommitChunkReached() bool {
+	return false
+}
+
+func (m *mockCrawler) NotifyPush(_ context.Context) error {
 	return nil
 }
 
+func (m *mockCrawler) Pull(_ context.Context, _ sync.PullOptions) (*sync.PullResult, error) {
+	return &sync.PullResult{PagesQueued: 1}, nil
+}
+
+func (m *mockCrawler) Cleanup(_ context.Context, _ bool) (*sync.CleanupResult, error) {
+	return &sync.CleanupResult{}, nil
+}
+
+func (m *mockCrawler) SetTransaction(_ store.Transaction) {}
+
+func (m *mockCrawler) CheckAuth(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.authErr
+}
+
 // createTestWorker creates a SyncWorker for testing.
 // Tests are simplified since we don't need actual sync functionality.
 func createTestWorker(t *testing.T, opts ...SyncWorkerOption) *SyncWorker {
@@ -47,7 +96,7 @@ func createTestWorker(t *testing.T, opts ...SyncWorkerOption) *SyncWorker {
 	// Create worker with minimal setup for notification testing
 	worker := &SyncWorker{
 		crawler:      nil, // Not used in notification tests
-		store:        nil, // Not used in notification tests
+		store:        store.NewMemStore(),
 		remoteConfig: nil, // No commits in tests
 		logger:       logger,
 		notify:       make(chan struct{}, 1),
@@ -212,3 +261,242 @@ func TestSyncWorker_CoalesceNotifications(t *testing.T) {
 		t.Errorf("expected at most 2 process calls due to coalescing, got %d", count)
 	}
 }
+
+// TestSyncWorker_BatchesFolders verifies that notifications received during the sync delay are batched
+// into a single run per notified folder.
+func TestSyncWorker_BatchesFolders(t *testing.T) {
+	t.Parallel()
+	crawler := &mockCrawler{}
+	worker := createTestWorker(t, WithSyncDelay(50*time.Millisecond))
+	worker.crawler = crawler
+
+	go worker.Start(t.Context())
+
+	worker.NotifyFolder("tech")
+	worker.NotifyFolder("product")
+	worker.NotifyFolder("tech")
+
+	time.Sleep(200 * time.Millisecond)
+
+	crawler.mu.Lock()
+	defer crawler.mu.Unlock()
+	if !slices.Equal(crawler.folders, []string{"product", "tech"}) {
+		t.Errorf("processed folders = %q, want product and tech once", crawler.folders)
+	}
+}
+
+// TestSyncWorker_NotifyAll verifies that a notification without folder processes all folders.
+func TestSyncWorker_NotifyAll(t *testing.T) {
+	t.Parallel()
+	worker := createTestWorker(t)
+
+	worker.NotifyFolder("tech")
+	worker.Notify()
+
+	folders, all := worker.takeBatch()
+	if !all || !slices.Equal(folders, []string{"tech"}) {
+		t.Errorf("takeBatch() = %q, %v, want all folders", folders, all)
+	}
+	if folders, all = worker.takeBatch(); all || len(folders) != 0 {
+		t.Errorf("takeBatch() = %q, %v after taking the batch, want nothing pending", folders, all)
+	}
+}
+
+// TestSyncWorker_MaxRunTime verifies that a run is capped and the remaining folders deferred to the next run.
+func TestSyncWorker_MaxRunTime(t *testing.T) {
+	t.Parallel()
+	crawler := &mockCrawler{processDelay: 30 * time.Millisecond}
+	maxRunTime := 40 * time.Millisecond
+	worker := createTestWorker(t, WithMaxRunTime(maxRunTime))
+	worker.crawler = crawler
+
+	if err := worker.processQueue(context.Background(), []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("processQueue() error = %v", err)
+	}
+
+	crawler.mu.Lock()
+	if !slices.Equal(crawler.folders, []string{"a", "b"}) {
+		t.Errorf("processed folders = %q, want a and b", crawler.folders)
+	}
+	if crawler.maxTimes[0] > maxRunTime || crawler.maxTimes[1] <= 0 || crawler.maxTimes[1] >= crawler.maxTimes[0] {
+		t.Errorf("max times = %v, want the remaining run time", crawler.maxTimes)
+	}
+	crawler.mu.Unlock()
+
+	folders, all := worker.takeBatch()
+	if all || !slices.Equal(folders, []string{"b", "c"}) {
+		t.Errorf("deferred folders = %q, %v, want b and c", folders, all)
+	}
+	select {
+	case <-worker.notify:
+	default:
+		t.Error("worker not notified to continue the run")
+	}
+}
+
+// TestSyncWorker_ReleasesStoreLock verifies that the store directory is unlocked between runs, so that other
+// commands can write to it, but not while a run or an event is writing to it.
+func TestSyncWorker_ReleasesStoreLock(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	st, err := store.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	worker := createTestWorker(t)
+	worker.crawler = &mockCrawler{}
+	worker.store = st
+	handler := &Handler{store: st, syncWorker: worker, logger: worker.logger}
+	lockPath := filepath.Join(dir, store.LockFile)
+	assertLocked := func(when string, want bool) {
+		t.Helper()
+		_, err := os.Stat(lockPath)
+		if locked := err == nil; locked != want {
+			t.Errorf("lock %s: stat error = %v, want locked = %v", when, err, want)
+		}
+	}
+
+	if err := worker.processQueue(ctx, []string{""}); err != nil {
+		t.Fatalf("processQueue() error = %v", err)
+	}
+	assertLocked("after a run", false)
+
+	// A run ending while an event is queued keeps the lock of the event
+	if _, err := st.BeginTx(ctx); err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if err := worker.processQueue(ctx, []string{""}); err != nil {
+		t.Fatalf("processQueue() error = %v", err)
+	}
+	assertLocked("during an event", true)
+
+	// Concurrent events keep it until the last one is done
+	if _, err := st.BeginTx(ctx); err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	handler.releaseStoreLock(ctx)
+	assertLocked("during the second event", true)
+	handler.releaseStoreLock(ctx)
+	assertLocked("after the events", false)
+
+	// The next run locks it again
+	if err := worker.beginRun(ctx); err != nil {
+		t.Fatalf("beginRun() error = %v", err)
+	}
+	assertLocked("during the next run", true)
+	worker.releaseStoreLock(ctx)
+	assertLocked("after the next run", false)
+}
+
+// TestSyncWorker_RejectedToken verifies that a rejected token suspends syncing until it is accepted again.
+func TestSyncWorker_RejectedToken(t *testing.T) {
+	t.Parallel()
+	rejected := fmt.Errorf("process queue: %w", apperrors.ErrNotionUnauthorized)
+	crawler := &mockCrawler{err: rejected, authErr: rejected}
+	worker := createTestWorker(t, WithAuthProbeDelay(10*time.Millisecond))
+	worker.crawler = crawler
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := worker.processQueue(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("processQueue() error = %v, want the run to stop without error", err)
+	}
+	if worker.Degraded() == "" {
+		t.Fatal("worker not degraded after the token was rejected")
+	}
+	folders, all := worker.takeBatch()
+	if all || !slices.Equal(folders, []string{"a", "b"}) {
+		t.Errorf("pending folders = %q, %v, want a and b kept", folders, all)
+	}
+	<-worker.notify
+
+	time.Sleep(30 * time.Millisecond)
+	if worker.Degraded() == "" {
+		t.Fatal("worker recovered while the token is still rejected")
+	}
+
+	crawler.mu.Lock()
+	crawler.err, crawler.authErr = nil, nil
+	crawler.mu.Unlock()
+	select {
+	case <-worker.notify:
+	case <-time.After(time.Second):
+		t.Fatal("worker not notified once the token was accepted again")
+	}
+	if reason := worker.Degraded(); reason != "" {
+		t.Errorf("Degraded() = %q after the token was accepted again", reason)
+	}
+}
+
+var errRemoteOffline = errors.New("remote offline")
+
+// spoolingStore is an in-memory store whose pushes fail until the remote is back online.
+type spoolingStore struct {
+	*store.MemStore
+
+	online atomic.Bool
+	pushes atomic.Int32
+}
+
+func (s *spoolingStore) Push(_ context.Context) error {
+	s.pushes.Add(1)
+	if !s.online.Load() {
+		return errRemoteOffline
+	}
+	return nil
+}
+
+func (s *spoolingStore) PendingPush() (*store.PushSpool, error) {
+	if s.online.Load() {
+		return nil, nil //nolint:nilnil // nil spool indicates nothing is pending
+	}
+	return &store.PushSpool{Commits: []string{"abc"}, Attempts: 1}, nil
+}
+
+// TestSyncWorker_RetryPendingPush verifies the backoff of pending push retries.
+func TestSyncWorker_RetryPendingPush(t *testing.T) {
+	t.Parallel()
+
+	st := &spoolingStore{MemStore: store.NewMemStore()}
+	worker := createTestWorker(t, WithPushRetryDelay(time.Minute))
+	worker.store = st
+	ctx := context.Background()
+
+	if next := worker.retryPendingPush(ctx, st, time.Minute); next != 2*time.Minute {
+		t.Errorf("delay after a failed push = %v, want doubled", next)
+	}
+	if next := worker.retryPendingPush(ctx, st, 20*time.Minute); next != maxPushRetryDelay {
+		t.Errorf("delay after a failed push = %v, want capped at %v", next, maxPushRetryDelay)
+	}
+
+	st.online.Store(true)
+	if next := worker.retryPendingPush(ctx, st, 20*time.Minute); next != time.Minute {
+		t.Errorf("delay without pending push = %v, want reset", next)
+	}
+	if got := st.pushes.Load(); got != 2 {
+		t.Errorf("pushes = %d, want 2 (no push without pending commits)", got)
+	}
+}
+
+// TestSyncWorker_WaitQuietHours verifies that processing is deferred during quiet hours.
+func TestSyncWorker_WaitQuietHours(t *testing.T) {
+	t.Parallel()
+
+	worker := createTestWorker(t)
+	if !worker.waitQuietHours(context.Background()) {
+		t.Error("waitQuietHours() without quiet hours should not wait")
+	}
+
+	allDay, err := sync.ParseQuietHours("00:00-23:59", "UTC")
+	if err != nil {
+		t.Fatalf("ParseQuietHours() error = %v", err)
+	}
+	worker = createTestWorker(t, WithQuietHours(allDay))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if worker.waitQuietHours(ctx) {
+		t.Error("waitQuietHours() during quiet hours should wait until the context is canceled")
+	}
+}
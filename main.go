@@ -6,9 +6,12 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"runtime/pprof"
 	"syscall"
 
 	"github.com/fclairamb/ntnsync/internal/cmd"
+	"github.com/fclairamb/ntnsync/internal/shutdown"
+	"github.com/fclairamb/ntnsync/internal/sync"
 )
 
 func main() {
@@ -16,25 +19,44 @@ func main() {
 }
 
 func run() int {
-	// Set up signal handling for graceful shutdown
+	// Set up signal handling for graceful shutdown: syncs finish the pages in progress and save their progress
+	// within the grace period, a second signal stops them immediately
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	ctx, controller := shutdown.New(ctx)
 
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
 
 	go func() {
-		<-sigCh
-		slog.Info("received shutdown signal")
-		cancel()
+		for sig := range sigCh {
+			if sig == syscall.SIGQUIT {
+				dumpDebugState(ctx)
+				continue
+			}
+			slog.Info("received shutdown signal")
+			controller.Request(sync.GetConfig().ShutdownGrace)
+		}
 	}()
 
 	// Run the CLI
 	app := cmd.NewApp()
 	if err := app.Run(ctx, os.Args); err != nil {
 		slog.Error("error", "error", err)
-		return 1
+		return cmd.ExitCode(err)
 	}
 
 	return 0
 }
+
+// dumpDebugState writes a diagnostic dump of the sync runs in progress, to investigate hung syncs. Without a run
+// in progress, the goroutine stacks are written to stderr instead. The process keeps running.
+func dumpDebugState(ctx context.Context) {
+	files, err := sync.DumpDebugState(context.WithoutCancel(ctx))
+	if err != nil {
+		slog.Warn("failed to write debug dump, writing goroutine stacks to stderr", "error", err)
+		_ = pprof.Lookup("goroutine").WriteTo(os.Stderr, 2) //nolint:mnd // Stacks formatted like a panic
+		return
+	}
+	slog.Info("wrote debug dump", "files", files)
+}
@@ -0,0 +1,801 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ntnsync/v1/ntnsync.proto
+
+package ntnsyncv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EnqueuePageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Page ID or Notion URL.
+	PageId string `protobuf:"bytes,1,opt,name=page_id,json=pageId,proto3" json:"page_id,omitempty"`
+	// Target folder. Defaults to the folder of the page if it is tracked, "default" otherwise.
+	Folder        string `protobuf:"bytes,2,opt,name=folder,proto3" json:"folder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueuePageRequest) Reset() {
+	*x = EnqueuePageRequest{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueuePageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueuePageRequest) ProtoMessage() {}
+
+func (x *EnqueuePageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueuePageRequest.ProtoReflect.Descriptor instead.
+func (*EnqueuePageRequest) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EnqueuePageRequest) GetPageId() string {
+	if x != nil {
+		return x.PageId
+	}
+	return ""
+}
+
+func (x *EnqueuePageRequest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+type EnqueuePageResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Normalized page ID.
+	PageId string `protobuf:"bytes,1,opt,name=page_id,json=pageId,proto3" json:"page_id,omitempty"`
+	Folder string `protobuf:"bytes,2,opt,name=folder,proto3" json:"folder,omitempty"`
+	// Queue file the page was written to.
+	QueueFile     string `protobuf:"bytes,3,opt,name=queue_file,json=queueFile,proto3" json:"queue_file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueuePageResponse) Reset() {
+	*x = EnqueuePageResponse{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueuePageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueuePageResponse) ProtoMessage() {}
+
+func (x *EnqueuePageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueuePageResponse.ProtoReflect.Descriptor instead.
+func (*EnqueuePageResponse) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EnqueuePageResponse) GetPageId() string {
+	if x != nil {
+		return x.PageId
+	}
+	return ""
+}
+
+func (x *EnqueuePageResponse) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *EnqueuePageResponse) GetQueueFile() string {
+	if x != nil {
+		return x.QueueFile
+	}
+	return ""
+}
+
+type SyncNowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncNowRequest) Reset() {
+	*x = SyncNowRequest{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncNowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncNowRequest) ProtoMessage() {}
+
+func (x *SyncNowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncNowRequest.ProtoReflect.Descriptor instead.
+func (*SyncNowRequest) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{2}
+}
+
+type SyncNowResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncNowResponse) Reset() {
+	*x = SyncNowResponse{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncNowResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncNowResponse) ProtoMessage() {}
+
+func (x *SyncNowResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncNowResponse.ProtoReflect.Descriptor instead.
+func (*SyncNowResponse) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{3}
+}
+
+type GetStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return the status of this folder.
+	Folder        string `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetStatusRequest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+type GetStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folders       []*FolderStatus        `protobuf:"bytes,1,rep,name=folders,proto3" json:"folders,omitempty"`
+	QueueEntries  []*QueueEntry          `protobuf:"bytes,2,rep,name=queue_entries,json=queueEntries,proto3" json:"queue_entries,omitempty"`
+	BlockedPages  int32                  `protobuf:"varint,3,opt,name=blocked_pages,json=blockedPages,proto3" json:"blocked_pages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusResponse) Reset() {
+	*x = GetStatusResponse{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusResponse) ProtoMessage() {}
+
+func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetStatusResponse) GetFolders() []*FolderStatus {
+	if x != nil {
+		return x.Folders
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetQueueEntries() []*QueueEntry {
+	if x != nil {
+		return x.QueueEntries
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetBlockedPages() int32 {
+	if x != nil {
+		return x.BlockedPages
+	}
+	return 0
+}
+
+type FolderStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PageCount     int32                  `protobuf:"varint,2,opt,name=page_count,json=pageCount,proto3" json:"page_count,omitempty"`
+	RootPages     int32                  `protobuf:"varint,3,opt,name=root_pages,json=rootPages,proto3" json:"root_pages,omitempty"`
+	LastSynced    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_synced,json=lastSynced,proto3" json:"last_synced,omitempty"`
+	QueuedPages   int32                  `protobuf:"varint,5,opt,name=queued_pages,json=queuedPages,proto3" json:"queued_pages,omitempty"`
+	TotalBytes    int64                  `protobuf:"varint,6,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	OverQuota     bool                   `protobuf:"varint,7,opt,name=over_quota,json=overQuota,proto3" json:"over_quota,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FolderStatus) Reset() {
+	*x = FolderStatus{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FolderStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FolderStatus) ProtoMessage() {}
+
+func (x *FolderStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FolderStatus.ProtoReflect.Descriptor instead.
+func (*FolderStatus) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FolderStatus) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FolderStatus) GetPageCount() int32 {
+	if x != nil {
+		return x.PageCount
+	}
+	return 0
+}
+
+func (x *FolderStatus) GetRootPages() int32 {
+	if x != nil {
+		return x.RootPages
+	}
+	return 0
+}
+
+func (x *FolderStatus) GetLastSynced() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSynced
+	}
+	return nil
+}
+
+func (x *FolderStatus) GetQueuedPages() int32 {
+	if x != nil {
+		return x.QueuedPages
+	}
+	return 0
+}
+
+func (x *FolderStatus) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *FolderStatus) GetOverQuota() bool {
+	if x != nil {
+		return x.OverQuota
+	}
+	return false
+}
+
+type QueueEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QueueFile     string                 `protobuf:"bytes,1,opt,name=queue_file,json=queueFile,proto3" json:"queue_file,omitempty"`
+	Folder        string                 `protobuf:"bytes,2,opt,name=folder,proto3" json:"folder,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	PageCount     int32                  `protobuf:"varint,4,opt,name=page_count,json=pageCount,proto3" json:"page_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueEntry) Reset() {
+	*x = QueueEntry{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueEntry) ProtoMessage() {}
+
+func (x *QueueEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueEntry.ProtoReflect.Descriptor instead.
+func (*QueueEntry) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *QueueEntry) GetQueueFile() string {
+	if x != nil {
+		return x.QueueFile
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *QueueEntry) GetPageCount() int32 {
+	if x != nil {
+		return x.PageCount
+	}
+	return 0
+}
+
+type ListPagesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return the pages of this folder.
+	Folder        string `protobuf:"bytes,1,opt,name=folder,proto3" json:"folder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPagesRequest) Reset() {
+	*x = ListPagesRequest{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPagesRequest) ProtoMessage() {}
+
+func (x *ListPagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPagesRequest.ProtoReflect.Descriptor instead.
+func (*ListPagesRequest) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListPagesRequest) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+type ListPagesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pages         []*Page                `protobuf:"bytes,1,rep,name=pages,proto3" json:"pages,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPagesResponse) Reset() {
+	*x = ListPagesResponse{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPagesResponse) ProtoMessage() {}
+
+func (x *ListPagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPagesResponse.ProtoReflect.Descriptor instead.
+func (*ListPagesResponse) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListPagesResponse) GetPages() []*Page {
+	if x != nil {
+		return x.Pages
+	}
+	return nil
+}
+
+type Page struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Folder        string                 `protobuf:"bytes,3,opt,name=folder,proto3" json:"folder,omitempty"`
+	Path          string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	LastSynced    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_synced,json=lastSynced,proto3" json:"last_synced,omitempty"`
+	IsRoot        bool                   `protobuf:"varint,6,opt,name=is_root,json=isRoot,proto3" json:"is_root,omitempty"`
+	IsOrphaned    bool                   `protobuf:"varint,7,opt,name=is_orphaned,json=isOrphaned,proto3" json:"is_orphaned,omitempty"`
+	ParentId      string                 `protobuf:"bytes,8,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Page) Reset() {
+	*x = Page{}
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Page) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Page) ProtoMessage() {}
+
+func (x *Page) ProtoReflect() protoreflect.Message {
+	mi := &file_ntnsync_v1_ntnsync_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Page.ProtoReflect.Descriptor instead.
+func (*Page) Descriptor() ([]byte, []int) {
+	return file_ntnsync_v1_ntnsync_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Page) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Page) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Page) GetFolder() string {
+	if x != nil {
+		return x.Folder
+	}
+	return ""
+}
+
+func (x *Page) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Page) GetLastSynced() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSynced
+	}
+	return nil
+}
+
+func (x *Page) GetIsRoot() bool {
+	if x != nil {
+		return x.IsRoot
+	}
+	return false
+}
+
+func (x *Page) GetIsOrphaned() bool {
+	if x != nil {
+		return x.IsOrphaned
+	}
+	return false
+}
+
+func (x *Page) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+var File_ntnsync_v1_ntnsync_proto protoreflect.FileDescriptor
+
+const file_ntnsync_v1_ntnsync_proto_rawDesc = "" +
+	"\n" +
+	"\x18ntnsync/v1/ntnsync.proto\x12\n" +
+	"ntnsync.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"E\n" +
+	"\x12EnqueuePageRequest\x12\x17\n" +
+	"\apage_id\x18\x01 \x01(\tR\x06pageId\x12\x16\n" +
+	"\x06folder\x18\x02 \x01(\tR\x06folder\"e\n" +
+	"\x13EnqueuePageResponse\x12\x17\n" +
+	"\apage_id\x18\x01 \x01(\tR\x06pageId\x12\x16\n" +
+	"\x06folder\x18\x02 \x01(\tR\x06folder\x12\x1d\n" +
+	"\n" +
+	"queue_file\x18\x03 \x01(\tR\tqueueFile\"\x10\n" +
+	"\x0eSyncNowRequest\"\x11\n" +
+	"\x0fSyncNowResponse\"*\n" +
+	"\x10GetStatusRequest\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\"\xa9\x01\n" +
+	"\x11GetStatusResponse\x122\n" +
+	"\afolders\x18\x01 \x03(\v2\x18.ntnsync.v1.FolderStatusR\afolders\x12;\n" +
+	"\rqueue_entries\x18\x02 \x03(\v2\x16.ntnsync.v1.QueueEntryR\fqueueEntries\x12#\n" +
+	"\rblocked_pages\x18\x03 \x01(\x05R\fblockedPages\"\x80\x02\n" +
+	"\fFolderStatus\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"page_count\x18\x02 \x01(\x05R\tpageCount\x12\x1d\n" +
+	"\n" +
+	"root_pages\x18\x03 \x01(\x05R\trootPages\x12;\n" +
+	"\vlast_synced\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastSynced\x12!\n" +
+	"\fqueued_pages\x18\x05 \x01(\x05R\vqueuedPages\x12\x1f\n" +
+	"\vtotal_bytes\x18\x06 \x01(\x03R\n" +
+	"totalBytes\x12\x1d\n" +
+	"\n" +
+	"over_quota\x18\a \x01(\bR\toverQuota\"v\n" +
+	"\n" +
+	"QueueEntry\x12\x1d\n" +
+	"\n" +
+	"queue_file\x18\x01 \x01(\tR\tqueueFile\x12\x16\n" +
+	"\x06folder\x18\x02 \x01(\tR\x06folder\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"page_count\x18\x04 \x01(\x05R\tpageCount\"*\n" +
+	"\x10ListPagesRequest\x12\x16\n" +
+	"\x06folder\x18\x01 \x01(\tR\x06folder\";\n" +
+	"\x11ListPagesResponse\x12&\n" +
+	"\x05pages\x18\x01 \x03(\v2\x10.ntnsync.v1.PageR\x05pages\"\xec\x01\n" +
+	"\x04Page\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06folder\x18\x03 \x01(\tR\x06folder\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\x12;\n" +
+	"\vlast_synced\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastSynced\x12\x17\n" +
+	"\ais_root\x18\x06 \x01(\bR\x06isRoot\x12\x1f\n" +
+	"\vis_orphaned\x18\a \x01(\bR\n" +
+	"isOrphaned\x12\x1b\n" +
+	"\tparent_id\x18\b \x01(\tR\bparentId2\xb5\x02\n" +
+	"\vSyncService\x12N\n" +
+	"\vEnqueuePage\x12\x1e.ntnsync.v1.EnqueuePageRequest\x1a\x1f.ntnsync.v1.EnqueuePageResponse\x12B\n" +
+	"\aSyncNow\x12\x1a.ntnsync.v1.SyncNowRequest\x1a\x1b.ntnsync.v1.SyncNowResponse\x12H\n" +
+	"\tGetStatus\x12\x1c.ntnsync.v1.GetStatusRequest\x1a\x1d.ntnsync.v1.GetStatusResponse\x12H\n" +
+	"\tListPages\x12\x1c.ntnsync.v1.ListPagesRequest\x1a\x1d.ntnsync.v1.ListPagesResponseB,Z*github.com/fclairamb/ntnsync/rpc/ntnsyncv1b\x06proto3"
+
+var (
+	file_ntnsync_v1_ntnsync_proto_rawDescOnce sync.Once
+	file_ntnsync_v1_ntnsync_proto_rawDescData []byte
+)
+
+func file_ntnsync_v1_ntnsync_proto_rawDescGZIP() []byte {
+	file_ntnsync_v1_ntnsync_proto_rawDescOnce.Do(func() {
+		file_ntnsync_v1_ntnsync_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ntnsync_v1_ntnsync_proto_rawDesc), len(file_ntnsync_v1_ntnsync_proto_rawDesc)))
+	})
+	return file_ntnsync_v1_ntnsync_proto_rawDescData
+}
+
+var file_ntnsync_v1_ntnsync_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_ntnsync_v1_ntnsync_proto_goTypes = []any{
+	(*EnqueuePageRequest)(nil),    // 0: ntnsync.v1.EnqueuePageRequest
+	(*EnqueuePageResponse)(nil),   // 1: ntnsync.v1.EnqueuePageResponse
+	(*SyncNowRequest)(nil),        // 2: ntnsync.v1.SyncNowRequest
+	(*SyncNowResponse)(nil),       // 3: ntnsync.v1.SyncNowResponse
+	(*GetStatusRequest)(nil),      // 4: ntnsync.v1.GetStatusRequest
+	(*GetStatusResponse)(nil),     // 5: ntnsync.v1.GetStatusResponse
+	(*FolderStatus)(nil),          // 6: ntnsync.v1.FolderStatus
+	(*QueueEntry)(nil),            // 7: ntnsync.v1.QueueEntry
+	(*ListPagesRequest)(nil),      // 8: ntnsync.v1.ListPagesRequest
+	(*ListPagesResponse)(nil),     // 9: ntnsync.v1.ListPagesResponse
+	(*Page)(nil),                  // 10: ntnsync.v1.Page
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_ntnsync_v1_ntnsync_proto_depIdxs = []int32{
+	6,  // 0: ntnsync.v1.GetStatusResponse.folders:type_name -> ntnsync.v1.FolderStatus
+	7,  // 1: ntnsync.v1.GetStatusResponse.queue_entries:type_name -> ntnsync.v1.QueueEntry
+	11, // 2: ntnsync.v1.FolderStatus.last_synced:type_name -> google.protobuf.Timestamp
+	10, // 3: ntnsync.v1.ListPagesResponse.pages:type_name -> ntnsync.v1.Page
+	11, // 4: ntnsync.v1.Page.last_synced:type_name -> google.protobuf.Timestamp
+	0,  // 5: ntnsync.v1.SyncService.EnqueuePage:input_type -> ntnsync.v1.EnqueuePageRequest
+	2,  // 6: ntnsync.v1.SyncService.SyncNow:input_type -> ntnsync.v1.SyncNowRequest
+	4,  // 7: ntnsync.v1.SyncService.GetStatus:input_type -> ntnsync.v1.GetStatusRequest
+	8,  // 8: ntnsync.v1.SyncService.ListPages:input_type -> ntnsync.v1.ListPagesRequest
+	1,  // 9: ntnsync.v1.SyncService.EnqueuePage:output_type -> ntnsync.v1.EnqueuePageResponse
+	3,  // 10: ntnsync.v1.SyncService.SyncNow:output_type -> ntnsync.v1.SyncNowResponse
+	5,  // 11: ntnsync.v1.SyncService.GetStatus:output_type -> ntnsync.v1.GetStatusResponse
+	9,  // 12: ntnsync.v1.SyncService.ListPages:output_type -> ntnsync.v1.ListPagesResponse
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_ntnsync_v1_ntnsync_proto_init() }
+func file_ntnsync_v1_ntnsync_proto_init() {
+	if File_ntnsync_v1_ntnsync_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ntnsync_v1_ntnsync_proto_rawDesc), len(file_ntnsync_v1_ntnsync_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ntnsync_v1_ntnsync_proto_goTypes,
+		DependencyIndexes: file_ntnsync_v1_ntnsync_proto_depIdxs,
+		MessageInfos:      file_ntnsync_v1_ntnsync_proto_msgTypes,
+	}.Build()
+	File_ntnsync_v1_ntnsync_proto = out.File
+	file_ntnsync_v1_ntnsync_proto_goTypes = nil
+	file_ntnsync_v1_ntnsync_proto_depIdxs = nil
+}